@@ -51,8 +51,30 @@ var (
 	errRequestTimeout  error                      = errors.New("reverse request timed out")
 	requestsLock       sync.Mutex                 = sync.Mutex{}
 	requests           map[requestKey]requestInfo = make(map[requestKey]requestInfo)
+
+	// The last time each origin (by hostname) polled the broker for reversal requests
+	heartbeatsLock   sync.RWMutex         = sync.RWMutex{}
+	originHeartbeats map[string]time.Time = make(map[string]time.Time)
 )
 
+// Record that the origin is polling the broker, i.e. its reverse connection tunnel is live
+func recordHeartbeat(origin string) {
+	heartbeatsLock.Lock()
+	defer heartbeatsLock.Unlock()
+	originHeartbeats[origin] = time.Now()
+}
+
+// Get a copy of the last time each origin polled the broker
+func getHeartbeats() map[string]time.Time {
+	heartbeatsLock.RLock()
+	defer heartbeatsLock.RUnlock()
+	result := make(map[string]time.Time, len(originHeartbeats))
+	for origin, lastHeartbeat := range originHeartbeats {
+		result[origin] = lastHeartbeat
+	}
+	return result
+}
+
 func getOriginQueue(prefix, origin string) chan reversalRequest {
 	requestsLock.Lock()
 	defer requestsLock.Unlock()
@@ -106,7 +106,9 @@ func retrieveRequest(ctx context.Context, ginCtx *gin.Context) {
 	}
 	if !ok {
 		ginCtx.AbortWithStatusJSON(http.StatusUnauthorized, newBrokerRespFail("Authorization denied"))
+		return
 	}
+	recordHeartbeat(originReq.Origin)
 
 	req, err := handleRetrieve(ctx, ginCtx, originReq.Prefix, originReq.Origin, timeoutVal)
 	if errors.Is(err, errRetrieveTimeout) {
@@ -182,6 +184,13 @@ func RegisterBroker(ctx context.Context, router *gin.RouterGroup) {
 	// Establish the routes used for cache/origin redirection
 	router.POST("/api/v1.0/broker/retrieve", func(ginCtx *gin.Context) { retrieveRequest(ctx, ginCtx) })
 	router.POST("/api/v1.0/broker/reverse", func(ginCtx *gin.Context) { reverseRequest(ctx, ginCtx) })
+	router.GET("/api/v1.0/broker/heartbeats", listHeartbeats)
+}
+
+// List the last time each origin (by hostname) polled the broker, so the director
+// can tell whether an origin's reverse connection tunnel is live
+func listHeartbeats(ginCtx *gin.Context) {
+	ginCtx.JSON(http.StatusOK, server_structs.BrokerHeartbeatsResp{Heartbeats: getHeartbeats()})
 }
 
 // Cache's HTTP handler function for callbacks from an origin
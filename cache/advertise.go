@@ -29,6 +29,7 @@ import (
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/utils"
 )
 
@@ -42,12 +43,24 @@ type (
 
 func (server *CacheServer) CreateAdvertisement(name, originUrl, originWebUrl string) (*server_structs.OriginAdvertiseV2, error) {
 	registryPrefix := server_structs.GetCacheNS(param.Xrootd_Sitename.GetString())
+	labels, err := server_utils.GetServerLabels()
+	if err != nil {
+		return nil, err
+	}
+	altDataUrls, err := server_utils.GetAlternateDataURLs(context.Background(), originUrl)
+	if err != nil {
+		return nil, err
+	}
 	ad := server_structs.OriginAdvertiseV2{
 		Name:           name,
 		RegistryPrefix: registryPrefix,
 		DataURL:        originUrl,
 		WebURL:         originWebUrl,
 		Namespaces:     server.GetNamespaceAds(),
+		Site:           param.Server_Site.GetString(),
+		Labels:         labels,
+		AltDataURLs:    altDataUrls,
+		UpstreamCaches: param.Cache_UpstreamCaches.GetStringSlice(),
 	}
 
 	return &ad, nil
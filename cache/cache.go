@@ -38,5 +38,8 @@ func RegisterCacheAPI(router *gin.Engine, ctx context.Context, egrp *errgroup.Gr
 	group := router.Group("/api/v1.0/cache")
 	{
 		group.POST("/directorTest", func(ginCtx *gin.Context) { server_utils.HandleDirectorTestResponse(ginCtx, notificationChan) })
+		group.POST("/prefetch", handlePrefetch(ctx))
+		group.GET("/prefetch/:id", handleGetPrefetchJob)
 	}
+	launchPrefetchJobCleanup(ctx, egrp)
 }
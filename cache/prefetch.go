@@ -0,0 +1,330 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/client"
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+type (
+	prefetchStatus string
+
+	prefetchObject struct {
+		Path   string
+		Status prefetchStatus
+		Bytes  int64
+		Error  string
+	}
+
+	// A prefetch job, i.e. the objects requested by one prefetch request
+	prefetchJob struct {
+		mutex   sync.RWMutex
+		ID      string
+		Objects []prefetchObject
+	}
+)
+
+const (
+	prefetchPending    prefetchStatus = "pending"
+	prefetchInProgress prefetchStatus = "in_progress"
+	prefetchSucceeded  prefetchStatus = "succeeded"
+	prefetchFailed     prefetchStatus = "failed"
+
+	// The number of objects of a prefetch job fetched concurrently
+	prefetchConcurrency = 4
+	// How long the status of a prefetch job is kept after it is submitted
+	prefetchJobTTL = 24 * time.Hour
+)
+
+var (
+	prefetchJobs = ttlcache.New(ttlcache.WithTTL[string, *prefetchJob](prefetchJobTTL))
+
+	// Fetch an object through the cache so that it is stored by the cache; a variable to be mocked in tests
+	fetchThroughCache = fetchObjectThroughCache
+	// List the objects directly under a namespace prefix in the federation; a variable to be mocked in tests
+	listPrefixObjects = listObjectsInFederation
+)
+
+// Snapshot the job for the API response
+func (job *prefetchJob) snapshot() server_structs.PrefetchJobStatus {
+	job.mutex.RLock()
+	defer job.mutex.RUnlock()
+	res := server_structs.PrefetchJobStatus{
+		ID:      job.ID,
+		Objects: make([]server_structs.PrefetchObjectStatus, 0, len(job.Objects)),
+	}
+	for _, obj := range job.Objects {
+		res.Objects = append(res.Objects, server_structs.PrefetchObjectStatus{
+			Path:   obj.Path,
+			Status: string(obj.Status),
+			Bytes:  obj.Bytes,
+			Error:  obj.Error,
+		})
+		if obj.Status == prefetchPending || obj.Status == prefetchInProgress {
+			res.Pending++
+		} else if obj.Status == prefetchFailed {
+			res.Failed++
+		}
+	}
+	res.Done = res.Pending == 0
+	return res
+}
+
+func (job *prefetchJob) setObjectStatus(idx int, status prefetchStatus, bytes int64, err error) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	job.Objects[idx].Status = status
+	job.Objects[idx].Bytes = bytes
+	if err != nil {
+		job.Objects[idx].Error = err.Error()
+	}
+}
+
+// Check if the object path is under one of Cache.PermittedNamespaces. All paths are permitted if it's not set
+func isPrefetchPermitted(objectPath string) bool {
+	nsList := param.Cache_PermittedNamespaces.GetStringSlice()
+	if len(nsList) == 0 {
+		return true
+	}
+	for _, ns := range nsList {
+		ns = "/" + strings.Trim(ns, "/") + "/"
+		if strings.HasPrefix(objectPath+"/", ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch the object from the cache's own XRootD endpoint, which pulls the object from the
+// origin into the cache. The object content is discarded.
+func fetchObjectThroughCache(ctx context.Context, objectPath string, tok string) (int64, error) {
+	cacheUrl, err := url.Parse(param.Cache_Url.GetString())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse Cache.Url")
+	}
+	cacheUrl.Path = objectPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cacheUrl.String(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create the prefetch request")
+	}
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	httpClient := http.Client{Transport: config.GetTransport()}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fetch the object")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("fetching the object returned status code %d", resp.StatusCode)
+	}
+	return io.Copy(io.Discard, resp.Body)
+}
+
+// List the objects directly under the namespace prefix through the federation. Sub-collections are not included
+func listObjectsInFederation(ctx context.Context, prefix string, tok string) ([]string, error) {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discoveryUrl := param.Federation_DiscoveryUrl.GetString()
+	if discoveryUrl == "" {
+		discoveryUrl = fedInfo.DirectorEndpoint
+	}
+	fedUrl, err := url.Parse(discoveryUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the federation URL")
+	}
+	listUrl := url.URL{Scheme: "pelican", Host: fedUrl.Host, Path: prefix}
+	options := []client.TransferOption{client.WithAcquireToken(false)}
+	if tok != "" {
+		options = append(options, client.WithToken(tok))
+	}
+	infos, err := client.DoList(ctx, listUrl.String(), options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the objects under %s", prefix)
+	}
+	objects := []string{}
+	for _, info := range infos {
+		if info.IsDir {
+			continue
+		}
+		// Depending on the origin, the names are either relative to the prefix or absolute
+		name := info.Name
+		if !strings.HasPrefix(name, prefix) {
+			name = path.Join(prefix, name)
+		}
+		objects = append(objects, name)
+	}
+	return objects, nil
+}
+
+// Fetch the objects of the job in the background, at most prefetchConcurrency at a time
+func runPrefetchJob(ctx context.Context, job *prefetchJob, tok string) {
+	egrp := errgroup.Group{}
+	egrp.SetLimit(prefetchConcurrency)
+	for idx := range job.Objects {
+		idx := idx
+		egrp.Go(func() error {
+			objectPath := job.Objects[idx].Path
+			job.setObjectStatus(idx, prefetchInProgress, 0, nil)
+			bytes, err := fetchThroughCache(ctx, objectPath, tok)
+			if err != nil {
+				log.Warningf("Prefetch job %s failed to fetch %s: %v", job.ID, objectPath, err)
+				job.setObjectStatus(idx, prefetchFailed, bytes, err)
+			} else {
+				job.setObjectStatus(idx, prefetchSucceeded, bytes, nil)
+			}
+			return nil
+		})
+	}
+	_ = egrp.Wait()
+	log.Infof("Prefetch job %s finished", job.ID)
+}
+
+// Verify the request has a token with the cache.prefetch scope issued by the federation or the cache
+// itself, and respond with the error if not
+func verifyPrefetchToken(ginCtx *gin.Context) bool {
+	status, ok, err := token.Verify(ginCtx, token.AuthOption{
+		Sources: []token.TokenSource{token.Header},
+		Issuers: []token.TokenIssuer{token.FederationIssuer, token.LocalIssuer},
+		Scopes:  []token_scopes.TokenScope{token_scopes.Cache_Prefetch},
+	})
+	if !ok {
+		ginCtx.JSON(status, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Failed to verify the token: ", err),
+		})
+	}
+	return ok
+}
+
+// A gin route handler to prefetch a list of objects, or the objects directly under a namespace prefix, into the cache.
+// The objects are fetched in the background; the response contains the job ID to query the progress
+func handlePrefetch(ctx context.Context) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		if !verifyPrefetchToken(ginCtx) {
+			return
+		}
+
+		req := server_structs.PrefetchRequest{}
+		if err := ginCtx.ShouldBindJSON(&req); err != nil {
+			ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Invalid prefetch request: " + err.Error(),
+			})
+			return
+		}
+		if (req.Prefix == "") == (len(req.Objects) == 0) {
+			ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Exactly one of 'prefix' and 'objects' is required",
+			})
+			return
+		}
+
+		objects := req.Objects
+		if req.Prefix != "" {
+			prefix := path.Clean("/" + req.Prefix)
+			if !isPrefetchPermitted(prefix) {
+				ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("The cache doesn't serve the namespace %s", prefix),
+				})
+				return
+			}
+			var err error
+			objects, err = listPrefixObjects(ginCtx.Request.Context(), prefix, req.Token)
+			if err != nil {
+				ginCtx.JSON(http.StatusBadGateway, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    err.Error(),
+				})
+				return
+			}
+		}
+
+		job := &prefetchJob{ID: uuid.NewString()}
+		for _, obj := range objects {
+			objectPath := path.Clean("/" + obj)
+			if !isPrefetchPermitted(objectPath) {
+				ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("The cache doesn't serve the object %s", objectPath),
+				})
+				return
+			}
+			job.Objects = append(job.Objects, prefetchObject{Path: objectPath, Status: prefetchPending})
+		}
+
+		prefetchJobs.Set(job.ID, job, ttlcache.DefaultTTL)
+		log.Infof("Starting prefetch job %s of %d objects", job.ID, len(job.Objects))
+		go runPrefetchJob(ctx, job, req.Token)
+		ginCtx.JSON(http.StatusAccepted, job.snapshot())
+	}
+}
+
+// A gin route handler to get the progress of a prefetch job by the path variable `id`
+func handleGetPrefetchJob(ginCtx *gin.Context) {
+	if !verifyPrefetchToken(ginCtx) {
+		return
+	}
+	item := prefetchJobs.Get(ginCtx.Param("id"))
+	if item == nil {
+		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Prefetch job not found",
+		})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, item.Value().snapshot())
+}
+
+// Start the expiry of finished prefetch jobs
+func launchPrefetchJobCleanup(ctx context.Context, egrp *errgroup.Group) {
+	go prefetchJobs.Start()
+	egrp.Go(func() error {
+		<-ctx.Done()
+		prefetchJobs.Stop()
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPrefetchPermitted(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+
+	viper.Reset()
+	assert.True(t, isPrefetchPermitted("/any/object"))
+
+	viper.Set("Cache.PermittedNamespaces", []string{"/foo", "bar/"})
+	assert.True(t, isPrefetchPermitted("/foo/object"))
+	assert.True(t, isPrefetchPermitted("/bar/baz/object"))
+	assert.False(t, isPrefetchPermitted("/foobar/object"))
+	assert.False(t, isPrefetchPermitted("/baz/object"))
+}
+
+func TestRunPrefetchJob(t *testing.T) {
+	oldFetch := fetchThroughCache
+	t.Cleanup(func() {
+		fetchThroughCache = oldFetch
+	})
+	fetchThroughCache = func(ctx context.Context, objectPath string, tok string) (int64, error) {
+		if objectPath == "/foo/missing" {
+			return 0, errors.New("fetching the object returned status code 404")
+		}
+		assert.Equal(t, "test-token", tok)
+		return 42, nil
+	}
+
+	job := &prefetchJob{
+		ID: "test-job",
+		Objects: []prefetchObject{
+			{Path: "/foo/a", Status: prefetchPending},
+			{Path: "/foo/missing", Status: prefetchPending},
+			{Path: "/foo/b", Status: prefetchPending},
+		},
+	}
+	before := job.snapshot()
+	assert.False(t, before.Done)
+	assert.Equal(t, 3, before.Pending)
+
+	runPrefetchJob(context.Background(), job, "test-token")
+
+	status := job.snapshot()
+	assert.True(t, status.Done)
+	assert.Equal(t, 0, status.Pending)
+	assert.Equal(t, 1, status.Failed)
+	require.Len(t, status.Objects, 3)
+	assert.Equal(t, string(prefetchSucceeded), status.Objects[0].Status)
+	assert.Equal(t, int64(42), status.Objects[0].Bytes)
+	assert.Equal(t, string(prefetchFailed), status.Objects[1].Status)
+	assert.Contains(t, status.Objects[1].Error, "404")
+	assert.Equal(t, string(prefetchSucceeded), status.Objects[2].Status)
+}
@@ -0,0 +1,192 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+type (
+	ChecksumAlgorithm  string
+	ChecksumVerifyMode string
+
+	// The downloaded object doesn't match the checksum advertised by the server
+	ChecksumMismatchError struct {
+		Algorithm ChecksumAlgorithm
+		Expected  string
+		Actual    string
+	}
+)
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c"
+
+	ChecksumVerifyOff   ChecksumVerifyMode = "off"
+	ChecksumVerifyWarn  ChecksumVerifyMode = "warn"
+	ChecksumVerifyError ChecksumVerifyMode = "error"
+)
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for the downloaded object: expected %s %s but got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// Parse the checksum algorithm, e.g. from Client.ChecksumAlgorithm
+func ParseChecksumAlgorithm(alg string) (ChecksumAlgorithm, error) {
+	switch ChecksumAlgorithm(strings.ToLower(alg)) {
+	case ChecksumSHA256:
+		return ChecksumSHA256, nil
+	case ChecksumCRC32C:
+		return ChecksumCRC32C, nil
+	default:
+		return "", errors.Errorf("unsupported checksum algorithm %q; valid algorithms are sha256 and crc32c", alg)
+	}
+}
+
+// Parse the checksum verification mode, e.g. from Client.VerifyChecksum or the --verify flag
+func ParseChecksumVerifyMode(mode string) (ChecksumVerifyMode, error) {
+	switch ChecksumVerifyMode(strings.ToLower(mode)) {
+	case ChecksumVerifyOff:
+		return ChecksumVerifyOff, nil
+	case ChecksumVerifyWarn:
+		return ChecksumVerifyWarn, nil
+	case ChecksumVerifyError:
+		return ChecksumVerifyError, nil
+	default:
+		return "", errors.Errorf("invalid checksum verification mode %q; valid modes are off, warn, and error", mode)
+	}
+}
+
+// The name of the algorithm in the Want-Digest and Digest headers (RFC 3230)
+func (alg ChecksumAlgorithm) digestName() string {
+	if alg == ChecksumSHA256 {
+		return "sha-256"
+	}
+	return string(alg)
+}
+
+func (alg ChecksumAlgorithm) newHash() hash.Hash {
+	if alg == ChecksumCRC32C {
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	}
+	return sha256.New()
+}
+
+// Get the hex-encoded checksum of the algorithm from the X-Pelican-Digest or Digest header.
+// The header values are comma-separated "<algorithm>=<value>" pairs where the value is either
+// hex- or base64-encoded. Returns an empty string if no such checksum is present
+func getDigestFromHeader(header http.Header, alg ChecksumAlgorithm) string {
+	for _, headerName := range []string{"X-Pelican-Digest", "Digest"} {
+		for _, value := range header.Values(headerName) {
+			for _, digest := range strings.Split(value, ",") {
+				name, encoded, found := strings.Cut(strings.TrimSpace(digest), "=")
+				if !found || !strings.EqualFold(name, alg.digestName()) {
+					continue
+				}
+				if checksum := decodeDigestValue(encoded, alg); checksum != "" {
+					return checksum
+				}
+				log.Debugf("Ignoring the unparseable %s checksum %q in the %s header", alg, encoded, headerName)
+			}
+		}
+	}
+	return ""
+}
+
+// Decode a hex- or base64-encoded digest value to hex
+func decodeDigestValue(encoded string, alg ChecksumAlgorithm) string {
+	size := alg.newHash().Size()
+	if raw, err := hex.DecodeString(encoded); err == nil && len(raw) == size {
+		return hex.EncodeToString(raw)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(raw) == size {
+		return hex.EncodeToString(raw)
+	}
+	return ""
+}
+
+// Compute the hex-encoded checksum of the file
+func computeFileChecksum(filePath string, alg ChecksumAlgorithm) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := alg.newHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify the downloaded file against the checksum in the response header, according to Client.VerifyChecksum.
+// Returns a *ChecksumMismatchError on mismatch if the transfer should fail
+func verifyDownloadChecksum(filePath string, header http.Header) error {
+	// An unset Client.VerifyChecksum, e.g. when the client is used as a library without config, disables verification
+	modeStr := param.Client_VerifyChecksum.GetString()
+	if modeStr == "" {
+		return nil
+	}
+	mode, err := ParseChecksumVerifyMode(modeStr)
+	if err != nil {
+		return err
+	}
+	if mode == ChecksumVerifyOff {
+		return nil
+	}
+	alg := ChecksumSHA256
+	if algStr := param.Client_ChecksumAlgorithm.GetString(); algStr != "" {
+		if alg, err = ParseChecksumAlgorithm(algStr); err != nil {
+			return err
+		}
+	}
+	expected := getDigestFromHeader(header, alg)
+	if expected == "" {
+		log.Debugf("The server doesn't advertise a %s checksum for %s; skipping checksum verification", alg, filePath)
+		return nil
+	}
+	actual, err := computeFileChecksum(filePath, alg)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute the checksum of the downloaded object")
+	}
+	if actual == expected {
+		log.Debugf("Verified the %s checksum of %s", alg, filePath)
+		return nil
+	}
+	mismatchErr := &ChecksumMismatchError{Algorithm: alg, Expected: expected, Actual: actual}
+	if mode == ChecksumVerifyWarn {
+		log.Warningf("%s: %v", filePath, mismatchErr)
+		return nil
+	}
+	return mismatchErr
+}
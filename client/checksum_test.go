@@ -0,0 +1,135 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash/crc32"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDigestFromHeader(t *testing.T) {
+	sum := sha256.Sum256([]byte("test content"))
+	hexSum := hex.EncodeToString(sum[:])
+
+	t.Run("hex-x-pelican-digest", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("X-Pelican-Digest", "sha-256="+hexSum)
+		assert.Equal(t, hexSum, getDigestFromHeader(header, ChecksumSHA256))
+	})
+
+	t.Run("base64-digest", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Digest", "md5=abcd, SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		assert.Equal(t, hexSum, getDigestFromHeader(header, ChecksumSHA256))
+	})
+
+	t.Run("other-algorithm", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Digest", "sha-256="+hexSum)
+		assert.Equal(t, "", getDigestFromHeader(header, ChecksumCRC32C))
+	})
+
+	t.Run("unparseable-value", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Digest", "sha-256=not-a-checksum")
+		assert.Equal(t, "", getDigestFromHeader(header, ChecksumSHA256))
+	})
+}
+
+func TestVerifyDownloadChecksum(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+
+	content := []byte("test content")
+	filePath := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(filePath, content, 0644))
+
+	sum := sha256.Sum256(content)
+	goodHeader := http.Header{}
+	goodHeader.Set("Digest", "sha-256="+hex.EncodeToString(sum[:]))
+	badSum := sha256.Sum256([]byte("other content"))
+	badHeader := http.Header{}
+	badHeader.Set("Digest", "sha-256="+hex.EncodeToString(badSum[:]))
+
+	t.Run("match", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "error")
+		assert.NoError(t, verifyDownloadChecksum(filePath, goodHeader))
+	})
+
+	t.Run("mismatch-error", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "error")
+		err := verifyDownloadChecksum(filePath, badHeader)
+		require.Error(t, err)
+		var mismatchErr *ChecksumMismatchError
+		require.ErrorAs(t, err, &mismatchErr)
+		assert.Equal(t, hex.EncodeToString(sum[:]), mismatchErr.Actual)
+		assert.Equal(t, hex.EncodeToString(badSum[:]), mismatchErr.Expected)
+	})
+
+	t.Run("mismatch-warn", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "warn")
+		assert.NoError(t, verifyDownloadChecksum(filePath, badHeader))
+	})
+
+	t.Run("mismatch-off", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "off")
+		assert.NoError(t, verifyDownloadChecksum(filePath, badHeader))
+	})
+
+	t.Run("no-checksum-advertised", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "error")
+		assert.NoError(t, verifyDownloadChecksum(filePath, http.Header{}))
+	})
+
+	t.Run("crc32c", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "error")
+		viper.Set("Client.ChecksumAlgorithm", "crc32c")
+		h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+		_, err := h.Write(content)
+		require.NoError(t, err)
+		header := http.Header{}
+		header.Set("Digest", "crc32c="+hex.EncodeToString(h.Sum(nil)))
+		assert.NoError(t, verifyDownloadChecksum(filePath, header))
+		header.Set("Digest", "crc32c=00000000")
+		assert.Error(t, verifyDownloadChecksum(filePath, header))
+	})
+
+	t.Run("invalid-mode", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Client.VerifyChecksum", "sometimes")
+		assert.ErrorContains(t, verifyDownloadChecksum(filePath, goodHeader), "invalid checksum verification mode")
+	})
+}
@@ -0,0 +1,128 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The validators of a downloaded object, kept next to the destination file when Client.ConditionalGet
+// is enabled so that later downloads of the object can be skipped if it is unchanged
+type objectValidator struct {
+	ObjectPath   string `json:"objectPath"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// The path of the validator file of the destination file
+func objectValidatorPath(dest string) string {
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".pelican-etag")
+}
+
+// Read the validators of the object downloaded to dest. Returns nil if the destination file
+// is missing or there are no validators for the object
+func readObjectValidator(dest string, objectPath string) *objectValidator {
+	if fi, err := os.Stat(dest); err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	contents, err := os.ReadFile(objectValidatorPath(dest))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Debugf("Failed to read the validators of %s: %v", dest, err)
+		}
+		return nil
+	}
+	validator := objectValidator{}
+	if err := json.Unmarshal(contents, &validator); err != nil {
+		log.Debugf("Ignoring the corrupt validators of %s: %v", dest, err)
+		return nil
+	}
+	if validator.ObjectPath != objectPath || (validator.ETag == "" && validator.LastModified == "") {
+		return nil
+	}
+	return &validator
+}
+
+// Record the validators of the object downloaded to dest from the response headers. Any previous
+// validators are removed if the response has none
+func writeObjectValidator(dest string, objectPath string, header http.Header) {
+	validator := objectValidator{
+		ObjectPath:   objectPath,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if validator.ETag == "" && validator.LastModified == "" {
+		if err := os.Remove(objectValidatorPath(dest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Debugf("Failed to remove the stale validators of %s: %v", dest, err)
+		}
+		return
+	}
+	contents, err := json.Marshal(validator)
+	if err == nil {
+		err = os.WriteFile(objectValidatorPath(dest), contents, 0600)
+	}
+	if err != nil {
+		log.Debugf("Failed to record the validators of %s; the next download won't be conditional: %v", dest, err)
+	}
+}
+
+// Check if the object is unchanged since it was downloaded to dest, by sending a HEAD request
+// with the recorded validators in If-None-Match and If-Modified-Since headers. A 304 Not Modified
+// response means the object is unchanged; so does a response carrying the same strong ETag, for
+// servers ignoring conditional headers. Objects of immutable namespaces cannot change, so no request
+// is sent for them once they have been downloaded
+func isObjectUnchanged(httpClient *http.Client, req *http.Request, objectPath string, dest string, immutable bool) bool {
+	validator := readObjectValidator(dest, objectPath)
+	if validator == nil {
+		return false
+	} else if immutable {
+		return true
+	}
+
+	headReq := req.Clone(req.Context())
+	headReq.Method = http.MethodHead
+	if validator.ETag != "" {
+		headReq.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		headReq.Header.Set("If-Modified-Since", validator.LastModified)
+	}
+	headResp, err := httpClient.Do(headReq)
+	if err != nil {
+		log.Debugf("Failed to check whether %s changed since its last download: %v", objectPath, err)
+		return false
+	}
+	headResp.Body.Close()
+	switch {
+	case headResp.StatusCode == http.StatusNotModified:
+		return true
+	case headResp.StatusCode == http.StatusOK:
+		etag := headResp.Header.Get("ETag")
+		return etag != "" && !strings.HasPrefix(etag, "W/") && etag == validator.ETag
+	default:
+		return false
+	}
+}
@@ -0,0 +1,207 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+// A server for a single object honoring conditional requests, counting the GET requests it serves
+type versionedObjectServer struct {
+	mutex              sync.Mutex
+	content            []byte
+	etag               string
+	ignoreConditionals bool
+	gets               int
+}
+
+func (s *versionedObjectServer) setObject(content []byte, etag string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.content = content
+	s.etag = etag
+}
+
+func (s *versionedObjectServer) getCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.gets
+}
+
+func (s *versionedObjectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	content, etag := s.content, s.etag
+	if r.Method == http.MethodGet {
+		s.gets++
+	}
+	if s.ignoreConditionals {
+		r.Header.Del("If-None-Match")
+		r.Header.Del("If-Modified-Since")
+	}
+	s.mutex.Unlock()
+
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+func TestConditionalDownload(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{"Client.ConditionalGet": true})
+	ctx, _, _ := test_utils.TestContext(context.Background(), t)
+
+	content := []byte("the first version of the object")
+	changedContent := []byte("the second version of the object")
+
+	download := func(t *testing.T, serverURL *url.URL, dest string) int64 {
+		downloaded, _, _, _, _, err := downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.NoError(t, err)
+		return downloaded
+	}
+
+	for _, ignoreConditionals := range []bool{false, true} {
+		name := "not-modified"
+		if ignoreConditionals {
+			name = "same-etag"
+		}
+		t.Run(name, func(t *testing.T) {
+			objServer := &versionedObjectServer{ignoreConditionals: ignoreConditionals}
+			objServer.setObject(content, `"v1"`)
+			server := httptest.NewServer(objServer)
+			defer server.Close()
+			serverURL, err := url.Parse(server.URL + "/test/object")
+			require.NoError(t, err)
+			dest := filepath.Join(t.TempDir(), "object")
+
+			assert.Equal(t, int64(len(content)), download(t, serverURL, dest))
+			require.NotNil(t, readObjectValidator(dest, "/test/object"))
+			require.Equal(t, 1, objServer.getCount())
+
+			// The unchanged object isn't downloaded again
+			assert.Equal(t, int64(0), download(t, serverURL, dest))
+			assert.Equal(t, 1, objServer.getCount())
+			downloaded, err := os.ReadFile(dest)
+			require.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+
+			// A changed object is
+			objServer.setObject(changedContent, `"v2"`)
+			assert.Equal(t, int64(len(changedContent)), download(t, serverURL, dest))
+			assert.Equal(t, 2, objServer.getCount())
+			downloaded, err = os.ReadFile(dest)
+			require.NoError(t, err)
+			assert.Equal(t, changedContent, downloaded)
+		})
+	}
+
+	t.Run("missing-destination", func(t *testing.T) {
+		objServer := &versionedObjectServer{}
+		objServer.setObject(content, `"v1"`)
+		server := httptest.NewServer(objServer)
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		download(t, serverURL, dest)
+		require.NoError(t, os.Remove(dest))
+		assert.Equal(t, int64(len(content)), download(t, serverURL, dest))
+		assert.Equal(t, 2, objServer.getCount())
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		objServer := &versionedObjectServer{}
+		objServer.setObject(content, `"v1"`)
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			objServer.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+		immutable := transferAttemptDetails{Url: serverURL, Immutable: true}
+
+		downloaded, _, _, _, _, err := downloadHTTP(ctx, nil, nil, immutable, dest, -1, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), downloaded)
+		firstRequests := requests.Load()
+
+		// Once downloaded, the object of an immutable namespace is reused without a round-trip
+		// to the server, even if the server would now serve something else
+		objServer.setObject(changedContent, `"v2"`)
+		downloaded, _, _, _, _, err = downloadHTTP(ctx, nil, nil, immutable, dest, -1, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), downloaded)
+		assert.Equal(t, firstRequests, requests.Load())
+		onDisk, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, content, onDisk)
+	})
+}
+
+// The director answers object requests with a redirect; the conditional headers must survive it
+// so that the cache or origin serving the object can answer 304 Not Modified
+func TestConditionalDownloadThroughRedirect(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{"Client.ConditionalGet": true})
+	ctx, _, _ := test_utils.TestContext(context.Background(), t)
+
+	content := []byte("an object behind a redirect")
+	objServer := &versionedObjectServer{}
+	objServer.setObject(content, `"v1"`)
+	var conditionalRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/director/test/object" {
+			http.Redirect(w, r, "/test/object", http.StatusTemporaryRedirect)
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" {
+			conditionalRequests.Add(1)
+		}
+		objServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL + "/director/test/object")
+	require.NoError(t, err)
+	dest := filepath.Join(t.TempDir(), "object")
+
+	downloaded, _, _, _, _, err := downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), downloaded)
+
+	downloaded, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), downloaded)
+	assert.Equal(t, 1, objServer.getCount())
+	assert.Equal(t, int32(1), conditionalRequests.Load())
+}
@@ -51,6 +51,8 @@ func CreateNsFromDirectorResp(dirResp *http.Response) (namespace namespaces.Name
 	namespace.UseTokenOnRead, _ = strconv.ParseBool(xPelicanNamespace["require-token"])
 	namespace.ReadHTTPS, _ = strconv.ParseBool(xPelicanNamespace["readhttps"])
 	namespace.DirListHost = xPelicanNamespace["collections-url"]
+	namespace.Immutable, _ = strconv.ParseBool(xPelicanNamespace["immutable"])
+	namespace.StagedUploads, _ = strconv.ParseBool(xPelicanNamespace["staged-uploads"])
 
 	xPelicanAuthorization := []string{} // map of header to x - single entry - want to create an array for issuer
 	if len(dirResp.Header.Values("X-Pelican-Authorization")) > 0 {
@@ -107,9 +109,15 @@ func CreateNsFromDirectorResp(dirResp *http.Response) (namespace namespaces.Name
 	return
 }
 
+// Whether the status code is one the director may redirect with; see Director.RedirectStatusCode
+func isDirectorRedirect(statusCode int) bool {
+	return statusCode == http.StatusFound || statusCode == http.StatusTemporaryRedirect || statusCode == http.StatusPermanentRedirect
+}
+
 // Make a request to the director for a given verb/resource; return the
-// HTTP response object only if a 307 is returned.
-func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string) (resp *http.Response, err error) {
+// HTTP response object only if a redirect (302, 307, or 308) is returned.
+// The director's cookies, such as its sticky session cookies, are kept in jar if it isn't nil.
+func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string, jar http.CookieJar) (resp *http.Response, err error) {
 	resourceUrl := directorUrl + sourcePath
 	// Here we use http.Transport to prevent the client from following the director's
 	// redirect. We use the Location url elsewhere (plus we still need to do the token
@@ -118,6 +126,7 @@ func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string) (r
 	tr := config.GetTransport()
 	client = &http.Client{
 		Transport: tr,
+		Jar:       jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -144,7 +153,7 @@ func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string) (r
 
 	defer resp.Body.Close()
 	log.Tracef("Director's response: %#v\n", resp)
-	// Check HTTP response -- should be 307 (redirect), else something went wrong
+	// Check HTTP response -- should be a redirect, else something went wrong
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorln("Failed to read the body from the director response:", err)
@@ -177,7 +186,7 @@ func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string) (r
 	} else if resp.StatusCode == http.StatusMultiStatus && verb == "PROPFIND" {
 		// This is a director >7.9 proxy the PROPFIND response instead of redirect to the origin
 		return
-	} else if resp.StatusCode != 307 {
+	} else if !isDirectorRedirect(resp.StatusCode) {
 		return resp, errors.Errorf("%d: %s", resp.StatusCode, errMsg)
 	}
 
@@ -185,13 +194,18 @@ func queryDirector(ctx context.Context, verb, sourcePath, directorUrl string) (r
 }
 
 func getCachesFromDirectorResponse(resp *http.Response, needsToken bool) (caches []namespaces.DirectorCache, err error) {
-	// Get the Link header
+	// Get the Link header. The director lists all the servers in one header value, or, for Metalink/HTTP
+	// clients (RFC 6249), one server per value, so every value is parsed
 	linkHeader := resp.Header.Values("Link")
 	if len(linkHeader) == 0 {
 		return []namespaces.DirectorCache{}, nil
 	}
 
-	for _, linksStr := range strings.Split(linkHeader[0], ",") {
+	linksStrs := []string{}
+	for _, headerVal := range linkHeader {
+		linksStrs = append(linksStrs, strings.Split(headerVal, ",")...)
+	}
+	for _, linksStr := range linksStrs {
 		links := strings.Split(strings.ReplaceAll(linksStr, " ", ""), ";")
 
 		var endpoint string
@@ -203,6 +217,12 @@ func getCachesFromDirectorResponse(resp *http.Response, needsToken bool) (caches
 		for _, val := range links {
 			if strings.HasPrefix(val, "<") {
 				endpoint = val[1 : len(val)-1]
+				// Metalink links carry the request's query parameters, such as its token, which the
+				// client adds to its requests itself
+				if endpointUrl, err := url.Parse(endpoint); err == nil && endpointUrl.RawQuery != "" {
+					endpointUrl.RawQuery = ""
+					endpoint = endpointUrl.String()
+				}
 			} else if strings.HasPrefix(val, "pri") {
 				pri, _ = strconv.Atoi(val[4:])
 			}
@@ -222,8 +242,9 @@ func getCachesFromDirectorResponse(resp *http.Response, needsToken bool) (caches
 
 	// Making the assumption that the Link header doesn't already provide the caches
 	// in order (even though it probably does). This sorts the caches and ensures
-	// we're using the "pri" tag to order them
-	sort.Slice(caches, func(i, j int) bool {
+	// we're using the "pri" tag to order them; the alternate URLs of a cache share its
+	// priority and follow it, so the sort is stable
+	sort.SliceStable(caches, func(i, j int) bool {
 		val1 := caches[i].Priority
 		val2 := caches[j].Priority
 		return val1 < val2
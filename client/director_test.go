@@ -21,10 +21,12 @@ package client
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,6 +63,54 @@ func TestGetCachesFromDirectorResponse(t *testing.T) {
 	assert.Equal(t, true, caches[1].AuthedReq)
 }
 
+// With metalink headers on, the director lists each server in its own Link header value
+func TestGetCachesFromMetalinkDirectorResponse(t *testing.T) {
+	directorResponse := &http.Response{
+		StatusCode: http.StatusTemporaryRedirect,
+		Header: http.Header{"Link": []string{
+			`<https://cache-2.edu:8443/foo/bar?authz=token>; rel=duplicate; pri=2; depth=2`,
+			`<https://cache-1.edu:8443/foo/bar?authz=token>; rel=duplicate; pri=1; depth=2`,
+			`<https://cache-3.edu:8443/foo/bar?authz=token>; rel=duplicate; pri=3; depth=2`,
+		}},
+		Body: io.NopCloser(bytes.NewReader(nil)),
+	}
+
+	caches, err := getCachesFromDirectorResponse(directorResponse, true)
+	require.NoError(t, err)
+	require.Len(t, caches, 3)
+	for idx, cache := range caches {
+		assert.Equal(t, idx+1, cache.Priority)
+		assert.Equal(t, fmt.Sprintf("https://cache-%d.edu:8443/foo/bar", idx+1), cache.EndpointUrl)
+	}
+}
+
+// The alternate URLs of a cache share its priority and stay right after it
+func TestGetCachesFromDirectorResponseAltURLs(t *testing.T) {
+	directorResponse := &http.Response{
+		StatusCode: http.StatusTemporaryRedirect,
+		Header: http.Header{"Link": []string{
+			`<https://cache-2.edu:8443/foo/bar>; rel="duplicate"; pri=2; depth=2, ` +
+				`<https://cache-1.edu:8443/foo/bar>; rel="duplicate"; pri=1; depth=2, ` +
+				`<https://cache-1-alt.edu:8443/foo/bar>; rel="duplicate"; pri=1; depth=2, ` +
+				`<https://cache-1-alt2.edu:8443/foo/bar>; rel="duplicate"; pri=1; depth=2`,
+		}},
+		Body: io.NopCloser(bytes.NewReader(nil)),
+	}
+
+	caches, err := getCachesFromDirectorResponse(directorResponse, true)
+	require.NoError(t, err)
+	endpoints := []string{}
+	for _, cache := range caches {
+		endpoints = append(endpoints, cache.EndpointUrl)
+	}
+	assert.Equal(t, []string{
+		"https://cache-1.edu:8443/foo/bar",
+		"https://cache-1-alt.edu:8443/foo/bar",
+		"https://cache-1-alt2.edu:8443/foo/bar",
+		"https://cache-2.edu:8443/foo/bar",
+	}, endpoints)
+}
+
 func TestCreateNsFromDirectorResp(t *testing.T) {
 	//Craft the Director's response
 	directorHeaders := make(map[string][]string)
@@ -189,29 +239,35 @@ func TestNewTransferDetailsUsingDirector(t *testing.T) {
 }
 
 func TestQueryDirector(t *testing.T) {
-	// Construct a local server that we can poke with QueryDirector
-	expectedLocation := "http://redirect.com"
-	handler := func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Location", expectedLocation)
-		w.WriteHeader(http.StatusTemporaryRedirect)
-	}
-	server := httptest.NewServer(http.HandlerFunc(handler))
-	defer server.Close()
-
-	// Call QueryDirector with the test server URL and a source path
-	actualResp, err := queryDirector(context.Background(), "GET", "/foo/bar", server.URL)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Check the Location header
-	actualLocation := actualResp.Header.Get("Location")
-	if actualLocation != expectedLocation {
-		t.Errorf("Expected Location header %q, but got %q", expectedLocation, actualLocation)
+	// The director redirects with Director.RedirectStatusCode, which may be any of these
+	for _, code := range []int{http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		code := code
+		t.Run(strconv.Itoa(code), func(t *testing.T) {
+			// Construct a local server that we can poke with QueryDirector
+			expectedLocation := "http://redirect.com"
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Location", expectedLocation)
+				w.WriteHeader(code)
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			// Call QueryDirector with the test server URL and a source path
+			actualResp, err := queryDirector(context.Background(), "GET", "/foo/bar", server.URL, nil)
+			require.NoError(t, err)
+
+			// Check the Location header and the HTTP status code
+			assert.Equal(t, expectedLocation, actualResp.Header.Get("Location"))
+			assert.Equal(t, code, actualResp.StatusCode)
+		})
 	}
 
-	// Check the HTTP status code
-	if actualResp.StatusCode != http.StatusTemporaryRedirect {
-		t.Errorf("Expected HTTP status code %d, but got %d", http.StatusFound, actualResp.StatusCode)
-	}
+	t.Run("non-redirect-fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		_, err := queryDirector(context.Background(), "GET", "/foo/bar", server.URL, nil)
+		assert.Error(t, err)
+	})
 }
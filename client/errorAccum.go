@@ -166,6 +166,14 @@ func IsRetryable(err error) bool {
 	if errors.Is(err, &HeaderTimeoutError{}) {
 		return true
 	}
+	// A 5xx from one server may not recur on another (or on the same one, later), nor may a
+	// request timeout or rate limit; anything else, such as a missing object or rejected token,
+	// will fail the same way again
+	var sce *StatusCodeError
+	if errors.As(err, &sce) {
+		code := int(*sce)
+		return code >= http.StatusInternalServerError || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+	}
 	var cse *ConnectionSetupError
 	if errors.As(err, &cse) {
 		if sce, ok := cse.Unwrap().(grab.StatusCodeError); ok {
@@ -34,6 +34,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -441,6 +442,45 @@ func TestGetPublicRead(t *testing.T) {
 	})
 }
 
+// A test that spins up a federation, and checks that a conditional download of an unchanged
+// object is answered without a transfer, going through the director and the cache to the origin
+func TestConditionalGetPublicRead(t *testing.T) {
+	viper.Reset()
+	server_utils.ResetOriginExports()
+	fed := fed_test_utils.NewFedTest(t, bothPublicOriginCfg)
+	viper.Set("Logging.DisableProgressBars", true)
+	viper.Set("Client.ConditionalGet", true)
+
+	for _, export := range fed.Exports {
+		testFileContent := "test file content"
+		tempFile, err := os.Create(filepath.Join(export.StoragePrefix, "conditional.txt"))
+		require.NoError(t, err, "Error creating temp file")
+		defer os.Remove(tempFile.Name())
+		_, err = tempFile.WriteString(testFileContent)
+		require.NoError(t, err, "Error writing to temp file")
+		tempFile.Close()
+
+		downloadURL := fmt.Sprintf("pelican://%s:%s%s/%s", param.Server_Hostname.GetString(), strconv.Itoa(param.Server_WebPort.GetInt()),
+			export.FederationPrefix, filepath.Base(tempFile.Name()))
+		dest := filepath.Join(t.TempDir(), "conditional.txt")
+
+		transferResults, err := client.DoGet(fed.Ctx, downloadURL, dest, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(testFileContent)), transferResults[0].TransferredBytes)
+
+		// The origin reports the object unchanged, so the second download transfers nothing
+		transferResults, err = client.DoGet(fed.Ctx, downloadURL, dest, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), transferResults[0].TransferredBytes)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, testFileContent, string(downloaded))
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+}
+
 // A test that spins up a federation, and tests object stat
 func TestObjectStat(t *testing.T) {
 	viper.Reset()
@@ -925,3 +965,66 @@ func TestClientUnpack(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(11), fi.Size())
 }
+
+// A request body that fails after sending part of an object, as if the client went away mid-upload
+type interruptedBody struct {
+	sent  int
+	limit int
+}
+
+func (body *interruptedBody) Read(p []byte) (int, error) {
+	if body.sent >= body.limit {
+		return 0, errors.New("upload interrupted")
+	}
+	n := min(len(p), body.limit-body.sent)
+	for idx := range p[:n] {
+		p[idx] = 'a'
+	}
+	body.sent += n
+	return n, nil
+}
+
+// Test that an upload cut off partway leaves no partial object visible through stat or listing
+func TestInterruptedUploadNotVisible(t *testing.T) {
+	viper.Reset()
+	server_utils.ResetOriginExports()
+	defer server_utils.ResetOriginExports()
+	defer viper.Reset()
+	fed := fed_test_utils.NewFedTest(t, bothAuthOriginCfg)
+
+	tempToken, tkn := getTempToken(t)
+	defer tempToken.Close()
+	defer os.Remove(tempToken.Name())
+	viper.Set("Logging.DisableProgressBars", true)
+
+	export := fed.Exports[0]
+	uploadUrl, err := url.JoinPath(param.Origin_Url.GetString(), export.FederationPrefix, "partial.txt")
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(fed.Ctx, http.MethodPut, uploadUrl, &interruptedBody{limit: 64 * 1024})
+	require.NoError(t, err)
+	req.ContentLength = 1024 * 1024
+	req.Header.Set("Authorization", "Bearer "+tkn)
+	httpClient := http.Client{Transport: config.GetTransport()}
+	resp, err := httpClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		require.Fail(t, "the interrupted upload unexpectedly succeeded")
+	}
+
+	// The origin removes the object once it notices the upload failed
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(export.StoragePrefix, "partial.txt"))
+		return os.IsNotExist(err)
+	}, 10*time.Second, 100*time.Millisecond, "the partial object was left on disk")
+
+	statUrl := fmt.Sprintf("pelican://%s:%d%s/partial.txt", param.Server_Hostname.GetString(), param.Server_WebPort.GetInt(), export.FederationPrefix)
+	_, err = client.DoStat(fed.Ctx, statUrl, client.WithTokenLocation(tempToken.Name()))
+	assert.Error(t, err, "the partial object is visible to stat")
+
+	listUrl := fmt.Sprintf("pelican://%s:%d%s", param.Server_Hostname.GetString(), param.Server_WebPort.GetInt(), export.FederationPrefix)
+	listing, err := client.DoList(fed.Ctx, listUrl, client.WithTokenLocation(tempToken.Name()))
+	require.NoError(t, err)
+	for _, info := range listing {
+		assert.NotEqual(t, "partial.txt", info.Name, "the partial object is visible in the listing")
+	}
+}
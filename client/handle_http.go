@@ -25,6 +25,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httputil"
 	"net/url"
 	"os"
@@ -54,8 +55,10 @@ import (
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/error_codes"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/namespaces"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/utils"
 )
 
@@ -73,6 +76,9 @@ var (
 			ctx, cancel := context.WithTimeout(ctx, param.Transport_ResponseHeaderTimeout.GetDuration())
 			defer cancel()
 			urlFederation, err := config.DiscoverUrlFederation(ctx, key)
+			if err == nil && urlFederation.DirectorEndpoint == "" {
+				err = errors.Errorf("the federation metadata of %s does not advertise a director", key)
+			}
 			if err != nil {
 				// Set a shorter TTL for failures
 				item := c.Set(key, cacheItem{err: err}, failureTTL)
@@ -169,6 +175,7 @@ type (
 		TransferEndTime   time.Time     // when the transfer ends
 		TransferTime      time.Duration // amount of time we were transferring per attempt (in seconds)
 		CacheAge          time.Duration // age of the data reported by the cache
+		TTLHint           time.Duration // how much longer the server suggests the object may be cached; negative if no hint was given
 		Endpoint          string        // which origin did it use
 		ServerVersion     string        // version of the server
 		Error             error         // what error the attempt returned (if any)
@@ -198,6 +205,9 @@ type (
 
 		// Whether or not the cache has been queried
 		CacheQuery bool
+
+		// Whether the object's namespace is immutable, so a previously downloaded copy is known to be current
+		Immutable bool
 	}
 
 	// A structure representing a single file to transfer.
@@ -279,6 +289,9 @@ type (
 		ewmaCtr         atomic.Int64
 		clientLock      sync.RWMutex
 		pelicanURLCache *ttlcache.Cache[string, cacheItem]
+		// The director's cookies, kept for the lifetime of the engine so that the director's
+		// sticky sessions route the engine's successive transfers to the same cache
+		directorCookies http.CookieJar
 	}
 
 	TransferCallbackFunc = func(path string, downloaded int64, totalSize int64, completed bool)
@@ -561,6 +574,49 @@ func (tr TransferResults) ID() string {
 	return tr.jobId.String()
 }
 
+// Get the host of a federation URL, which may lack the https:// scheme
+func federationHost(federationUrl string) string {
+	if !strings.Contains(federationUrl, "://") {
+		federationUrl = "https://" + federationUrl
+	}
+	fedUrl, err := url.Parse(federationUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(fedUrl.Host)
+}
+
+// Check if the federation URL is the one configured in Federation.DiscoveryUrl
+func isConfiguredFederation(federationUrl string) bool {
+	host := federationHost(federationUrl)
+	return host != "" && host == federationHost(param.Federation_DiscoveryUrl.GetString())
+}
+
+// Get the director of a federation from its metadata document at /.well-known/pelican-configuration,
+// caching the result in the transfer engine. If the discovery of the configured federation fails,
+// fall back to the director configured in Federation.DirectorUrl; it's no substitute for the
+// director of any other federation
+func (te *TransferEngine) discoverFederation(federationUrl string) (pelicanURL pelicanUrl, err error) {
+	// Check if cache has key of federationURL, if not, loader will add it:
+	pelicanUrlItem := te.pelicanURLCache.Get(federationUrl)
+	if pelicanUrlItem == nil {
+		err = errors.Errorf("issue getting metadata information of the federation %s from cache", federationUrl)
+	} else if err = pelicanUrlItem.Value().err; err == nil {
+		return pelicanUrlItem.Value().url, nil
+	}
+
+	if !isConfiguredFederation(federationUrl) {
+		return pelicanUrl{}, err
+	}
+	// The configured federation info holds Federation.DirectorUrl even if its discovery failed
+	fedInfo, _ := config.GetFederation(te.ctx)
+	if configuredDirector := fedInfo.DirectorEndpoint; configuredDirector != "" {
+		log.Warningf("Failed to discover the federation %s (%v); falling back to the configured director %s", federationUrl, err, configuredDirector)
+		return pelicanUrl{directorUrl: configuredDirector}, nil
+	}
+	return pelicanUrl{}, err
+}
+
 func (te *TransferEngine) newPelicanURL(remoteUrl *url.URL) (pelicanURL pelicanUrl, err error) {
 	scheme := remoteUrl.Scheme
 	if remoteUrl.Host != "" {
@@ -582,12 +638,8 @@ func (te *TransferEngine) newPelicanURL(remoteUrl *url.URL) (pelicanURL pelicanU
 			federationUrl.Path = ""
 			federationUrl.Host = remoteUrl.Host
 
-			// Check if cache has key of federationURL, if not, loader will add it:
-			pelicanUrlItem := te.pelicanURLCache.Get(federationUrl.String())
-			if pelicanUrlItem.Value().err != nil {
-				return pelicanUrl{}, pelicanUrlItem.Value().err
-			} else {
-				pelicanURL = pelicanUrlItem.Value().url
+			if pelicanURL, err = te.discoverFederation(federationUrl.String()); err != nil {
+				return pelicanUrl{}, err
 			}
 		}
 	}
@@ -609,13 +661,8 @@ func (te *TransferEngine) newPelicanURL(remoteUrl *url.URL) (pelicanURL pelicanU
 		} else if config.GetPreferredPrefix() == config.PelicanPrefix {
 			// We hit this case when we are using a pelican binary but an osdf:// url, therefore we need to discover the osdf federation
 			log.Debugln("In Pelican mode with an osdf:// url, populating metadata with OSDF defaults")
-			// Check if cache has key of federationURL, if not, loader will add it:
-			pelicanUrlItem := te.pelicanURLCache.Get("osg-htc.org")
-			if pelicanUrlItem.Value().err != nil {
-				err = pelicanUrlItem.Value().err
+			if pelicanURL, err = te.discoverFederation("osg-htc.org"); err != nil {
 				return
-			} else {
-				pelicanURL = pelicanUrlItem.Value().url
 			}
 		}
 	} else if scheme == "pelican" && remoteUrl.Host == "" {
@@ -624,12 +671,8 @@ func (te *TransferEngine) newPelicanURL(remoteUrl *url.URL) (pelicanURL pelicanU
 			return pelicanUrl{}, errors.Errorf("pelican url scheme without discovery-url detected, please provide a federation discovery-url " +
 				"(e.g. pelican://<federation-url-in-hostname.org></namespace></path/to/file>) within the hostname or with the -f flag")
 		}
-		// Check if cache has key of federationURL, if not, loader will add it:
-		pelicanUrlItem := te.pelicanURLCache.Get(param.Federation_DiscoveryUrl.GetString())
-		if pelicanUrlItem != nil {
-			pelicanURL = pelicanUrlItem.Value().url
-		} else {
-			return pelicanUrl{}, errors.Errorf("issue getting metadata information from cache")
+		if pelicanURL, err = te.discoverFederation(param.Federation_DiscoveryUrl.GetString()); err != nil {
+			return pelicanUrl{}, err
 		}
 	} else if scheme == "" {
 		// If we don't have a url scheme, then our metadata information should be in the config
@@ -657,6 +700,11 @@ func NewTransferEngine(ctx context.Context) (te *TransferEngine, err error) {
 		return nil, errors.New("client has not been initialized, unable to create transfer engine")
 	}
 
+	directorCookies, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the cookie jar for the director")
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	egrp, _ := errgroup.WithContext(ctx)
 	work := make(chan *clientTransferJob, 5)
@@ -688,6 +736,7 @@ func NewTransferEngine(ctx context.Context) (te *TransferEngine, err error) {
 		ewmaTick:        time.NewTicker(ewmaInterval),
 		ewma:            ewma.NewMovingAverage(),
 		pelicanURLCache: pelicanURLCache,
+		directorCookies: directorCookies,
 	}
 	workerCount := param.Client_WorkerCount.GetInt()
 	if workerCount <= 0 {
@@ -1148,7 +1197,7 @@ func (tc *TransferClient) NewTransferJob(ctx context.Context, remoteUrl *url.URL
 		tj.useDirector = true
 		tj.directorUrl = pelicanURL.directorUrl
 	}
-	ns, err := getNamespaceInfo(tj.ctx, remoteUrl.Path, pelicanURL.directorUrl, upload, remoteUrl.RawQuery)
+	ns, err := getNamespaceInfo(tj.ctx, remoteUrl.Path, pelicanURL.directorUrl, upload, remoteUrl.RawQuery, tc.engine.directorCookies)
 	if err != nil {
 		log.Errorln(err)
 		err = errors.Wrapf(err, "failed to get namespace information for remote URL %s", remoteUrl.String())
@@ -1714,14 +1763,40 @@ func downloadObject(transfer *transferFile) (transferResults TransferResults, er
 	}
 
 	size, attempts := sortAttempts(transfer.job.ctx, transfer.remoteURL.Path, transfer.attempts)
+	// Cap the attempts so a flaky federation doesn't keep the download thrashing between caches
+	if maxAttempts := param.Client_MaximumTransferAttempts.GetInt(); maxAttempts > 0 && len(attempts) > maxAttempts {
+		log.Debugf("Limiting the download of %s to the first %d of %d attempts", transfer.remoteURL.Path, maxAttempts, len(attempts))
+		attempts = attempts[:maxAttempts]
+	}
+
+	// Failing over is bounded in wall-clock time as well as attempts; once the budget is spent,
+	// the attempt underway is interrupted and no new one starts
+	retryCtx := transfer.ctx
+	if maxRetryDuration := param.Client_MaximumRetryDuration.GetDuration(); maxRetryDuration > 0 {
+		var cancel context.CancelFunc
+		retryCtx, cancel = context.WithTimeout(transfer.ctx, maxRetryDuration)
+		defer cancel()
+	}
+	backoffBase := param.Client_RetryBackoffBase.GetDuration()
 
 	transferResults = newTransferResults(transfer.job)
 	xferErrors := NewTransferErrors()
 	success := false
+	var lastErr error
 	// transferStartTime is the start time of the last transfer attempt
 	// we create a var here and update it in the loop
 	var transferStartTime time.Time
 	for idx, transferEndpoint := range attempts { // For each transfer attempt (usually 3), try to download via HTTP
+		if idx > 0 {
+			if isPermanentError(lastErr) {
+				log.Debugf("Not retrying the download of %s since other caches cannot fix the error: %v", transfer.remoteURL.Path, lastErr)
+				break
+			}
+			if waitErr := waitRetryBackoff(retryCtx, backoffBase, idx); waitErr != nil {
+				log.Infof("Giving up on the download of %s after %d of %d attempts: %v", transfer.remoteURL.Path, idx, len(attempts), waitErr)
+				break
+			}
+		}
 		var attempt TransferResult
 		attempt.CacheAge = -1
 		attempt.Number = idx // Start with 0
@@ -1734,14 +1809,23 @@ func downloadObject(transfer *transferFile) (transferResults TransferResults, er
 		transferEndpointUrl := *transferEndpoint.Url
 		transferEndpointUrl.Path = transfer.remoteURL.Path
 		transferEndpoint.Url = &transferEndpointUrl
+		transferEndpoint.Immutable = transfer.job.namespace.Immutable
+		if idx > 0 {
+			// The download resumes from the bytes already written if the next cache serves the same version of the object
+			resumeOffset := partialDownloadSize(transfer.localPath)
+			log.Infof("Failing over the download of %s from %s to %s (attempt %d of %d); %d bytes were already downloaded",
+				transfer.remoteURL.Path, attempts[idx-1].Url.Host, transferEndpointUrl.Host, idx+1, len(attempts), resumeOffset)
+			metrics.PelicanClientDownloadFailovers.WithLabelValues(strconv.FormatBool(resumeOffset > 0)).Inc()
+		}
 		transferStartTime = time.Now() // Update start time for this attempt
-		attemptDownloaded, timeToFirstByte, cacheAge, serverVersion, err := downloadHTTP(
-			transfer.ctx, transfer.engine, transfer.callback, transferEndpoint, transfer.localPath, size, transfer.token, transfer.project,
+		attemptDownloaded, timeToFirstByte, cacheAge, ttlHint, serverVersion, err := downloadHTTP(
+			retryCtx, transfer.engine, transfer.callback, transferEndpoint, transfer.localPath, size, transfer.token, transfer.project,
 		)
 		endTime := time.Now()
 		if cacheAge >= 0 {
 			attempt.CacheAge = cacheAge
 		}
+		attempt.TTLHint = ttlHint
 		attempt.TransferEndTime = endTime
 		attempt.TransferTime = endTime.Sub(transferStartTime)
 		attempt.ServerVersion = serverVersion
@@ -1785,6 +1869,7 @@ func downloadObject(transfer *transferFile) (transferResults TransferResults, er
 				attempt.Error = newTransferAttemptError(serviceStr, proxyStr, false, false, err)
 			}
 			xferErrors.AddPastError(attempt.Error, endTime)
+			lastErr = attempt.Error
 		}
 		transferResults.Attempts = append(transferResults.Attempts, attempt)
 
@@ -1816,10 +1901,55 @@ func parseTransferStatus(status string) (int, string) {
 	return statusCode, strings.TrimSpace(parts[1])
 }
 
+// Get how much longer the server suggests the object in the response may be cached, from the
+// X-Pelican-Cache-TTL header (in seconds) or, failing that, the Cache-Control header less the
+// object's Age. Returns a negative duration if the response carries no hint
+func parseTTLHint(header http.Header) time.Duration {
+	if ttlStr := header.Get("X-Pelican-Cache-TTL"); ttlStr != "" {
+		if ttlSec, err := strconv.ParseInt(ttlStr, 10, 64); err == nil && ttlSec >= 0 {
+			return time.Duration(ttlSec) * time.Second
+		}
+		log.Debugf("Ignoring invalid X-Pelican-Cache-TTL header %q", ttlStr)
+	}
+
+	maxAge, sharedMaxAge := int64(-1), int64(-1)
+	for _, directive := range strings.Split(strings.Join(header.Values("Cache-Control"), ","), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0
+		case "max-age", "s-maxage":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			if strings.EqualFold(name, "s-maxage") {
+				sharedMaxAge = seconds
+			} else {
+				maxAge = seconds
+			}
+		}
+	}
+	// s-maxage applies to shared caches and takes precedence over max-age
+	if sharedMaxAge >= 0 {
+		maxAge = sharedMaxAge
+	}
+	if maxAge < 0 {
+		return -1
+	}
+	if ageSec, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && ageSec > 0 {
+		maxAge -= ageSec
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	return time.Duration(maxAge) * time.Second
+}
+
 // Perform the actual download of the file
 //
-// Returns the downloaded size, time to 1st byte downloaded, serverVersion and an error if there is one
-func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCallbackFunc, transfer transferAttemptDetails, dest string, totalSize int64, token string, project string) (downloaded int64, timeToFirstByte time.Duration, cacheAge time.Duration, serverVersion string, err error) {
+// Returns the downloaded size, time to 1st byte downloaded, the cache age and TTL hint, serverVersion and an error if there is one
+func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCallbackFunc, transfer transferAttemptDetails, dest string, totalSize int64, token string, project string) (downloaded int64, timeToFirstByte time.Duration, cacheAge time.Duration, ttlHint time.Duration, serverVersion string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Errorln("Panic occurred in downloadHTTP:", r)
@@ -1830,6 +1960,8 @@ func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCall
 
 	// Negative cache age indicates no Age response header was received
 	cacheAge = -1
+	// Negative TTL hint indicates the server gave no caching hint
+	ttlHint = -1
 
 	lastUpdate := time.Now()
 	if callback != nil {
@@ -1870,7 +2002,7 @@ func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCall
 	}
 	httpClient, ok := client.HTTPClient.(*http.Client)
 	if !ok {
-		return 0, 0, -1, "", errors.New("Internal error: implementation is not a http.Client type")
+		return 0, 0, -1, -1, "", errors.New("Internal error: implementation is not a http.Client type")
 	}
 	httpClient.Transport = transport
 	headerTimeout := transport.ResponseHeaderTimeout
@@ -1889,20 +2021,20 @@ func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCall
 	if transfer.PackOption != "" {
 		behavior, err := GetBehavior(transfer.PackOption)
 		if err != nil {
-			return 0, 0, -1, "", err
+			return 0, 0, -1, -1, "", err
 		}
 		if dest == "." {
 			dest, err = os.Getwd()
 			if err != nil {
-				return 0, 0, -1, "", errors.Wrap(err, "Failed to get current directory for destination")
+				return 0, 0, -1, -1, "", errors.Wrap(err, "Failed to get current directory for destination")
 			}
 		}
 		unpacker = newAutoUnpacker(dest, behavior)
 		if req, err = grab.NewRequestToWriter(unpacker, transferUrl.String()); err != nil {
-			return 0, 0, -1, "", errors.Wrap(err, "Failed to create new download request")
+			return 0, 0, -1, -1, "", errors.Wrap(err, "Failed to create new download request")
 		}
 	} else if req, err = grab.NewRequest(dest, transferUrl.String()); err != nil {
-		return 0, 0, -1, "", errors.Wrap(err, "Failed to create new download request")
+		return 0, 0, -1, -1, "", errors.Wrap(err, "Failed to create new download request")
 	}
 
 	rateLimit := param.Client_MaximumDownloadSpeed.GetInt()
@@ -1921,8 +2053,58 @@ func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCall
 	}
 	req.HTTPRequest.Header.Set("TE", "trailers")
 	req.HTTPRequest.Header.Set("User-Agent", getUserAgent(project))
+	if alg, err := ParseChecksumAlgorithm(param.Client_ChecksumAlgorithm.GetString()); err == nil {
+		req.HTTPRequest.Header.Set("Want-Digest", alg.digestName())
+	}
 	req = req.WithContext(ctx)
 
+	// Skip downloading an object that is unchanged since it was downloaded to dest
+	conditional := param.Client_ConditionalGet.GetBool() && unpacker == nil
+	if conditional && isObjectUnchanged(httpClient, req.HTTPRequest, transfer.Url.Path, dest, transfer.Immutable) {
+		log.Infof("Skipping the download of %s: the object is unchanged since it was downloaded to %s", transfer.Url.Path, dest)
+		if fi, statErr := os.Stat(dest); statErr == nil {
+			totalSize = fi.Size()
+		}
+		return
+	}
+
+	if streams := param.Client_DownloadStreams.GetInt(); streams > 1 && unpacker == nil {
+		var header http.Header
+		downloadStart := time.Now()
+		header, err = downloadMultiStream(ctx, httpClient, req.HTTPRequest, dest, streams, func(bytesComplete, size int64) {
+			if timeToFirstByte == 0 && bytesComplete > 0 {
+				timeToFirstByte = time.Since(downloadStart)
+			}
+			downloaded, totalSize = bytesComplete, size
+			if callback != nil {
+				callback(dest, bytesComplete, size, false)
+			}
+		})
+		if err == nil {
+			serverVersion = header.Get("Server")
+			if ageSec, ageErr := strconv.Atoi(header.Get("Age")); ageErr == nil {
+				cacheAge = time.Duration(ageSec) * time.Second
+			}
+			ttlHint = parseTTLHint(header)
+			log.Debugf("Downloaded %s with %d streams", transfer.Url.Path, streams)
+			removeProgressMarker(dest)
+			if err = verifyDownloadChecksum(dest, header); err == nil && conditional {
+				writeObjectValidator(dest, transfer.Url.Path, header)
+			}
+			return
+		} else if !errors.Is(err, errMultiStreamUnsupported) {
+			log.Errorln("Failed to download:", err)
+			return
+		}
+		log.Debugf("Falling back to a single-stream download of %s: %v", transfer.Url.Path, err)
+		err = nil
+	}
+
+	// Unpacked objects are streamed to the unpacker, so there is no partial file to resume
+	if unpacker == nil {
+		setupResumableDownload(httpClient, req, transfer.Url.Path, dest)
+	}
+
 	// Test the transfer speed every 0.5 seconds
 	t := time.NewTicker(500 * time.Millisecond)
 	defer t.Stop()
@@ -1966,6 +2148,7 @@ func downloadHTTP(ctx context.Context, te *TransferEngine, callback TransferCall
 			log.Debugf("Server at %s gave unparseable Age header (%s) in response: %s", transfer.Url.Host, ageStr, err.Error())
 		}
 	}
+	ttlHint = parseTTLHint(resp.HTTPResponse.Header)
 	if cacheAge == 0 {
 		log.Debugln("Server at", transfer.Url.Host, "had a cache miss")
 	} else if cacheAge > 0 {
@@ -2126,15 +2309,25 @@ Loop:
 	// prior attempt.
 	if resp.HTTPResponse.StatusCode != 200 && resp.HTTPResponse.StatusCode != 206 {
 		log.Debugln("Got failure status code:", resp.HTTPResponse.StatusCode)
-		return 0, 0, -1, serverVersion, &HttpErrResp{resp.HTTPResponse.StatusCode, fmt.Sprintf("Request failed (HTTP status %d): %s",
+		return 0, 0, -1, -1, serverVersion, &HttpErrResp{resp.HTTPResponse.StatusCode, fmt.Sprintf("Request failed (HTTP status %d): %s",
 			resp.HTTPResponse.StatusCode, resp.Err().Error())}
 	}
 
+	// Unpacked objects are not verified against the checksum as their bytes are not kept
 	if unpacker != nil {
 		unpacker.Close()
 		if err = unpacker.Error(); err != nil {
 			return
 		}
+	} else {
+		// The download is complete; a corrupt download must not be resumed by a later attempt either
+		removeProgressMarker(resp.Filename)
+		if err = verifyDownloadChecksum(resp.Filename, resp.HTTPResponse.Header); err != nil {
+			return
+		}
+		if conditional {
+			writeObjectValidator(resp.Filename, transfer.Url.Path, resp.HTTPResponse.Header)
+		}
 	}
 
 	log.Debugln("HTTP Transfer was successful")
@@ -2261,6 +2454,15 @@ func uploadObject(transfer *transferFile) (transferResult TransferResults, err e
 		Scheme: "https",
 		Path:   transfer.remoteURL.Path,
 	}
+	// Upload to a staging name when the origin supports it, so a partial object is never
+	// visible under the object's name, and move it into place once complete
+	finalDest := dest
+	var stagedDest *url.URL
+	if stagedPath := stagedUploadPath(transfer.remoteURL.Path); transfer.job != nil && shouldStageUpload(transfer.job.namespace, transfer.remoteURL.Path, stagedPath, transfer.token) {
+		stagedDest = &url.URL{Host: dest.Host, Scheme: dest.Scheme, Path: stagedPath}
+		dest = stagedDest
+		log.Debugln("Uploading to the staging name", stagedPath)
+	}
 	attempt.Endpoint = dest.Host
 	// Create the wrapped reader and send it to the request
 	closed := make(chan bool, 1)
@@ -2340,10 +2542,14 @@ Loop:
 			log.Debugln("File closed")
 		case response := <-responseChan:
 			attempt.ServerVersion = response.Header.Get("Server")
-			if response.StatusCode != 200 {
+			if !uploadSucceeded(response.StatusCode) {
 				log.Errorln("Got failure status code:", response.StatusCode)
-				lastError = &HttpErrResp{response.StatusCode, fmt.Sprintf("Request failed (HTTP status %d)",
-					response.StatusCode)}
+				// The origin doesn't persist a failed upload, so report why it was rejected
+				msg := fmt.Sprintf("Request failed (HTTP status %d)", response.StatusCode)
+				if body, err := io.ReadAll(io.LimitReader(response.Body, 1024)); err == nil && len(bytes.TrimSpace(body)) > 0 {
+					msg += ": " + string(bytes.TrimSpace(body))
+				}
+				lastError = &HttpErrResp{response.StatusCode, msg}
 				break Loop
 			}
 			break Loop
@@ -2366,6 +2572,15 @@ Loop:
 		}
 	}
 
+	if stagedDest != nil {
+		if lastError == nil {
+			lastError = commitStagedUpload(putContext, stagedDest, finalDest, transfer.token, transfer.project)
+		}
+		if lastError != nil {
+			removeStagedUpload(stagedDest, transfer.token, transfer.project)
+		}
+	}
+
 	transferEndTime := time.Now()
 	uploaded = reader.BytesComplete()
 	transferResult.TransferredBytes = uploaded
@@ -2406,7 +2621,7 @@ func runPut(request *http.Request, responseChan chan<- *http.Response, errorChan
 	}
 	dump, _ = httputil.DumpResponse(response, true)
 	log.Debugf("Dumping response: %s", dump)
-	if response.StatusCode != 200 {
+	if !uploadSucceeded(response.StatusCode) {
 		log.Errorln("Error status code:", response.Status)
 		log.Debugln("From the server:")
 		textResponse, err := io.ReadAll(response.Body)
@@ -2416,11 +2631,19 @@ func runPut(request *http.Request, responseChan chan<- *http.Response, errorChan
 			return
 		}
 		log.Debugln(string(textResponse))
+		// Keep the body around so the failure can be reported with the server's message
+		response.Body = io.NopCloser(bytes.NewReader(textResponse))
 	}
 	responseChan <- response
 
 }
 
+// Check if the status code of a PUT means the object was written in full. Depending
+// on the server, a successful upload is answered with 200 OK, 201 Created or 204 No Content
+func uploadSucceeded(statusCode int) bool {
+	return statusCode == http.StatusOK || statusCode == http.StatusCreated || statusCode == http.StatusNoContent
+}
+
 // This function queries the director with a PROPFIND to attempt to get the 'collections url'. If a propfind is not allowed on the director
 // We fall back to the deprecated dirlisthost in the namespace
 func getCollectionsUrl(ctx context.Context, remoteObjectUrl *url.URL, namespace namespaces.Namespace, directorUrl string) (collectionsUrl *url.URL, err error) {
@@ -2428,7 +2651,7 @@ func getCollectionsUrl(ctx context.Context, remoteObjectUrl *url.URL, namespace
 	// PROPFINDing the director
 	if directorUrl != "" {
 		// Query the director a PROPFIND to see if we can get our directory listing
-		resp, err := queryDirector(ctx, "PROPFIND", remoteObjectUrl.Path, directorUrl)
+		resp, err := queryDirector(ctx, "PROPFIND", remoteObjectUrl.Path, directorUrl, nil)
 		if err != nil {
 			// If we have an issue querying the director, we want to fallback to the deprecated dirlisthost from the namespace
 			// At this point, we have already queried the director (and it should have succeeded if we are here) so the error
@@ -2454,8 +2677,8 @@ func getCollectionsUrl(ctx context.Context, remoteObjectUrl *url.URL, namespace
 				}
 				return collectionsUrl, nil
 			}
-		} else if resp.StatusCode == http.StatusTemporaryRedirect {
-			// If the director responds with a 307 (temporary redirect), we're working with a new Director.
+		} else if isDirectorRedirect(resp.StatusCode) {
+			// If the director responds with a redirect (302, 307, or 308), we're working with a new Director.
 			// In that event, we can get the collections URL from our redirect
 			collections := resp.Header.Get("Location")
 			if collections == "" {
@@ -2549,6 +2772,10 @@ func (te *TransferEngine) walkDirDownloadHelper(job *clientTransferJob, transfer
 	}
 	localBase := strings.TrimPrefix(remotePath, job.job.remoteURL.Path)
 	for _, info := range infos {
+		// Skip the in-flight uploads of other clients
+		if !info.IsDir() && server_structs.IsStagedUploadName(info.Name()) {
+			continue
+		}
 		newPath := remotePath + "/" + info.Name()
 		if info.IsDir() {
 			err := te.walkDirDownloadHelper(job, transfers, files, newPath, client)
@@ -2676,6 +2903,10 @@ func listHttp(ctx context.Context, remoteObjectUrl *url.URL, directorUrl string,
 	}
 
 	for _, info := range infos {
+		// In-flight uploads aren't objects yet
+		if !info.IsDir() && server_structs.IsStagedUploadName(info.Name()) {
+			continue
+		}
 		// Create a FileInfo for the file and append it to the slice
 		file := FileInfo{
 			Name:    info.Name(),
@@ -2685,7 +2916,7 @@ func listHttp(ctx context.Context, remoteObjectUrl *url.URL, directorUrl string,
 		}
 		fileInfos = append(fileInfos, file)
 	}
-	return fileInfos, nil
+	return filterListingByToken(fileInfos, remotePath, namespace, token), nil
 }
 
 // Invoke a stat request against a remote URL that accepts WebDAV protocol,
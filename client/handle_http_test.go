@@ -24,6 +24,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/fs"
 	"net"
 	"net/http"
@@ -34,6 +36,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -213,7 +216,7 @@ func TestSlowTransfers(t *testing.T) {
 	var err error
 	// Do a quick timeout
 	go func() {
-		_, _, _, _, err = downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 		finishedChannel <- true
 	}()
 
@@ -299,7 +302,7 @@ func TestStoppedTransfer(t *testing.T) {
 	var err error
 
 	go func() {
-		_, _, _, _, err = downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 		finishedChannel <- true
 	}()
 
@@ -332,7 +335,7 @@ func TestConnectionError(t *testing.T) {
 	addr := l.Addr().String()
 	l.Close()
 
-	_, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: &url.URL{Host: addr, Scheme: "http"}, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+	_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: &url.URL{Host: addr, Scheme: "http"}, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 
 	assert.IsType(t, &ConnectionSetupError{}, err)
 
@@ -372,7 +375,7 @@ func TestTrailerError(t *testing.T) {
 	assert.Equal(t, svr.URL, transfers[0].Url.String())
 
 	// Call DownloadHTTP and check if the error is returned correctly
-	_, _, _, _, err := downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+	_, _, _, _, _, err := downloadHTTP(ctx, nil, nil, transfers[0], filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 
 	assert.NotNil(t, err)
 	assert.EqualError(t, err, "transfer error: Unable to read test.txt; input/output error")
@@ -436,6 +439,46 @@ func TestFailedUpload(t *testing.T) {
 	}
 }
 
+// Test that uploads answered with any success code complete, and that a rejected upload
+// reports the server's reason
+func TestUploadStatusCodes(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{
+		"TLSSkipVerify": true,
+	})
+	testfileLocation := filepath.Join(t.TempDir(), "testfile.txt")
+	require.NoError(t, os.WriteFile(testfileLocation, []byte("Hello, world!\n"), fs.FileMode(0600)))
+
+	upload := func(t *testing.T, statusCode int, body string) error {
+		svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(statusCode)
+			_, _ = w.Write([]byte(body))
+		}))
+		defer svr.Close()
+		svrURL, err := url.Parse(svr.URL)
+		require.NoError(t, err)
+		transfer := &transferFile{
+			ctx:       context.Background(),
+			job:       &TransferJob{},
+			localPath: testfileLocation,
+			remoteURL: svrURL,
+			attempts:  []transferAttemptDetails{{Url: svrURL}},
+		}
+		transferResult, err := uploadObject(transfer)
+		require.NoError(t, err)
+		return transferResult.Error
+	}
+
+	for _, statusCode := range []int{http.StatusOK, http.StatusCreated, http.StatusNoContent} {
+		assert.NoError(t, upload(t, statusCode, ""), "status code %d", statusCode)
+	}
+
+	err := upload(t, http.StatusInsufficientStorage, "No space left on device")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "507")
+	assert.Contains(t, err.Error(), "No space left on device")
+}
+
 func TestSortAttempts(t *testing.T) {
 	ctx, cancel, _ := test_utils.TestContext(context.Background(), t)
 
@@ -525,7 +568,7 @@ func TestTimeoutHeaderSetForDownload(t *testing.T) {
 
 	serverURL, err := url.Parse(server.URL)
 	assert.NoError(t, err)
-	_, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+	_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 	assert.NoError(t, err)
 	viper.Reset()
 }
@@ -564,7 +607,7 @@ func TestJobIdHeaderSetForDownload(t *testing.T) {
 
 	serverURL, err := url.Parse(server.URL)
 	assert.NoError(t, err)
-	_, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
+	_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "")
 	assert.NoError(t, err)
 	viper.Reset()
 	os.Unsetenv("_CONDOR_JOB_AD")
@@ -599,7 +642,7 @@ func TestProjInUserAgent(t *testing.T) {
 
 	serverURL, err := url.Parse(server_test.server.URL)
 	assert.NoError(t, err)
-	_, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "test")
+	_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL, Proxy: false}, filepath.Join(t.TempDir(), "test.txt"), -1, "", "test")
 	assert.NoError(t, err)
 
 	// Test the user-agent header is what we expect it to be
@@ -838,6 +881,80 @@ func TestNewPelicanURL(t *testing.T) {
 		assert.True(t, errors.Is(err, config.MetadataTimeoutErr))
 	})
 
+	t.Run("TestPelicanSchemeMalformedMetadata", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{
+			"TLSSkipVerify": true,
+		})
+
+		te, err := NewTransferEngine(ctx)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, te.Shutdown())
+		}()
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, err := w.Write([]byte("{not json"))
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+		noDirectorServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, err := w.Write([]byte(`{"namespace_registration_endpoint": "registry"}`))
+			assert.NoError(t, err)
+		}))
+		defer noDirectorServer.Close()
+
+		for _, fedServer := range []*httptest.Server{server, noDirectorServer} {
+			serverURL, err := url.Parse(fedServer.URL)
+			require.NoError(t, err)
+			remoteObjectURL, err := url.Parse("pelican://" + serverURL.Host + "/something/somewhere/thatdoesnotexist.txt")
+			require.NoError(t, err)
+
+			_, err = te.newPelicanURL(remoteObjectURL)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("TestPelicanSchemeFallbackToConfiguredDirector", func(t *testing.T) {
+		// The federation has no metadata document
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		test_utils.InitClient(t, map[string]any{
+			"TLSSkipVerify":           true,
+			"Federation.DiscoveryUrl": server.URL,
+			"Federation.DirectorUrl":  "https://configured-director.org",
+			"Federation.RegistryUrl":  "https://configured-registry.org",
+		})
+
+		te, err := NewTransferEngine(ctx)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, te.Shutdown())
+		}()
+
+		remoteObjectURL, err := url.Parse("pelican://" + serverURL.Host + "/something/somewhere/thatdoesnotexist.txt")
+		require.NoError(t, err)
+
+		pelicanURL, err := te.newPelicanURL(remoteObjectURL)
+		require.NoError(t, err)
+		assert.Equal(t, "https://configured-director.org", pelicanURL.directorUrl)
+
+		// Another federation must not be routed to the configured director
+		otherServer := httptest.NewTLSServer(http.NotFoundHandler())
+		defer otherServer.Close()
+		otherURL, err := url.Parse(otherServer.URL)
+		require.NoError(t, err)
+		remoteObjectURL, err = url.Parse("pelican://" + otherURL.Host + "/something/somewhere/thatdoesnotexist.txt")
+		require.NoError(t, err)
+
+		_, err = te.newPelicanURL(remoteObjectURL)
+		assert.Error(t, err)
+	})
+
 	t.Cleanup(func() {
 		cancel()
 		if err := egrp.Wait(); err != nil && err != context.Canceled && err != http.ErrServerClosed {
@@ -848,6 +965,76 @@ func TestNewPelicanURL(t *testing.T) {
 	})
 }
 
+// The director's sticky session cookie routes the successive transfers of an engine to the same cache
+func TestDirectorStickySession(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{"TLSSkipVerify": true})
+	ctx, _, _ := test_utils.TestContext(context.Background(), t)
+
+	var cacheGets [2]atomic.Int32
+	caches := make([]*httptest.Server, 2)
+	for idx := range caches {
+		idx := idx
+		caches[idx] = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Skip the single-byte requests the client probes the caches with
+			if r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+				cacheGets[idx].Add(1)
+			}
+			_, _ = w.Write([]byte("test file content"))
+		}))
+		defer caches[idx].Close()
+	}
+
+	// A director choosing the caches in turn, unless the client has a sticky session cookie
+	var fedUrl string
+	var selections atomic.Int32
+	fed := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/pelican-configuration" {
+			_ = json.NewEncoder(w).Encode(config.FederationDiscovery{DirectorEndpoint: fedUrl})
+			return
+		}
+		var chosen int
+		if cookie, err := r.Cookie("sticky"); err == nil {
+			chosen, _ = strconv.Atoi(cookie.Value)
+		} else {
+			chosen = int(selections.Add(1)) % 2
+			http.SetCookie(w, &http.Cookie{Name: "sticky", Value: strconv.Itoa(chosen), Path: "/", Secure: true})
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="duplicate"; pri=1, <%s>; rel="duplicate"; pri=2`, caches[chosen].URL, caches[1-chosen].URL))
+		w.Header().Set("X-Pelican-Namespace", "namespace=/test, require-token=false")
+		http.Redirect(w, r, caches[chosen].URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer fed.Close()
+	fedUrl = fed.URL
+	fedHost := strings.TrimPrefix(fed.URL, "https://")
+
+	te, err := NewTransferEngine(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, te.Shutdown())
+	}()
+	tc, err := te.NewClient()
+	require.NoError(t, err)
+	destDir := t.TempDir()
+	for idx := 0; idx < 4; idx++ {
+		remoteUrl, err := url.Parse(fmt.Sprintf("pelican://%s/test/object%d", fedHost, idx))
+		require.NoError(t, err)
+		tj, err := tc.NewTransferJob(ctx, remoteUrl, filepath.Join(destDir, fmt.Sprintf("object%d", idx)), false, false)
+		require.NoError(t, err)
+		require.NoError(t, tc.Submit(tj))
+	}
+	results, err := tc.Shutdown()
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+	}
+
+	// All of the transfers went to the cache the director chose first
+	assert.Equal(t, int32(1), selections.Load())
+	assert.Equal(t, int32(4), cacheGets[1].Load())
+	assert.Equal(t, int32(0), cacheGets[0].Load())
+}
+
 // Tests the functionality of getCachesToTry, ensuring that the function returns the correct number of caches and removes duplicates
 func TestGetCachesToTry(t *testing.T) {
 	directorCaches := make([]namespaces.DirectorCache, 3)
@@ -1159,22 +1346,25 @@ func TestGetCollectionsUrl(t *testing.T) {
 	err := config.InitClient()
 	assert.NoError(t, err)
 
-	// Test we get dirlisthost with valid PROPFIND on test server
-	t.Run("testValidPropfind", func(t *testing.T) {
-		expectedLocation := "http://some/origin/path/to/object"
-		handler := func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Location", expectedLocation)
-			w.WriteHeader(http.StatusTemporaryRedirect)
-		}
-		server := httptest.NewServer(http.HandlerFunc(handler))
-		defer server.Close()
-		testObjectUrl, err := url.Parse("pelican://federation/some/object")
-		require.NoError(t, err)
+	// Test we get dirlisthost with valid PROPFIND on test server, for each redirect code the director may use
+	for _, code := range []int{http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		code := code
+		t.Run(fmt.Sprintf("testValidPropfind%d", code), func(t *testing.T) {
+			expectedLocation := "http://some/origin/path/to/object"
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Location", expectedLocation)
+				w.WriteHeader(code)
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+			testObjectUrl, err := url.Parse("pelican://federation/some/object")
+			require.NoError(t, err)
 
-		dirListHost, err := getCollectionsUrl(ctx, testObjectUrl, namespaces.Namespace{}, server.URL)
-		require.NoError(t, err)
-		assert.Equal(t, "http://some", dirListHost.String())
-	})
+			dirListHost, err := getCollectionsUrl(ctx, testObjectUrl, namespaces.Namespace{}, server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, "http://some", dirListHost.String())
+		})
+	}
 
 	// Test we get dirlist host when PROPFIND returns 405 but dirlisthost set in namespace
 	t.Run("testInvalidPropfindValidDirListInNamespace", func(t *testing.T) {
@@ -1320,3 +1510,30 @@ func TestGetCollectionsUrl(t *testing.T) {
 		assert.Contains(t, err.Error(), "some server error")
 	})
 }
+
+func TestParseTTLHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected time.Duration
+	}{
+		{name: "no-hint", headers: map[string]string{}, expected: -1},
+		{name: "max-age", headers: map[string]string{"Cache-Control": "public, max-age=3600"}, expected: time.Hour},
+		{name: "max-age-less-age", headers: map[string]string{"Cache-Control": "max-age=3600", "Age": "600"}, expected: 50 * time.Minute},
+		{name: "older-than-max-age", headers: map[string]string{"Cache-Control": "max-age=60", "Age": "600"}, expected: 0},
+		{name: "s-maxage-precedence", headers: map[string]string{"Cache-Control": "s-maxage=120, max-age=60"}, expected: 2 * time.Minute},
+		{name: "no-store", headers: map[string]string{"Cache-Control": "no-store"}, expected: 0},
+		{name: "pelican-header-precedence", headers: map[string]string{"Cache-Control": "max-age=60", "X-Pelican-Cache-TTL": "30"}, expected: 30 * time.Second},
+		{name: "invalid-pelican-header", headers: map[string]string{"Cache-Control": "max-age=60", "X-Pelican-Cache-TTL": "soon"}, expected: time.Minute},
+		{name: "invalid-max-age", headers: map[string]string{"Cache-Control": "max-age=forever"}, expected: -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, val := range test.headers {
+				header.Set(key, val)
+			}
+			assert.Equal(t, test.expected, parseTTLHint(header))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/namespaces"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+// Get the matcher of the token scopes for the namespace. The resources of the token's scopes are
+// relative to the issuer's base path, which is the namespace prefix unless the director says otherwise
+func namespaceScopeMatcher(namespace namespaces.Namespace) token_scopes.ScopeMatcher {
+	matcher := token_scopes.ScopeMatcher{BasePath: namespace.Path}
+	if namespace.CredentialGen != nil && namespace.CredentialGen.BasePath != nil && *namespace.CredentialGen.BasePath != "" {
+		matcher.BasePath = *namespace.CredentialGen.BasePath
+	}
+	return matcher
+}
+
+// Filter the entries of a listing of remotePath down to the ones the token may read.
+// The director filters its own listings the same way; this covers listings fetched
+// straight from an origin and keeps a misbehaving server from showing more.
+// The resources of the token's scopes are relative to the issuer's base path, which is
+// the namespace prefix unless the director says otherwise. Listings made without a token,
+// or with a token lacking read scopes (e.g. an opaque one), are returned unfiltered, as
+// the server has already decided what the client may see
+func filterListingByToken(fileInfos []FileInfo, remotePath string, namespace namespaces.Namespace, token string) []FileInfo {
+	if token == "" {
+		return fileInfos
+	}
+	readScopes := token_scopes.ParseReadScopes(token)
+	if len(readScopes) == 0 {
+		return fileInfos
+	}
+
+	matcher := namespaceScopeMatcher(namespace)
+	filtered := make([]FileInfo, 0, len(fileInfos))
+	for _, info := range fileInfos {
+		if matcher.ListingAuthorized(readScopes, path.Join(remotePath, info.Name), info.IsDir) {
+			filtered = append(filtered, info)
+		} else {
+			log.Debugf("Omitting %s from the listing of %s, as the token doesn't authorize reading it", info.Name, remotePath)
+		}
+	}
+	return filtered
+}
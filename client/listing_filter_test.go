@@ -0,0 +1,90 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/namespaces"
+)
+
+func TestFilterListingByToken(t *testing.T) {
+	makeToken := func(scope string) string {
+		tok, err := jwt.NewBuilder().Claim("scope", scope).Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(tok, jwt.WithInsecureNoSignature())
+		require.NoError(t, err)
+		return string(signed)
+	}
+	listingNames := func(infos []FileInfo) []string {
+		names := []string{}
+		for _, info := range infos {
+			names = append(names, info.Name)
+		}
+		return names
+	}
+	listing := []FileInfo{
+		{Name: "public", IsDir: true},
+		{Name: "private", IsDir: true},
+		{Name: "readme.txt"},
+	}
+	ns := namespaces.Namespace{Path: "/foo"}
+
+	t.Run("narrow-scope", func(t *testing.T) {
+		filtered := filterListingByToken(listing, "/foo", ns, makeToken("storage.read:/public storage.modify:/private"))
+		assert.Equal(t, []string{"public"}, listingNames(filtered))
+
+		// Directories leading to a granted path are listed as well
+		filtered = filterListingByToken(listing, "/foo", ns, makeToken("storage.read:/private/data"))
+		assert.Equal(t, []string{"private"}, listingNames(filtered))
+	})
+
+	t.Run("broad-scope", func(t *testing.T) {
+		filtered := filterListingByToken(listing, "/foo", ns, makeToken("storage.read:/"))
+		assert.Equal(t, []string{"public", "private", "readme.txt"}, listingNames(filtered))
+	})
+
+	t.Run("issuer-base-path", func(t *testing.T) {
+		basePath := "/"
+		nsWithBase := namespaces.Namespace{Path: "/foo", CredentialGen: &namespaces.CredentialGeneration{BasePath: &basePath}}
+		filtered := filterListingByToken(listing, "/foo", nsWithBase, makeToken("storage.read:/foo/readme.txt"))
+		assert.Equal(t, []string{"readme.txt"}, listingNames(filtered))
+	})
+
+	t.Run("wildcard-and-max-scope-depth", func(t *testing.T) {
+		filtered := filterListingByToken(listing, "/foo", ns, makeToken("storage.read:/public/*"))
+		assert.Equal(t, []string{"public"}, listingNames(filtered))
+
+		// MaxScopeDepth only tells clients how deep a scope to request; deeper scopes still grant access
+		depth := 1
+		nsWithDepth := namespaces.Namespace{Path: "/foo", CredentialGen: &namespaces.CredentialGeneration{MaxScopeDepth: &depth}}
+		filtered = filterListingByToken(listing, "/foo", nsWithDepth, makeToken("storage.read:/public/* storage.read:/private/data"))
+		assert.Equal(t, []string{"public", "private"}, listingNames(filtered))
+	})
+
+	t.Run("unfiltered-without-read-scopes", func(t *testing.T) {
+		assert.Len(t, filterListingByToken(listing, "/foo", ns, ""), 3)
+		assert.Len(t, filterListingByToken(listing, "/foo", ns, "opaque-token"), 3)
+		assert.Len(t, filterListingByToken(listing, "/foo", ns, makeToken("storage.modify:/")), 3)
+	})
+}
@@ -209,7 +209,7 @@ func DoStat(ctx context.Context, destination string, options ...TransferOption)
 		return nil, errors.Wrap(err, "Failed to generate pelicanURL object")
 	}
 
-	ns, err := getNamespaceInfo(ctx, destUri.Path, pelicanURL.directorUrl, false, "")
+	ns, err := getNamespaceInfo(ctx, destUri.Path, pelicanURL.directorUrl, false, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +247,7 @@ func GetCacheHostnames(ctx context.Context, testFile string) (urls []string, err
 	if err != nil {
 		return
 	}
-	ns, err := getNamespaceInfo(ctx, testFile, fedInfo.DirectorEndpoint, false, "")
+	ns, err := getNamespaceInfo(ctx, testFile, fedInfo.DirectorEndpoint, false, "", nil)
 	if err != nil {
 		return
 	}
@@ -441,7 +441,7 @@ func discoverHTCondorToken(tokenName string) string {
 // Retrieve federation namespace information for a given URL.
 // If OSDFDirectorUrl is non-empty, then the namespace information will be pulled from the director;
 // otherwise, it is pulled from topology.
-func getNamespaceInfo(ctx context.Context, resourcePath, OSDFDirectorUrl string, isPut bool, query string) (ns namespaces.Namespace, err error) {
+func getNamespaceInfo(ctx context.Context, resourcePath, OSDFDirectorUrl string, isPut bool, query string, jar http.CookieJar) (ns namespaces.Namespace, err error) {
 	// If we have a director set, go through that for namespace info, otherwise use topology
 	if OSDFDirectorUrl != "" {
 		log.Debugln("Will query director at", OSDFDirectorUrl, "for object", resourcePath)
@@ -453,7 +453,7 @@ func getNamespaceInfo(ctx context.Context, resourcePath, OSDFDirectorUrl string,
 			resourcePath += "?" + query
 		}
 		var dirResp *http.Response
-		dirResp, err = queryDirector(ctx, verb, resourcePath, OSDFDirectorUrl)
+		dirResp, err = queryDirector(ctx, verb, resourcePath, OSDFDirectorUrl, jar)
 		if err != nil {
 			if isPut && dirResp != nil && dirResp.StatusCode == 405 {
 				err = errors.New("Error 405: No writeable origins were found")
@@ -540,7 +540,7 @@ func DoList(ctx context.Context, remoteObject string, options ...TransferOption)
 		return nil, errors.Wrap(err, "failed to generate pelicanURL object")
 	}
 
-	ns, err := getNamespaceInfo(ctx, remoteObjectUrl.Path, pelicanURL.directorUrl, false, "")
+	ns, err := getNamespaceInfo(ctx, remoteObjectUrl.Path, pelicanURL.directorUrl, false, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,227 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+// A reader counting the bytes read in a counter shared by all streams of a download
+type countingReader struct {
+	reader  io.Reader
+	counter *atomic.Int64
+}
+
+const (
+	// The size of the byte range downloaded by one request of a multi-stream download
+	multiStreamChunkSize int64 = 4 * 1024 * 1024
+	// The number of downloaded chunks per stream that may be held in memory, waiting to be written in order
+	multiStreamChunksPerStream = 2
+)
+
+// The object can't be downloaded with multiple streams; the caller should fall back to a single stream
+var errMultiStreamUnsupported = errors.New("multi-stream download is not possible")
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.reader.Read(p)
+	cr.counter.Add(int64(n))
+	return
+}
+
+// Download the byte range [start, end] of the object
+func downloadRange(ctx context.Context, httpClient *http.Client, req *http.Request, start, end int64, bytesComplete *atomic.Int64) ([]byte, error) {
+	rangeReq := req.Clone(ctx)
+	rangeReq.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	resp, err := httpClient.Do(rangeReq)
+	if err != nil {
+		return nil, &ConnectionSetupError{URL: req.URL.String(), Err: err}
+	}
+	defer resp.Body.Close()
+	// A 200 response means the server ignored the range or, with If-Range, that the object changed
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, &HttpErrResp{resp.StatusCode, fmt.Sprintf("Range request for bytes %d-%d failed (HTTP status %d)", start, end, resp.StatusCode)}
+	}
+	if contentRange := resp.Header.Get("Content-Range"); !strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-%d/", start, end)) {
+		return nil, errors.Errorf("the server responded with the range %q instead of bytes %d-%d", contentRange, start, end)
+	}
+
+	buf := make([]byte, end-start+1)
+	if _, err := io.ReadFull(&countingReader{reader: resp.Body, counter: bytesComplete}, buf); err != nil {
+		return nil, errors.Wrapf(err, "failed to read bytes %d-%d", start, end)
+	}
+	// Drain the body so the trailers are available
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if errorStatus := resp.Trailer.Get("X-Transfer-Status"); errorStatus != "" {
+		if statusCode, statusText := parseTransferStatus(errorStatus); statusCode != 200 {
+			return nil, errors.New("transfer error: " + statusText)
+		}
+	}
+	return buf, nil
+}
+
+// Download the object to dest with `streams` concurrent range requests of multiStreamChunkSize bytes each.
+// The chunks are written to dest in order and at most streams*multiStreamChunksPerStream chunks are held
+// in memory. Progress is reported periodically through the progress function.
+//
+// Returns the headers of the object, or errMultiStreamUnsupported before anything is written if the
+// server doesn't support range requests or the object is too small to split
+func downloadMultiStream(ctx context.Context, httpClient *http.Client, req *http.Request, dest string, streams int, progress func(downloaded, size int64)) (http.Header, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	headReq := req.Clone(ctx)
+	headReq.Method = http.MethodHead
+	headResp, err := httpClient.Do(headReq)
+	if err != nil {
+		return nil, errors.Wrapf(errMultiStreamUnsupported, "the HEAD request failed: %v", err)
+	}
+	headResp.Body.Close()
+	if headResp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(errMultiStreamUnsupported, "the HEAD request returned status code %d", headResp.StatusCode)
+	}
+	if headResp.Header.Get("Accept-Ranges") != "bytes" {
+		return nil, errors.Wrap(errMultiStreamUnsupported, "the server doesn't support range requests")
+	}
+	size := headResp.ContentLength
+	if size < 2*multiStreamChunkSize {
+		return nil, errors.Wrapf(errMultiStreamUnsupported, "the object of %d bytes is too small to split", size)
+	}
+
+	// Fail the ranges rather than mixing the versions if the object changes during the download
+	rangeReq := req.Clone(ctx)
+	if etag := headResp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		rangeReq.Header.Set("If-Range", etag)
+	} else if lastModified := headResp.Header.Get("Last-Modified"); lastModified != "" {
+		rangeReq.Header.Set("If-Range", lastModified)
+	}
+
+	file, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	numChunks := int((size + multiStreamChunkSize - 1) / multiStreamChunkSize)
+	chunks := make([]chan []byte, numChunks)
+	for idx := range chunks {
+		chunks[idx] = make(chan []byte, 1)
+	}
+	jobs := make(chan int)
+	window := make(chan struct{}, streams*multiStreamChunksPerStream)
+	bytesComplete := atomic.Int64{}
+
+	egrp, egrpCtx := errgroup.WithContext(ctx)
+	// Hand out the chunks in order. A chunk takes a slot of the window before it's downloaded, so the
+	// earliest unwritten chunk always has a slot and the writer can't wait on it forever
+	egrp.Go(func() error {
+		defer close(jobs)
+		for idx := range chunks {
+			select {
+			case window <- struct{}{}:
+			case <-egrpCtx.Done():
+				return nil
+			}
+			select {
+			case jobs <- idx:
+			case <-egrpCtx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+	for i := 0; i < streams; i++ {
+		egrp.Go(func() error {
+			for idx := range jobs {
+				start := int64(idx) * multiStreamChunkSize
+				end := min(start+multiStreamChunkSize, size) - 1
+				buf, err := downloadRange(egrpCtx, httpClient, rangeReq, start, end, &bytesComplete)
+				if err != nil {
+					return err
+				}
+				chunks[idx] <- buf
+			}
+			return nil
+		})
+	}
+	egrp.Go(func() error {
+		for idx := range chunks {
+			select {
+			case buf := <-chunks[idx]:
+				if _, err := file.Write(buf); err != nil {
+					return errors.Wrapf(err, "failed to write to %s", dest)
+				}
+				<-window
+			case <-egrpCtx.Done():
+				return egrpCtx.Err()
+			}
+		}
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- egrp.Wait()
+	}()
+
+	stoppedTransferTimeout := compatToDuration(param.Client_StoppedTransferTimeout.GetDuration(), "Client.StoppedTranferTimeout")
+	progressTicker := time.NewTicker(100 * time.Millisecond)
+	defer progressTicker.Stop()
+	var lastBytesComplete int64
+	lastProgress := time.Now()
+	for {
+		select {
+		case err := <-done:
+			progress(bytesComplete.Load(), size)
+			if err != nil {
+				return nil, err
+			}
+			if err := file.Close(); err != nil {
+				return nil, errors.Wrapf(err, "failed to close %s", dest)
+			}
+			return headResp.Header, nil
+		case <-progressTicker.C:
+			downloaded := bytesComplete.Load()
+			progress(downloaded, size)
+			if downloaded != lastBytesComplete {
+				lastBytesComplete = downloaded
+				lastProgress = time.Now()
+			} else if time.Since(lastProgress) > stoppedTransferTimeout {
+				cancel()
+				<-done
+				return nil, &StoppedTransferError{
+					BytesTransferred: downloaded,
+					StoppedTime:      time.Since(lastProgress),
+					CacheHit:         headResp.Header.Get("Age") != "" && headResp.Header.Get("Age") != "0",
+				}
+			}
+		}
+	}
+}
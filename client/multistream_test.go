@@ -0,0 +1,182 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestDownloadMultiStream(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{})
+	ctx, _, _ := test_utils.TestContext(context.Background(), t)
+
+	content := make([]byte, 5*multiStreamChunkSize/2)
+	for idx := range content {
+		content[idx] = byte(idx % 251)
+	}
+	httpClient := &http.Client{Transport: config.GetTransport()}
+
+	t.Run("parallel-ranges", func(t *testing.T) {
+		rangeRequests := atomic.Int32{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "" {
+				rangeRequests.Add(1)
+			}
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/test/object", nil)
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		var lastDownloaded, lastSize int64
+		_, err = downloadMultiStream(ctx, httpClient, req, dest, 4, func(downloaded, size int64) {
+			lastDownloaded, lastSize = downloaded, size
+		})
+		require.NoError(t, err)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(content, downloaded))
+		assert.Equal(t, int32(3), rangeRequests.Load())
+		assert.Equal(t, int64(len(content)), lastDownloaded)
+		assert.Equal(t, int64(len(content)), lastSize)
+	})
+
+	t.Run("object-changed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.Header().Set("ETag", `"v1"`)
+			} else {
+				w.Header().Set("ETag", `"v2"`)
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/test/object", nil)
+		require.NoError(t, err)
+
+		_, err = downloadMultiStream(ctx, httpClient, req, filepath.Join(t.TempDir(), "object"), 4, func(int64, int64) {})
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errMultiStreamUnsupported)
+	})
+
+	t.Run("fallback-without-range-support", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(content)
+			}
+		}))
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, serverURL.String(), nil)
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		_, err = downloadMultiStream(ctx, httpClient, req, dest, 4, func(int64, int64) {})
+		assert.ErrorIs(t, err, errMultiStreamUnsupported)
+		_, err = os.Stat(dest)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		viper.Set("Client.DownloadStreams", 4)
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.NoError(t, err)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(content, downloaded))
+	})
+}
+
+// An endless source of zeros, to serve large objects without holding them in memory
+type zeroReaderAt struct{}
+
+func (zeroReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	for idx := range p {
+		p[idx] = 0
+	}
+	return len(p), nil
+}
+
+// Delay every window of bytes written by the round-trip time, as a TCP connection does over
+// a high-latency link
+type latencyWriter struct {
+	http.ResponseWriter
+	rtt     time.Duration
+	window  int
+	pending int
+}
+
+func (lw *latencyWriter) Write(p []byte) (int, error) {
+	lw.pending += len(p)
+	for lw.pending >= lw.window {
+		time.Sleep(lw.rtt)
+		lw.pending -= lw.window
+	}
+	return lw.ResponseWriter.Write(p)
+}
+
+// Benchmark the download of a >1GB object over a simulated link with a 20ms round-trip time and
+// a 1MiB window, i.e. ~50MB/s per stream
+func BenchmarkDownloadMultiStream(b *testing.B) {
+	const size = 1100 * 1024 * 1024
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(&latencyWriter{ResponseWriter: w, rtt: 20 * time.Millisecond, window: 1024 * 1024}, r, "", time.Time{}, io.NewSectionReader(zeroReaderAt{}, 0, size))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL + "/test/object")
+	require.NoError(b, err)
+	dest := filepath.Join(b.TempDir(), "object")
+
+	for _, streams := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("streams-%d", streams), func(b *testing.B) {
+			viper.Set("Client.DownloadStreams", streams)
+			b.Cleanup(func() {
+				viper.Set("Client.DownloadStreams", 1)
+			})
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				_, _, _, _, _, err := downloadHTTP(context.Background(), nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/opensaucerer/grab/v3"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The progress marker of a partially downloaded object, kept next to the destination file
+// until the download succeeds. It records the version of the object the partial file belongs to
+// so that a later attempt, possibly against another cache, only resumes the download if the object
+// hasn't changed
+type downloadProgressMarker struct {
+	Endpoint     string `json:"endpoint"`
+	ObjectPath   string `json:"objectPath"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// The path of the progress marker of the destination file
+func progressMarkerPath(dest string) string {
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".pelican-resume")
+}
+
+// Read the progress marker of the destination file. Returns nil if there is no valid marker
+func readProgressMarker(dest string) *downloadProgressMarker {
+	contents, err := os.ReadFile(progressMarkerPath(dest))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Debugf("Failed to read the progress marker of %s: %v", dest, err)
+		}
+		return nil
+	}
+	marker := downloadProgressMarker{}
+	if err := json.Unmarshal(contents, &marker); err != nil {
+		log.Debugf("Ignoring the corrupt progress marker of %s: %v", dest, err)
+		return nil
+	}
+	return &marker
+}
+
+func writeProgressMarker(dest string, marker downloadProgressMarker) error {
+	contents, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(progressMarkerPath(dest), contents, 0600)
+}
+
+func removeProgressMarker(dest string) {
+	if err := os.Remove(progressMarkerPath(dest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Debugf("Failed to remove the progress marker of %s: %v", dest, err)
+	}
+}
+
+// Create the progress marker of the object of the given size from the response headers of the endpoint.
+// Returns nil if the response has no validator to tell whether the object changed, in which case it can't be resumed
+func newProgressMarker(endpoint string, objectPath string, size int64, header http.Header) *downloadProgressMarker {
+	marker := &downloadProgressMarker{
+		Endpoint:     endpoint,
+		ObjectPath:   objectPath,
+		Size:         size,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if marker.ETag == "" && marker.LastModified == "" {
+		return nil
+	}
+	return marker
+}
+
+// Check if the current marker describes the same version of the object as the marker of the partial download.
+// ETags are specific to the server, so a download failing over to another cache is only resumed if the size
+// and the modification time of the object match
+func (marker *downloadProgressMarker) matches(current *downloadProgressMarker) bool {
+	if current == nil || current.ObjectPath != marker.ObjectPath || current.Size != marker.Size {
+		return false
+	}
+	if current.Endpoint == marker.Endpoint {
+		return current.ETag == marker.ETag && current.LastModified == marker.LastModified
+	}
+	return marker.LastModified != "" && current.LastModified == marker.LastModified
+}
+
+// Get the number of bytes of the partial download at dest that a later attempt may resume from
+func partialDownloadSize(dest string) int64 {
+	if readProgressMarker(dest) == nil {
+		return 0
+	}
+	if fi, err := os.Stat(dest); err == nil && fi.Mode().IsRegular() {
+		return fi.Size()
+	}
+	return 0
+}
+
+// Check if the partial download at dest can be resumed: a progress marker and a non-empty partial
+// file must exist, the server must advertise byte-range support, and the object must not have changed
+// since the partial download started
+func canResumeDownload(httpClient *http.Client, req *http.Request, objectPath string, dest string) bool {
+	marker := readProgressMarker(dest)
+	if marker == nil || partialDownloadSize(dest) == 0 {
+		return false
+	}
+
+	headReq := req.Clone(req.Context())
+	headReq.Method = http.MethodHead
+	headResp, err := httpClient.Do(headReq)
+	if err != nil {
+		log.Debugf("Failed to check whether the download of %s can be resumed: %v", objectPath, err)
+		return false
+	}
+	headResp.Body.Close()
+	if headResp.StatusCode != http.StatusOK {
+		log.Debugf("Not resuming the download of %s: the HEAD request returned status code %d", objectPath, headResp.StatusCode)
+		return false
+	}
+	if headResp.Header.Get("Accept-Ranges") != "bytes" {
+		log.Debugf("Not resuming the download of %s: the server doesn't support range requests", objectPath)
+		return false
+	}
+	if !marker.matches(newProgressMarker(req.URL.Host, objectPath, headResp.ContentLength, headResp.Header)) {
+		log.Infof("The object %s changed since the partial download; restarting the download from scratch", objectPath)
+		return false
+	}
+	return true
+}
+
+// Prepare the download request to resume the partial download at dest if possible; otherwise any
+// existing file is overwritten. The progress marker is written once the response headers arrive and
+// must be removed by the caller when the download succeeds
+func setupResumableDownload(httpClient *http.Client, req *grab.Request, objectPath string, dest string) {
+	endpoint := req.HTTPRequest.URL.Host
+	if canResumeDownload(httpClient, req.HTTPRequest, objectPath, dest) {
+		log.Debugf("Resuming the partial download of %s to %s", objectPath, dest)
+	} else {
+		req.NoResume = true
+		removeProgressMarker(dest)
+	}
+
+	req.BeforeCopy = func(resp *grab.Response) error {
+		if resp.DidResume {
+			// The object may change between the HEAD request and the resumed GET request
+			marker := readProgressMarker(resp.Filename)
+			current := newProgressMarker(endpoint, objectPath, resp.Size(), resp.HTTPResponse.Header)
+			if resp.HTTPResponse.StatusCode != http.StatusPartialContent || marker == nil || !marker.matches(current) {
+				removeProgressMarker(resp.Filename)
+				return errors.Errorf("the object %s changed while resuming the download", objectPath)
+			}
+			return nil
+		}
+		if marker := newProgressMarker(endpoint, objectPath, resp.Size(), resp.HTTPResponse.Header); marker != nil {
+			if err := writeProgressMarker(resp.Filename, *marker); err != nil {
+				log.Debugf("Failed to write the progress marker of %s; the download can't be resumed: %v", resp.Filename, err)
+			}
+		}
+		return nil
+	}
+}
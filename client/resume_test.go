@@ -0,0 +1,223 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+// A server for a single object that drops the connection halfway through the first GET request
+type flakyObjectServer struct {
+	mutex        sync.Mutex
+	content      []byte
+	etag         string
+	dropped      bool
+	rangeHeaders []string
+}
+
+func (s *flakyObjectServer) setObject(content []byte, etag string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.content = content
+	s.etag = etag
+}
+
+func (s *flakyObjectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	content, etag := s.content, s.etag
+	drop := r.Method == http.MethodGet && !s.dropped
+	if r.Method == http.MethodGet {
+		s.dropped = true
+		s.rangeHeaders = append(s.rangeHeaders, r.Header.Get("Range"))
+	}
+	s.mutex.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if drop {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content[:len(content)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+func (s *flakyObjectServer) getRangeHeaders() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.rangeHeaders
+}
+
+func TestResumeDownload(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{})
+	ctx, _, _ := test_utils.TestContext(context.Background(), t)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	changedContent := bytes.Repeat([]byte("fedcba9876543210"), 64*1024)
+
+	t.Run("resume-after-connection-drop", func(t *testing.T) {
+		objServer := &flakyObjectServer{}
+		objServer.setObject(content, `"v1"`)
+		server := httptest.NewServer(objServer)
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.Error(t, err)
+		fi, err := os.Stat(dest)
+		require.NoError(t, err)
+		partialSize := fi.Size()
+		require.Greater(t, partialSize, int64(0))
+		require.NotNil(t, readProgressMarker(dest))
+
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.NoError(t, err)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(content, downloaded))
+		assert.Equal(t, []string{"", "bytes=" + strconv.FormatInt(partialSize, 10) + "-"}, objServer.getRangeHeaders())
+		assert.Nil(t, readProgressMarker(dest))
+	})
+
+	t.Run("restart-when-object-changed", func(t *testing.T) {
+		objServer := &flakyObjectServer{}
+		objServer.setObject(content, `"v1"`)
+		server := httptest.NewServer(objServer)
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.Error(t, err)
+
+		objServer.setObject(changedContent, `"v2"`)
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.NoError(t, err)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(changedContent, downloaded))
+		assert.Equal(t, []string{"", ""}, objServer.getRangeHeaders())
+	})
+
+	t.Run("no-resume-without-marker", func(t *testing.T) {
+		objServer := &flakyObjectServer{dropped: true}
+		objServer.setObject(content, `"v1"`)
+		server := httptest.NewServer(objServer)
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL + "/test/object")
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+		require.NoError(t, os.WriteFile(dest, []byte("stale local file"), 0644))
+
+		_, _, _, _, _, err = downloadHTTP(ctx, nil, nil, transferAttemptDetails{Url: serverURL}, dest, -1, "", "")
+		require.NoError(t, err)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(content, downloaded))
+		assert.Equal(t, []string{""}, objServer.getRangeHeaders())
+	})
+
+	t.Run("failover-to-next-cache", func(t *testing.T) {
+		modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		// Both caches serve the same version of the object with server-specific ETags
+		serveObject := func(w http.ResponseWriter, r *http.Request, etag string) {
+			// The availability check sends an invalid range; serve the whole object instead
+			if r.Header.Get("Range") == "0-0" {
+				r.Header.Del("Range")
+			}
+			w.Header().Set("ETag", etag)
+			http.ServeContent(w, r, "", modTime, bytes.NewReader(content))
+		}
+		mutex := sync.Mutex{}
+		dropped := false
+		failingCache := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			drop := r.Method == http.MethodGet && r.Header.Get("Range") == "" && !dropped
+			dropped = dropped || drop
+			mutex.Unlock()
+			if drop {
+				w.Header().Set("ETag", `"cache-a"`)
+				w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			serveObject(w, r, `"cache-a"`)
+		}))
+		defer failingCache.Close()
+		nextRanges := []string{}
+		nextCache := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet && r.Header.Get("Range") != "0-0" {
+				mutex.Lock()
+				nextRanges = append(nextRanges, r.Header.Get("Range"))
+				mutex.Unlock()
+			}
+			serveObject(w, r, `"cache-b"`)
+		}))
+		defer nextCache.Close()
+		failingURL, err := url.Parse(failingCache.URL)
+		require.NoError(t, err)
+		nextURL, err := url.Parse(nextCache.URL)
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "object")
+
+		transfer := &transferFile{
+			ctx:       ctx,
+			job:       &TransferJob{ctx: ctx},
+			localPath: dest,
+			remoteURL: &url.URL{Path: "/test/object"},
+			attempts:  []transferAttemptDetails{{Url: failingURL}, {Url: nextURL}},
+		}
+		transferResult, err := downloadObject(transfer)
+		require.NoError(t, err)
+		require.NoError(t, transferResult.Error)
+		assert.Len(t, transferResult.Attempts, 2)
+		downloaded, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(content, downloaded))
+		mutex.Lock()
+		defer mutex.Unlock()
+		require.Len(t, nextRanges, 1)
+		assert.Regexp(t, `^bytes=[1-9][0-9]*-$`, nextRanges[0])
+		assert.Nil(t, readProgressMarker(dest))
+	})
+}
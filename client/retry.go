@@ -0,0 +1,88 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/opensaucerer/grab/v3"
+	"github.com/pkg/errors"
+)
+
+// The longest the client waits between two attempts, however many attempts have failed
+const maxRetryBackoff = 30 * time.Second
+
+// Returns true if the error is one that no other cache can fix, such as the object not existing
+// or the token being rejected, so the transfer should fail without trying the remaining candidates
+func isPermanentError(err error) bool {
+	code := 0
+	var sce *StatusCodeError
+	var gsce grab.StatusCodeError
+	var hep *HttpErrResp
+	if errors.As(err, &sce) {
+		code = int(*sce)
+	} else if errors.As(err, &gsce) {
+		code = int(gsce)
+	} else if errors.As(err, &hep) {
+		code = hep.Code
+	}
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// Compute how long to wait before the given retry (1 for the first retry): the base delay doubles
+// with each retry up to maxRetryBackoff, and half of it is randomized so that clients which failed
+// at the same time don't all retry in lockstep
+func retryBackoff(base time.Duration, retry int) time.Duration {
+	if base <= 0 || retry < 1 {
+		return 0
+	}
+	backoff := base
+	for idx := 1; idx < retry && backoff < maxRetryBackoff; idx++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxRetryBackoff)
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// Wait for the backoff before the given retry, returning an error if the context is done first
+// or if its deadline would pass before the wait is over
+func waitRetryBackoff(ctx context.Context, base time.Duration, retry int) error {
+	backoff := retryBackoff(base, retry)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+		return context.DeadlineExceeded
+	}
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,226 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Zero(t, retryBackoff(0, 3))
+	assert.Zero(t, retryBackoff(time.Second, 0))
+
+	for retry, expected := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		10: maxRetryBackoff,
+	} {
+		for idx := 0; idx < 20; idx++ {
+			backoff := retryBackoff(time.Second, retry)
+			assert.GreaterOrEqual(t, backoff, expected/2, "retry %d", retry)
+			assert.LessOrEqual(t, backoff, expected, "retry %d", retry)
+		}
+	}
+}
+
+func TestWaitRetryBackoff(t *testing.T) {
+	assert.NoError(t, waitRetryBackoff(context.Background(), time.Millisecond, 1))
+
+	// A wait that would outlast the deadline fails right away instead of sleeping
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, waitRetryBackoff(ctx, time.Minute, 1), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, waitRetryBackoff(ctx, 0, 1), context.Canceled)
+}
+
+func TestIsPermanentError(t *testing.T) {
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		sce := StatusCodeError(code)
+		assert.True(t, isPermanentError(newTransferAttemptError("cache", "", false, false, &sce)), "code %d", code)
+		assert.True(t, isPermanentError(&HttpErrResp{Code: code}), "code %d", code)
+		assert.False(t, IsRetryable(&sce), "code %d", code)
+	}
+	for _, code := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		sce := StatusCodeError(code)
+		assert.False(t, isPermanentError(&sce), "code %d", code)
+		assert.True(t, IsRetryable(&sce), "code %d", code)
+	}
+	assert.False(t, isPermanentError(&NetworkResetError{}))
+	assert.False(t, isPermanentError(errors.New("connection refused")))
+	assert.False(t, isPermanentError(nil))
+}
+
+// Test that downloads fail over to the next cache after transient errors but give up
+// immediately on errors that no other cache could fix
+func TestDownloadRetry(t *testing.T) {
+	const content = "hello world"
+
+	// Start a cache that answers the availability probe but fails real downloads with the given status;
+	// the returned counter tracks the download attempts it receives
+	newCache := func(t *testing.T, status int) (*url.URL, *atomic.Int32) {
+		var downloads atomic.Int32
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "0-0" {
+				downloads.Add(1)
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+			}
+			_, _ = w.Write([]byte(content))
+		}))
+		t.Cleanup(svr.Close)
+		svrURL, err := url.Parse(svr.URL)
+		require.NoError(t, err)
+		svrURL.Path = "/test/object"
+		return svrURL, &downloads
+	}
+
+	download := func(t *testing.T, caches ...*url.URL) TransferResults {
+		transfer := &transferFile{
+			ctx:       context.Background(),
+			job:       &TransferJob{ctx: context.Background()},
+			localPath: filepath.Join(t.TempDir(), "object"),
+			remoteURL: &url.URL{Path: "/test/object"},
+		}
+		for _, cache := range caches {
+			transfer.attempts = append(transfer.attempts, transferAttemptDetails{Url: cache})
+		}
+		results, err := downloadObject(transfer)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("transient-error-fails-over", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{"Client.RetryBackoffBase": "10ms"})
+		flaky, flakyDownloads := newCache(t, http.StatusServiceUnavailable)
+		healthy, healthyDownloads := newCache(t, http.StatusOK)
+
+		results := download(t, flaky, healthy)
+		require.NoError(t, results.Error)
+		assert.Len(t, results.Attempts, 2)
+		assert.Equal(t, int32(1), flakyDownloads.Load())
+		assert.Equal(t, int32(1), healthyDownloads.Load())
+	})
+
+	t.Run("permanent-error-fails-immediately", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{"Client.RetryBackoffBase": "10ms"})
+		missing, missingDownloads := newCache(t, http.StatusNotFound)
+		healthy, healthyDownloads := newCache(t, http.StatusOK)
+
+		results := download(t, missing, healthy)
+		require.Error(t, results.Error)
+		assert.Len(t, results.Attempts, 1)
+		assert.Equal(t, int32(1), missingDownloads.Load())
+		assert.Zero(t, healthyDownloads.Load())
+		sce := StatusCodeError(http.StatusNotFound)
+		assert.ErrorIs(t, results.Error, &sce)
+	})
+
+	t.Run("retry-duration-caps-attempts", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{
+			"Client.RetryBackoffBase":     "10s",
+			"Client.MaximumRetryDuration": "100ms",
+		})
+		flaky, _ := newCache(t, http.StatusServiceUnavailable)
+		healthy, healthyDownloads := newCache(t, http.StatusOK)
+
+		start := time.Now()
+		results := download(t, flaky, healthy)
+		require.Error(t, results.Error)
+		assert.Len(t, results.Attempts, 1)
+		assert.Zero(t, healthyDownloads.Load())
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("retry-duration-interrupts-attempt", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{
+			"Client.RetryBackoffBase":     "10ms",
+			"Client.MaximumRetryDuration": "500ms",
+		})
+		// A cache that never answers the download
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "0-0" {
+				_, _ = w.Write([]byte(content))
+				return
+			}
+			select {
+			case <-r.Context().Done():
+			case <-time.After(30 * time.Second):
+			}
+		}))
+		t.Cleanup(svr.Close)
+		stuck, err := url.Parse(svr.URL)
+		require.NoError(t, err)
+		stuck.Path = "/test/object"
+		healthy, healthyDownloads := newCache(t, http.StatusOK)
+
+		start := time.Now()
+		results := download(t, stuck, healthy)
+		require.Error(t, results.Error)
+		assert.Len(t, results.Attempts, 1)
+		assert.Zero(t, healthyDownloads.Load())
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("rate-limit-fails-over", func(t *testing.T) {
+		test_utils.InitClient(t, map[string]any{"Client.RetryBackoffBase": "10ms"})
+		limited, limitedDownloads := newCache(t, http.StatusTooManyRequests)
+		healthy, healthyDownloads := newCache(t, http.StatusOK)
+
+		results := download(t, limited, healthy)
+		require.NoError(t, results.Error)
+		assert.Len(t, results.Attempts, 2)
+		assert.Equal(t, int32(1), limitedDownloads.Load())
+		assert.Equal(t, int32(1), healthyDownloads.Load())
+	})
+}
+
+func TestStatusCodeRetryable(t *testing.T) {
+	for code, retryable := range map[int]bool{
+		http.StatusRequestTimeout:      true,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusBadRequest:          false,
+		http.StatusForbidden:           false,
+		http.StatusNotFound:            false,
+	} {
+		sce := StatusCodeError(code)
+		assert.Equal(t, retryable, IsRetryable(&sce), "status code %d", code)
+	}
+}
@@ -0,0 +1,105 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/namespaces"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+// Get a staging name next to the object to upload it to, unique to this upload
+func stagedUploadPath(remotePath string) string {
+	suffix := make([]byte, 8)
+	_, _ = rand.Read(suffix)
+	dir, name := path.Split(remotePath)
+	return path.Join(dir, server_structs.StagedUploadPrefix+name+"."+hex.EncodeToString(suffix))
+}
+
+// Check if the upload to remotePath should go to a staging name first. The namespace must advertise
+// staged uploads, and the token must allow moving the staged object into place, i.e. grant
+// storage.modify on both names; a token scoped to the object alone can only upload it in place
+func shouldStageUpload(namespace namespaces.Namespace, remotePath string, stagedPath string, token string) bool {
+	if !namespace.StagedUploads || token == "" {
+		return false
+	}
+	tok, err := jwt.ParseString(token, jwt.WithVerify(false), jwt.WithValidate(false))
+	if err != nil {
+		return false
+	}
+	scopes := token_scopes.ParseResourceScopeString(tok)
+	matcher := namespaceScopeMatcher(namespace)
+	return matcher.Authorized(scopes, token_scopes.Storage_Modify, remotePath) && matcher.Authorized(scopes, token_scopes.Storage_Modify, stagedPath)
+}
+
+// Move the completed upload from its staging name to the object's name with a WebDAV MOVE,
+// replacing any existing object
+func commitStagedUpload(ctx context.Context, stagedUrl *url.URL, destUrl *url.URL, token string, project string) error {
+	req, err := http.NewRequestWithContext(ctx, "MOVE", stagedUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Destination", destUrl.String())
+	req.Header.Set("Overwrite", "T")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", getUserAgent(project))
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to move the upload into place")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return &HttpErrResp{resp.StatusCode, fmt.Sprintf("Failed to move the upload into place (HTTP status %d)", resp.StatusCode)}
+	}
+	return nil
+}
+
+// Remove the staged object of a failed upload. The origin removes the ones left behind eventually,
+// so this is only best effort
+func removeStagedUpload(stagedUrl *url.URL, token string, project string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, stagedUrl.String(), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", getUserAgent(project))
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Debugln("Failed to remove the staged upload", stagedUrl.Path+":", err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,128 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package client
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+
+	"github.com/pelicanplatform/pelican/namespaces"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func makeScopedToken(t *testing.T, scope string) string {
+	tok, err := jwt.NewBuilder().Claim("scope", scope).Build()
+	require.NoError(t, err)
+	signed, err := jwt.Sign(tok, jwt.WithInsecureNoSignature())
+	require.NoError(t, err)
+	return string(signed)
+}
+
+func TestShouldStageUpload(t *testing.T) {
+	ns := namespaces.Namespace{Path: "/test", StagedUploads: true}
+	stagedPath := stagedUploadPath("/test/dir/object.txt")
+	assert.Equal(t, "/test/dir", path.Dir(stagedPath))
+	assert.True(t, server_structs.IsStagedUploadName(path.Base(stagedPath)))
+	assert.NotEqual(t, stagedPath, stagedUploadPath("/test/dir/object.txt"))
+
+	assert.True(t, shouldStageUpload(ns, "/test/dir/object.txt", stagedPath, makeScopedToken(t, "storage.modify:/dir")))
+	// The token must allow moving the staged object into place
+	assert.False(t, shouldStageUpload(ns, "/test/dir/object.txt", stagedPath, makeScopedToken(t, "storage.modify:/dir/object.txt")))
+	assert.False(t, shouldStageUpload(ns, "/test/dir/object.txt", stagedPath, makeScopedToken(t, "storage.create:/dir")))
+	assert.False(t, shouldStageUpload(ns, "/test/dir/object.txt", stagedPath, "opaque-token"))
+	// Only namespaces advertising staged uploads get them
+	assert.False(t, shouldStageUpload(namespaces.Namespace{Path: "/test"}, "/test/dir/object.txt", stagedPath, makeScopedToken(t, "storage.modify:/")))
+}
+
+// Test that an upload in flight is invisible under the object's name, and that the object
+// appears in full once the upload completes
+func TestStagedUpload(t *testing.T) {
+	test_utils.InitClient(t, map[string]any{
+		"TLSSkipVerify": true,
+	})
+	content := []byte("Hello, world!\n")
+	localPath := filepath.Join(t.TempDir(), "object.txt")
+	require.NoError(t, os.WriteFile(localPath, content, fs.FileMode(0600)))
+
+	ctx := context.Background()
+	davFs := webdav.NewMemFS()
+	require.NoError(t, davFs.Mkdir(ctx, "/test", 0755))
+	dav := &webdav.Handler{FileSystem: davFs, LockSystem: webdav.NewMemLS()}
+	inFlight := make(chan string)
+	resume := make(chan struct{})
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dav.ServeHTTP(w, r)
+		// Hold the response to the upload, as the client only moves the object into place once it completes
+		if r.Method == http.MethodPut {
+			inFlight <- r.URL.Path
+			<-resume
+		}
+	}))
+	defer svr.Close()
+	svrURL, err := url.Parse(svr.URL)
+	require.NoError(t, err)
+	remoteURL := *svrURL
+	remoteURL.Path = "/test/object.txt"
+
+	transfer := &transferFile{
+		ctx:       ctx,
+		job:       &TransferJob{namespace: namespaces.Namespace{Path: "/test", StagedUploads: true}},
+		localPath: localPath,
+		remoteURL: &remoteURL,
+		token:     makeScopedToken(t, "storage.modify:/"),
+		attempts:  []transferAttemptDetails{{Url: svrURL}},
+	}
+	type uploadResult struct {
+		result TransferResults
+		err    error
+	}
+	done := make(chan uploadResult, 1)
+	go func() {
+		result, err := uploadObject(transfer)
+		done <- uploadResult{result, err}
+	}()
+
+	stagedPath := <-inFlight
+	assert.Equal(t, "/test", path.Dir(stagedPath))
+	assert.True(t, server_structs.IsStagedUploadName(path.Base(stagedPath)))
+	_, err = davFs.Stat(ctx, "/test/object.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist, "the object is visible before its upload completes")
+	close(resume)
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.NoError(t, res.result.Error)
+	info, err := davFs.Stat(ctx, "/test/object.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), info.Size())
+	_, err = davFs.Stat(ctx, stagedPath)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the staged upload is left behind")
+}
@@ -0,0 +1,199 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+type (
+	// The subset of the director's server listing (/api/v2.0/director/servers) printed by dump-ads
+	dumpedServerAd struct {
+		Name              string   `json:"name"`
+		Type              string   `json:"type"`
+		URL               string   `json:"url"`
+		WebURL            string   `json:"webUrl"`
+		FromTopology      bool     `json:"fromTopology"`
+		Filtered          bool     `json:"filtered"`
+		HealthStatus      string   `json:"healthStatus"`
+		NamespacePrefixes []string `json:"namespacePrefixes"`
+	}
+)
+
+var (
+	directorDumpAdsCmd = &cobra.Command{
+		Use:   "dump-ads",
+		Short: "Print the origins and caches currently advertised to a director",
+		Long: `Print the origins and caches currently advertised to a director, along with
+the namespaces they serve. The director is located via Federation.DirectorUrl
+or the federation discovery URL, unless given with --director-url.`,
+		RunE:         dumpDirectorAds,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	flagSet := directorDumpAdsCmd.Flags()
+	flagSet.String("director-url", "", "URL of the director to query")
+	flagSet.String("type", "", "Only print servers of the type: origin or cache")
+	flagSet.String("namespace", "", "Only print servers serving a namespace at or under the prefix")
+	flagSet.BoolP("json", "j", false, "Print results in JSON format")
+	flagSet.Int("watch", 0, "Re-poll the director every N seconds; 0 prints once and exits")
+
+	directorCmd.AddCommand(directorDumpAdsCmd)
+}
+
+// Check if any of the namespace prefixes is the prefix or nested under it
+func servesNamespace(prefixes []string, prefix string) bool {
+	prefix = path.Clean("/" + prefix)
+	for _, nsPrefix := range prefixes {
+		nsPrefix = path.Clean("/" + nsPrefix)
+		if nsPrefix == prefix || prefix == "/" || strings.HasPrefix(nsPrefix, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchDirectorAds(ctx context.Context, directorUrl string, serverType string, namespace string) ([]dumpedServerAd, error) {
+	query := ""
+	if serverType != "" {
+		query = "?" + url.Values{"server_type": []string{serverType}}.Encode()
+	}
+	listUrl, err := url.JoinPath(directorUrl, "api", "v2.0", "director", "servers")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct the director's server list URL")
+	}
+	body, err := utils.MakeRequest(ctx, listUrl+query, "GET", nil, nil)
+	if err != nil {
+		// Directors predating the v2.0 server list only serve the deprecated one
+		log.Debugf("Failed to list servers at %s, falling back to the v1.0 server list: %v", listUrl, err)
+		listUrl, err = url.JoinPath(directorUrl, "api", "v1.0", "director_ui", "servers")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to construct the director's server list URL")
+		}
+		body, err = utils.MakeRequest(ctx, listUrl+query, "GET", nil, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list servers from the director: %s", string(body))
+		}
+	}
+	ads := []dumpedServerAd{}
+	if err := json.Unmarshal(body, &ads); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the director's server list")
+	}
+	if namespace == "" {
+		return ads, nil
+	}
+	filtered := []dumpedServerAd{}
+	for _, ad := range ads {
+		if servesNamespace(ad.NamespacePrefixes, namespace) {
+			filtered = append(filtered, ad)
+		}
+	}
+	return filtered, nil
+}
+
+func printDirectorAds(ads []dumpedServerAd, asJSON bool) error {
+	if asJSON {
+		jsonData, err := json.MarshalIndent(ads, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal server ads to JSON format")
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tURL\tHEALTH\tFILTERED\tNAMESPACES")
+	for _, ad := range ads {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", ad.Name, ad.Type, ad.URL, ad.HealthStatus, ad.Filtered, strings.Join(ad.NamespacePrefixes, ","))
+	}
+	return w.Flush()
+}
+
+func dumpDirectorAds(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if directorUrl, _ := cmd.Flags().GetString("director-url"); directorUrl != "" {
+		viper.Set("Federation.DirectorUrl", directorUrl)
+	}
+	if err := config.InitClient(); err != nil {
+		return errors.Wrap(err, "failed to initialize the client")
+	}
+
+	serverType, _ := cmd.Flags().GetString("type")
+	if serverType != "" {
+		sType, err := server_structs.ParseServerType(serverType)
+		if err != nil {
+			return err
+		}
+		serverType = strings.ToLower(string(sType))
+	}
+	namespace, _ := cmd.Flags().GetString("namespace")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	watch, _ := cmd.Flags().GetInt("watch")
+	if watch < 0 {
+		return errors.New("--watch must be a non-negative number of seconds")
+	}
+
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get federation information")
+	}
+	if fedInfo.DirectorEndpoint == "" {
+		return errors.New("no director specified; either give the federation name (-f) or specify the director URL directly (--director-url)")
+	}
+
+	for {
+		ads, err := fetchDirectorAds(ctx, fedInfo.DirectorEndpoint, serverType, namespace)
+		if err != nil {
+			return err
+		}
+		if watch > 0 {
+			fmt.Printf("Servers advertised to %s at %s:\n", fedInfo.DirectorEndpoint, time.Now().Format(time.RFC3339))
+		}
+		if err := printDirectorAds(ads, asJSON); err != nil {
+			return err
+		}
+		if watch == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(watch) * time.Second):
+			fmt.Println()
+		}
+	}
+}
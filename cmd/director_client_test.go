@@ -0,0 +1,68 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServesNamespace(t *testing.T) {
+	prefixes := []string{"/foo/bar", "/baz"}
+	assert.True(t, servesNamespace(prefixes, "/foo/bar"))
+	assert.True(t, servesNamespace(prefixes, "/foo"))
+	assert.True(t, servesNamespace(prefixes, "/baz/"))
+	assert.False(t, servesNamespace(prefixes, "/fo"))
+	assert.False(t, servesNamespace(prefixes, "/foo/bar/qux"))
+	assert.False(t, servesNamespace(nil, "/foo"))
+}
+
+func TestFetchDirectorAds(t *testing.T) {
+	ads := []dumpedServerAd{
+		{Name: "origin-1", Type: "Origin", NamespacePrefixes: []string{"/foo"}},
+		{Name: "origin-2", Type: "Origin", NamespacePrefixes: []string{"/bar"}},
+	}
+	var serverTypeQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1.0/director_ui/servers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		serverTypeQuery = r.URL.Query().Get("server_type")
+		body, _ := json.Marshal(ads)
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	got, err := fetchDirectorAds(context.Background(), ts.URL, "origin", "")
+	require.NoError(t, err)
+	assert.Equal(t, "origin", serverTypeQuery)
+	assert.Len(t, got, 2)
+
+	got, err = fetchDirectorAds(context.Background(), ts.URL, "", "/foo")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "origin-1", got[0].Name)
+}
@@ -0,0 +1,296 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+type (
+	// The director's coverage report (/api/v1.0/director/coverage)
+	coverageReport struct {
+		Namespaces int                 `json:"namespaces"`
+		Gaps       []namespaceCoverage `json:"gaps"`
+		NearGaps   []namespaceCoverage `json:"nearGaps"`
+	}
+
+	namespaceCoverage struct {
+		Prefix         string   `json:"prefix"`
+		Origins        int      `json:"origins"`
+		HealthyOrigins int      `json:"healthyOrigins"`
+		OriginNames    []string `json:"originNames"`
+	}
+
+	// The director's federation topology (/api/v1.0/director/topology)
+	federationTopology struct {
+		Servers    []topologyServer    `json:"servers"`
+		Namespaces []topologyNamespace `json:"namespaces"`
+	}
+
+	topologyServer struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+
+	// A namespace of the topology with the IDs of the servers serving it
+	topologyNamespace struct {
+		Path    string   `json:"path"`
+		Origins []string `json:"origins"`
+		Caches  []string `json:"caches"`
+	}
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+var (
+	directorCoverageCmd = &cobra.Command{
+		Use:   "coverage",
+		Short: "Print the registered namespaces lacking healthy origins",
+		Long: `Print the namespaces approved in the registry that no healthy origin serves,
+along with those served by a single healthy origin. The coverage report is an
+admin API of the director, so a token accepted by the director's admin APIs
+must be given with --token.`,
+		RunE:         printDirectorCoverage,
+		SilenceUsage: true,
+	}
+
+	directorTopologyCmd = &cobra.Command{
+		Use:   "topology",
+		Short: "Print the namespaces of the federation along with the servers serving them",
+		Long: `Print each namespace advertised to the director as a tree of the origins and
+caches serving it. Namespaces served by a single origin or by none are highlighted.`,
+		RunE:         printDirectorTopology,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{directorCoverageCmd, directorTopologyCmd} {
+		flagSet := cmd.Flags()
+		flagSet.String("director-url", "", "URL of the director to query")
+		flagSet.StringP("token", "t", "", "Token file to use for the request")
+		flagSet.BoolP("json", "j", false, "Print results in JSON format")
+		directorCmd.AddCommand(cmd)
+	}
+}
+
+// Whether to highlight the output; only when printing to a terminal, and unless NO_COLOR is set
+func useColor() bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func colorize(text string, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// Initialize the client from the command's flags, returning the director URL and the
+// Authorization headers to query it with
+func setupDirectorQuery(cmd *cobra.Command) (directorUrl string, headers map[string]string, err error) {
+	if directorUrl, _ := cmd.Flags().GetString("director-url"); directorUrl != "" {
+		viper.Set("Federation.DirectorUrl", directorUrl)
+	}
+	if err = config.InitClient(); err != nil {
+		return "", nil, errors.Wrap(err, "failed to initialize the client")
+	}
+	headers = map[string]string{}
+	if tokenLocation, _ := cmd.Flags().GetString("token"); tokenLocation != "" {
+		tokenBytes, err := os.ReadFile(tokenLocation)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to read the token file")
+		}
+		headers["Authorization"] = "Bearer " + strings.TrimSpace(string(tokenBytes))
+	}
+	fedInfo, err := config.GetFederation(cmd.Context())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get federation information")
+	}
+	if fedInfo.DirectorEndpoint == "" {
+		return "", nil, errors.New("no director specified; either give the federation name (-f) or specify the director URL directly (--director-url)")
+	}
+	return fedInfo.DirectorEndpoint, headers, nil
+}
+
+// GET a director API and decode its JSON response into result. The raw response is returned
+// as well, so that it can be printed with --json without dropping any of its fields
+func queryDirectorAPI(ctx context.Context, directorUrl string, apiPath string, headers map[string]string, result interface{}) ([]byte, error) {
+	apiUrl, err := url.JoinPath(directorUrl, "api", "v1.0", "director", apiPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to construct the director's %s URL", apiPath)
+	}
+	body, err := utils.MakeRequest(ctx, apiUrl, "GET", nil, headers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s from the director: %s", apiPath, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the %s from the director", apiPath)
+	}
+	return body, nil
+}
+
+func printJSON(out io.Writer, body []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return errors.Wrap(err, "failed to format the response as JSON")
+	}
+	_, err := fmt.Fprintln(out, indented.String())
+	return err
+}
+
+// Render the coverage report as a table; gaps are highlighted in red and namespaces
+// with a single healthy origin in yellow
+func renderCoverage(out io.Writer, report coverageReport, color bool) error {
+	fmt.Fprintf(out, "%d registered namespaces: %d without a healthy origin, %d with a single healthy origin\n",
+		report.Namespaces, len(report.Gaps), len(report.NearGaps))
+	if len(report.Gaps) == 0 && len(report.NearGaps) == 0 {
+		return nil
+	}
+
+	// Format the table first, so that the color codes don't count towards the column widths
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tSTATUS\tORIGINS\tHEALTHY\tORIGIN NAMES")
+	rowColors := []string{""}
+	for _, coverage := range report.Gaps {
+		fmt.Fprintf(w, "%s\tno healthy origin\t%d\t%d\t%s\n", coverage.Prefix, coverage.Origins, coverage.HealthyOrigins, strings.Join(coverage.OriginNames, ","))
+		rowColors = append(rowColors, ansiRed)
+	}
+	for _, coverage := range report.NearGaps {
+		fmt.Fprintf(w, "%s\tsingle healthy origin\t%d\t%d\t%s\n", coverage.Prefix, coverage.Origins, coverage.HealthyOrigins, strings.Join(coverage.OriginNames, ","))
+		rowColors = append(rowColors, ansiYellow)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	lines := strings.Split(table.String(), "\n")
+	fmt.Fprintln(out)
+	for idx, line := range lines {
+		if line == "" {
+			continue
+		}
+		fmt.Fprintln(out, colorize(line, rowColors[idx], color))
+	}
+	return nil
+}
+
+// Render the topology as a tree of the origins and caches serving each namespace; namespaces
+// without any origin are highlighted in red and those with a single origin in yellow
+func renderTopology(out io.Writer, topology federationTopology, color bool) {
+	names := make(map[string]string, len(topology.Servers))
+	for _, server := range topology.Servers {
+		names[server.ID] = server.Name
+	}
+	serverLabel := func(id string) string {
+		if name := names[id]; name != "" && name != id {
+			return name + " (" + id + ")"
+		}
+		return id
+	}
+
+	for idx, ns := range topology.Namespaces {
+		if idx > 0 {
+			fmt.Fprintln(out)
+		}
+		header := fmt.Sprintf("%s (%d origins, %d caches)", ns.Path, len(ns.Origins), len(ns.Caches))
+		switch len(ns.Origins) {
+		case 0:
+			header = colorize(header+" [no origin]", ansiRed, color)
+		case 1:
+			header = colorize(header+" [single origin]", ansiYellow, color)
+		}
+		fmt.Fprintln(out, header)
+
+		groups := []struct {
+			label   string
+			servers []string
+		}{{"origins", ns.Origins}, {"caches", ns.Caches}}
+		for groupIdx, group := range groups {
+			branch, indent := "├── ", "│   "
+			if groupIdx == len(groups)-1 {
+				branch, indent = "└── ", "    "
+			}
+			fmt.Fprintln(out, branch+group.label)
+			for serverIdx, id := range group.servers {
+				leaf := "├── "
+				if serverIdx == len(group.servers)-1 {
+					leaf = "└── "
+				}
+				fmt.Fprintln(out, indent+leaf+serverLabel(id))
+			}
+		}
+	}
+}
+
+func printDirectorCoverage(cmd *cobra.Command, args []string) error {
+	directorUrl, headers, err := setupDirectorQuery(cmd)
+	if err != nil {
+		return err
+	}
+	if headers["Authorization"] == "" {
+		return errors.New("the coverage report requires a token accepted by the director's admin APIs (--token)")
+	}
+	report := coverageReport{}
+	body, err := queryDirectorAPI(cmd.Context(), directorUrl, "coverage", headers, &report)
+	if err != nil {
+		return err
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return printJSON(os.Stdout, body)
+	}
+	return renderCoverage(os.Stdout, report, useColor())
+}
+
+func printDirectorTopology(cmd *cobra.Command, args []string) error {
+	directorUrl, headers, err := setupDirectorQuery(cmd)
+	if err != nil {
+		return err
+	}
+	topology := federationTopology{}
+	body, err := queryDirectorAPI(cmd.Context(), directorUrl, "topology", headers, &topology)
+	if err != nil {
+		return err
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return printJSON(os.Stdout, body)
+	}
+	renderTopology(os.Stdout, topology, useColor())
+	return nil
+}
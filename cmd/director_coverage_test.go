@@ -0,0 +1,112 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryDirectorAPI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1.0/director/coverage" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":"error","msg":"Authentication required"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"namespaces":2,"gaps":[{"prefix":"/foo","origins":0,"healthyOrigins":0,"originNames":[]}],"nearGaps":[]}`))
+	}))
+	defer ts.Close()
+
+	report := coverageReport{}
+	body, err := queryDirectorAPI(context.Background(), ts.URL, "coverage", map[string]string{"Authorization": "Bearer admin-token"}, &report)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Namespaces)
+	require.Len(t, report.Gaps, 1)
+	assert.Equal(t, "/foo", report.Gaps[0].Prefix)
+
+	var out bytes.Buffer
+	require.NoError(t, printJSON(&out, body))
+	assert.Contains(t, out.String(), `"prefix": "/foo"`)
+
+	_, err = queryDirectorAPI(context.Background(), ts.URL, "coverage", nil, &report)
+	assert.ErrorContains(t, err, "Authentication required")
+}
+
+func TestRenderCoverage(t *testing.T) {
+	report := coverageReport{
+		Namespaces: 3,
+		Gaps:       []namespaceCoverage{{Prefix: "/gap", Origins: 1, OriginNames: []string{"degraded-origin"}}},
+		NearGaps:   []namespaceCoverage{{Prefix: "/near-gap", Origins: 1, HealthyOrigins: 1, OriginNames: []string{"origin-1"}}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, renderCoverage(&out, report, false))
+	assert.Contains(t, out.String(), "3 registered namespaces: 1 without a healthy origin, 1 with a single healthy origin")
+	assert.NotContains(t, out.String(), "\033[")
+	lines := strings.Split(out.String(), "\n")
+	require.GreaterOrEqual(t, len(lines), 5)
+	assert.True(t, strings.HasPrefix(lines[2], "NAMESPACE"))
+	assert.True(t, strings.HasPrefix(lines[3], "/gap "))
+	assert.True(t, strings.HasPrefix(lines[4], "/near-gap "))
+
+	out.Reset()
+	require.NoError(t, renderCoverage(&out, report, true))
+	assert.Contains(t, out.String(), ansiRed+"/gap ")
+	assert.Contains(t, out.String(), ansiYellow+"/near-gap ")
+
+	out.Reset()
+	require.NoError(t, renderCoverage(&out, coverageReport{Namespaces: 1}, true))
+	assert.Equal(t, "1 registered namespaces: 0 without a healthy origin, 0 with a single healthy origin\n", out.String())
+}
+
+func TestRenderTopology(t *testing.T) {
+	topology := federationTopology{
+		Servers: []topologyServer{{ID: "https://origin-1.org", Name: "origin-1", Type: "Origin"}},
+		Namespaces: []topologyNamespace{
+			{Path: "/foo", Origins: []string{"https://origin-1.org"}, Caches: []string{"https://cache-1.org", "https://cache-2.org"}},
+			{Path: "/orphan", Caches: []string{"https://cache-1.org"}},
+		},
+	}
+
+	var out bytes.Buffer
+	renderTopology(&out, topology, true)
+	expected := ansiYellow + "/foo (1 origins, 2 caches) [single origin]" + ansiReset + "\n" +
+		"├── origins\n" +
+		"│   └── origin-1 (https://origin-1.org)\n" +
+		"└── caches\n" +
+		"    ├── https://cache-1.org\n" +
+		"    └── https://cache-2.org\n" +
+		"\n" +
+		ansiRed + "/orphan (0 origins, 1 caches) [no origin]" + ansiReset + "\n" +
+		"├── origins\n" +
+		"└── caches\n" +
+		"    └── https://cache-1.org\n"
+	assert.Equal(t, expected, out.String())
+}
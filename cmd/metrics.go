@@ -0,0 +1,77 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+var (
+	metricsCmd = &cobra.Command{
+		Use:   "metrics",
+		Short: "Inspect the metrics exposed by Pelican servers",
+	}
+
+	metricsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the metrics exposed by Pelican servers",
+		Long: `List the name, type, and labels of the metrics Pelican servers expose on their
+/metrics endpoint, e.g. to write Prometheus scrape configurations, recording rules, or
+dashboards.  Which of the metrics a server reports depends on the services it runs.`,
+		RunE:         listMetricsMain,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	metricsListCmd.Flags().BoolP("json", "j", false, "Print results in JSON format, including the description of each metric")
+	metricsCmd.AddCommand(metricsListCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func renderMetrics(out io.Writer, list []metrics.MetricInfo) error {
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tLABELS")
+	for _, info := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", info.Name, info.Type, strings.Join(info.Labels, ","))
+	}
+	return w.Flush()
+}
+
+func listMetricsMain(cmd *cobra.Command, args []string) error {
+	list := metrics.ListMetrics()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		body, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the list of metrics")
+		}
+		fmt.Fprintln(os.Stdout, string(body))
+		return nil
+	}
+	return renderMetrics(os.Stdout, list)
+}
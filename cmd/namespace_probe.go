@@ -0,0 +1,302 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/pelicanplatform/pelican/config"
+)
+
+type (
+	// The result of one step of a namespace test
+	namespaceTestStep struct {
+		Step     string        `json:"step"`
+		Success  bool          `json:"success"`
+		Skipped  bool          `json:"skipped,omitempty"`
+		Duration time.Duration `json:"duration"`
+		Detail   string        `json:"detail"`
+	}
+
+	namespaceTestOptions struct {
+		directorUrl string
+		registryUrl string
+		token       string
+		doGet       bool
+	}
+)
+
+// The number of bytes fetched by the optional GET of a namespace test
+const namespaceTestGetBytes = 1024
+
+var (
+	namespaceTestCmd = &cobra.Command{
+		Use:   "test {object}",
+		Short: "Test that an object in a namespace can be reached through the federation",
+		Long: `Test that an object in a namespace can be reached through the federation, step by step:
+the director resolves the origins and caches serving the object, the registry knows the
+namespace, and the top cache candidate (or the origin if no cache is available) answers
+a stat of the object. With --get, a small GET of the object is attempted as well.
+
+Each step is reported along with its timing. The command fails if any step fails.`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         testNamespace,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	flagSet := namespaceTestCmd.Flags()
+	flagSet.String("director-url", "", "URL of the director to query")
+	flagSet.StringP("token", "t", "", "Token file to use for the stat and GET of the object")
+	flagSet.Bool("get", false, fmt.Sprintf("Also GET the first %d bytes of the object", namespaceTestGetBytes))
+	flagSet.BoolP("json", "j", false, "Print results in JSON format")
+
+	namespaceCmd.AddCommand(namespaceTestCmd)
+}
+
+// Send a request without following redirects, so the director's redirect can be inspected
+func doNamespaceTestRequest(ctx context.Context, method string, reqUrl string, token string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "pelican-client/"+config.GetVersion())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	client := http.Client{
+		Transport: config.GetTransport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return client.Do(req)
+}
+
+// Get the namespace prefix from the director's X-Pelican-Namespace header
+func parseNamespacePrefix(header string) string {
+	for _, field := range strings.Split(header, ",") {
+		if key, val, found := strings.Cut(strings.TrimSpace(field), "="); found && key == "namespace" {
+			return val
+		}
+	}
+	return ""
+}
+
+// Ask the director where to get the object from, returning the redirect location and the namespace prefix
+func resolveAtDirector(ctx context.Context, step *namespaceTestStep, resolveUrl string) (location string, nsPrefix string) {
+	start := time.Now()
+	resp, err := doNamespaceTestRequest(ctx, http.MethodGet, resolveUrl, "", nil)
+	step.Duration = time.Since(start)
+	if err != nil {
+		step.Detail = fmt.Sprintf("failed to query the director: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	// The director redirects with the status code of Director.RedirectStatusCode
+	if resp.StatusCode != http.StatusFound && resp.StatusCode != http.StatusTemporaryRedirect && resp.StatusCode != http.StatusPermanentRedirect {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		step.Detail = fmt.Sprintf("the director responded with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return
+	}
+	location = resp.Header.Get("Location")
+	nsPrefix = parseNamespacePrefix(resp.Header.Get("X-Pelican-Namespace"))
+	step.Success = true
+	step.Detail = fmt.Sprintf("redirected to %s", location)
+	if links := resp.Header.Get("Link"); links != "" {
+		step.Detail += fmt.Sprintf(" (%d candidates)", len(strings.Split(links, ",")))
+	}
+	return
+}
+
+// Run the steps of a namespace test for the object. Later steps are skipped when
+// the steps they depend on fail
+func runNamespaceTest(ctx context.Context, objectPath string, opts namespaceTestOptions) []namespaceTestStep {
+	objectPath = path.Clean("/" + objectPath)
+	steps := []namespaceTestStep{}
+
+	originStep := namespaceTestStep{Step: "director-origin"}
+	originLocation, nsPrefix := resolveAtDirector(ctx, &originStep, strings.TrimSuffix(opts.directorUrl, "/")+"/api/v1.0/director/origin"+objectPath)
+	steps = append(steps, originStep)
+
+	cacheStep := namespaceTestStep{Step: "director-cache"}
+	cacheLocation, cacheNsPrefix := resolveAtDirector(ctx, &cacheStep, strings.TrimSuffix(opts.directorUrl, "/")+"/api/v1.0/director/object"+objectPath)
+	steps = append(steps, cacheStep)
+	if nsPrefix == "" {
+		nsPrefix = cacheNsPrefix
+	}
+
+	registryStep := namespaceTestStep{Step: "registry"}
+	if opts.registryUrl == "" || nsPrefix == "" {
+		registryStep.Skipped = true
+		registryStep.Detail = "skipped, as the registry or the namespace prefix is unknown"
+	} else {
+		start := time.Now()
+		resp, err := doNamespaceTestRequest(ctx, http.MethodGet, strings.TrimSuffix(opts.registryUrl, "/")+"/api/v1.0/registry"+nsPrefix+"/.well-known/issuer.jwks", "", nil)
+		registryStep.Duration = time.Since(start)
+		if err != nil {
+			registryStep.Detail = fmt.Sprintf("failed to query the registry: %v", err)
+		} else {
+			resp.Body.Close()
+			switch resp.StatusCode {
+			case http.StatusOK:
+				registryStep.Success = true
+				registryStep.Detail = fmt.Sprintf("the namespace %s is registered", nsPrefix)
+			case http.StatusNotFound:
+				registryStep.Detail = fmt.Sprintf("the namespace %s is not registered", nsPrefix)
+			case http.StatusForbidden:
+				registryStep.Detail = fmt.Sprintf("the namespace %s is registered but not approved", nsPrefix)
+			default:
+				registryStep.Detail = fmt.Sprintf("the registry responded with status code %d", resp.StatusCode)
+			}
+		}
+	}
+	steps = append(steps, registryStep)
+
+	candidate := cacheLocation
+	if candidate == "" {
+		candidate = originLocation
+	}
+	statStep := namespaceTestStep{Step: "stat"}
+	getStep := namespaceTestStep{Step: "get"}
+	if candidate == "" {
+		statStep.Skipped = true
+		statStep.Detail = "skipped, as the director returned no server for the object"
+		getStep.Skipped = true
+		getStep.Detail = statStep.Detail
+	} else {
+		start := time.Now()
+		resp, err := doNamespaceTestRequest(ctx, http.MethodHead, candidate, opts.token, nil)
+		statStep.Duration = time.Since(start)
+		if err != nil {
+			statStep.Detail = fmt.Sprintf("failed to stat the object at %s: %v", candidate, err)
+		} else {
+			resp.Body.Close()
+			statStep.Success = resp.StatusCode == http.StatusOK
+			statStep.Detail = fmt.Sprintf("%s responded with status code %d", candidate, resp.StatusCode)
+			if statStep.Success && resp.ContentLength >= 0 {
+				statStep.Detail += fmt.Sprintf(", object size %d bytes", resp.ContentLength)
+			}
+		}
+
+		if !opts.doGet {
+			getStep.Skipped = true
+			getStep.Detail = "skipped; pass --get to fetch the object"
+		} else {
+			start := time.Now()
+			resp, err := doNamespaceTestRequest(ctx, http.MethodGet, candidate, opts.token, map[string]string{"Range": fmt.Sprintf("bytes=0-%d", namespaceTestGetBytes-1)})
+			if err != nil {
+				getStep.Duration = time.Since(start)
+				getStep.Detail = fmt.Sprintf("failed to get the object from %s: %v", candidate, err)
+			} else {
+				received, readErr := io.Copy(io.Discard, io.LimitReader(resp.Body, namespaceTestGetBytes))
+				resp.Body.Close()
+				getStep.Duration = time.Since(start)
+				getStep.Success = readErr == nil && (resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent)
+				getStep.Detail = fmt.Sprintf("%s responded with status code %d and %d bytes", candidate, resp.StatusCode, received)
+				if readErr != nil {
+					getStep.Detail += fmt.Sprintf(": %v", readErr)
+				}
+			}
+		}
+	}
+	return append(steps, statStep, getStep)
+}
+
+func printNamespaceTest(steps []namespaceTestStep, asJSON bool) error {
+	if asJSON {
+		jsonData, err := json.MarshalIndent(steps, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the test results to JSON format")
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tRESULT\tTIME\tDETAIL")
+	for _, step := range steps {
+		result := "FAIL"
+		if step.Skipped {
+			result = "SKIP"
+		} else if step.Success {
+			result = "OK"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", step.Step, result, step.Duration.Round(time.Millisecond), step.Detail)
+	}
+	return w.Flush()
+}
+
+func testNamespace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if directorUrl, _ := cmd.Flags().GetString("director-url"); directorUrl != "" {
+		viper.Set("Federation.DirectorUrl", directorUrl)
+	}
+	if err := config.InitClient(); err != nil {
+		return errors.Wrap(err, "failed to initialize the client")
+	}
+
+	opts := namespaceTestOptions{}
+	opts.doGet, _ = cmd.Flags().GetBool("get")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if tokenLocation, _ := cmd.Flags().GetString("token"); tokenLocation != "" {
+		tokenBytes, err := os.ReadFile(tokenLocation)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the token file")
+		}
+		opts.token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get federation information")
+	}
+	if fedInfo.DirectorEndpoint == "" {
+		return errors.New("no director specified; either give the federation name (-f) or specify the director URL directly (--director-url)")
+	}
+	opts.directorUrl = fedInfo.DirectorEndpoint
+	opts.registryUrl = fedInfo.NamespaceRegistrationEndpoint
+
+	steps := runNamespaceTest(ctx, args[0], opts)
+	if err := printNamespaceTest(steps, asJSON); err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if !step.Success && !step.Skipped {
+			return errors.Errorf("the namespace test of %s failed at the %s step", args[0], step.Step)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNamespacePrefix(t *testing.T) {
+	assert.Equal(t, "/foo", parseNamespacePrefix("namespace=/foo, require-token=false"))
+	assert.Equal(t, "/foo", parseNamespacePrefix("require-token=true, namespace=/foo, collections-url=https://origin.com"))
+	assert.Equal(t, "", parseNamespacePrefix(""))
+}
+
+func TestRunNamespaceTest(t *testing.T) {
+	var ts *httptest.Server
+	redirectStatus := http.StatusTemporaryRedirect
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1.0/director/origin/foo"):
+			w.Header().Set("X-Pelican-Namespace", "namespace=/foo, require-token=false")
+			http.Redirect(w, r, ts.URL+"/origin"+strings.TrimPrefix(r.URL.Path, "/api/v1.0/director/origin"), redirectStatus)
+		case strings.HasPrefix(r.URL.Path, "/api/v1.0/director/object/foo"):
+			w.Header().Set("X-Pelican-Namespace", "namespace=/foo, require-token=false")
+			w.Header().Set("Link", "<https://cache1.com>; rel=\"duplicate\"; pri=1, <https://cache2.com>; rel=\"duplicate\"; pri=2")
+			http.Redirect(w, r, ts.URL+"/cache"+strings.TrimPrefix(r.URL.Path, "/api/v1.0/director/object"), redirectStatus)
+		case strings.HasPrefix(r.URL.Path, "/api/v1.0/director/"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":"error","msg":"No namespace found for path"}`))
+		case r.URL.Path == "/api/v1.0/registry/foo/.well-known/issuer.jwks":
+			_, _ = w.Write([]byte(`{"keys":[]}`))
+		case r.URL.Path == "/cache/foo/bar":
+			if r.Method == http.MethodGet {
+				assert.Equal(t, "bytes=0-1023", r.Header.Get("Range"))
+			}
+			_, _ = w.Write([]byte("hello world"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	stepsByName := func(steps []namespaceTestStep) map[string]namespaceTestStep {
+		byName := map[string]namespaceTestStep{}
+		for _, step := range steps {
+			byName[step.Step] = step
+		}
+		return byName
+	}
+
+	t.Run("reachable-object", func(t *testing.T) {
+		steps := runNamespaceTest(context.Background(), "/foo/bar", namespaceTestOptions{directorUrl: ts.URL, registryUrl: ts.URL, doGet: true})
+		require.Len(t, steps, 5)
+		for _, step := range steps {
+			assert.True(t, step.Success, "step %s failed: %s", step.Step, step.Detail)
+		}
+		assert.Contains(t, stepsByName(steps)["director-cache"].Detail, "2 candidates")
+		assert.Contains(t, stepsByName(steps)["get"].Detail, "11 bytes")
+	})
+
+	t.Run("redirect-status-codes", func(t *testing.T) {
+		t.Cleanup(func() { redirectStatus = http.StatusTemporaryRedirect })
+		for _, redirectStatus = range []int{http.StatusFound, http.StatusPermanentRedirect} {
+			steps := runNamespaceTest(context.Background(), "/foo/bar", namespaceTestOptions{directorUrl: ts.URL, registryUrl: ts.URL, doGet: true})
+			for _, step := range steps {
+				assert.True(t, step.Success, "step %s failed with redirect status %d: %s", step.Step, redirectStatus, step.Detail)
+			}
+		}
+	})
+
+	t.Run("missing-object", func(t *testing.T) {
+		steps := stepsByName(runNamespaceTest(context.Background(), "/foo/missing", namespaceTestOptions{directorUrl: ts.URL, registryUrl: ts.URL}))
+		assert.True(t, steps["director-cache"].Success)
+		assert.False(t, steps["stat"].Success)
+		assert.Contains(t, steps["stat"].Detail, "404")
+		assert.True(t, steps["get"].Skipped)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		steps := stepsByName(runNamespaceTest(context.Background(), "/unknown/bar", namespaceTestOptions{directorUrl: ts.URL, registryUrl: ts.URL}))
+		assert.False(t, steps["director-origin"].Success)
+		assert.Contains(t, steps["director-origin"].Detail, "404")
+		assert.True(t, steps["registry"].Skipped)
+		assert.True(t, steps["stat"].Skipped)
+	})
+}
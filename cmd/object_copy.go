@@ -55,6 +55,11 @@ func init() {
 	flagSet := copyCmd.Flags()
 	flagSet.StringP("cache", "c", "", "Cache to use")
 	flagSet.StringP("token", "t", "", "Token file to use for transfer")
+	flagSet.String("verify", "", "Verify downloads against the checksum advertised by the server: off, warn, or error. Overrides Client.VerifyChecksum")
+	flagSet.Int("streams", 1, "Number of concurrent range requests used to download each large object. Overrides Client.DownloadStreams")
+	flagSet.Int("max-attempts", 0, "Maximum number of caches to try for each download. Overrides Client.MaximumTransferAttempts")
+	flagSet.Duration("retry-backoff", 0, "Base delay before failing over a download to the next cache. Overrides Client.RetryBackoffBase")
+	flagSet.Duration("max-retry-duration", 0, "Maximum time to spend failing over each download across caches. Overrides Client.MaximumRetryDuration")
 	flagSet.BoolP("recursive", "r", false, "Recursively copy a directory.  Forces methods to only be http to get the freshest directory contents")
 	flagSet.StringP("cache-list-name", "n", "xroot", "(Deprecated) Cache list to use, currently either xroot or xroots; may be ignored")
 	flagSet.Lookup("cache-list-name").Hidden = true
@@ -105,6 +110,21 @@ func copyMain(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if err := setChecksumVerifyMode(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
+	if err := setDownloadStreams(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
+	if err := setRetryPolicy(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
 	if val, err := cmd.Flags().GetBool("version"); err == nil && val {
 		config.PrintPelicanVersion(os.Stdout)
 		os.Exit(0)
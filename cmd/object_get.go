@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/pelicanplatform/pelican/client"
 	"github.com/pelicanplatform/pelican/config"
@@ -45,6 +46,12 @@ func init() {
 	flagSet := getCmd.Flags()
 	flagSet.StringP("cache", "c", "", "Cache to use")
 	flagSet.StringP("token", "t", "", "Token file to use for transfer")
+	flagSet.String("verify", "", "Verify downloads against the checksum advertised by the server: off, warn, or error. Overrides Client.VerifyChecksum")
+	flagSet.Int("streams", 1, "Number of concurrent range requests used to download each large object. Overrides Client.DownloadStreams")
+	flagSet.Int("max-attempts", 0, "Maximum number of caches to try for each download. Overrides Client.MaximumTransferAttempts")
+	flagSet.Duration("retry-backoff", 0, "Base delay before failing over a download to the next cache. Overrides Client.RetryBackoffBase")
+	flagSet.Duration("max-retry-duration", 0, "Maximum time to spend failing over each download across caches. Overrides Client.MaximumRetryDuration")
+	flagSet.Bool("conditional", false, "Skip downloading objects unchanged since they were last downloaded to the destination, using their recorded ETag. Overrides Client.ConditionalGet")
 	flagSet.BoolP("recursive", "r", false, "Recursively download a directory.  Forces methods to only be http to get the freshest directory contents")
 	flagSet.StringP("cache-list-name", "n", "xroot", "(Deprecated) Cache list to use, currently either xroot or xroots; may be ignored")
 	flagSet.Lookup("cache-list-name").Hidden = true
@@ -67,6 +74,26 @@ func getMain(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if err := setChecksumVerifyMode(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
+	if err := setDownloadStreams(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
+	if err := setRetryPolicy(cmd); err != nil {
+		log.Errorln(err)
+		os.Exit(1)
+	}
+
+	if cmd.Flags().Changed("conditional") {
+		conditional, _ := cmd.Flags().GetBool("conditional")
+		viper.Set("Client.ConditionalGet", conditional)
+	}
+
 	tokenLocation, _ := cmd.Flags().GetString("token")
 
 	pb := newProgressBar()
@@ -152,3 +179,56 @@ func getMain(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+// Override Client.VerifyChecksum by the --verify flag, if set
+func setChecksumVerifyMode(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("verify") {
+		return nil
+	}
+	verify, _ := cmd.Flags().GetString("verify")
+	mode, err := client.ParseChecksumVerifyMode(verify)
+	if err != nil {
+		return err
+	}
+	viper.Set("Client.VerifyChecksum", string(mode))
+	return nil
+}
+
+// Override Client.DownloadStreams by the --streams flag, if set
+func setDownloadStreams(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("streams") {
+		return nil
+	}
+	streams, _ := cmd.Flags().GetInt("streams")
+	if streams < 1 {
+		return errors.Errorf("invalid number of streams %d; it must be at least 1", streams)
+	}
+	viper.Set("Client.DownloadStreams", streams)
+	return nil
+}
+
+// Override the download retry policy parameters by the corresponding flags, if set
+func setRetryPolicy(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("max-attempts") {
+		attempts, _ := cmd.Flags().GetInt("max-attempts")
+		if attempts < 1 {
+			return errors.Errorf("invalid maximum number of attempts %d; it must be at least 1", attempts)
+		}
+		viper.Set("Client.MaximumTransferAttempts", attempts)
+	}
+	if cmd.Flags().Changed("retry-backoff") {
+		backoff, _ := cmd.Flags().GetDuration("retry-backoff")
+		if backoff < 0 {
+			return errors.Errorf("invalid retry backoff %s; it must not be negative", backoff)
+		}
+		viper.Set("Client.RetryBackoffBase", backoff)
+	}
+	if cmd.Flags().Changed("max-retry-duration") {
+		duration, _ := cmd.Flags().GetDuration("max-retry-duration")
+		if duration < 0 {
+			return errors.Errorf("invalid maximum retry duration %s; it must not be negative", duration)
+		}
+		viper.Set("Client.MaximumRetryDuration", duration)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/registry"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+var (
+	registryImportCmd = &cobra.Command{
+		Use:   "import {namespaces.json}",
+		Short: "Register namespaces in bulk from a JSON file",
+		Long: `Register namespaces in bulk from a JSON file holding a list of namespace records:
+
+  [{"path": "/foo", "pubkey": {"keys": [...]}, "capabilities": {"Read": true}}]
+
+The records are registered in a single transaction as pending namespaces, so either every
+valid record is imported or none is. Records whose prefix is already registered or repeated
+in the file are reported as conflicts without aborting the import. Capabilities are accepted
+for compatibility with exported records but aren't stored, as they are advertised by the
+origins serving the namespace.
+
+A token with the web_ui.admin scope, issued by the registry, is required.`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         importNamespaces,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	flagSet := registryImportCmd.Flags()
+	flagSet.String("registry-url", "", "URL of the registry to import the namespaces into")
+	flagSet.StringP("token", "t", "", "File holding an admin token for the registry")
+	flagSet.Bool("dry-run", false, "Report what would be imported without registering anything")
+
+	registryCmd.AddCommand(registryImportCmd)
+}
+
+// Read the namespace records to import from a JSON file
+func readNamespaceImportFile(filename string) ([]registry.NamespaceImportRecord, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the namespaces file")
+	}
+	records := []registry.NamespaceImportRecord{}
+	if err := json.Unmarshal(contents, &records); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the namespaces file %s", filename)
+	}
+	return records, nil
+}
+
+// Send the namespace records to the registry's import endpoint
+func sendNamespaceImport(ctx context.Context, registryUrl string, token string, req registry.NamespaceImportReq) (*registry.NamespaceImportRes, error) {
+	importUrl, err := url.JoinPath(registryUrl, "api", "v1.0", "registry", "namespaces", "import")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct the import endpoint URL")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the namespaces to import")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, importUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "pelican-client/"+config.GetVersion())
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send the namespaces to the registry")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the registry's response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := server_structs.SimpleApiResp{}
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Msg != "" {
+			return nil, errors.Errorf("the registry responded with status code %d: %s", resp.StatusCode, errResp.Msg)
+		}
+		return nil, errors.Errorf("the registry responded with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	importRes := registry.NamespaceImportRes{}
+	if err := json.Unmarshal(respBody, &importRes); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the registry's response")
+	}
+	return &importRes, nil
+}
+
+func printNamespaceImport(res *registry.NamespaceImportRes) error {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "PREFIX\tSTATUS\tMESSAGE")
+	for _, result := range res.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Prefix, result.Status, result.Message)
+	}
+	return w.Flush()
+}
+
+func importNamespaces(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if registryUrl, _ := cmd.Flags().GetString("registry-url"); registryUrl != "" {
+		viper.Set("Federation.RegistryUrl", registryUrl)
+	}
+	if err := config.InitClient(); err != nil {
+		return errors.Wrap(err, "failed to initialize the client")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	tokenLocation, _ := cmd.Flags().GetString("token")
+	if tokenLocation == "" {
+		return errors.New("an admin token for the registry is required; pass the token file with --token")
+	}
+	tokenBytes, err := os.ReadFile(tokenLocation)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the token file")
+	}
+
+	records, err := readNamespaceImportFile(args[0])
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return errors.Errorf("the namespaces file %s holds no namespaces", args[0])
+	}
+	registryUrl, err := getNamespaceEndpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := sendNamespaceImport(ctx, registryUrl, strings.TrimSpace(string(tokenBytes)), registry.NamespaceImportReq{Namespaces: records, DryRun: dryRun})
+	if err != nil {
+		return err
+	}
+	if err := printNamespaceImport(res); err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range res.Results {
+		if result.Status == registry.ImportStatusConflict || result.Status == registry.ImportStatusInvalid {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d namespaces couldn't be imported", failed, len(res.Results))
+	}
+	return nil
+}
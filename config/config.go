@@ -39,6 +39,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -883,7 +884,11 @@ func setupTranslation() error {
 //
 // Config merging is handled by viper. For more information, see https://pkg.go.dev/github.com/spf13/viper#MergeConfig
 func handleContinuedCfg() error {
-	cfgDirs := viper.GetStringSlice("ConfigLocations")
+	return mergeContinuedCfg(viper.GetViper(), viper.GetStringSlice("ConfigLocations"))
+}
+
+// Merge the files of the cfgDirs into v, in lexicographical order within each directory
+func mergeContinuedCfg(v *viper.Viper, cfgDirs []string) error {
 	if len(cfgDirs) == 0 {
 		return nil
 	}
@@ -922,7 +927,7 @@ func handleContinuedCfg() error {
 			defer fHandle.Close()
 
 			reader := io.Reader(fHandle)
-			err = viper.MergeConfig(reader)
+			err = v.MergeConfig(reader)
 			if err != nil {
 				return errors.Wrapf(err, "failed to merge extra configuration file %s", filepath.Join(cfgDir, file))
 			}
@@ -959,6 +964,48 @@ func setWebConfigOverride(v *viper.Viper, configPath string) error {
 	return nil
 }
 
+// Whether the OSDF defaults apply on top of defaults.yaml
+func useOSDFDefaults() bool {
+	prefix := GetPreferredPrefix()
+	loadOSDF := prefix == OsdfPrefix
+	if os.Getenv("STASH_USE_TOPOLOGY") == "" {
+		loadOSDF = loadOSDF || (prefix == "STASH")
+	}
+	return loadOSDF
+}
+
+// Read the configuration files again into a new viper instance, the way InitConfig
+// did at startup: the defaults, the config file, the files of ConfigLocations, and
+// the changes made through the web UI. Environment variables and command-line
+// flags aren't included. Used to apply config changes without a restart
+func ReadConfigFiles() (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.MergeConfig(strings.NewReader(defaultsYaml)); err != nil {
+		return nil, errors.Wrap(err, "failed to read the default configuration")
+	}
+	if useOSDFDefaults() {
+		if err := v.MergeConfig(strings.NewReader(osdfDefaultsYaml)); err != nil {
+			return nil, errors.Wrap(err, "failed to read the OSDF default configuration")
+		}
+	}
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
+		v.SetConfigFile(configFile)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, errors.Wrapf(err, "failed to read the config file %s", configFile)
+		}
+	}
+	if err := mergeContinuedCfg(v, viper.GetStringSlice("ConfigLocations")); err != nil {
+		return nil, err
+	}
+	if webConfigPath := param.Server_WebConfigFile.GetString(); webConfigPath != "" {
+		if err := setWebConfigOverride(v, webConfigPath); err != nil {
+			return nil, errors.Wrap(err, "failed to read the configuration changes from the web UI")
+		}
+	}
+	return v, nil
+}
+
 func InitConfig() {
 	// Enable BindStruct to allow unmarshal env into a nested struct
 	viper.SetOptions(viper.ExperimentalBindStruct())
@@ -974,12 +1021,7 @@ func InitConfig() {
 		cobra.CheckErr(err)
 	}
 	// 2) Set up osdf.yaml (if needed)
-	prefix := GetPreferredPrefix()
-	loadOSDF := prefix == OsdfPrefix
-	if os.Getenv("STASH_USE_TOPOLOGY") == "" {
-		loadOSDF = loadOSDF || (prefix == "STASH")
-	}
-	if loadOSDF {
+	if useOSDFDefaults() {
 		err := viper.MergeConfig(strings.NewReader(osdfDefaultsYaml))
 		if err != nil {
 			cobra.CheckErr(err)
@@ -1227,6 +1269,7 @@ func InitServer(ctx context.Context, currentServers ServerType) error {
 
 		viper.SetDefault("Origin.Multiuser", true)
 		viper.SetDefault(param.Origin_DbLocation.GetName(), "/var/lib/pelican/origin.sqlite")
+		viper.SetDefault(param.Origin_UploadLogLocation.GetName(), "/var/lib/pelican/origin-uploads")
 		viper.SetDefault("Director.GeoIPLocation", "/var/cache/pelican/maxmind/GeoLite2-City.mmdb")
 		viper.SetDefault("Registry.DbLocation", "/var/lib/pelican/registry.sqlite")
 		// The lotman db will actually take this path and create the lot at /path/.lot/lotman_cpp.sqlite
@@ -1237,6 +1280,7 @@ func InitServer(ctx context.Context, currentServers ServerType) error {
 		viper.SetDefault(param.Origin_GlobusConfigLocation.GetName(), filepath.Join("/run", "pelican", "xrootd", "origin", "globus"))
 	} else {
 		viper.SetDefault(param.Origin_DbLocation.GetName(), filepath.Join(configDir, "origin.sqlite"))
+		viper.SetDefault(param.Origin_UploadLogLocation.GetName(), filepath.Join(configDir, "origin-uploads"))
 		viper.SetDefault("Director.GeoIPLocation", filepath.Join(configDir, "maxmind", "GeoLite2-City.mmdb"))
 		viper.SetDefault("Registry.DbLocation", filepath.Join(configDir, "ns-registry.sqlite"))
 		// Lotdb will live at <configDir>/.lot/lotman_cpp.sqlite
@@ -1493,6 +1537,12 @@ func InitServer(ctx context.Context, currentServers ServerType) error {
 		if maxStatRes < minStatRes {
 			return errors.New("Invalid Director.MinStatResponse and Director.MaxStatResponse. MaxStatResponse is less than MinStatResponse")
 		}
+		// An unset value is the same as the default, 0, which disables the check
+		if minFreeSpaceStr := param.Director_MinOriginFreeSpace.GetString(); minFreeSpaceStr != "" {
+			if minFreeSpace, err := units.ParseStrictBytes(minFreeSpaceStr); err != nil || minFreeSpace < 0 {
+				return errors.Errorf("Invalid Director.MinOriginFreeSpace %q: must be a non-negative size, e.g. 20GB", minFreeSpaceStr)
+			}
+		}
 	}
 
 	if currentServers.IsEnabled(RegistryType) {
@@ -34,6 +34,8 @@ type (
 		Uid        int
 		Gid        int
 		ExtraEnv   []string
+		// If set, called with each line the daemon outputs, after it's logged
+		LogLineHandler func(line string)
 	}
 )
 
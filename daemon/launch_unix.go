@@ -62,7 +62,7 @@ func checkPIDExists(pid int) bool {
 	return err == nil
 }
 
-func ForwardCommandToLogger(ctx context.Context, daemonName string, cmdStdout io.ReadCloser, cmdStderr io.ReadCloser) {
+func ForwardCommandToLogger(ctx context.Context, daemonName string, cmdStdout io.ReadCloser, cmdStderr io.ReadCloser, lineHandler func(string)) {
 	cmd_logger := log.WithFields(log.Fields{"daemon": daemonName})
 	stdout_scanner := bufio.NewScanner(cmdStdout)
 	stdout_lines := make(chan string, 10)
@@ -86,12 +86,18 @@ func ForwardCommandToLogger(ctx context.Context, daemonName string, cmdStdout io
 		case stdout_line, ok := <-stdout_lines:
 			if ok {
 				cmd_logger.Info(stdout_line)
+				if lineHandler != nil {
+					lineHandler(stdout_line)
+				}
 			} else {
 				stdout_lines = nil
 			}
 		case stderr_line, ok := <-stderr_lines:
 			if ok {
 				cmd_logger.Info(stderr_line)
+				if lineHandler != nil {
+					lineHandler(stderr_line)
+				}
 			} else {
 				stderr_lines = nil
 			}
@@ -153,7 +159,7 @@ func (launcher DaemonLauncher) Launch(ctx context.Context) (context.Context, int
 	if err := cmd.Start(); err != nil {
 		return ctx, -1, err
 	}
-	go ForwardCommandToLogger(ctx, launcher.Name(), cmdStdout, cmdStderr)
+	go ForwardCommandToLogger(ctx, launcher.Name(), cmdStdout, cmdStderr, launcher.LogLineHandler)
 
 	ctx_result, cancel := context.WithCancelCause(ctx)
 	go func() {
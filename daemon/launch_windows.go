@@ -36,6 +36,6 @@ func (launcher DaemonLauncher) Launch(ctx context.Context) (context.Context, int
 	return context.Background(), -1, errors.New("launching daemons is not supported on Windows")
 }
 
-func ForwardCommandToLogger(ctx context.Context, daemonName string, cmdStdout io.ReadCloser, cmdStderr io.ReadCloser) {
+func ForwardCommandToLogger(ctx context.Context, daemonName string, cmdStdout io.ReadCloser, cmdStderr io.ReadCloser, lineHandler func(string)) {
 	return
 }
@@ -27,11 +27,16 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 )
 
+// The server_type label of the advertisement fetch timeouts of the topology, which advertises
+// the topology's origins and caches in one fetch
+const topologyFetchLabel = "topology"
+
 // Consolite two ServerAds that share the same ServerAd.URL. For all but the capability fields,
 // the existing ServerAds takes precedence. For capability fields, an OR is made between two ads
 // to get a union of permissions.
@@ -163,16 +168,29 @@ func updateDowntimeFromTopology(excludedNss, includedNss *server_utils.TopologyN
 	log.Infof("The following servers are put in downtime: %#v", filteredServers)
 }
 
-// Populate internal cache with origin/cache ads
+// Count a topology fetch that ran out of Director.AdvertiseFetchTimeout
+func countTopologyFetchTimeout(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(topologyFetchLabel).Inc()
+	}
+}
+
+// Populate internal cache with origin/cache ads.  The topology fetches are bounded by
+// Director.AdvertiseFetchTimeout so that a hanging topology server doesn't stall the periodic
+// reload; the ads from the previous reload are kept until their TTL runs out
 func AdvertiseOSDF(ctx context.Context) error {
-	namespaces, err := server_utils.GetTopologyJSON(ctx, false)
+	fetchCtx, cancel := advertiseFetchContext(ctx)
+	defer cancel()
+	namespaces, err := server_utils.GetTopologyJSON(fetchCtx, false)
 	if err != nil {
+		countTopologyFetchTimeout(err)
 		return errors.Wrapf(err, "Failed to get topology JSON")
 	}
 
 	// Second call to fetch all servers (including servers in downtime)
-	includedNss, err := server_utils.GetTopologyJSON(ctx, true)
+	includedNss, err := server_utils.GetTopologyJSON(fetchCtx, true)
 	if err != nil {
+		countTopologyFetchTimeout(err)
 		return errors.Wrapf(err, "Failed to get topology JSON with server in downtime included (include_downed)")
 	}
 
@@ -25,13 +25,17 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 )
@@ -299,6 +303,44 @@ func TestAdvertiseOSDF(t *testing.T) {
 		assert.Equal(t, server_structs.CacheType, foundAd.Type)
 		assert.Len(t, foundAd.NamespaceAds, 2)
 	})
+
+	t.Run("hanging-topology-times-out", func(t *testing.T) {
+		viper.Reset()
+		serverAds.DeleteAll()
+		defer func() {
+			viper.Reset()
+			serverAds.DeleteAll()
+		}()
+
+		// An ad from the previous reload
+		topoAd := server_structs.ServerAd{Name: "topo-origin", URL: url.URL{Scheme: "https", Host: "topo-origin.com"}, Type: server_structs.OriginType, FromTopology: true}
+		serverAds.Set(topoAd.URL.String(), &server_structs.Advertisement{ServerAd: topoAd}, ttlcache.DefaultTTL)
+
+		unblock := make(chan struct{})
+		hangingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-unblock:
+			case <-r.Context().Done():
+			}
+		}))
+		defer func() {
+			close(unblock)
+			hangingServer.CloseClientConnections()
+			hangingServer.Close()
+		}()
+		viper.Set("Federation.TopologyNamespaceUrl", hangingServer.URL)
+		viper.Set("Director.AdvertiseFetchTimeout", 50*time.Millisecond)
+		before := testutil.ToFloat64(metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(topologyFetchLabel))
+
+		start := time.Now()
+		err := AdvertiseOSDF(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(topologyFetchLabel)))
+		// The ads of the previous reload are kept
+		assert.True(t, serverAds.Has(topoAd.URL.String()))
+	})
 }
 
 func TestFindDownedTopologyCache(t *testing.T) {
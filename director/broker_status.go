@@ -0,0 +1,169 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// The state of the reverse connection tunnel of an origin through its broker
+type brokerTunnelStatus string
+
+const (
+	brokerTunnelConnected    brokerTunnelStatus = "connected"    // The origin polled its broker within Director.BrokerHeartbeatTimeout
+	brokerTunnelDisconnected brokerTunnelStatus = "disconnected" // The origin's last poll is too old, or its broker never received one
+	brokerTunnelUnknown      brokerTunnelStatus = "unknown"      // The broker hasn't reported on the origin yet
+)
+
+const brokerHeartbeatQueryInterval = 15 * time.Second
+
+var (
+	// The last time each origin behind a broker polled its broker, keyed by the origin's URL.
+	// Origins are missing from the map until their broker reported on them; a zero time means
+	// the broker never received a poll from the origin
+	brokerHeartbeats      = map[string]time.Time{}
+	brokerHeartbeatsMutex = sync.RWMutex{}
+
+	// Query a broker for its origin heartbeats; a variable to be mocked in tests
+	queryBrokerHeartbeats = getBrokerHeartbeats
+)
+
+// Get the broker's heartbeat endpoint and the name the origin polls the broker with
+// from the broker URL in the origin's advertisement
+func getBrokerHeartbeatTarget(ad server_structs.ServerAd) (endpoint string, origin string) {
+	brokerUrl := ad.BrokerURL
+	origin = brokerUrl.Query().Get("origin")
+	if origin == "" {
+		origin = ad.WebURL.Hostname()
+	}
+	brokerUrl.Path = "/api/v1.0/broker/heartbeats"
+	brokerUrl.RawQuery = ""
+	return brokerUrl.String(), origin
+}
+
+// Fetch the last time each origin polled the broker at the heartbeat endpoint
+func getBrokerHeartbeats(ctx context.Context, endpoint string) (map[string]time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "pelican-director/"+config.GetVersion())
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d from the broker", resp.StatusCode)
+	}
+	heartbeatsResp := server_structs.BrokerHeartbeatsResp{}
+	if err := json.NewDecoder(resp.Body).Decode(&heartbeatsResp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the broker response")
+	}
+	return heartbeatsResp.Heartbeats, nil
+}
+
+// Query the brokers of all the origins behind one and update their heartbeats. When a broker
+// can't be reached, its origins keep the heartbeats it last reported, so that their tunnels are
+// considered down once those are older than Director.BrokerHeartbeatTimeout
+func updateBrokerHeartbeats(ctx context.Context) {
+	originsByBroker := map[string][]server_structs.ServerAd{}
+	for _, ad := range listAdvertisement([]server_structs.ServerType{server_structs.OriginType}) {
+		if ad.BrokerURL.String() == "" {
+			continue
+		}
+		endpoint, _ := getBrokerHeartbeatTarget(ad.ServerAd)
+		originsByBroker[endpoint] = append(originsByBroker[endpoint], ad.ServerAd)
+	}
+
+	newHeartbeats := map[string]time.Time{}
+	for endpoint, origins := range originsByBroker {
+		heartbeats, err := queryBrokerHeartbeats(ctx, endpoint)
+		if err != nil {
+			log.Warningf("Failed to query the broker at %s for origin heartbeats: %v", endpoint, err)
+			brokerHeartbeatsMutex.RLock()
+			for _, ad := range origins {
+				if lastHeartbeat, ok := brokerHeartbeats[ad.URL.String()]; ok {
+					newHeartbeats[ad.URL.String()] = lastHeartbeat
+				}
+			}
+			brokerHeartbeatsMutex.RUnlock()
+			continue
+		}
+		for _, ad := range origins {
+			_, origin := getBrokerHeartbeatTarget(ad)
+			newHeartbeats[ad.URL.String()] = heartbeats[origin]
+		}
+	}
+
+	brokerHeartbeatsMutex.Lock()
+	defer brokerHeartbeatsMutex.Unlock()
+	brokerHeartbeats = newHeartbeats
+}
+
+// Get the state of the reverse connection tunnel of the origin through its broker, along with
+// the last time the origin polled the broker. Servers not behind a broker are considered connected.
+// An origin its broker reported no poll from is disconnected; one the broker hasn't reported on
+// yet, such as an origin that just advertised, is unknown
+func getBrokerStatus(ad server_structs.ServerAd) (status brokerTunnelStatus, lastHeartbeat time.Time) {
+	if ad.BrokerURL.String() == "" {
+		return brokerTunnelConnected, time.Time{}
+	}
+	brokerHeartbeatsMutex.RLock()
+	defer brokerHeartbeatsMutex.RUnlock()
+	lastHeartbeat, ok := brokerHeartbeats[ad.URL.String()]
+	if !ok {
+		return brokerTunnelUnknown, time.Time{}
+	}
+	if lastHeartbeat.IsZero() || time.Since(lastHeartbeat) >= param.Director_BrokerHeartbeatTimeout.GetDuration() {
+		return brokerTunnelDisconnected, lastHeartbeat
+	}
+	return brokerTunnelConnected, lastHeartbeat
+}
+
+// Periodically query the brokers of origins behind one to learn whether their tunnels are live
+func LaunchBrokerHeartbeatQuery(ctx context.Context, egrp *errgroup.Group) {
+	egrp.Go(func() error {
+		ticker := time.NewTicker(brokerHeartbeatQueryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				queryCtx, cancel := context.WithTimeout(ctx, brokerHeartbeatQueryInterval)
+				updateBrokerHeartbeats(queryCtx)
+				cancel()
+			}
+		}
+	})
+}
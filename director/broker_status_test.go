@@ -0,0 +1,123 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestBrokerStatus(t *testing.T) {
+	viper.Reset()
+	serverAds.DeleteAll()
+	oldQuery := queryBrokerHeartbeats
+	t.Cleanup(func() {
+		queryBrokerHeartbeats = oldQuery
+		brokerHeartbeatsMutex.Lock()
+		brokerHeartbeats = map[string]time.Time{}
+		brokerHeartbeatsMutex.Unlock()
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Set("Director.BrokerHeartbeatTimeout", time.Minute)
+
+	brokerOrigin := func(name, host string) server_structs.ServerAd {
+		ad := mockOriginServerAd
+		ad.Name = name
+		ad.URL = url.URL{Scheme: "https", Host: host}
+		ad.BrokerURL = url.URL{Scheme: "https", Host: "broker.com", Path: "/api/v1.0/broker/reverse", RawQuery: "origin=" + host + "&prefix=%2Ffoo"}
+		return ad
+	}
+	liveOrigin := brokerOrigin("live-origin", "live.com")
+	deadOrigin := brokerOrigin("dead-origin", "dead.com")
+	silentOrigin := brokerOrigin("silent-origin", "silent.com")
+	unknownOrigin := brokerOrigin("unknown-origin", "unknown.com")
+	unknownOrigin.BrokerURL.Host = "unreachable-broker.com"
+	directOrigin := mockOriginServerAd
+	for _, ad := range []server_structs.ServerAd{liveOrigin, deadOrigin, silentOrigin, unknownOrigin, directOrigin} {
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}},
+		}, ttlcache.DefaultTTL)
+	}
+
+	queried := []string{}
+	queryBrokerHeartbeats = func(ctx context.Context, endpoint string) (map[string]time.Time, error) {
+		queried = append(queried, endpoint)
+		if endpoint != "https://broker.com/api/v1.0/broker/heartbeats" {
+			return nil, errors.New("connection refused")
+		}
+		return map[string]time.Time{
+			"live.com": time.Now().Add(-10 * time.Second),
+			"dead.com": time.Now().Add(-5 * time.Minute),
+		}, nil
+	}
+	updateBrokerHeartbeats(context.Background())
+	assert.ElementsMatch(t, []string{
+		"https://broker.com/api/v1.0/broker/heartbeats",
+		"https://unreachable-broker.com/api/v1.0/broker/heartbeats",
+	}, queried)
+
+	status, lastHeartbeat := getBrokerStatus(liveOrigin)
+	assert.Equal(t, brokerTunnelConnected, status)
+	assert.False(t, lastHeartbeat.IsZero())
+	status, lastHeartbeat = getBrokerStatus(deadOrigin)
+	assert.Equal(t, brokerTunnelDisconnected, status)
+	assert.False(t, lastHeartbeat.IsZero())
+	// The broker received no poll from the origin at all
+	status, lastHeartbeat = getBrokerStatus(silentOrigin)
+	assert.Equal(t, brokerTunnelDisconnected, status)
+	assert.True(t, lastHeartbeat.IsZero())
+	// The tunnel state is unknown when the broker can't be reached
+	status, lastHeartbeat = getBrokerStatus(unknownOrigin)
+	assert.Equal(t, brokerTunnelUnknown, status)
+	assert.True(t, lastHeartbeat.IsZero())
+	status, _ = getBrokerStatus(directOrigin)
+	assert.Equal(t, brokerTunnelConnected, status)
+
+	// Origins whose tunnel is down aren't redirected to
+	_, originAds, _ := getAdsForPath("/foo/bar")
+	names := []string{}
+	for _, ad := range originAds {
+		names = append(names, ad.Name)
+	}
+	assert.ElementsMatch(t, []string{liveOrigin.Name, unknownOrigin.Name, directOrigin.Name}, names)
+
+	// A broker that can't be reached anymore keeps its last heartbeats
+	queryBrokerHeartbeats = func(ctx context.Context, endpoint string) (map[string]time.Time, error) {
+		return nil, errors.New("connection refused")
+	}
+	updateBrokerHeartbeats(context.Background())
+	status, lastHeartbeat = getBrokerStatus(liveOrigin)
+	assert.Equal(t, brokerTunnelConnected, status)
+	assert.False(t, lastHeartbeat.IsZero())
+	status, _ = getBrokerStatus(deadOrigin)
+	assert.Equal(t, brokerTunnelDisconnected, status)
+	status, _ = getBrokerStatus(silentOrigin)
+	assert.Equal(t, brokerTunnelDisconnected, status)
+}
@@ -20,6 +20,7 @@ package director
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/netip"
@@ -49,6 +50,9 @@ const (
 var (
 	// The in-memory cache of xrootd server advertisement, with the key being ServerAd.URL.String()
 	serverAds = ttlcache.New(ttlcache.WithTTL[string, *server_structs.Advertisement](15 * time.Minute))
+	// Server advertisements that expired from serverAds but are kept for Director.StaleAdGracePeriod,
+	// with the key being ServerAd.URL.String(). They are used only when no fresh origin serves a request path
+	staleServerAds = ttlcache.New(ttlcache.WithTTL[string, *server_structs.Advertisement](15 * time.Minute))
 	// The map holds servers that are disabled, with the key being the ServerAd.Name
 	// The map should be idenpendent of serverAds as we want to persist this change in-memory, regardless of the presence of the serverAd
 	filteredServers      = map[string]filterType{}
@@ -72,6 +76,58 @@ func (f filterType) String() string {
 	}
 }
 
+// Get the machine-readable name of the filter type, e.g. "temporarily_disabled"
+func (f filterType) Name() string {
+	switch f {
+	case permFiltered:
+		return "permanently_disabled"
+	case tempFiltered:
+		return "temporarily_disabled"
+	case topoFiltered:
+		return "topology_disabled"
+	case tempAllowed:
+		return "temporarily_enabled"
+	case "":
+		return ""
+	default:
+		return "unknown"
+	}
+}
+
+// Get the filter type by its machine-readable name, the inverse of Name
+func filterTypeFromName(name string) (filterType, bool) {
+	for _, ft := range []filterType{permFiltered, tempFiltered, topoFiltered, tempAllowed} {
+		if ft.Name() == name {
+			return ft, true
+		}
+	}
+	return "", false
+}
+
+// Marshal the filter type as its machine-readable name instead of the internal identifier
+func (f filterType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Name())
+}
+
+// Get the TTL of a server advertisement in serverAds by the server type, falling back to
+// Director.AdvertisementTTL if the type-specific TTL is not set
+func getServerAdTTL(sType server_structs.ServerType) time.Duration {
+	switch sType {
+	case server_structs.OriginType:
+		if ttl := reloadableDuration(param.Director_OriginAdTTL); ttl > 0 {
+			return ttl
+		}
+	case server_structs.CacheType:
+		if ttl := reloadableDuration(param.Director_CacheAdTTL); ttl > 0 {
+			return ttl
+		}
+	}
+	if ttl := reloadableDuration(param.Director_AdvertisementTTL); ttl > 0 {
+		return ttl
+	}
+	return ttlcache.DefaultTTL
+}
+
 // recordAd does following for an incoming ServerAd and []NamespaceAdV2 pair:
 //
 //  1. Update the ServerAd by setting server location and updating server topology attribute
@@ -85,7 +141,7 @@ func recordAd(ctx context.Context, sAd server_structs.ServerAd, namespaceAds *[]
 	}
 
 	if sAd.URL.String() == "" {
-		log.Errorf("The URL of the serverAd %#v is empty. Cannot set the TTL cache.", sAd)
+		log.Errorf("Cannot set the TTL cache for the advertisement of %s: its URL is empty", (&server_structs.Advertisement{ServerAd: sAd, NamespaceAds: *namespaceAds}).Summary())
 		return
 	}
 	// Since servers from topology always use http, while servers from Pelican always use https
@@ -125,11 +181,30 @@ func recordAd(ctx context.Context, sAd server_structs.ServerAd, namespaceAds *[]
 		}
 	}
 
-	ad := server_structs.Advertisement{ServerAd: sAd, NamespaceAds: *namespaceAds}
+	// Servers are tracked by URL, but filters and the web UI refer to them by name, so let the admin know
+	// when distinct servers share a name
+	if others := listOtherServersWithName(sAd.Name, sAd.URL); len(others) > 0 {
+		log.Warningf("The %s server at %s advertised with the name %s, which is also used by the servers at %v. "+
+			"The servers are tracked separately, but filtering a server by name applies to all of them",
+			string(sAd.Type), sAd.URL.String(), sAd.Name, others)
+	}
+
+	lastAdvertised := time.Now()
+	sAd.LastAdvertised = &lastAdvertised
+	// Cache a copy so the caller's namespace ads aren't shared with the cached ad
+	ad := (&server_structs.Advertisement{ServerAd: sAd, NamespaceAds: *namespaceAds}).Clone()
 
-	customTTL := param.Director_AdvertisementTTL.GetDuration()
+	// A server that's new or serves other namespaces may have objects the director recently found missing
+	if existing == nil || namespacesChanged(existing.Value().NamespaceAds, ad.NamespaceAds) {
+		invalidateNotFoundNamespaces(ad.NamespaceAds)
+	}
 
-	serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: sAd, NamespaceAds: *namespaceAds}, customTTL)
+	inserted := !serverAds.Has(ad.URL.String())
+	serverAds.Set(ad.URL.String(), ad, getServerAdTTL(sAd.Type))
+	serverAdsIndex.set(ad.URL.String(), ad, inserted)
+	log.Debugf("Recorded the advertisement of %s", ad.Summary())
+	// The server is advertising again, so its stale ad is obsolete
+	staleServerAds.Delete(ad.URL.String())
 
 	// Prepare `stat` call utilities for all servers regardless of its source (topology or Pelican)
 	func() {
@@ -296,28 +371,73 @@ func matchesPrefix(reqPath string, namespaceAds []server_structs.NamespaceAdV2)
 	return best
 }
 
+// Find the namespace serving the request path, as well as the origins and caches serving it.
+// Returns no namespace or servers if the namespace is blocked by the namespace allowlist or denylist
 func getAdsForPath(reqPath string) (originNamespace server_structs.NamespaceAdV2, originAds []server_structs.ServerAd, cacheAds []server_structs.ServerAd) {
-	skippedServers := []server_structs.ServerAd{}
+	originNamespace, originAds, cacheAds = getAdsForPathUnfiltered(reqPath)
+	if _, blocked := checkNamespaceFilter(originNamespace.Path); blocked {
+		log.Debugf("getAdsForPath: the namespace %s serving the request path %s is blocked by the namespace filters", originNamespace.Path, reqPath)
+		return server_structs.NamespaceAdV2{}, nil, nil
+	}
+	return
+}
 
+// Find the namespace serving the request path, as well as the origins and caches serving it,
+// regardless of the namespace allowlist and denylist
+func getAdsForPathUnfiltered(reqPath string) (originNamespace server_structs.NamespaceAdV2, originAds []server_structs.ServerAd, cacheAds []server_structs.ServerAd) {
 	// Clean the path, but re-append a trailing / to deal with some namespaces
 	// from topo that have a trailing /
 	reqPath = path.Clean(reqPath)
 	reqPath += "/"
 
+	ads := getServerAdsForPath(reqPath)
+	originNamespace, originAds, cacheAds = matchAdsForPath(reqPath, ads)
+	if len(originAds) > 0 || staleServerAds.Len() == 0 {
+		return
+	}
+
+	// No fresh origin serves the path. As a last resort, fall back to the stale ads of
+	// servers that stopped advertising within Director.StaleAdGracePeriod
+	staleAds := []*server_structs.Advertisement{}
+	for _, item := range staleServerAds.Items() {
+		staleAds = append(staleAds, item.Value())
+	}
+	staleNamespace, staleOriginAds, staleCacheAds := matchAdsForPath(reqPath, append(ads, staleAds...))
+	if len(staleOriginAds) > 0 {
+		log.Debugf("getAdsForPath: no fresh origin serves the request path %s; falling back to stale origin ads: %s",
+			reqPath, server_structs.ServerAdsToServerNameURL(staleOriginAds))
+		return staleNamespace, staleOriginAds, staleCacheAds
+	}
+	return
+}
+
+// Find the best matching namespace for the cleaned request path among the ads, as well as
+// the origins and caches serving it
+func matchAdsForPath(reqPath string, ads []*server_structs.Advertisement) (originNamespace server_structs.NamespaceAdV2, originAds []server_structs.ServerAd, cacheAds []server_structs.ServerAd) {
+	skippedServers := []server_structs.ServerAd{}
+
 	// Iterate through all of the server ads. For each "item", the key
 	// is the server ad itself (either cache or origin), and the value
 	// is a slice of namespace prefixes are supported by that server
 	var best *server_structs.NamespaceAdV2
-	ads := []*server_structs.Advertisement{}
-	for _, item := range serverAds.Items() {
-		ads = append(ads, item.Value())
-	}
 	sortedAds := sortServerAdsByTopo(ads)
 	for _, ad := range sortedAds {
 		if filtered, ft := checkFilter(ad.Name); filtered {
 			log.Debugf("Skipping %s server %s as it's in the filtered server list with type %s", ad.Type, ad.Name, ft)
 			continue
 		}
+		if draining, _ := checkDraining(ad.URL.String()); draining {
+			log.Debugf("Skipping %s server %s as it's being drained", ad.Type, ad.Name)
+			continue
+		}
+		if status, _ := getBrokerStatus(ad.ServerAd); status == brokerTunnelDisconnected {
+			log.Debugf("Skipping %s server %s as its reverse connection tunnel through the broker is down", ad.Type, ad.Name)
+			continue
+		}
+		if getHealthStatus(ad.ServerAd) == HealthStatusError {
+			log.Debugf("Skipping %s server %s as it's down according to the director tests", ad.Type, ad.Name)
+			continue
+		}
 		if ns := matchesPrefix(reqPath, ad.NamespaceAds); ns != nil {
 			if best == nil || len(ns.Path) > len(best.Path) {
 				best = ns
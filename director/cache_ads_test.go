@@ -20,6 +20,7 @@ package director
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
 	"testing"
 	"time"
@@ -465,3 +466,122 @@ func TestRecordAd(t *testing.T) {
 		assert.True(t, ok)
 	})
 }
+
+func TestGetServerAdTTL(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+
+	viper.Reset()
+	viper.Set("Director.AdvertisementTTL", 15*time.Minute)
+	assert.Equal(t, 15*time.Minute, getServerAdTTL(server_structs.OriginType))
+	assert.Equal(t, 15*time.Minute, getServerAdTTL(server_structs.CacheType))
+
+	viper.Set("Director.OriginAdTTL", 30*time.Minute)
+	assert.Equal(t, 30*time.Minute, getServerAdTTL(server_structs.OriginType))
+	assert.Equal(t, 15*time.Minute, getServerAdTTL(server_structs.CacheType))
+
+	viper.Set("Director.CacheAdTTL", 5*time.Minute)
+	assert.Equal(t, 30*time.Minute, getServerAdTTL(server_structs.OriginType))
+	assert.Equal(t, 5*time.Minute, getServerAdTTL(server_structs.CacheType))
+
+	viper.Reset()
+	assert.Equal(t, ttlcache.DefaultTTL, getServerAdTTL(server_structs.OriginType))
+}
+
+func TestEvictionCleansTempFilteredServer(t *testing.T) {
+	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
+	egrp, ctx := errgroup.WithContext(shutdownCtx)
+	LaunchTTLCache(ctx, egrp)
+
+	filteredServersMutex.Lock()
+	tmp := filteredServers
+	filteredServers = map[string]filterType{"temp-filtered": tempFiltered, "perm-filtered": permFiltered}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		shutdownCancel()
+		assert.NoError(t, egrp.Wait())
+		filteredServersMutex.Lock()
+		filteredServers = tmp
+		filteredServersMutex.Unlock()
+	})
+
+	tempAd := server_structs.ServerAd{Name: "temp-filtered", Type: server_structs.OriginType, URL: url.URL{Host: "temp.server.org"}}
+	permAd := server_structs.ServerAd{Name: "perm-filtered", Type: server_structs.OriginType, URL: url.URL{Host: "perm.server.org"}}
+	serverAds.DeleteAll()
+	serverAds.Set(tempAd.URL.String(), &server_structs.Advertisement{ServerAd: tempAd}, 500*time.Millisecond)
+	serverAds.Set(permAd.URL.String(), &server_structs.Advertisement{ServerAd: permAd}, 500*time.Millisecond)
+
+	time.Sleep(time.Second)
+	serverAds.DeleteExpired()
+
+	require.Eventually(t, func() bool {
+		filtered, _ := checkFilter("temp-filtered")
+		return !filtered
+	}, 3*time.Second, 50*time.Millisecond, "Temporarily filtered server is still filtered after its ad is evicted")
+
+	filtered, ft := checkFilter("perm-filtered")
+	assert.True(t, filtered)
+	assert.Equal(t, permFiltered, ft)
+}
+
+func TestGetAdsForPathWithStaleAds(t *testing.T) {
+	serverAds.DeleteAll()
+	staleServerAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		staleServerAds.DeleteAll()
+	})
+
+	nsAds := []server_structs.NamespaceAdV2{{Path: "/stale/ns"}}
+	staleOrigin := server_structs.ServerAd{Name: "stale-origin", Type: server_structs.OriginType, URL: url.URL{Scheme: "https", Host: "stale.org"}}
+	freshOrigin := server_structs.ServerAd{Name: "fresh-origin", Type: server_structs.OriginType, URL: url.URL{Scheme: "https", Host: "fresh.org"}}
+
+	staleServerAds.Set(staleOrigin.URL.String(), &server_structs.Advertisement{ServerAd: staleOrigin, NamespaceAds: nsAds}, time.Minute)
+
+	t.Run("fall-back-to-stale-without-fresh-ads", func(t *testing.T) {
+		nsAd, oAds, _ := getAdsForPath("/stale/ns/foo")
+		assert.Equal(t, "/stale/ns", nsAd.Path)
+		require.Len(t, oAds, 1)
+		assert.Equal(t, "stale-origin", oAds[0].Name)
+	})
+
+	t.Run("prefer-fresh-ads", func(t *testing.T) {
+		serverAds.Set(freshOrigin.URL.String(), &server_structs.Advertisement{ServerAd: freshOrigin, NamespaceAds: nsAds}, time.Minute)
+		nsAd, oAds, _ := getAdsForPath("/stale/ns/foo")
+		assert.Equal(t, "/stale/ns", nsAd.Path)
+		require.Len(t, oAds, 1)
+		assert.Equal(t, "fresh-origin", oAds[0].Name)
+	})
+
+	t.Run("readvertised-server-is-no-longer-stale", func(t *testing.T) {
+		serverAds.DeleteAll()
+		recordAd(context.Background(), staleOrigin, &nsAds)
+		assert.False(t, staleServerAds.Has(staleOrigin.URL.String()))
+	})
+}
+
+func TestFilterTypeSerialization(t *testing.T) {
+	testCases := []struct {
+		ft       filterType
+		expected string
+	}{
+		{permFiltered, `"permanently_disabled"`},
+		{tempFiltered, `"temporarily_disabled"`},
+		{topoFiltered, `"topology_disabled"`},
+		{tempAllowed, `"temporarily_enabled"`},
+		{"", `""`},
+	}
+	for _, tc := range testCases {
+		bytes, err := json.Marshal(tc.ft)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, string(bytes))
+	}
+
+	// Marshaling works for filter types nested in maps as well
+	bytes, err := json.Marshal(map[string]filterType{"my-origin": tempFiltered})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"my-origin": "temporarily_disabled"}`, string(bytes))
+
+	assert.Equal(t, "Temporarily disabled via the admin website", tempFiltered.String())
+}
@@ -0,0 +1,54 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Mark the caches without the object as having it when one of their parent caches does,
+// as they can fetch it from their warm parent instead of the origin. The availability map
+// is keyed by the caches' URLs and is followed up through every level of the hierarchy
+func applyCacheHierarchy(cacheAds []server_structs.ServerAd, availability map[string]bool) {
+	// Parent caches are matched by host, as caches may advertise their parents' URLs
+	// with a different scheme or path than the parents advertise themselves
+	warmHosts := make(map[string]bool, len(cacheAds))
+	for _, cAd := range cacheAds {
+		if availability[cAd.URL.String()] {
+			warmHosts[cAd.URL.Host] = true
+		}
+	}
+	// Repeat until a pass marks no more caches, so that hierarchies of any depth are covered
+	for changed := true; changed; {
+		changed = false
+		for _, cAd := range cacheAds {
+			if availability[cAd.URL.String()] {
+				continue
+			}
+			for _, upstream := range cAd.UpstreamCaches {
+				if warmHosts[upstream.Host] {
+					availability[cAd.URL.String()] = true
+					warmHosts[cAd.URL.Host] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
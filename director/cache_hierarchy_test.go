@@ -0,0 +1,119 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestCacheHierarchy(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+	})
+
+	// A parent cache with two leaf caches pulling from it, one of them advertising
+	// the parent's URL with a different scheme
+	parent := server_structs.ServerAd{
+		Name: "parent",
+		URL:  url.URL{Scheme: "https", Host: "parent.example.com:8443"},
+		Type: server_structs.CacheType,
+	}
+	leafA := server_structs.ServerAd{
+		Name:           "leaf-a",
+		URL:            url.URL{Scheme: "https", Host: "leaf-a.example.com:8443"},
+		Type:           server_structs.CacheType,
+		UpstreamCaches: []url.URL{{Scheme: "https", Host: "parent.example.com:8443"}},
+	}
+	leafB := server_structs.ServerAd{
+		Name:           "leaf-b",
+		URL:            url.URL{Scheme: "https", Host: "leaf-b.example.com:8443"},
+		Type:           server_structs.CacheType,
+		UpstreamCaches: []url.URL{{Scheme: "http", Host: "parent.example.com:8443"}},
+	}
+
+	t.Run("topology-shows-two-level-tree", func(t *testing.T) {
+		orphan := server_structs.ServerAd{
+			Name:           "orphan",
+			URL:            url.URL{Scheme: "https", Host: "orphan.example.com:8443"},
+			Type:           server_structs.CacheType,
+			UpstreamCaches: []url.URL{{Scheme: "https", Host: "unknown.example.com:8443"}},
+		}
+		for _, ad := range []server_structs.ServerAd{parent, leafA, leafB, orphan} {
+			serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad}, ttlcache.DefaultTTL)
+		}
+
+		graph := buildFederationGraph()
+		upstreams := map[string][]string{}
+		for _, server := range graph.Servers {
+			upstreams[server.Name] = server.UpstreamCaches
+		}
+		require.Len(t, upstreams, 4)
+		assert.Empty(t, upstreams["parent"])
+		assert.Equal(t, []string{parent.URL.String()}, upstreams["leaf-a"])
+		assert.Equal(t, []string{parent.URL.String()}, upstreams["leaf-b"])
+		// Parents the director doesn't know are kept as advertised
+		assert.Equal(t, []string{"https://unknown.example.com:8443"}, upstreams["orphan"])
+	})
+
+	t.Run("warm-parent-makes-leaf-available", func(t *testing.T) {
+		grandchild := server_structs.ServerAd{
+			Name:           "grandchild",
+			URL:            url.URL{Scheme: "https", Host: "grandchild.example.com:8443"},
+			Type:           server_structs.CacheType,
+			UpstreamCaches: []url.URL{leafA.URL},
+		}
+		cacheAds := []server_structs.ServerAd{grandchild, leafA, leafB, parent}
+
+		// Nothing is warm, so nothing changes
+		availability := map[string]bool{}
+		applyCacheHierarchy(cacheAds, availability)
+		assert.Empty(t, availability)
+
+		// The parent is warm, so are the caches below it at every level
+		availability = map[string]bool{parent.URL.String(): true}
+		applyCacheHierarchy(cacheAds, availability)
+		assert.Equal(t, map[string]bool{
+			parent.URL.String():     true,
+			leafA.URL.String():      true,
+			leafB.URL.String():      true,
+			grandchild.URL.String(): true,
+		}, availability)
+
+		// A warm leaf doesn't make its parent or sibling available
+		availability = map[string]bool{leafA.URL.String(): true}
+		applyCacheHierarchy(cacheAds, availability)
+		assert.Equal(t, map[string]bool{
+			leafA.URL.String():      true,
+			grandchild.URL.String(): true,
+		}, availability)
+
+		// Parents that aren't candidates, e.g. because they're filtered, aren't followed
+		availability = map[string]bool{parent.URL.String(): true}
+		applyCacheHierarchy([]server_structs.ServerAd{leafA, leafB}, availability)
+		assert.Equal(t, map[string]bool{parent.URL.String(): true}, availability)
+	})
+}
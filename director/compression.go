@@ -0,0 +1,230 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// A gin.ResponseWriter compressing the response body once it grows past minCompressSize.
+	// Smaller bodies are buffered and sent as is, with an exact Content-Length
+	compressWriter struct {
+		gin.ResponseWriter
+		encoding    string         // The negotiated content encoding, "gzip" or "zstd"
+		buf         []byte         // The start of the body, until the compression decision is made
+		encoder     io.WriteCloser // Set once the body is being compressed
+		passthrough bool           // Set once the body is being sent uncompressed
+	}
+)
+
+// Responses smaller than this are not worth compressing; the framing overhead and
+// the CPU time outweigh the few bytes saved
+const minCompressSize = 1024
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(io.Discard)
+		},
+	}
+	zstdEncoderPool = sync.Pool{
+		New: func() any {
+			// A single goroutine per encoder, as each one handles a single response
+			encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return encoder
+		},
+	}
+)
+
+// Pick the content encoding for the response from the request's Accept-Encoding header,
+// returning "" if the client accepts neither zstd nor gzip. zstd is preferred when the
+// client weighs both equally, as it compresses better at a lower CPU cost
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, field := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(field), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, val, found := strings.Cut(strings.TrimSpace(param), "="); found && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range []string{"zstd", "gzip"} {
+		quality, ok := qualities[coding]
+		if !ok {
+			if quality, ok = qualities["*"]; !ok {
+				continue
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == "zstd" {
+		encoder := zstdEncoderPool.Get().(*zstd.Encoder)
+		encoder.Reset(w)
+		return &pooledEncoder{WriteCloser: encoder, release: func() { zstdEncoderPool.Put(encoder) }}
+	}
+	gzipWriter := gzipWriterPool.Get().(*gzip.Writer)
+	gzipWriter.Reset(w)
+	return &pooledEncoder{WriteCloser: gzipWriter, release: func() { gzipWriterPool.Put(gzipWriter) }}
+}
+
+// An encoder returned to its pool once closed
+type pooledEncoder struct {
+	io.WriteCloser
+	release func()
+}
+
+func (encoder *pooledEncoder) Close() error {
+	err := encoder.WriteCloser.Close()
+	encoder.release()
+	return err
+}
+
+func (encoder *pooledEncoder) Flush() error {
+	if flusher, ok := encoder.WriteCloser.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	} else if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= minCompressSize {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Send the buffered start of the body and whatever follows through the encoder. Bodies
+// the handler encoded itself or that aren't allowed for the status are sent as is
+func (w *compressWriter) startCompression() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.Status() == http.StatusNoContent || w.Status() == http.StatusNotModified {
+		return w.startPassthrough()
+	}
+	header.Set("Content-Encoding", w.encoding)
+	// The length of the compressed body is unknown until it has been streamed
+	header.Del("Content-Length")
+	w.encoder = newEncoder(w.encoding, w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// Send the buffered start of the body and whatever follows uncompressed
+func (w *compressWriter) startPassthrough() error {
+	w.passthrough = true
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Streaming handlers flushing the response before it grows past minCompressSize
+// get it uncompressed, so that the data they flush reaches the client right away
+func (w *compressWriter) Flush() {
+	if w.encoder == nil && !w.passthrough {
+		if err := w.startPassthrough(); err != nil {
+			log.Debugln("Failed to write the response body:", err)
+		}
+	}
+	if flusher, ok := w.encoder.(*pooledEncoder); ok {
+		if err := flusher.Flush(); err != nil {
+			log.Debugln("Failed to flush the compressed response body:", err)
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Finish the response once the handler returns: close the encoder of a compressed body,
+// or send a body too small to compress along with its exact length
+func (w *compressWriter) finish() error {
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	if !w.passthrough && len(w.buf) > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(w.buf)))
+		return w.startPassthrough()
+	}
+	return nil
+}
+
+// A gin middleware compressing the response with zstd or gzip, as negotiated with the
+// request's Accept-Encoding header, when the body is at least minCompressSize bytes.
+// Meant for the director's read-heavy JSON endpoints, whose responses grow with the
+// size of the federation
+func compressionMiddleware(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiateEncoding(ctx.GetHeader("Accept-Encoding"))
+	if encoding == "" || ctx.Request.Method == http.MethodHead {
+		ctx.Next()
+		return
+	}
+
+	writer := &compressWriter{ResponseWriter: ctx.Writer, encoding: encoding}
+	ctx.Writer = writer
+	defer func() {
+		ctx.Writer = writer.ResponseWriter
+	}()
+	ctx.Next()
+	if err := writer.finish(); err != nil {
+		log.Debugf("Failed to finish the %s-compressed response to %s: %v", encoding, ctx.Request.URL.Path, err)
+	}
+}
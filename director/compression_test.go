@@ -0,0 +1,176 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "", negotiateEncoding(""))
+	assert.Equal(t, "", negotiateEncoding("identity, br"))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip, deflate"))
+	assert.Equal(t, "zstd", negotiateEncoding("gzip, zstd"))
+	assert.Equal(t, "gzip", negotiateEncoding("zstd;q=0.5, gzip"))
+	assert.Equal(t, "gzip", negotiateEncoding("zstd;q=0, GZIP;q=0.1"))
+	assert.Equal(t, "zstd", negotiateEncoding("*"))
+	assert.Equal(t, "", negotiateEncoding("*;q=0"))
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	largeBody := strings.Repeat(`{"name":"server","url":"https://server.example.com"},`, 100)
+	router := gin.New()
+	router.GET("/large", compressionMiddleware, func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, largeBody)
+	})
+	router.GET("/small", compressionMiddleware, func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "small")
+	})
+	router.GET("/chunked", compressionMiddleware, func(ctx *gin.Context) {
+		// Written in pieces smaller than the threshold, which add up past it
+		for idx := 0; idx < 10; idx++ {
+			_, _ = ctx.Writer.WriteString(largeBody[:len(largeBody)/10])
+		}
+	})
+
+	get := func(path string, acceptEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		w := get("/large", "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Empty(t, w.Header().Get("Content-Length"))
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, largeBody, string(body))
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		w := get("/large", "gzip, zstd")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "zstd", w.Header().Get("Content-Encoding"))
+		decoder, err := zstd.NewReader(w.Body)
+		require.NoError(t, err)
+		defer decoder.Close()
+		body, err := io.ReadAll(decoder)
+		require.NoError(t, err)
+		assert.Equal(t, largeBody, string(body))
+	})
+
+	t.Run("chunked-writes", func(t *testing.T) {
+		w := get("/chunked", "gzip")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat(largeBody[:len(largeBody)/10], 10), string(body))
+	})
+
+	t.Run("below-threshold", func(t *testing.T) {
+		w := get("/small", "gzip, zstd")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "5", w.Header().Get("Content-Length"))
+		assert.Equal(t, "small", w.Body.String())
+	})
+
+	t.Run("no-accept-encoding", func(t *testing.T) {
+		w := get("/large", "")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+}
+
+// Report the size of the /servers response of a federation with 5000 servers,
+// with each of the content encodings supported by the director
+func BenchmarkListServersCompression(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	serverAds.DeleteAll()
+	b.Cleanup(serverAds.DeleteAll)
+	for idx := 0; idx < 5000; idx++ {
+		serverAd := server_structs.ServerAd{
+			Name:      fmt.Sprintf("server-%d", idx),
+			URL:       url.URL{Scheme: "https", Host: fmt.Sprintf("server-%d.example.com:8443", idx)},
+			WebURL:    url.URL{Scheme: "https", Host: fmt.Sprintf("server-%d.example.com:8444", idx)},
+			Type:      server_structs.CacheType,
+			Latitude:  float64(idx%180) - 90,
+			Longitude: float64(idx%360) - 180,
+		}
+		if idx%2 == 0 {
+			serverAd.Type = server_structs.OriginType
+		}
+		serverAds.Set(serverAd.URL.String(), &server_structs.Advertisement{
+			ServerAd:     serverAd,
+			NamespaceAds: mockNamespaceAds(2, serverAd.Name),
+		}, ttlcache.DefaultTTL)
+	}
+
+	router := gin.New()
+	router.GET("/servers", compressionMiddleware, listServers)
+	identitySize := 0
+	for _, encoding := range []string{"identity", "gzip", "zstd"} {
+		b.Run(encoding, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodGet, "/servers", nil)
+				req.Header.Set("Accept-Encoding", encoding)
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status code %d", w.Code)
+				}
+				size = w.Body.Len()
+			}
+			if encoding == "identity" {
+				identitySize = size
+			}
+			b.ReportMetric(float64(size), "bytes/response")
+			if identitySize > 0 {
+				b.ReportMetric(float64(identitySize)/float64(size), "ratio")
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type configReloadResp struct {
+	// The reloadable settings whose value changed
+	Changed []string `json:"changed"`
+	// The settings whose value in the config differs from the running one but that only apply at startup
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// The director settings a reload applies.  Each is read when it's used, so a new value takes
+// effect on the next request; the advertisement TTLs apply to the advertisements received after
+// the reload.  All other settings, such as Director.DefaultResponse, Director.GeoIPLocation,
+// Director.ClientSites, Director.AdvertiseRateLimit, and Director.OriginCacheHealthTestInterval,
+// require a restart
+var reloadableDirectorParams = []string{
+	// Server selection
+	param.Director_CacheSelectionStrategy.GetName(),
+	param.Director_CacheSortMethod.GetName(),
+	param.Director_PreferSameSiteCaches.GetName(),
+	param.Director_UseCacheHierarchy.GetName(),
+	param.Director_MinOriginFreeSpace.GetName(),
+	param.Director_CachesPullFromCaches.GetName(),
+	param.Director_EnableStickySessions.GetName(),
+	param.Director_StickySessionTTL.GetName(),
+	param.Director_OriginInFlightWindow.GetName(),
+	param.Director_HealthTestDegradedLatency.GetName(),
+	param.Director_HealthFailureThreshold.GetName(),
+	param.Director_FailurePenalty.GetName(),
+	param.Director_FailurePenaltyWindow.GetName(),
+	// Redirect responses
+	param.Director_RedirectStatusCode.GetName(),
+	param.Director_MetalinkHeaders.GetName(),
+	param.Director_CacheResponseHostnames.GetName(),
+	param.Director_OriginResponseHostnames.GetName(),
+	param.Director_SupportContactEmail.GetName(),
+	param.Director_SupportContactUrl.GetName(),
+	// TTLs and thresholds
+	param.Director_AdvertisementTTL.GetName(),
+	param.Director_OriginAdTTL.GetName(),
+	param.Director_CacheAdTTL.GetName(),
+	param.Director_StaleAdGracePeriod.GetName(),
+	param.Director_RegistryBreakerThreshold.GetName(),
+	param.Director_RegistryBreakerCooldown.GetName(),
+	// Object stats
+	param.Director_EnableStat.GetName(),
+	param.Director_MinStatResponse.GetName(),
+	param.Director_MaxStatResponse.GetName(),
+	param.Director_StatTimeout.GetName(),
+	param.Director_NotFoundCacheTTL.GetName(),
+	// Allow and deny lists
+	param.Director_NamespaceAllowlist.GetName(),
+	param.Director_NamespaceDenylist.GetName(),
+}
+
+// The reloadable director settings applied by the last reload, keyed by the lowercase parameter name
+type directorConfigSnapshot struct {
+	values map[string]interface{}
+}
+
+var (
+	// Only one reload at a time, so that concurrent reloads can't interleave their settings
+	configReloadMutex sync.Mutex
+
+	// The settings of the last reload.  A reload replaces the whole snapshot rather than writing to the
+	// global config, which request handlers read concurrently; nil until the first reload
+	reloadedDirectorConfig atomic.Pointer[directorConfigSnapshot]
+)
+
+// Look up a reloadable setting in the last reload's snapshot
+func reloadedValue(name string) (value interface{}, ok bool) {
+	snapshot := reloadedDirectorConfig.Load()
+	if snapshot == nil {
+		return nil, false
+	}
+	value, ok = snapshot.values[strings.ToLower(name)]
+	return
+}
+
+// The current value of a reloadable director setting: the reloaded one if any, otherwise the one read at startup
+func reloadableString(p param.StringParam) string {
+	if value, ok := reloadedValue(p.GetName()); ok {
+		return cast.ToString(value)
+	}
+	return p.GetString()
+}
+
+func reloadableBool(p param.BoolParam) bool {
+	if value, ok := reloadedValue(p.GetName()); ok {
+		return cast.ToBool(value)
+	}
+	return p.GetBool()
+}
+
+func reloadableInt(p param.IntParam) int {
+	if value, ok := reloadedValue(p.GetName()); ok {
+		return cast.ToInt(value)
+	}
+	return p.GetInt()
+}
+
+func reloadableDuration(p param.DurationParam) time.Duration {
+	if value, ok := reloadedValue(p.GetName()); ok {
+		return cast.ToDuration(value)
+	}
+	return p.GetDuration()
+}
+
+func reloadableStringSlice(p param.StringSliceParam) []string {
+	if value, ok := reloadedValue(p.GetName()); ok {
+		return cast.ToStringSlice(value)
+	}
+	return p.GetStringSlice()
+}
+
+// The current value of a reloadable director setting by name, for comparing against the config files
+func currentReloadableValue(name string) interface{} {
+	if value, ok := reloadedValue(name); ok {
+		return value
+	}
+	return viper.Get(name)
+}
+
+// Whether an environment variable sets the parameter, in which case it takes precedence over the config file
+func setByEnv(name string) bool {
+	_, ok := os.LookupEnv("PELICAN_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_")))
+	return ok
+}
+
+// Format a config value for comparison, where an unset value is the same as the empty one
+// the reload sets in its place
+func configValueString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Check the reloadable settings of the new config before applying any of them
+func validateReloadedConfig(fileConfig *viper.Viper) error {
+	strategy := fileConfig.GetString(param.Director_CacheSelectionStrategy.GetName())
+	if !slices.Contains([]string{"", "geo", "consistent-hash"}, strategy) {
+		return errors.Errorf("invalid Director.CacheSelectionStrategy %q; valid strategies are 'geo' and 'consistent-hash'", strategy)
+	}
+	sortMethod := fileConfig.GetString(param.Director_CacheSortMethod.GetName())
+	if !slices.Contains([]string{"distance", "distanceAndLoad", "random"}, sortMethod) {
+		return errors.Errorf("invalid Director.CacheSortMethod %q; valid methods are 'distance', 'distanceAndLoad', and 'random'", sortMethod)
+	}
+	if code := fileConfig.GetInt(param.Director_RedirectStatusCode.GetName()); !IsValidRedirectStatusCode(code) {
+		return errors.Errorf("invalid Director.RedirectStatusCode %d; valid codes are 302, 307, and 308", code)
+	}
+	if err := validateNamespacePatterns(fileConfig.GetStringSlice(param.Director_NamespaceAllowlist.GetName())); err != nil {
+		return errors.Wrap(err, "invalid Director.NamespaceAllowlist")
+	}
+	if err := validateNamespacePatterns(fileConfig.GetStringSlice(param.Director_NamespaceDenylist.GetName())); err != nil {
+		return errors.Wrap(err, "invalid Director.NamespaceDenylist")
+	}
+	return nil
+}
+
+// Re-read the config files and apply the reloadable director settings, keeping the server
+// advertisements and health state.  Nothing is applied if any of the new settings is invalid
+func reloadDirectorConfig() (resp configReloadResp, err error) {
+	configReloadMutex.Lock()
+	defer configReloadMutex.Unlock()
+
+	fileConfig, err := config.ReadConfigFiles()
+	if err != nil {
+		return
+	}
+	if err = validateReloadedConfig(fileConfig); err != nil {
+		return
+	}
+
+	resp = configReloadResp{Changed: []string{}, RestartRequired: []string{}}
+	snapshot := &directorConfigSnapshot{values: make(map[string]interface{}, len(reloadableDirectorParams))}
+	for _, name := range reloadableDirectorParams {
+		if setByEnv(name) {
+			continue
+		}
+		newValue := fileConfig.Get(name)
+		if newValue == nil {
+			// Removed from the config and without a default.  Leaving it out of the snapshot would fall
+			// back to the value read at startup; the empty string reads as the zero value of any type
+			newValue = ""
+		}
+		if configValueString(newValue) != configValueString(currentReloadableValue(name)) {
+			resp.Changed = append(resp.Changed, name)
+		}
+		snapshot.values[strings.ToLower(name)] = newValue
+	}
+	reloadedDirectorConfig.Store(snapshot)
+	if err = ConfigNamespaceFilters(); err != nil {
+		return
+	}
+
+	for _, key := range fileConfig.AllKeys() {
+		if !strings.HasPrefix(key, "director.") || setByEnv(key) {
+			continue
+		}
+		if slices.ContainsFunc(reloadableDirectorParams, func(name string) bool { return strings.EqualFold(name, key) }) {
+			continue
+		}
+		if configValueString(fileConfig.Get(key)) != configValueString(viper.Get(key)) {
+			resp.RestartRequired = append(resp.RestartRequired, key)
+		}
+	}
+	sort.Strings(resp.RestartRequired)
+
+	if len(resp.RestartRequired) > 0 {
+		log.Warningf("Reloaded the director config; the changes to %s require a restart", strings.Join(resp.RestartRequired, ", "))
+	} else {
+		log.Infof("Reloaded the director config; changed settings: %v", resp.Changed)
+	}
+	return
+}
+
+// A gin route handler re-reading the config files and applying the reloadable director settings
+func handleReloadDirectorConfig(ginCtx *gin.Context) {
+	resp, err := reloadDirectorConfig()
+	if err != nil {
+		log.Errorln("Failed to reload the director config:", err)
+		ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to reload the config; no settings were changed: " + err.Error(),
+		})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,161 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestReloadDirectorConfig(t *testing.T) {
+	viper.Reset()
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		viper.Reset()
+		reloadedDirectorConfig.Store(nil)
+		serverAds.DeleteAll()
+		require.NoError(t, ConfigNamespaceFilters())
+	})
+
+	configDir := t.TempDir()
+	writeConfig := func(contents string) {
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "pelican.yaml"), []byte(contents), 0644))
+	}
+	writeConfig(`
+Director:
+  CacheSelectionStrategy: consistent-hash
+  PreferSameSiteCaches: false
+`)
+	viper.Set("ConfigDir", configDir)
+	config.InitConfig()
+	require.Equal(t, "consistent-hash", viper.GetString("Director.CacheSelectionStrategy"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1.0/director/config/reload", handleReloadDirectorConfig)
+	reload := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1.0/director/config/reload", nil))
+		return recorder
+	}
+
+	cacheA := server_structs.ServerAd{Name: "cache-a", URL: url.URL{Scheme: "https", Host: "cache-a.com"}, Type: server_structs.CacheType, Site: "site-a"}
+	cacheB := server_structs.ServerAd{Name: "cache-b", URL: url.URL{Scheme: "https", Host: "cache-b.com"}, Type: server_structs.CacheType, Site: "site-b"}
+	ads := []server_structs.ServerAd{cacheA, cacheB}
+	serverAds.Set(cacheA.URL.String(), &server_structs.Advertisement{ServerAd: cacheA}, ttlcache.DefaultTTL)
+
+	clientAddr := netip.MustParseAddr("192.0.2.10")
+	before, err := selectServers("/foo/object", clientAddr, "", ads, nil)
+	require.NoError(t, err)
+	// The site of the cache ranked last by the hash, so that preferring it changes the order
+	lastSite := before[1].Site
+
+	t.Run("changed-setting-applies-to-next-selection", func(t *testing.T) {
+		sorted, err := selectServers("/foo/object", clientAddr, lastSite, ads, nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, lastSite, sorted[0].Site)
+
+		writeConfig(`
+Director:
+  CacheSelectionStrategy: consistent-hash
+  PreferSameSiteCaches: true
+`)
+		recorder := reload()
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		resp := configReloadResp{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"Director.PreferSameSiteCaches"}, resp.Changed)
+		assert.Empty(t, resp.RestartRequired)
+
+		sorted, err = selectServers("/foo/object", clientAddr, lastSite, ads, nil)
+		require.NoError(t, err)
+		assert.Equal(t, lastSite, sorted[0].Site)
+
+		// The advertisements survive the reload
+		assert.True(t, serverAds.Has(cacheA.URL.String()))
+		// The reload doesn't write to the global config, which request handlers read concurrently
+		assert.False(t, viper.GetBool("Director.PreferSameSiteCaches"))
+	})
+
+	t.Run("reload-concurrent-with-selection", func(t *testing.T) {
+		// Run with -race: selections read the settings while reloads replace them
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					_, err := selectServers("/foo/object", clientAddr, lastSite, ads, nil)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, reload().Code)
+		}
+		wg.Wait()
+	})
+
+	t.Run("invalid-config-changes-nothing", func(t *testing.T) {
+		writeConfig(`
+Director:
+  CacheSelectionStrategy: consistent-hash
+  PreferSameSiteCaches: false
+  NamespaceDenylist: ["[invalid"]
+`)
+		assert.Equal(t, http.StatusBadRequest, reload().Code)
+		assert.True(t, reloadableBool(param.Director_PreferSameSiteCaches))
+	})
+
+	t.Run("startup-settings-reported", func(t *testing.T) {
+		writeConfig(`
+Director:
+  CacheSelectionStrategy: consistent-hash
+  PreferSameSiteCaches: true
+  DefaultResponse: origin
+  NamespaceDenylist: ["/blocked"]
+`)
+		recorder := reload()
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		resp := configReloadResp{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"Director.NamespaceDenylist"}, resp.Changed)
+		assert.Equal(t, []string{"director.defaultresponse"}, resp.RestartRequired)
+
+		status, blocked := checkNamespaceFilter("/blocked/object")
+		assert.True(t, blocked)
+		assert.Equal(t, http.StatusUnavailableForLegalReasons, status)
+	})
+}
@@ -0,0 +1,143 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+type (
+	// The origins serving a registered namespace
+	namespaceCoverage struct {
+		Prefix         string   `json:"prefix"`
+		Origins        int      `json:"origins"`        // The origins advertising the namespace
+		HealthyOrigins int      `json:"healthyOrigins"` // The origins advertising the namespace that are neither filtered nor degraded
+		OriginNames    []string `json:"originNames"`
+	}
+
+	coverageResponse struct {
+		Namespaces int                 `json:"namespaces"` // The number of approved namespaces in the registry
+		Gaps       []namespaceCoverage `json:"gaps"`       // The namespaces without any healthy origin
+		NearGaps   []namespaceCoverage `json:"nearGaps"`   // The namespaces with a single healthy origin
+	}
+)
+
+// Fetch the prefixes of the approved namespaces from the registry; a variable to be mocked in tests
+var getRegisteredNamespaces = fetchRegisteredNamespaces
+
+func fetchRegisteredNamespaces(ctx context.Context) ([]string, error) {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fedInfo.NamespaceRegistrationEndpoint == "" {
+		return nil, errors.New("Federation.RegistryUrl is not set")
+	}
+	nsUrl, err := url.JoinPath(fedInfo.NamespaceRegistrationEndpoint, "api", "v1.0", "registry_ui", "namespaces")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct the registry's namespaces URL")
+	}
+	// Only data namespaces count; the registrations of the origins and caches themselves are skipped
+	nsUrl += "?prefixType=namespace&status=" + url.QueryEscape(server_structs.RegApproved.String())
+	var body []byte
+	err = registryBreaker.call(func() (err error) {
+		body, err = utils.MakeRequest(ctx, nsUrl, http.MethodGet, nil, nil)
+		return
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the namespaces from the registry")
+	}
+	namespaces := []struct {
+		Prefix string `json:"prefix"`
+	}{}
+	if err := json.Unmarshal(body, &namespaces); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the namespaces from the registry")
+	}
+	prefixes := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		prefixes = append(prefixes, ns.Prefix)
+	}
+	return prefixes, nil
+}
+
+// Check whether a namespace ad serves the registered prefix, either exactly or as one of its parents
+func servesPrefix(nsAdPath string, prefix string) bool {
+	nsAdPath = strings.TrimSuffix(nsAdPath, "/")
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == nsAdPath || strings.HasPrefix(prefix, nsAdPath+"/")
+}
+
+// Cross-reference the registered namespaces with the origins currently advertising to the director
+func computeCoverage(prefixes []string, originAds []*server_structs.Advertisement) coverageResponse {
+	res := coverageResponse{Namespaces: len(prefixes), Gaps: []namespaceCoverage{}, NearGaps: []namespaceCoverage{}}
+	for _, prefix := range prefixes {
+		coverage := namespaceCoverage{Prefix: prefix, OriginNames: []string{}}
+		for _, ad := range originAds {
+			for _, nsAd := range ad.NamespaceAds {
+				if !servesPrefix(nsAd.Path, prefix) {
+					continue
+				}
+				coverage.Origins++
+				coverage.OriginNames = append(coverage.OriginNames, ad.Name)
+				filtered, _ := checkFilter(ad.Name)
+				draining, _ := checkDraining(ad.URL.String())
+				if !filtered && !draining && getHealthStatus(ad.ServerAd) != HealthStatusDegraded {
+					coverage.HealthyOrigins++
+				}
+				break
+			}
+		}
+		sort.Strings(coverage.OriginNames)
+		switch coverage.HealthyOrigins {
+		case 0:
+			res.Gaps = append(res.Gaps, coverage)
+		case 1:
+			res.NearGaps = append(res.NearGaps, coverage)
+		}
+	}
+	return res
+}
+
+// A gin route handler listing the registered namespaces without any healthy origin, along
+// with those served by a single healthy origin, so that operators can alert on coverage gaps
+func getCoverage(ctx *gin.Context) {
+	prefixes, err := getRegisteredNamespaces(ctx)
+	if err != nil {
+		log.Errorln("Failed to get the registered namespaces for the coverage report:", err)
+		ctx.JSON(http.StatusBadGateway, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to get the registered namespaces from the registry: " + err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, computeCoverage(prefixes, listAdvertisement([]server_structs.ServerType{server_structs.OriginType})))
+}
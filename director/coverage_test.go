@@ -0,0 +1,120 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestGetCoverage(t *testing.T) {
+	serverAds.DeleteAll()
+	oldGetNamespaces := getRegisteredNamespaces
+	filteredServersMutex.Lock()
+	oldFiltered := filteredServers
+	filteredServers = map[string]filterType{"filtered-origin": tempFiltered}
+	filteredServersMutex.Unlock()
+	healthTestUtilsMutex.Lock()
+	oldHealthUtils := healthTestUtils
+	healthTestUtils = map[string]*healthTestUtil{"https://degraded-origin.com": {Status: HealthStatusDegraded}}
+	healthTestUtilsMutex.Unlock()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		getRegisteredNamespaces = oldGetNamespaces
+		filteredServersMutex.Lock()
+		filteredServers = oldFiltered
+		filteredServersMutex.Unlock()
+		healthTestUtilsMutex.Lock()
+		healthTestUtils = oldHealthUtils
+		healthTestUtilsMutex.Unlock()
+	})
+
+	setOrigin := func(name string, paths ...string) {
+		ad := server_structs.ServerAd{Name: name, URL: url.URL{Scheme: "https", Host: name + ".com"}, Type: server_structs.OriginType}
+		nsAds := []server_structs.NamespaceAdV2{}
+		for _, nsPath := range paths {
+			nsAds = append(nsAds, server_structs.NamespaceAdV2{Path: nsPath})
+		}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad, NamespaceAds: nsAds}, ttlcache.DefaultTTL)
+	}
+	setOrigin("origin-a", "/replicated", "/single")
+	setOrigin("origin-b", "/replicated", "/parent")
+	setOrigin("filtered-origin", "/filtered")
+	setOrigin("degraded-origin", "/degraded", "/single")
+
+	router := gin.New()
+	router.GET("/coverage", getCoverage)
+	doRequest := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/coverage", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("reports-gaps-and-near-gaps", func(t *testing.T) {
+		getRegisteredNamespaces = func(context.Context) ([]string, error) {
+			return []string{"/replicated", "/single", "/parent/child", "/filtered", "/degraded", "/orphan"}, nil
+		}
+		w := doRequest()
+		require.Equal(t, http.StatusOK, w.Code)
+		res := coverageResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, 6, res.Namespaces)
+
+		gaps := map[string]namespaceCoverage{}
+		for _, gap := range res.Gaps {
+			gaps[gap.Prefix] = gap
+		}
+		assert.Len(t, gaps, 3)
+		assert.Equal(t, namespaceCoverage{Prefix: "/orphan", OriginNames: []string{}}, gaps["/orphan"])
+		assert.Equal(t, 1, gaps["/filtered"].Origins)
+		assert.Equal(t, []string{"degraded-origin"}, gaps["/degraded"].OriginNames)
+
+		nearGaps := map[string]namespaceCoverage{}
+		for _, nearGap := range res.NearGaps {
+			nearGaps[nearGap.Prefix] = nearGap
+		}
+		assert.Len(t, nearGaps, 2)
+		// The degraded origin still counts as an origin of the namespace, but not as a healthy one
+		assert.Equal(t, namespaceCoverage{Prefix: "/single", Origins: 2, HealthyOrigins: 1, OriginNames: []string{"degraded-origin", "origin-a"}}, nearGaps["/single"])
+		// Registered namespaces are served by origins exporting one of their parents
+		assert.Equal(t, 1, nearGaps["/parent/child"].HealthyOrigins)
+	})
+
+	t.Run("registry-unavailable", func(t *testing.T) {
+		getRegisteredNamespaces = func(context.Context) ([]string, error) {
+			return nil, errors.New("connection refused")
+		}
+		w := doRequest()
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Contains(t, w.Body.String(), "connection refused")
+	})
+}
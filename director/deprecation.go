@@ -0,0 +1,65 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The deprecation of a director route, advertised with the Deprecation (RFC 9745) and
+// Sunset (RFC 8594) response headers so that clients can notice the upcoming removal
+type routeDeprecation struct {
+	deprecatedAt time.Time // When the route was deprecated
+	sunset       time.Time // When the route is planned to be removed
+	successor    string    // The route replacing the deprecated one, if any
+}
+
+// The deprecated director routes, keyed by the request method and the route as registered with gin.
+// Deprecated routes keep working until their sunset; add an entry here when a route gets a successor
+var deprecatedRoutes = map[string]routeDeprecation{
+	http.MethodGet + " /api/v1.0/director/listNamespaces": {
+		deprecatedAt: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		sunset:       time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+		successor:    "/api/v2.0/director/listNamespaces",
+	},
+	http.MethodGet + " /api/v1.0/director_ui/servers": {
+		deprecatedAt: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		sunset:       time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC),
+		successor:    "/api/v2.0/director/servers",
+	},
+}
+
+// A gin middleware adding the Deprecation and Sunset headers, along with a Link to the
+// successor route, to the responses of the deprecated routes in deprecatedRoutes
+func deprecationMiddleware(ctx *gin.Context) {
+	if deprecation, ok := deprecatedRoutes[ctx.Request.Method+" "+ctx.FullPath()]; ok {
+		ctx.Header("Deprecation", fmt.Sprintf("@%d", deprecation.deprecatedAt.Unix()))
+		if !deprecation.sunset.IsZero() {
+			ctx.Header("Sunset", deprecation.sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.successor != "" {
+			ctx.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, deprecation.successor))
+		}
+	}
+	ctx.Next()
+}
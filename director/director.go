@@ -21,6 +21,7 @@ package director
 import (
 	"context"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"path"
@@ -28,6 +29,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -45,6 +47,7 @@ import (
 	"github.com/pelicanplatform/pelican/token"
 	"github.com/pelicanplatform/pelican/token_scopes"
 	"github.com/pelicanplatform/pelican/utils"
+	"github.com/pelicanplatform/pelican/web_ui"
 )
 
 type (
@@ -64,6 +67,8 @@ type (
 		ErrGrpContext context.Context
 		Cancel        context.CancelFunc
 		Status        HealthTestStatus
+		// The number of director tests the server failed in a row
+		ConsecutiveFailures int
 	}
 	// Utility struct to keep track of the `stat` call the director made to the origin/cache servers
 	serverStatUtil struct {
@@ -78,7 +83,12 @@ const (
 	HealthStatusUnknown  HealthTestStatus = "Unknown"
 	HealthStatusInit     HealthTestStatus = "Initializing"
 	HealthStatusOK       HealthTestStatus = "OK"
-	HealthStatusError    HealthTestStatus = "Error"
+	// The server is struggling: it passes the director tests slowly, or it started failing them.
+	// The director still redirects to degraded servers, but prefers healthy ones
+	HealthStatusDegraded HealthTestStatus = "Degraded"
+	// The server is down, having failed Director.HealthFailureThreshold director tests in a row.
+	// The director doesn't redirect to servers that are down
+	HealthStatusError HealthTestStatus = "Error"
 )
 
 const (
@@ -147,6 +157,63 @@ func getLinkDepth(filepath, prefix string) (int, error) {
 	return pathDepth, nil
 }
 
+// Get the namespace capability needed to serve a request with the method, or an empty string
+// if the method doesn't need any
+func requiredCapability(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "reads"
+	case http.MethodPut:
+		return "writes"
+	case "PROPFIND":
+		return "listings"
+	default:
+		return ""
+	}
+}
+
+// Check whether the capabilities include the capability named by requiredCapability
+func hasCapability(caps server_structs.Capabilities, capability string) bool {
+	switch capability {
+	case "reads":
+		return caps.Reads || caps.PublicReads
+	case "writes":
+		return caps.Writes
+	case "listings":
+		return caps.Listings
+	default:
+		return true
+	}
+}
+
+// Merge the capabilities the origins advertised for the namespace, so the namespace has a capability if any
+// of the origins allows it. Writes and listings also have to be enabled for the origin as a whole.
+// advertised is false if none of the origins advertised any capability for the namespace, as older
+// origins and those whose namespaces come from the topology don't
+func mergeNamespaceCaps(nsPath string, originAds []server_structs.ServerAd) (merged server_structs.Capabilities, advertised bool) {
+	for _, oAd := range originAds {
+		item := serverAds.Get(oAd.URL.String())
+		if item == nil {
+			continue
+		}
+		ad := item.Value()
+		ad.RLock()
+		for _, ns := range ad.NamespaceAds {
+			if ns.Path != nsPath {
+				continue
+			}
+			advertised = advertised || ns.Caps != (server_structs.Capabilities{})
+			merged.PublicReads = merged.PublicReads || ns.Caps.PublicReads
+			merged.Reads = merged.Reads || ns.Caps.Reads
+			merged.Writes = merged.Writes || (ns.Caps.Writes && oAd.Writes)
+			merged.Listings = merged.Listings || (ns.Caps.Listings && oAd.Listings)
+			merged.DirectReads = merged.DirectReads || ns.Caps.DirectReads
+		}
+		ad.RUnlock()
+	}
+	return
+}
+
 // Aggregate various request parameters from header and query to a single url.Values struct
 func getRequestParameters(req *http.Request) (requestParams url.Values) {
 	requestParams = url.Values{}
@@ -210,8 +277,8 @@ func generateXTokenGenHeader(ginCtx *gin.Context, namespaceAd server_structs.Nam
 		tokenGen := ""
 		first := true
 		hdrVals := []string{namespaceAd.Generation[0].CredentialIssuer.String(), fmt.Sprint(namespaceAd.Generation[0].MaxScopeDepth),
-			string(namespaceAd.Generation[0].Strategy)}
-		for idx, hdrKey := range []string{"issuer", "max-scope-depth", "strategy"} {
+			string(namespaceAd.Generation[0].Strategy), getTokenBasePath(namespaceAd)}
+		for idx, hdrKey := range []string{"issuer", "max-scope-depth", "strategy", "base-path"} {
 			hdrVal := hdrVals[idx]
 			if hdrVal == "" {
 				continue
@@ -228,11 +295,32 @@ func generateXTokenGenHeader(ginCtx *gin.Context, namespaceAd server_structs.Nam
 	}
 }
 
+// Get the base path of the namespace's token issuer, which the resources of the token scopes are
+// relative to: the longest issuer base path containing the namespace. Empty if there is none
+func getTokenBasePath(namespaceAd server_structs.NamespaceAdV2) string {
+	basePath := ""
+	for _, tokIss := range namespaceAd.Issuer {
+		for _, candidate := range tokIss.BasePaths {
+			candidate = path.Clean("/" + candidate)
+			if len(candidate) > len(basePath) && (candidate == "/" || namespaceAd.Path == candidate || strings.HasPrefix(namespaceAd.Path, candidate+"/")) {
+				basePath = candidate
+			}
+		}
+	}
+	return basePath
+}
+
 func generateXNamespaceHeader(ginCtx *gin.Context, namespaceAd server_structs.NamespaceAdV2, collUrl string) {
 	xPelicanNamespace := fmt.Sprintf("namespace=%s, require-token=%v", namespaceAd.Path, !namespaceAd.Caps.PublicReads)
 	if collUrl != "" {
 		xPelicanNamespace += fmt.Sprintf(", collections-url=%s", collUrl)
 	}
+	if namespaceAd.Caps.Immutable {
+		xPelicanNamespace += ", immutable=true"
+	}
+	if namespaceAd.StagedUploads {
+		xPelicanNamespace += ", staged-uploads=true"
+	}
 	ginCtx.Writer.Header()["X-Pelican-Namespace"] = []string{xPelicanNamespace}
 }
 
@@ -247,6 +335,57 @@ func getFinalRedirectURL(rurl url.URL, requstParams url.Values) string {
 	return rurl.String()
 }
 
+// Check if the client asked for RFC 6249 Metalink/HTTP mirror listings, through an Accept header naming a
+// metalink media type as download managers like aria2 send, or if Director.MetalinkHeaders is set
+func wantsMetalinkHeaders(ginCtx *gin.Context) bool {
+	if reloadableBool(param.Director_MetalinkHeaders) {
+		return true
+	}
+	for _, accept := range strings.Split(ginCtx.GetHeader("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil &&
+			(mediaType == "application/metalink4+xml" || mediaType == "application/metalink+xml") {
+			return true
+		}
+	}
+	return false
+}
+
+// Set the Link header listing the ranked servers of the redirect, up to serverResLimit of them.
+// The alternate URLs of a server follow it with the same priority, for clients to fail over to.
+//
+// Pelican clients get a single header value and add their token to the links themselves, which keeps
+// the header short. Metalink/HTTP clients (RFC 6249) get one `rel=duplicate` header value per mirror
+// instead, each URL carrying the request's query parameters (e.g. `authz`) so the mirror can be used as is
+func setLinkHeader(ginCtx *gin.Context, reqPath string, namespaceAd server_structs.NamespaceAdV2, ads []server_structs.ServerAd, depth int, reqParams url.Values) {
+	if len(ads) > serverResLimit {
+		ads = ads[:serverResLimit]
+	}
+	metalink := wantsMetalinkHeaders(ginCtx)
+	links := make([]string, 0, len(ads))
+	addLink := func(ad server_structs.ServerAd, pri int) {
+		redirectURL := getRedirectURL(reqPath, ad, !namespaceAd.Caps.PublicReads)
+		if metalink {
+			links = append(links, fmt.Sprintf(`<%s>; rel=duplicate; pri=%d; depth=%d`, getFinalRedirectURL(redirectURL, reqParams), pri, depth))
+		} else {
+			links = append(links, fmt.Sprintf(`<%s>; rel="duplicate"; pri=%d; depth=%d`, redirectURL.String(), pri, depth))
+		}
+	}
+	for idx, ad := range ads {
+		addLink(ad, idx+1)
+		for _, altURL := range ad.AltURLs {
+			altAd := ad
+			altAd.URL = altURL
+			altAd.AuthURL = altURL
+			addLink(altAd, idx+1)
+		}
+	}
+	if metalink {
+		ginCtx.Writer.Header()["Link"] = links
+	} else {
+		ginCtx.Writer.Header()["Link"] = []string{strings.Join(links, ", ")}
+	}
+}
+
 func checkVersionCompat(ginCtx *gin.Context) error {
 	// Check that the version of whichever service (eg client, origin, etc) is talking to the Director
 	// is actually something the Director thinks it can communicate with
@@ -306,9 +445,59 @@ func checkRedirectQuery(query url.Values) error {
 	if hasDirectRead && hasPreferCached {
 		return errors.New("cannot have both directread and prefercached query parameters")
 	}
+	if _, err := getMaxAdAge(query); err != nil {
+		return err
+	}
 	return nil
 }
 
+// The query parameter with which clients demand that the director only redirects them to
+// servers that advertised within the given number of seconds
+const maxAdAgeQuery = "max_ad_age"
+
+// Get the maximum age of the ads to redirect to from the max_ad_age query parameter,
+// returning 0 if the client set no maximum
+func getMaxAdAge(query url.Values) (time.Duration, error) {
+	if !query.Has(maxAdAgeQuery) {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(query.Get(maxAdAgeQuery))
+	if err != nil || seconds <= 0 {
+		return 0, errors.Errorf("%s must be a positive number of seconds", maxAdAgeQuery)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// Filter out the ads the director recorded more than maxAge ago, or whose age is unknown
+func filterAdsByAge(ads []server_structs.ServerAd, maxAge time.Duration) []server_structs.ServerAd {
+	fresh := make([]server_structs.ServerAd, 0, len(ads))
+	for _, ad := range ads {
+		if ad.LastAdvertised != nil && time.Since(*ad.LastAdvertised) <= maxAge {
+			fresh = append(fresh, ad)
+		}
+	}
+	return fresh
+}
+
+// Drop the origin and cache ads older than the client's max_ad_age, if any. Responds with 503
+// and returns false if the ads of every server of the namespace are too old
+func applyMaxAdAge(ginCtx *gin.Context, originAds []server_structs.ServerAd, cacheAds []server_structs.ServerAd) ([]server_structs.ServerAd, []server_structs.ServerAd, bool) {
+	// The query was validated by checkRedirectQuery
+	maxAdAge, _ := getMaxAdAge(ginCtx.Request.URL.Query())
+	if maxAdAge == 0 {
+		return originAds, cacheAds, true
+	}
+	freshOriginAds, freshCacheAds := filterAdsByAge(originAds, maxAdAge), filterAdsByAge(cacheAds, maxAdAge)
+	if len(freshOriginAds) == 0 && len(freshCacheAds) == 0 && len(originAds)+len(cacheAds) > 0 {
+		ginCtx.JSON(http.StatusServiceUnavailable, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("None of the %d servers for this object advertised to the director within the last %s, as required by %s", len(originAds)+len(cacheAds), maxAdAge, maxAdAgeQuery),
+		})
+		return nil, nil, false
+	}
+	return freshOriginAds, freshCacheAds, true
+}
+
 func redirectToCache(ginCtx *gin.Context) {
 	err := checkVersionCompat(ginCtx)
 	if err != nil {
@@ -334,23 +523,35 @@ func redirectToCache(ginCtx *gin.Context) {
 
 	reqParams := getRequestParameters(ginCtx.Request)
 
-	disableStat := !param.Director_EnableStat.GetBool()
+	disableStat := !reloadableBool(param.Director_EnableStat)
 
 	// Skip the stat check for object availability
 	// If either disableStat or skipstat is set, then skip the stat query
 	skipStat := ginCtx.Request.URL.Query().Has("skipstat") || disableStat
 
+	// Re-homed datasets are served under the namespace their alias resolves to
+	reqPath = resolveNamespaceAlias(reqPath)
+
+	// Time the server selection, from the lookup of the ads to the final ordering of the servers
+	selectionStart := time.Now()
 	namespaceAd, originAds, cacheAds := getAdsForPath(reqPath)
+	recordNamespaceRequest(namespaceAd.Path)
 	// if GetAdsForPath doesn't find any ads because the prefix doesn't exist, we should
 	// report the lack of path first -- this is most important for the user because it tells them
 	// they're trying to get an object that simply doesn't exist
 	if namespaceAd.Path == "" {
-		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
-			Status: server_structs.RespFailed,
-			Msg:    "No namespace found for path. Either it doesn't exist, or the Director is experiencing problems",
-		})
+		respondNamespaceNotFound(ginCtx, reqPath)
+		return
+	}
+	originAds, cacheAds, ok := applyMaxAdAge(ginCtx, originAds, cacheAds)
+	if !ok {
 		return
 	}
+	// The namespace's own redirect policy takes precedence over the director's configuration
+	policy := getRedirectPolicy(reqPath)
+	if policy.Mode == server_structs.RedirectPolicyDirectToOrigin {
+		cacheAds = nil
+	}
 	// if err != nil, depth == 0, which is the default value for depth
 	// so we can use it as the value for the header even with err
 	depth, err := getLinkDepth(reqPath, namespaceAd.Path)
@@ -358,6 +559,14 @@ func redirectToCache(ginCtx *gin.Context) {
 		log.Errorf("Failed to get depth attribute for the redirecting request to %q, with best match namespace prefix %q", reqPath, namespaceAd.Path)
 	}
 
+	// A recent stat found no server with the object, so don't ask them again
+	if !skipStat {
+		if msg, found := lookupNotFound(server_structs.CacheType, reqPath); found {
+			respondObjectNotFound(ginCtx, server_structs.CacheType, reqPath, msg, false)
+			return
+		}
+	}
+
 	// Stat origins and caches for object availability
 	// For origins, we only want ones with the object
 	// For caches, we still list all in the response but turn down priorities for ones that don't have the object
@@ -380,8 +589,11 @@ func redirectToCache(ginCtx *gin.Context) {
 		// TODO: come back and re-evaluate if we need this many responses and potential origin/cache
 		// server performance issue out of this
 		maxRes := len(cacheAds) + len(originAds)
+		statStart := time.Now()
 		qr := q.Query(context.Background(), reqPath, st, 1, maxRes,
 			withOriginAds(originAds), withCacheAds(cacheAds), WithToken(reqParams.Get("authz")))
+		// The stat waits on the servers; it isn't part of the selection
+		selectionStart = selectionStart.Add(time.Since(statStart))
 		log.Debugf("Stat result for %s: %s", reqPath, qr.String())
 
 		// For successful response, we got a list of URLs to access the object.
@@ -426,9 +638,30 @@ func redirectToCache(ginCtx *gin.Context) {
 		}
 	}
 
+	if !skipStat && reloadableBool(param.Director_UseCacheHierarchy) {
+		applyCacheHierarchy(cacheAds, cachesAvailabilityMap)
+	}
+
 	// If the namespace prefix DOES exist, then it makes sense to say we couldn't find a valid cache.
 	// In this case, we append originAd(s) to cacheAds if the origin enabled DirectReads
-	if len(cacheAds) == 0 {
+	if policy.Mode == server_structs.RedirectPolicyDirectToOrigin {
+		// All the origins allowing direct reads are candidates, in place of the caches
+		for _, originAd := range originAdsWObject {
+			if originAd.DirectReads {
+				cacheAds = append(cacheAds, originAd)
+			}
+		}
+		if len(cacheAds) == 0 {
+			respondObjectNotFound(ginCtx, server_structs.CacheType, reqPath,
+				"The namespace's redirect policy only allows reads directly from origins, but no origin allowing direct reads was found for this object", !skipStat)
+			return
+		}
+	} else if len(cacheAds) == 0 {
+		if policy.Mode == server_structs.RedirectPolicyCachesOnly {
+			respondObjectNotFound(ginCtx, server_structs.CacheType, reqPath,
+				"No cache found for this object, and the namespace's redirect policy doesn't allow falling back to an origin", !skipStat)
+			return
+		}
 		for _, originAd := range originAdsWObject {
 			// Find the first origin that enables direct reads as the fallback
 			if originAd.DirectReads {
@@ -437,15 +670,13 @@ func redirectToCache(ginCtx *gin.Context) {
 			}
 		}
 		if len(cacheAds) == 0 {
-			ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
-				Status: server_structs.RespFailed,
-				Msg:    "No cache or fallback origin found for this object. The object may not exist in the federation",
-			})
+			respondObjectNotFound(ginCtx, server_structs.CacheType, reqPath,
+				"No cache or fallback origin found for this object. The object may not exist in the federation", !skipStat)
 			return
 		}
 	}
 
-	cacheAds, err = sortServerAdsByIP(ipAddr, cacheAds)
+	cacheAds, err = selectServers(reqPath, ipAddr, getClientSite(ginCtx.GetHeader("X-Pelican-Site"), ipAddr), cacheAds, cachesAvailabilityMap)
 	if err != nil {
 		log.Error("Error determining server ordering for cacheAds: ", err)
 		ginCtx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
@@ -454,28 +685,22 @@ func redirectToCache(ginCtx *gin.Context) {
 		})
 		return
 	}
-
-	// Re-sort by availability, where caches having the object have higher priority
-	sortServerAdsByAvailability(cacheAds, cachesAvailabilityMap)
+	// Origins serving as the fallback are skipped once at their concurrency limit
+	cacheAds, fullAds := originInFlight.filterAtCapacity(cacheAds)
+	if len(cacheAds) == 0 {
+		respondServersAtCapacity(ginCtx, fullAds)
+		return
+	}
+	stickySession := applyStickySession(ginCtx, namespaceAd.Path, cacheAds)
+	cacheAds = limitServers(cacheAds, policy)
+	metrics.PelicanDirectorRedirectLatency.WithLabelValues(string(server_structs.CacheType)).Observe(time.Since(selectionStart).Seconds())
+	if !stickySession && reloadableBool(param.Director_EnableStickySessions) {
+		setStickySessionCookie(ginCtx, namespaceAd.Path, cacheAds[0])
+	}
 
 	redirectURL := getRedirectURL(reqPath, cacheAds[0], !namespaceAd.Caps.PublicReads)
 
-	linkHeader := ""
-	first := true
-	cachesToSend := serverResLimit
-	if numCAds := len(cacheAds); numCAds < serverResLimit {
-		cachesToSend = numCAds
-	}
-	for idx, ad := range cacheAds[:cachesToSend] {
-		if first {
-			first = false
-		} else {
-			linkHeader += ", "
-		}
-		redirectURL := getRedirectURL(reqPath, ad, !namespaceAd.Caps.PublicReads)
-		linkHeader += fmt.Sprintf(`<%s>; rel="duplicate"; pri=%d; depth=%d`, redirectURL.String(), idx+1, depth)
-	}
-	ginCtx.Writer.Header()["Link"] = []string{linkHeader}
+	setLinkHeader(ginCtx, reqPath, namespaceAd, cacheAds, depth, reqParams)
 
 	// Generate headers needed for token generation/verification
 	generateXAuthHeader(ginCtx, namespaceAd)
@@ -494,11 +719,54 @@ func redirectToCache(ginCtx *gin.Context) {
 	}
 	generateXNamespaceHeader(ginCtx, namespaceAd, colUrl)
 
+	if wantsRedirectCandidates(ginCtx) {
+		respondWithRedirectCandidates(ginCtx, reqPath, ipAddr, namespaceAd, cacheAds, depth)
+		return
+	}
+
 	// Note we only append the `authz` query parameter in the case of the redirect response and not the
 	// duplicate link metadata above.  This is purposeful: the Link header might get too long if we repeat
 	// the token 20 times for 20 caches.  This means a "normal HTTP client" will correctly redirect but
 	// anything parsing the `Link` header for metalinks will need logic for redirecting appropriately.
-	ginCtx.Redirect(307, getFinalRedirectURL(redirectURL, reqParams))
+	originInFlight.record(cacheAds[0])
+	ginCtx.Redirect(getRedirectStatusCode(ginCtx), getFinalRedirectURL(redirectURL, reqParams))
+}
+
+// Check if the status code is one the director may redirect clients with
+func IsValidRedirectStatusCode(code int) bool {
+	return code == http.StatusFound || code == http.StatusTemporaryRedirect || code == http.StatusPermanentRedirect
+}
+
+// Get the status code of the redirect response to the client: the one requested by the client through
+// the X-Pelican-Redirect-Code header if valid, otherwise Director.RedirectStatusCode.
+// Requests other than GET and HEAD, e.g. PUT uploads, are never redirected with 302, as clients
+// may turn them into GET requests and drop the body; 307 is used instead
+func getRedirectStatusCode(ginCtx *gin.Context) int {
+	code := reloadableInt(param.Director_RedirectStatusCode)
+	if hint := ginCtx.GetHeader("X-Pelican-Redirect-Code"); hint != "" {
+		if hintCode, err := strconv.Atoi(hint); err == nil && IsValidRedirectStatusCode(hintCode) {
+			code = hintCode
+		} else {
+			log.Debugf("Ignoring the invalid X-Pelican-Redirect-Code header %q", hint)
+		}
+	}
+	if !IsValidRedirectStatusCode(code) {
+		code = http.StatusTemporaryRedirect
+	}
+	if code == http.StatusFound && ginCtx.Request.Method != http.MethodGet && ginCtx.Request.Method != http.MethodHead {
+		code = http.StatusTemporaryRedirect
+	}
+	return code
+}
+
+// Count a redirect request by the namespace it matched. Only namespaces advertised in serverAds
+// get their own label; requests matching no namespace are counted under "other" to bound the cardinality
+func recordNamespaceRequest(namespacePath string) {
+	countNamespaceStatsRequest(namespacePath)
+	if namespacePath == "" {
+		namespacePath = "other"
+	}
+	metrics.PelicanDirectorNamespaceRequestsTotal.WithLabelValues(namespacePath).Inc()
 }
 
 func redirectToOrigin(ginCtx *gin.Context) {
@@ -538,35 +806,78 @@ func redirectToOrigin(ginCtx *gin.Context) {
 
 	// Skip the stat check for object availability
 	// If either disableStat or skipstat is set, then skip the stat query
-	skipStat := reqParams.Has(utils.QuerySkipStat.String()) || !param.Director_EnableStat.GetBool()
+	skipStat := reqParams.Has(utils.QuerySkipStat.String()) || !reloadableBool(param.Director_EnableStat)
 
 	// Include caches in the response if Director.CachesPullFromCaches is enabled
 	// AND prefercached query parameter is set
-	includeCaches := param.Director_CachesPullFromCaches.GetBool() && reqParams.Has(utils.QueryPreferCached.String())
+	includeCaches := reloadableBool(param.Director_CachesPullFromCaches) && reqParams.Has(utils.QueryPreferCached.String())
+
+	// Re-homed datasets are served under the namespace their alias resolves to
+	reqPath = resolveNamespaceAlias(reqPath)
 
+	// Time the server selection, from the lookup of the ads to the final ordering of the servers
+	selectionStart := time.Now()
 	namespaceAd, originAds, cacheAds := getAdsForPath(reqPath)
+	recordNamespaceRequest(namespaceAd.Path)
 	// if GetAdsForPath doesn't find any ads because the prefix doesn't exist, we should
 	// report the lack of path first -- this is most important for the user because it tells them
 	// they're trying to get an object that simply doesn't exist
 	if namespaceAd.Path == "" {
-		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+		respondNamespaceNotFound(ginCtx, reqPath)
+		return
+	}
+	originAds, cacheAds, ok := applyMaxAdAge(ginCtx, originAds, cacheAds)
+	if !ok {
+		return
+	}
+
+	// Caches still look up the origins of a caches-only namespace here, but clients may not read from them directly
+	policy := getRedirectPolicy(reqPath)
+	if policy.Mode == server_structs.RedirectPolicyCachesOnly && reqParams.Has(utils.QueryDirectRead.String()) {
+		ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
-			Msg:    "No namespace found for path. Either it doesn't exist, or the Director is experiencing problems",
+			Msg:    fmt.Sprintf("The redirect policy of the namespace %s only allows reads through caches", namespaceAd.Path),
 		})
 		return
 	}
 
+	// Fail fast instead of redirecting to an origin that would reject the request
+	if capability := requiredCapability(ginCtx.Request.Method); capability != "" {
+		if caps, advertised := mergeNamespaceCaps(namespaceAd.Path, originAds); advertised && !hasCapability(caps, capability) {
+			ginCtx.JSON(http.StatusMethodNotAllowed, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("The %s request needs the %s capability, which none of the origins serving the namespace %s allow", ginCtx.Request.Method, capability, namespaceAd.Path),
+			})
+			return
+		}
+	}
+
+	// Skip stat query for PUT (upload), PROPFIND (listing) or skipStat query flag is on
+	statObject := ginCtx.Request.Method != http.MethodPut && ginCtx.Request.Method != "PROPFIND" && !skipStat
+	if ginCtx.Request.Method == http.MethodPut {
+		// The object is about to exist, regardless of what a recent stat found
+		invalidateNotFoundPath(reqPath)
+	} else if statObject {
+		// A recent stat found no origin with the object, so don't ask them again
+		if msg, found := lookupNotFound(server_structs.OriginType, reqPath); found {
+			respondObjectNotFound(ginCtx, server_structs.OriginType, reqPath, msg, false)
+			return
+		}
+	}
+
 	var q *ObjectStat
 
 	availableAds := []server_structs.ServerAd{}
-	// Skip stat query for PUT (upload), PROPFIND (listing) or skipStat query flag is on
-	if ginCtx.Request.Method == http.MethodPut || ginCtx.Request.Method == "PROPFIND" || skipStat {
+	if !statObject {
 		availableAds = originAds
 	} else {
 		// Query Origins and check if the object exists on the server
 		q = NewObjectStat()
+		statStart := time.Now()
 		qr := q.Query(context.Background(), reqPath, config.OriginType, 1, 3,
 			withOriginAds(originAds), WithToken(reqParams.Get("authz")), withAuth(!namespaceAd.PublicRead))
+		// The stat waits on the servers; it isn't part of the selection
+		selectionStart = selectionStart.Add(time.Since(statStart))
 		log.Debugf("Stat result for %s: %s", reqPath, qr.String())
 
 		// For successful response, we got a list of URL to access the object.
@@ -617,8 +928,10 @@ func redirectToOrigin(ginCtx *gin.Context) {
 		if q == nil {
 			q = NewObjectStat()
 		}
+		statStart := time.Now()
 		qr := q.Query(context.Background(), reqPath, config.CacheType, 1, 3,
 			withCacheAds(cacheAds), WithToken(reqParams.Get("authz")))
+		selectionStart = selectionStart.Add(time.Since(statStart))
 		log.Debugf("CachesPullFromCaches is enabled. Stat result for %s among caches: %s", reqPath, qr.String())
 
 		// For successful response, we got a list of URL to access the object.
@@ -656,10 +969,8 @@ func redirectToOrigin(ginCtx *gin.Context) {
 
 	// No available originAds or cacheAds if CachesPullFromCaches is enabled, object does not exist
 	if len(availableAds) == 0 {
-		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
-			Status: server_structs.RespFailed,
-			Msg:    "There are currently no origins hosting the object: available origin Ads is 0",
-		})
+		respondObjectNotFound(ginCtx, server_structs.OriginType, reqPath,
+			"There are currently no origins hosting the object: available origin Ads is 0", statObject)
 		return
 	}
 
@@ -680,22 +991,19 @@ func redirectToOrigin(ginCtx *gin.Context) {
 		return
 	}
 
-	linkHeader := ""
-	first := true
-	serversToSend := serverResLimit
-	if numCAds := len(availableAds); numCAds < serverResLimit {
-		serversToSend = numCAds
-	}
-	for idx, ad := range availableAds[:serversToSend] {
-		if first {
-			first = false
-		} else {
-			linkHeader += ", "
-		}
-		redirectURL := getRedirectURL(reqPath, ad, !namespaceAd.Caps.PublicReads)
-		linkHeader += fmt.Sprintf(`<%s>; rel="duplicate"; pri=%d; depth=%d`, redirectURL.String(), idx+1, depth)
+	// Re-sort by health, where degraded origins have lower priority
+	sortServerAdsByHealth(availableAds)
+	sortServerAdsByFailurePenalty(availableAds, ipAddr)
+	// Origins at their concurrency limit are skipped, as long as another server has the object
+	availableAds, fullAds := originInFlight.filterAtCapacity(availableAds)
+	if len(availableAds) == 0 {
+		respondServersAtCapacity(ginCtx, fullAds)
+		return
 	}
-	ginCtx.Writer.Header()["Link"] = []string{linkHeader}
+	availableAds = limitServers(availableAds, policy)
+	metrics.PelicanDirectorRedirectLatency.WithLabelValues(string(server_structs.OriginType)).Observe(time.Since(selectionStart).Seconds())
+
+	setLinkHeader(ginCtx, reqPath, namespaceAd, availableAds, depth, reqParams)
 
 	var colUrl string
 	// If the namespace or the origin does not allow directory listings, then we should not advertise a collections-url.
@@ -720,7 +1028,8 @@ func redirectToOrigin(ginCtx *gin.Context) {
 				if brokerUrl := availableAds[idx].BrokerURL; brokerUrl.String() != "" {
 					ginCtx.Header("X-Pelican-Broker", brokerUrl.String())
 				}
-				ginCtx.Redirect(http.StatusTemporaryRedirect, getFinalRedirectURL(redirectURL, reqParams))
+				originInFlight.record(availableAds[idx])
+				ginCtx.Redirect(getRedirectStatusCode(ginCtx), getFinalRedirectURL(redirectURL, reqParams))
 				return
 			}
 		}
@@ -742,7 +1051,8 @@ func redirectToOrigin(ginCtx *gin.Context) {
 				if brokerUrl := availableAds[idx].BrokerURL; brokerUrl.String() != "" {
 					ginCtx.Header("X-Pelican-Broker", brokerUrl.String())
 				}
-				ginCtx.Redirect(http.StatusTemporaryRedirect, getFinalRedirectURL(redirectURL, reqParams))
+				originInFlight.record(availableAds[idx])
+				ginCtx.Redirect(getRedirectStatusCode(ginCtx), getFinalRedirectURL(redirectURL, reqParams))
 				return
 			}
 		}
@@ -759,22 +1069,63 @@ func redirectToOrigin(ginCtx *gin.Context) {
 
 	// If we are doing a PUT, check to see if any origins are writeable
 	if ginCtx.Request.Method == "PUT" {
-		for idx, ad := range availableAds {
-			if ad.Writes {
-				redirectURL = getRedirectURL(reqPath, availableAds[idx], !namespaceAd.PublicRead)
-				if brokerUrl := availableAds[idx].BrokerURL; brokerUrl.String() != "" {
-					ginCtx.Header("X-Pelican-Broker", brokerUrl.String())
-				}
-				ginCtx.Redirect(http.StatusTemporaryRedirect, getFinalRedirectURL(redirectURL, reqParams))
-				return
+		writeAds := availableAds
+		if minFreeSpace := getMinOriginFreeSpace(); minFreeSpace > 0 {
+			writeAds = filterOriginsByFreeSpace(availableAds, minFreeSpace)
+		}
+		if writeAd, ok := selectWeightedWriteOrigin(writeAds); ok {
+			redirectURL = getRedirectURL(reqPath, writeAd, !namespaceAd.PublicRead)
+			if brokerUrl := writeAd.BrokerURL; brokerUrl.String() != "" {
+				ginCtx.Header("X-Pelican-Broker", brokerUrl.String())
 			}
+			originInFlight.record(writeAd)
+			ginCtx.Redirect(getRedirectStatusCode(ginCtx), getFinalRedirectURL(redirectURL, reqParams))
+			return
+		}
+		if _, ok := selectWeightedWriteOrigin(availableAds); ok {
+			// Some origins allow writes, but all of them are low on space
+			ginCtx.JSON(http.StatusInsufficientStorage, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "No origins on specified endpoint have enough free space for writes",
+			})
+			return
 		}
 		ginCtx.JSON(http.StatusMethodNotAllowed, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
-			Msg:    "No origins on specified endpoint have direct reads enabled",
+			Msg:    "No origins on specified endpoint allow writes",
 		})
 		return
 	} else { // Otherwise, we are doing a GET
+		if wantsRedirectCandidates(ginCtx) {
+			respondWithRedirectCandidates(ginCtx, reqPath, ipAddr, namespaceAd, availableAds, depth)
+			return
+		}
+		// S3 origins may serve the object straight from S3 with a presigned URL, once they verify the client's token
+		if supportsS3Presign(availableAds[0], namespaceAd) {
+			presignedUrl, err := fetchPresignedURL(ginCtx, availableAds[0], reqPath, ginCtx.Request.Method, reqParams.Get("authz"))
+			if err == nil {
+				ginCtx.Redirect(getRedirectStatusCode(ginCtx), presignedUrl)
+				return
+			}
+			var deniedErr *presignDeniedError
+			if errors.As(err, &deniedErr) {
+				ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    deniedErr.Msg,
+				})
+				return
+			}
+			if servedOnlyByPresign(availableAds[0], namespaceAd) {
+				log.Warningf("Failed to get a presigned URL for %s from the origin %s: %v", reqPath, availableAds[0].Name, err)
+				ginCtx.JSON(http.StatusBadGateway, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    "Failed to get a presigned URL for the object from the origin",
+				})
+				return
+			}
+			log.Warningf("Failed to get a presigned URL for %s from the origin %s; redirecting to the origin instead: %v", reqPath, availableAds[0].Name, err)
+		}
+
 		redirectURL := getRedirectURL(reqPath, availableAds[0], !namespaceAd.PublicRead)
 		if brokerUrl := availableAds[0].BrokerURL; brokerUrl.String() != "" {
 			ginCtx.Header("X-Pelican-Broker", brokerUrl.String())
@@ -782,13 +1133,14 @@ func redirectToOrigin(ginCtx *gin.Context) {
 
 		// See note in RedirectToCache as to why we only add the authz query parameter to this URL,
 		// not those in the `Link`.
-		ginCtx.Redirect(http.StatusTemporaryRedirect, getFinalRedirectURL(redirectURL, reqParams))
+		originInFlight.record(availableAds[0])
+		ginCtx.Redirect(getRedirectStatusCode(ginCtx), getFinalRedirectURL(redirectURL, reqParams))
 	}
 }
 
 func checkHostnameRedirects(c *gin.Context, incomingHost string) {
-	oRedirectHosts := param.Director_OriginResponseHostnames.GetStringSlice()
-	cRedirectHosts := param.Director_CacheResponseHostnames.GetStringSlice()
+	oRedirectHosts := reloadableStringSlice(param.Director_OriginResponseHostnames)
+	cRedirectHosts := reloadableStringSlice(param.Director_CacheResponseHostnames)
 
 	for _, hostname := range oRedirectHosts {
 		if hostname == incomingHost {
@@ -878,6 +1230,14 @@ func ShortcutMiddleware(defaultResponse string) gin.HandlerFunc {
 			c.Next()
 		} else if defaultResponse == "origin" {
 			if !strings.HasPrefix(c.Request.URL.Path, "/api/v1.0/director/") && (c.Request.Method == "GET" || c.Request.Method == "HEAD") {
+				// A caches-only namespace overrides the director's default of reading from origins
+				reqPath := resolveNamespaceAlias(path.Clean("/" + c.Request.URL.Path))
+				if getRedirectPolicy(reqPath).Mode == server_structs.RedirectPolicyCachesOnly {
+					c.Request.URL.Path = "/api/v1.0/director/object" + c.Request.URL.Path
+					redirectToCache(c)
+					c.Abort()
+					return
+				}
 				c.Request.URL.Path = "/api/v1.0/director/origin" + c.Request.URL.Path
 				redirectToOrigin(c)
 				c.Abort()
@@ -972,6 +1332,60 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 		})
 	}
 
+	altUrls := make([]url.URL, 0, len(adV2.AltDataURLs))
+	for _, altDataUrl := range adV2.AltDataURLs {
+		altUrl, err := url.Parse(altDataUrl)
+		if err != nil || altUrl.Host == "" {
+			log.Warningf("Rejected the advertisement of %s %s with invalid alternate data URL %s", sType, adV2.Name, altDataUrl)
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("Invalid %s registration. Alternate data URL %s is not a valid URL", sType, altDataUrl),
+			})
+			return
+		}
+		altUrls = append(altUrls, *altUrl)
+	}
+
+	// Only caches pull from parent caches
+	upstreamCaches := []url.URL{}
+	if sType == server_structs.CacheType {
+		for _, upstreamCache := range adV2.UpstreamCaches {
+			upstreamUrl, err := url.Parse(upstreamCache)
+			if err != nil || upstreamUrl.Host == "" {
+				log.Warningf("Rejected the advertisement of %s %s with invalid upstream cache URL %s", sType, adV2.Name, upstreamCache)
+				ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("Invalid %s registration. Upstream cache URL %s is not a valid URL", sType, upstreamCache),
+				})
+				return
+			}
+			upstreamCaches = append(upstreamCaches, *upstreamUrl)
+		}
+	}
+
+	if err := server_structs.ValidateServerLabels(adV2.Labels); err != nil {
+		log.Warningf("Rejected the advertisement of %s %s with invalid labels: %v", sType, adV2.Name, err)
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Invalid %s registration. Server.Labels is invalid: %v", sType, err),
+		})
+		return
+	}
+
+	// Caches relay the namespaces the director lists, which were checked when their origins advertised
+	if sType == server_structs.OriginType {
+		for _, namespace := range adV2.Namespaces {
+			if err := namespace.Validate(); err != nil {
+				log.Warningf("Rejected the advertisement of %s %s with an invalid namespace: %v", sType, adV2.Name, err)
+				ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    fmt.Sprintf("Invalid %s registration. %v", sType, err),
+				})
+				return
+			}
+		}
+	}
+
 	// Verify server registration
 	token := strings.TrimPrefix(tokens[0], "Bearer ")
 
@@ -990,12 +1404,12 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 
 	approvalErrMsg := "You may find more information on " + param.Server_ExternalWebUrl.GetString()
 	// Prepare the admin approval error message
-	if param.Director_SupportContactEmail.GetString() != "" && param.Director_SupportContactUrl.GetString() != "" {
-		approvalErrMsg = fmt.Sprintf("Contact %s or visit %s for help.", param.Director_SupportContactEmail.GetString(), param.Director_SupportContactUrl.GetString())
-	} else if param.Director_SupportContactEmail.GetString() != "" {
-		approvalErrMsg = fmt.Sprintf("Contact %s for help.", param.Director_SupportContactEmail.GetString())
-	} else if param.Director_SupportContactUrl.GetString() != "" {
-		approvalErrMsg = fmt.Sprintf("Visit %s for help.", param.Director_SupportContactUrl.GetString())
+	if reloadableString(param.Director_SupportContactEmail) != "" && reloadableString(param.Director_SupportContactUrl) != "" {
+		approvalErrMsg = fmt.Sprintf("Contact %s or visit %s for help.", reloadableString(param.Director_SupportContactEmail), reloadableString(param.Director_SupportContactUrl))
+	} else if reloadableString(param.Director_SupportContactEmail) != "" {
+		approvalErrMsg = fmt.Sprintf("Contact %s for help.", reloadableString(param.Director_SupportContactEmail))
+	} else if reloadableString(param.Director_SupportContactUrl) != "" {
+		approvalErrMsg = fmt.Sprintf("Visit %s for help.", reloadableString(param.Director_SupportContactUrl))
 	}
 
 	if verifyServer {
@@ -1007,6 +1421,7 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 				return
 			} else {
 				log.Warningln("Failed to verify token:", err)
+				metrics.PelicanDirectorAdvertisementsRejectedTotal.WithLabelValues(string(sType), "invalid_signature").Inc()
 				ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
 					Status: server_structs.RespFailed,
 					Msg:    fmt.Sprintf("Authorization token verification failed %v", err),
@@ -1037,6 +1452,7 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 					return
 				} else {
 					log.Warningln("Failed to verify token:", err)
+					metrics.PelicanDirectorAdvertisementsRejectedTotal.WithLabelValues(string(sType), "invalid_signature").Inc()
 					ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
 						Status: server_structs.RespFailed,
 						Msg:    fmt.Sprintf("Authorization token verification failed: %v", err),
@@ -1056,6 +1472,44 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 		}
 	}
 
+	// The token was verified against the keys registered for the server or its namespaces above;
+	// check that it signs this advertisement. The registry keys are cached in namespaceKeys, so
+	// verifying an advertisement doesn't cost a round trip to the registry
+	signatureErr := errUnsignedAdvertisement
+	if verifyServer || (sType == server_structs.OriginType && len(adV2.Namespaces) > 0) {
+		body, _ := ctx.Get(gin.BodyBytesKey)
+		bodyBytes, _ := body.([]byte)
+		signatureErr = verifyAdvertisementDigest(token, bodyBytes)
+	}
+	if errors.Is(signatureErr, errUnsignedAdvertisement) {
+		if param.Director_RequireSignedAdvertisements.GetBool() {
+			log.Warningf("Rejecting the unsigned advertisement of %s %s", sType, adV2.Name)
+			metrics.PelicanDirectorAdvertisementsRejectedTotal.WithLabelValues(string(sType), "unsigned").Inc()
+			ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "The advertisement must be signed by the key registered in the registry",
+			})
+			return
+		}
+		log.Debugf("Accepting the unsigned advertisement of %s %s", sType, adV2.Name)
+	} else if signatureErr != nil {
+		log.Warningf("Rejecting the advertisement of %s %s: %v", sType, adV2.Name, signatureErr)
+		metrics.PelicanDirectorAdvertisementsRejectedTotal.WithLabelValues(string(sType), "invalid_signature").Inc()
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Advertisement signature verification failed: " + signatureErr.Error(),
+		})
+		return
+	}
+
+	// Only charge the server name's bucket once the advertisement is authenticated, so a
+	// forged advertisement can't exhaust the limit of a real server. Unauthenticated
+	// requests are limited by their source IP in advertiseRateLimitMiddleware
+	if ok, retryAfter := allowAdvertisement(advertiseLimitByServerName, adV2.Name); !ok {
+		abortRateLimitedAdvertisement(ctx, advertiseLimitByServerName, adV2.Name, retryAfter)
+		return
+	}
+
 	st := adV2.StorageType
 	// Defaults to POSIX
 	if st == "" {
@@ -1080,6 +1534,19 @@ func registerServeAd(engineCtx context.Context, ctx *gin.Context, sType server_s
 		DirectReads:         adV2.Caps.DirectReads,
 		Listings:            adV2.Caps.Listings,
 		IOLoad:              0.5, // Defaults to 0.5, as 0 means the server is "very free" which is not necessarily true
+		Weight:              adV2.Weight,
+		Protocols:           adV2.Protocols,
+		Site:                adV2.Site,
+		Labels:              adV2.Labels,
+		FreeSpace:           adV2.FreeSpace,
+		TotalSpace:          adV2.TotalSpace,
+		MaxConcurrency:      adV2.MaxConcurrency,
+	}
+	if len(altUrls) > 0 {
+		sAd.AltURLs = altUrls
+	}
+	if len(upstreamCaches) > 0 {
+		sAd.UpstreamCaches = upstreamCaches
 	}
 
 	recordAd(engineCtx, sAd, &adV2.Namespaces)
@@ -1252,6 +1719,7 @@ func getHealthTestFile(ctx *gin.Context) {
 
 func RegisterDirectorAPI(ctx context.Context, router *gin.RouterGroup) {
 	directorAPIV1 := router.Group("/api/v1.0/director")
+	directorAPIV1.Use(deprecationMiddleware)
 	{
 		// Establish the routes used for cache/origin redirection
 		directorAPIV1.GET("/object/*any", redirectToCache)
@@ -1259,9 +1727,9 @@ func RegisterDirectorAPI(ctx context.Context, router *gin.RouterGroup) {
 		directorAPIV1.GET("/origin/*any", redirectToOrigin)
 		directorAPIV1.HEAD("/origin/*any", redirectToOrigin)
 		directorAPIV1.PUT("/origin/*any", redirectToOrigin)
-		directorAPIV1.POST("/registerOrigin", serverAdMetricMiddleware, func(gctx *gin.Context) { registerServeAd(ctx, gctx, server_structs.OriginType) })
-		directorAPIV1.POST("/registerCache", serverAdMetricMiddleware, func(gctx *gin.Context) { registerServeAd(ctx, gctx, server_structs.CacheType) })
-		directorAPIV1.GET("/listNamespaces", listNamespacesV1)
+		directorAPIV1.POST("/registerOrigin", serverAdMetricMiddleware, advertiseRateLimitMiddleware(server_structs.OriginType), func(gctx *gin.Context) { registerServeAd(ctx, gctx, server_structs.OriginType) })
+		directorAPIV1.POST("/registerCache", serverAdMetricMiddleware, advertiseRateLimitMiddleware(server_structs.CacheType), func(gctx *gin.Context) { registerServeAd(ctx, gctx, server_structs.CacheType) })
+		directorAPIV1.GET("/listNamespaces", compressionMiddleware, listNamespacesV1)
 		directorAPIV1.GET("/namespaces/prefix/*path", getPrefixByPath)
 		directorAPIV1.GET("/healthTest/*path", getHealthTestFile)
 		directorAPIV1.HEAD("/healthTest/*path", getHealthTestFile)
@@ -1275,10 +1743,23 @@ func RegisterDirectorAPI(ctx context.Context, router *gin.RouterGroup) {
 		// so that director can be our point of contact for collecting system-level metrics.
 		// Rename the endpoint to reflect such plan.
 		directorAPIV1.GET("/discoverServers", discoverOriginCache)
+		directorAPIV1.GET("/topology", compressionMiddleware, getFederationTopology)
+		directorAPIV1.GET("/snapshot", compressionMiddleware, getFederationSnapshot)
+		directorAPIV1.GET("/explain", explainRedirect)
+		directorAPIV1.GET("/listing/*path", compressionMiddleware, listCollection)
+		directorAPIV1.GET("/stat/*path", getObjectStat)
+		directorAPIV1.POST("/reportFailure", handleReportFailure)
+		directorAPIV1.POST("/refresh", web_ui.AdminTokenAuthHandler, handleAdvertiseRefresh)
+		directorAPIV1.GET("/coverage", web_ui.AdminTokenAuthHandler, getCoverage)
+		directorAPIV1.POST("/simulate", web_ui.AdminTokenAuthHandler, handleSimulateRouting)
+		directorAPIV1.GET("/config", web_ui.AdminTokenAuthHandler, getEffectiveConfig)
+		directorAPIV1.POST("/config/reload", web_ui.AdminTokenAuthHandler, handleReloadDirectorConfig)
+		directorAPIV1.GET("/openapi.json", getOpenAPISpec)
 	}
 
 	directorAPIV2 := router.Group("/api/v2.0/director")
 	{
-		directorAPIV2.GET("/listNamespaces", listNamespacesV2)
+		directorAPIV2.GET("/listNamespaces", compressionMiddleware, listNamespacesV2)
+		directorAPIV2.GET("/servers", compressionMiddleware, listServers)
 	}
 }
@@ -21,6 +21,8 @@ package director
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"slices"
 	"strconv"
 	"time"
 
@@ -40,8 +42,13 @@ func listNamespacesFromOrigins() []server_structs.NamespaceAdV2 {
 	namespaces := make([]server_structs.NamespaceAdV2, 0, len(serverAdItems))
 	for _, item := range serverAdItems {
 		ad := item.Value()
-		if ad.Type == server_structs.OriginType {
-			namespaces = append(namespaces, ad.NamespaceAds...)
+		if ad.Type != server_structs.OriginType {
+			continue
+		}
+		for _, ns := range ad.NamespaceAds {
+			if _, blocked := checkNamespaceFilter(ns.Path); !blocked {
+				namespaces = append(namespaces, ns)
+			}
 		}
 	}
 	return namespaces
@@ -49,8 +56,17 @@ func listNamespacesFromOrigins() []server_structs.NamespaceAdV2 {
 
 // List all advertisements in the TTL cache that match the serverType array
 func listAdvertisement(serverTypes []server_structs.ServerType) []*server_structs.Advertisement {
+	return filterAdsByType(serverAds.Items(), serverTypes)
+}
+
+// List all stale advertisements, i.e. those expired within Director.StaleAdGracePeriod, that match the serverType array
+func listStaleAdvertisement(serverTypes []server_structs.ServerType) []*server_structs.Advertisement {
+	return filterAdsByType(staleServerAds.Items(), serverTypes)
+}
+
+func filterAdsByType(items map[string]*ttlcache.Item[string, *server_structs.Advertisement], serverTypes []server_structs.ServerType) []*server_structs.Advertisement {
 	ads := make([]*server_structs.Advertisement, 0)
-	for _, item := range serverAds.Items() {
+	for _, item := range items {
 		ad := item.Value()
 		for _, serverType := range serverTypes {
 			if ad.Type == serverType {
@@ -89,6 +105,42 @@ func checkFilter(serverName string) (bool, filterType) {
 	}
 }
 
+// List the URLs of the other servers, live or stale, advertising with the given name. Servers are
+// identified by hostname:port, so that a topology ad and the Pelican ad of the same server aren't counted
+func listOtherServersWithName(name string, serverUrl url.URL) []string {
+	others := []string{}
+	for _, items := range []map[string]*ttlcache.Item[string, *server_structs.Advertisement]{serverAds.Items(), staleServerAds.Items()} {
+		for _, item := range items {
+			ad := item.Value()
+			if ad.Name == name && ad.URL.Host != serverUrl.Host && !slices.Contains(others, ad.URL.String()) {
+				others = append(others, ad.URL.String())
+			}
+		}
+	}
+	return others
+}
+
+// Remove the temporary filter of a server that is gone, as the entry from the admin website is orphaned.
+// Other filter types are kept, as the server should remain filtered if it comes back. Filters are keyed
+// by server name, so the filter is also kept while another server with the same name is still around
+func removeTempFilter(serverAd server_structs.ServerAd) {
+	if others := listOtherServersWithName(serverAd.Name, serverAd.URL); len(others) > 0 {
+		log.Debugf("Kept the temporary filter of %s server %s as servers at %v share its name", string(serverAd.Type), serverAd.Name, others)
+		return
+	}
+	filteredServersMutex.Lock()
+	ft, ok := filteredServers[serverAd.Name]
+	if !ok || ft != tempFiltered {
+		filteredServersMutex.Unlock()
+		return
+	}
+	delete(filteredServers, serverAd.Name)
+	state := snapshotFilteredServersLocked()
+	filteredServersMutex.Unlock()
+	persistFilteredServers(state)
+	log.Debugf("Removed the temporary filter of %s server %s as its serverAd expired", string(serverAd.Type), serverAd.Name)
+}
+
 // Configure TTL caches to enable cache eviction and other additional cache events handling logic
 //
 // The `ctx` is the context for listening to server shutdown event in order to cleanup internal cache eviction goroutine
@@ -96,11 +148,27 @@ func LaunchTTLCache(ctx context.Context, egrp *errgroup.Group) {
 	// Start automatic expired item deletion
 	go serverAds.Start()
 	go namespaceKeys.Start()
+	go advertiseLimiters.Start()
+	go staleServerAds.Start()
+	go notFoundCache.Start()
+	go serverFailures.Start()
 
 	serverAds.OnEviction(func(ctx context.Context, er ttlcache.EvictionReason, i *ttlcache.Item[string, *server_structs.Advertisement]) {
 		serverAd := i.Value().ServerAd
 		serverUrl := i.Key()
-		log.Debugf("serverAds for %s server %s is evicted. Clean up started.", string(serverAd.Type), serverAd.Name)
+		serverAdsIndex.evict(serverUrl, i.Value())
+		log.Debugf("serverAds for %s is evicted. Clean up started.", i.Value().Summary())
+		if er == ttlcache.EvictionReasonExpired {
+			metrics.PelicanDirectorAdEvictionsTotal.WithLabelValues(string(serverAd.Type)).Inc()
+
+			// Keep serving the ad as stale for the grace period, in case the server is only partitioned from the director.
+			// Explicit deletions are skipped, as they happen when a topology ad is replaced by the Pelican ad of the same server
+			if gracePeriod := reloadableDuration(param.Director_StaleAdGracePeriod); gracePeriod > 0 {
+				staleServerAds.Set(serverUrl, i.Value(), gracePeriod)
+			} else {
+				removeTempFilter(serverAd)
+			}
+		}
 
 		// Always lock statUtilsMutex first then healthTestUtilsMutex to avoid cyclic dependency
 		func() {
@@ -148,6 +216,13 @@ func LaunchTTLCache(ctx context.Context, egrp *errgroup.Group) {
 		}
 	})
 
+	staleServerAds.OnEviction(func(ctx context.Context, er ttlcache.EvictionReason, i *ttlcache.Item[string, *server_structs.Advertisement]) {
+		if er == ttlcache.EvictionReasonExpired {
+			log.Debugf("Stale serverAd for %s is evicted", i.Value().Summary())
+			removeTempFilter(i.Value().ServerAd)
+		}
+	})
+
 	// Put stop logic in a separate goroutine so that parent function is not blocking
 	egrp.Go(func() error {
 		<-ctx.Done()
@@ -156,6 +231,14 @@ func LaunchTTLCache(ctx context.Context, egrp *errgroup.Group) {
 		serverAds.Stop()
 		namespaceKeys.DeleteAll()
 		namespaceKeys.Stop()
+		advertiseLimiters.DeleteAll()
+		advertiseLimiters.Stop()
+		staleServerAds.DeleteAll()
+		staleServerAds.Stop()
+		notFoundCache.DeleteAll()
+		notFoundCache.Stop()
+		serverFailures.DeleteAll()
+		serverFailures.Stop()
 		log.Info("Director TTL cache eviction has been stopped")
 		return nil
 	})
@@ -193,6 +276,9 @@ func LaunchMapMetrics(ctx context.Context, egrp *errgroup.Group) {
 				metrics.PelicanDirectorMapItemsTotal.WithLabelValues("filteredServers").Set(float64(len(filteredServers)))
 				metrics.PelicanDirectorMapItemsTotal.WithLabelValues("healthTestUtils").Set(float64(len(healthTestUtils)))
 				metrics.PelicanDirectorMapItemsTotal.WithLabelValues("originStatUtils").Set(float64(len(statUtils)))
+
+				// The in-flight redirects to origins expire without any new redirect to update them
+				originInFlight.refreshMetrics()
 			}
 		}
 	})
@@ -116,24 +116,21 @@ func TestListNamespaces(t *testing.T) {
 	t.Run("multiple-origin-namespace-entries-from-different-origins", func(t *testing.T) {
 		setup()
 
-		serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
+		origin1 := &server_structs.Advertisement{
 			ServerAd:     mockOriginServerAd,
 			NamespaceAds: mockNamespaceAds(10, "origin1"),
-		}, ttlcache.DefaultTTL)
-		// change the name field of serverAD as same name will cause cache to merge
-		oldServerUrl := mockOriginServerAd.URL
-		mockOriginServerAd.URL.Host = "origin2.com"
-
-		serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
-			ServerAd:     mockOriginServerAd,
-			NamespaceAds: mockNamespaceAds(10, "origin2"),
-		}, ttlcache.DefaultTTL)
+		}
+		serverAds.Set(origin1.URL.String(), origin1, ttlcache.DefaultTTL)
+		// change the URL of the copied ad as the same URL will cause cache to merge
+		origin2 := origin1.Clone()
+		origin2.URL.Host = "origin2.com"
+		origin2.NamespaceAds = mockNamespaceAds(10, "origin2")
+		serverAds.Set(origin2.URL.String(), origin2, ttlcache.DefaultTTL)
 		ns := listNamespacesFromOrigins()
 
 		assert.Equal(t, 20, len(ns), "List has length not equal to 10 for namespace cache with 10 entries.")
 		assert.True(t, namespaceAdContainsPath(ns, mockPathPreix+"origin1/"+fmt.Sprint(5)), "Returned namespace path does not match what's added")
 		assert.True(t, namespaceAdContainsPath(ns, mockPathPreix+"origin2/"+fmt.Sprint(9)), "Returned namespace path does not match what's added")
-		mockOriginServerAd.URL = oldServerUrl
 	})
 	t.Run("one-cache-namespace-entry", func(t *testing.T) {
 		setup()
@@ -188,6 +185,47 @@ func TestListServerAds(t *testing.T) {
 		require.Equal(t, 1, len(adsCache))
 		assert.EqualValues(t, &mockCacheAd, adsCache[0])
 	})
+
+	t.Run("same-name-different-url", func(t *testing.T) {
+		serverAds.DeleteAll()
+		staleServerAds.DeleteAll()
+		filteredServersMutex.Lock()
+		tmp := filteredServers
+		filteredServers = map[string]filterType{mockOriginServerAd.Name: tempFiltered}
+		filteredServersMutex.Unlock()
+		t.Cleanup(func() {
+			serverAds.DeleteAll()
+			filteredServersMutex.Lock()
+			filteredServers = tmp
+			filteredServersMutex.Unlock()
+		})
+
+		secondOrigin := mockOriginServerAd
+		secondOrigin.URL = url.URL{Scheme: "https", Host: "origin2.com"}
+		for _, ad := range []server_structs.ServerAd{mockOriginServerAd, secondOrigin} {
+			serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+				ServerAd:     ad,
+				NamespaceAds: []server_structs.NamespaceAdV2{},
+			}, ttlcache.DefaultTTL)
+		}
+
+		adsOrigin := listAdvertisement([]server_structs.ServerType{server_structs.OriginType})
+		require.Len(t, adsOrigin, 2)
+		urls := []string{adsOrigin[0].URL.String(), adsOrigin[1].URL.String()}
+		assert.ElementsMatch(t, []string{mockOriginServerAd.URL.String(), secondOrigin.URL.String()}, urls)
+
+		// The temporary filter is kept while a server with the same name is still around
+		serverAds.Delete(mockOriginServerAd.URL.String())
+		removeTempFilter(mockOriginServerAd)
+		filtered, ft := checkFilter(mockOriginServerAd.Name)
+		assert.True(t, filtered)
+		assert.Equal(t, tempFiltered, ft)
+
+		serverAds.Delete(secondOrigin.URL.String())
+		removeTempFilter(secondOrigin)
+		filtered, _ = checkFilter(mockOriginServerAd.Name)
+		assert.False(t, filtered)
+	})
 }
 
 func TestCheckFilter(t *testing.T) {
@@ -252,3 +290,58 @@ func TestCheckFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildFederationGraph(t *testing.T) {
+	serverAds.DeleteAll()
+	filteredServersMutex.Lock()
+	tmp := filteredServers
+	filteredServers = map[string]filterType{}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		filteredServers = tmp
+		filteredServersMutex.Unlock()
+	})
+
+	sharedNs := server_structs.NamespaceAdV2{Path: "/shared"}
+	soloNs := server_structs.NamespaceAdV2{Path: "/solo"}
+	secondOriginAd := mockOriginServerAd
+	secondOriginAd.Name = "second-origin-server"
+	secondOriginAd.URL = url.URL{Host: "origin2.com", Scheme: "https"}
+
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockOriginServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{sharedNs, soloNs},
+	}, ttlcache.DefaultTTL)
+	serverAds.Set(secondOriginAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     secondOriginAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{sharedNs},
+	}, ttlcache.DefaultTTL)
+	serverAds.Set(mockCacheServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockCacheServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{sharedNs},
+	}, ttlcache.DefaultTTL)
+
+	graph := buildFederationGraph()
+	assert.Len(t, graph.Servers, 3)
+	require.Len(t, graph.Namespaces, 2)
+	assert.Equal(t, "/shared", graph.Namespaces[0].Path)
+	assert.Equal(t, 2, graph.Namespaces[0].OriginCount)
+	assert.Equal(t, 1, graph.Namespaces[0].CacheCount)
+	assert.Equal(t, []string{mockCacheServerAd.URL.String()}, graph.Namespaces[0].Caches)
+	assert.False(t, graph.Namespaces[0].UnderReplicated)
+	assert.Equal(t, "/solo", graph.Namespaces[1].Path)
+	assert.Equal(t, []string{mockOriginServerAd.URL.String()}, graph.Namespaces[1].Origins)
+	assert.True(t, graph.Namespaces[1].UnderReplicated)
+
+	// Filtered servers are not routable, so they are left out of the graph
+	filteredServersMutex.Lock()
+	filteredServers[secondOriginAd.Name] = tempFiltered
+	filteredServersMutex.Unlock()
+	graph = buildFederationGraph()
+	assert.Len(t, graph.Servers, 2)
+	require.Len(t, graph.Namespaces, 2)
+	assert.Equal(t, 1, graph.Namespaces[0].OriginCount)
+	assert.True(t, graph.Namespaces[0].UnderReplicated)
+}
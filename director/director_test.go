@@ -32,6 +32,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,11 +41,13 @@ import (
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/test_utils"
@@ -258,7 +261,7 @@ func TestDirectorRegistration(t *testing.T) {
 		jwks := jwk.NewSet()
 		err := jwks.AddKey(key)
 		require.NoError(t, err)
-		namespaceKeys.Set(ts.URL+"/api/v1.0/registry"+ns+"/.well-known/issuer.jwks", jwks, ttlcache.DefaultTTL)
+		namespaceKeys.Set(ts.URL+"/api/v1.0/registry"+ns+"/.well-known/issuer.jwks", &namespaceKeysEntry{Keys: jwks}, ttlcache.DefaultTTL)
 	}
 
 	teardown := func() {
@@ -323,6 +326,10 @@ func TestDirectorRegistration(t *testing.T) {
 			Namespaces: []server_structs.NamespaceAdV2{{
 				Path:   "/foo/bar",
 				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{
+					Strategy:         server_structs.OAuthStrategy,
+					CredentialIssuer: isurl,
+				}},
 			}},
 		}
 
@@ -345,6 +352,39 @@ func TestDirectorRegistration(t *testing.T) {
 		teardown()
 	})
 
+	t.Run("invalid-namespace-V2", func(t *testing.T) {
+		c, r, w := setupContext()
+		pKey, token, _ := generateToken()
+		publicKey, err := jwk.PublicKeyOf(pKey)
+		assert.NoError(t, err, "Error creating public key from private key")
+
+		setupJwksCache(t, "/foo/bar", publicKey)
+
+		// A namespace that requires tokens but advertises neither an issuer nor a way to get tokens
+		ad := server_structs.OriginAdvertiseV2{
+			BrokerURL: "https://broker-url.org",
+			DataURL:   "https://or-url.org",
+			Name:      "test",
+			Namespaces: []server_structs.NamespaceAdV2{{
+				Path: "/foo/bar",
+			}},
+		}
+
+		jsonad, err := json.Marshal(ad)
+		assert.NoError(t, err, "Error marshalling OriginAdvertise")
+
+		setupRequest(c, r, jsonad, token, server_structs.OriginType)
+
+		r.ServeHTTP(w, c.Request)
+
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		body, err := io.ReadAll(w.Result().Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "/foo/bar is not publicly readable but has no token issuer")
+		assert.Nil(t, serverAds.Get("https://or-url.org"), "The origin shouldn't be in the director cache")
+		teardown()
+	})
+
 	// Now repeat the above test, but with an invalid token
 	t.Run("invalid-token-V1", func(t *testing.T) {
 		c, r, w := setupContext()
@@ -399,8 +439,9 @@ func TestDirectorRegistration(t *testing.T) {
 		isurl.Path = ts.URL
 
 		ad := server_structs.OriginAdvertiseV2{Name: "test", DataURL: "https://or-url.org", Namespaces: []server_structs.NamespaceAdV2{{
-			Path:   "/foo/bar",
-			Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Path:       "/foo/bar",
+			Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 		}}}
 
 		jsonad, err := json.Marshal(ad)
@@ -419,6 +460,51 @@ func TestDirectorRegistration(t *testing.T) {
 		teardown()
 	})
 
+	t.Run("invalid-token-does-not-consume-name-limit", func(t *testing.T) {
+		viper.Set("Director.AdvertiseRateLimit", 1)
+		t.Cleanup(func() {
+			viper.Set("Director.AdvertiseRateLimit", 0)
+			advertiseLimiters.DeleteAll()
+			teardown()
+		})
+		advertiseLimiters.DeleteAll()
+
+		pKey, token, _ := generateToken()
+		publicKey, err := jwk.PublicKeyOf(pKey)
+		require.NoError(t, err)
+		setupJwksCache(t, "/foo/bar", publicKey)
+		_, forgedToken, _ := generateToken()
+
+		isurl := url.URL{}
+		isurl.Path = ts.URL
+		ad := server_structs.OriginAdvertiseV2{Name: "test", DataURL: "https://or-url.org", Namespaces: []server_structs.NamespaceAdV2{{
+			Path:       "/foo/bar",
+			Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
+		}}}
+		jsonad, err := json.Marshal(ad)
+		require.NoError(t, err)
+
+		// Forged advertisements under the server's name are rejected for their token...
+		for i := 0; i < 2; i++ {
+			c, r, w := setupContext()
+			setupRequest(c, r, jsonad, forgedToken, server_structs.OriginType)
+			r.ServeHTTP(w, c.Request)
+			assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+		}
+
+		// ...without exhausting the real server's bucket
+		c, r, w := setupContext()
+		setupRequest(c, r, jsonad, token, server_structs.OriginType)
+		r.ServeHTTP(w, c.Request)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		c, r, w = setupContext()
+		setupRequest(c, r, jsonad, token, server_structs.OriginType)
+		r.ServeHTTP(w, c.Request)
+		assert.Equal(t, http.StatusTooManyRequests, w.Result().StatusCode)
+	})
+
 	t.Run("valid-token-with-web-url-V1", func(t *testing.T) {
 		c, r, w := setupContext()
 		pKey, token, _ := generateToken()
@@ -463,8 +549,9 @@ func TestDirectorRegistration(t *testing.T) {
 		isurl.Path = ts.URL
 
 		ad := server_structs.OriginAdvertiseV2{DataURL: "https://data-url.org", WebURL: "https://localhost:8844", Namespaces: []server_structs.NamespaceAdV2{{
-			Path:   "/foo/bar",
-			Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Path:       "/foo/bar",
+			Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 		}}}
 
 		jsonad, err := json.Marshal(ad)
@@ -517,7 +604,9 @@ func TestDirectorRegistration(t *testing.T) {
 		isurl.Path = ts.URL
 
 		ad := server_structs.OriginAdvertiseV2{DataURL: "https://or-url.org", Namespaces: []server_structs.NamespaceAdV2{{Path: "/foo/bar",
-			Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}}}}}
+			Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+			Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
+		}}}
 
 		jsonad, err := json.Marshal(ad)
 		assert.NoError(t, err, "Error marshalling OriginAdvertise")
@@ -551,8 +640,9 @@ func TestDirectorRegistration(t *testing.T) {
 			BrokerURL: brokerUrl,
 			Name:      "test",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}},
 		}
 
@@ -599,8 +689,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}}}
 
 		jsonad, err := json.Marshal(ad)
@@ -633,8 +724,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}}}
 
 		jsonad, err := json.Marshal(ad)
@@ -666,8 +758,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}}}
 
 		jsonad, err := json.Marshal(ad)
@@ -700,8 +793,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}},
 			StorageType:         "s3",
 			DisableDirectorTest: true,
@@ -724,6 +818,57 @@ func TestDirectorRegistration(t *testing.T) {
 		teardown()
 	})
 
+	t.Run("origin-with-alternate-urls", func(t *testing.T) {
+		c, r, w := setupContext()
+		pKey, token, _ := generateToken()
+		publicKey, err := jwk.PublicKeyOf(pKey)
+		assert.NoError(t, err, "Error creating public key from private key")
+		setupJwksCache(t, "/origins/test", publicKey) // for origin
+		setupJwksCache(t, "/foo/bar", publicKey)      // for namespace
+
+		isurl := url.URL{}
+		isurl.Path = ts.URL
+
+		ad := server_structs.OriginAdvertiseV2{
+			Name:           "Human-readable name",
+			RegistryPrefix: "/origins/test",
+			DataURL:        "https://data-url.org",
+			WebURL:         "https://localhost:8844",
+			Namespaces: []server_structs.NamespaceAdV2{{
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
+			}},
+			AltDataURLs: []string{"https://data-alias.org:8443"},
+		}
+
+		jsonad, err := json.Marshal(ad)
+		assert.NoError(t, err, "Error marshalling OriginAdvertise")
+
+		setupRequest(c, r, jsonad, token, server_structs.OriginType)
+
+		r.ServeHTTP(w, c.Request)
+
+		require.Equal(t, 200, w.Result().StatusCode, w.Body.String())
+		get := serverAds.Get("https://data-url.org")
+		require.NotNil(t, get, "Origin fail to register at serverAds")
+		assert.Equal(t, []url.URL{{Scheme: "https", Host: "data-alias.org:8443"}}, get.Value().AltURLs)
+		teardown()
+
+		// An alternate URL without a host is rejected
+		c, r, w = setupContext()
+		setupJwksCache(t, "/origins/test", publicKey)
+		setupJwksCache(t, "/foo/bar", publicKey)
+		ad.AltDataURLs = []string{"data-alias.org"}
+		jsonad, err = json.Marshal(ad)
+		assert.NoError(t, err, "Error marshalling OriginAdvertise")
+		setupRequest(c, r, jsonad, token, server_structs.OriginType)
+		r.ServeHTTP(w, c.Request)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Nil(t, serverAds.Get("https://data-url.org"))
+		teardown()
+	})
+
 	t.Run("origin-s3-type-and-enable-test", func(t *testing.T) {
 		c, r, w := setupContext()
 		pKey, token, _ := generateToken()
@@ -741,8 +886,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}},
 			StorageType: "s3",
 		}
@@ -781,8 +927,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}},
 			StorageType: "posix",
 		}
@@ -821,8 +968,9 @@ func TestDirectorRegistration(t *testing.T) {
 			DataURL:        "https://data-url.org",
 			WebURL:         "https://localhost:8844",
 			Namespaces: []server_structs.NamespaceAdV2{{
-				Path:   "/foo/bar",
-				Issuer: []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Path:       "/foo/bar",
+				Issuer:     []server_structs.TokenIssuer{{IssuerUrl: isurl}},
+				Generation: []server_structs.TokenGen{{Strategy: server_structs.OAuthStrategy, CredentialIssuer: isurl}},
 			}},
 			StorageType:         "posix",
 			DisableDirectorTest: true,
@@ -873,6 +1021,71 @@ func TestDirectorRegistration(t *testing.T) {
 		assert.False(t, getAd.DisableDirectorTest)
 		teardown()
 	})
+
+	t.Run("signed-advertisement", func(t *testing.T) {
+		t.Cleanup(func() { viper.Set("Director.RequireSignedAdvertisements", false) })
+		pKey, unsignedToken, issuerURL := generateToken()
+		publicKey, err := jwk.PublicKeyOf(pKey)
+		require.NoError(t, err)
+
+		ad := server_structs.OriginAdvertiseV2{
+			Name:           "test",
+			RegistryPrefix: "/origins/test",
+			DataURL:        "https://signed-origin.org",
+			Namespaces:     []server_structs.NamespaceAdV2{{Path: "/foo/bar", PublicRead: true}},
+		}
+		jsonad, err := json.Marshal(ad)
+		require.NoError(t, err)
+		signToken := func(body []byte) string {
+			tok, err := jwt.NewBuilder().
+				Issuer(issuerURL.String()).
+				Claim("scope", token_scopes.Pelican_Advertise.String()).
+				Claim(server_structs.AdvertisementDigestClaim, server_structs.AdvertisementDigest(body)).
+				Audience([]string{"director.test"}).
+				Subject("origin").
+				Build()
+			require.NoError(t, err)
+			signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, pKey))
+			require.NoError(t, err)
+			return string(signed)
+		}
+		advertise := func(body []byte, token string) int {
+			setupJwksCache(t, "/origins/test", publicKey)
+			setupJwksCache(t, "/foo/bar", publicKey)
+			c, r, w := setupContext()
+			setupRequest(c, r, body, token, server_structs.OriginType)
+			r.ServeHTTP(w, c.Request)
+			return w.Result().StatusCode
+		}
+		rejected := func(reason string) float64 {
+			return testutil.ToFloat64(metrics.PelicanDirectorAdvertisementsRejectedTotal.WithLabelValues(string(server_structs.OriginType), reason))
+		}
+
+		assert.Equal(t, http.StatusOK, advertise(jsonad, signToken(jsonad)))
+		assert.NotNil(t, serverAds.Get("https://signed-origin.org"))
+		teardown()
+
+		// A token signing another advertisement can't be replayed with this one
+		rogueAd := ad
+		rogueAd.DataURL = "https://rogue-origin.org"
+		rogueJsonad, err := json.Marshal(rogueAd)
+		require.NoError(t, err)
+		before := rejected("invalid_signature")
+		assert.Equal(t, http.StatusForbidden, advertise(rogueJsonad, signToken(jsonad)))
+		assert.Nil(t, serverAds.Get("https://rogue-origin.org"))
+		assert.Equal(t, before+1, rejected("invalid_signature"))
+		teardown()
+
+		// Unsigned advertisements are only accepted unless signatures are required
+		assert.Equal(t, http.StatusOK, advertise(jsonad, unsignedToken))
+		teardown()
+		viper.Set("Director.RequireSignedAdvertisements", true)
+		before = rejected("unsigned")
+		assert.Equal(t, http.StatusForbidden, advertise(jsonad, unsignedToken))
+		assert.Equal(t, before+1, rejected("unsigned"))
+		assert.Equal(t, http.StatusOK, advertise(jsonad, signToken(jsonad)))
+		teardown()
+	})
 }
 
 func TestGetAuthzEscaped(t *testing.T) {
@@ -1582,7 +1795,176 @@ func TestRedirects(t *testing.T) {
 		assert.NotEmpty(t, c.Writer.Header().Get("X-Pelican-Token-Generation"))
 		assert.NotEmpty(t, c.Writer.Header().Get("X-Pelican-Namespace"))
 	})
+
+	t.Run("conditional-requests-redirected", func(t *testing.T) {
+		viper.Reset()
+		t.Cleanup(func() {
+			viper.Reset()
+		})
+
+		viper.Set("Director.CacheSortMethod", "random")
+
+		// The director never answers a conditional request itself; the client follows the
+		// redirect with the same headers and the cache or origin decides whether to send a 304
+		for _, endpoint := range []func(*gin.Context){redirectToCache, redirectToOrigin} {
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				req, _ := http.NewRequest(method, "/my/server", nil)
+				req.Header.Add("User-Agent", "pelican-v7.999.999")
+				req.Header.Add("X-Real-Ip", "128.104.153.60")
+				req.Header.Add("If-None-Match", `"v1"`)
+				req.Header.Add("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+				recorder := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(recorder)
+				c.Request = req
+				endpoint(c)
+
+				assert.Equal(t, http.StatusTemporaryRedirect, c.Writer.Status())
+				assert.NotEmpty(t, c.Writer.Header().Get("Location"))
+				assert.Empty(t, c.Writer.Header().Get("ETag"))
+				assert.Empty(t, c.Writer.Header().Get("Last-Modified"))
+			}
+		}
+	})
+
+	t.Run("candidate-list-with-json-accept", func(t *testing.T) {
+		viper.Reset()
+		t.Cleanup(func() {
+			viper.Reset()
+		})
+
+		viper.Set("Director.CacheSortMethod", "random")
+
+		for _, endpoint := range []func(*gin.Context){redirectToCache, redirectToOrigin} {
+			req, _ := http.NewRequest("GET", "/my/server", nil)
+			req.Header.Add("User-Agent", "pelican-v7.999.999")
+			req.Header.Add("X-Real-Ip", "128.104.153.60")
+			req.Header.Add("Accept", "application/json")
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = req
+			endpoint(c)
+
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Empty(t, recorder.Header().Get("Location"))
+			res := server_structs.RedirectCandidatesResp{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+			assert.Equal(t, "/my/server", res.Namespace)
+			require.NotEmpty(t, res.Candidates)
+			// The candidates are listed in the order of the Link header
+			links := strings.Split(recorder.Header().Get("Link"), ", ")
+			require.Len(t, res.Candidates, len(links))
+			for idx, candidate := range res.Candidates {
+				assert.Equal(t, idx+1, candidate.Priority)
+				assert.Contains(t, links[idx], "<"+candidate.URL+">")
+			}
+		}
+
+		// Wildcards still get a redirect
+		req, _ := http.NewRequest("GET", "/my/server", nil)
+		req.Header.Add("User-Agent", "pelican-v7.999.999")
+		req.Header.Add("X-Real-Ip", "128.104.153.60")
+		req.Header.Add("Accept", "*/*")
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = req
+		redirectToCache(c)
+		assert.NotEmpty(t, c.Writer.Header().Get("Location"))
+	})
+
+	t.Run("metalink-link-headers", func(t *testing.T) {
+		viper.Reset()
+		t.Cleanup(func() {
+			viper.Reset()
+		})
+
+		// Consistent hashing gives the same ranking for every request of the object
+		viper.Set("Director.CacheSelectionStrategy", "consistent-hash")
+		request := func(accept string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/my/server?authz=test-token", nil)
+			req.Header.Add("User-Agent", "pelican-v7.999.999")
+			req.Header.Add("X-Real-Ip", "128.104.153.60")
+			if accept != "" {
+				req.Header.Add("Accept", accept)
+			}
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = req
+			redirectToCache(c)
+			return recorder
+		}
+
+		candidatesRecorder := request("application/json")
+		require.Equal(t, http.StatusOK, candidatesRecorder.Code)
+		candidates := server_structs.RedirectCandidatesResp{}
+		require.NoError(t, json.Unmarshal(candidatesRecorder.Body.Bytes(), &candidates))
+
+		// aria2 asks for metalinks alongside any other media type
+		recorder := request("*/*,application/metalink4+xml,application/metalink+xml")
+		links := recorder.Header().Values("Link")
+		require.Len(t, links, len(candidates.Candidates))
+		for idx, link := range links {
+			assert.Contains(t, link, "rel=duplicate")
+			assert.Contains(t, link, fmt.Sprintf("pri=%d;", idx+1))
+			assert.True(t, strings.HasPrefix(link, "<"+candidates.Candidates[idx].URL+"?authz=test-token>"), link)
+		}
+		assert.True(t, strings.HasPrefix(links[0], "<"+recorder.Header().Get("Location")+">"))
+
+		// Without the Accept header, Pelican clients get the single Link header without the token
+		recorder = request("")
+		require.Len(t, recorder.Header().Values("Link"), 1)
+		assert.NotContains(t, recorder.Header().Get("Link"), "authz")
+
+		// Unless metalinks are enabled for every client
+		viper.Set("Director.MetalinkHeaders", true)
+		recorder = request("")
+		assert.Len(t, recorder.Header().Values("Link"), len(candidates.Candidates))
+	})
+
+	t.Run("redirect-latency-observed", func(t *testing.T) {
+		// The cache and origin redirects above each record the time spent selecting servers
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.PelicanDirectorRedirectLatency, "pelican_director_redirect_latency_seconds"))
+	})
 }
+func TestSetLinkHeaderAltURLs(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	reloadedDirectorConfig.Store(nil)
+
+	ads := []server_structs.ServerAd{
+		{
+			URL:     url.URL{Scheme: "https", Host: "cache1.org:8443"},
+			AltURLs: []url.URL{{Scheme: "https", Host: "cache1-alt.org:8443"}},
+		},
+		{URL: url.URL{Scheme: "https", Host: "cache2.org:8443"}},
+	}
+	namespaceAd := server_structs.NamespaceAdV2{Path: "/foo", Caps: server_structs.Capabilities{PublicReads: true}}
+
+	setHeader := func(accept string) http.Header {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/foo/bar?authz=test-token", nil)
+		c.Request.Header.Set("Accept", accept)
+		setLinkHeader(c, "/foo/bar", namespaceAd, ads, 1, url.Values{"authz": []string{"test-token"}})
+		return recorder.Header()
+	}
+
+	t.Run("pelican-clients", func(t *testing.T) {
+		links := setHeader("*/*").Values("Link")
+		require.Len(t, links, 1)
+		assert.Equal(t, `<https://cache1.org:8443/foo/bar>; rel="duplicate"; pri=1; depth=1, `+
+			`<https://cache1-alt.org:8443/foo/bar>; rel="duplicate"; pri=1; depth=1, `+
+			`<https://cache2.org:8443/foo/bar>; rel="duplicate"; pri=2; depth=1`, links[0])
+	})
+
+	t.Run("metalink-clients", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`<https://cache1.org:8443/foo/bar?authz=test-token>; rel=duplicate; pri=1; depth=1`,
+			`<https://cache1-alt.org:8443/foo/bar?authz=test-token>; rel=duplicate; pri=1; depth=1`,
+			`<https://cache2.org:8443/foo/bar?authz=test-token>; rel=duplicate; pri=2; depth=1`,
+		}, setHeader("application/metalink4+xml").Values("Link"))
+	})
+}
+
 func TestHeaderGenFuncs(t *testing.T) {
 	issUrl := url.URL{
 		Scheme: "https",
@@ -1677,6 +2059,24 @@ func TestHeaderGenFuncs(t *testing.T) {
 		assert.Contains(t, cPub.Writer.Header().Get("X-Pelican-Namespace"), "namespace=/different/server")
 		assert.Contains(t, cPub.Writer.Header().Get("X-Pelican-Namespace"), "require-token=false")
 		assert.NotContains(t, cPub.Writer.Header().Get("X-Pelican-Namespace"), "collections-url")
+		assert.NotContains(t, cPub.Writer.Header().Get("X-Pelican-Namespace"), "immutable")
+
+		immutableRecorder := httptest.NewRecorder()
+		cImmutable, _ := gin.CreateTestContext(immutableRecorder)
+		cImmutable.Request = pubReq
+		immutableNamespaceAd := publicNamespaceAd
+		immutableNamespaceAd.Caps.Immutable = true
+		generateXNamespaceHeader(cImmutable, immutableNamespaceAd, "")
+		assert.Contains(t, cImmutable.Writer.Header().Get("X-Pelican-Namespace"), "immutable=true")
+		assert.NotContains(t, cImmutable.Writer.Header().Get("X-Pelican-Namespace"), "staged-uploads")
+
+		stagedRecorder := httptest.NewRecorder()
+		cStaged, _ := gin.CreateTestContext(stagedRecorder)
+		cStaged.Request = pubReq
+		stagedNamespaceAd := publicNamespaceAd
+		stagedNamespaceAd.StagedUploads = true
+		generateXNamespaceHeader(cStaged, stagedNamespaceAd, "")
+		assert.Contains(t, cStaged.Writer.Header().Get("X-Pelican-Namespace"), "staged-uploads=true")
 	})
 }
 
@@ -1914,6 +2314,90 @@ func TestHandleAllowServer(t *testing.T) {
 	})
 }
 
+func TestFilterServerAdminAuth(t *testing.T) {
+	ctx, cancel, egrp := test_utils.TestContext(context.Background(), t)
+	defer func() { require.NoError(t, egrp.Wait()) }()
+	defer cancel()
+
+	viper.Reset()
+	t.Cleanup(func() {
+		viper.Reset()
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		filteredServers = map[string]filterType{}
+	})
+	mockDirectorUrl := "https://fake-director.org:8888"
+	viper.Set("Server.ExternalWebUrl", mockDirectorUrl)
+	viper.Set("IssuerKey", filepath.Join(t.TempDir(), "testKey"))
+	viper.Set("ConfigDir", t.TempDir())
+	config.InitConfig()
+	require.NoError(t, config.InitServer(ctx, config.DirectorType))
+	_, err := config.GetIssuerPublicJWKS()
+	require.NoError(t, err)
+
+	createToken := func(scopes ...token_scopes.TokenScope) string {
+		tokCfg := token.NewWLCGToken()
+		tokCfg.Lifetime = time.Minute
+		tokCfg.Issuer = mockDirectorUrl
+		tokCfg.Subject = "admin-tool"
+		tokCfg.AddAudienceAny()
+		tokCfg.AddScopes(scopes...)
+		tok, err := tokCfg.CreateToken()
+		require.NoError(t, err)
+		return tok
+	}
+
+	router := gin.Default()
+	RegisterDirectorWebAPI(router.Group("/"))
+
+	t.Run("missing-token-returns-401", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1.0/director_ui/servers/filter/mock-auth", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.NotContains(t, filteredServers, "mock-auth")
+	})
+
+	t.Run("non-admin-token-returns-403", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1.0/director_ui/servers/filter/mock-auth", nil)
+		req.Header.Set("Authorization", "Bearer "+createToken(token_scopes.WebUi_Access))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.NotContains(t, filteredServers, "mock-auth")
+	})
+
+	t.Run("admin-token-filters-and-allows-server", func(t *testing.T) {
+		adminToken := createToken(token_scopes.WebUi_Admin)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1.0/director_ui/servers/filter/mock-auth", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		filteredServersMutex.RLock()
+		assert.Equal(t, tempFiltered, filteredServers["mock-auth"])
+		filteredServersMutex.RUnlock()
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPatch, "/api/v1.0/director_ui/servers/allow/mock-auth", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.NotContains(t, filteredServers, "mock-auth")
+	})
+}
+
 func TestGetRedirectUrl(t *testing.T) {
 	adFromTopo := server_structs.ServerAd{
 		URL: url.URL{
@@ -2009,3 +2493,400 @@ func TestGetFinalRedirectURL(t *testing.T) {
 		assert.Equal(t, "https://example.org:8444?key1=val1&key2=val2&raw="+encodedVal, get)
 	})
 }
+
+func TestRecordNamespaceRequest(t *testing.T) {
+	before := testutil.ToFloat64(metrics.PelicanDirectorNamespaceRequestsTotal.WithLabelValues("/my/namespace"))
+	beforeOther := testutil.ToFloat64(metrics.PelicanDirectorNamespaceRequestsTotal.WithLabelValues("other"))
+
+	recordNamespaceRequest("/my/namespace")
+	recordNamespaceRequest("/my/namespace")
+	// Paths matching no advertised namespace are collapsed to "other"
+	recordNamespaceRequest("")
+
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.PelicanDirectorNamespaceRequestsTotal.WithLabelValues("/my/namespace")))
+	assert.Equal(t, beforeOther+1, testutil.ToFloat64(metrics.PelicanDirectorNamespaceRequestsTotal.WithLabelValues("other")))
+}
+
+func TestGetRedirectStatusCode(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	getCode := func(method string, hint string) int {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest(method, "/api/v1.0/director/origin/foo", nil)
+		if hint != "" {
+			ginCtx.Request.Header.Set("X-Pelican-Redirect-Code", hint)
+		}
+		return getRedirectStatusCode(ginCtx)
+	}
+
+	viper.Reset()
+	assert.Equal(t, http.StatusTemporaryRedirect, getCode(http.MethodGet, ""))
+
+	viper.Set("Director.RedirectStatusCode", http.StatusFound)
+	assert.Equal(t, http.StatusFound, getCode(http.MethodGet, ""))
+	assert.Equal(t, http.StatusFound, getCode(http.MethodHead, ""))
+	assert.Equal(t, http.StatusPermanentRedirect, getCode(http.MethodGet, "308"))
+	assert.Equal(t, http.StatusFound, getCode(http.MethodGet, "301"), "invalid hints are ignored")
+
+	// Uploads keep their body across the redirect
+	assert.Equal(t, http.StatusTemporaryRedirect, getCode(http.MethodPut, ""))
+	assert.Equal(t, http.StatusTemporaryRedirect, getCode(http.MethodPut, "302"))
+	assert.Equal(t, http.StatusPermanentRedirect, getCode(http.MethodPut, "308"))
+
+	viper.Set("Director.RedirectStatusCode", http.StatusTemporaryRedirect)
+	assert.Equal(t, http.StatusFound, getCode(http.MethodGet, "302"))
+}
+
+func TestNamespaceCapabilityCheck(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+
+	readOnlyOrigin := mockOriginServerAd
+	readOnlyOrigin.Caps = server_structs.Capabilities{Reads: true}
+	serverAds.Set(readOnlyOrigin.URL.String(), &server_structs.Advertisement{
+		ServerAd:     readOnlyOrigin,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: server_structs.Capabilities{Reads: true}}},
+	}, ttlcache.DefaultTTL)
+
+	router := gin.New()
+	router.Handle(http.MethodGet, "/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.Handle(http.MethodPut, "/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.Handle("PROPFIND", "/api/v1.0/director/origin/*any", redirectToOrigin)
+	doRequest := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1.0/director/origin/foo/bar?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusTemporaryRedirect, doRequest(http.MethodGet).Code)
+	w := doRequest(http.MethodPut)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Contains(t, w.Body.String(), "writes capability")
+	w = doRequest("PROPFIND")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Contains(t, w.Body.String(), "listings capability")
+
+	// The capabilities are merged across the origins serving the namespace
+	writableOrigin := readOnlyOrigin
+	writableOrigin.Name = "writable-origin"
+	writableOrigin.URL = url.URL{Scheme: "https", Host: "writable-origin.com"}
+	writableOrigin.Writes = true
+	serverAds.Set(writableOrigin.URL.String(), &server_structs.Advertisement{
+		ServerAd:     writableOrigin,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: server_structs.Capabilities{Reads: true, Writes: true}}},
+	}, ttlcache.DefaultTTL)
+	w = doRequest(http.MethodPut)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Location"), writableOrigin.URL.Host)
+
+	// Without any advertised capabilities, the origins are left to decide
+	serverAds.DeleteAll()
+	uncappedOrigin := mockOriginServerAd
+	uncappedOrigin.Writes = true
+	serverAds.Set(uncappedOrigin.URL.String(), &server_structs.Advertisement{
+		ServerAd:     uncappedOrigin,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}},
+	}, ttlcache.DefaultTTL)
+	assert.Equal(t, http.StatusTemporaryRedirect, doRequest(http.MethodGet).Code)
+	assert.Equal(t, http.StatusTemporaryRedirect, doRequest(http.MethodPut).Code)
+}
+
+func TestOriginReadOnlyToggle(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Reset()
+
+	// Mimic the origin re-advertising itself as it enters and leaves read-only mode
+	advertise := func(writes bool) {
+		origin := mockOriginServerAd
+		origin.Writes = writes
+		origin.Listings = true
+		origin.Caps = server_structs.Capabilities{Reads: true, Writes: writes, Listings: true}
+		serverAds.Set(origin.URL.String(), &server_structs.Advertisement{
+			ServerAd:     origin,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: origin.Caps}},
+		}, ttlcache.DefaultTTL)
+	}
+
+	router := gin.New()
+	router.Handle(http.MethodGet, "/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.Handle(http.MethodPut, "/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.Handle("PROPFIND", "/api/v1.0/director/origin/*any", redirectToOrigin)
+	doRequest := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1.0/director/origin/foo/bar?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	advertise(true)
+	w := doRequest(http.MethodPut)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+
+	advertise(false)
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(http.MethodPut).Code)
+	w = doRequest(http.MethodGet)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Location"), mockOriginServerAd.URL.Host)
+	assert.Equal(t, http.StatusTemporaryRedirect, doRequest("PROPFIND").Code)
+
+	advertise(true)
+	w = doRequest(http.MethodPut)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+}
+
+func TestMinOriginFreeSpace(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.MinOriginFreeSpace", "10GB")
+
+	setOrigin := func(name string, freeSpace uint64) server_structs.ServerAd {
+		origin := mockOriginServerAd
+		origin.Name = name
+		origin.URL = url.URL{Scheme: "https", Host: name + ".com"}
+		origin.Writes = true
+		origin.Caps = server_structs.Capabilities{Reads: true, Writes: true}
+		origin.FreeSpace = freeSpace
+		origin.TotalSpace = 100 << 30
+		serverAds.Set(origin.URL.String(), &server_structs.Advertisement{
+			ServerAd:     origin,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: origin.Caps}},
+		}, ttlcache.DefaultTTL)
+		return origin
+	}
+	setOrigin("full-origin", 1<<30)
+	spacious := setOrigin("spacious-origin", 50<<30)
+
+	router := gin.New()
+	router.Handle(http.MethodPut, "/api/v1.0/director/origin/*any", redirectToOrigin)
+	doPut := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1.0/director/origin/foo/bar?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The origin below the threshold is never picked for writes
+	for i := 0; i < 20; i++ {
+		w := doPut()
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), spacious.URL.Host)
+	}
+
+	// Uploads fail once every writeable origin is low on space
+	setOrigin("spacious-origin", 5<<30)
+	w := doPut()
+	assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+	assert.Contains(t, w.Body.String(), "enough free space")
+
+	// Without a threshold, the space is ignored
+	viper.Set("Director.MinOriginFreeSpace", "0")
+	assert.Equal(t, http.StatusTemporaryRedirect, doPut().Code)
+}
+
+func TestMaxAdAge(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+
+	setServer := func(ad server_structs.ServerAd, name string, age time.Duration) server_structs.ServerAd {
+		ad.Name = name
+		ad.URL = url.URL{Scheme: "https", Host: name + ".com"}
+		ad.Caps = server_structs.Capabilities{Reads: true}
+		lastAdvertised := time.Now().Add(-age)
+		ad.LastAdvertised = &lastAdvertised
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: ad.Caps}},
+		}, ttlcache.DefaultTTL)
+		return ad
+	}
+	freshOrigin := setServer(mockOriginServerAd, "fresh-origin", 10*time.Second)
+	setServer(mockOriginServerAd, "stale-origin", 10*time.Minute)
+	freshCache := setServer(mockCacheServerAd, "fresh-cache", 10*time.Second)
+	setServer(mockCacheServerAd, "stale-cache", 10*time.Minute)
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.GET("/api/v1.0/director/object/*any", redirectToCache)
+	doRequest := func(reqPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, reqPath, nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("stale-ads-are-skipped", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			w := doRequest("/api/v1.0/director/origin/foo/bar?skipstat&max_ad_age=60")
+			require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Location"), freshOrigin.URL.Host)
+
+			w = doRequest("/api/v1.0/director/object/foo/bar?skipstat&max_ad_age=60")
+			require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Location"), freshCache.URL.Host)
+			assert.NotContains(t, w.Header().Get("Link"), "stale-cache")
+		}
+	})
+
+	t.Run("all-ads-too-stale", func(t *testing.T) {
+		w := doRequest("/api/v1.0/director/origin/foo/bar?skipstat&max_ad_age=5")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "max_ad_age")
+
+		w = doRequest("/api/v1.0/director/object/foo/bar?skipstat&max_ad_age=5")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("invalid-max-ad-age", func(t *testing.T) {
+		for _, maxAge := range []string{"", "0", "-1", "1m"} {
+			w := doRequest("/api/v1.0/director/origin/foo/bar?skipstat&max_ad_age=" + maxAge)
+			assert.Equal(t, http.StatusBadRequest, w.Code, maxAge)
+		}
+	})
+}
+
+func TestRedirectPolicies(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		namespaceRedirectPolicies.Store(nil)
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+
+	setServer := func(ad server_structs.ServerAd, name string, directReads bool) server_structs.ServerAd {
+		ad.Name = name
+		ad.URL = url.URL{Scheme: "https", Host: name + ".com"}
+		ad.DirectReads = directReads
+		ad.Caps = server_structs.Capabilities{Reads: true, DirectReads: directReads}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd: ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{
+				{Path: "/foo", Caps: server_structs.Capabilities{Reads: true, DirectReads: true}},
+				{Path: "/bar", Caps: server_structs.Capabilities{Reads: true, DirectReads: true}},
+			},
+		}, ttlcache.DefaultTTL)
+		return ad
+	}
+	origin := setServer(mockOriginServerAd, "direct-origin", true)
+	cache1 := setServer(mockCacheServerAd, "cache-one", false)
+	cache2 := setServer(mockCacheServerAd, "cache-two", false)
+	setPolicy := func(policy server_structs.RedirectPolicy) {
+		namespaceRedirectPolicies.Store(&map[string]server_structs.RedirectPolicy{"/foo": policy})
+	}
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.GET("/api/v1.0/director/object/*any", redirectToCache)
+	doRequest := func(handler http.Handler, reqPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, reqPath, nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	shortcutRouter := func(defaultResponse string) *gin.Engine {
+		engine := gin.New()
+		engine.Use(ShortcutMiddleware(defaultResponse))
+		return engine
+	}
+
+	t.Run("direct-to-origin-never-uses-caches", func(t *testing.T) {
+		setPolicy(server_structs.RedirectPolicy{Mode: server_structs.RedirectPolicyDirectToOrigin})
+		for i := 0; i < 10; i++ {
+			w := doRequest(router, "/api/v1.0/director/object/foo/bar?skipstat")
+			require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Location"), origin.URL.Host)
+			assert.NotContains(t, w.Header().Get("Link"), "cache-")
+		}
+		// The policy takes precedence over the director's default of redirecting to caches
+		w := doRequest(shortcutRouter("cache"), "/foo/bar?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), origin.URL.Host)
+
+		// Namespaces without a policy are unaffected
+		w = doRequest(router, "/api/v1.0/director/object/bar/baz?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), "cache-")
+	})
+
+	t.Run("direct-to-origin-without-direct-reads", func(t *testing.T) {
+		setPolicy(server_structs.RedirectPolicy{Mode: server_structs.RedirectPolicyDirectToOrigin})
+		setServer(mockOriginServerAd, "direct-origin", false)
+		defer setServer(mockOriginServerAd, "direct-origin", true)
+		w := doRequest(router, "/api/v1.0/director/object/foo/bar?skipstat")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "redirect policy")
+	})
+
+	t.Run("caches-only-never-reads-from-origins", func(t *testing.T) {
+		setPolicy(server_structs.RedirectPolicy{Mode: server_structs.RedirectPolicyCachesOnly})
+		w := doRequest(router, "/api/v1.0/director/origin/foo/bar?skipstat&directread")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "only allows reads through caches")
+
+		// Caches still find the origins of the namespace
+		w = doRequest(router, "/api/v1.0/director/origin/foo/bar?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), origin.URL.Host)
+
+		// The policy takes precedence over the director's default of redirecting to origins
+		w = doRequest(shortcutRouter("origin"), "/foo/bar?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), "cache-")
+		w = doRequest(shortcutRouter("origin"), "/bar/baz?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), origin.URL.Host)
+
+		// Without caches, there's no falling back to the origin allowing direct reads
+		serverAds.Delete(cache1.URL.String())
+		serverAds.Delete(cache2.URL.String())
+		defer setServer(mockCacheServerAd, "cache-one", false)
+		defer setServer(mockCacheServerAd, "cache-two", false)
+		w = doRequest(router, "/api/v1.0/director/object/foo/bar?skipstat")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "redirect policy")
+		w = doRequest(router, "/api/v1.0/director/object/bar/baz?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), origin.URL.Host)
+	})
+
+	t.Run("max-servers", func(t *testing.T) {
+		setPolicy(server_structs.RedirectPolicy{MaxServers: 1})
+		w := doRequest(router, "/api/v1.0/director/object/foo/bar?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Link"), "pri=1")
+		assert.NotContains(t, w.Header().Get("Link"), "pri=2")
+
+		w = doRequest(router, "/api/v1.0/director/object/bar/baz?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Link"), "pri=2")
+	})
+}
@@ -23,6 +23,8 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -33,9 +35,14 @@ import (
 	"github.com/pelicanplatform/pelican/web_ui"
 )
 
+// The response header listing, one per value, the problems the director worked around to answer
+const warningsHeader = "X-Pelican-Warnings"
+
 type (
 	listServerRequest struct {
-		ServerType string `form:"server_type"` // "cache" or "origin"
+		ServerType string   `form:"server_type"` // "cache" or "origin"
+		Labels     []string `form:"label"`       // "key=value" label selectors, all of which a server must match
+		Strict     bool     `form:"strict"`      // Fail rather than skip invalid server ads
 	}
 
 	listServerResponse struct {
@@ -50,15 +57,28 @@ type (
 		URL               string                      `json:"url"`    // This is server's XRootD URL for file transfer
 		WebURL            string                      `json:"webUrl"` // This is server's Web interface and API
 		Type              server_structs.ServerType   `json:"type"`
+		Site              string                      `json:"site,omitempty"`
+		Labels            map[string]string           `json:"labels,omitempty"`
+		FreeSpace         uint64                      `json:"freeSpace,omitempty"`  // The bytes available on the origin's storage
+		TotalSpace        uint64                      `json:"totalSpace,omitempty"` // The size of the origin's storage in bytes
 		Latitude          float64                     `json:"latitude"`
 		Longitude         float64                     `json:"longitude"`
 		Caps              server_structs.Capabilities `json:"capabilities"`
 		Filtered          bool                        `json:"filtered"`
 		FilteredType      string                      `json:"filteredType"`
+		Draining          bool                        `json:"draining"`                // The server is kept out of new redirects until DrainingUntil
+		DrainingUntil     *time.Time                  `json:"drainingUntil,omitempty"` // When a draining server returns to rotation
 		FromTopology      bool                        `json:"fromTopology"`
 		HealthStatus      HealthTestStatus            `json:"healthStatus"`
+		HealthFailures    int                         `json:"healthConsecutiveFailures"` // The number of director tests the server failed in a row
 		IOLoad            float64                     `json:"ioLoad"`
 		NamespacePrefixes []string                    `json:"namespacePrefixes"`
+		Stale             bool                        `json:"stale"`                         // The server stopped advertising and its ad is kept for Director.StaleAdGracePeriod
+		BrokerConnected   bool                        `json:"brokerConnected"`               // Whether the reverse connection tunnel through the broker is known to be live. Always true for servers not behind a broker
+		BrokerStatus      brokerTunnelStatus          `json:"brokerStatus,omitempty"`        // The state of the tunnel through the broker: connected, disconnected, or unknown; empty for servers not behind a broker
+		BrokerHeartbeat   *time.Time                  `json:"brokerLastHeartbeat,omitempty"` // The last time the origin polled its broker
+		AltURLs           []string                    `json:"altUrls,omitempty"`             // Other URLs the server's data endpoint is reachable by
+		UpstreamCaches    []string                    `json:"upstreamCaches,omitempty"`      // The data URLs of the parent caches a cache pulls from
 	}
 
 	statRequest struct {
@@ -72,14 +92,30 @@ type (
 	}
 )
 
-func (req listServerRequest) ToInternalServerType() server_structs.ServerType {
-	if req.ServerType == "cache" {
-		return server_structs.CacheType
+// Parse the "key=value" label selectors of a server listing into the labels to match
+func parseLabelSelectors(selectors []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, selector := range selectors {
+		key, val, found := strings.Cut(selector, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: must be of the form key=value", selector)
+		}
+		if prev, ok := labels[key]; ok && prev != val {
+			return nil, fmt.Errorf("conflicting label selectors for the key %q", key)
+		}
+		labels[key] = val
 	}
-	if req.ServerType == "origin" {
-		return server_structs.OriginType
+	return labels, nil
+}
+
+// Check whether the labels of a server match every label selector
+func matchLabels(labels map[string]string, selectors map[string]string) bool {
+	for key, val := range selectors {
+		if serverVal, ok := labels[key]; !ok || serverVal != val {
+			return false
+		}
 	}
-	return ""
+	return true
 }
 
 func listServers(ctx *gin.Context) {
@@ -91,27 +127,50 @@ func listServers(ctx *gin.Context) {
 		})
 		return
 	}
-	var servers []*server_structs.Advertisement
+	serverTypes := []server_structs.ServerType{server_structs.OriginType, server_structs.CacheType}
 	if queryParams.ServerType != "" {
-		if !strings.EqualFold(queryParams.ServerType, string(server_structs.OriginType)) && !strings.EqualFold(queryParams.ServerType, string(server_structs.CacheType)) {
+		serverType, err := server_structs.ParseServerType(queryParams.ServerType)
+		if err != nil {
 			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 				Status: server_structs.RespFailed,
-				Msg:    "Invalid server type",
+				Msg:    err.Error(),
 			})
 			return
 		}
-		servers = listAdvertisement([]server_structs.ServerType{server_structs.ServerType(queryParams.ToInternalServerType())})
-	} else {
-		servers = listAdvertisement([]server_structs.ServerType{server_structs.OriginType, server_structs.CacheType})
+		serverTypes = []server_structs.ServerType{serverType}
+	}
+	labelSelectors, err := parseLabelSelectors(queryParams.Labels)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error(),
+		})
+		return
 	}
+	servers := listAdvertisement(serverTypes)
+	freshCount := len(servers)
+	servers = append(servers, listStaleAdvertisement(serverTypes)...)
 	healthTestUtilsMutex.RLock()
 	defer healthTestUtilsMutex.RUnlock()
 	resList := make([]listServerResponse, 0)
-	for _, server := range servers {
+	warnings := []string{}
+	for idx, server := range servers {
+		if !matchLabels(server.Labels, labelSelectors) {
+			continue
+		}
+		// Skip malformed ads rather than listing garbage or failing to encode the response,
+		// so a single misbehaving server doesn't hide the rest of the federation
+		if err := server.Validate(); err != nil {
+			log.Debugf("listServers: skipping the invalid ad of the server at %s: %v", server.URL.String(), err)
+			warnings = append(warnings, err.Error())
+			continue
+		}
 		healthStatus := HealthStatusUnknown
+		healthFailures := 0
 		healthUtil, ok := healthTestUtils[server.URL.String()]
 		if ok {
 			healthStatus = healthUtil.Status
+			healthFailures = healthUtil.ConsecutiveFailures
 		} else {
 			if server.DisableDirectorTest {
 				healthStatus = HealthStatusDisabled
@@ -122,31 +181,74 @@ func listServers(ctx *gin.Context) {
 			}
 		}
 		filtered, ft := checkFilter(server.Name)
+		draining, drainingUntil := checkDraining(server.URL.String())
+		brokerStatus, brokerHeartbeat := getBrokerStatus(server.ServerAd)
 
 		res := listServerResponse{
 			Name:                server.Name,
 			StorageType:         server.StorageType,
 			DisableDirectorTest: server.DisableDirectorTest,
 			BrokerURL:           server.BrokerURL.String(),
+			BrokerConnected:     brokerStatus == brokerTunnelConnected,
 			// For web UI, if authURL is not set, we don't want to confuse user by copying server URL as authURL
-			AuthURL:      server.AuthURL.String(),
-			URL:          server.URL.String(),
-			WebURL:       server.WebURL.String(),
-			Type:         server.Type,
-			Latitude:     server.Latitude,
-			Longitude:    server.Longitude,
-			Caps:         server.Caps,
-			Filtered:     filtered,
-			FilteredType: ft.String(),
-			FromTopology: server.FromTopology,
-			HealthStatus: healthStatus,
-			IOLoad:       server.GetIOLoad(),
+			AuthURL:        server.AuthURL.String(),
+			URL:            server.URL.String(),
+			WebURL:         server.WebURL.String(),
+			Type:           server.Type,
+			Site:           server.Site,
+			Labels:         server.Labels,
+			FreeSpace:      server.FreeSpace,
+			TotalSpace:     server.TotalSpace,
+			Latitude:       server.Latitude,
+			Longitude:      server.Longitude,
+			Caps:           server.Caps,
+			Filtered:       filtered,
+			FilteredType:   ft.String(),
+			Draining:       draining,
+			FromTopology:   server.FromTopology,
+			HealthStatus:   healthStatus,
+			HealthFailures: healthFailures,
+			IOLoad:         server.GetIOLoad(),
+			Stale:          idx >= freshCount,
+		}
+		if server.BrokerURL.String() != "" {
+			res.BrokerStatus = brokerStatus
+		}
+		if !brokerHeartbeat.IsZero() {
+			res.BrokerHeartbeat = &brokerHeartbeat
+		}
+		if draining {
+			res.DrainingUntil = &drainingUntil
+		}
+		for _, altUrl := range server.AltURLs {
+			res.AltURLs = append(res.AltURLs, altUrl.String())
+		}
+		for _, upstreamUrl := range server.UpstreamCaches {
+			res.UpstreamCaches = append(res.UpstreamCaches, upstreamUrl.String())
 		}
 		for _, ns := range server.NamespaceAds {
 			res.NamespacePrefixes = append(res.NamespacePrefixes, ns.Path)
 		}
 		resList = append(resList, res)
 	}
+	if len(warnings) > 0 {
+		if queryParams.Strict {
+			ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Invalid server ads: " + strings.Join(warnings, "; "),
+			})
+			return
+		}
+		for _, warning := range warnings {
+			// The messages quote advertised values, which must not break the header
+			ctx.Writer.Header().Add(warningsHeader, strings.Map(func(r rune) rune {
+				if unicode.IsControl(r) {
+					return -1
+				}
+				return r
+			}, warning))
+		}
+	}
 	ctx.JSON(http.StatusOK, resList)
 }
 
@@ -226,6 +328,32 @@ func queryOrigins(ctx *gin.Context) {
 	}
 }
 
+// A gin route handler that removes the advertisement of the server at the `serverUrl` query
+// parameter, including a stale one, from the director. Unlike filtering the server, the eviction
+// isn't remembered: the server comes back as soon as it advertises again
+func handleEvictServer(ctx *gin.Context) {
+	serverUrl := ctx.Query("serverUrl")
+	if serverUrl == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'serverUrl' is a required query parameter",
+		})
+		return
+	}
+	// Deleting the ad triggers the eviction callback cleaning up the server's health tests and stat utilities
+	_, found := serverAds.GetAndDelete(serverUrl)
+	_, foundStale := staleServerAds.GetAndDelete(serverUrl)
+	if !found && !foundStale {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("No server with the URL %s is advertised to the director", serverUrl),
+		})
+		return
+	}
+	web_ui.RequestLogger(ctx).Infof("The advertisement of server %s is evicted by user %s", serverUrl, ctx.GetString("User"))
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
+}
+
 // A gin route handler that given a server hostname through path variable `name`,
 // checks and adds the server to a list of servers to be bypassed when the director redirects
 // object requests from the client
@@ -240,6 +368,7 @@ func handleFilterServer(ctx *gin.Context) {
 	}
 	filtered, filterType := checkFilter(sn)
 	if filtered {
+		web_ui.RequestLogger(ctx).Warningf("Failed to filter server %s: it has already been filtered with type %s", sn, filterType)
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    fmt.Sprint("Can't filter a server that already has been fitlered with type ", filterType),
@@ -247,14 +376,16 @@ func handleFilterServer(ctx *gin.Context) {
 		return
 	}
 	filteredServersMutex.Lock()
-	defer filteredServersMutex.Unlock()
-
 	// If we previously temporarily allowed a server, we switch to permFiltered (reset)
 	if filterType == tempAllowed {
 		filteredServers[sn] = permFiltered
 	} else {
 		filteredServers[sn] = tempFiltered
 	}
+	state := snapshotFilteredServersLocked()
+	filteredServersMutex.Unlock()
+	persistFilteredServers(state)
+	web_ui.RequestLogger(ctx).Infof("Server %s is filtered by user %s", sn, ctx.GetString("User"))
 	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
 }
 
@@ -272,6 +403,7 @@ func handleAllowServer(ctx *gin.Context) {
 	}
 	filtered, ft := checkFilter(sn)
 	if !filtered {
+		web_ui.RequestLogger(ctx).Warningf("Failed to allow server %s: it is not being filtered", sn)
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    fmt.Sprintf("Can't allow server %s that is not being filtered", sn),
@@ -280,8 +412,6 @@ func handleAllowServer(ctx *gin.Context) {
 	}
 
 	filteredServersMutex.Lock()
-	defer filteredServersMutex.Unlock()
-
 	if ft == tempFiltered {
 		// For temporarily filtered server, allowing them by removing the server from the map
 		delete(filteredServers, sn)
@@ -289,30 +419,42 @@ func handleAllowServer(ctx *gin.Context) {
 		// For servers to filter from the config, temporarily allow the server
 		filteredServers[sn] = tempAllowed
 	} else if ft == topoFiltered {
+		filteredServersMutex.Unlock()
+		web_ui.RequestLogger(ctx).Warningf("Failed to allow server %s: it is disabled by the OSG Topology", sn)
 		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    fmt.Sprintf("Can't allow server %s that is disabled by the OSG Topology. Contact OSG admin at support@osg-htc.org to enable the server.", sn),
 		})
 		return
 	}
+	state := snapshotFilteredServersLocked()
+	filteredServersMutex.Unlock()
+	persistFilteredServers(state)
+	web_ui.RequestLogger(ctx).Infof("Server %s is allowed by user %s", sn, ctx.GetString("User"))
 	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
 }
 
 // Endpoint for director support contact information
 func handleDirectorContact(ctx *gin.Context) {
-	email := param.Director_SupportContactEmail.GetString()
-	url := param.Director_SupportContactUrl.GetString()
+	email := reloadableString(param.Director_SupportContactEmail)
+	url := reloadableString(param.Director_SupportContactUrl)
 
 	ctx.JSON(http.StatusOK, supportContactRes{Email: email, Url: url})
 }
 
 func RegisterDirectorWebAPI(router *gin.RouterGroup) {
 	directorWebAPI := router.Group("/api/v1.0/director_ui")
+	directorWebAPI.Use(deprecationMiddleware)
 	// Follow RESTful schema
 	{
-		directorWebAPI.GET("/servers", listServers)
-		directorWebAPI.PATCH("/servers/filter/*name", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleFilterServer)
-		directorWebAPI.PATCH("/servers/allow/*name", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleAllowServer)
+		directorWebAPI.GET("/servers", compressionMiddleware, listServers)
+		directorWebAPI.DELETE("/servers", web_ui.AdminTokenAuthHandler, handleEvictServer)
+		directorWebAPI.PATCH("/servers/filter/*name", web_ui.AdminTokenAuthHandler, handleFilterServer)
+		directorWebAPI.PATCH("/servers/allow/*name", web_ui.AdminTokenAuthHandler, handleAllowServer)
+		directorWebAPI.POST("/servers/drain", web_ui.AdminTokenAuthHandler, handleDrainServer)
+		directorWebAPI.DELETE("/servers/drain", web_ui.AdminTokenAuthHandler, handleUndrainServer)
+		directorWebAPI.POST("/prefetch", web_ui.AdminTokenAuthHandler, handlePrefetch)
+		directorWebAPI.POST("/namespaceFilters/reload", web_ui.AdminTokenAuthHandler, handleReloadNamespaceFilters)
 		directorWebAPI.GET("/servers/origins/stat/*path", web_ui.AuthHandler, queryOrigins)
 		directorWebAPI.HEAD("/servers/origins/stat/*path", web_ui.AuthHandler, queryOrigins)
 		directorWebAPI.GET("/contact", handleDirectorContact)
@@ -20,8 +20,11 @@ package director
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -77,6 +80,8 @@ func TestListServers(t *testing.T) {
 		FromTopology:      mockOriginServerAd.FromTopology,
 		HealthStatus:      HealthStatusUnknown,
 		NamespacePrefixes: expectedListOriginResNss,
+		// Not behind a broker
+		BrokerConnected: true,
 	}
 
 	expectedlistCacheRes := listServerResponse{
@@ -92,6 +97,8 @@ func TestListServers(t *testing.T) {
 		FromTopology:      mockCacheServerAd.FromTopology,
 		HealthStatus:      HealthStatusUnknown,
 		NamespacePrefixes: expectedListCacheResNss,
+		// Not behind a broker
+		BrokerConnected: true,
 	}
 
 	t.Run("query-origin", func(t *testing.T) {
@@ -169,3 +176,209 @@ func TestListServers(t *testing.T) {
 		require.Equal(t, 400, w.Code)
 	})
 }
+
+func TestListServersByLabel(t *testing.T) {
+	router := gin.New()
+	router.GET("/servers", listServers)
+
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+	gpuOrigin := mockOriginServerAd
+	gpuOrigin.Labels = map[string]string{"tier": "production", "gpu": "true"}
+	cache := mockCacheServerAd
+	cache.Labels = map[string]string{"tier": "production"}
+	serverAds.Set(gpuOrigin.URL.String(), &server_structs.Advertisement{ServerAd: gpuOrigin}, ttlcache.DefaultTTL)
+	serverAds.Set(cache.URL.String(), &server_structs.Advertisement{ServerAd: cache}, ttlcache.DefaultTTL)
+
+	listServerNames := func(t *testing.T, query string) []string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/servers"+query, nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		servers := []listServerResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+		names := []string{}
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{gpuOrigin.Name, cache.Name}, listServerNames(t, "?label=tier=production"))
+	assert.Equal(t, []string{gpuOrigin.Name}, listServerNames(t, "?label=tier=production&label=gpu=true"))
+	assert.Empty(t, listServerNames(t, "?label=tier=staging"))
+	assert.Empty(t, listServerNames(t, "?label=gpu=true&server_type=cache"))
+
+	for _, query := range []string{"?label=tier", "?label==production", "?label=tier=production&label=tier=staging"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/servers"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListServersAltURLs(t *testing.T) {
+	router := gin.New()
+	router.GET("/servers", listServers)
+
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+	cache := mockCacheServerAd
+	cache.AltURLs = []url.URL{{Scheme: "https", Host: "cache-alias.example.com:8443"}}
+	serverAds.Set(cache.URL.String(), &server_structs.Advertisement{ServerAd: cache}, ttlcache.DefaultTTL)
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockOriginServerAd}, ttlcache.DefaultTTL)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/servers", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	servers := []listServerResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+	require.Len(t, servers, 2)
+	for _, server := range servers {
+		if server.Name == cache.Name {
+			assert.Equal(t, []string{"https://cache-alias.example.com:8443"}, server.AltURLs)
+		} else {
+			assert.Empty(t, server.AltURLs)
+		}
+	}
+}
+
+func TestListServersInvalidAds(t *testing.T) {
+	router := gin.New()
+	router.GET("/servers", listServers)
+
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockOriginServerAd}, ttlcache.DefaultTTL)
+	serverAds.Set(mockCacheServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockCacheServerAd}, ttlcache.DefaultTTL)
+	nanOrigin := mockOriginServerAd
+	nanOrigin.Name = "nan-origin"
+	nanOrigin.URL = url.URL{Scheme: "https", Host: "nan-origin.com"}
+	nanOrigin.IOLoad = math.NaN()
+	serverAds.Set(nanOrigin.URL.String(), &server_structs.Advertisement{ServerAd: nanOrigin}, ttlcache.DefaultTTL)
+	badLabelCache := mockCacheServerAd
+	badLabelCache.Name = "bad-label-cache"
+	badLabelCache.URL = url.URL{Scheme: "https", Host: "bad-label-cache.com"}
+	badLabelCache.Labels = map[string]string{"tier": "prod\r\nuction"}
+	serverAds.Set(badLabelCache.URL.String(), &server_structs.Advertisement{ServerAd: badLabelCache}, ttlcache.DefaultTTL)
+
+	doRequest := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/servers"+query, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("skips-invalid-ads", func(t *testing.T) {
+		w := doRequest("")
+		require.Equal(t, http.StatusOK, w.Code)
+		servers := []listServerResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+		names := []string{}
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+		assert.ElementsMatch(t, []string{mockOriginServerAd.Name, mockCacheServerAd.Name}, names)
+
+		warnings := w.Header().Values(warningsHeader)
+		require.Len(t, warnings, 2)
+		assert.Contains(t, strings.Join(warnings, " "), "nan-origin")
+		assert.Contains(t, strings.Join(warnings, " "), "bad-label-cache")
+	})
+
+	t.Run("only-warns-about-listed-servers", func(t *testing.T) {
+		w := doRequest("?server_type=origin")
+		require.Equal(t, http.StatusOK, w.Code)
+		warnings := w.Header().Values(warningsHeader)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "nan-origin")
+	})
+
+	t.Run("strict-fails", func(t *testing.T) {
+		w := doRequest("?strict=true")
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		res := server_structs.SimpleApiResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, server_structs.RespFailed, res.Status)
+		assert.Contains(t, res.Msg, "nan-origin")
+	})
+
+	t.Run("no-warnings-when-valid", func(t *testing.T) {
+		serverAds.Delete(nanOrigin.URL.String())
+		serverAds.Delete(badLabelCache.URL.String())
+		w := doRequest("?strict=true")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Values(warningsHeader))
+	})
+}
+
+func TestDeprecatedRouteHeaders(t *testing.T) {
+	router := gin.New()
+	RegisterDirectorWebAPI(router.Group(""))
+	directorAPIV2 := router.Group("/api/v2.0/director")
+	directorAPIV2.Use(deprecationMiddleware)
+	directorAPIV2.GET("/servers", listServers)
+
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+	serverAds.Set(mockOriginServerAd.URL.String(),
+		&server_structs.Advertisement{
+			ServerAd:     mockOriginServerAd,
+			NamespaceAds: mockNamespaceAds(1, "origin1"),
+		}, ttlcache.DefaultTTL)
+
+	listServersAt := func(t *testing.T, path string) (*httptest.ResponseRecorder, []listServerResponse) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		servers := []listServerResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+		return w, servers
+	}
+
+	// The deprecated route still lists the servers, but announces its removal
+	w, servers := listServersAt(t, "/api/v1.0/director_ui/servers")
+	require.Len(t, servers, 1)
+	assert.Equal(t, mockOriginServerAd.Name, servers[0].Name)
+	assert.Equal(t, "@1792108800", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 16 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v2.0/director/servers>; rel="successor-version"`, w.Header().Get("Link"))
+
+	// Its successor doesn't
+	w, servers = listServersAt(t, "/api/v2.0/director/servers")
+	require.Len(t, servers, 1)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
+
+func TestHandleEvictServer(t *testing.T) {
+	serverAds.DeleteAll()
+	staleServerAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		staleServerAds.DeleteAll()
+	})
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockOriginServerAd}, ttlcache.DefaultTTL)
+	staleServerAds.Set(mockCacheServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockCacheServerAd}, ttlcache.DefaultTTL)
+
+	router := gin.New()
+	router.DELETE("/servers", handleEvictServer)
+	doRequest := func(serverUrl string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/servers?serverUrl="+url.QueryEscape(serverUrl), nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, doRequest(mockOriginServerAd.URL.String()))
+	assert.False(t, serverAds.Has(mockOriginServerAd.URL.String()))
+	// Stale ads are evicted too
+	assert.Equal(t, http.StatusOK, doRequest(mockCacheServerAd.URL.String()))
+	assert.False(t, staleServerAds.Has(mockCacheServerAd.URL.String()))
+
+	assert.Equal(t, http.StatusNotFound, doRequest(mockOriginServerAd.URL.String()))
+	assert.Equal(t, http.StatusBadRequest, doRequest(""))
+}
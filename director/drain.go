@@ -0,0 +1,112 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/web_ui"
+)
+
+// The longest a server may be drained for at once
+const maxDrainDuration = 7 * 24 * time.Hour
+
+// Servers being drained, with the key being ServerAd.URL.String(). Unlike a filtered server, a draining server
+// stays advertised and health-tested; it's only skipped for new redirects until its item expires,
+// at which point it returns to rotation by itself
+var drainingServers = ttlcache.New(ttlcache.WithDisableTouchOnHit[string, struct{}]())
+
+// Check if the server at the URL is being drained, and until when
+func checkDraining(serverUrl string) (bool, time.Time) {
+	item := drainingServers.Get(serverUrl)
+	if item == nil {
+		return false, time.Time{}
+	}
+	return true, item.ExpiresAt()
+}
+
+// A gin route handler that drains the server at the `serverUrl` query parameter for the `duration`
+// query parameter, e.g. "2h": the director stops redirecting new requests to the server while keeping
+// it listed, and returns it to rotation once the duration elapses. Draining again resets the duration
+func handleDrainServer(ctx *gin.Context) {
+	serverUrl := ctx.Query("serverUrl")
+	if serverUrl == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'serverUrl' is a required query parameter",
+		})
+		return
+	}
+	duration, err := time.ParseDuration(ctx.Query("duration"))
+	if err != nil || duration <= 0 || duration > maxDrainDuration {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("'duration' must be a positive duration of at most %s, e.g. 2h", maxDrainDuration),
+		})
+		return
+	}
+	existing := serverAds.Get(serverUrl)
+	if existing == nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("No server with the URL %s is advertised to the director", serverUrl),
+		})
+		return
+	}
+	// A disabled server gets no requests at all, so there is nothing left to drain
+	if filtered, ft := checkFilter(existing.Value().Name); filtered {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Can't drain server %s that is already disabled with type %s", existing.Value().Name, ft.Name()),
+		})
+		return
+	}
+	drainingServers.Set(serverUrl, struct{}{}, duration)
+	web_ui.RequestLogger(ctx).Infof("Server %s is drained for %s by user %s", serverUrl, duration, ctx.GetString("User"))
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
+}
+
+// A gin route handler that returns the draining server at the `serverUrl` query parameter to rotation
+// before its drain duration elapses
+func handleUndrainServer(ctx *gin.Context) {
+	serverUrl := ctx.Query("serverUrl")
+	if serverUrl == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'serverUrl' is a required query parameter",
+		})
+		return
+	}
+	if draining, _ := checkDraining(serverUrl); !draining {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("The server %s is not being drained", serverUrl),
+		})
+		return
+	}
+	drainingServers.Delete(serverUrl)
+	web_ui.RequestLogger(ctx).Infof("Server %s is returned to rotation by user %s", serverUrl, ctx.GetString("User"))
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
+}
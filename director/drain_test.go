@@ -0,0 +1,167 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestDrainServer(t *testing.T) {
+	serverAds.DeleteAll()
+	drainingServers.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		drainingServers.DeleteAll()
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		filteredServers = map[string]filterType{}
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+
+	setCache := func(name string) server_structs.ServerAd {
+		ad := mockCacheServerAd
+		ad.Name = name
+		ad.URL = url.URL{Scheme: "https", Host: name + ".com"}
+		ad.Caps = server_structs.Capabilities{Reads: true}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: ad.Caps}},
+		}, ttlcache.DefaultTTL)
+		return ad
+	}
+	cache1 := setCache("cache-one")
+	cache2 := setCache("cache-two")
+
+	router := gin.New()
+	router.POST("/servers/drain", handleDrainServer)
+	router.DELETE("/servers/drain", handleUndrainServer)
+	router.PATCH("/servers/filter/*name", handleFilterServer)
+	router.PATCH("/servers/allow/*name", handleAllowServer)
+	router.GET("/servers", listServers)
+	router.GET("/api/v1.0/director/object/*any", redirectToCache)
+	doRequest := func(method, reqUrl string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, reqUrl, nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	drain := func(ad server_structs.ServerAd, duration string) *httptest.ResponseRecorder {
+		return doRequest(http.MethodPost, "/servers/drain?serverUrl="+url.QueryEscape(ad.URL.String())+"&duration="+duration)
+	}
+	listed := func() map[string]listServerResponse {
+		w := doRequest(http.MethodGet, "/servers")
+		require.Equal(t, http.StatusOK, w.Code)
+		servers := []listServerResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+		byName := map[string]listServerResponse{}
+		for _, server := range servers {
+			byName[server.Name] = server
+		}
+		return byName
+	}
+
+	t.Run("invalid-requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doRequest(http.MethodPost, "/servers/drain?duration=1h").Code)
+		for _, duration := range []string{"", "0", "-1h", "1d", "200h"} {
+			assert.Equal(t, http.StatusBadRequest, drain(cache1, duration).Code, duration)
+		}
+		unknown := cache1
+		unknown.URL = url.URL{Scheme: "https", Host: "unknown.com"}
+		assert.Equal(t, http.StatusNotFound, drain(unknown, "1h").Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(http.MethodDelete, "/servers/drain?serverUrl="+url.QueryEscape(cache1.URL.String())).Code)
+	})
+
+	t.Run("draining-server-is-not-selected", func(t *testing.T) {
+		defer drainingServers.DeleteAll()
+		require.Equal(t, http.StatusOK, drain(cache1, "1h").Code)
+		for i := 0; i < 10; i++ {
+			w := doRequest(http.MethodGet, "/api/v1.0/director/object/foo/bar?skipstat")
+			require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Location"), cache2.URL.Host)
+			assert.NotContains(t, w.Header().Get("Link"), cache1.URL.Host)
+		}
+
+		// The draining server is still listed, marked as draining
+		servers := listed()
+		require.Contains(t, servers, cache1.Name)
+		assert.True(t, servers[cache1.Name].Draining)
+		require.NotNil(t, servers[cache1.Name].DrainingUntil)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *servers[cache1.Name].DrainingUntil, time.Minute)
+		assert.False(t, servers[cache1.Name].Filtered)
+		assert.False(t, servers[cache2.Name].Draining)
+		assert.Nil(t, servers[cache2.Name].DrainingUntil)
+	})
+
+	t.Run("server-returns-to-rotation", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, drain(cache1, "100ms").Code)
+		draining, _ := checkDraining(cache1.URL.String())
+		assert.True(t, draining)
+		require.Eventually(t, func() bool {
+			draining, _ := checkDraining(cache1.URL.String())
+			return !draining
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.False(t, listed()[cache1.Name].Draining)
+
+		// Returning a server to rotation early
+		require.Equal(t, http.StatusOK, drain(cache1, "1h").Code)
+		assert.Equal(t, http.StatusOK, doRequest(http.MethodDelete, "/servers/drain?serverUrl="+url.QueryEscape(cache1.URL.String())).Code)
+		assert.False(t, listed()[cache1.Name].Draining)
+	})
+
+	t.Run("interaction-with-disabled-servers", func(t *testing.T) {
+		defer drainingServers.DeleteAll()
+		// A disabled server can't be drained
+		require.Equal(t, http.StatusOK, doRequest(http.MethodPatch, "/servers/filter/"+cache1.Name).Code)
+		w := drain(cache1, "1h")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "already disabled")
+		require.Equal(t, http.StatusOK, doRequest(http.MethodPatch, "/servers/allow/"+cache1.Name).Code)
+
+		// A draining server can be disabled, and stays draining once allowed again
+		require.Equal(t, http.StatusOK, drain(cache2, "1h").Code)
+		require.Equal(t, http.StatusOK, doRequest(http.MethodPatch, "/servers/filter/"+cache2.Name).Code)
+		servers := listed()
+		assert.True(t, servers[cache2.Name].Filtered)
+		assert.True(t, servers[cache2.Name].Draining)
+		require.Equal(t, http.StatusOK, doRequest(http.MethodPatch, "/servers/allow/"+cache2.Name).Code)
+		assert.False(t, listed()[cache2.Name].Filtered)
+		draining, _ := checkDraining(cache2.URL.String())
+		assert.True(t, draining)
+		w = doRequest(http.MethodGet, "/api/v1.0/director/object/foo/bar?skipstat")
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), cache1.URL.Host)
+	})
+}
@@ -0,0 +1,126 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+const redactedConfigValue = "REDACTED"
+
+var (
+	// The settings holding keys, tokens, passwords, or other secrets (or the files containing them),
+	// matched against the words of the camel-case setting names so that, e.g., the Scitokens settings
+	// aren't caught. Webhook URLs commonly embed the receiver's secret in their path
+	sensitiveConfigNameRegex = regexp.MustCompile(`Key|Token|Password|Secret|WebhookUrls`)
+	// The same, for the keys nested in object-valued settings, which viper lowercases
+	sensitiveConfigKeyRegex = regexp.MustCompile(`(?i)key|token|password|secret|webhookurls`)
+)
+
+// Convert a value of the director's config to its JSON representation, replacing the non-empty
+// strings of sensitive settings with a placeholder, and the credentials embedded in URLs and the
+// values of their query strings, which may carry tokens, with "xxxxx".  Durations are kept in
+// their human-readable form
+func redactConfigValue(value reflect.Value, sensitive bool) any {
+	if value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(value.Int()).String()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]any, value.NumField())
+		for idx := 0; idx < value.NumField(); idx++ {
+			name := value.Type().Field(idx).Name
+			fields[name] = redactConfigValue(value.Field(idx), sensitiveConfigNameRegex.MatchString(name))
+		}
+		return fields
+	case reflect.Map:
+		entries := make(map[string]any, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key, ok := iter.Key().Interface().(string)
+			if !ok {
+				continue
+			}
+			entries[key] = redactConfigValue(iter.Value(), sensitive || sensitiveConfigKeyRegex.MatchString(key))
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		items := make([]any, value.Len())
+		for idx := range items {
+			items[idx] = redactConfigValue(value.Index(idx), sensitive)
+		}
+		return items
+	case reflect.String:
+		str := value.String()
+		if str == "" {
+			return str
+		} else if sensitive {
+			return redactedConfigValue
+		}
+		if strings.Contains(str, "://") {
+			if parsed, err := url.Parse(str); err == nil && (parsed.User != nil || parsed.RawQuery != "") {
+				if parsed.RawQuery != "" {
+					query := parsed.Query()
+					for key := range query {
+						query[key] = []string{"xxxxx"}
+					}
+					parsed.RawQuery = query.Encode()
+				}
+				return parsed.Redacted()
+			}
+		}
+		return str
+	default:
+		return value.Interface()
+	}
+}
+
+// A gin route handler returning the director's effective config, that is the defaults merged with
+// the config files and the environment, with its secrets redacted
+func getEffectiveConfig(ginCtx *gin.Context) {
+	rawConfig, err := param.UnmarshalConfig()
+	if err != nil {
+		log.Errorln("Failed to unmarshal the director's config:", err)
+		ginCtx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to read the config",
+		})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, redactConfigValue(reflect.ValueOf(*rawConfig), false))
+}
@@ -0,0 +1,191 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"path"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+const earthRadiusKm = 6371.0
+
+type (
+	// How a server was considered when selecting the server for a redirect
+	explainCandidate struct {
+		Name            string                    `json:"name"`
+		URL             string                    `json:"url"`
+		Type            server_structs.ServerType `json:"type"`
+		DistanceKm      *float64                  `json:"distanceKm"` // Null if the client or server location is unknown
+		Load            float64                   `json:"load"`
+		CapabilityMatch bool                      `json:"capabilityMatch"` // The server serves the best-matching namespace
+		Filtered        bool                      `json:"filtered"`
+		FilterType      filterType                `json:"filterType,omitempty"`
+		Draining        bool                      `json:"draining"`
+		Rank            int                       `json:"rank"` // 1-based position in the redirect, 0 if the server is not selectable
+	}
+
+	explainResponse struct {
+		Path       string             `json:"path"`
+		ClientIP   string             `json:"clientIp"`
+		Namespace  string             `json:"namespace"`
+		SortMethod string             `json:"sortMethod"`
+		Candidates []explainCandidate `json:"candidates"`
+	}
+)
+
+// Get the distance between the client and the server, or nil if the server's location is unknown
+func serverDistanceKm(clientCoord Coordinate, ad server_structs.ServerAd) *float64 {
+	if ad.Latitude == 0 && ad.Longitude == 0 {
+		return nil
+	}
+	distance := distanceOnSphere(clientCoord.Lat, clientCoord.Long, ad.Latitude, ad.Longitude) * math.Pi * earthRadiusKm
+	return &distance
+}
+
+// Run the cache selection of an object redirect for the path and client IP and report how
+// each server was considered. Unlike the redirect, the object availability is not checked.
+func explainSelection(reqPath string, clientAddr netip.Addr) (explainResponse, error) {
+	reqPath = resolveNamespaceAlias(path.Clean("/" + reqPath))
+	res := explainResponse{
+		Path:       reqPath,
+		SortMethod: reloadableString(param.Director_CacheSortMethod),
+		Candidates: []explainCandidate{},
+	}
+	if clientAddr.IsValid() {
+		res.ClientIP = clientAddr.String()
+	}
+
+	namespaceAd, originAds, cacheAds := getAdsForPath(reqPath)
+	if namespaceAd.Path == "" {
+		return res, nil
+	}
+	res.Namespace = namespaceAd.Path
+
+	// Mirror the fallback of redirectToCache to the first origin enabling direct reads
+	if len(cacheAds) == 0 {
+		for _, originAd := range originAds {
+			if originAd.DirectReads {
+				cacheAds = append(cacheAds, originAd)
+				break
+			}
+		}
+	}
+	sortedAds, err := sortServerAdsByIP(clientAddr, cacheAds)
+	if err != nil {
+		return res, err
+	}
+	ranks := make(map[string]int, len(sortedAds))
+	for idx, ad := range sortedAds {
+		ranks[ad.URL.String()] = idx + 1
+	}
+
+	clientCoord, hasClientCoord := Coordinate{}, false
+	if clientAddr.IsValid() {
+		clientCoord, hasClientCoord = getClientLatLong(clientAddr)
+	}
+
+	// Candidates include the filtered servers serving the path, which getAdsForPath skips
+	cleanedPath := path.Clean(reqPath) + "/"
+	for _, item := range serverAds.Items() {
+		ad := item.Value()
+		ns := matchesPrefix(cleanedPath, ad.NamespaceAds)
+		if ns == nil {
+			continue
+		}
+		candidate := explainCandidate{
+			Name:            ad.Name,
+			URL:             ad.URL.String(),
+			Type:            ad.Type,
+			Load:            ad.IOLoad,
+			CapabilityMatch: ns.Path == namespaceAd.Path,
+			Rank:            ranks[ad.URL.String()],
+		}
+		candidate.Filtered, candidate.FilterType = checkFilter(ad.Name)
+		candidate.Draining, _ = checkDraining(ad.URL.String())
+		if hasClientCoord {
+			candidate.DistanceKm = serverDistanceKm(clientCoord, ad.ServerAd)
+		}
+		res.Candidates = append(res.Candidates, candidate)
+	}
+
+	// Selected servers first by rank, then the rest by name
+	sort.SliceStable(res.Candidates, func(i, j int) bool {
+		ci, cj := res.Candidates[i], res.Candidates[j]
+		if (ci.Rank == 0) != (cj.Rank == 0) {
+			return ci.Rank != 0
+		}
+		if ci.Rank != cj.Rank {
+			return ci.Rank < cj.Rank
+		}
+		return ci.Name < cj.Name
+	})
+	return res, nil
+}
+
+// A gin route handler explaining the server selection of an object redirect. It takes the object
+// path from the required `path` query parameter and the client IP from the optional `client_ip`
+// query parameter, falling back to the IP of the requester. It doesn't redirect.
+func explainRedirect(ctx *gin.Context) {
+	reqPath := ctx.Query("path")
+	if reqPath == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'path' is a required query parameter",
+		})
+		return
+	}
+	clientAddr := utils.ClientIPAddr(ctx)
+	if clientIP := ctx.Query("client_ip"); clientIP != "" {
+		addr, err := netip.ParseAddr(clientIP)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Invalid 'client_ip' query parameter: " + err.Error(),
+			})
+			return
+		}
+		clientAddr = addr
+	}
+
+	res, err := explainSelection(reqPath, clientAddr)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to determine server ordering: " + err.Error(),
+		})
+		return
+	}
+	if res.Namespace == "" {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No namespace found for path. Either it doesn't exist, or the Director is experiencing problems",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
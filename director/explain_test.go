@@ -0,0 +1,130 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestExplainRedirect(t *testing.T) {
+	serverAds.DeleteAll()
+	filteredServersMutex.Lock()
+	tmp := filteredServers
+	filteredServers = map[string]filterType{}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		viper.Reset()
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		filteredServers = tmp
+		filteredServersMutex.Unlock()
+	})
+	viper.Set("Director.CacheSortMethod", "random")
+
+	ns := server_structs.NamespaceAdV2{Path: "/foo"}
+	filteredCacheAd := mockCacheServerAd
+	filteredCacheAd.Name = "filtered-cache-server"
+	filteredCacheAd.URL = url.URL{Host: "cache2.com", Scheme: "https"}
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockOriginServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{ns},
+	}, ttlcache.DefaultTTL)
+	serverAds.Set(mockCacheServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockCacheServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{ns},
+	}, ttlcache.DefaultTTL)
+	serverAds.Set(filteredCacheAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     filteredCacheAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{ns},
+	}, ttlcache.DefaultTTL)
+	filteredServersMutex.Lock()
+	filteredServers[filteredCacheAd.Name] = tempFiltered
+	filteredServersMutex.Unlock()
+
+	t.Run("explain-selection", func(t *testing.T) {
+		res, err := explainSelection("/foo/bar.txt", netip.Addr{})
+		require.NoError(t, err)
+		assert.Equal(t, "/foo", res.Namespace)
+		require.Len(t, res.Candidates, 3)
+
+		// The selected cache comes first, followed by the unselected servers by name
+		assert.Equal(t, mockCacheServerAd.Name, res.Candidates[0].Name)
+		assert.Equal(t, 1, res.Candidates[0].Rank)
+		assert.True(t, res.Candidates[0].CapabilityMatch)
+		assert.False(t, res.Candidates[0].Filtered)
+		assert.Nil(t, res.Candidates[0].DistanceKm)
+
+		assert.Equal(t, filteredCacheAd.Name, res.Candidates[1].Name)
+		assert.Equal(t, 0, res.Candidates[1].Rank)
+		assert.True(t, res.Candidates[1].Filtered)
+		assert.Equal(t, tempFiltered, res.Candidates[1].FilterType)
+
+		assert.Equal(t, mockOriginServerAd.Name, res.Candidates[2].Name)
+		assert.Equal(t, 0, res.Candidates[2].Rank)
+	})
+
+	router := gin.New()
+	router.GET("/explain", explainRedirect)
+
+	t.Run("explain-endpoint", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/explain?path=/foo/bar.txt&client_ip=192.0.2.1", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		res := explainResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "192.0.2.1", res.ClientIP)
+		assert.Len(t, res.Candidates, 3)
+	})
+
+	t.Run("missing-path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/explain", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid-client-ip", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/explain?path=/foo/bar.txt&client_ip=not-an-ip", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/explain?path=/unknown/bar.txt", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
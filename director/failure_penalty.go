@@ -0,0 +1,194 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+type (
+	// A client's report of a failed transfer against a server it was redirected to
+	failureReport struct {
+		Server string `json:"server" binding:"required"` // The URL or host:port of the server
+		Path   string `json:"path"`                      // The object the transfer was for, for logging
+	}
+)
+
+// The number of recent failures counted towards a server's penalty, so that a burst of
+// reports can't push a server further down the ranking than this many failures would
+const maxServerFailures = 10
+
+var (
+	// The times of the recent failures of each server, with the key being ServerAd.URL.String()
+	// for the failures the director observed itself and reportedFailureKey for the failures clients
+	// reported. Entries are dropped once their most recent failure is older than Director.FailurePenaltyWindow
+	serverFailures = ttlcache.New(
+		ttlcache.WithDisableTouchOnHit[string, []time.Time](),
+	)
+	serverFailuresMutex = sync.Mutex{}
+)
+
+// The key of the failures a client reported against a server in serverFailures. Reports aren't
+// authenticated, so they only penalize the server for the client that reported them; otherwise a
+// single client could keep any server demoted for the whole federation
+func reportedFailureKey(serverUrl string, clientAddr netip.Addr) string {
+	return serverUrl + "|" + clientAddr.String()
+}
+
+// Record a failure of the server at the given time, increasing its penalty in server selection.
+// The key is either the server's URL or a reportedFailureKey
+func recordServerFailure(key string, at time.Time) {
+	window := reloadableDuration(param.Director_FailurePenaltyWindow)
+	if window <= 0 {
+		return
+	}
+	serverFailuresMutex.Lock()
+	defer serverFailuresMutex.Unlock()
+
+	failures := []time.Time{}
+	if item := serverFailures.Get(key); item != nil {
+		for _, failedAt := range item.Value() {
+			if at.Sub(failedAt) < window {
+				failures = append(failures, failedAt)
+			}
+		}
+	}
+	failures = append(failures, at)
+	if len(failures) > maxServerFailures {
+		failures = failures[len(failures)-maxServerFailures:]
+	}
+	serverFailures.Set(key, failures, window)
+}
+
+// Get the failure penalty of a server for a client at the given time: each failure the director
+// observed, or the client reported, counts 1 when it happens and decays linearly to 0 over
+// Director.FailurePenaltyWindow. An invalid clientAddr only counts the director's failures
+func getFailurePenalty(serverUrl string, clientAddr netip.Addr, now time.Time) float64 {
+	window := reloadableDuration(param.Director_FailurePenaltyWindow)
+	if window <= 0 {
+		return 0
+	}
+	keys := []string{serverUrl}
+	if clientAddr.IsValid() {
+		keys = append(keys, reportedFailureKey(serverUrl, clientAddr))
+	}
+	penalty := 0.0
+	for _, key := range keys {
+		item := serverFailures.Get(key)
+		if item == nil {
+			continue
+		}
+		for _, failedAt := range item.Value() {
+			if age := now.Sub(failedAt); age < window {
+				penalty += 1 - float64(age)/float64(window)
+			}
+		}
+	}
+	return penalty
+}
+
+// Stable-sort the given serverAds in-place for the client at clientAddr so that recently-failed
+// servers are moved down Director.FailurePenalty positions for each of their failures, rounded to
+// whole positions as the failures decay.
+// Penalized servers are only ranked lower, never removed, so they still serve when they're
+// the only option
+//
+// Smaller index in the sorted array means higher priority
+func sortServerAdsByFailurePenalty(ads []server_structs.ServerAd, clientAddr netip.Addr) {
+	positions := reloadableInt(param.Director_FailurePenalty)
+	if positions <= 0 || reloadableDuration(param.Director_FailurePenaltyWindow) <= 0 {
+		return
+	}
+	now := time.Now()
+	penalties := make(map[string]float64, len(ads))
+	ranks := make(map[string]float64, len(ads))
+	for idx, ad := range ads {
+		penalties[ad.URL.String()] = getFailurePenalty(ad.URL.String(), clientAddr, now)
+		ranks[ad.URL.String()] = float64(idx) + math.Round(penalties[ad.URL.String()]*float64(positions))
+	}
+	slices.SortStableFunc(ads, func(a, b server_structs.ServerAd) int {
+		if order := cmp.Compare(ranks[a.URL.String()], ranks[b.URL.String()]); order != 0 {
+			return order
+		}
+		// A server moved down onto another's position goes after it
+		return cmp.Compare(penalties[a.URL.String()], penalties[b.URL.String()])
+	})
+}
+
+// Find the advertisement of the server a client refers to by its URL or its host
+func findServerAd(server string) *server_structs.Advertisement {
+	host := server
+	if serverUrl, err := url.Parse(server); err == nil && serverUrl.Host != "" {
+		host = serverUrl.Host
+	}
+	for _, item := range serverAds.Items() {
+		ad := item.Value()
+		if ad.URL.String() == server || ad.URL.Host == host || ad.AuthURL.Host == host {
+			return ad
+		}
+	}
+	return nil
+}
+
+// A gin route handler for clients to report a failed transfer against a server, which the
+// director then ranks lower for that client for Director.FailurePenaltyWindow
+func handleReportFailure(ctx *gin.Context) {
+	report := failureReport{}
+	if err := ctx.ShouldBindJSON(&report); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_utils.NewBindingErrorResp(err, report))
+		return
+	}
+	ad := findServerAd(report.Server)
+	if ad == nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("No server found for %q", report.Server),
+		})
+		return
+	}
+
+	clientAddr := utils.ClientIPAddr(ctx)
+	if !clientAddr.IsValid() {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Unable to determine the address of the client reporting the failure",
+		})
+		return
+	}
+	log.Debugf("Client %s reported a failed transfer of %q against %s", clientAddr, report.Path, ad.Summary())
+	recordServerFailure(reportedFailureKey(ad.URL.String(), clientAddr), time.Now())
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "Failure recorded"})
+}
@@ -0,0 +1,183 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestFailurePenalty(t *testing.T) {
+	t.Cleanup(func() {
+		serverFailures.DeleteAll()
+		serverAds.DeleteAll()
+		healthTestUtilsMutex.Lock()
+		healthTestUtils = make(map[string]*healthTestUtil)
+		healthTestUtilsMutex.Unlock()
+		viper.Reset()
+	})
+
+	newCache := func(host string) server_structs.ServerAd {
+		cache := mockCacheServerAd
+		cache.Name = host
+		cache.URL = url.URL{Scheme: "https", Host: host}
+		return cache
+	}
+	hosts := func(ads []server_structs.ServerAd) []string {
+		result := []string{}
+		for _, ad := range ads {
+			result = append(result, ad.URL.Host)
+		}
+		return result
+	}
+	cacheA, cacheB, cacheC := newCache("cache-a.com:8443"), newCache("cache-b.com:8443"), newCache("cache-c.com:8443")
+
+	t.Run("penalty-decays", func(t *testing.T) {
+		viper.Reset()
+		serverFailures.DeleteAll()
+		viper.Set("Director.FailurePenaltyWindow", 10*time.Minute)
+
+		now := time.Now()
+		serverUrl := cacheA.URL.String()
+		assert.Zero(t, getFailurePenalty(serverUrl, netip.Addr{}, now))
+
+		recordServerFailure(serverUrl, now)
+		assert.InDelta(t, 1.0, getFailurePenalty(serverUrl, netip.Addr{}, now), 0.001)
+		assert.InDelta(t, 0.5, getFailurePenalty(serverUrl, netip.Addr{}, now.Add(5*time.Minute)), 0.001)
+		assert.InDelta(t, 0.1, getFailurePenalty(serverUrl, netip.Addr{}, now.Add(9*time.Minute)), 0.001)
+		assert.Zero(t, getFailurePenalty(serverUrl, netip.Addr{}, now.Add(10*time.Minute)))
+
+		// Failures add up, but only the most recent ones count
+		recordServerFailure(serverUrl, now.Add(-5*time.Minute))
+		assert.InDelta(t, 1.5, getFailurePenalty(serverUrl, netip.Addr{}, now), 0.001)
+		for i := 0; i < 2*maxServerFailures; i++ {
+			recordServerFailure(serverUrl, now)
+		}
+		assert.InDelta(t, float64(maxServerFailures), getFailurePenalty(serverUrl, netip.Addr{}, now), 0.001)
+
+		// Without a window, there's no penalty
+		viper.Set("Director.FailurePenaltyWindow", 0)
+		assert.Zero(t, getFailurePenalty(serverUrl, netip.Addr{}, now))
+		recordServerFailure(cacheB.URL.String(), now)
+		assert.False(t, serverFailures.Has(cacheB.URL.String()))
+	})
+
+	t.Run("penalized-servers-are-ranked-lower", func(t *testing.T) {
+		viper.Reset()
+		serverFailures.DeleteAll()
+		viper.Set("Director.FailurePenaltyWindow", 10*time.Minute)
+		viper.Set("Director.FailurePenalty", 1)
+
+		ads := []server_structs.ServerAd{cacheA, cacheB, cacheC}
+		sortServerAdsByFailurePenalty(ads, netip.Addr{})
+		assert.Equal(t, []string{"cache-a.com:8443", "cache-b.com:8443", "cache-c.com:8443"}, hosts(ads))
+
+		// A fresh failure moves the server down one position, two failures two positions
+		now := time.Now()
+		recordServerFailure(cacheA.URL.String(), now)
+		ads = []server_structs.ServerAd{cacheA, cacheB, cacheC}
+		sortServerAdsByFailurePenalty(ads, netip.Addr{})
+		assert.Equal(t, []string{"cache-b.com:8443", "cache-a.com:8443", "cache-c.com:8443"}, hosts(ads))
+		recordServerFailure(cacheA.URL.String(), now)
+		ads = []server_structs.ServerAd{cacheA, cacheB, cacheC}
+		sortServerAdsByFailurePenalty(ads, netip.Addr{})
+		assert.Equal(t, []string{"cache-b.com:8443", "cache-c.com:8443", "cache-a.com:8443"}, hosts(ads))
+
+		// A decayed failure moves the server less
+		serverFailures.DeleteAll()
+		recordServerFailure(cacheA.URL.String(), now.Add(-8*time.Minute))
+		ads = []server_structs.ServerAd{cacheA, cacheB, cacheC}
+		sortServerAdsByFailurePenalty(ads, netip.Addr{})
+		assert.Equal(t, []string{"cache-a.com:8443", "cache-b.com:8443", "cache-c.com:8443"}, hosts(ads))
+
+		// A penalized server is still selected when it's the only option
+		for i := 0; i < maxServerFailures; i++ {
+			recordServerFailure(cacheA.URL.String(), now)
+		}
+		sorted, err := selectServers("/foo/bar", netip.Addr{}, "", []server_structs.ServerAd{cacheA}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cache-a.com:8443"}, hosts(sorted))
+
+		// The penalty can be turned off
+		viper.Set("Director.FailurePenalty", 0)
+		ads = []server_structs.ServerAd{cacheA, cacheB, cacheC}
+		sortServerAdsByFailurePenalty(ads, netip.Addr{})
+		assert.Equal(t, []string{"cache-a.com:8443", "cache-b.com:8443", "cache-c.com:8443"}, hosts(ads))
+	})
+
+	t.Run("failures-are-reported", func(t *testing.T) {
+		viper.Reset()
+		serverFailures.DeleteAll()
+		serverAds.DeleteAll()
+		viper.Set("Director.FailurePenaltyWindow", 10*time.Minute)
+		serverAds.Set(cacheA.URL.String(), &server_structs.Advertisement{ServerAd: cacheA}, ttlcache.DefaultTTL)
+
+		router := gin.New()
+		router.POST("/api/v1.0/director/reportFailure", handleReportFailure)
+		report := func(body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/v1.0/director/reportFailure", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "192.0.2.1:12345"
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		reporter := netip.MustParseAddr("192.0.2.1")
+		w := report(`{"server": "https://cache-a.com:8443/foo/bar", "path": "/foo/bar"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.InDelta(t, 1.0, getFailurePenalty(cacheA.URL.String(), reporter, time.Now()), 0.01)
+		// The server can also be given by its host
+		require.Equal(t, http.StatusOK, report(`{"server": "cache-a.com:8443"}`).Code)
+		assert.InDelta(t, 2.0, getFailurePenalty(cacheA.URL.String(), reporter, time.Now()), 0.01)
+
+		// Reports only penalize the server for the client that sent them
+		assert.Zero(t, getFailurePenalty(cacheA.URL.String(), netip.MustParseAddr("198.51.100.7"), time.Now()))
+		assert.Zero(t, getFailurePenalty(cacheA.URL.String(), netip.Addr{}, time.Now()))
+		viper.Set("Director.FailurePenalty", 1)
+		ads := []server_structs.ServerAd{cacheA, cacheB}
+		sortServerAdsByFailurePenalty(ads, netip.MustParseAddr("198.51.100.7"))
+		assert.Equal(t, []string{"cache-a.com:8443", "cache-b.com:8443"}, hosts(ads))
+		sortServerAdsByFailurePenalty(ads, reporter)
+		assert.Equal(t, []string{"cache-b.com:8443", "cache-a.com:8443"}, hosts(ads))
+
+		assert.Equal(t, http.StatusNotFound, report(`{"server": "https://unknown.com:8443"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, report(`{"path": "/foo/bar"}`).Code)
+
+		// Failed director tests count as failures too
+		healthTestUtilsMutex.Lock()
+		healthTestUtils[cacheB.URL.String()] = &healthTestUtil{}
+		healthTestUtilsMutex.Unlock()
+		recordHealthTestResult(cacheB, false, time.Second)
+		assert.InDelta(t, 1.0, getFailurePenalty(cacheB.URL.String(), netip.Addr{}, time.Now()), 0.01)
+	})
+}
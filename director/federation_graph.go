@@ -0,0 +1,127 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// A server node in the federation graph
+	federationServerNode struct {
+		ID           string                    `json:"id"` // The server URL, unique across the federation
+		Name         string                    `json:"name"`
+		Type         server_structs.ServerType `json:"type"`
+		WebURL       string                    `json:"webUrl"`
+		FromTopology bool                      `json:"fromTopology"`
+		// The IDs of the parent caches a cache pulls from, or their advertised URLs if the director doesn't know them
+		UpstreamCaches []string `json:"upstreamCaches,omitempty"`
+	}
+
+	// A namespace node with its edges to the servers serving it, by server ID
+	federationNamespaceNode struct {
+		Path            string   `json:"path"`
+		Origins         []string `json:"origins"`
+		Caches          []string `json:"caches"`
+		OriginCount     int      `json:"originCount"`
+		CacheCount      int      `json:"cacheCount"`
+		UnderReplicated bool     `json:"underReplicated"` // Served by at most one origin
+	}
+
+	federationGraph struct {
+		Servers    []federationServerNode    `json:"servers"`
+		Namespaces []federationNamespaceNode `json:"namespaces"`
+	}
+)
+
+// Build the graph of the routable federation from serverAds, i.e. filtered servers are excluded
+func buildFederationGraph() federationGraph {
+	graph := federationGraph{
+		Servers:    []federationServerNode{},
+		Namespaces: []federationNamespaceNode{},
+	}
+	namespaces := map[string]*federationNamespaceNode{}
+	// The IDs of the caches by host, to resolve the parent caches advertised by the caches
+	cacheIds := map[string]string{}
+	for _, item := range serverAds.Items() {
+		if ad := item.Value(); ad != nil && ad.Type == server_structs.CacheType {
+			cacheIds[ad.URL.Host] = ad.URL.String()
+		}
+	}
+	for _, item := range serverAds.Items() {
+		ad := item.Value()
+		if ad == nil {
+			continue
+		}
+		if filtered, _ := checkFilter(ad.Name); filtered {
+			continue
+		}
+		serverId := ad.URL.String()
+		node := federationServerNode{
+			ID:           serverId,
+			Name:         ad.Name,
+			Type:         ad.Type,
+			WebURL:       ad.WebURL.String(),
+			FromTopology: ad.FromTopology,
+		}
+		for _, upstream := range ad.UpstreamCaches {
+			if upstreamId, ok := cacheIds[upstream.Host]; ok {
+				node.UpstreamCaches = append(node.UpstreamCaches, upstreamId)
+			} else {
+				node.UpstreamCaches = append(node.UpstreamCaches, upstream.String())
+			}
+		}
+		graph.Servers = append(graph.Servers, node)
+		for _, nsAd := range ad.NamespaceAds {
+			ns, ok := namespaces[nsAd.Path]
+			if !ok {
+				ns = &federationNamespaceNode{Path: nsAd.Path, Origins: []string{}, Caches: []string{}}
+				namespaces[nsAd.Path] = ns
+			}
+			if ad.Type == server_structs.OriginType {
+				ns.Origins = append(ns.Origins, serverId)
+			} else if ad.Type == server_structs.CacheType {
+				ns.Caches = append(ns.Caches, serverId)
+			}
+		}
+	}
+
+	for _, ns := range namespaces {
+		sort.Strings(ns.Origins)
+		sort.Strings(ns.Caches)
+		ns.OriginCount = len(ns.Origins)
+		ns.CacheCount = len(ns.Caches)
+		ns.UnderReplicated = ns.OriginCount <= 1
+		graph.Namespaces = append(graph.Namespaces, *ns)
+	}
+	sort.Slice(graph.Servers, func(i, j int) bool { return graph.Servers[i].ID < graph.Servers[j].ID })
+	sort.Slice(graph.Namespaces, func(i, j int) bool { return graph.Namespaces[i].Path < graph.Namespaces[j].Path })
+	return graph
+}
+
+// Return the federation topology as servers and namespaces, where each namespace lists
+// the servers serving it
+func getFederationTopology(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, buildFederationGraph())
+}
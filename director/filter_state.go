@@ -0,0 +1,157 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+// The on-disk representation of filteredServers, keyed by the server name, with the
+// machine-readable names of the filter types (see filterType.Name)
+type filteredServersState struct {
+	Servers map[string]string `json:"servers"`
+	// The order of the snapshot, so that an older one never overwrites a newer one
+	version uint64
+}
+
+var (
+	// Incremented with each snapshot of filteredServers; guarded by filteredServersMutex
+	filteredServersVersion uint64
+	// Serializes the writes of Director.FilteredServersStateFile
+	filteredServersPersistMutex sync.Mutex
+	// The version of the last snapshot written; guarded by filteredServersPersistMutex
+	filteredServersPersistedVersion uint64
+)
+
+// Snapshot the admin-driven entries of filteredServers, to be saved with persistFilteredServers.
+// Topology-filtered servers are not persisted as they are recomputed from the Topology on startup.
+//
+// The caller must hold filteredServersMutex
+func snapshotFilteredServersLocked() filteredServersState {
+	filteredServersVersion++
+	state := filteredServersState{Servers: make(map[string]string, len(filteredServers)), version: filteredServersVersion}
+	for sn, ft := range filteredServers {
+		if ft == topoFiltered {
+			continue
+		}
+		state.Servers[sn] = ft.Name()
+	}
+	return state
+}
+
+// Save a snapshot of filteredServers to Director.FilteredServersStateFile so that the filters
+// survive a director restart. The caller must not hold filteredServersMutex, so that the redirects
+// checking the filters don't wait on the disk. A snapshot older than the last one saved is dropped
+func persistFilteredServers(state filteredServersState) {
+	stateFile := param.Director_FilteredServersStateFile.GetString()
+	if stateFile == "" {
+		return
+	}
+	filteredServersPersistMutex.Lock()
+	defer filteredServersPersistMutex.Unlock()
+	if state.version <= filteredServersPersistedVersion {
+		return
+	}
+	if err := writeStateFile(stateFile, state); err != nil {
+		log.Errorf("Failed to persist the filtered servers to %s: %v", stateFile, err)
+		return
+	}
+	filteredServersPersistedVersion = state.version
+}
+
+// Write the state to a temporary file and rename it so that a crash never leaves a partial state file
+func writeStateFile(stateFile string, state any) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the state")
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0750); err != nil {
+		return errors.Wrap(err, "failed to create the directory for the state file")
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(stateFile), filepath.Base(stateFile)+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary state file")
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "failed to write the temporary state file")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to close the temporary state file")
+	}
+	return os.Rename(tmpFile.Name(), stateFile)
+}
+
+// Reload filteredServers from Director.FilteredServersStateFile. This should be called
+// after ConfigFilterdServers so that admin actions from the previous run take precedence over
+// Director.FilteredServers. Entries derived from Director.FilteredServers only apply while the
+// server is still configured there, so removing a server from the configuration re-enables it.
+// A missing or corrupt state file leaves filteredServers unchanged.
+func LoadFilteredServers() {
+	stateFile := param.Director_FilteredServersStateFile.GetString()
+	if stateFile == "" {
+		return
+	}
+	content, err := os.ReadFile(stateFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Debugf("No filtered servers state file at %s; starting with no persisted filters", stateFile)
+		} else {
+			log.Warningf("Failed to read the filtered servers state file %s; starting with no persisted filters: %v", stateFile, err)
+		}
+		return
+	}
+	state := filteredServersState{}
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Warningf("The filtered servers state file %s is corrupt; starting with no persisted filters: %v", stateFile, err)
+		return
+	}
+
+	filteredServersMutex.Lock()
+	defer filteredServersMutex.Unlock()
+	for sn, ftName := range state.Servers {
+		ft, ok := filterTypeFromName(ftName)
+		if !ok {
+			// State files written by earlier versions hold the internal identifiers
+			ft = filterType(ftName)
+		}
+		switch ft {
+		case tempFiltered:
+			filteredServers[sn] = ft
+		case permFiltered, tempAllowed:
+			// A server is only permanently filtered, or temporarily allowed despite it, if it's
+			// still filtered by the configuration
+			if filteredServers[sn] == permFiltered {
+				filteredServers[sn] = ft
+			}
+		default:
+			log.Warningf("Ignoring server %s with unknown filter type %q in the filtered servers state file %s", sn, ftName, stateFile)
+		}
+	}
+	log.Infof("Loaded %d filtered servers from %s", len(state.Servers), stateFile)
+}
@@ -0,0 +1,189 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilteredServersPersistence(t *testing.T) {
+	resetFilteredServers := func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		filteredServers = map[string]filterType{}
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		resetFilteredServers()
+	})
+
+	t.Run("save-and-reload", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "state", "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+		viper.Set("Director.FilteredServers", []string{"perm-server", "allowed-server"})
+
+		filteredServersMutex.Lock()
+		filteredServers["perm-server"] = permFiltered
+		filteredServers["allowed-server"] = tempAllowed
+		filteredServers["temp-server"] = tempFiltered
+		filteredServers["topo-server"] = topoFiltered
+		state := snapshotFilteredServersLocked()
+		filteredServersMutex.Unlock()
+		persistFilteredServers(state)
+
+		// Simulate a restart
+		resetFilteredServers()
+		ConfigFilterdServers()
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Equal(t, map[string]filterType{
+			"perm-server":    permFiltered,
+			"allowed-server": tempAllowed,
+			"temp-server":    tempFiltered,
+		}, filteredServers)
+	})
+
+	t.Run("state-file-uses-filter-names", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+
+		filteredServersMutex.Lock()
+		filteredServers["temp-server"] = tempFiltered
+		state := snapshotFilteredServersLocked()
+		filteredServersMutex.Unlock()
+		persistFilteredServers(state)
+
+		content, err := os.ReadFile(stateFile)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"servers":{"temp-server":"temporarily_disabled"}}`, string(content))
+	})
+
+	t.Run("older-snapshot-does-not-overwrite", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+
+		filteredServersMutex.Lock()
+		filteredServers["temp-server"] = tempFiltered
+		older := snapshotFilteredServersLocked()
+		delete(filteredServers, "temp-server")
+		newer := snapshotFilteredServersLocked()
+		filteredServersMutex.Unlock()
+
+		// The writes may finish out of order once the lock is released
+		persistFilteredServers(newer)
+		persistFilteredServers(older)
+
+		content, err := os.ReadFile(stateFile)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"servers":{}}`, string(content))
+	})
+
+	t.Run("legacy-identifiers-still-load", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+		require.NoError(t, os.WriteFile(stateFile, []byte(`{"servers":{"temp-server":"tempFiltered"}}`), 0600))
+
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Equal(t, map[string]filterType{"temp-server": tempFiltered}, filteredServers)
+	})
+
+	t.Run("config-removal-wins-over-perm-filtered", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+		viper.Set("Director.FilteredServers", []string{"perm-server"})
+
+		ConfigFilterdServers()
+		filteredServersMutex.Lock()
+		state := snapshotFilteredServersLocked()
+		filteredServersMutex.Unlock()
+		persistFilteredServers(state)
+
+		// Restart without the server in Director.FilteredServers
+		resetFilteredServers()
+		viper.Set("Director.FilteredServers", []string{})
+		ConfigFilterdServers()
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Empty(t, filteredServers)
+	})
+
+	t.Run("temp-allowed-dropped-when-not-configured", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+		require.NoError(t, os.WriteFile(stateFile, []byte(`{"servers":{"allowed-server":"temporarily_enabled"}}`), 0600))
+
+		ConfigFilterdServers()
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Empty(t, filteredServers)
+	})
+
+	t.Run("corrupt-file-starts-empty", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		stateFile := filepath.Join(t.TempDir(), "filtered-servers.json")
+		viper.Set("Director.FilteredServersStateFile", stateFile)
+		require.NoError(t, os.WriteFile(stateFile, []byte("not json"), 0600))
+
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Empty(t, filteredServers)
+	})
+
+	t.Run("missing-file-starts-empty", func(t *testing.T) {
+		viper.Reset()
+		resetFilteredServers()
+		viper.Set("Director.FilteredServersStateFile", filepath.Join(t.TempDir(), "does-not-exist.json"))
+
+		LoadFilteredServers()
+
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		assert.Empty(t, filteredServers)
+	})
+}
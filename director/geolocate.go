@@ -0,0 +1,142 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"bytes"
+	"cmp"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// A source of the geographic location of IP addresses
+	GeoLocator interface {
+		Locate(ip net.IP) (Coordinate, error)
+	}
+
+	// Locate IPs with the MaxMind GeoLite2 City database loaded by InitializeDB
+	maxMindLocator struct{}
+
+	// An entry of Director.GeoIPStaticFile
+	staticGeoEntry struct {
+		Network string  `yaml:"Network"`
+		Lat     float64 `yaml:"Lat"`
+		Long    float64 `yaml:"Long"`
+	}
+
+	staticNetwork struct {
+		network *net.IPNet
+		coord   Coordinate
+	}
+
+	// Locate IPs with a static table of networks, most specific network first
+	staticLocator struct {
+		networks []staticNetwork
+	}
+)
+
+// The locator used for the IPs without a GeoIPOverrides entry; set by InitializeDB
+var geoLocator GeoLocator = maxMindLocator{}
+
+func (maxMindLocator) Locate(ip net.IP) (coord Coordinate, err error) {
+	reader := maxMindReader.Load()
+	if reader == nil {
+		err = errors.New("No GeoIP database is available")
+		return
+	}
+	record, err := reader.City(ip)
+	if err != nil {
+		return
+	}
+	coord.Lat = record.Location.Latitude
+	coord.Long = record.Location.Longitude
+	return
+}
+
+// Parse a static table of networks to coordinates in the format of Director.GeoIPStaticFile
+func newStaticLocator(contents []byte) (*staticLocator, error) {
+	entries := []staticGeoEntry{}
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the static GeoIP table")
+	}
+	locator := &staticLocator{networks: make([]staticNetwork, 0, len(entries))}
+	for _, entry := range entries {
+		cidr := entry.Network
+		if !strings.Contains(cidr, "/") {
+			// A single IP is a network of its own
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf("invalid network %q in the static GeoIP table", entry.Network)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid network %q in the static GeoIP table", entry.Network)
+		}
+		locator.networks = append(locator.networks, staticNetwork{network: network, coord: Coordinate{Lat: entry.Lat, Long: entry.Long}})
+	}
+	// Check the most specific networks first, keeping the file order between equally specific ones
+	slices.SortStableFunc(locator.networks, func(a, b staticNetwork) int {
+		aOnes, _ := a.network.Mask.Size()
+		bOnes, _ := b.network.Mask.Size()
+		return cmp.Compare(bOnes, aOnes)
+	})
+	return locator, nil
+}
+
+// Load the static table of networks to coordinates at the path
+func loadStaticLocator(path string) (*staticLocator, error) {
+	if path == "" {
+		return nil, errors.New("Director.GeoIPStaticFile must be set to use the static GeoIP provider")
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read Director.GeoIPStaticFile")
+	}
+	return newStaticLocator(contents)
+}
+
+func (locator *staticLocator) Locate(ip net.IP) (Coordinate, error) {
+	for _, entry := range locator.networks {
+		if entry.network.Contains(ip) {
+			return entry.coord, nil
+		}
+	}
+	return Coordinate{}, errors.Errorf("no network of the static GeoIP table contains %s", ip.String())
+}
+
+// Whether the locator can locate IPs yet; the MaxMind database may still be downloading
+func geoLocatorReady() bool {
+	if _, ok := geoLocator.(maxMindLocator); ok {
+		return maxMindReader.Load() != nil
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// A GeoLocator with a fixed location per IP
+type fakeLocator map[string]Coordinate
+
+func (locator fakeLocator) Locate(ip net.IP) (Coordinate, error) {
+	if coord, ok := locator[ip.String()]; ok {
+		return coord, nil
+	}
+	return Coordinate{}, errors.New("unknown IP")
+}
+
+func TestStaticLocator(t *testing.T) {
+	locator, err := newStaticLocator([]byte(`
+- Network: 10.0.0.0/8
+  Lat: 43.07
+  Long: -89.38
+- Network: 10.12.0.0/16
+  Lat: 41.88
+  Long: -87.63
+- Network: 192.0.2.7
+  Lat: 50.11
+  Long: 8.68
+- Network: "2001:db8::/32"
+  Lat: 35.68
+  Long: 139.69
+`))
+	require.NoError(t, err)
+
+	for ip, expected := range map[string]Coordinate{
+		"10.1.2.3":      {Lat: 43.07, Long: -89.38},
+		"10.12.2.3":     {Lat: 41.88, Long: -87.63}, // The most specific network wins
+		"192.0.2.7":     {Lat: 50.11, Long: 8.68},
+		"2001:db8::abc": {Lat: 35.68, Long: 139.69},
+	} {
+		coord, err := locator.Locate(net.ParseIP(ip))
+		require.NoError(t, err, ip)
+		assert.Equal(t, expected, coord, ip)
+	}
+	_, err = locator.Locate(net.ParseIP("192.0.2.8"))
+	assert.Error(t, err)
+
+	_, err = newStaticLocator([]byte("- Network: not-a-network\n  Lat: 1\n  Long: 1\n"))
+	assert.Error(t, err)
+	_, err = newStaticLocator([]byte("- Net: 10.0.0.0/8\n"))
+	assert.Error(t, err)
+}
+
+func TestInitializeStaticGeoIP(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+		geoLocator = maxMindLocator{}
+	})
+	staticFile := filepath.Join(t.TempDir(), "geoip.yaml")
+	require.NoError(t, os.WriteFile(staticFile, []byte("- Network: 10.0.0.0/8\n  Lat: 43.07\n  Long: -89.38\n"), 0644))
+
+	viper.Set("Director.GeoIPProvider", "static")
+	viper.Set("Director.GeoIPStaticFile", staticFile)
+	require.NoError(t, InitializeDB(context.Background()))
+	assert.True(t, geoLocatorReady())
+	lat, long, err := getLatLong(netip.MustParseAddr("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, 43.07, lat)
+	assert.Equal(t, -89.38, long)
+
+	viper.Set("Director.GeoIPStaticFile", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, InitializeDB(context.Background()))
+	viper.Set("Director.GeoIPProvider", "ip2location")
+	assert.Error(t, InitializeDB(context.Background()))
+}
+
+func TestSortWithFakeLocator(t *testing.T) {
+	geoIPOverrides = nil
+	t.Cleanup(func() {
+		viper.Reset()
+		geoLocator = maxMindLocator{}
+		geoIPOverrides = nil
+	})
+	viper.Set("Director.CacheSortMethod", "distance")
+	geoLocator = fakeLocator{
+		"192.0.2.1":    {Lat: 43.07, Long: -89.38},
+		"198.51.100.1": {Lat: 50.11, Long: 8.68},
+	}
+
+	usCache := server_structs.ServerAd{Name: "us-cache", URL: url.URL{Host: "us-cache.com"}, Latitude: 41.88, Longitude: -87.63}
+	euCache := server_structs.ServerAd{Name: "eu-cache", URL: url.URL{Host: "eu-cache.com"}, Latitude: 52.52, Longitude: 13.40}
+	ads := []server_structs.ServerAd{euCache, usCache}
+
+	sorted, err := sortServerAdsByIP(netip.MustParseAddr("192.0.2.1"), ads)
+	require.NoError(t, err)
+	assert.Equal(t, "us-cache", sorted[0].Name)
+	sorted, err = sortServerAdsByIP(netip.MustParseAddr("198.51.100.1"), ads)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-cache", sorted[0].Name)
+}
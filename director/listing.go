@@ -0,0 +1,397 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+const (
+	// The maximum number of origins the director lists a collection from concurrently
+	listingConcurrency = 5
+	listingTimeout     = 30 * time.Second
+	// The number of entries in a listing page when the client doesn't set a limit, and the most it may request
+	defaultListingLimit = 1000
+	maxListingLimit     = 10000
+)
+
+type (
+	// The page of a collection listing to fetch from an origin
+	listingOptions struct {
+		// Only list the entries whose name starts with the prefix
+		Prefix string
+		// Only list the entries whose name sorts after this one
+		After string
+		// The most entries to return
+		Limit int
+	}
+
+	// The position in a listing to resume from, opaque to the client
+	listingCursor struct {
+		After string `json:"after"`
+	}
+
+	davPropstat struct {
+		Status string `xml:"DAV: status"`
+		Prop   struct {
+			ContentLength int64  `xml:"DAV: getcontentlength"`
+			LastModified  string `xml:"DAV: getlastmodified"`
+			ResourceType  struct {
+				Collection *struct{} `xml:"DAV: collection"`
+			} `xml:"DAV: resourcetype"`
+		} `xml:"DAV: prop"`
+	}
+
+	davResponse struct {
+		Href      string        `xml:"DAV: href"`
+		Propstats []davPropstat `xml:"DAV: propstat"`
+	}
+)
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/><D:getcontentlength/><D:getlastmodified/></D:prop></D:propfind>`
+
+var (
+	// List the collection at the origin; a variable to be mocked in tests
+	fetchOriginListing = propfindOriginListing
+
+	errListingNotFound  = errors.New("the collection doesn't exist")
+	errListingForbidden = errors.New("not authorized to list the collection")
+)
+
+func encodeListingCursor(after string) string {
+	cursor, _ := json.Marshal(listingCursor{After: after})
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+func decodeListingCursor(encoded string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("invalid cursor")
+	}
+	cursor := listingCursor{}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.After == "" {
+		return "", errors.New("invalid cursor")
+	}
+	return cursor.After, nil
+}
+
+// Insert the entry into the listing sorted by name, keeping at most limit entries
+func insertListingEntry(entries []server_structs.ListingEntry, entry server_structs.ListingEntry, limit int) []server_structs.ListingEntry {
+	idx := sort.Search(len(entries), func(i int) bool { return entries[i].Name >= entry.Name })
+	if idx >= limit {
+		return entries
+	}
+	if idx < len(entries) && entries[idx].Name == entry.Name {
+		if entry.ModTime.After(entries[idx].ModTime) {
+			entries[idx] = entry
+		}
+		return entries
+	}
+	entries = append(entries, server_structs.ListingEntry{})
+	copy(entries[idx+1:], entries[idx:])
+	entries[idx] = entry
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// Convert a PROPFIND response into a listing entry of the collection. Returns false for the
+// response describing the collection itself
+func davResponseToEntry(resp davResponse, collectionPath string, collection string) (server_structs.ListingEntry, bool) {
+	hrefUrl, err := url.Parse(resp.Href)
+	if err != nil {
+		return server_structs.ListingEntry{}, false
+	}
+	hrefPath := path.Clean("/" + hrefUrl.Path)
+	if hrefPath == path.Clean("/"+collectionPath) {
+		return server_structs.ListingEntry{}, false
+	}
+	name := path.Base(hrefPath)
+	entry := server_structs.ListingEntry{Name: name, Path: path.Join(collection, name)}
+	for _, propstat := range resp.Propstats {
+		if !strings.Contains(propstat.Status, " 200") {
+			continue
+		}
+		entry.Size = propstat.Prop.ContentLength
+		entry.IsCollection = propstat.Prop.ResourceType.Collection != nil
+		if modTime, err := http.ParseTime(propstat.Prop.LastModified); err == nil {
+			entry.ModTime = modTime
+		}
+	}
+	return entry, true
+}
+
+// List a page of the collection at the origin with a WebDAV PROPFIND, passing along the client's token.
+//
+// WebDAV has no way to ask the origin for a single page, so the response is decoded as it streams in
+// and only the first opts.Limit matching entries (by name) are kept rather than the whole collection.
+func propfindOriginListing(originAd server_structs.ServerAd, requiresAuth bool, collection string, tok string, opts listingOptions) ([]server_structs.ListingEntry, error) {
+	originUrl := originAd.URL
+	if requiresAuth && originAd.AuthURL.String() != "" {
+		originUrl = originAd.AuthURL
+	}
+	reqUrl := originUrl.JoinPath(collection)
+	// Collections are requested with a trailing slash to avoid a redirect from the origin
+	reqUrl.Path = strings.TrimSuffix(reqUrl.Path, "/") + "/"
+
+	ctx, cancel := context.WithTimeout(context.Background(), listingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", reqUrl.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return nil, errListingNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errListingForbidden
+	default:
+		return nil, errors.Errorf("unexpected status code %d from the origin", resp.StatusCode)
+	}
+
+	entries := []server_structs.ListingEntry{}
+	decoder := xml.NewDecoder(resp.Body)
+	for {
+		xmlTok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the PROPFIND response")
+		}
+		start, ok := xmlTok.(xml.StartElement)
+		if !ok || start.Name.Space != "DAV:" || start.Name.Local != "response" {
+			continue
+		}
+		davResp := davResponse{}
+		if err := decoder.DecodeElement(&davResp, &start); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the PROPFIND response")
+		}
+		entry, ok := davResponseToEntry(davResp, reqUrl.Path, collection)
+		if !ok || !strings.HasPrefix(entry.Name, opts.Prefix) || entry.Name <= opts.After {
+			continue
+		}
+		// In-flight uploads aren't objects yet
+		if !entry.IsCollection && server_structs.IsStagedUploadName(entry.Name) {
+			continue
+		}
+		entries = insertListingEntry(entries, entry, opts.Limit)
+	}
+	return entries, nil
+}
+
+// Merge the listings of the same collection from several origins, ordered by name. An entry listed
+// by several origins is kept once, preferring the most recently modified one
+func mergeListings(listings [][]server_structs.ListingEntry) []server_structs.ListingEntry {
+	merged := map[string]server_structs.ListingEntry{}
+	for _, listing := range listings {
+		for _, entry := range listing {
+			if existing, ok := merged[entry.Name]; !ok || entry.ModTime.After(existing.ModTime) {
+				merged[entry.Name] = entry
+			}
+		}
+	}
+	entries := make([]server_structs.ListingEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Filter the listing entries down to the ones the client's token may read, so a token scoped to part
+// of a namespace doesn't reveal the names in the rest of it.  The origin only checks that the token
+// may list the collection itself; a token without read scopes, like an opaque one, is left to it
+func filterListingByToken(entries []server_structs.ListingEntry, namespaceAd server_structs.NamespaceAdV2, tok string) []server_structs.ListingEntry {
+	if tok == "" {
+		return entries
+	}
+	readScopes := token_scopes.ParseReadScopes(tok)
+	if len(readScopes) == 0 {
+		return entries
+	}
+	matcher := token_scopes.ScopeMatcher{BasePath: getTokenBasePath(namespaceAd)}
+	if matcher.BasePath == "" {
+		matcher.BasePath = namespaceAd.Path
+	}
+	filtered := make([]server_structs.ListingEntry, 0, len(entries))
+	for _, entry := range entries {
+		if matcher.ListingAuthorized(readScopes, entry.Path, entry.IsCollection) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// Parse the pagination and filtering query parameters of a listing request
+func getListingOptions(ginCtx *gin.Context) (listingOptions, error) {
+	opts := listingOptions{Prefix: ginCtx.Query("prefix"), Limit: defaultListingLimit}
+	if limitStr := ginCtx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxListingLimit {
+			return opts, errors.Errorf("invalid limit %q: must be an integer between 1 and %d", limitStr, maxListingLimit)
+		}
+		opts.Limit = limit
+	}
+	if cursor := ginCtx.Query("cursor"); cursor != "" {
+		after, err := decodeListingCursor(cursor)
+		if err != nil {
+			return opts, err
+		}
+		opts.After = after
+	}
+	return opts, nil
+}
+
+// A gin route handler listing the collection at the request path, as a single JSON listing merged
+// from all the origins serving the namespace with listings enabled
+func listCollection(ginCtx *gin.Context) {
+	collection := path.Clean("/" + ginCtx.Param("path"))
+	reqParams := getRequestParameters(ginCtx.Request)
+	opts, err := getListingOptions(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	namespaceAd, originAds, _ := getAdsForPath(collection)
+	if namespaceAd.Path == "" {
+		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No namespace found for path " + collection,
+		})
+		return
+	}
+	listingAds := []server_structs.ServerAd{}
+	if namespaceAd.Caps.Listings {
+		for _, ad := range originAds {
+			if ad.Listings {
+				listingAds = append(listingAds, ad)
+			}
+		}
+	}
+	if len(listingAds) == 0 {
+		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No origin serving the namespace " + namespaceAd.Path + " supports listings",
+		})
+		return
+	}
+
+	// Each origin returns one entry past the page so the director knows whether there's another page
+	originOpts := opts
+	originOpts.Limit = opts.Limit + 1
+	listings := [][]server_structs.ListingEntry{}
+	errs := []error{}
+	mutex := sync.Mutex{}
+	egrp := errgroup.Group{}
+	egrp.SetLimit(listingConcurrency)
+	for _, ad := range listingAds {
+		ad := ad
+		egrp.Go(func() error {
+			entries, err := fetchOriginListing(ad, !namespaceAd.PublicRead, collection, reqParams.Get("authz"), originOpts)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if !errors.Is(err, errListingNotFound) && !errors.Is(err, errListingForbidden) {
+					log.Warningf("Failed to list %s at the origin %s: %v", collection, ad.Name, err)
+				}
+				errs = append(errs, err)
+				return nil
+			}
+			listings = append(listings, entries)
+			return nil
+		})
+	}
+	_ = egrp.Wait()
+
+	if len(listings) == 0 {
+		notFound, forbidden := true, true
+		for _, err := range errs {
+			notFound = notFound && errors.Is(err, errListingNotFound)
+			forbidden = forbidden && errors.Is(err, errListingForbidden)
+		}
+		if notFound {
+			ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "The collection " + collection + " doesn't exist",
+			})
+		} else if forbidden {
+			ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Not authorized to list the collection " + collection,
+			})
+		} else {
+			ginCtx.JSON(http.StatusBadGateway, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Failed to list the collection at the origins: " + errs[0].Error(),
+			})
+		}
+		return
+	}
+	entries := mergeListings(listings)
+	listingResp := server_structs.ListingResp{Path: collection, Entries: entries}
+	if len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+		listingResp.NextCursor = encodeListingCursor(entries[opts.Limit-1].Name)
+	}
+	// Filtered after paging so the cursor moves past the hidden entries too; a page may come up short
+	if !namespaceAd.PublicRead {
+		entries = filterListingByToken(entries, namespaceAd, reqParams.Get("authz"))
+	}
+	listingResp.Entries = entries
+	ginCtx.JSON(http.StatusOK, listingResp)
+}
@@ -0,0 +1,240 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// A WebDAV origin serving the given files and collections
+func newMockListingOrigin(t *testing.T, files map[string]string, dirs ...string) *httptest.Server {
+	ctx := context.Background()
+	fs := webdav.NewMemFS()
+	for _, dir := range dirs {
+		require.NoError(t, fs.Mkdir(ctx, dir, 0755))
+	}
+	for name, content := range files {
+		file, err := fs.OpenFile(ctx, name, os.O_CREATE|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	return httptest.NewServer(&webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()})
+}
+
+func TestListCollection(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+	})
+
+	// The staged upload in flight is no object yet, so it isn't listed
+	originA := newMockListingOrigin(t, map[string]string{"/foo/a.txt": "a", "/foo/shared.txt": "shared", "/foo/.pelican-upload.c.txt.0123456789abcdef": "c"}, "/foo", "/foo/sub")
+	defer originA.Close()
+	originB := newMockListingOrigin(t, map[string]string{"/foo/b.txt": "bb", "/foo/shared.txt": "shared"}, "/foo")
+	defer originB.Close()
+	noListingOrigin := newMockListingOrigin(t, map[string]string{"/bar/c.txt": "c"}, "/bar")
+	defer noListingOrigin.Close()
+
+	addOrigin := func(name, serverUrl string, listings bool, ns server_structs.NamespaceAdV2) {
+		originUrl, err := url.Parse(serverUrl)
+		require.NoError(t, err)
+		ad := server_structs.ServerAd{Name: name, URL: *originUrl, Type: server_structs.OriginType, Listings: listings}
+		serverAds.Set(originUrl.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{ns},
+		}, ttlcache.DefaultTTL)
+	}
+	fooNs := server_structs.NamespaceAdV2{Path: "/foo", PublicRead: true, Caps: server_structs.Capabilities{PublicReads: true, Listings: true}}
+	barNs := server_structs.NamespaceAdV2{Path: "/bar", PublicRead: true, Caps: server_structs.Capabilities{PublicReads: true, Listings: true}}
+	addOrigin("origin-a", originA.URL, true, fooNs)
+	addOrigin("origin-b", originB.URL, true, fooNs)
+	addOrigin("origin-no-listing", noListingOrigin.URL, false, barNs)
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/listing/*path", listCollection)
+	doRequest := func(collection string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/listing"+collection, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	getPage := func(query string) server_structs.ListingResp {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/listing/foo?"+query, nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := server_structs.ListingResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	t.Run("merged-listing", func(t *testing.T) {
+		w := doRequest("/foo")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := server_structs.ListingResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "/foo", res.Path)
+		names := []string{}
+		for _, entry := range res.Entries {
+			names = append(names, entry.Name)
+			switch entry.Name {
+			case "sub":
+				assert.True(t, entry.IsCollection)
+			case "b.txt":
+				assert.Equal(t, int64(2), entry.Size)
+				assert.Equal(t, "/foo/b.txt", entry.Path)
+			}
+		}
+		assert.Equal(t, []string{"a.txt", "b.txt", "shared.txt", "sub"}, names)
+	})
+
+	t.Run("paginated-listing", func(t *testing.T) {
+		names := []string{}
+		query := "limit=2"
+		pages := 0
+		for {
+			res := getPage(query)
+			assert.LessOrEqual(t, len(res.Entries), 2)
+			for _, entry := range res.Entries {
+				names = append(names, entry.Name)
+			}
+			pages++
+			if res.NextCursor == "" {
+				break
+			}
+			query = "limit=2&cursor=" + url.QueryEscape(res.NextCursor)
+		}
+		assert.Equal(t, 2, pages)
+		assert.Equal(t, []string{"a.txt", "b.txt", "shared.txt", "sub"}, names)
+	})
+
+	t.Run("prefix-filter", func(t *testing.T) {
+		res := getPage("prefix=s")
+		names := []string{}
+		for _, entry := range res.Entries {
+			names = append(names, entry.Name)
+		}
+		assert.Equal(t, []string{"shared.txt", "sub"}, names)
+		assert.Empty(t, res.NextCursor)
+	})
+
+	t.Run("invalid-pagination", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doRequest("/foo?limit=0").Code)
+		assert.Equal(t, http.StatusBadRequest, doRequest("/foo?limit=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, doRequest("/foo?cursor=not-a-cursor").Code)
+	})
+
+	t.Run("missing-collection", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doRequest("/foo/missing").Code)
+	})
+
+	t.Run("no-origin-supports-listings", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doRequest("/bar").Code)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doRequest("/unknown").Code)
+	})
+}
+
+func TestListCollectionFiltersByToken(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+	})
+
+	origin := newMockListingOrigin(t, map[string]string{"/priv/a.txt": "a", "/priv/b.txt": "b", "/priv/shared/c.txt": "c"}, "/priv", "/priv/shared")
+	defer origin.Close()
+	originUrl, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+	ns := server_structs.NamespaceAdV2{Path: "/priv", Caps: server_structs.Capabilities{Listings: true}}
+	serverAds.Set(originUrl.String(), &server_structs.Advertisement{
+		ServerAd:     server_structs.ServerAd{Name: "origin", URL: *originUrl, Type: server_structs.OriginType, Listings: true},
+		NamespaceAds: []server_structs.NamespaceAdV2{ns},
+	}, ttlcache.DefaultTTL)
+
+	makeToken := func(scope string) string {
+		tok, err := jwt.NewBuilder().Claim("scope", scope).Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(tok, jwt.WithInsecureNoSignature())
+		require.NoError(t, err)
+		return string(signed)
+	}
+	router := gin.New()
+	router.GET("/api/v1.0/director/listing/*path", listCollection)
+	list := func(query string, tok string) server_structs.ListingResp {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/listing/priv?"+query, nil)
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := server_structs.ListingResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+	names := func(res server_structs.ListingResp) []string {
+		names := []string{}
+		for _, entry := range res.Entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	t.Run("narrow-scope", func(t *testing.T) {
+		assert.Equal(t, []string{"b.txt"}, names(list("", makeToken("storage.read:/b.txt"))))
+		// Collections leading to a granted path are listed as well
+		assert.Equal(t, []string{"shared"}, names(list("", makeToken("storage.read:/shared/c.txt"))))
+	})
+
+	t.Run("broad-scope", func(t *testing.T) {
+		assert.Equal(t, []string{"a.txt", "b.txt", "shared"}, names(list("", makeToken("storage.read:/"))))
+	})
+
+	t.Run("opaque-token-unfiltered", func(t *testing.T) {
+		assert.Equal(t, []string{"a.txt", "b.txt", "shared"}, names(list("", "opaque-token")))
+	})
+
+	t.Run("pages-skip-hidden-entries", func(t *testing.T) {
+		tok := makeToken("storage.read:/b.txt")
+		first := list("limit=1", tok)
+		assert.Empty(t, first.Entries)
+		require.NotEmpty(t, first.NextCursor)
+		second := list("limit=1&cursor="+url.QueryEscape(first.NextCursor), tok)
+		assert.Equal(t, []string{"b.txt"}, names(second))
+	})
+}
@@ -132,6 +132,46 @@ func reportStatusToServer(ctx context.Context, serverWebUrl string, status strin
 	return nil
 }
 
+// Update the health status of the server from the outcome of a director test. A server passing the test
+// is degraded if the test took longer than Director.HealthTestDegradedLatency. A server failing the test is
+// degraded until it fails Director.HealthFailureThreshold tests in a row, after which it's down
+func recordHealthTestResult(serverAd server_structs.ServerAd, succeeded bool, elapsed time.Duration) {
+	healthTestUtilsMutex.Lock()
+	defer healthTestUtilsMutex.Unlock()
+	existingUtil, ok := healthTestUtils[serverAd.URL.String()]
+	if !ok {
+		log.Debugln("HealthTestUtil missing for", serverAd.Type, "server:", serverAd.URL.String(), "Failed to update internal status")
+		return
+	}
+	if succeeded {
+		existingUtil.ConsecutiveFailures = 0
+		if degradedLatency := reloadableDuration(param.Director_HealthTestDegradedLatency); degradedLatency > 0 && elapsed > degradedLatency {
+			log.Debugf("The director test of %s server %s took %s, longer than %s; marking the server as degraded", serverAd.Type, serverAd.Name, elapsed.Round(time.Millisecond), degradedLatency)
+			existingUtil.Status = HealthStatusDegraded
+		} else {
+			existingUtil.Status = HealthStatusOK
+		}
+		return
+	}
+	existingUtil.ConsecutiveFailures++
+	recordServerFailure(serverAd.URL.String(), time.Now())
+	if existingUtil.ConsecutiveFailures >= reloadableInt(param.Director_HealthFailureThreshold) {
+		existingUtil.Status = HealthStatusError
+	} else {
+		existingUtil.Status = HealthStatusDegraded
+	}
+}
+
+// Get the health status of the server from the director tests
+func getHealthStatus(serverAd server_structs.ServerAd) HealthTestStatus {
+	healthTestUtilsMutex.RLock()
+	defer healthTestUtilsMutex.RUnlock()
+	if util, ok := healthTestUtils[serverAd.URL.String()]; ok {
+		return util.Status
+	}
+	return HealthStatusUnknown
+}
+
 // Run a periodic test file transfer against an origin to ensure
 // it's talking to the director
 func LaunchPeriodicDirectorTest(ctx context.Context, serverAd server_structs.ServerAd) {
@@ -178,6 +218,7 @@ func LaunchPeriodicDirectorTest(ctx context.Context, serverAd server_structs.Ser
 			log.Debug(fmt.Sprintf("Starting a director test cycle for %s server %s at %s", serverAd.Type, serverName, serverUrl))
 			ok := true
 			var err error
+			testStart := time.Now()
 			if serverAd.Type == server_structs.OriginType {
 				fileTests := server_utils.TestFileTransferImpl{}
 				ok, err = fileTests.RunTests(ctx, serverUrl, serverUrl, "", server_utils.DirectorTest)
@@ -188,15 +229,7 @@ func LaunchPeriodicDirectorTest(ctx context.Context, serverAd server_structs.Ser
 			// Successfully run a test, no error
 			if ok && err == nil {
 				log.Debugf("Director file transfer test cycle succeeded at %s for %s server with URL at %s", time.Now().Format(time.RFC3339), serverAd.Type, serverUrl)
-				func() {
-					healthTestUtilsMutex.Lock()
-					defer healthTestUtilsMutex.Unlock()
-					if existingUtil, ok := healthTestUtils[serverAd.URL.String()]; ok {
-						existingUtil.Status = HealthStatusOK
-					} else {
-						log.Debugln("HealthTestUtil missing for ", serverAd.Type, " server: ", serverUrl, " Failed to update internal status")
-					}
-				}()
+				recordHealthTestResult(serverAd, true, time.Since(testStart))
 
 				// Report error back to origin/server
 				if err := reportStatusToServer(
@@ -267,15 +300,7 @@ func LaunchPeriodicDirectorTest(ctx context.Context, serverAd server_structs.Ser
 				// The file tests failed. Report failure back to origin/cache
 			} else {
 				log.Warningln("Director file transfer test cycle failed for ", serverAd.Type, " server: ", serverUrl, " ", err)
-				func() {
-					healthTestUtilsMutex.Lock()
-					defer healthTestUtilsMutex.Unlock()
-					if existingUtil, ok := healthTestUtils[serverAd.URL.String()]; ok {
-						existingUtil.Status = HealthStatusError
-					} else {
-						log.Debugln("HealthTestUtil missing for", serverAd.Type, " server: ", serverUrl, " Failed to update internal status")
-					}
-				}()
+				recordHealthTestResult(serverAd, false, time.Since(testStart))
 
 				if err := reportStatusToServer(
 					ctx,
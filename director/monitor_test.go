@@ -0,0 +1,107 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestRecordHealthTestResult(t *testing.T) {
+	viper.Reset()
+	serverAds.DeleteAll()
+	healthTestUtilsMutex.Lock()
+	tmp := healthTestUtils
+	healthTestUtils = map[string]*healthTestUtil{mockOriginServerAd.URL.String(): {Status: HealthStatusInit}}
+	healthTestUtilsMutex.Unlock()
+	t.Cleanup(func() {
+		healthTestUtilsMutex.Lock()
+		healthTestUtils = tmp
+		healthTestUtilsMutex.Unlock()
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	viper.Set("Director.HealthTestDegradedLatency", time.Second)
+	viper.Set("Director.HealthFailureThreshold", 2)
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockOriginServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}},
+	}, ttlcache.DefaultTTL)
+	originsForPath := func() int {
+		_, originAds, _ := getAdsForPath("/foo/bar")
+		return len(originAds)
+	}
+	router := gin.New()
+	router.GET("/servers", listServers)
+	listedFailures := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/servers", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		servers := []listServerResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &servers))
+		for _, server := range servers {
+			if server.URL == mockOriginServerAd.URL.String() {
+				return server.HealthFailures
+			}
+		}
+		require.Fail(t, "the server isn't listed")
+		return 0
+	}
+
+	recordHealthTestResult(mockOriginServerAd, true, 100*time.Millisecond)
+	assert.Equal(t, HealthStatusOK, getHealthStatus(mockOriginServerAd))
+
+	// A slow test degrades the server, but it's still redirected to
+	recordHealthTestResult(mockOriginServerAd, true, 2*time.Second)
+	assert.Equal(t, HealthStatusDegraded, getHealthStatus(mockOriginServerAd))
+	assert.Equal(t, 1, originsForPath())
+
+	// A single failure degrades the server; failing the threshold in a row brings it down
+	recordHealthTestResult(mockOriginServerAd, false, 100*time.Millisecond)
+	assert.Equal(t, HealthStatusDegraded, getHealthStatus(mockOriginServerAd))
+	assert.Equal(t, 1, originsForPath())
+	assert.Equal(t, 1, listedFailures())
+	recordHealthTestResult(mockOriginServerAd, false, 100*time.Millisecond)
+	assert.Equal(t, HealthStatusError, getHealthStatus(mockOriginServerAd))
+	assert.Equal(t, 0, originsForPath())
+	assert.Equal(t, 2, listedFailures())
+
+	// The server recovers after passing a test
+	recordHealthTestResult(mockOriginServerAd, true, 100*time.Millisecond)
+	assert.Equal(t, HealthStatusOK, getHealthStatus(mockOriginServerAd))
+	assert.Equal(t, 1, originsForPath())
+	assert.Equal(t, 0, listedFailures())
+
+	// With a threshold of 1, a single failure brings the server down
+	viper.Set("Director.HealthFailureThreshold", 1)
+	recordHealthTestResult(mockOriginServerAd, false, 100*time.Millisecond)
+	assert.Equal(t, HealthStatusError, getHealthStatus(mockOriginServerAd))
+}
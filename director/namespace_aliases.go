@@ -0,0 +1,113 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+const namespaceAliasRefreshInterval = 5 * time.Minute
+
+// Namespace aliases fetched from the registry, with the key being the alias path and the value being the target
+var namespaceAliases atomic.Pointer[map[string]string]
+
+// Fetch the namespace aliases configured at the registry
+func fetchNamespaceAliases(ctx context.Context) (map[string]string, error) {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fedInfo.NamespaceRegistrationEndpoint == "" {
+		return nil, errors.New("Federation.RegistryUrl is not set")
+	}
+	aliasUrl, err := url.JoinPath(fedInfo.NamespaceRegistrationEndpoint, "api", "v1.0", "registry_ui", "aliases")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct the registry's namespace aliases URL")
+	}
+	var body []byte
+	err = registryBreaker.call(func() (err error) {
+		body, err = utils.MakeRequest(ctx, aliasUrl, http.MethodGet, nil, nil)
+		return
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get namespace aliases from the registry")
+	}
+	aliases := []server_structs.NamespaceAlias{}
+	if err := json.Unmarshal(body, &aliases); err != nil {
+		return nil, errors.Wrap(err, "failed to parse namespace aliases from the registry")
+	}
+	// The registry already validates the aliases, but don't trust it to avoid looping on each request
+	return server_structs.ValidateNamespaceAliases(aliases)
+}
+
+// Periodically fetch the namespace aliases from the registry
+func LaunchNamespaceAliasFetch(ctx context.Context, egrp *errgroup.Group) {
+	refresh := func() {
+		aliases, err := fetchNamespaceAliases(ctx)
+		if err != nil {
+			log.Warningln("Failed to refresh namespace aliases from the registry:", err)
+			return
+		}
+		namespaceAliases.Store(&aliases)
+	}
+	egrp.Go(func() error {
+		refresh()
+		ticker := time.NewTicker(namespaceAliasRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+}
+
+// Resolve the request path through the namespace aliases from the registry, returning
+// the request path untouched if no alias applies
+func resolveNamespaceAlias(reqPath string) string {
+	aliases := namespaceAliases.Load()
+	if aliases == nil || len(*aliases) == 0 {
+		return reqPath
+	}
+	resolved, err := server_structs.ResolveNamespaceAlias(*aliases, reqPath)
+	if err != nil {
+		log.Warningf("Failed to resolve namespace aliases for %s: %v", reqPath, err)
+		return reqPath
+	}
+	if resolved != reqPath {
+		log.Debugf("Resolved namespace alias %s to %s", reqPath, resolved)
+	}
+	return resolved
+}
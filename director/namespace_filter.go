@@ -0,0 +1,204 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type namespaceFilterResp struct {
+	Allowlist []string `json:"allowlist"`
+	Denylist  []string `json:"denylist"`
+}
+
+var (
+	namespaceAllowlist   []string
+	namespaceDenylist    []string
+	namespaceFilterMutex = sync.RWMutex{}
+)
+
+// Check every pattern is a valid glob
+func validateNamespacePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return errors.Wrapf(err, "invalid namespace pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Set the namespace allowlist and denylist from Director.NamespaceAllowlist and
+// Director.NamespaceDenylist. The current lists are kept if either is invalid
+func ConfigNamespaceFilters() error {
+	allowlist := reloadableStringSlice(param.Director_NamespaceAllowlist)
+	denylist := reloadableStringSlice(param.Director_NamespaceDenylist)
+	if err := validateNamespacePatterns(allowlist); err != nil {
+		return errors.Wrap(err, "invalid Director.NamespaceAllowlist")
+	}
+	if err := validateNamespacePatterns(denylist); err != nil {
+		return errors.Wrap(err, "invalid Director.NamespaceDenylist")
+	}
+
+	namespaceFilterMutex.Lock()
+	defer namespaceFilterMutex.Unlock()
+	namespaceAllowlist = allowlist
+	namespaceDenylist = denylist
+	log.Debugf("Namespace allowlist: %v; namespace denylist: %v", allowlist, denylist)
+	return nil
+}
+
+// Re-read the namespace allowlist and denylist from the config file and apply them
+func reloadNamespaceFilters() error {
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
+		fileConfig := viper.New()
+		fileConfig.SetConfigFile(configFile)
+		if err := fileConfig.ReadInConfig(); err != nil {
+			return errors.Wrapf(err, "failed to read the config file %s", configFile)
+		}
+		allowlistName := param.Director_NamespaceAllowlist.GetName()
+		denylistName := param.Director_NamespaceDenylist.GetName()
+		if err := validateNamespacePatterns(fileConfig.GetStringSlice(allowlistName)); err != nil {
+			return errors.Wrap(err, "invalid Director.NamespaceAllowlist")
+		}
+		if err := validateNamespacePatterns(fileConfig.GetStringSlice(denylistName)); err != nil {
+			return errors.Wrap(err, "invalid Director.NamespaceDenylist")
+		}
+
+		// Replace the two lists in a copy of the reloaded settings rather than in the global config
+		configReloadMutex.Lock()
+		defer configReloadMutex.Unlock()
+		snapshot := &directorConfigSnapshot{values: map[string]interface{}{}}
+		if current := reloadedDirectorConfig.Load(); current != nil {
+			for key, value := range current.values {
+				snapshot.values[key] = value
+			}
+		}
+		snapshot.values[strings.ToLower(allowlistName)] = fileConfig.GetStringSlice(allowlistName)
+		snapshot.values[strings.ToLower(denylistName)] = fileConfig.GetStringSlice(denylistName)
+		reloadedDirectorConfig.Store(snapshot)
+	}
+	return ConfigNamespaceFilters()
+}
+
+// Check whether the pattern matches the namespace or any of its parents, so that
+// a pattern covering a namespace also covers the namespaces nested under it
+func matchesNamespacePattern(pattern string, nsPath string) bool {
+	for current := path.Clean(nsPath); ; current = path.Dir(current) {
+		if matched, _ := path.Match(pattern, current); matched {
+			return true
+		}
+		if current == "/" || current == "." {
+			return false
+		}
+	}
+}
+
+// Check whether the director serves the namespace, returning the status code to respond with if it doesn't.
+// The denylist takes precedence: a namespace matching the denylist is blocked with 451 even if it matches
+// the allowlist. Otherwise, when the allowlist is set, a namespace not matching it is blocked with 403
+func checkNamespaceFilter(nsPath string) (status int, blocked bool) {
+	if nsPath == "" {
+		return 0, false
+	}
+	namespaceFilterMutex.RLock()
+	defer namespaceFilterMutex.RUnlock()
+	for _, pattern := range namespaceDenylist {
+		if matchesNamespacePattern(pattern, nsPath) {
+			return http.StatusUnavailableForLegalReasons, true
+		}
+	}
+	if len(namespaceAllowlist) == 0 {
+		return 0, false
+	}
+	for _, pattern := range namespaceAllowlist {
+		if matchesNamespacePattern(pattern, nsPath) {
+			return 0, false
+		}
+	}
+	return http.StatusForbidden, true
+}
+
+// Respond to a request for a path whose namespace wasn't found, telling the client if
+// the namespace exists but is blocked by the namespace allowlist or denylist
+func respondNamespaceNotFound(ginCtx *gin.Context, reqPath string) {
+	if namespaceAd, _, _ := getAdsForPathUnfiltered(reqPath); namespaceAd.Path != "" {
+		if status, blocked := checkNamespaceFilter(namespaceAd.Path); blocked {
+			ginCtx.JSON(status, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "The namespace " + namespaceAd.Path + " is not available through this director",
+			})
+			return
+		}
+	}
+	ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    "No namespace found for path. Either it doesn't exist, or the Director is experiencing problems",
+	})
+}
+
+// A gin route handler re-reading the namespace allowlist and denylist from the config file
+func handleReloadNamespaceFilters(ginCtx *gin.Context) {
+	if err := reloadNamespaceFilters(); err != nil {
+		log.Errorln("Failed to reload the namespace filters:", err)
+		ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to reload the namespace filters: " + err.Error(),
+		})
+		return
+	}
+	namespaceFilterMutex.RLock()
+	defer namespaceFilterMutex.RUnlock()
+	ginCtx.JSON(http.StatusOK, namespaceFilterResp{Allowlist: namespaceAllowlist, Denylist: namespaceDenylist})
+}
+
+// Reload the namespace allowlist and denylist from the config file on SIGHUP
+func LaunchNamespaceFilterReload(ctx context.Context, egrp *errgroup.Group) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	egrp.Go(func() error {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-sigs:
+				log.Info("Received SIGHUP; reloading the namespace filters")
+				if err := reloadNamespaceFilters(); err != nil {
+					log.Errorln("Failed to reload the namespace filters; keeping the current ones:", err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,151 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func resetNamespaceFilters(t *testing.T) {
+	viper.Reset()
+	reloadedDirectorConfig.Store(nil)
+	require.NoError(t, ConfigNamespaceFilters())
+}
+
+func TestCheckNamespaceFilter(t *testing.T) {
+	t.Cleanup(func() { resetNamespaceFilters(t) })
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		denylist  []string
+		nsPath    string
+		status    int
+		blocked   bool
+	}{
+		{name: "no-filters", nsPath: "/foo", blocked: false},
+		{name: "denied", denylist: []string{"/foo"}, nsPath: "/foo", status: http.StatusUnavailableForLegalReasons, blocked: true},
+		{name: "denied-parent", denylist: []string{"/foo"}, nsPath: "/foo/bar", status: http.StatusUnavailableForLegalReasons, blocked: true},
+		{name: "not-denied-sibling", denylist: []string{"/foo"}, nsPath: "/foobar", blocked: false},
+		{name: "allowed-glob", allowlist: []string{"/foo/*"}, nsPath: "/foo/bar", blocked: false},
+		{name: "not-allowed", allowlist: []string{"/foo/*"}, nsPath: "/baz", status: http.StatusForbidden, blocked: true},
+		{name: "glob-does-not-cover-parent", allowlist: []string{"/foo/*"}, nsPath: "/foo", status: http.StatusForbidden, blocked: true},
+		// When the lists overlap, the denylist takes precedence
+		{name: "overlap-denied", allowlist: []string{"/foo/*"}, denylist: []string{"/foo/secret*"}, nsPath: "/foo/secret-data", status: http.StatusUnavailableForLegalReasons, blocked: true},
+		{name: "overlap-allowed", allowlist: []string{"/foo/*"}, denylist: []string{"/foo/secret*"}, nsPath: "/foo/public", blocked: false},
+		{name: "overlap-same-pattern", allowlist: []string{"/foo"}, denylist: []string{"/foo"}, nsPath: "/foo", status: http.StatusUnavailableForLegalReasons, blocked: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			viper.Set("Director.NamespaceAllowlist", test.allowlist)
+			viper.Set("Director.NamespaceDenylist", test.denylist)
+			require.NoError(t, ConfigNamespaceFilters())
+			status, blocked := checkNamespaceFilter(test.nsPath)
+			assert.Equal(t, test.blocked, blocked)
+			assert.Equal(t, test.status, status)
+		})
+	}
+
+	t.Run("invalid-pattern-keeps-current-filters", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Director.NamespaceDenylist", []string{"/foo"})
+		require.NoError(t, ConfigNamespaceFilters())
+		viper.Set("Director.NamespaceDenylist", []string{"/foo/["})
+		require.Error(t, ConfigNamespaceFilters())
+		_, blocked := checkNamespaceFilter("/foo")
+		assert.True(t, blocked)
+	})
+}
+
+func TestNamespaceFilterResolution(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		resetNamespaceFilters(t)
+	})
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{
+		ServerAd:     mockOriginServerAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}, {Path: "/foo/secret"}, {Path: "/takedown"}},
+	}, ttlcache.DefaultTTL)
+
+	configFile := filepath.Join(t.TempDir(), "pelican.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("Director:\n  NamespaceDenylist: [\"/takedown\"]\n"), 0600))
+	viper.Reset()
+	viper.SetConfigFile(configFile)
+	require.NoError(t, viper.ReadInConfig())
+	require.NoError(t, ConfigNamespaceFilters())
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/origin/*any", redirectToOrigin)
+	router.POST("/reload", handleReloadNamespaceFilters)
+	getStatus := func(objectPath string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/origin"+objectPath+"?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-client/7.6.1")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	listedPaths := func() []string {
+		paths := []string{}
+		for _, ns := range listNamespacesFromOrigins() {
+			paths = append(paths, ns.Path)
+		}
+		return paths
+	}
+
+	assert.Equal(t, http.StatusTemporaryRedirect, getStatus("/foo/obj.txt"))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, getStatus("/takedown/obj.txt"))
+	assert.Equal(t, http.StatusNotFound, getStatus("/unknown/obj.txt"))
+	assert.ElementsMatch(t, []string{"/foo", "/foo/secret"}, listedPaths())
+
+	// Lift the takedown and allowlist only /foo, then reload
+	require.NoError(t, os.WriteFile(configFile, []byte("Director:\n  NamespaceAllowlist: [\"/foo\"]\n"), 0600))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/reload", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, getStatus("/takedown/obj.txt"))
+	assert.ElementsMatch(t, []string{"/foo", "/foo/secret"}, listedPaths())
+	namespaceAd, _, _ := getAdsForPath("/foo/obj.txt")
+	assert.Equal(t, "/foo", namespaceAd.Path)
+
+	// With overlapping lists, the denylist wins for the namespace the object resolves to
+	require.NoError(t, os.WriteFile(configFile, []byte("Director:\n  NamespaceAllowlist: [\"/foo\"]\n  NamespaceDenylist: [\"/foo/secret\"]\n"), 0600))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/reload", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusTemporaryRedirect, getStatus("/foo/obj.txt"))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, getStatus("/foo/secret/obj.txt"))
+	assert.Equal(t, http.StatusForbidden, getStatus("/takedown/obj.txt"))
+	assert.Equal(t, []string{"/foo"}, listedPaths())
+}
@@ -0,0 +1,234 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+// The on-disk representation of the namespace request reports not yet delivered to the registry
+type namespaceStatsState struct {
+	Pending []server_structs.NamespaceStatsReport `json:"pending"`
+}
+
+const namespaceStatsRequestTimeout = 30 * time.Second
+
+var (
+	// The redirect requests counted per namespace prefix since namespaceStatsWindowStart
+	namespaceRequestCounts    = map[string]int64{}
+	namespaceStatsWindowStart = time.Now()
+	// The closed windows waiting to be delivered to the registry, oldest first
+	pendingNamespaceStats []server_structs.NamespaceStatsReport
+	namespaceStatsMutex   = sync.Mutex{}
+
+	// Send the report to the registry; a variable to be mocked in tests
+	sendNamespaceStats = postNamespaceStats
+)
+
+// Count a redirect request for the namespace prefix in the current reporting window
+func countNamespaceStatsRequest(namespacePath string) {
+	if namespacePath == "" || param.Director_NamespaceStatsPushInterval.GetDuration() <= 0 {
+		return
+	}
+	namespaceStatsMutex.Lock()
+	defer namespaceStatsMutex.Unlock()
+	namespaceRequestCounts[namespacePath]++
+}
+
+// Save the pending reports to Director.NamespaceStatsStateFile so that they are delivered after a restart.
+//
+// The caller must hold namespaceStatsMutex
+func persistNamespaceStatsLocked() {
+	stateFile := param.Director_NamespaceStatsStateFile.GetString()
+	if stateFile == "" {
+		return
+	}
+	if err := writeStateFile(stateFile, namespaceStatsState{Pending: pendingNamespaceStats}); err != nil {
+		log.Errorf("Failed to persist the pending namespace request reports to %s: %v", stateFile, err)
+	}
+}
+
+// Close the current reporting window at `now`, queueing its counts for delivery under a new window ID
+func closeNamespaceStatsWindow(now time.Time) {
+	namespaceStatsMutex.Lock()
+	defer namespaceStatsMutex.Unlock()
+	if len(namespaceRequestCounts) > 0 {
+		pendingNamespaceStats = append(pendingNamespaceStats, server_structs.NamespaceStatsReport{
+			WindowID:    uuid.NewString(),
+			WindowStart: namespaceStatsWindowStart,
+			WindowEnd:   now,
+			Requests:    namespaceRequestCounts,
+		})
+		namespaceRequestCounts = map[string]int64{}
+		persistNamespaceStatsLocked()
+	}
+	namespaceStatsWindowStart = now
+}
+
+// Deliver the pending reports to the registry in order, stopping at the first failure so that
+// the remaining reports are retried on the next push with the same window IDs
+func pushNamespaceStats(ctx context.Context) error {
+	for {
+		namespaceStatsMutex.Lock()
+		if len(pendingNamespaceStats) == 0 {
+			namespaceStatsMutex.Unlock()
+			return nil
+		}
+		report := pendingNamespaceStats[0]
+		namespaceStatsMutex.Unlock()
+
+		if err := registryBreaker.call(func() error { return sendNamespaceStats(ctx, report) }); err != nil {
+			return errors.Wrapf(err, "failed to report the namespace requests of window %s to the registry", report.WindowID)
+		}
+
+		namespaceStatsMutex.Lock()
+		if len(pendingNamespaceStats) > 0 && pendingNamespaceStats[0].WindowID == report.WindowID {
+			pendingNamespaceStats = pendingNamespaceStats[1:]
+		}
+		persistNamespaceStatsLocked()
+		namespaceStatsMutex.Unlock()
+	}
+}
+
+// Send the report to the registry, authorized by a director-issued token
+func postNamespaceStats(ctx context.Context, report server_structs.NamespaceStatsReport) error {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return err
+	}
+	if fedInfo.NamespaceRegistrationEndpoint == "" {
+		return errors.New("the registry endpoint is unknown")
+	}
+	statsUrl, err := url.JoinPath(fedInfo.NamespaceRegistrationEndpoint, "api/v1.0/registry/namespaceStats")
+	if err != nil {
+		return errors.Wrap(err, "failed to construct the registry URL")
+	}
+
+	tokCfg := token.NewWLCGToken()
+	tokCfg.Lifetime = time.Minute
+	tokCfg.Issuer = param.Server_ExternalWebUrl.GetString()
+	tokCfg.AddAudiences(fedInfo.NamespaceRegistrationEndpoint)
+	tokCfg.Subject = "director"
+	tokCfg.AddScopes(token_scopes.Pelican_NamespaceStats)
+	tok, err := tokCfg.CreateToken()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the namespace stats token")
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the namespace stats report")
+	}
+	ctx, cancel := context.WithTimeout(ctx, namespaceStatsRequestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, statsUrl, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the namespace stats request")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+tok)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to send the namespace stats request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		resBody, _ := io.ReadAll(resp.Body)
+		return errors.Errorf("the registry responded with status code %d: %s", resp.StatusCode, string(resBody))
+	}
+	return nil
+}
+
+// Reload the undelivered reports of the previous run from Director.NamespaceStatsStateFile.
+// A missing or corrupt state file leaves no pending reports
+func loadNamespaceStats() {
+	stateFile := param.Director_NamespaceStatsStateFile.GetString()
+	if stateFile == "" {
+		return
+	}
+	content, err := os.ReadFile(stateFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warningf("Failed to read the namespace stats state file %s: %v", stateFile, err)
+		}
+		return
+	}
+	state := namespaceStatsState{}
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Warningf("The namespace stats state file %s is corrupt; dropping the undelivered reports: %v", stateFile, err)
+		return
+	}
+	namespaceStatsMutex.Lock()
+	defer namespaceStatsMutex.Unlock()
+	pendingNamespaceStats = append(state.Pending, pendingNamespaceStats...)
+	if len(state.Pending) > 0 {
+		log.Infof("Loaded %d undelivered namespace request reports from %s", len(state.Pending), stateFile)
+	}
+}
+
+// Periodically report the redirect requests counted per namespace to the registry, every
+// Director.NamespaceStatsPushInterval. On shutdown, the current window is closed and saved
+// so that it's delivered after the restart
+func LaunchNamespaceStatsPush(ctx context.Context, egrp *errgroup.Group) {
+	interval := param.Director_NamespaceStatsPushInterval.GetDuration()
+	if interval <= 0 {
+		log.Debugln("Director.NamespaceStatsPushInterval is not positive; not reporting namespace requests to the registry")
+		return
+	}
+	loadNamespaceStats()
+
+	egrp.Go(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				closeNamespaceStatsWindow(time.Now())
+				if err := pushNamespaceStats(ctx); err != nil {
+					log.Warningln(err)
+				}
+			case <-ctx.Done():
+				closeNamespaceStatsWindow(time.Now())
+				log.Infoln("Namespace stats push loop has been terminated")
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,138 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// A registry that accumulates the reports once per window ID
+type mockStatsRegistry struct {
+	windows  map[string]bool
+	requests map[string]int64
+	// Fail the next sends before accumulating them
+	failSends int
+	// Accumulate the next sends but fail them, as if the response was lost
+	loseResponses int
+}
+
+func (r *mockStatsRegistry) send(ctx context.Context, report server_structs.NamespaceStatsReport) error {
+	if r.failSends > 0 {
+		r.failSends--
+		return errors.New("connection refused")
+	}
+	if !r.windows[report.WindowID] {
+		r.windows[report.WindowID] = true
+		for prefix, count := range report.Requests {
+			r.requests[prefix] += count
+		}
+	}
+	if r.loseResponses > 0 {
+		r.loseResponses--
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+// Reset the in-memory state as a director restart does
+func resetNamespaceStats() {
+	namespaceStatsMutex.Lock()
+	defer namespaceStatsMutex.Unlock()
+	namespaceRequestCounts = map[string]int64{}
+	pendingNamespaceStats = nil
+	namespaceStatsWindowStart = time.Now()
+}
+
+func TestNamespaceStatsPush(t *testing.T) {
+	viper.Reset()
+	oldSend := sendNamespaceStats
+	t.Cleanup(func() {
+		sendNamespaceStats = oldSend
+		resetNamespaceStats()
+		viper.Reset()
+	})
+	viper.Set("Director.NamespaceStatsPushInterval", time.Minute)
+	viper.Set("Director.NamespaceStatsStateFile", filepath.Join(t.TempDir(), "namespace-stats.json"))
+	registry := &mockStatsRegistry{windows: map[string]bool{}, requests: map[string]int64{}}
+	sendNamespaceStats = registry.send
+	ctx := context.Background()
+
+	t.Run("retry-does-not-double-count", func(t *testing.T) {
+		resetNamespaceStats()
+		countNamespaceStatsRequest("/foo")
+		countNamespaceStatsRequest("/foo")
+		countNamespaceStatsRequest("")
+		closeNamespaceStatsWindow(time.Now())
+
+		registry.loseResponses = 1
+		require.Error(t, pushNamespaceStats(ctx))
+		require.NoError(t, pushNamespaceStats(ctx))
+		assert.Equal(t, map[string]int64{"/foo": 2}, registry.requests)
+		assert.Len(t, registry.windows, 1)
+	})
+
+	t.Run("backfill-across-restarts", func(t *testing.T) {
+		resetNamespaceStats()
+		registry.requests = map[string]int64{}
+		countNamespaceStatsRequest("/foo")
+		countNamespaceStatsRequest("/bar")
+		closeNamespaceStatsWindow(time.Now())
+		registry.failSends = 1
+		require.Error(t, pushNamespaceStats(ctx))
+
+		// The director shuts down with more requests in the current window
+		countNamespaceStatsRequest("/bar")
+		closeNamespaceStatsWindow(time.Now())
+
+		// After the restart, both undelivered windows are sent
+		resetNamespaceStats()
+		loadNamespaceStats()
+		countNamespaceStatsRequest("/foo")
+		closeNamespaceStatsWindow(time.Now())
+		require.NoError(t, pushNamespaceStats(ctx))
+		assert.Equal(t, map[string]int64{"/foo": 2, "/bar": 2}, registry.requests)
+
+		// Nothing is left to backfill after another restart
+		resetNamespaceStats()
+		loadNamespaceStats()
+		namespaceStatsMutex.Lock()
+		assert.Empty(t, pendingNamespaceStats)
+		namespaceStatsMutex.Unlock()
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		resetNamespaceStats()
+		viper.Set("Director.NamespaceStatsPushInterval", 0)
+		countNamespaceStatsRequest("/foo")
+		closeNamespaceStatsWindow(time.Now())
+		namespaceStatsMutex.Lock()
+		assert.Empty(t, pendingNamespaceStats)
+		namespaceStatsMutex.Unlock()
+	})
+}
@@ -0,0 +1,116 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// The maximum number of not-found decisions the director remembers at once
+const notFoundCacheCapacity = 100000
+
+// The in-memory cache of object paths no server could serve, kept for Director.NotFoundCacheTTL
+// so that repeated requests for a missing object are answered without querying the servers again.
+// The key is "<server type>:<object path>" and the value is the message of the 404 response
+var notFoundCache = ttlcache.New(
+	ttlcache.WithCapacity[string, string](notFoundCacheCapacity),
+	// A repeated request must not keep the decision alive past its TTL
+	ttlcache.WithDisableTouchOnHit[string, string](),
+)
+
+func notFoundCacheKey(sType server_structs.ServerType, reqPath string) string {
+	return string(sType) + ":" + reqPath
+}
+
+// Remember that no server of the given type could serve reqPath
+func recordNotFound(sType server_structs.ServerType, reqPath string, msg string) {
+	ttl := reloadableDuration(param.Director_NotFoundCacheTTL)
+	if ttl <= 0 {
+		return
+	}
+	notFoundCache.Set(notFoundCacheKey(sType, reqPath), msg, ttl)
+}
+
+// Look up a recent not-found decision for reqPath, returning the message of the 404 response
+func lookupNotFound(sType server_structs.ServerType, reqPath string) (msg string, found bool) {
+	if reloadableDuration(param.Director_NotFoundCacheTTL) <= 0 {
+		return "", false
+	}
+	item := notFoundCache.Get(notFoundCacheKey(sType, reqPath))
+	if item == nil {
+		return "", false
+	}
+	return item.Value(), true
+}
+
+// Respond with a 404 for an object no server of the given type can serve, remembering the
+// decision if it's based on a stat of the servers
+func respondObjectNotFound(ginCtx *gin.Context, sType server_structs.ServerType, reqPath string, msg string, statted bool) {
+	if statted {
+		recordNotFound(sType, reqPath, msg)
+	}
+	ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    msg,
+	})
+}
+
+// Forget the not-found decisions for reqPath, e.g. because the object is being uploaded
+func invalidateNotFoundPath(reqPath string) {
+	notFoundCache.Delete(notFoundCacheKey(server_structs.OriginType, reqPath))
+	notFoundCache.Delete(notFoundCacheKey(server_structs.CacheType, reqPath))
+}
+
+// Forget the not-found decisions for every path under the given namespaces, as a
+// server that may serve them has just advertised
+func invalidateNotFoundNamespaces(namespaceAds []server_structs.NamespaceAdV2) {
+	if len(namespaceAds) == 0 || notFoundCache.Len() == 0 {
+		return
+	}
+	for _, key := range notFoundCache.Keys() {
+		_, reqPath, _ := strings.Cut(key, ":")
+		if matchesPrefix(reqPath, namespaceAds) != nil {
+			notFoundCache.Delete(key)
+		}
+	}
+}
+
+// Report whether a server's advertisement covers a different set of namespaces than before
+func namespacesChanged(oldAds, newAds []server_structs.NamespaceAdV2) bool {
+	if len(oldAds) != len(newAds) {
+		return true
+	}
+	paths := make(map[string]bool, len(oldAds))
+	for _, ns := range oldAds {
+		paths[ns.Path] = true
+	}
+	for _, ns := range newAds {
+		if !paths[ns.Path] {
+			return true
+		}
+	}
+	return false
+}
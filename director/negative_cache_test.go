@@ -0,0 +1,165 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestNotFoundCache(t *testing.T) {
+	t.Cleanup(func() {
+		notFoundCache.DeleteAll()
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+
+	t.Run("disabled-by-default", func(t *testing.T) {
+		viper.Reset()
+		notFoundCache.DeleteAll()
+		recordNotFound(server_structs.OriginType, "/foo/bar", "missing")
+		_, found := lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.False(t, found)
+		assert.Equal(t, 0, notFoundCache.Len())
+	})
+
+	t.Run("entries-expire", func(t *testing.T) {
+		viper.Reset()
+		notFoundCache.DeleteAll()
+		viper.Set("Director.NotFoundCacheTTL", 100*time.Millisecond)
+
+		recordNotFound(server_structs.OriginType, "/foo/bar", "missing")
+		msg, found := lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.True(t, found)
+		assert.Equal(t, "missing", msg)
+		// Decisions are per server type
+		_, found = lookupNotFound(server_structs.CacheType, "/foo/bar")
+		assert.False(t, found)
+
+		// Repeated lookups don't extend the entry's lifetime
+		time.Sleep(60 * time.Millisecond)
+		_, found = lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.True(t, found)
+		time.Sleep(60 * time.Millisecond)
+		_, found = lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.False(t, found)
+	})
+
+	t.Run("ads-invalidate-entries", func(t *testing.T) {
+		viper.Reset()
+		notFoundCache.DeleteAll()
+		serverAds.DeleteAll()
+		viper.Set("Director.NotFoundCacheTTL", time.Minute)
+
+		originAd := server_structs.ServerAd{
+			Name: "origin1",
+			URL:  url.URL{Scheme: "https", Host: "origin1.example.com"},
+			Type: server_structs.OriginType,
+		}
+		fooSlice := []server_structs.NamespaceAdV2{{Path: "/foo"}}
+		recordAd(context.Background(), originAd, &fooSlice)
+
+		recordNotFound(server_structs.OriginType, "/foo/bar", "missing")
+		recordNotFound(server_structs.CacheType, "/foo/bar", "missing")
+		recordNotFound(server_structs.OriginType, "/foobar/baz", "missing")
+		recordNotFound(server_structs.OriginType, "/other/baz", "missing")
+
+		// Re-advertising the same namespaces keeps the entries
+		recordAd(context.Background(), originAd, &fooSlice)
+		assert.Equal(t, 4, notFoundCache.Len())
+
+		// An origin that now serves another namespace may have the objects under it
+		otherSlice := []server_structs.NamespaceAdV2{{Path: "/foo"}, {Path: "/other"}}
+		recordAd(context.Background(), originAd, &otherSlice)
+		_, found := lookupNotFound(server_structs.OriginType, "/other/baz")
+		assert.False(t, found)
+		_, found = lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.False(t, found, "entries under every namespace of a changed ad are dropped")
+		_, found = lookupNotFound(server_structs.OriginType, "/foobar/baz")
+		assert.True(t, found)
+
+		// A new server drops the entries under its namespaces
+		recordNotFound(server_structs.CacheType, "/foo/bar", "missing")
+		cacheAd := server_structs.ServerAd{
+			Name: "cache1",
+			URL:  url.URL{Scheme: "https", Host: "cache1.example.com"},
+			Type: server_structs.CacheType,
+		}
+		recordAd(context.Background(), cacheAd, &fooSlice)
+		_, found = lookupNotFound(server_structs.CacheType, "/foo/bar")
+		assert.False(t, found)
+		_, found = lookupNotFound(server_structs.OriginType, "/foobar/baz")
+		assert.True(t, found)
+	})
+
+	t.Run("redirects-use-entries", func(t *testing.T) {
+		viper.Reset()
+		notFoundCache.DeleteAll()
+		serverAds.DeleteAll()
+		viper.Set("Director.NotFoundCacheTTL", time.Minute)
+		viper.Set("Director.CacheSortMethod", "random")
+		viper.Set("Director.EnableStat", true)
+
+		writableOrigin := mockOriginServerAd
+		writableOrigin.Writes = true
+		writableOrigin.Caps = server_structs.Capabilities{Reads: true, Writes: true}
+		serverAds.Set(writableOrigin.URL.String(), &server_structs.Advertisement{
+			ServerAd:     writableOrigin,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: server_structs.Capabilities{Reads: true, Writes: true}}},
+		}, ttlcache.DefaultTTL)
+		recordNotFound(server_structs.OriginType, "/foo/bar", "The object was not found")
+
+		router := gin.New()
+		router.Handle(http.MethodGet, "/api/v1.0/director/origin/*any", redirectToOrigin)
+		router.Handle(http.MethodPut, "/api/v1.0/director/origin/*any", redirectToOrigin)
+		doRequest := func(method, query string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, "/api/v1.0/director/origin/foo/bar"+query, nil)
+			req.Header.Set("User-Agent", "pelican-v7.999.999")
+			req.Header.Set("X-Real-Ip", "128.104.153.60")
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		// The origins aren't asked again
+		w := doRequest(http.MethodGet, "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "The object was not found")
+
+		// Without a stat, there's nothing to short-circuit
+		assert.Equal(t, http.StatusTemporaryRedirect, doRequest(http.MethodGet, "?skipstat").Code)
+
+		// Uploading the object drops the entry
+		assert.Equal(t, http.StatusTemporaryRedirect, doRequest(http.MethodPut, "").Code)
+		_, found := lookupNotFound(server_structs.OriginType, "/foo/bar")
+		assert.False(t, found)
+	})
+}
@@ -0,0 +1,380 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// A query parameter of a director API operation
+	apiParameter struct {
+		name        string
+		description string
+		schemaType  string // The OpenAPI type of the parameter, "string" by default
+		required    bool
+	}
+
+	// A director API operation as documented in the OpenAPI spec. The request and
+	// response schemas are generated from the Go types the handlers bind and return
+	apiOperation struct {
+		method      string
+		path        string // The route as registered with gin
+		summary     string
+		auth        string // "admin" for admin credentials, "user" for a logged-in user, "token" for a server token, "" if public
+		query       []apiParameter
+		requestBody any    // A value of the type of the JSON request body, if any
+		response    any    // A value of the type of the JSON response body, if any
+		contentType string // The content type of the response, if not JSON
+		redirect    bool   // The operation redirects the client rather than responding with a body
+	}
+
+	// Build JSON schemas of Go types, collecting the named struct types as reusable components
+	openAPISchemaGenerator struct {
+		schemas map[string]any
+		names   map[reflect.Type]string
+	}
+)
+
+var (
+	objectRedirectQuery = []apiParameter{
+		{name: "authz", description: "A token authorizing the request, if it isn't sent in the Authorization header"},
+		{name: "skipstat", description: "Skip checking which servers have the object"},
+		{name: "directread", description: "Redirect to an origin rather than a cache"},
+		{name: "prefercached", description: "Prefer caches already having the object"},
+		{name: maxAdAgeQuery, description: "Only redirect to servers that advertised within this many seconds", schemaType: "integer"},
+		{name: "pelican.timeout", description: "The timeout of the request, passed on to the server"},
+	}
+
+	// The operations of the director API. Every route registered by RegisterDirectorAPI and
+	// RegisterDirectorWebAPI must be listed here, except those in undocumentedRoutes
+	directorAPIOperations = []apiOperation{
+		{method: http.MethodGet, path: "/api/v1.0/director/object/*any", summary: "Redirect to a cache serving the object", query: objectRedirectQuery, redirect: true},
+		{method: http.MethodHead, path: "/api/v1.0/director/object/*any", summary: "Redirect to a cache serving the object", query: objectRedirectQuery, redirect: true},
+		{method: http.MethodGet, path: "/api/v1.0/director/origin/*any", summary: "Redirect to an origin serving the object", query: objectRedirectQuery, redirect: true},
+		{method: http.MethodHead, path: "/api/v1.0/director/origin/*any", summary: "Redirect to an origin serving the object", query: objectRedirectQuery, redirect: true},
+		{method: http.MethodPut, path: "/api/v1.0/director/origin/*any", summary: "Redirect an upload to a writeable origin", query: objectRedirectQuery, redirect: true},
+		{method: http.MethodPost, path: "/api/v1.0/director/registerOrigin", summary: "Advertise an origin to the director", auth: "token", requestBody: server_structs.OriginAdvertiseV2{}, response: server_structs.SimpleApiResp{}},
+		{method: http.MethodPost, path: "/api/v1.0/director/registerCache", summary: "Advertise a cache to the director", auth: "token", requestBody: server_structs.OriginAdvertiseV2{}, response: server_structs.SimpleApiResp{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/listNamespaces", summary: "List the namespaces advertised to the director", response: []server_structs.NamespaceAdV1{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/namespaces/prefix/*path", summary: "Get the namespace prefix serving a path", response: server_structs.GetPrefixByPathRes{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/healthTest/*path", summary: "Get a director health test file", contentType: "text/plain"},
+		{method: http.MethodHead, path: "/api/v1.0/director/healthTest/*path", summary: "Get a director health test file", contentType: "text/plain"},
+		{method: http.MethodGet, path: "/api/v1.0/director/discoverServers", summary: "List the servers to scrape for metrics, in the Prometheus HTTP service discovery format", auth: "token", response: []PromDiscoveryItem{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/topology", summary: "Get the graph of the servers and namespaces of the federation", response: federationGraph{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/snapshot", summary: "Get a versioned snapshot of the namespaces and the servers serving them, with an ETag for conditional polling", response: federationSnapshot{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/explain", summary: "Explain how the director would rank the servers for an object", response: explainResponse{}, query: []apiParameter{
+			{name: "path", description: "The object path", required: true},
+			{name: "client_ip", description: "The client IP address to rank the servers for, by default that of the requester"},
+		}},
+		{method: http.MethodGet, path: "/api/v1.0/director/listing/*path", summary: "List a collection across the origins serving it", response: server_structs.ListingResp{}, query: []apiParameter{
+			{name: "prefix", description: "Only list the entries whose name starts with the prefix"},
+			{name: "limit", description: "The maximum number of entries to return", schemaType: "integer"},
+			{name: "cursor", description: "The cursor of the page to return, from the previous page"},
+		}},
+		{method: http.MethodGet, path: "/api/v1.0/director/stat/*path", summary: "Get the metadata of an object from an origin serving it", response: server_structs.StatResponse{}},
+		{method: http.MethodPost, path: "/api/v1.0/director/reportFailure", summary: "Report a failed transfer against a server, ranking it lower for the reporting client for a while", requestBody: failureReport{}, response: server_structs.SimpleApiResp{}},
+		{method: http.MethodPost, path: "/api/v1.0/director/refresh", summary: "Ask an origin to advertise itself right away", auth: "admin", response: refreshResponse{}, query: []apiParameter{
+			{name: "serverUrl", description: "The URL of the origin", required: true},
+		}},
+		{method: http.MethodGet, path: "/api/v1.0/director/coverage", summary: "List the registered namespaces without healthy origins", auth: "admin", response: coverageResponse{}},
+		{method: http.MethodPost, path: "/api/v1.0/director/simulate", summary: "Simulate which server each of a batch of client IPs would be redirected to for a namespace", auth: "admin", requestBody: simulateRequest{}, response: simulateResponse{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/config", summary: "Get the director's effective config, with keys, tokens, and passwords redacted", auth: "admin", response: map[string]any{}},
+		{method: http.MethodPost, path: "/api/v1.0/director/config/reload", summary: "Re-read the config files and apply the director settings that don't require a restart", auth: "admin", response: configReloadResp{}},
+		{method: http.MethodGet, path: "/api/v1.0/director/openapi.json", summary: "Get this OpenAPI document"},
+		{method: http.MethodGet, path: "/api/v2.0/director/listNamespaces", summary: "List the namespaces advertised to the director", response: []server_structs.NamespaceAdV2{}},
+		{method: http.MethodGet, path: "/api/v2.0/director/servers", summary: "List the servers known to the director", response: []listServerResponse{}, query: listServersQuery},
+		{method: http.MethodGet, path: "/api/v1.0/director_ui/servers", summary: "List the servers known to the director", response: []listServerResponse{}, query: listServersQuery},
+		{method: http.MethodDelete, path: "/api/v1.0/director_ui/servers", summary: "Evict a server's advertisement", auth: "admin", response: server_structs.SimpleApiResp{}, query: []apiParameter{
+			{name: "serverUrl", description: "The URL of the server", required: true},
+		}},
+		{method: http.MethodPatch, path: "/api/v1.0/director_ui/servers/filter/*name", summary: "Stop redirecting to a server", auth: "admin", response: server_structs.SimpleApiResp{}},
+		{method: http.MethodPatch, path: "/api/v1.0/director_ui/servers/allow/*name", summary: "Resume redirecting to a filtered server", auth: "admin", response: server_structs.SimpleApiResp{}},
+		{method: http.MethodPost, path: "/api/v1.0/director_ui/servers/drain", summary: "Stop redirecting new requests to a server for a while, keeping it listed", auth: "admin", response: server_structs.SimpleApiResp{}, query: []apiParameter{
+			{name: "serverUrl", description: "The URL of the server", required: true},
+			{name: "duration", description: "How long to drain the server for, e.g. 2h", required: true},
+		}},
+		{method: http.MethodDelete, path: "/api/v1.0/director_ui/servers/drain", summary: "Return a draining server to rotation", auth: "admin", response: server_structs.SimpleApiResp{}, query: []apiParameter{
+			{name: "serverUrl", description: "The URL of the server", required: true},
+		}},
+		{method: http.MethodPost, path: "/api/v1.0/director_ui/prefetch", summary: "Ask caches to prefetch objects", auth: "admin", requestBody: server_structs.PrefetchRequest{}, response: prefetchResponse{}},
+		{method: http.MethodPost, path: "/api/v1.0/director_ui/namespaceFilters/reload", summary: "Reload the namespace allow and deny lists", auth: "admin", response: namespaceFilterResp{}},
+		{method: http.MethodGet, path: "/api/v1.0/director_ui/servers/origins/stat/*path", summary: "Find the origins having an object", auth: "user", response: queryResult{}, query: statQuery},
+		{method: http.MethodHead, path: "/api/v1.0/director_ui/servers/origins/stat/*path", summary: "Find the origins having an object", auth: "user", response: queryResult{}, query: statQuery},
+		{method: http.MethodGet, path: "/api/v1.0/director_ui/contact", summary: "Get the support contact of the federation", response: supportContactRes{}},
+	}
+
+	listServersQuery = []apiParameter{
+		{name: "server_type", description: "Only list servers of this type, origin or cache"},
+		{name: "label", description: "Only list servers with the label, given as key=value; may be repeated"},
+		{name: "strict", description: "Fail if any server ad is invalid rather than skipping it", schemaType: "boolean"},
+	}
+
+	statQuery = []apiParameter{
+		{name: "min_responses", description: "The minimum number of origins to wait for", schemaType: "integer"},
+		{name: "max_responses", description: "The maximum number of origins to query", schemaType: "integer"},
+	}
+
+	// Registered routes left out of the OpenAPI document, with the reason why
+	undocumentedRoutes = map[string]string{
+		"/api/v1.0/director/origin": "Only handles WebDAV PROPFIND requests, which OpenAPI can't describe",
+	}
+
+	ginParamRegex = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+	getDirectorOpenAPISpec = sync.OnceValue(buildDirectorOpenAPISpec)
+)
+
+// Convert a gin route to an OpenAPI path template. Wildcards such as *path match several
+// path segments, which OpenAPI path parameters can't express; they are documented as such
+func openAPIPath(ginPath string) (string, []string) {
+	params := []string{}
+	for _, match := range ginParamRegex.FindAllStringSubmatch(ginPath, -1) {
+		params = append(params, match[1])
+	}
+	return ginParamRegex.ReplaceAllString(ginPath, "{$1}"), params
+}
+
+func (gen *openAPISchemaGenerator) schemaFor(t reflect.Type, urlAsString bool) map[string]any {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return map[string]any{}
+	case reflect.TypeOf(url.URL{}):
+		if urlAsString {
+			return map[string]any{"type": "string", "format": "uri"}
+		}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return gen.schemaFor(t.Elem(), urlAsString)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": gen.schemaFor(t.Elem(), urlAsString)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": gen.schemaFor(t.Elem(), urlAsString)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return gen.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + gen.componentName(t)}
+	default:
+		// Interfaces may hold any value
+		return map[string]any{}
+	}
+}
+
+// Get the name of the component holding the schema of a named struct type, generating the
+// schema the first time the type is seen
+func (gen *openAPISchemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := gen.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := gen.schemas[name]; taken {
+		name = strings.ReplaceAll(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:], "_", "") + "." + name
+	}
+	gen.names[t] = name
+	// Reserve the name before recursing, for types referring to themselves
+	gen.schemas[name] = nil
+	gen.schemas[name] = gen.structSchema(t)
+	return name
+}
+
+// Build the object schema of a struct type from its fields' JSON tags
+func (gen *openAPISchemaGenerator) structSchema(t reflect.Type) map[string]any {
+	// The custom marshalers of the director's types render their URLs as strings
+	jsonMarshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	urlAsString := t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler)
+	properties := map[string]any{}
+	gen.addProperties(properties, t, urlAsString)
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+func (gen *openAPISchemaGenerator) addProperties(properties map[string]any, t reflect.Type, urlAsString bool) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		// Like encoding/json, the fields of untagged embedded structs are promoted
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			gen.addProperties(properties, fieldType, urlAsString)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = gen.schemaFor(field.Type, urlAsString)
+	}
+}
+
+// Build the OpenAPI 3 document of the director API from directorAPIOperations
+func buildDirectorOpenAPISpec() map[string]any {
+	gen := &openAPISchemaGenerator{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	errorResponse := map[string]any{
+		"description": "The request failed",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": gen.schemaFor(reflect.TypeOf(server_structs.SimpleApiResp{}), false)},
+		},
+	}
+
+	paths := map[string]any{}
+	for _, op := range directorAPIOperations {
+		pathTemplate, pathParams := openAPIPath(op.path)
+		operation := map[string]any{"summary": op.summary}
+		if deprecation, ok := deprecatedRoutes[op.method+" "+op.path]; ok {
+			operation["deprecated"] = true
+			operation["description"] = "Deprecated in favor of " + deprecation.successor + ", to be removed on " + deprecation.sunset.Format(time.DateOnly)
+		}
+
+		parameters := []any{}
+		for _, param := range pathParams {
+			parameters = append(parameters, map[string]any{
+				"name":        param,
+				"in":          "path",
+				"required":    true,
+				"description": "Matches the rest of the path, including slashes",
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+		for _, param := range op.query {
+			schemaType := param.schemaType
+			if schemaType == "" {
+				schemaType = "string"
+			}
+			parameters = append(parameters, map[string]any{
+				"name":        param.name,
+				"in":          "query",
+				"required":    param.required,
+				"description": param.description,
+				"schema":      map[string]any{"type": schemaType},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.requestBody != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": gen.schemaFor(reflect.TypeOf(op.requestBody), false)},
+				},
+			}
+		}
+
+		responses := map[string]any{"default": errorResponse}
+		switch {
+		case op.redirect:
+			responses["307"] = map[string]any{
+				"description": "Redirect to the selected server",
+				"headers": map[string]any{
+					"Location": map[string]any{"description": "The URL of the selected server", "schema": map[string]any{"type": "string", "format": "uri"}},
+					"Link":     map[string]any{"description": "The ranked servers for the object, as RFC 8288 links", "schema": map[string]any{"type": "string"}},
+				},
+			}
+		case op.contentType != "":
+			responses["200"] = map[string]any{
+				"description": "Success",
+				"content":     map[string]any{op.contentType: map[string]any{"schema": map[string]any{"type": "string"}}},
+			}
+		case op.response != nil:
+			responses["200"] = map[string]any{
+				"description": "Success",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": gen.schemaFor(reflect.TypeOf(op.response), false)},
+				},
+			}
+		default:
+			responses["200"] = map[string]any{"description": "Success"}
+		}
+		operation["responses"] = responses
+
+		switch op.auth {
+		case "admin", "token":
+			operation["security"] = []any{map[string]any{"bearerAuth": []any{}}, map[string]any{"cookieAuth": []any{}}}
+		case "user":
+			operation["security"] = []any{map[string]any{"cookieAuth": []any{}}}
+		}
+
+		pathItem, ok := paths[pathTemplate].(map[string]any)
+		if !ok {
+			pathItem = map[string]any{}
+			paths[pathTemplate] = pathItem
+		}
+		pathItem[strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Pelican Director API",
+			"description": "The API of the Pelican director, generated from the director's routes and Go types",
+			"version":     config.GetVersion(),
+			"license":     map[string]any{"name": "Apache 2.0", "url": "http://www.apache.org/licenses/LICENSE-2.0"},
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": gen.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": "login"},
+			},
+		},
+	}
+}
+
+// A gin route handler serving the OpenAPI document of the director API
+func getOpenAPISpec(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, getDirectorOpenAPISpec())
+}
@@ -0,0 +1,161 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Collect the values of every "$ref" key in a decoded JSON document
+func collectRefs(node any, refs *[]string) {
+	switch val := node.(type) {
+	case map[string]any:
+		for key, child := range val {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(child, refs)
+		}
+	case []any:
+		for _, child := range val {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	router := gin.New()
+	RegisterDirectorAPI(context.Background(), router.Group(""))
+	RegisterDirectorWebAPI(router.Group(""))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/openapi.json", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	spec := map[string]any{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	t.Run("validates", func(t *testing.T) {
+		loader := openapi3.NewLoader()
+		doc, err := loader.LoadFromData(w.Body.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, doc.Validate(loader.Context))
+
+		assert.Regexp(t, `^3\.\d+\.\d+$`, spec["openapi"])
+		info, ok := spec["info"].(map[string]any)
+		require.True(t, ok)
+		assert.NotEmpty(t, info["title"])
+		assert.NotEmpty(t, info["version"])
+
+		components, ok := spec["components"].(map[string]any)
+		require.True(t, ok)
+		schemas, ok := components["schemas"].(map[string]any)
+		require.True(t, ok)
+		securitySchemes, ok := components["securitySchemes"].(map[string]any)
+		require.True(t, ok)
+
+		refs := []string{}
+		collectRefs(spec, &refs)
+		assert.NotEmpty(t, refs)
+		for _, ref := range refs {
+			name, found := strings.CutPrefix(ref, "#/components/schemas/")
+			if assert.True(t, found, "unexpected reference %s", ref) {
+				assert.Contains(t, schemas, name, "dangling reference %s", ref)
+			}
+		}
+
+		templateParam := regexp.MustCompile(`{([^}]+)}`)
+		paths, ok := spec["paths"].(map[string]any)
+		require.True(t, ok)
+		for path, item := range paths {
+			assert.True(t, strings.HasPrefix(path, "/"), path)
+			assert.NotContains(t, path, "*")
+			assert.NotContains(t, path, ":")
+			for method, opVal := range item.(map[string]any) {
+				op := opVal.(map[string]any)
+				assert.Contains(t, []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}, method)
+				assert.NotEmpty(t, op["responses"], "%s %s has no responses", method, path)
+
+				declared := map[string]bool{}
+				params, _ := op["parameters"].([]any)
+				for _, paramVal := range params {
+					param := paramVal.(map[string]any)
+					assert.Contains(t, []string{"path", "query", "header", "cookie"}, param["in"])
+					if param["in"] == "path" {
+						assert.Equal(t, true, param["required"])
+						declared[param["name"].(string)] = true
+					}
+				}
+				for _, match := range templateParam.FindAllStringSubmatch(path, -1) {
+					assert.True(t, declared[match[1]], "%s %s doesn't declare path parameter %s", method, path, match[1])
+				}
+				assert.Len(t, declared, len(templateParam.FindAllString(path, -1)))
+
+				security, _ := op["security"].([]any)
+				for _, reqVal := range security {
+					for scheme := range reqVal.(map[string]any) {
+						assert.Contains(t, securitySchemes, scheme)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("documents-all-routes", func(t *testing.T) {
+		paths := spec["paths"].(map[string]any)
+		registered := map[string]bool{}
+		for _, route := range router.Routes() {
+			if _, ok := undocumentedRoutes[route.Path]; ok {
+				continue
+			}
+			registered[route.Method+" "+route.Path] = true
+			pathTemplate, _ := openAPIPath(route.Path)
+			item, ok := paths[pathTemplate].(map[string]any)
+			if assert.True(t, ok, "route %s %s isn't documented", route.Method, route.Path) {
+				assert.Contains(t, item, strings.ToLower(route.Method), "route %s %s isn't documented", route.Method, route.Path)
+			}
+		}
+		for _, op := range directorAPIOperations {
+			assert.True(t, registered[op.method+" "+op.path], "documented route %s %s isn't registered", op.method, op.path)
+		}
+	})
+
+	t.Run("describes-types", func(t *testing.T) {
+		schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+		listServer, ok := schemas["listServerResponse"].(map[string]any)
+		require.True(t, ok)
+		props := listServer["properties"].(map[string]any)
+		assert.Contains(t, props, "name")
+		assert.Contains(t, props, "labels")
+
+		listServers := spec["paths"].(map[string]any)["/api/v1.0/director_ui/servers"].(map[string]any)["get"].(map[string]any)
+		assert.Equal(t, true, listServers["deprecated"])
+	})
+}
@@ -31,11 +31,13 @@ import (
 
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
@@ -43,16 +45,35 @@ import (
 	"github.com/pelicanplatform/pelican/utils"
 )
 
+type (
+	// A cached namespace JWKS along with the validators the registry sent with it
+	// so that the key set can be revalidated with a conditional request once it's stale
+	namespaceKeysEntry struct {
+		Keys jwk.Set
+		ETag string
+		// The time after which the keys need to be revalidated with the registry,
+		// derived from the Cache-Control max-age. A zero value means the keys stay
+		// fresh until the entry is evicted from the TTL cache
+		FreshUntil time.Time
+	}
+)
+
 var (
 	// namespaceKeys caches jwks from various origins.
 	// The cache key is jwks endpoint (e.g. https://example.com/path/to/issuer.jwks).
 	// TTL cache is thread-safe
-	namespaceKeys = ttlcache.New(ttlcache.WithTTL[string, jwk.Set](15 * time.Minute))
+	namespaceKeys = ttlcache.New(ttlcache.WithTTL[string, *namespaceKeysEntry](15 * time.Minute))
+
+	// The namespace approval statuses last reported by the registry, to verify advertisements
+	// with while the registry is unavailable
+	namespaceApprovals = ttlcache.New(ttlcache.WithTTL[string, bool](15 * time.Minute))
 
 	adminApprovalErr error
+
+	errUnsignedAdvertisement = errors.New("the advertisement is not signed")
 )
 
-func checkNamespaceStatus(prefix string, registryWebUrlStr string) (bool, error) {
+func checkNamespaceStatus(ctx context.Context, prefix string, registryWebUrlStr string) (bool, error) {
 	registryUrl, err := url.Parse(registryWebUrlStr)
 	if err != nil {
 		return false, err
@@ -65,16 +86,17 @@ func checkNamespaceStatus(prefix string, registryWebUrlStr string) (bool, error)
 		return false, err
 	}
 	client := http.Client{Transport: config.GetTransport()}
-	req, err := http.NewRequest(http.MethodPost, reqUrl.String(), bytes.NewBuffer(reqByte))
-	req.Header.Add("Content-Type", "application/json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqUrl.String(), bytes.NewBuffer(reqByte))
 	if err != nil {
 		return false, err
 	}
+	req.Header.Add("Content-Type", "application/json")
 
 	res, err := client.Do(req)
 	if err != nil {
 		return false, err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		if res.StatusCode == 404 {
@@ -84,9 +106,9 @@ func checkNamespaceStatus(prefix string, registryWebUrlStr string) (bool, error)
 		} else {
 			body, err := io.ReadAll(res.Body)
 			if err != nil {
-				return false, errors.New(fmt.Sprintf("Registry returns error when checkNamespaceStatus %d and can't get the response body %v", res.StatusCode, err))
+				return false, &utils.StatusCodeError{StatusCode: res.StatusCode, Msg: fmt.Sprintf("Registry returns error when checkNamespaceStatus %d and can't get the response body %v", res.StatusCode, err)}
 			} else {
-				return false, errors.New(fmt.Sprintf("Registry returns error when checkNamespaceStatus %d with body %s", res.StatusCode, string(body)))
+				return false, &utils.StatusCodeError{StatusCode: res.StatusCode, Msg: fmt.Sprintf("Registry returns error when checkNamespaceStatus %d with body %s", res.StatusCode, string(body))}
 			}
 		}
 	}
@@ -104,6 +126,106 @@ func checkNamespaceStatus(prefix string, registryWebUrlStr string) (bool, error)
 	return resBody.Approved, nil
 }
 
+// Check the approval status of a namespace at the registry through registryBreaker. While the
+// breaker is open, fall back to the status the registry last reported for the namespace
+func getNamespaceApproval(ctx context.Context, prefix string, registryWebUrlStr string) (bool, error) {
+	var approved bool
+	err := registryBreaker.call(func() (err error) {
+		approved, err = checkNamespaceStatus(ctx, prefix, registryWebUrlStr)
+		return
+	})
+	if err != nil {
+		if item := namespaceApprovals.Get(prefix); item != nil && errors.Is(err, errRegistryUnavailable) {
+			log.Debugf("The registry is unavailable; using the last known approval status of %s", prefix)
+			return item.Value(), nil
+		}
+		return false, err
+	}
+	namespaceApprovals.Set(prefix, approved, ttlcache.DefaultTTL)
+	return approved, nil
+}
+
+func (entry *namespaceKeysEntry) isFresh() bool {
+	return entry.FreshUntil.IsZero() || time.Now().Before(entry.FreshUntil)
+}
+
+// Get the JWKS at keyLoc. A fresh cached copy is returned as-is; a stale one is
+// revalidated with the registry using its ETag so that an unchanged key set costs
+// a 304 response instead of a full download
+func getNamespaceKeys(ctx context.Context, keyLoc string) (jwk.Set, error) {
+	var cached *namespaceKeysEntry
+	if item := namespaceKeys.Get(keyLoc); item != nil && !item.IsExpired() {
+		cached = item.Value()
+	}
+
+	if cached != nil && cached.isFresh() {
+		metrics.PelicanDirectorJwksCacheRequestsTotal.WithLabelValues(string(metrics.JwksCacheHit)).Inc()
+		return cached.Keys, nil
+	}
+
+	etag := ""
+	if cached != nil {
+		etag = cached.ETag
+	}
+	var res utils.JwksFetchResult
+	err := registryBreaker.call(func() (err error) {
+		res, err = utils.GetJwksConditional(ctx, keyLoc, etag)
+		return
+	})
+	if err != nil {
+		// Stale keys beat no keys while the registry is unavailable
+		if cached != nil && errors.Is(err, errRegistryUnavailable) {
+			log.Debugf("The registry is unavailable; using the stale cached keys at %s", keyLoc)
+			return cached.Keys, nil
+		}
+		return nil, err
+	}
+
+	entry := &namespaceKeysEntry{ETag: res.ETag}
+	if res.NotModified {
+		metrics.PelicanDirectorJwksCacheRequestsTotal.WithLabelValues(string(metrics.JwksCacheRevalidated)).Inc()
+		entry.Keys = cached.Keys
+		if entry.ETag == "" {
+			entry.ETag = cached.ETag
+		}
+	} else {
+		metrics.PelicanDirectorJwksCacheRequestsTotal.WithLabelValues(string(metrics.JwksCacheMiss)).Inc()
+		entry.Keys = res.Keys
+	}
+	if res.HasMaxAge {
+		entry.FreshUntil = time.Now().Add(res.MaxAge)
+	}
+
+	customTTL := reloadableDuration(param.Director_AdvertisementTTL)
+	if customTTL == 0 {
+		namespaceKeys.Set(keyLoc, entry, ttlcache.DefaultTTL)
+	} else {
+		namespaceKeys.Set(keyLoc, entry, customTTL)
+	}
+	return entry.Keys, nil
+}
+
+// Check that the kid in the token's header names a key of the keyset, so that a token
+// signed by an unknown key (e.g. one that was retired and pruned from the namespace's JWKS)
+// is rejected up front instead of being checked against every key of the namespace
+func checkTokenKeyID(token string, keyset jwk.Set) error {
+	msg, err := jws.Parse([]byte(token))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the token")
+	}
+	if len(msg.Signatures()) == 0 {
+		return errors.New("the token is not signed")
+	}
+	kid := msg.Signatures()[0].ProtectedHeaders().KeyID()
+	if kid == "" {
+		return errors.New("the token has no key ID (kid) in its header")
+	}
+	if _, found := keyset.LookupKeyID(kid); !found {
+		return errors.Errorf("the token is signed by the key %q, which is not in the namespace's JWKS", kid)
+	}
+	return nil
+}
+
 // Given a token and a location in the namespace to advertise in,
 // see if the entity is authorized to advertise an origin for the
 // namespace
@@ -124,7 +246,7 @@ func verifyAdvertiseToken(ctx context.Context, token, namespace string) (bool, e
 	}
 	regUrlStr := fedInfo.NamespaceRegistrationEndpoint
 
-	approved, err := checkNamespaceStatus(namespace, regUrlStr)
+	approved, err := getNamespaceApproval(ctx, namespace, regUrlStr)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to check namespace approval status")
 	}
@@ -134,28 +256,14 @@ func verifyAdvertiseToken(ctx context.Context, token, namespace string) (bool, e
 	}
 
 	log.Debugln("Attempting to fetch keys from ", keyLoc)
-	var keyset jwk.Set
-
-	item := namespaceKeys.Get(keyLoc)
-	if item != nil {
-		if !item.IsExpired() {
-			keyset = item.Value()
-		}
+	keyset, err := getNamespaceKeys(ctx, keyLoc)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get jwks at %s", keyLoc)
 	}
 
-	if keyset == nil {
-		keyset, err = utils.GetJwks(ctx, keyLoc)
-		if err != nil {
-			return false, errors.Wrapf(err, "failed to get jwks at %s", keyLoc)
-		}
-		customTTL := param.Director_AdvertisementTTL.GetDuration()
-		if customTTL == 0 {
-			namespaceKeys.Set(keyLoc, keyset, ttlcache.DefaultTTL)
-		} else {
-			namespaceKeys.Set(keyLoc, keyset, customTTL)
-		}
+	if err := checkTokenKeyID(token, keyset); err != nil {
+		return false, err
 	}
-
 	tok, err := jwt.Parse([]byte(token), jwt.WithKeySet(keyset), jwt.WithValidate(true))
 	if err != nil {
 		return false, err
@@ -179,3 +287,21 @@ func verifyAdvertiseToken(ctx context.Context, token, namespace string) (bool, e
 	}
 	return false, nil
 }
+
+// Check the advertisement body against the digest claim of its advertise token, so that the
+// advertisement is known to be signed by the key the token was verified with. Returns
+// errUnsignedAdvertisement if the token has no digest claim. The token must already be verified
+func verifyAdvertisementDigest(token string, body []byte) error {
+	tok, err := jwt.Parse([]byte(token), jwt.WithVerify(false), jwt.WithValidate(false))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the advertise token")
+	}
+	claim, present := tok.Get(server_structs.AdvertisementDigestClaim)
+	if !present {
+		return errUnsignedAdvertisement
+	}
+	if digest, ok := claim.(string); !ok || digest != server_structs.AdvertisementDigest(body) {
+		return errors.New("the advertisement doesn't match the signature in its advertise token")
+	}
+	return nil
+}
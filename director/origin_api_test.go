@@ -20,6 +20,9 @@ package director
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -28,7 +31,9 @@ import (
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,7 +92,7 @@ func TestVerifyAdvertiseToken(t *testing.T) {
 
 	kSet, err := config.GetIssuerPublicJWKS()
 	require.NoError(t, err)
-	namespaceKeys.Set(ts.URL+"/api/v1.0/registry/test-namespace/.well-known/issuer.jwks", kSet, ttlcache.DefaultTTL)
+	namespaceKeys.Set(ts.URL+"/api/v1.0/registry/test-namespace/.well-known/issuer.jwks", &namespaceKeysEntry{Keys: kSet}, ttlcache.DefaultTTL)
 
 	issuerUrl, err := server_utils.GetNSIssuerURL("/test-namespace")
 	assert.NoError(t, err)
@@ -135,6 +140,23 @@ func TestVerifyAdvertiseToken(t *testing.T) {
 	ok, err = verifyAdvertiseToken(ctx, tok, "/test-namespace")
 	assert.Equal(t, false, ok, "Should fail due to incorrect scope name")
 	assert.NoError(t, err, "Incorrect scope name should not throw and error")
+
+	// A token signed by a key the namespace's JWKS doesn't have fails on its kid
+	unknownKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	unknownJwk, err := jwk.FromRaw(unknownKey)
+	require.NoError(t, err)
+	require.NoError(t, unknownJwk.Set(jwk.KeyIDKey, "unknown-kid"))
+	unknownTok, err := jwt.NewBuilder().Issuer(issuerUrl).Subject("origin").Claim("scope", token_scopes.Pelican_Advertise.String()).
+		Expiration(time.Now().Add(time.Minute)).Build()
+	require.NoError(t, err)
+	signed, err := jwt.Sign(unknownTok, jwt.WithKey(jwa.ES256, unknownJwk))
+	require.NoError(t, err)
+
+	ok, err = verifyAdvertiseToken(ctx, string(signed), "/test-namespace")
+	assert.False(t, ok)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"unknown-kid"`)
 }
 
 func TestNamespaceKeysCacheEviction(t *testing.T) {
@@ -157,7 +179,7 @@ func TestNamespaceKeysCacheEviction(t *testing.T) {
 		go func() {
 			namespaceKeys.DeleteAll()
 
-			namespaceKeys.Set(mockNamespaceKey, jwk.NewSet(), time.Second*2)
+			namespaceKeys.Set(mockNamespaceKey, &namespaceKeysEntry{Keys: jwk.NewSet()}, time.Second*2)
 			require.True(t, namespaceKeys.Has(mockNamespaceKey), "Failed to register namespace key")
 		}()
 
@@ -186,3 +208,59 @@ func TestNamespaceKeysCacheEviction(t *testing.T) {
 		}
 	})
 }
+
+func TestGetNamespaceKeysRevalidation(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(func() {
+		namespaceKeys.DeleteAll()
+		viper.Reset()
+	})
+
+	key, err := jwk.FromRaw([]byte("not-really-a-key"))
+	require.NoError(t, err)
+	require.NoError(t, key.Set(jwk.KeyIDKey, "key-1"))
+	keySet := jwk.NewSet()
+	require.NoError(t, keySet.AddKey(key))
+	keySetBytes, err := json.Marshal(keySet)
+	require.NoError(t, err)
+
+	etag := `"v1"`
+	fullResponses := 0
+	notModifiedResponses := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", etag)
+		// Always stale so that every lookup goes back to the server
+		w.Header().Set("Cache-Control", "max-age=0")
+		if req.Header.Get("If-None-Match") == etag {
+			notModifiedResponses++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		_, err := w.Write(keySetBytes)
+		require.NoError(t, err)
+	}))
+	defer ts.Close()
+	keyLoc := ts.URL + "/api/v1.0/registry/foo/.well-known/issuer.jwks"
+
+	keys, err := getNamespaceKeys(context.Background(), keyLoc)
+	require.NoError(t, err)
+	_, found := keys.LookupKeyID("key-1")
+	assert.True(t, found)
+	assert.Equal(t, 1, fullResponses)
+
+	// The stale entry is revalidated with its ETag and the cached keys are reused
+	keys, err = getNamespaceKeys(context.Background(), keyLoc)
+	require.NoError(t, err)
+	_, found = keys.LookupKeyID("key-1")
+	assert.True(t, found)
+	assert.Equal(t, 1, fullResponses)
+	assert.Equal(t, 1, notModifiedResponses)
+
+	// A fresh entry is served from the cache without contacting the server
+	namespaceKeys.Set(keyLoc, &namespaceKeysEntry{Keys: keys, ETag: etag, FreshUntil: time.Now().Add(time.Minute)}, ttlcache.DefaultTTL)
+	_, err = getNamespaceKeys(context.Background(), keyLoc)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fullResponses)
+	assert.Equal(t, 1, notModifiedResponses)
+}
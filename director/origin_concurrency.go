@@ -0,0 +1,138 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// The recent redirects to an origin, oldest first
+	originRedirects struct {
+		name  string
+		times []time.Time
+	}
+
+	// Track the redirects in flight to each origin, to enforce the MaxConcurrency origins advertise.
+	// The director can't tell when a transfer ends, so a redirect counts as in flight for
+	// Director.OriginInFlightWindow after it's sent
+	inFlightTracker struct {
+		mutex sync.Mutex
+		// The key is ServerAd.URL.String()
+		redirects map[string]*originRedirects
+		now       func() time.Time
+	}
+)
+
+var originInFlight = newInFlightTracker()
+
+func newInFlightTracker() *inFlightTracker {
+	return &inFlightTracker{redirects: map[string]*originRedirects{}, now: time.Now}
+}
+
+// Drop the redirects of the origin that are out of the window and return the number left.
+// The caller must hold the mutex
+func (t *inFlightTracker) pruneLocked(serverUrl string) int {
+	entry, ok := t.redirects[serverUrl]
+	if !ok {
+		return 0
+	}
+	cutoff := t.now().Add(-reloadableDuration(param.Director_OriginInFlightWindow))
+	idx := 0
+	for idx < len(entry.times) && !entry.times[idx].After(cutoff) {
+		idx++
+	}
+	entry.times = entry.times[idx:]
+	if len(entry.times) == 0 {
+		delete(t.redirects, serverUrl)
+		metrics.PelicanDirectorOriginInFlightRedirects.DeleteLabelValues(entry.name, serverUrl)
+		return 0
+	}
+	metrics.PelicanDirectorOriginInFlightRedirects.WithLabelValues(entry.name, serverUrl).Set(float64(len(entry.times)))
+	return len(entry.times)
+}
+
+// Get the number of redirects in flight to the server
+func (t *inFlightTracker) count(serverUrl string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.pruneLocked(serverUrl)
+}
+
+// Count a redirect to the server as in flight. Only origins are tracked
+func (t *inFlightTracker) record(ad server_structs.ServerAd) {
+	if ad.Type != server_structs.OriginType || reloadableDuration(param.Director_OriginInFlightWindow) <= 0 {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	serverUrl := ad.URL.String()
+	t.pruneLocked(serverUrl)
+	entry, ok := t.redirects[serverUrl]
+	if !ok {
+		entry = &originRedirects{name: ad.Name}
+		t.redirects[serverUrl] = entry
+	}
+	entry.times = append(entry.times, t.now())
+	metrics.PelicanDirectorOriginInFlightRedirects.WithLabelValues(entry.name, serverUrl).Set(float64(len(entry.times)))
+}
+
+// Split the ranked servers into the ones that can take another redirect and the ones at their
+// MaxConcurrency, keeping the order of both
+func (t *inFlightTracker) filterAtCapacity(ads []server_structs.ServerAd) (available []server_structs.ServerAd, full []server_structs.ServerAd) {
+	available = make([]server_structs.ServerAd, 0, len(ads))
+	for _, ad := range ads {
+		if ad.MaxConcurrency > 0 && t.count(ad.URL.String()) >= ad.MaxConcurrency {
+			full = append(full, ad)
+		} else {
+			available = append(available, ad)
+		}
+	}
+	return
+}
+
+// Update the in-flight metric of every origin, so that it drops back down when the redirects stop
+func (t *inFlightTracker) refreshMetrics() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for serverUrl := range t.redirects {
+		t.pruneLocked(serverUrl)
+	}
+}
+
+// Respond that all the servers the request could be redirected to are at their concurrency limit
+func respondServersAtCapacity(ginCtx *gin.Context, full []server_structs.ServerAd) {
+	retryAfter := max(int(math.Ceil(reloadableDuration(param.Director_OriginInFlightWindow).Seconds())), 1)
+	ginCtx.Header("Retry-After", strconv.Itoa(retryAfter))
+	ginCtx.JSON(http.StatusServiceUnavailable, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    fmt.Sprintf("All %d origins serving the object are at their concurrency limit. Retry later", len(full)),
+	})
+}
@@ -0,0 +1,144 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("Director.OriginInFlightWindow", time.Minute)
+
+	now := time.Now()
+	tracker := newInFlightTracker()
+	tracker.now = func() time.Time { return now }
+
+	origin := mockOriginServerAd
+	origin.Name = "tracked-origin"
+	origin.URL = url.URL{Scheme: "https", Host: "tracked-origin.com"}
+	gauge := metrics.PelicanDirectorOriginInFlightRedirects.WithLabelValues(origin.Name, origin.URL.String())
+
+	tracker.record(origin)
+	now = now.Add(30 * time.Second)
+	tracker.record(origin)
+	assert.Equal(t, 2, tracker.count(origin.URL.String()))
+	assert.Equal(t, 2.0, testutil.ToFloat64(gauge))
+
+	// Redirects leave the window one by one
+	now = now.Add(31 * time.Second)
+	assert.Equal(t, 1, tracker.count(origin.URL.String()))
+	now = now.Add(30 * time.Second)
+	tracker.refreshMetrics()
+	assert.Equal(t, 0, tracker.count(origin.URL.String()))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.PelicanDirectorOriginInFlightRedirects.WithLabelValues(origin.Name, origin.URL.String())))
+
+	// Caches aren't tracked
+	tracker.record(mockCacheServerAd)
+	assert.Equal(t, 0, tracker.count(mockCacheServerAd.URL.String()))
+
+	// Nothing is tracked without a window
+	viper.Set("Director.OriginInFlightWindow", 0)
+	tracker.record(origin)
+	assert.Equal(t, 0, tracker.count(origin.URL.String()))
+}
+
+func TestOriginConcurrencyLimit(t *testing.T) {
+	serverAds.DeleteAll()
+	oldTracker := originInFlight
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		originInFlight = oldTracker
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+	viper.Set("Director.OriginInFlightWindow", time.Minute)
+
+	now := time.Now()
+	originInFlight = newInFlightTracker()
+	originInFlight.now = func() time.Time { return now }
+
+	setOrigin := func(name string, maxConcurrency int) server_structs.ServerAd {
+		ad := mockOriginServerAd
+		ad.Name = name
+		ad.URL = url.URL{Scheme: "https", Host: name + ".com"}
+		ad.Caps = server_structs.Capabilities{Reads: true}
+		ad.MaxConcurrency = maxConcurrency
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: ad.Caps}},
+		}, ttlcache.DefaultTTL)
+		return ad
+	}
+	small := setOrigin("small-origin", 1)
+	big := setOrigin("big-origin", 0)
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/origin/*any", redirectToOrigin)
+	doRequest := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/origin/foo/bar?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("origin-at-capacity-is-skipped", func(t *testing.T) {
+		originInFlight.record(small)
+		for i := 0; i < 10; i++ {
+			w := doRequest()
+			require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Location"), big.URL.Host)
+			assert.NotContains(t, w.Header().Get("Link"), small.URL.Host)
+		}
+		assert.Equal(t, 10, originInFlight.count(big.URL.String()))
+	})
+
+	t.Run("only-origin-at-capacity", func(t *testing.T) {
+		serverAds.Delete(big.URL.String())
+		w := doRequest()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "concurrency limit")
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		// The origin takes redirects again once the earlier ones leave the window
+		now = now.Add(2 * time.Minute)
+		w = doRequest()
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Location"), small.URL.Host)
+		assert.Equal(t, http.StatusServiceUnavailable, doRequest().Code)
+	})
+}
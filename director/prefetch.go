@@ -0,0 +1,228 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+	"github.com/pelicanplatform/pelican/web_ui"
+)
+
+type (
+	// The result of forwarding a prefetch request to a cache
+	cachePrefetchResult struct {
+		Name    string   `json:"name"`
+		WebURL  string   `json:"webUrl"`
+		Objects []string `json:"objects,omitempty"`
+		Prefix  string   `json:"prefix,omitempty"`
+		JobID   string   `json:"jobId,omitempty"`
+		Error   string   `json:"error,omitempty"`
+	}
+
+	prefetchResponse struct {
+		Caches []cachePrefetchResult `json:"caches"`
+	}
+)
+
+const (
+	// The number of caches the director forwards a prefetch request to concurrently
+	prefetchFanOutConcurrency = 10
+	prefetchRequestTimeout    = 30 * time.Second
+)
+
+// Forward the prefetch request to the cache; a variable to be mocked in tests
+var sendPrefetchRequest = postPrefetchRequest
+
+// Send the prefetch request to the cache's web API, authorized by a director-issued token
+func postPrefetchRequest(ctx context.Context, cacheAd server_structs.ServerAd, req server_structs.PrefetchRequest) (server_structs.PrefetchJobStatus, error) {
+	jobStatus := server_structs.PrefetchJobStatus{}
+
+	tokCfg := token.NewWLCGToken()
+	tokCfg.Lifetime = time.Minute
+	tokCfg.Issuer = param.Server_ExternalWebUrl.GetString()
+	tokCfg.AddAudiences(cacheAd.WebURL.String())
+	tokCfg.Subject = "director"
+	tokCfg.AddScopes(token_scopes.Cache_Prefetch)
+	tok, err := tokCfg.CreateToken()
+	if err != nil {
+		return jobStatus, errors.Wrap(err, "failed to create the prefetch token")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return jobStatus, errors.Wrap(err, "failed to marshal the prefetch request")
+	}
+	prefetchUrl := cacheAd.WebURL
+	prefetchUrl.Path = "/api/v1.0/cache/prefetch"
+
+	ctx, cancel := context.WithTimeout(ctx, prefetchRequestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, prefetchUrl.String(), bytes.NewReader(body))
+	if err != nil {
+		return jobStatus, errors.Wrap(err, "failed to create the prefetch request")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+tok)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return jobStatus, errors.Wrap(err, "failed to send the prefetch request")
+	}
+	defer resp.Body.Close()
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return jobStatus, errors.Wrap(err, "failed to read the prefetch response")
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return jobStatus, errors.Errorf("the cache responded with status code %d: %s", resp.StatusCode, string(resBody))
+	}
+	if err := json.Unmarshal(resBody, &jobStatus); err != nil {
+		return jobStatus, errors.Wrap(err, "failed to parse the prefetch response")
+	}
+	return jobStatus, nil
+}
+
+// Split the prefetch request into one per cache serving the requested namespace prefix or objects.
+// Filtered caches are excluded, as are the objects whose namespaces are not found
+func planPrefetch(req server_structs.PrefetchRequest) (plans map[string]*cachePrefetchResult, cacheAds map[string]server_structs.ServerAd, err error) {
+	plans = map[string]*cachePrefetchResult{}
+	cacheAds = map[string]server_structs.ServerAd{}
+	addCaches := func(ads []server_structs.ServerAd, object string) {
+		for _, ad := range ads {
+			key := ad.URL.String()
+			plan, ok := plans[key]
+			if !ok {
+				plan = &cachePrefetchResult{Name: ad.Name, WebURL: ad.WebURL.String()}
+				plans[key] = plan
+				cacheAds[key] = ad
+			}
+			if object != "" {
+				plan.Objects = append(plan.Objects, object)
+			} else {
+				plan.Prefix = req.Prefix
+			}
+		}
+	}
+
+	if req.Prefix != "" {
+		req.Prefix = path.Clean("/" + req.Prefix)
+		namespaceAd, _, ads := getAdsForPath(resolveNamespaceAlias(req.Prefix))
+		if namespaceAd.Path == "" {
+			return nil, nil, errors.Errorf("no namespace found for the prefix %s", req.Prefix)
+		}
+		if !namespaceAd.Caps.Listings {
+			return nil, nil, errors.Errorf("the namespace %s doesn't allow listings; list the objects to prefetch instead", namespaceAd.Path)
+		}
+		addCaches(ads, "")
+		return
+	}
+
+	for _, obj := range req.Objects {
+		objectPath := resolveNamespaceAlias(path.Clean("/" + obj))
+		namespaceAd, _, ads := getAdsForPath(objectPath)
+		if namespaceAd.Path == "" {
+			return nil, nil, errors.Errorf("no namespace found for the object %s", objectPath)
+		}
+		addCaches(ads, objectPath)
+	}
+	return
+}
+
+// A gin route handler that forwards a prefetch request to all caches serving the requested namespace
+// prefix or objects, and reports the prefetch job started at each cache
+func handlePrefetch(ctx *gin.Context) {
+	req := server_structs.PrefetchRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid prefetch request: " + err.Error(),
+		})
+		return
+	}
+	if (req.Prefix == "") == (len(req.Objects) == 0) {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Exactly one of 'prefix' and 'objects' is required",
+		})
+		return
+	}
+
+	plans, cacheAds, err := planPrefetch(req)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	res := prefetchResponse{Caches: []cachePrefetchResult{}}
+	resMutex := sync.Mutex{}
+	egrp := errgroup.Group{}
+	egrp.SetLimit(prefetchFanOutConcurrency)
+	for key, plan := range plans {
+		key, plan := key, plan
+		egrp.Go(func() error {
+			cacheAd := cacheAds[key]
+			if cacheAd.WebURL.String() == "" {
+				plan.Error = "the cache doesn't advertise a web URL to receive prefetch requests"
+			} else {
+				jobStatus, err := sendPrefetchRequest(ctx.Request.Context(), cacheAd, server_structs.PrefetchRequest{
+					Prefix:  plan.Prefix,
+					Objects: plan.Objects,
+					Token:   req.Token,
+				})
+				if err != nil {
+					plan.Error = err.Error()
+				} else {
+					plan.JobID = jobStatus.ID
+				}
+			}
+			if plan.Error != "" {
+				web_ui.RequestLogger(ctx).Warningf("Failed to forward the prefetch request to cache %s: %s", plan.Name, plan.Error)
+			}
+			resMutex.Lock()
+			defer resMutex.Unlock()
+			res.Caches = append(res.Caches, *plan)
+			return nil
+		})
+	}
+	_ = egrp.Wait()
+	sort.Slice(res.Caches, func(i, j int) bool { return res.Caches[i].Name < res.Caches[j].Name })
+	web_ui.RequestLogger(ctx).Infof("Prefetch request by user %s is forwarded to %d caches", ctx.GetString("User"), len(res.Caches))
+	ctx.JSON(http.StatusOK, res)
+}
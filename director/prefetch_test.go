@@ -0,0 +1,128 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestHandlePrefetch(t *testing.T) {
+	serverAds.DeleteAll()
+	filteredServersMutex.Lock()
+	tmp := filteredServers
+	filteredServers = map[string]filterType{}
+	filteredServersMutex.Unlock()
+	oldSend := sendPrefetchRequest
+	t.Cleanup(func() {
+		sendPrefetchRequest = oldSend
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		filteredServers = tmp
+		filteredServersMutex.Unlock()
+	})
+
+	ns := server_structs.NamespaceAdV2{Path: "/foo", Caps: server_structs.Capabilities{Listings: true}}
+	cacheAd := mockCacheServerAd
+	cacheAd.WebURL = url.URL{Scheme: "https", Host: "cache.com:8444"}
+	filteredCacheAd := cacheAd
+	filteredCacheAd.Name = "filtered-cache-server"
+	filteredCacheAd.URL = url.URL{Scheme: "https", Host: "cache2.com"}
+	noWebCacheAd := mockCacheServerAd
+	noWebCacheAd.Name = "topology-cache-server"
+	noWebCacheAd.URL = url.URL{Scheme: "https", Host: "cache3.com"}
+	for _, ad := range []server_structs.ServerAd{mockOriginServerAd, cacheAd, filteredCacheAd, noWebCacheAd} {
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{ns},
+		}, ttlcache.DefaultTTL)
+	}
+	filteredServersMutex.Lock()
+	filteredServers[filteredCacheAd.Name] = tempFiltered
+	filteredServersMutex.Unlock()
+
+	sent := map[string]server_structs.PrefetchRequest{}
+	sentMutex := sync.Mutex{}
+	sendPrefetchRequest = func(ctx context.Context, ad server_structs.ServerAd, req server_structs.PrefetchRequest) (server_structs.PrefetchJobStatus, error) {
+		sentMutex.Lock()
+		defer sentMutex.Unlock()
+		sent[ad.Name] = req
+		return server_structs.PrefetchJobStatus{ID: "job-" + ad.Name}, nil
+	}
+
+	router := gin.New()
+	router.POST("/prefetch", handlePrefetch)
+	doRequest := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/prefetch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("prefetch-objects", func(t *testing.T) {
+		sent = map[string]server_structs.PrefetchRequest{}
+		w := doRequest(`{"objects": ["/foo/a", "/foo/b"], "token": "read-token"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		res := prefetchResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Caches, 2)
+		assert.Equal(t, cacheAd.Name, res.Caches[0].Name)
+		assert.Equal(t, "job-"+cacheAd.Name, res.Caches[0].JobID)
+		assert.Equal(t, noWebCacheAd.Name, res.Caches[1].Name)
+		assert.NotEmpty(t, res.Caches[1].Error)
+
+		// The filtered cache is skipped
+		require.Len(t, sent, 1)
+		assert.Equal(t, []string{"/foo/a", "/foo/b"}, sent[cacheAd.Name].Objects)
+		assert.Equal(t, "read-token", sent[cacheAd.Name].Token)
+	})
+
+	t.Run("prefetch-prefix", func(t *testing.T) {
+		sent = map[string]server_structs.PrefetchRequest{}
+		w := doRequest(`{"prefix": "/foo"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/foo", sent[cacheAd.Name].Prefix)
+		assert.Empty(t, sent[cacheAd.Name].Objects)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		w := doRequest(`{"objects": ["/unknown/a"]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("both-prefix-and-objects", func(t *testing.T) {
+		w := doRequest(`{"prefix": "/foo", "objects": ["/foo/a"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
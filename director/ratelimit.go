@@ -0,0 +1,109 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type advertiseLimitType string
+
+const (
+	advertiseLimitByIP         advertiseLimitType = "source_ip"
+	advertiseLimitByServerName advertiseLimitType = "server_name"
+)
+
+var (
+	// Token-bucket rate limiters for the advertisement endpoints, with the key being
+	// "<advertiseLimitType>:<source IP or server name>". Limiters of sources that
+	// stopped advertising are evicted from the cache
+	advertiseLimiters = ttlcache.New(ttlcache.WithTTL[string, *rate.Limiter](15 * time.Minute))
+)
+
+// Check if an advertisement identified by key (a source IP or a server name) is within
+// Director.AdvertiseRateLimit. Returns true if it's allowed; otherwise, returns false along
+// with the duration the sender should wait before advertising again.
+//
+// A Director.AdvertiseRateLimit <= 0 disables the rate limiting
+func allowAdvertisement(limitType advertiseLimitType, key string) (bool, time.Duration) {
+	perMinute := param.Director_AdvertiseRateLimit.GetInt()
+	if perMinute <= 0 {
+		return true, 0
+	}
+	limit := rate.Every(time.Minute / time.Duration(perMinute))
+	cacheKey := string(limitType) + ":" + key
+	item, _ := advertiseLimiters.GetOrSet(cacheKey, rate.NewLimiter(limit, perMinute))
+	limiter := item.Value()
+	// Director.AdvertiseRateLimit may have changed since the limiter was cached
+	if limiter.Limit() != limit || limiter.Burst() != perMinute {
+		limiter = rate.NewLimiter(limit, perMinute)
+		advertiseLimiters.Set(cacheKey, limiter, ttlcache.DefaultTTL)
+	}
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, time.Minute
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		// Give the token back, as the advertisement won't be processed
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+// Abort the advertisement request with 429 Too Many Requests and a Retry-After header
+func abortRateLimitedAdvertisement(ctx *gin.Context, limitType advertiseLimitType, key string, retryAfter time.Duration) {
+	metrics.PelicanDirectorAdvertiseRateLimitedTotal.With(map[string]string{
+		"server_type": ctx.GetString("serverType"),
+		"limit_type":  string(limitType),
+	}).Inc()
+	log.Debugf("Rejected advertisement from %s %q: over the rate limit of %d advertisements per minute", limitType, key, param.Director_AdvertiseRateLimit.GetInt())
+
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    fmt.Sprintf("Too many advertisements from the %s %s. Retry after %s", limitType, key, retryAfter.Round(time.Second)),
+	})
+}
+
+// Gin middleware to rate limit advertisements by their source IP address
+func advertiseRateLimitMiddleware(sType server_structs.ServerType) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("serverType", string(sType))
+		clientIP := ctx.ClientIP()
+		if ok, retryAfter := allowAdvertisement(advertiseLimitByIP, clientIP); !ok {
+			abortRateLimitedAdvertisement(ctx, advertiseLimitByIP, clientIP, retryAfter)
+			return
+		}
+		ctx.Next()
+	}
+}
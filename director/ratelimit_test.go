@@ -0,0 +1,112 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestAdvertiseRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() {
+		viper.Reset()
+		advertiseLimiters.DeleteAll()
+	})
+
+	t.Run("disabled-when-zero", func(t *testing.T) {
+		viper.Reset()
+		advertiseLimiters.DeleteAll()
+		viper.Set("Director.AdvertiseRateLimit", 0)
+		for i := 0; i < 100; i++ {
+			ok, _ := allowAdvertisement(advertiseLimitByServerName, "my-origin")
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("limit-by-server-name", func(t *testing.T) {
+		viper.Reset()
+		advertiseLimiters.DeleteAll()
+		viper.Set("Director.AdvertiseRateLimit", 2)
+
+		ok, _ := allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.True(t, ok)
+		ok, _ = allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.True(t, ok)
+		ok, retryAfter := allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.False(t, ok)
+		assert.Greater(t, retryAfter.Seconds(), 0.0)
+
+		// Other servers and source IPs have their own buckets
+		ok, _ = allowAdvertisement(advertiseLimitByServerName, "other-origin")
+		assert.True(t, ok)
+		ok, _ = allowAdvertisement(advertiseLimitByIP, "my-origin")
+		assert.True(t, ok)
+	})
+
+	t.Run("rate-change-rebuilds-limiters", func(t *testing.T) {
+		viper.Reset()
+		advertiseLimiters.DeleteAll()
+		viper.Set("Director.AdvertiseRateLimit", 1)
+
+		ok, _ := allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.True(t, ok)
+		ok, _ = allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.False(t, ok)
+
+		// The cached limiter picks up the new rate
+		viper.Set("Director.AdvertiseRateLimit", 3)
+		for i := 0; i < 3; i++ {
+			ok, _ = allowAdvertisement(advertiseLimitByServerName, "my-origin")
+			assert.True(t, ok)
+		}
+		ok, _ = allowAdvertisement(advertiseLimitByServerName, "my-origin")
+		assert.False(t, ok)
+	})
+
+	t.Run("middleware-returns-429-with-retry-after", func(t *testing.T) {
+		viper.Reset()
+		advertiseLimiters.DeleteAll()
+		viper.Set("Director.AdvertiseRateLimit", 1)
+
+		router := gin.New()
+		router.POST("/registerOrigin", advertiseRateLimitMiddleware(server_structs.OriginType), func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/registerOrigin", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/registerOrigin", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+}
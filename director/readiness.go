@@ -0,0 +1,88 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Check if the director is ready to serve redirects: the registry circuit breaker isn't open,
+// the GeoIP database is loaded, the registry is reachable, and at least one origin has
+// advertised. Until then, the director would redirect clients to an empty federation
+func CheckReadiness(ctx context.Context) error {
+	if registryBreaker.currentState() == breakerOpen {
+		return errors.New("the circuit breaker around registry calls is open")
+	}
+	if !geoLocatorReady() {
+		return errors.New("GeoIP database is not loaded")
+	}
+	if err := checkRegistryReachable(ctx); err != nil {
+		return err
+	}
+	if !hasOriginAd() {
+		return errors.New("no origin has advertised to the director")
+	}
+	return nil
+}
+
+// Check the registry's web engine health endpoint
+func checkRegistryReachable(ctx context.Context) error {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get federation information")
+	}
+	if fedInfo.NamespaceRegistrationEndpoint == "" {
+		return errors.New("registry URL is not set")
+	}
+	healthUrl, err := url.JoinPath(fedInfo.NamespaceRegistrationEndpoint, "api", "v1.0", "health")
+	if err != nil {
+		return errors.Wrap(err, "invalid registry URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthUrl, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request to the registry")
+	}
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "registry is not reachable")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry health check returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func hasOriginAd() bool {
+	for _, item := range serverAds.Items() {
+		if ad := item.Value(); ad != nil && ad.Type == server_structs.OriginType {
+			return true
+		}
+	}
+	return false
+}
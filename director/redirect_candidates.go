@@ -0,0 +1,77 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"mime"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Check if the client asked for the ranked list of candidate servers as JSON instead of a redirect,
+// through an Accept header naming application/json. Wildcards don't count, so browsers and
+// ordinary HTTP clients keep getting redirected
+func wantsRedirectCandidates(ginCtx *gin.Context) bool {
+	for _, accept := range strings.Split(ginCtx.GetHeader("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// Respond with the ranked candidates of the redirect, up to the number of servers in the Link header,
+// so clients can fail over between them without asking the director again
+func respondWithRedirectCandidates(ginCtx *gin.Context, reqPath string, clientAddr netip.Addr, namespaceAd server_structs.NamespaceAdV2, ads []server_structs.ServerAd, depth int) {
+	tokenRequired := !namespaceAd.Caps.PublicReads
+	clientCoord, hasClientCoord := Coordinate{}, false
+	if clientAddr.IsValid() {
+		clientCoord, hasClientCoord = getClientLatLong(clientAddr)
+	}
+	if len(ads) > serverResLimit {
+		ads = ads[:serverResLimit]
+	}
+
+	res := server_structs.RedirectCandidatesResp{
+		Namespace:  namespaceAd.Path,
+		Depth:      depth,
+		Candidates: make([]server_structs.RedirectCandidate, 0, len(ads)),
+	}
+	for idx, ad := range ads {
+		redirectURL := getRedirectURL(reqPath, ad, tokenRequired)
+		candidate := server_structs.RedirectCandidate{
+			URL:           redirectURL.String(),
+			Name:          ad.Name,
+			Type:          ad.Type,
+			Priority:      idx + 1,
+			Load:          ad.IOLoad,
+			TokenRequired: tokenRequired,
+		}
+		if hasClientCoord {
+			candidate.DistanceKm = serverDistanceKm(clientCoord, ad)
+		}
+		res.Candidates = append(res.Candidates, candidate)
+	}
+	ginCtx.JSON(http.StatusOK, res)
+}
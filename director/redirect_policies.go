@@ -0,0 +1,121 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+const redirectPolicyRefreshInterval = 5 * time.Minute
+
+// Namespace redirect policies fetched from the registry, keyed by namespace prefix
+var namespaceRedirectPolicies atomic.Pointer[map[string]server_structs.RedirectPolicy]
+
+// Fetch the redirect policies that namespace owners set at the registry
+func fetchRedirectPolicies(ctx context.Context) (map[string]server_structs.RedirectPolicy, error) {
+	fedInfo, err := config.GetFederation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fedInfo.NamespaceRegistrationEndpoint == "" {
+		return nil, errors.New("Federation.RegistryUrl is not set")
+	}
+	policyUrl, err := url.JoinPath(fedInfo.NamespaceRegistrationEndpoint, "api", "v1.0", "registry_ui", "redirectPolicies")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct the registry's redirect policies URL")
+	}
+	var body []byte
+	err = registryBreaker.call(func() (err error) {
+		body, err = utils.MakeRequest(ctx, policyUrl, http.MethodGet, nil, nil)
+		return
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get namespace redirect policies from the registry")
+	}
+	nsPolicies := []server_structs.NamespaceRedirectPolicy{}
+	if err := json.Unmarshal(body, &nsPolicies); err != nil {
+		return nil, errors.Wrap(err, "failed to parse namespace redirect policies from the registry")
+	}
+	policies := make(map[string]server_structs.RedirectPolicy, len(nsPolicies))
+	for _, nsPolicy := range nsPolicies {
+		// Skip the policies this director doesn't understand rather than dropping all of them
+		if err := nsPolicy.Policy.Validate(); err != nil {
+			log.Warningf("Ignoring the redirect policy of namespace %s: %v", nsPolicy.Prefix, err)
+			continue
+		}
+		policies[nsPolicy.Prefix] = nsPolicy.Policy
+	}
+	return policies, nil
+}
+
+// Periodically fetch the namespace redirect policies from the registry
+func LaunchRedirectPolicyFetch(ctx context.Context, egrp *errgroup.Group) {
+	refresh := func() {
+		policies, err := fetchRedirectPolicies(ctx)
+		if err != nil {
+			log.Warningln("Failed to refresh namespace redirect policies from the registry:", err)
+			return
+		}
+		namespaceRedirectPolicies.Store(&policies)
+	}
+	egrp.Go(func() error {
+		refresh()
+		ticker := time.NewTicker(redirectPolicyRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+}
+
+// Get the redirect policy of the namespace the (alias-resolved) request path belongs to.
+// Namespaces without a policy get the default one, deferring to the director's configuration
+func getRedirectPolicy(reqPath string) server_structs.RedirectPolicy {
+	policies := namespaceRedirectPolicies.Load()
+	if policies == nil {
+		return server_structs.RedirectPolicy{}
+	}
+	return server_structs.ResolveRedirectPolicy(*policies, reqPath)
+}
+
+// Trim the ranked servers to the policy's limit, if any
+func limitServers(ads []server_structs.ServerAd, policy server_structs.RedirectPolicy) []server_structs.ServerAd {
+	if policy.MaxServers > 0 && len(ads) > policy.MaxServers {
+		return ads[:policy.MaxServers]
+	}
+	return ads
+}
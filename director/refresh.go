@@ -0,0 +1,178 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+	"github.com/pelicanplatform/pelican/web_ui"
+)
+
+type refreshResponse struct {
+	ServerUrl  string `json:"serverUrl"`
+	Namespaces int    `json:"namespaces"`
+}
+
+// The minimum interval between two refreshes of the same server
+const minAdvertiseRefreshInterval = 30 * time.Second
+
+var (
+	// The servers refreshed in the last minAdvertiseRefreshInterval, with the key being ServerAd.URL.String()
+	recentRefreshes = ttlcache.New(ttlcache.WithTTL[string, struct{}](minAdvertiseRefreshInterval), ttlcache.WithDisableTouchOnHit[string, struct{}]())
+
+	// Ask the server to advertise itself immediately; a variable to be mocked in tests
+	requestAdvertisement = postAdvertiseRefresh
+)
+
+// Bound a fetch of advertisements by Director.AdvertiseFetchTimeout; a timeout of 0 or less leaves
+// the fetch bounded only by the parent context
+func advertiseFetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := param.Director_AdvertiseFetchTimeout.GetDuration(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Ask the origin to advertise to the director right away. Returns once the origin's advertisement
+// has been processed, as the origin waits for the director's response to its advertisement
+func postAdvertiseRefresh(ctx context.Context, serverAd server_structs.ServerAd) error {
+	tokCfg := token.NewWLCGToken()
+	tokCfg.Lifetime = time.Minute
+	tokCfg.Issuer = param.Server_ExternalWebUrl.GetString()
+	tokCfg.AddAudiences(serverAd.WebURL.String())
+	tokCfg.Subject = "director"
+	tokCfg.AddScopes(token_scopes.Pelican_AdvertiseRefresh)
+	tok, err := tokCfg.CreateToken()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the refresh token")
+	}
+
+	refreshUrl := serverAd.WebURL
+	refreshUrl.Path = "/api/v1.0/origin/advertise"
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, refreshUrl.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the refresh request")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+tok)
+
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to send the refresh request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		resBody, _ := io.ReadAll(resp.Body)
+		return errors.Errorf("the server responded with status code %d: %s", resp.StatusCode, string(resBody))
+	}
+	return nil
+}
+
+// A gin route handler that asks the origin at the `serverUrl` query parameter to advertise immediately,
+// instead of waiting for its next periodic advertisement, and responds with the number of namespaces
+// the origin serves after the refresh
+func handleAdvertiseRefresh(ctx *gin.Context) {
+	serverUrl := ctx.Query("serverUrl")
+	if serverUrl == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "'serverUrl' is a required query parameter",
+		})
+		return
+	}
+	existing := serverAds.Get(serverUrl)
+	if existing == nil || existing.Value().Type != server_structs.OriginType {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("No origin with the URL %s has advertised to the director", serverUrl),
+		})
+		return
+	}
+	serverAd := existing.Value().ServerAd
+	if serverAd.WebURL.String() == "" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("The origin %s didn't advertise a web URL to refresh from", serverUrl),
+		})
+		return
+	}
+
+	if item, found := recentRefreshes.GetOrSet(serverUrl, struct{}{}); found {
+		retryAfter := time.Until(item.ExpiresAt())
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("The origin %s was refreshed recently. Retry after %s", serverUrl, retryAfter.Round(time.Second)),
+		})
+		return
+	}
+
+	// Bound the wait so a hanging origin doesn't tie up the request. The previously cached
+	// advertisement is kept either way, as only a new advertisement replaces it
+	fetchCtx, cancel := advertiseFetchContext(ctx)
+	defer cancel()
+	if err := requestAdvertisement(fetchCtx, serverAd); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(string(serverAd.Type)).Inc()
+			web_ui.RequestLogger(ctx).Warningf("The origin %s didn't advertise within %s; keeping its cached advertisement", serverUrl, param.Director_AdvertiseFetchTimeout.GetDuration())
+			ctx.JSON(http.StatusGatewayTimeout, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("The origin %s didn't advertise within %s. Its previous advertisement is kept", serverUrl, param.Director_AdvertiseFetchTimeout.GetDuration()),
+			})
+			return
+		}
+		web_ui.RequestLogger(ctx).Warningf("Failed to refresh the advertisement of the origin %s: %v", serverUrl, err)
+		ctx.JSON(http.StatusBadGateway, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Failed to refresh the advertisement of the origin %s: %v", serverUrl, err),
+		})
+		return
+	}
+
+	refreshed := serverAds.Get(serverUrl)
+	if refreshed == nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("The origin %s is no longer registered at the director", serverUrl),
+		})
+		return
+	}
+	refreshed.Value().RLock()
+	numNamespaces := len(refreshed.Value().NamespaceAds)
+	refreshed.Value().RUnlock()
+	web_ui.RequestLogger(ctx).Infof("Refreshed the advertisement of the origin %s on request of user %s", serverUrl, ctx.GetString("User"))
+	ctx.JSON(http.StatusOK, refreshResponse{ServerUrl: serverUrl, Namespaces: numNamespaces})
+}
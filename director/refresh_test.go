@@ -0,0 +1,135 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestHandleAdvertiseRefresh(t *testing.T) {
+	serverAds.DeleteAll()
+	recentRefreshes.DeleteAll()
+	oldRequest := requestAdvertisement
+	t.Cleanup(func() {
+		requestAdvertisement = oldRequest
+		serverAds.DeleteAll()
+		recentRefreshes.DeleteAll()
+	})
+
+	originAd := mockOriginServerAd
+	originAd.WebURL = url.URL{Scheme: "https", Host: "origin.com:8444"}
+	failingOriginAd := originAd
+	failingOriginAd.Name = "failing-origin-server"
+	failingOriginAd.URL = url.URL{Scheme: "https", Host: "origin2.com"}
+	hangingOriginAd := originAd
+	hangingOriginAd.Name = "hanging-origin-server"
+	hangingOriginAd.URL = url.URL{Scheme: "https", Host: "origin3.com"}
+	for _, ad := range []server_structs.ServerAd{originAd, failingOriginAd, hangingOriginAd, mockCacheServerAd} {
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}},
+		}, ttlcache.DefaultTTL)
+	}
+
+	refreshes := 0
+	// The origin advertises a newly registered namespace when asked to refresh
+	requestAdvertisement = func(ctx context.Context, ad server_structs.ServerAd) error {
+		refreshes++
+		if ad.Name == failingOriginAd.Name {
+			return errors.New("connection refused")
+		}
+		if ad.Name == hangingOriginAd.Name {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo"}, {Path: "/bar"}},
+		}, ttlcache.DefaultTTL)
+		return nil
+	}
+
+	router := gin.New()
+	router.POST("/refresh", handleAdvertiseRefresh)
+	doRequest := func(serverUrl string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/refresh?serverUrl="+url.QueryEscape(serverUrl), nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("refresh-origin", func(t *testing.T) {
+		w := doRequest(originAd.URL.String())
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := refreshResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, originAd.URL.String(), res.ServerUrl)
+		assert.Equal(t, 2, res.Namespaces)
+		assert.Equal(t, 1, refreshes)
+	})
+
+	t.Run("rate-limited", func(t *testing.T) {
+		w := doRequest(originAd.URL.String())
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		assert.Equal(t, 1, refreshes)
+	})
+
+	t.Run("refresh-failed", func(t *testing.T) {
+		w := doRequest(failingOriginAd.URL.String())
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("refresh-timed-out", func(t *testing.T) {
+		viper.Set("Director.AdvertiseFetchTimeout", 10*time.Millisecond)
+		t.Cleanup(viper.Reset)
+		before := testutil.ToFloat64(metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(string(server_structs.OriginType)))
+
+		w := doRequest(hangingOriginAd.URL.String())
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.PelicanDirectorAdvertiseFetchTimeoutsTotal.WithLabelValues(string(server_structs.OriginType))))
+		// The previously cached ad is kept until the origin advertises again
+		cached := serverAds.Get(hangingOriginAd.URL.String())
+		require.NotNil(t, cached)
+		assert.Len(t, cached.Value().NamespaceAds, 1)
+	})
+
+	t.Run("unknown-server", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doRequest("https://unknown.com").Code)
+		assert.Equal(t, http.StatusNotFound, doRequest(mockCacheServerAd.URL.String()).Code)
+		assert.Equal(t, http.StatusBadRequest, doRequest("").Code)
+	})
+}
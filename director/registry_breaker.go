@@ -0,0 +1,179 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+type (
+	breakerState int
+
+	// A circuit breaker failing calls to the registry fast while the registry is down, instead of
+	// letting each of them wait for the registry to time out.
+	//
+	// The breaker starts closed, letting calls through. After Director.RegistryBreakerThreshold
+	// failures in a row, it opens and fails every call for Director.RegistryBreakerCooldown. Then
+	// it's half-open: a single trial call goes through, closing the breaker if it succeeds or
+	// opening it again if it fails
+	circuitBreaker struct {
+		mutex    sync.Mutex
+		state    breakerState
+		failures int       // The number of calls failed in a row
+		openedAt time.Time // When the breaker last opened
+		probing  bool      // Whether the trial call of the half-open breaker is in flight
+		now      func() time.Time
+	}
+)
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+var (
+	errRegistryUnavailable = errors.New("the registry is unavailable; failing fast until it recovers")
+
+	registryBreaker = newCircuitBreaker()
+)
+
+func (state breakerState) String() string {
+	switch state {
+	case breakerClosed:
+		return "closed"
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{now: time.Now}
+}
+
+// Must be called with the mutex held
+func (cb *circuitBreaker) setState(state breakerState) {
+	if cb.state != state {
+		log.Infof("The registry circuit breaker is now %s", state)
+	}
+	cb.state = state
+	metrics.PelicanDirectorRegistryBreakerState.Set(float64(state))
+}
+
+// Get the state of the breaker, moving an open breaker to half-open once its cooldown is over
+func (cb *circuitBreaker) currentState() breakerState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.checkCooldown()
+	return cb.state
+}
+
+// Must be called with the mutex held
+func (cb *circuitBreaker) checkCooldown() {
+	if cb.state == breakerOpen && cb.now().Sub(cb.openedAt) >= reloadableDuration(param.Director_RegistryBreakerCooldown) {
+		cb.setState(breakerHalfOpen)
+	}
+}
+
+// Check whether a call may go through, returning errRegistryUnavailable if not
+func (cb *circuitBreaker) allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.checkCooldown()
+	switch cb.state {
+	case breakerOpen:
+		return errRegistryUnavailable
+	case breakerHalfOpen:
+		if cb.probing {
+			return errRegistryUnavailable
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+// Whether the error of a registry call says the registry is unhealthy: it couldn't be reached,
+// timed out, or responded with a 5xx. Any other response, e.g. a 400 for a namespace that doesn't
+// exist or a 404 for its keys, comes from a working registry. The calls are made on behalf of
+// unauthenticated advertisements, so such responses mustn't be able to open the breaker
+func isRegistryFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *utils.StatusCodeError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// Record the outcome of a call let through by allow
+func (cb *circuitBreaker) record(err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.probing = false
+	if !isRegistryFailure(err) {
+		cb.failures = 0
+		cb.setState(breakerClosed)
+		return
+	}
+	cb.failures++
+	if cb.state == breakerHalfOpen || cb.failures >= reloadableInt(param.Director_RegistryBreakerThreshold) {
+		cb.openedAt = cb.now()
+		cb.setState(breakerOpen)
+	}
+}
+
+// Call the registry through the breaker. Only the errors isRegistryFailure deems failures count
+// against the registry; a call canceled by its caller says nothing about its health either way. A non-positive Director.RegistryBreakerThreshold
+// disables the breaker
+func (cb *circuitBreaker) call(fn func() error) error {
+	if reloadableInt(param.Director_RegistryBreakerThreshold) <= 0 {
+		return fn()
+	}
+	if err := cb.allow(); err != nil {
+		metrics.PelicanDirectorRegistryBreakerRejectedTotal.Inc()
+		return err
+	}
+	err := fn()
+	if errors.Is(err, context.Canceled) {
+		cb.mutex.Lock()
+		cb.probing = false
+		cb.mutex.Unlock()
+		return err
+	}
+	cb.record(err)
+	return err
+}
@@ -0,0 +1,196 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+func TestRegistryBreakerTransitions(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("Director.RegistryBreakerThreshold", 3)
+	viper.Set("Director.RegistryBreakerCooldown", time.Minute)
+
+	now := time.Now()
+	cb := newCircuitBreaker()
+	cb.now = func() time.Time { return now }
+	calls := 0
+	connRefused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	failing := func() error { calls++; return connRefused }
+	succeeding := func() error { calls++; return nil }
+
+	// Failures below the threshold keep the breaker closed, and a success resets the count
+	require.Error(t, cb.call(failing))
+	require.Error(t, cb.call(failing))
+	require.NoError(t, cb.call(succeeding))
+	require.Error(t, cb.call(failing))
+	require.Error(t, cb.call(failing))
+	assert.Equal(t, breakerClosed, cb.currentState())
+
+	// The registry is down: the breaker opens and fails fast without calling it
+	require.Error(t, cb.call(failing))
+	assert.Equal(t, breakerOpen, cb.currentState())
+	assert.Equal(t, float64(breakerOpen), testutil.ToFloat64(metrics.PelicanDirectorRegistryBreakerState))
+	calls = 0
+	rejected := testutil.ToFloat64(metrics.PelicanDirectorRegistryBreakerRejectedTotal)
+	assert.ErrorIs(t, cb.call(succeeding), errRegistryUnavailable)
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, rejected+1, testutil.ToFloat64(metrics.PelicanDirectorRegistryBreakerRejectedTotal))
+
+	// After the cooldown a single trial call goes through; a failed trial reopens the breaker
+	now = now.Add(time.Minute)
+	assert.Equal(t, breakerHalfOpen, cb.currentState())
+	require.NoError(t, cb.allow())
+	assert.ErrorIs(t, cb.allow(), errRegistryUnavailable, "only one trial call is allowed at once")
+	cb.record(connRefused)
+	assert.Equal(t, breakerOpen, cb.currentState())
+	assert.ErrorIs(t, cb.call(succeeding), errRegistryUnavailable)
+
+	// A trial canceled by its caller doesn't count either way
+	now = now.Add(time.Minute)
+	require.ErrorIs(t, cb.call(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, breakerHalfOpen, cb.currentState())
+
+	// A successful trial closes the breaker
+	require.NoError(t, cb.call(succeeding))
+	assert.Equal(t, breakerClosed, cb.currentState())
+	assert.Equal(t, float64(breakerClosed), testutil.ToFloat64(metrics.PelicanDirectorRegistryBreakerState))
+	require.Error(t, cb.call(failing))
+	assert.Equal(t, breakerClosed, cb.currentState())
+
+	// Errors the registry responds with aren't failures, nor are errors of the caller
+	for i := 0; i < 5; i++ {
+		require.Error(t, cb.call(func() error { return &utils.StatusCodeError{StatusCode: http.StatusBadRequest} }))
+		require.Error(t, cb.call(func() error { return errors.New("invalid response body") }))
+	}
+	assert.Equal(t, breakerClosed, cb.currentState())
+	require.Error(t, cb.call(func() error { return context.DeadlineExceeded }))
+	require.Error(t, cb.call(func() error { return &utils.StatusCodeError{StatusCode: http.StatusBadGateway} }))
+	require.Error(t, cb.call(failing))
+	assert.Equal(t, breakerOpen, cb.currentState())
+	now = now.Add(time.Minute)
+	require.NoError(t, cb.call(succeeding))
+
+	// A threshold of 0 disables the breaker
+	viper.Set("Director.RegistryBreakerThreshold", 0)
+	for i := 0; i < 5; i++ {
+		require.Error(t, cb.call(failing))
+	}
+	calls = 0
+	require.NoError(t, cb.call(succeeding))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRegistryBreakerFallback(t *testing.T) {
+	oldBreaker := registryBreaker
+	registryBreaker = newCircuitBreaker()
+	t.Cleanup(func() {
+		registryBreaker = oldBreaker
+		namespaceKeys.DeleteAll()
+		namespaceApprovals.DeleteAll()
+		viper.Reset()
+	})
+	viper.Set("Director.RegistryBreakerThreshold", 2)
+	viper.Set("Director.RegistryBreakerCooldown", time.Hour)
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	keyLoc := ts.URL + "/api/v1.0/registry/foo/.well-known/issuer.jwks"
+	cachedKeys := jwk.NewSet()
+	namespaceKeys.Set(keyLoc, &namespaceKeysEntry{Keys: cachedKeys, FreshUntil: time.Now().Add(-time.Minute)}, ttlcache.DefaultTTL)
+	namespaceApprovals.Set("/foo", true, ttlcache.DefaultTTL)
+
+	// Until the breaker opens, the registry's failures are reported
+	_, err := getNamespaceKeys(context.Background(), keyLoc)
+	require.Error(t, err)
+	_, err = getNamespaceApproval(context.Background(), "/foo", ts.URL)
+	require.Error(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, breakerOpen, registryBreaker.currentState())
+	assert.ErrorContains(t, CheckReadiness(context.Background()), "circuit breaker")
+
+	// Once it's open, the cached data is used without calling the registry
+	keys, err := getNamespaceKeys(context.Background(), keyLoc)
+	require.NoError(t, err)
+	assert.Equal(t, cachedKeys, keys)
+	approved, err := getNamespaceApproval(context.Background(), "/foo", ts.URL)
+	require.NoError(t, err)
+	assert.True(t, approved)
+	assert.Equal(t, 2, requests)
+
+	// Without cached data, the calls fail fast
+	_, err = getNamespaceApproval(context.Background(), "/bar", ts.URL)
+	assert.ErrorIs(t, err, errRegistryUnavailable)
+	_, err = getNamespaceKeys(context.Background(), ts.URL+"/api/v1.0/registry/bar/.well-known/issuer.jwks")
+	assert.ErrorIs(t, err, errRegistryUnavailable)
+	assert.Equal(t, 2, requests)
+}
+
+func TestRegistryBreakerIgnoresClientErrors(t *testing.T) {
+	oldBreaker := registryBreaker
+	registryBreaker = newCircuitBreaker()
+	t.Cleanup(func() {
+		registryBreaker = oldBreaker
+		namespaceKeys.DeleteAll()
+		namespaceApprovals.DeleteAll()
+		viper.Reset()
+	})
+	viper.Set("Director.RegistryBreakerThreshold", 2)
+	viper.Set("Director.RegistryBreakerCooldown", time.Hour)
+
+	// The registry is up, but the advertisements name namespaces it doesn't know
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := getNamespaceApproval(context.Background(), "/bogus", ts.URL)
+		require.Error(t, err)
+		_, err = getNamespaceKeys(context.Background(), ts.URL+"/api/v1.0/registry/bogus/.well-known/issuer.jwks")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errRegistryUnavailable)
+	}
+	assert.Equal(t, breakerClosed, registryBreaker.currentState())
+}
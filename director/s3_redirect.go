@@ -0,0 +1,111 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// The origin refused to presign a URL for the object, e.g. because the client's token doesn't grant reading it
+	presignDeniedError struct {
+		Msg string
+	}
+)
+
+const presignRequestTimeout = 5 * time.Second
+
+// Ask the origin for a presigned S3 URL of the object; a variable to be mocked in tests
+var fetchPresignedURL = getPresignedURL
+
+func (e *presignDeniedError) Error() string {
+	return "the origin denied presigning the URL: " + e.Msg
+}
+
+// Check if the server advertises support for redirecting clients to presigned S3 URLs for the namespace.
+// Origins mixing storage backends advertise the backend of each namespace; only S3 namespaces can be presigned
+func supportsS3Presign(ad server_structs.ServerAd, namespaceAd server_structs.NamespaceAdV2) bool {
+	if namespaceAd.Backend != "" && namespaceAd.Backend != server_structs.OriginStorageS3 {
+		return false
+	}
+	return ad.Type == server_structs.OriginType && slices.Contains(ad.Protocols, server_structs.ProtocolS3Presign)
+}
+
+// Check if the origin serves the namespace only through presigned S3 URLs: XRootD doesn't serve the
+// S3 exports of an origin of another storage type, so there's no redirecting to the origin instead
+func servedOnlyByPresign(ad server_structs.ServerAd, namespaceAd server_structs.NamespaceAdV2) bool {
+	return namespaceAd.Backend == server_structs.OriginStorageS3 && ad.StorageType != "" && ad.StorageType != server_structs.OriginStorageS3
+}
+
+// Request a presigned S3 URL of the object from the origin's web API, passing along the client's token
+// for the origin to verify. The URL is signed for the client's method, GET or HEAD, as S3 checks it.
+// Returns a *presignDeniedError if the origin refuses the token
+func getPresignedURL(ctx context.Context, originAd server_structs.ServerAd, objectPath string, method string, tok string) (string, error) {
+	presignUrl := originAd.WebURL
+	presignUrl.Path = "/api/v1.0/origin/presign"
+	presignUrl.RawQuery = url.Values{"path": []string{objectPath}, "method": []string{method}}.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, presignRequestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, presignUrl.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the presign request")
+	}
+	if tok != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+tok)
+	}
+
+	client := http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to send the presign request")
+	}
+	defer resp.Body.Close()
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the presign response")
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		errResp := server_structs.SimpleApiResp{}
+		_ = json.Unmarshal(resBody, &errResp)
+		return "", &presignDeniedError{Msg: errResp.Msg}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("the origin responded with status code %d: %s", resp.StatusCode, string(resBody))
+	}
+	presigned := server_structs.PresignedURLResp{}
+	if err := json.Unmarshal(resBody, &presigned); err != nil {
+		return "", errors.Wrap(err, "failed to parse the presign response")
+	}
+	if presigned.URL == "" {
+		return "", errors.New("the origin returned an empty presigned URL")
+	}
+	return presigned.URL, nil
+}
@@ -0,0 +1,84 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestGetPresignedURL(t *testing.T) {
+	presignedUrl := "https://s3.example.com/bucket/object?X-Amz-Signature=abc"
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1.0/origin/presign", r.URL.Path)
+		switch r.Header.Get("Authorization") {
+		case "Bearer good-token":
+			assert.Equal(t, "/test/object", r.URL.Query().Get("path"))
+			assert.Equal(t, http.MethodHead, r.URL.Query().Get("method"))
+			_ = json.NewEncoder(w).Encode(server_structs.PresignedURLResp{URL: presignedUrl})
+		case "Bearer bad-token":
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(server_structs.SimpleApiResp{Status: server_structs.RespFailed, Msg: "Authorization failed"})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer origin.Close()
+	webUrl, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+	originAd := server_structs.ServerAd{
+		Type:      server_structs.OriginType,
+		WebURL:    *webUrl,
+		Protocols: []string{server_structs.ProtocolS3Presign},
+	}
+
+	assert.True(t, supportsS3Presign(originAd, server_structs.NamespaceAdV2{}))
+	assert.True(t, supportsS3Presign(originAd, server_structs.NamespaceAdV2{Backend: server_structs.OriginStorageS3}))
+	assert.False(t, supportsS3Presign(originAd, server_structs.NamespaceAdV2{Backend: server_structs.OriginStoragePosix}))
+	assert.False(t, supportsS3Presign(server_structs.ServerAd{Type: server_structs.OriginType}, server_structs.NamespaceAdV2{}))
+
+	// XRootD only serves the S3 exports of an S3 origin, so those of a POSIX origin have no fallback
+	assert.True(t, servedOnlyByPresign(server_structs.ServerAd{StorageType: server_structs.OriginStoragePosix}, server_structs.NamespaceAdV2{Backend: server_structs.OriginStorageS3}))
+	assert.False(t, servedOnlyByPresign(server_structs.ServerAd{StorageType: server_structs.OriginStorageS3}, server_structs.NamespaceAdV2{Backend: server_structs.OriginStorageS3}))
+	assert.False(t, servedOnlyByPresign(server_structs.ServerAd{StorageType: server_structs.OriginStoragePosix}, server_structs.NamespaceAdV2{Backend: server_structs.OriginStoragePosix}))
+
+	result, err := getPresignedURL(context.Background(), originAd, "/test/object", http.MethodHead, "good-token")
+	require.NoError(t, err)
+	assert.Equal(t, presignedUrl, result)
+
+	_, err = getPresignedURL(context.Background(), originAd, "/test/object", http.MethodHead, "bad-token")
+	deniedErr := &presignDeniedError{}
+	require.ErrorAs(t, err, &deniedErr)
+	assert.Equal(t, "Authorization failed", deniedErr.Msg)
+
+	_, err = getPresignedURL(context.Background(), originAd, "/test/object", http.MethodHead, "")
+	require.Error(t, err)
+	var otherErr *presignDeniedError
+	assert.False(t, errors.As(err, &otherErr))
+}
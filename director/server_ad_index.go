@@ -0,0 +1,209 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/jellydator/ttlcache/v3"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// A node of the namespace prefix tree, with one level per path component
+	prefixNode struct {
+		children map[string]*prefixNode
+		servers  map[string]struct{} // The keys in serverAds of the servers serving the namespace ending here
+	}
+
+	// The namespace paths a server is indexed under, and the ad they're from
+	indexedServer struct {
+		ad    *server_structs.Advertisement
+		paths []string
+	}
+
+	// An index of serverAds by the namespace prefixes the servers serve, so that finding the servers
+	// for a path walks the components of the path rather than every server. serverAds remains the
+	// source of truth: the index only narrows down which of its ads to match against a path.
+	//
+	// Servers advertising are indexed by recordAd and evicted ads are removed by the eviction
+	// callback of serverAds. The index counts the insertions and evictions it has accounted for,
+	// and when serverAds has seen others, e.g. from ads set directly, it's rebuilt on the next lookup
+	serverAdIndex struct {
+		mutex      sync.RWMutex
+		root       *prefixNode
+		servers    map[string]indexedServer
+		insertions uint64
+		evictions  uint64
+	}
+)
+
+var serverAdsIndex = newServerAdIndex()
+
+func newServerAdIndex() *serverAdIndex {
+	return &serverAdIndex{root: &prefixNode{}, servers: map[string]indexedServer{}}
+}
+
+// Split a namespace or request path into its components, ignoring empty ones, so that
+// namespaces with and without a trailing / are indexed alike
+func splitNamespacePath(nsPath string) []string {
+	return strings.FieldsFunc(nsPath, func(r rune) bool { return r == '/' })
+}
+
+func (idx *serverAdIndex) addLocked(key string, ad *server_structs.Advertisement) {
+	indexed := indexedServer{ad: ad, paths: make([]string, 0, len(ad.NamespaceAds))}
+	for _, ns := range ad.NamespaceAds {
+		node := idx.root
+		for _, component := range splitNamespacePath(ns.Path) {
+			if node.children == nil {
+				node.children = map[string]*prefixNode{}
+			}
+			child, ok := node.children[component]
+			if !ok {
+				child = &prefixNode{}
+				node.children[component] = child
+			}
+			node = child
+		}
+		if node.servers == nil {
+			node.servers = map[string]struct{}{}
+		}
+		node.servers[key] = struct{}{}
+		indexed.paths = append(indexed.paths, ns.Path)
+	}
+	idx.servers[key] = indexed
+}
+
+// Remove the server from the nodes of its namespaces, pruning the nodes left empty
+func (idx *serverAdIndex) removeLocked(key string) {
+	indexed, ok := idx.servers[key]
+	if !ok {
+		return
+	}
+	for _, nsPath := range indexed.paths {
+		removeFromNode(idx.root, splitNamespacePath(nsPath), key)
+	}
+	delete(idx.servers, key)
+}
+
+// Remove the server from the node at the path below the given node, returning whether the node is now empty
+func removeFromNode(node *prefixNode, components []string, key string) bool {
+	if len(components) == 0 {
+		delete(node.servers, key)
+	} else if child, ok := node.children[components[0]]; ok && removeFromNode(child, components[1:], key) {
+		delete(node.children, components[0])
+	}
+	return len(node.servers) == 0 && len(node.children) == 0
+}
+
+// Index the ad a server just advertised, replacing the namespaces it was indexed under.
+// Set inserted if the ad is new to serverAds rather than replacing the server's previous ad
+func (idx *serverAdIndex) set(key string, ad *server_structs.Advertisement, inserted bool) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.removeLocked(key)
+	idx.addLocked(key, ad)
+	if inserted {
+		idx.insertions++
+	}
+}
+
+// Remove the ad evicted from serverAds, unless the server has advertised again since
+func (idx *serverAdIndex) evict(key string, ad *server_structs.Advertisement) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if indexed, ok := idx.servers[key]; ok && indexed.ad == ad {
+		idx.removeLocked(key)
+	}
+	idx.evictions++
+}
+
+// Whether the index accounts for every insertion and eviction of serverAds. Must be called with the mutex held
+func (idx *serverAdIndex) inSyncLocked() bool {
+	metrics := serverAds.Metrics()
+	return metrics.Insertions == idx.insertions && metrics.Evictions == idx.evictions
+}
+
+// Rebuild the index from serverAds. Must be called with the mutex held for writing
+func (idx *serverAdIndex) rebuildLocked() {
+	// Read the metrics first, so that changes made during the rebuild trigger another one
+	metrics := serverAds.Metrics()
+	idx.root = &prefixNode{}
+	idx.servers = map[string]indexedServer{}
+	for key, item := range serverAds.Items() {
+		if ad := item.Value(); ad != nil {
+			idx.addLocked(key, ad)
+		}
+	}
+	idx.insertions = metrics.Insertions
+	idx.evictions = metrics.Evictions
+}
+
+// Get the keys of the servers serving a namespace that's a prefix of the request path
+func (idx *serverAdIndex) serversForPath(reqPath string) []string {
+	idx.mutex.RLock()
+	inSync := idx.inSyncLocked()
+	idx.mutex.RUnlock()
+	if !inSync {
+		idx.mutex.Lock()
+		if !idx.inSyncLocked() {
+			idx.rebuildLocked()
+		}
+		idx.mutex.Unlock()
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	// A server serving nested namespaces is found at several nodes along the path
+	keys := map[string]struct{}{}
+	node := idx.root
+	for _, component := range splitNamespacePath(reqPath) {
+		for key := range node.servers {
+			keys[key] = struct{}{}
+		}
+		if node = node.children[component]; node == nil {
+			break
+		}
+	}
+	if node != nil {
+		for key := range node.servers {
+			keys[key] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(keys))
+	for key := range keys {
+		result = append(result, key)
+	}
+	return result
+}
+
+// Get the ads of the servers serving a namespace that's a prefix of the request path
+func getServerAdsForPath(reqPath string) []*server_structs.Advertisement {
+	keys := serverAdsIndex.serversForPath(reqPath)
+	ads := make([]*server_structs.Advertisement, 0, len(keys))
+	for _, key := range keys {
+		// Looking up an ad mustn't extend its lifetime
+		if item := serverAds.Get(key, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]()); item != nil {
+			ads = append(ads, item.Value())
+		}
+	}
+	return ads
+}
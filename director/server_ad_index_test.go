@@ -0,0 +1,188 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Match the request path against every ad in serverAds, as done before the index
+func scanAdsForPath(reqPath string) (server_structs.NamespaceAdV2, []server_structs.ServerAd, []server_structs.ServerAd) {
+	ads := []*server_structs.Advertisement{}
+	for _, item := range serverAds.Items() {
+		ads = append(ads, item.Value())
+	}
+	return matchAdsForPath(reqPath+"/", ads)
+}
+
+func serverNames(ads []server_structs.ServerAd) []string {
+	names := []string{}
+	for _, ad := range ads {
+		names = append(names, ad.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check that resolving the paths through the index gives the same servers as scanning serverAds
+func requireIndexConsistent(t *testing.T, reqPaths ...string) {
+	for _, reqPath := range reqPaths {
+		scanNs, scanOrigins, scanCaches := scanAdsForPath(reqPath)
+		ns, origins, caches := getAdsForPath(reqPath)
+		require.Equal(t, scanNs.Path, ns.Path, "namespace for %s", reqPath)
+		require.Equal(t, serverNames(scanOrigins), serverNames(origins), "origins for %s", reqPath)
+		require.Equal(t, serverNames(scanCaches), serverNames(caches), "caches for %s", reqPath)
+	}
+}
+
+func newIndexTestAd(name string, sType server_structs.ServerType) server_structs.ServerAd {
+	return server_structs.ServerAd{
+		Name:                name,
+		URL:                 url.URL{Scheme: "https", Host: name + ".example.com"},
+		Type:                sType,
+		DisableDirectorTest: true,
+	}
+}
+
+func nsAdsFor(nsPaths ...string) *[]server_structs.NamespaceAdV2 {
+	nsAds := []server_structs.NamespaceAdV2{}
+	for _, nsPath := range nsPaths {
+		nsAds = append(nsAds, server_structs.NamespaceAdV2{Path: nsPath})
+	}
+	return &nsAds
+}
+
+func TestServerAdIndex(t *testing.T) {
+	serverAds.DeleteAll()
+	viper.Reset()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		viper.Reset()
+	})
+	reqPaths := []string{"/foo/bar/obj", "/foo/obj", "/foobar/obj", "/nested/a/b/obj", "/nested/a/obj", "/other/obj", "/obj"}
+
+	t.Run("follows-cache-changes", func(t *testing.T) {
+		origin1 := newIndexTestAd("origin1", server_structs.OriginType)
+		origin2 := newIndexTestAd("origin2", server_structs.OriginType)
+		cache1 := newIndexTestAd("cache1", server_structs.CacheType)
+		recordAd(context.Background(), origin1, nsAdsFor("/foo", "/nested/a/b/"))
+		recordAd(context.Background(), origin2, nsAdsFor("/foo/bar", "/nested/a"))
+		recordAd(context.Background(), cache1, nsAdsFor("/foo", "/foo/bar", "/nested/a"))
+		requireIndexConsistent(t, reqPaths...)
+		_, origins, caches := getAdsForPath("/foo/bar/obj")
+		assert.Equal(t, []string{"origin2"}, serverNames(origins))
+		assert.Equal(t, []string{"cache1"}, serverNames(caches))
+
+		// A server advertising other namespaces is moved in the index
+		recordAd(context.Background(), origin1, nsAdsFor("/foobar"))
+		requireIndexConsistent(t, reqPaths...)
+		_, origins, _ = getAdsForPath("/foobar/obj")
+		assert.Equal(t, []string{"origin1"}, serverNames(origins))
+		ns, _, _ := getAdsForPath("/foo/obj")
+		assert.Equal(t, "/foo", ns.Path)
+
+		// Ads set or deleted without going through recordAd are picked up too
+		serverAds.Delete(origin2.URL.String())
+		requireIndexConsistent(t, reqPaths...)
+		root := newIndexTestAd("root", server_structs.OriginType)
+		serverAds.Set(root.URL.String(), &server_structs.Advertisement{ServerAd: root, NamespaceAds: *nsAdsFor("/")}, ttlcache.DefaultTTL)
+		requireIndexConsistent(t, reqPaths...)
+		_, origins, _ = getAdsForPath("/other/obj")
+		assert.Equal(t, []string{"root"}, serverNames(origins))
+
+		serverAds.DeleteAll()
+		requireIndexConsistent(t, reqPaths...)
+		ns, _, _ = getAdsForPath("/foo/obj")
+		assert.Empty(t, ns.Path)
+	})
+
+	t.Run("eviction-keeps-readvertised-ad", func(t *testing.T) {
+		idx := newServerAdIndex()
+		oldAd := &server_structs.Advertisement{NamespaceAds: *nsAdsFor("/foo")}
+		newAd := &server_structs.Advertisement{NamespaceAds: *nsAdsFor("/foo/bar")}
+		idx.set("https://origin.example.com", oldAd, true)
+		idx.set("https://origin.example.com", newAd, false)
+		assert.Len(t, idx.root.children, 1)
+		assert.Empty(t, idx.root.children["foo"].servers, "the old namespace is no longer indexed")
+
+		// The eviction of the replaced ad doesn't remove the new one
+		idx.evict("https://origin.example.com", oldAd)
+		require.Contains(t, idx.servers, "https://origin.example.com")
+		assert.Equal(t, newAd, idx.servers["https://origin.example.com"].ad)
+
+		// Evicting the current ad prunes the emptied nodes
+		idx.evict("https://origin.example.com", newAd)
+		assert.Empty(t, idx.servers)
+		assert.Empty(t, idx.root.children)
+		assert.Equal(t, uint64(1), idx.insertions)
+		assert.Equal(t, uint64(2), idx.evictions)
+	})
+
+	t.Run("matches-full-scan-at-scale", func(t *testing.T) {
+		serverAds.DeleteAll()
+		populateServerAds(1000)
+		requireIndexConsistent(t, "/ns-0/data/obj", "/ns-17/data/sub/obj", "/ns-999/obj", "/shared/obj", "/missing/obj")
+	})
+}
+
+// Advertise the given number of origins, each serving its own namespace as well as a shared one,
+// along with a cache for every ten origins
+func populateServerAds(count int) {
+	for i := 0; i < count; i++ {
+		sType := server_structs.OriginType
+		if i%10 == 0 {
+			sType = server_structs.CacheType
+		}
+		ad := newIndexTestAd(fmt.Sprintf("server-%d", i), sType)
+		nsAds := nsAdsFor(fmt.Sprintf("/ns-%d/data", i), fmt.Sprintf("/ns-%d", i), "/shared")
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad, NamespaceAds: *nsAds}, ttlcache.DefaultTTL)
+	}
+}
+
+func BenchmarkGetAdsForPath(b *testing.B) {
+	serverAds.DeleteAll()
+	b.Cleanup(func() {
+		serverAds.DeleteAll()
+	})
+	populateServerAds(10000)
+
+	b.Run("full-scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanAdsForPath(fmt.Sprintf("/ns-%d/data/obj", i%10000))
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		getAdsForPath("/ns-0/data/obj") // Build the index outside of the timing
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			getAdsForPath(fmt.Sprintf("/ns-%d/data/obj", i%10000))
+		}
+	})
+}
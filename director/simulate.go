@@ -0,0 +1,157 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// The most client IPs a single routing simulation may cover
+const maxSimulatedClients = 10000
+
+type (
+	simulateRequest struct {
+		Namespace string   `json:"namespace" binding:"required"`
+		ClientIPs []string `json:"clientIps" binding:"required,min=1"`
+	}
+
+	// The server a client would be redirected to first
+	simulatedRoute struct {
+		ClientIP string `json:"clientIp"`
+		Server   string `json:"server"` // Empty if no server is available to the client
+		URL      string `json:"url"`
+	}
+
+	simulateResponse struct {
+		Namespace string           `json:"namespace"` // The advertised namespace serving the requested one
+		Routes    []simulatedRoute `json:"routes"`
+		Hits      map[string]int   `json:"hits"` // The number of clients routed to each server, by server name
+	}
+)
+
+// Get the servers a read of the path may be redirected to, following the fallback of
+// redirectToCache to origins allowing direct reads. The object availability is not checked,
+// so every origin is assumed to have the object
+func getSimulationCandidates(reqPath string) (namespaceAd server_structs.NamespaceAdV2, candidates []server_structs.ServerAd) {
+	namespaceAd, originAds, cacheAds := getAdsForPath(reqPath)
+	if namespaceAd.Path == "" {
+		return
+	}
+	switch getRedirectPolicy(reqPath).Mode {
+	case server_structs.RedirectPolicyDirectToOrigin:
+		cacheAds = nil
+		for _, originAd := range originAds {
+			if originAd.DirectReads {
+				cacheAds = append(cacheAds, originAd)
+			}
+		}
+	case server_structs.RedirectPolicyCachesOnly:
+	default:
+		if len(cacheAds) == 0 {
+			for _, originAd := range originAds {
+				if originAd.DirectReads {
+					cacheAds = append(cacheAds, originAd)
+					break
+				}
+			}
+		}
+	}
+	return namespaceAd, cacheAds
+}
+
+// Work out the server each client would be redirected to first for a read under the path
+func simulateRouting(reqPath string, clientAddrs []netip.Addr) (simulateResponse, error) {
+	reqPath = resolveNamespaceAlias(path.Clean("/" + reqPath))
+	res := simulateResponse{Routes: make([]simulatedRoute, 0, len(clientAddrs)), Hits: map[string]int{}}
+	namespaceAd, candidates := getSimulationCandidates(reqPath)
+	res.Namespace = namespaceAd.Path
+	if namespaceAd.Path == "" {
+		return res, nil
+	}
+	for _, clientAddr := range clientAddrs {
+		route := simulatedRoute{ClientIP: clientAddr.String()}
+		if len(candidates) > 0 {
+			sortedAds, err := selectServers(reqPath, clientAddr, getClientSite("", clientAddr), candidates, nil)
+			if err != nil {
+				return res, err
+			}
+			route.Server = sortedAds[0].Name
+			route.URL = sortedAds[0].URL.String()
+			res.Hits[route.Server]++
+		}
+		res.Routes = append(res.Routes, route)
+	}
+	return res, nil
+}
+
+// A gin route handler simulating how the director would route reads of a namespace from a batch
+// of client IPs, e.g. to plan capacity for the traffic of a region. It doesn't redirect, nor does
+// it count towards the redirect metrics or the in-flight requests of origins
+func handleSimulateRouting(ctx *gin.Context) {
+	req := simulateRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_utils.NewBindingErrorResp(err, req))
+		return
+	}
+	if len(req.ClientIPs) > maxSimulatedClients {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Too many client IPs: at most %d can be simulated at once", maxSimulatedClients),
+		})
+		return
+	}
+	clientAddrs := make([]netip.Addr, 0, len(req.ClientIPs))
+	for _, clientIP := range req.ClientIPs {
+		addr, err := netip.ParseAddr(clientIP)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("Invalid client IP %q: %v", clientIP, err),
+			})
+			return
+		}
+		clientAddrs = append(clientAddrs, addr)
+	}
+
+	res, err := simulateRouting(req.Namespace, clientAddrs)
+	if err != nil {
+		log.Errorln("Failed to determine the server ordering for the routing simulation:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to determine server ordering: " + err.Error(),
+		})
+		return
+	}
+	if res.Namespace == "" {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No namespace found for path. Either it doesn't exist, or the Director is experiencing problems",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,106 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestSimulateRouting(t *testing.T) {
+	serverAds.DeleteAll()
+	geoIPOverrides = nil
+	t.Cleanup(func() {
+		viper.Reset()
+		serverAds.DeleteAll()
+		geoIPOverrides = nil
+	})
+	viper.Set("Director.CacheSortMethod", "distance")
+	// One region in the US Midwest and one in Central Europe
+	viper.Set("GeoIPOverrides", []map[string]interface{}{
+		{"IP": "192.0.2.0/24", "Coordinate": map[string]float64{"lat": 43.07, "long": -89.40}},
+		{"IP": "198.51.100.0/24", "Coordinate": map[string]float64{"lat": 50.11, "long": 8.68}},
+	})
+
+	ns := server_structs.NamespaceAdV2{Path: "/foo", Caps: server_structs.Capabilities{Reads: true}}
+	usCache := mockCacheServerAd
+	usCache.Name = "us-cache"
+	usCache.URL = url.URL{Scheme: "https", Host: "us-cache.com"}
+	usCache.Latitude, usCache.Longitude = 41.88, -87.63
+	euCache := mockCacheServerAd
+	euCache.Name = "eu-cache"
+	euCache.URL = url.URL{Scheme: "https", Host: "eu-cache.com"}
+	euCache.Latitude, euCache.Longitude = 52.52, 13.40
+	for _, ad := range []server_structs.ServerAd{mockOriginServerAd, usCache, euCache} {
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{
+			ServerAd:     ad,
+			NamespaceAds: []server_structs.NamespaceAdV2{ns},
+		}, ttlcache.DefaultTTL)
+	}
+
+	router := gin.New()
+	router.POST("/simulate", handleSimulateRouting)
+	doSimulate := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/simulate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("routes-by-region", func(t *testing.T) {
+		w := doSimulate(`{"namespace": "/foo", "clientIps": ["192.0.2.1", "198.51.100.7", "192.0.2.20", "192.0.2.30", "198.51.100.8"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := simulateResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "/foo", res.Namespace)
+		assert.Equal(t, map[string]int{"us-cache": 3, "eu-cache": 2}, res.Hits)
+		require.Len(t, res.Routes, 5)
+		assert.Equal(t, simulatedRoute{ClientIP: "192.0.2.1", Server: "us-cache", URL: "https://us-cache.com"}, res.Routes[0])
+		assert.Equal(t, simulatedRoute{ClientIP: "198.51.100.7", Server: "eu-cache", URL: "https://eu-cache.com"}, res.Routes[1])
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		w := doSimulate(`{"namespace": "/bar", "clientIps": ["192.0.2.1"]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid-request", func(t *testing.T) {
+		w := doSimulate(`{"namespace": "/foo", "clientIps": ["192.0.2.1", "not-an-ip"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not-an-ip")
+
+		w = doSimulate(`{"namespace": "/foo", "clientIps": []}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doSimulate(`{"clientIps": ["192.0.2.1"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "field 'namespace' is required")
+	})
+}
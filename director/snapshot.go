@@ -0,0 +1,165 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// A server serving a namespace in the federation snapshot, with the capabilities
+	// it advertised for the namespace
+	snapshotServer struct {
+		Name string                      `json:"name"`
+		URL  string                      `json:"url"`
+		Caps server_structs.Capabilities `json:"caps"`
+	}
+
+	snapshotNamespace struct {
+		Path    string           `json:"path"`
+		Origins []snapshotServer `json:"origins"`
+		Caches  []snapshotServer `json:"caches"`
+	}
+
+	// A snapshot of which servers the director routes each namespace to. Generation increases
+	// whenever the namespaces change, so pollers can order the snapshots they fetch
+	federationSnapshot struct {
+		Version    int                 `json:"version"`
+		Generation int64               `json:"generation"`
+		Namespaces []snapshotNamespace `json:"namespaces"`
+	}
+
+	// The generation of the namespaces last served in a snapshot
+	snapshotGeneration struct {
+		mutex      sync.Mutex
+		checksum   string
+		generation int64
+	}
+)
+
+// The version of the snapshot document format, bumped on incompatible changes
+const snapshotVersion = 1
+
+var currentSnapshot = &snapshotGeneration{}
+
+// Get the generation of the namespaces with the given checksum, advancing it if they changed.
+// New generations are at least the current Unix time in milliseconds, so the generation keeps
+// increasing across director restarts
+func (sg *snapshotGeneration) advance(checksum string) int64 {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+	if checksum != sg.checksum {
+		sg.checksum = checksum
+		sg.generation = max(sg.generation+1, time.Now().UnixMilli())
+	}
+	return sg.generation
+}
+
+// Build the namespaces of the snapshot from the routable servers, i.e. filtered and draining
+// servers are excluded, sorted so that the same federation always yields the same document
+func buildSnapshotNamespaces() []snapshotNamespace {
+	namespaces := map[string]*snapshotNamespace{}
+	for _, item := range serverAds.Items() {
+		ad := item.Value()
+		if ad == nil {
+			continue
+		}
+		if filtered, _ := checkFilter(ad.Name); filtered {
+			continue
+		}
+		if draining, _ := checkDraining(ad.URL.String()); draining {
+			continue
+		}
+		for _, nsAd := range ad.NamespaceAds {
+			ns, ok := namespaces[nsAd.Path]
+			if !ok {
+				ns = &snapshotNamespace{Path: nsAd.Path, Origins: []snapshotServer{}, Caches: []snapshotServer{}}
+				namespaces[nsAd.Path] = ns
+			}
+			server := snapshotServer{Name: ad.Name, URL: ad.URL.String(), Caps: nsAd.Caps}
+			if ad.Type == server_structs.OriginType {
+				ns.Origins = append(ns.Origins, server)
+			} else if ad.Type == server_structs.CacheType {
+				ns.Caches = append(ns.Caches, server)
+			}
+		}
+	}
+
+	result := make([]snapshotNamespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		sort.Slice(ns.Origins, func(i, j int) bool { return ns.Origins[i].URL < ns.Origins[j].URL })
+		sort.Slice(ns.Caches, func(i, j int) bool { return ns.Caches[i].URL < ns.Caches[j].URL })
+		result = append(result, *ns)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// Check if the value of an If-None-Match header matches the etag.
+// Weak validators (W/"...") are compared by their opaque tag, per RFC 9110 section 13.1.2
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Return a snapshot of the namespaces of the federation and the servers serving them, for tools
+// to poll and diff. The ETag changes only when the namespaces do, so an unchanged federation
+// costs pollers sending If-None-Match a 304
+func getFederationSnapshot(ctx *gin.Context) {
+	namespaces := buildSnapshotNamespaces()
+	namespacesBytes, err := json.Marshal(namespaces)
+	if err != nil {
+		log.Errorln("Failed to marshal the namespaces of the federation snapshot:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to build the federation snapshot",
+		})
+		return
+	}
+	checksum := sha256.Sum256(namespacesBytes)
+	checksumStr := hex.EncodeToString(checksum[:])
+	etag := `"` + checksumStr + `"`
+
+	ctx.Header("ETag", etag)
+	if ifNoneMatch := ctx.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, federationSnapshot{
+		Version:    snapshotVersion,
+		Generation: currentSnapshot.advance(checksumStr),
+		Namespaces: namespaces,
+	})
+}
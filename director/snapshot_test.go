@@ -0,0 +1,127 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestFederationSnapshot(t *testing.T) {
+	serverAds.DeleteAll()
+	filteredServersMutex.Lock()
+	oldFiltered := filteredServers
+	filteredServers = map[string]filterType{"filtered-origin": tempFiltered}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		filteredServers = oldFiltered
+		filteredServersMutex.Unlock()
+	})
+
+	setServer := func(name string, serverType server_structs.ServerType, caps server_structs.Capabilities, paths ...string) {
+		ad := server_structs.ServerAd{Name: name, URL: url.URL{Scheme: "https", Host: name + ".com"}, Type: serverType}
+		nsAds := []server_structs.NamespaceAdV2{}
+		for _, nsPath := range paths {
+			nsAds = append(nsAds, server_structs.NamespaceAdV2{Path: nsPath, Caps: caps})
+		}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad, NamespaceAds: nsAds}, ttlcache.DefaultTTL)
+	}
+	readWrite := server_structs.Capabilities{Reads: true, Writes: true}
+	setServer("origin-b", server_structs.OriginType, readWrite, "/foo")
+	setServer("origin-a", server_structs.OriginType, server_structs.Capabilities{PublicReads: true, Reads: true}, "/foo", "/bar")
+	setServer("cache-a", server_structs.CacheType, server_structs.Capabilities{Reads: true}, "/foo")
+	setServer("filtered-origin", server_structs.OriginType, readWrite, "/filtered")
+
+	router := gin.New()
+	router.GET("/snapshot", getFederationSnapshot)
+	doRequest := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/snapshot", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	getSnapshot := func(t *testing.T) (federationSnapshot, string) {
+		w := doRequest("")
+		require.Equal(t, http.StatusOK, w.Code)
+		snapshot := federationSnapshot{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		return snapshot, etag
+	}
+
+	snapshot, etag := getSnapshot(t)
+	assert.Equal(t, snapshotVersion, snapshot.Version)
+	require.Len(t, snapshot.Namespaces, 2)
+	assert.Equal(t, "/bar", snapshot.Namespaces[0].Path)
+	foo := snapshot.Namespaces[1]
+	assert.Equal(t, "/foo", foo.Path)
+	require.Len(t, foo.Origins, 2)
+	assert.Equal(t, snapshotServer{Name: "origin-a", URL: "https://origin-a.com", Caps: server_structs.Capabilities{PublicReads: true, Reads: true}}, foo.Origins[0])
+	assert.Equal(t, "origin-b", foo.Origins[1].Name)
+	assert.True(t, foo.Origins[1].Caps.Writes)
+	require.Len(t, foo.Caches, 1)
+	assert.Equal(t, "cache-a", foo.Caches[0].Name)
+
+	t.Run("unchanged-federation-not-modified", func(t *testing.T) {
+		w := doRequest(etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		w = doRequest(`W/` + etag + `, "other"`)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		again, againEtag := getSnapshot(t)
+		assert.Equal(t, etag, againEtag)
+		assert.Equal(t, snapshot.Generation, again.Generation)
+	})
+
+	t.Run("change-advances-generation", func(t *testing.T) {
+		setServer("origin-c", server_structs.OriginType, readWrite, "/baz")
+
+		w := doRequest(etag)
+		require.Equal(t, http.StatusOK, w.Code)
+		changed := federationSnapshot{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &changed))
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		assert.Greater(t, changed.Generation, snapshot.Generation)
+		assert.Len(t, changed.Namespaces, 3)
+
+		// Going back to the earlier federation is still a change for the pollers that saw it go
+		serverAds.Delete("https://origin-c.com")
+		reverted, revertedEtag := getSnapshot(t)
+		assert.Equal(t, etag, revertedEtag)
+		assert.Greater(t, reverted.Generation, changed.Generation)
+	})
+}
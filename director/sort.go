@@ -23,6 +23,8 @@ import (
 	"cmp"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math/rand"
@@ -38,6 +40,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/oschwald/geoip2-golang"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -74,8 +77,15 @@ type GeoIPOverride struct {
 	Coordinate Coordinate `mapstructure:"Coordinate"`
 }
 
+// The networks of an administrative site's clients, from Director.ClientSites
+type ClientSite struct {
+	Site     string   `mapstructure:"Site"`
+	Networks []string `mapstructure:"Networks"`
+}
+
 var invalidOverrideLogOnce = map[string]bool{}
 var geoIPOverrides []GeoIPOverride
+var clientSites []ClientSite
 
 func (me SwapMaps) Len() int {
 	return len(me)
@@ -147,17 +157,12 @@ func getLatLong(addr netip.Addr) (lat float64, long float64, err error) {
 		return override.Lat, override.Long, nil
 	}
 
-	reader := maxMindReader.Load()
-	if reader == nil {
-		err = errors.New("No GeoIP database is available")
-		return
-	}
-	record, err := reader.City(ip)
+	coord, err := geoLocator.Locate(ip)
 	if err != nil {
 		return
 	}
-	lat = record.Location.Latitude
-	long = record.Location.Longitude
+	lat = coord.Lat
+	long = coord.Long
 
 	if lat == 0 && long == 0 {
 		log.Infof("GeoIP Resolution of the address %s resulted in the nul lat/long.", ip.String())
@@ -181,7 +186,7 @@ func sortServerAdsByIP(clientAddr netip.Addr, ads []server_structs.ServerAd) ([]
 	// Each entry in weights will map a priority to an index in the original ads slice.
 	// A larger weight is a higher priority.
 	weights := make(SwapMaps, len(ads))
-	sortMethod := param.Director_CacheSortMethod.GetString()
+	sortMethod := reloadableString(param.Director_CacheSortMethod)
 
 	// If the client addr is not valid, we use random sort
 	if !clientAddr.IsValid() {
@@ -215,7 +220,7 @@ func sortServerAdsByIP(clientAddr netip.Addr, ads []server_structs.ServerAd) ([]
 			weights[idx] = SwapMap{rand.Float64(), idx}
 		default:
 			return nil, errors.Errorf("Invalid sort method '%s' set in Director.CacheSortMethod. Valid methods are 'distance',"+
-				"'distanceAndLoad', and 'random.'", param.Director_CacheSortMethod.GetString())
+				"'distanceAndLoad', and 'random.'", reloadableString(param.Director_CacheSortMethod))
 		}
 	}
 
@@ -228,6 +233,56 @@ func sortServerAdsByIP(clientAddr netip.Addr, ads []server_structs.ServerAd) ([]
 	return resultAds, nil
 }
 
+// Sort serverAds by rendezvous (highest random weight) hashing on the object path, so that the same
+// object is consistently routed to the same server while objects are spread evenly across servers.
+// Adding or removing a server only remaps the objects ranking that server first
+func sortServerAdsByRendezvousHash(objectPath string, ads []server_structs.ServerAd) []server_structs.ServerAd {
+	weights := make(SwapMaps, len(ads))
+	for idx, ad := range ads {
+		sum := sha256.Sum256([]byte(ad.URL.String() + "\x00" + objectPath))
+		weights[idx] = SwapMap{float64(binary.BigEndian.Uint64(sum[:8])), idx}
+	}
+	sort.Stable(sort.Reverse(weights))
+	resultAds := make([]server_structs.ServerAd, len(ads))
+	for idx, weight := range weights {
+		resultAds[idx] = ads[weight.Index]
+	}
+	return resultAds
+}
+
+// Sort the caches for a client requesting the object according to Director.CacheSelectionStrategy
+func sortCacheAds(objectPath string, clientAddr netip.Addr, ads []server_structs.ServerAd) ([]server_structs.ServerAd, error) {
+	switch strategy := reloadableString(param.Director_CacheSelectionStrategy); strategy {
+	case "geo", "":
+		return sortServerAdsByIP(clientAddr, ads)
+	case "consistent-hash":
+		return sortServerAdsByRendezvousHash(objectPath, ads), nil
+	default:
+		return nil, errors.Errorf("Invalid cache selection strategy '%s' set in Director.CacheSelectionStrategy. Valid strategies are 'geo' and 'consistent-hash'", strategy)
+	}
+}
+
+// Order the candidate servers of an object redirect for a client: by the cache selection strategy,
+// then by site if Director.PreferSameSiteCaches is set, then by health, where degraded servers have
+// lower priority, then by the penalty of recent failures, and finally by availability, where servers
+// having the object have higher priority.
+// A nil availability map skips the last step. The ads passed in are left untouched
+func selectServers(objectPath string, clientAddr netip.Addr, clientSite string, ads []server_structs.ServerAd, availability map[string]bool) ([]server_structs.ServerAd, error) {
+	sortedAds, err := sortCacheAds(objectPath, clientAddr, ads)
+	if err != nil {
+		return nil, err
+	}
+	if reloadableBool(param.Director_PreferSameSiteCaches) {
+		sortServerAdsBySite(sortedAds, clientSite)
+	}
+	sortServerAdsByHealth(sortedAds)
+	sortServerAdsByFailurePenalty(sortedAds, clientAddr)
+	if availability != nil {
+		sortServerAdsByAvailability(sortedAds, availability)
+	}
+	return sortedAds, nil
+}
+
 // Sort a list of ServerAds with the following rule:
 //   - if a ServerAds has FromTopology = true, then it will be moved to the end of the list
 //   - if two ServerAds has the SAME FromTopology value (both true or false), then break tie them by name
@@ -263,6 +318,138 @@ func sortServerAdsByAvailability(ads []server_structs.ServerAd, avaiMap map[stri
 	})
 }
 
+// Stable-sort the given serverAds in-place so that degraded servers come after the others.
+// Servers that are down aren't expected here, as they're excluded when matching the ads for a path
+//
+// Smaller index in the sorted array means higher priority
+func sortServerAdsByHealth(ads []server_structs.ServerAd) {
+	degraded := make(map[string]bool, len(ads))
+	for _, ad := range ads {
+		degraded[ad.URL.String()] = getHealthStatus(ad) == HealthStatusDegraded
+	}
+	slices.SortStableFunc(ads, func(a, b server_structs.ServerAd) int {
+		if degraded[a.URL.String()] && !degraded[b.URL.String()] {
+			return 1
+		} else if !degraded[a.URL.String()] && degraded[b.URL.String()] {
+			return -1
+		} else {
+			// Preserve original ordering
+			return 0
+		}
+	})
+}
+
+// Get the administrative site of a client: the site named by the client's X-Pelican-Site header
+// if any, or else the first site in Director.ClientSites with a network containing the client's address.
+// Returns an empty string if the site is unknown
+func getClientSite(siteHeader string, clientAddr netip.Addr) string {
+	if site := strings.TrimSpace(siteHeader); site != "" {
+		return site
+	}
+	if !clientAddr.IsValid() {
+		return ""
+	}
+	// Unmarshal the sites, but only the first time we run through this block
+	if clientSites == nil {
+		clientSites = []ClientSite{}
+		if err := param.Director_ClientSites.Unmarshal(&clientSites); err != nil {
+			log.Warningf("Error while unmarshaling Director.ClientSites: %v", err)
+		}
+	}
+	clientAddr = clientAddr.Unmap()
+	for _, clientSite := range clientSites {
+		for _, network := range clientSite.Networks {
+			if prefix, err := netip.ParsePrefix(network); err == nil {
+				if prefix.Contains(clientAddr) {
+					return clientSite.Site
+				}
+			} else if addr, err := netip.ParseAddr(network); err == nil {
+				if addr.Unmap() == clientAddr {
+					return clientSite.Site
+				}
+			} else if !invalidOverrideLogOnce[network] {
+				log.Warningf("Failed to parse the network %q of the site %s in Director.ClientSites", network, clientSite.Site)
+				invalidOverrideLogOnce[network] = true
+			}
+		}
+	}
+	return ""
+}
+
+// Stable-sort the given serverAds in-place so that the servers of the client's site come before
+// the others, keeping the existing order (e.g. by distance) within each group. Does nothing if
+// the client's site is unknown
+//
+// Smaller index in the sorted array means higher priority
+func sortServerAdsBySite(ads []server_structs.ServerAd, clientSite string) {
+	if clientSite == "" {
+		return
+	}
+	slices.SortStableFunc(ads, func(a, b server_structs.ServerAd) int {
+		aSameSite := strings.EqualFold(a.Site, clientSite)
+		bSameSite := strings.EqualFold(b.Site, clientSite)
+		if !aSameSite && bSameSite {
+			return 1
+		} else if aSameSite && !bSameSite {
+			return -1
+		} else {
+			// Preserve original ordering
+			return 0
+		}
+	})
+}
+
+// Randomly select a writeable origin from ads, with the probability proportional to its
+// advertised weight. Origins advertising a weight below 1 (including those predating the
+// weight attribute) are given a weight of 1. Returns false if none of the ads allows writes.
+func selectWeightedWriteOrigin(ads []server_structs.ServerAd) (server_structs.ServerAd, bool) {
+	totalWeight := 0
+	for _, ad := range ads {
+		if ad.Writes {
+			totalWeight += max(ad.Weight, 1)
+		}
+	}
+	if totalWeight == 0 {
+		return server_structs.ServerAd{}, false
+	}
+	pick := rand.Intn(totalWeight)
+	for _, ad := range ads {
+		if !ad.Writes {
+			continue
+		}
+		pick -= max(ad.Weight, 1)
+		if pick < 0 {
+			return ad, true
+		}
+	}
+	// Unreachable, as pick < totalWeight
+	return server_structs.ServerAd{}, false
+}
+
+// Get the free space origins must advertise to be sent uploads, from Director.MinOriginFreeSpace.
+// The parameter is validated at startup, so a parse failure here only disables the check
+func getMinOriginFreeSpace() uint64 {
+	minFreeSpace, err := units.ParseStrictBytes(reloadableString(param.Director_MinOriginFreeSpace))
+	if err != nil || minFreeSpace < 0 {
+		return 0
+	}
+	return uint64(minFreeSpace)
+}
+
+// Filter out the origins advertising less free space than minFreeSpace. Origins that don't
+// advertise the size of their storage are kept, as their free space is unknown
+func filterOriginsByFreeSpace(ads []server_structs.ServerAd, minFreeSpace uint64) []server_structs.ServerAd {
+	filtered := make([]server_structs.ServerAd, 0, len(ads))
+	for _, ad := range ads {
+		if ad.TotalSpace > 0 && ad.FreeSpace < minFreeSpace {
+			log.Debugf("Skipping origin %s for writes, as it has %d bytes free, less than Director.MinOriginFreeSpace", ad.Name, ad.FreeSpace)
+			continue
+		}
+		filtered = append(filtered, ad)
+	}
+	return filtered
+}
+
 func downloadDB(localFile string) error {
 	err := os.MkdirAll(filepath.Dir(localFile), 0755)
 	if err != nil {
@@ -358,7 +545,24 @@ func periodicMaxMindReload(ctx context.Context) {
 	}
 }
 
-func InitializeDB(ctx context.Context) {
+// Set up the GeoIP provider of Director.GeoIPProvider. A MaxMind database that fails to load is
+// only logged, as it may be downloaded later, while an invalid configuration is returned as an error
+func InitializeDB(ctx context.Context) error {
+	switch provider := param.Director_GeoIPProvider.GetString(); provider {
+	case "static":
+		locator, err := loadStaticLocator(param.Director_GeoIPStaticFile.GetString())
+		if err != nil {
+			return err
+		}
+		geoLocator = locator
+		log.Infof("Using the static GeoIP table at %s with %d networks", param.Director_GeoIPStaticFile.GetString(), len(locator.networks))
+		return nil
+	case "maxmind", "":
+		geoLocator = maxMindLocator{}
+	default:
+		return errors.Errorf("invalid Director.GeoIPProvider %q; valid providers are 'maxmind' and 'static'", provider)
+	}
+
 	go periodicMaxMindReload(ctx)
 	localFile := param.Director_GeoIPLocation.GetString()
 	localReader, err := geoip2.Open(localFile)
@@ -367,13 +571,14 @@ func InitializeDB(ctx context.Context) {
 		err = downloadDB(localFile)
 		if err != nil {
 			log.Errorln("Failed to download GeoIP database!  Will not be available:", err)
-			return
+			return nil
 		}
 		localReader, err = geoip2.Open(localFile)
 		if err != nil {
 			log.Errorln("Failed to reopen GeoIP database!  Will not be available:", err)
-			return
+			return nil
 		}
 	}
 	maxMindReader.Store(localReader)
+	return nil
 }
@@ -20,12 +20,15 @@ package director
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
+	"fmt"
 	"math/rand"
 	"net"
 	"net/netip"
 	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -282,6 +285,89 @@ func TestSortServerAdsByIP(t *testing.T) {
 	})
 }
 
+func TestSortServerAdsByRendezvousHash(t *testing.T) {
+	caches := make([]server_structs.ServerAd, 10)
+	for idx := range caches {
+		caches[idx] = server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: fmt.Sprintf("cache%d.org", idx)}}
+	}
+	objects := make([]string, 2000)
+	for idx := range objects {
+		objects[idx] = fmt.Sprintf("/foo/object-%d", idx)
+	}
+	assign := func(ads []server_structs.ServerAd) map[string]string {
+		assignment := map[string]string{}
+		for _, object := range objects {
+			assignment[object] = sortServerAdsByRendezvousHash(object, ads)[0].URL.Host
+		}
+		return assignment
+	}
+	before := assign(caches)
+
+	t.Run("deterministic-regardless-of-order", func(t *testing.T) {
+		reversed := slices.Clone(caches)
+		slices.Reverse(reversed)
+		assert.Equal(t, before, assign(reversed))
+	})
+
+	t.Run("balanced", func(t *testing.T) {
+		counts := map[string]int{}
+		for _, host := range before {
+			counts[host]++
+		}
+		require.Len(t, counts, len(caches))
+		mean := len(objects) / len(caches)
+		for host, count := range counts {
+			assert.Greater(t, count, mean/2, "cache %s is assigned too few objects", host)
+			assert.Less(t, count, mean*3/2, "cache %s is assigned too many objects", host)
+		}
+	})
+
+	t.Run("removing-a-cache-only-remaps-its-objects", func(t *testing.T) {
+		removed := caches[3].URL.Host
+		after := assign(append(slices.Clone(caches[:3]), caches[4:]...))
+		for object, host := range before {
+			if host != removed {
+				assert.Equal(t, host, after[object])
+			} else {
+				assert.NotEqual(t, removed, after[object])
+			}
+		}
+	})
+
+	t.Run("adding-a-cache-only-moves-objects-to-it", func(t *testing.T) {
+		added := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "new-cache.org"}}
+		after := assign(append(slices.Clone(caches), added))
+		moved := 0
+		for object, host := range before {
+			if after[object] != host {
+				assert.Equal(t, added.URL.Host, after[object])
+				moved++
+			}
+		}
+		// About 1/11 of the objects should move to the new cache
+		assert.Greater(t, moved, len(objects)/22)
+		assert.Less(t, moved, len(objects)*2/11)
+	})
+}
+
+func TestSortCacheAds(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	ads := []server_structs.ServerAd{
+		{URL: url.URL{Scheme: "https", Host: "cache1.org"}},
+		{URL: url.URL{Scheme: "https", Host: "cache2.org"}},
+		{URL: url.URL{Scheme: "https", Host: "cache3.org"}},
+	}
+
+	viper.Set("Director.CacheSelectionStrategy", "consistent-hash")
+	sorted, err := sortCacheAds("/foo/bar", netip.MustParseAddr("128.104.153.60"), ads)
+	require.NoError(t, err)
+	assert.Equal(t, sortServerAdsByRendezvousHash("/foo/bar", ads), sorted)
+
+	viper.Set("Director.CacheSelectionStrategy", "nearest")
+	_, err = sortCacheAds("/foo/bar", netip.MustParseAddr("128.104.153.60"), ads)
+	assert.Error(t, err)
+}
+
 func TestSortServerAdsByAvailability(t *testing.T) {
 	firstUrl := url.URL{Host: "first.org", Scheme: "https"}
 	secondUrl := url.URL{Host: "second.org", Scheme: "https"}
@@ -304,3 +390,138 @@ func TestSortServerAdsByAvailability(t *testing.T) {
 	sortServerAdsByAvailability(randomOrder, avaiMap)
 	assert.EqualValues(t, expected, randomOrder)
 }
+
+func TestSortServerAdsByHealth(t *testing.T) {
+	healthTestUtilsMutex.Lock()
+	tmp := healthTestUtils
+	healthTestUtilsMutex.Unlock()
+	t.Cleanup(func() {
+		healthTestUtilsMutex.Lock()
+		healthTestUtils = tmp
+		healthTestUtilsMutex.Unlock()
+	})
+
+	healthyServer := server_structs.ServerAd{URL: url.URL{Host: "healthy.org", Scheme: "https"}}
+	degradedServer := server_structs.ServerAd{URL: url.URL{Host: "degraded.org", Scheme: "https"}}
+	unknownServer := server_structs.ServerAd{URL: url.URL{Host: "unknown.org", Scheme: "https"}}
+	healthTestUtilsMutex.Lock()
+	healthTestUtils = map[string]*healthTestUtil{
+		healthyServer.URL.String():  {Status: HealthStatusOK},
+		degradedServer.URL.String(): {Status: HealthStatusDegraded},
+	}
+	healthTestUtilsMutex.Unlock()
+
+	ads := []server_structs.ServerAd{degradedServer, healthyServer, unknownServer}
+	sortServerAdsByHealth(ads)
+	assert.EqualValues(t, []server_structs.ServerAd{healthyServer, unknownServer, degradedServer}, ads)
+}
+
+func TestSortServerAdsBySite(t *testing.T) {
+	viper.Reset()
+	clientSites = nil
+	t.Cleanup(func() {
+		viper.Reset()
+		clientSites = nil
+	})
+
+	// Resolves to roughly the same location as the Madison cache through the GeoIP override
+	clientIP := netip.MustParseAddr("128.104.153.60")
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(yamlMockup)))
+	viper.Set("Director.CacheSortMethod", "distance")
+	viper.Set("Director.ClientSites", []map[string]interface{}{{"Site": "SDSC", "Networks": []string{"128.104.0.0/16"}}})
+
+	closerCache := server_structs.ServerAd{Name: "madison", Latitude: 43.0753, Longitude: -89.4114, Site: "CHTC"}
+	fartherCache := server_structs.ServerAd{Name: "sdsc", Latitude: 32.8761, Longitude: -117.2318, Site: "SDSC"}
+	noSiteCache := server_structs.ServerAd{Name: "london", Latitude: 51.5103, Longitude: -0.1167}
+
+	sorted, err := sortServerAdsByIP(clientIP, []server_structs.ServerAd{noSiteCache, fartherCache, closerCache})
+	require.NoError(t, err)
+	require.EqualValues(t, []server_structs.ServerAd{closerCache, fartherCache, noSiteCache}, sorted)
+
+	t.Run("same-site-outranks-closer", func(t *testing.T) {
+		ads := slices.Clone(sorted)
+		sortServerAdsBySite(ads, getClientSite("", clientIP))
+		assert.EqualValues(t, []server_structs.ServerAd{fartherCache, closerCache, noSiteCache}, ads)
+	})
+
+	t.Run("header-overrides-networks", func(t *testing.T) {
+		ads := slices.Clone(sorted)
+		sortServerAdsBySite(ads, getClientSite("chtc", clientIP))
+		assert.EqualValues(t, []server_structs.ServerAd{closerCache, fartherCache, noSiteCache}, ads)
+	})
+
+	t.Run("unknown-site-keeps-order", func(t *testing.T) {
+		assert.Equal(t, "", getClientSite("", netip.MustParseAddr("192.0.2.1")))
+		ads := slices.Clone(sorted)
+		sortServerAdsBySite(ads, getClientSite("", netip.MustParseAddr("192.0.2.1")))
+		assert.EqualValues(t, sorted, ads)
+	})
+}
+
+func TestFilterOriginsByFreeSpace(t *testing.T) {
+	full := server_structs.ServerAd{Name: "full", Writes: true, FreeSpace: 1 << 30, TotalSpace: 100 << 30}
+	spacious := server_structs.ServerAd{Name: "spacious", Writes: true, FreeSpace: 50 << 30, TotalSpace: 100 << 30}
+	unknown := server_structs.ServerAd{Name: "unknown", Writes: true}
+
+	filtered := filterOriginsByFreeSpace([]server_structs.ServerAd{full, spacious, unknown}, 10<<30)
+	assert.Equal(t, []server_structs.ServerAd{spacious, unknown}, filtered)
+	assert.Empty(t, filterOriginsByFreeSpace([]server_structs.ServerAd{full}, 10<<30))
+}
+
+func TestSelectWeightedWriteOrigin(t *testing.T) {
+	light := server_structs.ServerAd{Name: "light", URL: url.URL{Scheme: "https", Host: "light.org"}, Type: server_structs.OriginType, Writes: true, Weight: 1}
+	heavy := server_structs.ServerAd{Name: "heavy", URL: url.URL{Scheme: "https", Host: "heavy.org"}, Type: server_structs.OriginType, Writes: true, Weight: 3}
+	readOnly := server_structs.ServerAd{Name: "read-only", URL: url.URL{Scheme: "https", Host: "readonly.org"}, Type: server_structs.OriginType, Weight: 100}
+
+	t.Run("distributes-by-weight", func(t *testing.T) {
+		const rounds = 10000
+		counts := map[string]int{}
+		for i := 0; i < rounds; i++ {
+			ad, ok := selectWeightedWriteOrigin([]server_structs.ServerAd{light, heavy, readOnly})
+			require.True(t, ok)
+			counts[ad.Name]++
+		}
+		assert.Zero(t, counts["read-only"])
+		assert.InDelta(t, 0.75, float64(counts["heavy"])/rounds, 0.03)
+		assert.InDelta(t, 0.25, float64(counts["light"])/rounds, 0.03)
+	})
+
+	t.Run("unset-weight-defaults-to-one", func(t *testing.T) {
+		unweighted := light
+		unweighted.Weight = 0
+		ad, ok := selectWeightedWriteOrigin([]server_structs.ServerAd{unweighted})
+		require.True(t, ok)
+		assert.Equal(t, "light", ad.Name)
+	})
+
+	t.Run("no-writeable-origin", func(t *testing.T) {
+		_, ok := selectWeightedWriteOrigin([]server_structs.ServerAd{readOnly})
+		assert.False(t, ok)
+	})
+
+	t.Run("filtered-origin-excluded", func(t *testing.T) {
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		tmp := filteredServers
+		filteredServers = map[string]filterType{"heavy": tempFiltered}
+		filteredServersMutex.Unlock()
+		t.Cleanup(func() {
+			serverAds.DeleteAll()
+			filteredServersMutex.Lock()
+			filteredServers = tmp
+			filteredServersMutex.Unlock()
+		})
+
+		nsAds := []server_structs.NamespaceAdV2{{Path: "/upload", Caps: server_structs.Capabilities{Writes: true}}}
+		recordAd(context.Background(), light, &nsAds)
+		recordAd(context.Background(), heavy, &nsAds)
+
+		_, originAds, _ := getAdsForPath("/upload/file")
+		for i := 0; i < 100; i++ {
+			ad, ok := selectWeightedWriteOrigin(originAds)
+			require.True(t, ok)
+			assert.Equal(t, "light", ad.Name)
+		}
+	})
+}
@@ -25,9 +25,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -50,9 +52,11 @@ type (
 
 	queryOption    func(*queryConfig)
 	objectMetadata struct {
-		URL           url.URL `json:"url"` // The URL to the object
-		Checksum      string  `json:"checksum"`
-		ContentLength int     `json:"contentLength"`
+		URL           url.URL    `json:"url"` // The URL to the object
+		Checksum      string     `json:"checksum"`
+		ContentLength int        `json:"contentLength"`
+		LastModified  *time.Time `json:"lastModified,omitempty"`
+		ContentType   string     `json:"contentType,omitempty"`
 	}
 
 	queryStatus    string
@@ -228,7 +232,17 @@ func (stat *ObjectStat) sendHeadReq(ctx context.Context, objectName string, data
 		if err != nil {
 			return nil, errors.New(fmt.Sprintf("error parsing content-length header from response. Header was: %s", cLenStr))
 		}
-		return &objectMetadata{ContentLength: clen, Checksum: checksumStr, URL: *dataUrl.JoinPath(objectName)}, nil
+		meta := &objectMetadata{
+			ContentLength: clen,
+			Checksum:      checksumStr,
+			ContentType:   res.Header.Get("Content-Type"),
+			URL:           *dataUrl.JoinPath(objectName),
+		}
+		// Older origins may not report the modification time; it's optional in the metadata
+		if lastModified, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+			meta.LastModified = &lastModified
+		}
+		return meta, nil
 	}
 }
 
@@ -295,8 +309,8 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 		}
 	}
 
-	minReq := param.Director_MinStatResponse.GetInt()
-	maxReq := param.Director_MaxStatResponse.GetInt()
+	minReq := reloadableInt(param.Director_MinStatResponse)
+	maxReq := reloadableInt(param.Director_MaxStatResponse)
 	if minimum > 0 {
 		minReq = minimum
 	}
@@ -309,7 +323,7 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 		qResult.Msg = "Invalid parameter, max_responses must be larger than min_responses"
 		return
 	}
-	timeout := param.Director_StatTimeout.GetDuration()
+	timeout := reloadableDuration(param.Director_StatTimeout)
 	positiveReqChan := make(chan *objectMetadata)
 	negativeReqChan := make(chan error)
 	deniedReqChan := make(chan *headReqForbiddenErr) // Requests with 403 response
@@ -469,3 +483,55 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 		}
 	}
 }
+
+// A gin route handler returning the metadata of the object at the path, as reported by the
+// first origin found to have it. The client's token is passed along to the origins
+func getObjectStat(ctx *gin.Context) {
+	objectPath := path.Clean("/" + ctx.Param("path"))
+	if objectPath == "/" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Path should not be empty",
+		})
+		return
+	}
+	objectPath = resolveNamespaceAlias(objectPath)
+	reqParams := getRequestParameters(ctx.Request)
+
+	qr := NewObjectStat().Query(ctx, objectPath, config.OriginType, 1, 1, WithToken(reqParams.Get("authz")))
+	if qr.Status == querySuccessful && len(qr.Objects) > 0 {
+		obj := qr.Objects[0]
+		ctx.JSON(http.StatusOK, server_structs.StatResponse{
+			Path:         objectPath,
+			Size:         int64(obj.ContentLength),
+			LastModified: obj.LastModified,
+			Checksum:     obj.Checksum,
+			ContentType:  obj.ContentType,
+			OriginURL:    obj.URL.String(),
+		})
+		return
+	}
+	switch {
+	case qr.ErrorType == queryNoPrefixMatchErr:
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No origin found serving the path " + objectPath,
+		})
+	case len(qr.DeniedServers) > 0:
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The origins serving the object denied access; a token authorized to read it is required",
+		})
+	case qr.ErrorType == queryInsufficientResErr:
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Object " + objectPath + " not found on any origin",
+		})
+	default:
+		log.Errorf("Failed to stat the object %s: %s", objectPath, qr.String())
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to stat the object: " + qr.Msg,
+		})
+	}
+}
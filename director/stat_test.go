@@ -20,6 +20,7 @@ package director
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -29,6 +30,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -707,6 +709,8 @@ func TestSendHeadReq(t *testing.T) {
 				rw.Header().Set("Digest", "mockChecksum")
 			}
 			rw.Header().Set("Content-Length", "1")
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
 			rw.WriteHeader(http.StatusOK)
 			return
 		} else if req.Method == "HEAD" && req.URL.String() == "/foo/bar/timeout.txt" {
@@ -750,6 +754,9 @@ func TestSendHeadReq(t *testing.T) {
 		assert.NotNil(t, meta)
 		assert.Equal(t, 1, meta.ContentLength)
 		assert.Equal(t, "mockChecksum", meta.Checksum)
+		assert.Equal(t, "text/plain", meta.ContentType)
+		require.NotNil(t, meta.LastModified)
+		assert.Equal(t, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC), meta.LastModified.UTC())
 	})
 
 	t.Run("404-input-gives-404-error", func(t *testing.T) {
@@ -804,3 +811,86 @@ func TestSendHeadReq(t *testing.T) {
 		assert.Nil(t, meta)
 	})
 }
+
+func TestGetObjectStat(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method != http.MethodHead:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		case req.URL.Path == "/foo/test.txt":
+			rw.Header().Set("Content-Length", "42")
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			if req.Header.Get("Want-Digest") == "crc32c" {
+				rw.Header().Set("Digest", "crc32c=2a6d8ed2")
+			}
+			rw.WriteHeader(http.StatusOK)
+		case req.URL.Path == "/foo/protected.txt" && req.Header.Get("Authorization") == "":
+			rw.WriteHeader(http.StatusForbidden)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(origin.Close)
+
+	viper.Reset()
+	viper.Set("ConfigDir", t.TempDir())
+	config.InitConfig()
+	viper.Set("Director.StatTimeout", time.Second)
+	originUrl, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		cancel()
+		serverAds.DeleteAll()
+		statUtilsMutex.Lock()
+		delete(statUtils, originUrl.String())
+		statUtilsMutex.Unlock()
+		viper.Reset()
+	})
+	originAd := server_structs.ServerAd{Name: "stat-origin", URL: *originUrl, Type: server_structs.OriginType}
+	serverAds.Set(originUrl.String(), &server_structs.Advertisement{
+		ServerAd:     originAd,
+		NamespaceAds: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: server_structs.Capabilities{Reads: true}}},
+	}, ttlcache.DefaultTTL)
+	statUtilsMutex.Lock()
+	statUtils[originUrl.String()] = serverStatUtil{Context: ctx, Cancel: cancel, Errgroup: &errgroup.Group{}}
+	statUtilsMutex.Unlock()
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/stat/*path", getObjectStat)
+	doStat := func(objectPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/stat"+objectPath, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("existing-object", func(t *testing.T) {
+		w := doStat("/foo/test.txt")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		res := server_structs.StatResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "/foo/test.txt", res.Path)
+		assert.Equal(t, int64(42), res.Size)
+		assert.Equal(t, "crc32c=2a6d8ed2", res.Checksum)
+		assert.Equal(t, "text/plain", res.ContentType)
+		require.NotNil(t, res.LastModified)
+		assert.Equal(t, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC), res.LastModified.UTC())
+		assert.Equal(t, origin.URL+"/foo/test.txt", res.OriginURL)
+	})
+
+	t.Run("missing-object", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doStat("/foo/missing.txt").Code)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doStat("/bar/test.txt").Code)
+	})
+
+	t.Run("denied-object", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doStat("/foo/protected.txt").Code)
+	})
+}
@@ -0,0 +1,86 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// The prefix of the name of the cookie pinning a client to a cache of a namespace.
+// Each namespace gets its own cookie so that reading from several namespaces doesn't
+// make them evict each other's cache
+const stickySessionCookiePrefix = "pelican_sticky_"
+
+// Get the name of the sticky session cookie of a namespace
+func getStickySessionCookieName(namespacePath string) string {
+	digest := sha256.Sum256([]byte(namespacePath))
+	return stickySessionCookiePrefix + hex.EncodeToString(digest[:8])
+}
+
+// Move the cache of the client's sticky session cookie for the namespace to the front of the
+// candidates, provided it's still a candidate and neither degraded nor failing its health tests.
+// Returns whether the cookie was honored; if not, the candidates are left untouched
+func applyStickySession(ginCtx *gin.Context, namespacePath string, ads []server_structs.ServerAd) bool {
+	if !reloadableBool(param.Director_EnableStickySessions) {
+		return false
+	}
+	cookie, err := ginCtx.Request.Cookie(getStickySessionCookieName(namespacePath))
+	if err != nil {
+		return false
+	}
+	stickyUrl, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		log.Debugf("Ignoring the malformed sticky session cookie of namespace %s", namespacePath)
+		return false
+	}
+	for idx, ad := range ads {
+		if ad.URL.String() != string(stickyUrl) {
+			continue
+		}
+		if status := getHealthStatus(ad); status == HealthStatusDegraded || status == HealthStatusError {
+			log.Debugf("Not honoring the sticky session of namespace %s on %s server %s with health status %s", namespacePath, ad.Type, ad.Name, status)
+			return false
+		}
+		copy(ads[1:idx+1], ads[:idx])
+		ads[0] = ad
+		return true
+	}
+	return false
+}
+
+// Pin the client to the server it's being redirected to for Director.StickySessionTTL
+func setStickySessionCookie(ginCtx *gin.Context, namespacePath string, ad server_structs.ServerAd) {
+	http.SetCookie(ginCtx.Writer, &http.Cookie{
+		Name:     getStickySessionCookieName(namespacePath),
+		Value:    base64.RawURLEncoding.EncodeToString([]byte(ad.URL.String())),
+		Path:     "/",
+		MaxAge:   int(reloadableDuration(param.Director_StickySessionTTL).Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,119 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package director
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestStickySessions(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		healthTestUtilsMutex.Lock()
+		healthTestUtils = make(map[string]*healthTestUtil)
+		healthTestUtilsMutex.Unlock()
+		viper.Reset()
+	})
+	viper.Reset()
+	viper.Set("Director.CacheSortMethod", "random")
+	viper.Set("Director.EnableStickySessions", true)
+	viper.Set("Director.StickySessionTTL", "10m")
+
+	nsAds := []server_structs.NamespaceAdV2{{Path: "/foo", Caps: server_structs.Capabilities{PublicReads: true, Reads: true}}}
+	cacheHosts := []string{"cache1.com", "cache2.com", "cache3.com"}
+	for _, host := range cacheHosts {
+		cache := mockCacheServerAd
+		cache.Name = host
+		cache.URL = url.URL{Scheme: "https", Host: host}
+		serverAds.Set(cache.URL.String(), &server_structs.Advertisement{ServerAd: cache, NamespaceAds: nsAds}, ttlcache.DefaultTTL)
+	}
+	serverAds.Set(mockOriginServerAd.URL.String(), &server_structs.Advertisement{ServerAd: mockOriginServerAd, NamespaceAds: nsAds}, ttlcache.DefaultTTL)
+
+	router := gin.New()
+	router.GET("/api/v1.0/director/object/*any", redirectToCache)
+	doRequest := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1.0/director/object/foo/bar?skipstat", nil)
+		req.Header.Set("User-Agent", "pelican-v7.999.999")
+		req.Header.Set("X-Real-Ip", "128.104.153.60")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	getStickyCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == getStickySessionCookieName("/foo") {
+				return cookie
+			}
+		}
+		return nil
+	}
+
+	// The first redirect pins the client to the cache it's sent to
+	w := doRequest(nil)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	cookie := getStickyCookie(w)
+	require.NotNil(t, cookie)
+	assert.Equal(t, 600, cookie.MaxAge)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	stickyHost := location.Host
+
+	// Later requests go to the same cache and don't reset the cookie
+	for i := 0; i < 10; i++ {
+		w = doRequest(cookie)
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, stickyHost, location.Host)
+		assert.Nil(t, getStickyCookie(w))
+	}
+
+	// A degraded sticky cache is passed over for a fresh selection, which becomes the new sticky cache
+	healthTestUtilsMutex.Lock()
+	healthTestUtils["https://"+stickyHost] = &healthTestUtil{Status: HealthStatusDegraded}
+	healthTestUtilsMutex.Unlock()
+	w = doRequest(cookie)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	location, err = url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.NotEqual(t, stickyHost, location.Host)
+	newCookie := getStickyCookie(w)
+	require.NotNil(t, newCookie)
+	assert.NotEqual(t, cookie.Value, newCookie.Value)
+
+	// Without sticky sessions, the cookie is neither set nor honored
+	viper.Set("Director.EnableStickySessions", false)
+	w = doRequest(nil)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Nil(t, getStickyCookie(w))
+}
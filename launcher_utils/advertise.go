@@ -28,11 +28,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -51,7 +53,25 @@ type directorResponse struct {
 	ApprovalError bool   `json:"approval_error"`
 }
 
-func doAdvertise(ctx context.Context, servers []server_structs.XRootDServer) {
+const (
+	// The interval between advertisements to the director
+	advertiseInterval = 1 * time.Minute
+	// The delay before the first retry of a failed advertisement; doubled on each consecutive failure
+	// and capped at advertiseInterval
+	advertiseRetryInitialBackoff = 5 * time.Second
+	// The number of consecutive failed advertisements after which failures are logged as errors
+	advertiseFailureAlertThreshold = 5
+	// The maximum time to wait for an advertisement requested by the director
+	advertiseRefreshTimeout = 30 * time.Second
+)
+
+// Requests for an immediate advertisement, served by the periodic advertisement loop.
+// The result of the advertisement is sent back on the enclosed channel
+var advertiseRequests = make(chan chan error)
+
+var errNotAdvertising = errors.New("The server is not advertising to the director")
+
+func doAdvertise(ctx context.Context, servers []server_structs.XRootDServer) error {
 	log.Debugf("About to advertise %d XRootD servers", len(servers))
 	err := Advertise(ctx, servers)
 	if err != nil {
@@ -60,38 +80,129 @@ func doAdvertise(ctx context.Context, servers []server_structs.XRootDServer) {
 	} else {
 		metrics.SetComponentHealthStatus(metrics.OriginCache_Federation, metrics.StatusOK, "")
 	}
+	return err
+}
+
+// Get the delay before the next advertisement given the number of consecutive failures so far.
+// After a failure, retry with an exponential backoff and jitter instead of waiting a full
+// advertiseInterval, so that the federation re-converges quickly after a director restart
+func getAdvertiseDelay(consecutiveFailures int) time.Duration {
+	if consecutiveFailures <= 0 {
+		return advertiseInterval
+	}
+	backoff := advertiseRetryInitialBackoff
+	for i := 1; i < consecutiveFailures && backoff < advertiseInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > advertiseInterval {
+		backoff = advertiseInterval
+	}
+	// Jitter in [backoff/2, backoff) so that servers don't retry in lockstep
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
 }
 
 // Launch periodic advertise of xrootd servers (origin and cache) to the director, in the errogroup
 func LaunchPeriodicAdvertise(ctx context.Context, egrp *errgroup.Group, servers []server_structs.XRootDServer) error {
 	metrics.SetComponentHealthStatus(metrics.OriginCache_Federation, metrics.StatusWarning, "First attempt to advertise to the director...")
-	doAdvertise(ctx, servers)
+	consecutiveFailures := 0
+	recordAdvertiseResult := func(err error) {
+		if err == nil {
+			if consecutiveFailures >= advertiseFailureAlertThreshold {
+				log.Infof("XRootD server advertised to the director after %d consecutive failures", consecutiveFailures)
+			}
+			consecutiveFailures = 0
+		} else {
+			consecutiveFailures++
+			if consecutiveFailures >= advertiseFailureAlertThreshold {
+				log.Errorf("XRootD server has failed to advertise to the director %d times in a row and is not visible in the federation: %v", consecutiveFailures, err)
+			}
+		}
+		metrics.PelicanAdvertiseConsecutiveFailures.Set(float64(consecutiveFailures))
+	}
+	recordAdvertiseResult(doAdvertise(ctx, servers))
 
-	ticker := time.NewTicker(1 * time.Minute)
 	egrp.Go(func() error {
-
+		timer := time.NewTimer(getAdvertiseDelay(consecutiveFailures))
+		defer timer.Stop()
 		for {
 			select {
-			case <-ticker.C:
-				err := Advertise(ctx, servers)
-				if err != nil {
-					log.Warningln("XRootD server failed to advertise to the director:", err)
-					metrics.SetComponentHealthStatus(metrics.OriginCache_Federation, metrics.StatusCritical, fmt.Sprintf("XRootD server failed to advertise to the director: %v", err))
-				} else {
-					metrics.SetComponentHealthStatus(metrics.OriginCache_Federation, metrics.StatusOK, "")
+			case <-timer.C:
+				recordAdvertiseResult(doAdvertise(ctx, servers))
+				timer.Reset(getAdvertiseDelay(consecutiveFailures))
+			case result := <-advertiseRequests:
+				err := doAdvertise(ctx, servers)
+				recordAdvertiseResult(err)
+				result <- err
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
 				}
+				timer.Reset(getAdvertiseDelay(consecutiveFailures))
 			case <-ctx.Done():
 				log.Infoln("Periodic advertisement loop has been terminated")
 				return nil
 			}
-
-			doAdvertise(ctx, servers)
 		}
 	})
 
 	return nil
 }
 
+// A gin route handler for the director to request an immediate advertisement, e.g. after an admin
+// changed the server's registration. Responds once the advertisement completes
+func HandleAdvertiseRefresh(ctx *gin.Context) {
+	status, ok, err := token.Verify(ctx, token.AuthOption{
+		Sources: []token.TokenSource{token.Header},
+		Issuers: []token.TokenIssuer{token.FederationIssuer},
+		Scopes:  []token_scopes.TokenScope{token_scopes.Pelican_AdvertiseRefresh},
+	})
+	if !ok || err != nil {
+		ctx.JSON(status, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Failed to verify the token: ", err),
+		})
+		return
+	}
+
+	if err = RequestAdvertise(ctx); errors.Is(err, errNotAdvertising) {
+		ctx.JSON(http.StatusServiceUnavailable, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error(),
+		})
+		return
+	} else if err != nil {
+		log.Warningln("Advertisement requested by the director failed:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Failed to advertise to the director: ", err),
+		})
+		return
+	}
+	log.Infoln("Advertised to the director on request")
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
+}
+
+// Request an immediate advertisement from the periodic advertisement loop and wait for its
+// result, up to advertiseRefreshTimeout
+func RequestAdvertise(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, advertiseRefreshTimeout)
+	defer cancel()
+	result := make(chan error, 1)
+	select {
+	case advertiseRequests <- result:
+	case <-timeoutCtx.Done():
+		return errNotAdvertising
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-timeoutCtx.Done():
+		return errors.New("timed out waiting for the advertisement")
+	}
+}
+
 // Advertise ONCE the xrootd servers (origin and cache) to the director
 func Advertise(ctx context.Context, servers []server_structs.XRootDServer) error {
 	var firstErr error
@@ -212,6 +323,8 @@ func advertiseInternal(ctx context.Context, server server_structs.XRootDServer)
 		advTokenCfg.Subject = "origin"
 	}
 	advTokenCfg.AddScopes(token_scopes.Pelican_Advertise)
+	// Sign the advertisement itself, so it can't be replayed with another body
+	advTokenCfg.Claims = map[string]string{server_structs.AdvertisementDigestClaim: server_structs.AdvertisementDigest(body)}
 
 	// CreateToken also handles validation for us
 	tok, err := advTokenCfg.CreateToken()
@@ -25,6 +25,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -84,3 +85,22 @@ func TestGetSitenameFromReg(t *testing.T) {
 		assert.Equal(t, "bar", sitename)
 	})
 }
+
+func TestGetAdvertiseDelay(t *testing.T) {
+	assert.Equal(t, advertiseInterval, getAdvertiseDelay(0))
+
+	prevMax := time.Duration(0)
+	for failures := 1; failures <= 10; failures++ {
+		delay := getAdvertiseDelay(failures)
+		// Retries come sooner than the regular advertisement and never exceed it
+		assert.Greater(t, delay, time.Duration(0))
+		assert.Less(t, delay, advertiseInterval)
+		maxDelay := advertiseRetryInitialBackoff << (failures - 1)
+		if maxDelay > advertiseInterval {
+			maxDelay = advertiseInterval
+		}
+		assert.GreaterOrEqual(t, delay, maxDelay/2)
+		assert.GreaterOrEqual(t, maxDelay, prevMax)
+		prevMax = maxDelay
+	}
+}
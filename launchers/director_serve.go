@@ -32,25 +32,41 @@ import (
 	"github.com/pelicanplatform/pelican/director"
 	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/web_ui"
 )
 
 func DirectorServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group) error {
 
 	log.Info("Initializing Director GeoIP database...")
-	director.InitializeDB(ctx)
+	if err := director.InitializeDB(ctx); err != nil {
+		return err
+	}
 
 	director.ConfigFilterdServers()
 
+	director.LoadFilteredServers()
+
+	if err := director.ConfigNamespaceFilters(); err != nil {
+		return err
+	}
+	director.LaunchNamespaceFilterReload(ctx, egrp)
+
 	director.LaunchTTLCache(ctx, egrp)
 
 	director.LaunchMapMetrics(ctx, egrp)
 
 	director.LaunchServerCountMetric(ctx, egrp)
 
-	director.ConfigFilterdServers()
-
 	director.LaunchServerIOQuery(ctx, egrp)
 
+	director.LaunchNamespaceAliasFetch(ctx, egrp)
+
+	director.LaunchRedirectPolicyFetch(ctx, egrp)
+
+	director.LaunchNamespaceStatsPush(ctx, egrp)
+
+	director.LaunchBrokerHeartbeatQuery(ctx, egrp)
+
 	if config.GetPreferredPrefix() == config.OsdfPrefix {
 		metrics.SetComponentHealthStatus(metrics.DirectorRegistry_Topology, metrics.StatusWarning, "Start requesting from topology, status unknown")
 		log.Info("Generating/advertising server ads from OSG topology service...")
@@ -71,13 +87,16 @@ func DirectorServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group
 			" but you provided %q. Was there a typo?", defaultResponse)
 	}
 	log.Debugf("The director will redirect to %ss by default", defaultResponse)
+	if code := param.Director_RedirectStatusCode.GetInt(); !director.IsValidRedirectStatusCode(code) {
+		return fmt.Errorf("the director's redirect status code must be 302, 307, or 308, but you provided %d", code)
+	}
 	if param.Director_SupportContactUrl.IsSet() {
 		_, err := url.Parse(param.Director_SupportContactUrl.GetString())
 		if err != nil {
 			return errors.Wrap(err, "invalid URL for Director.SupportContactUrl")
 		}
 	}
-	rootGroup := engine.Group("/")
+	rootGroup := engine.Group("/", web_ui.RequestLoggingMiddleware)
 	director.RegisterDirectorOIDCAPI(rootGroup)
 	director.RegisterDirectorWebAPI(rootGroup)
 	engine.Use(director.ShortcutMiddleware(defaultResponse))
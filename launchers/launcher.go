@@ -36,10 +36,12 @@ import (
 
 	"github.com/pelicanplatform/pelican/broker"
 	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/director"
 	"github.com/pelicanplatform/pelican/launcher_utils"
 	"github.com/pelicanplatform/pelican/local_cache"
 	"github.com/pelicanplatform/pelican/origin"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/registry"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/web_ui"
@@ -96,6 +98,8 @@ func LaunchModules(ctx context.Context, modules config.ServerType) (servers []se
 		err = errors.Wrap(err, "Failure when configuring the server")
 		return
 	}
+	// Must come before any route is registered, as gin only applies middleware to later routes
+	engine.Use(web_ui.NewCORSMiddleware())
 
 	// Set up necessary APIs to support Web UI, including auth and metrics
 	if err = web_ui.ConfigureServerWebAPI(ctx, engine, egrp); err != nil {
@@ -119,6 +123,7 @@ func LaunchModules(ctx context.Context, modules config.ServerType) (servers []se
 		if err = RegistryServe(ctx, engine, egrp); err != nil {
 			return
 		}
+		web_ui.RegisterReadinessCheck("registry", registry.CheckReadiness)
 	}
 
 	if modules.IsEnabled(config.BrokerType) {
@@ -134,6 +139,7 @@ func LaunchModules(ctx context.Context, modules config.ServerType) (servers []se
 		if err = DirectorServe(ctx, engine, egrp); err != nil {
 			return
 		}
+		web_ui.RegisterReadinessCheck("director", director.CheckReadiness)
 	}
 
 	// Start listening on the socket.  If `Server.WebPort` is 0, then a random port will be
@@ -226,6 +232,12 @@ func LaunchModules(ctx context.Context, modules config.ServerType) (servers []se
 		if err = OriginServeFinish(ctx, egrp); err != nil {
 			return
 		}
+
+		// Don't advertise an origin that can't serve its own namespaces
+		log.Info("Checking the origin's storage before advertising to the director")
+		if err = origin.WaitUntilSelfTestPasses(ctx); err != nil {
+			return
+		}
 	}
 
 	fedInfo, err := config.GetFederation(ctx)
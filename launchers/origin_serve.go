@@ -70,6 +70,16 @@ func OriginServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group,
 		return nil, errors.Wrap(err, "failed to initialize origin exports")
 	}
 
+	exportPrefixes := make([]string, 0, len(originExports))
+	for _, export := range originExports {
+		exportPrefixes = append(exportPrefixes, export.FederationPrefix)
+	}
+	if err := metrics.ConfigureAccessLog(ctx, egrp, exportPrefixes); err != nil {
+		return nil, err
+	}
+
+	origin.LaunchAbandonedUploadCleanup(ctx, egrp)
+
 	if param.Origin_StorageType.GetString() == string(server_structs.OriginStorageGlobus) {
 		if err := origin.InitGlobusBackend(originExports); err != nil {
 			return nil, errors.Wrap(err, "failed to initialize Globus backend")
@@ -81,6 +91,8 @@ func OriginServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group,
 	if err = origin.RegisterOriginAPI(engine, ctx, egrp); err != nil {
 		return nil, err
 	}
+	// Let the director request an immediate advertisement of the origin
+	engine.POST("/api/v1.0/origin/advertise", launcher_utils.HandleAdvertiseRefresh)
 
 	// Set up the APIs for the origin UI
 	if err = origin.RegisterOriginWebAPI(engine); err != nil {
@@ -141,10 +153,35 @@ func OriginServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group,
 	// delayed until after the viper config is done.
 	xrootd.LaunchXrootdMaintenance(ctx, originServer, 2*time.Minute)
 	origin.LaunchOriginFileTestMaintenance(ctx)
+	launchReadOnlyModeMaintenance(ctx, egrp, originServer)
 
 	return originServer, nil
 }
 
+// Apply the changes of the origin's read-only mode right away: regenerate the XRootD
+// authorization, which XRootD reloads within Xrootd.AuthRefreshInterval, and re-advertise
+// to the director instead of waiting for the next periodic advertisement
+func launchReadOnlyModeMaintenance(ctx context.Context, egrp *errgroup.Group, originServer *origin.OriginServer) {
+	egrp.Go(func() error {
+		for {
+			select {
+			case <-origin.ReadOnlyModeUpdates():
+				if err := xrootd.EmitAuthfile(originServer); err != nil {
+					log.Errorln("Failed to regenerate the authfile after the read-only mode changed:", err)
+				}
+				if err := xrootd.EmitScitokensConfig(originServer); err != nil {
+					log.Errorln("Failed to regenerate the scitokens configuration after the read-only mode changed:", err)
+				}
+				if err := launcher_utils.RequestAdvertise(ctx); err != nil {
+					log.Warningln("Failed to advertise to the director after the read-only mode changed:", err)
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+}
+
 // Finish configuration of the origin server.  To be invoked after the web UI components
 // have been launched.
 func OriginServeFinish(ctx context.Context, egrp *errgroup.Group) error {
@@ -30,6 +30,7 @@ import (
 	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/registry"
+	"github.com/pelicanplatform/pelican/web_ui"
 )
 
 func RegistryServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group) error {
@@ -41,6 +42,10 @@ func RegistryServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group
 		return errors.Wrap(err, "Unable to initialize the namespace registry database")
 	}
 
+	if err := registry.InitNamespaceAliases(); err != nil {
+		return err
+	}
+
 	if param.Server_EnableUI.GetBool() {
 		registry.InitOptionsCache(ctx, egrp)
 
@@ -64,7 +69,7 @@ func RegistryServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group
 		go registry.PeriodicTopologyReload(ctx)
 	}
 
-	rootRouterGroup := engine.Group("/")
+	rootRouterGroup := engine.Group("/", web_ui.RequestLoggingMiddleware)
 	// Register routes for server/Pelican client facing APIs
 	registry.RegisterRegistryAPI(rootRouterGroup)
 	// Register routes for APIs to registry Web UI
@@ -75,6 +80,12 @@ func RegistryServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group
 	// Launch registry prometheus metrics
 	registry.LaunchRegistryMetrics(ctx, egrp)
 
+	// Prune retired namespace keys once their grace period passes
+	registry.LaunchKeyPruning(ctx, egrp)
+
+	// Deliver namespace lifecycle events to the configured webhooks
+	registry.LaunchWebhookNotifier(ctx, egrp)
+
 	egrp.Go(func() error {
 		<-ctx.Done()
 		return registry.ShutdownRegistryDB()
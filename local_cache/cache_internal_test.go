@@ -21,10 +21,20 @@
 package local_cache
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/jellydator/ttlcache/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/pelicanplatform/pelican/client"
+	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/token_scopes"
 )
 
@@ -81,3 +91,116 @@ func TestCalcResources(t *testing.T) {
 		assert.Equal(t, test.result, result)
 	}
 }
+
+// Objects past their TTL hint are evicted before any other object under pressure, even when
+// they were used more recently
+func TestPurgeHonorsTTLHints(t *testing.T) {
+	lc := &LocalCache{
+		basePath:  t.TempDir(),
+		highWater: 250,
+		lowWater:  200,
+		lruLookup: make(map[string]*lruEntry),
+	}
+	addObject := func(objectPath string, lastUse time.Time, expiry time.Time) {
+		localPath := filepath.Join(lc.basePath, objectPath)
+		require.NoError(t, os.WriteFile(localPath, make([]byte, 100), 0600))
+		require.NoError(t, os.WriteFile(localPath+".DONE", nil, 0600))
+		lc.lruHit(lruEntry{lastUse: lastUse, path: objectPath, size: 100, expiry: expiry})
+	}
+	exists := func(objectPath string) bool {
+		_, err := os.Stat(filepath.Join(lc.basePath, objectPath))
+		return err == nil
+	}
+
+	now := time.Now()
+	addObject("/long-ttl", now.Add(-time.Hour), now.Add(time.Hour))
+	addObject("/short-ttl", now, now.Add(-time.Second))
+	assert.True(t, exists("/long-ttl"))
+	assert.True(t, exists("/short-ttl"))
+
+	// Going over the high water mark purges down to the low water mark
+	addObject("/no-ttl", now, time.Time{})
+	assert.False(t, exists("/short-ttl"))
+	assert.True(t, exists("/long-ttl"))
+	assert.True(t, exists("/no-ttl"))
+	assert.Equal(t, uint64(200), lc.cacheSize)
+
+	// Without expired objects, the least recently used object goes first
+	addObject("/another", now, time.Time{})
+	assert.False(t, exists("/long-ttl"))
+	assert.True(t, exists("/no-ttl"))
+	assert.True(t, exists("/another"))
+}
+
+func TestGetExpiry(t *testing.T) {
+	lc := &LocalCache{ac: &authConfig{}}
+	nsAds := []server_structs.NamespaceAdV2{
+		{Path: "/foo", CacheTTL: 60},
+		{Path: "/foo/bar", CacheTTL: 600},
+		{Path: "/baz"},
+		{Path: "/foo/immutable", CacheTTL: 60, Caps: server_structs.Capabilities{Immutable: true}},
+	}
+	lc.ac.ns.Store(&nsAds)
+	withHint := func(ttlHint time.Duration) client.TransferResults {
+		return client.TransferResults{Attempts: []client.TransferResult{{TTLHint: ttlHint}}}
+	}
+
+	// The object's own hint takes precedence over the namespace default
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), lc.getExpiry("/foo/obj", withHint(10*time.Second)), time.Second)
+	assert.WithinDuration(t, time.Now(), lc.getExpiry("/foo/obj", withHint(0)), time.Second)
+	// The longest matching namespace gives the default
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lc.getExpiry("/foo/obj", withHint(-1)), time.Second)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), lc.getExpiry("/foo/bar/obj", client.TransferResults{}), time.Second)
+	assert.True(t, lc.getExpiry("/foobar/obj", withHint(-1)).IsZero())
+	assert.True(t, lc.getExpiry("/baz/obj", withHint(-1)).IsZero())
+	// Objects of immutable namespaces never expire
+	assert.True(t, lc.getExpiry("/foo/immutable/obj", withHint(10*time.Second)).IsZero())
+	assert.True(t, lc.getExpiry("/foo/immutable/obj", withHint(-1)).IsZero())
+}
+
+// Once fetched, an object of an immutable namespace is served from disk without a round-trip
+// to the origin, and its TTL hint doesn't make it the first to go under space pressure
+func TestImmutableServedFromCache(t *testing.T) {
+	lc := &LocalCache{
+		basePath:  t.TempDir(),
+		highWater: 250,
+		lowWater:  200,
+		lruLookup: make(map[string]*lruEntry),
+		hitChan:   make(chan lruEntry, 1),
+		ac:        &authConfig{},
+	}
+	// No director is configured: any attempt to reach the origin fails the test
+	lc.ac.tokenAuthz = ttlcache.New[string, acls](
+		ttlcache.WithLoader[string, acls](ttlcache.LoaderFunc[string, acls](lc.ac.loader)),
+	)
+	nsAds := []server_structs.NamespaceAdV2{
+		{Path: "/immutable", CacheTTL: 60, Caps: server_structs.Capabilities{PublicReads: true, Immutable: true}},
+		{Path: "/mutable", CacheTTL: 60, Caps: server_structs.Capabilities{PublicReads: true}},
+	}
+	require.NoError(t, lc.ac.updateConfig(nsAds))
+	// The origin asked for the objects not to be cached any longer
+	expiredHint := client.TransferResults{Attempts: []client.TransferResult{{TTLHint: 0}}}
+	addObject := func(objectPath string, lastUse time.Time) {
+		localPath := filepath.Join(lc.basePath, objectPath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(localPath), 0700))
+		require.NoError(t, os.WriteFile(localPath, []byte(fmt.Sprintf("%-100s", objectPath)), 0600))
+		require.NoError(t, os.WriteFile(localPath+".DONE", nil, 0600))
+		lc.lruHit(lruEntry{lastUse: lastUse, path: objectPath, size: 100, expiry: lc.getExpiry(objectPath, expiredHint)})
+	}
+
+	now := time.Now()
+	addObject("/immutable/obj", now.Add(-time.Hour))
+	addObject("/mutable/obj", now)
+	// Going over the high water mark evicts the expired mutable object, even though the
+	// immutable one was used less recently
+	addObject("/mutable/another", now)
+	assert.Nil(t, lc.getFromDisk("/mutable/obj"))
+
+	reader, err := lc.Get(context.Background(), "/immutable/obj", "")
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.Equal(t, "*os.File", fmt.Sprintf("%T", reader))
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%-100s", "/immutable/obj"), string(content))
+}
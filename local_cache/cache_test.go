@@ -57,6 +57,9 @@ var (
 
 	//go:embed resources/auth-origin-cfg.yml
 	authOriginCfg string
+
+	//go:embed resources/immutable-origin-cfg.yml
+	immutableOriginCfg string
 )
 
 // Setup a federation, invoke "get" through the local cache module
@@ -87,6 +90,31 @@ func TestFedPublicGet(t *testing.T) {
 	assert.Equal(t, "Hello, World!", string(byteBuff))
 }
 
+// Once fetched, an object of an immutable namespace is served by the local cache
+// without going back to the origin, even after the origin lost it
+func TestFedImmutableGet(t *testing.T) {
+	viper.Reset()
+	ft := fed_test_utils.NewFedTest(t, immutableOriginCfg)
+
+	lc, err := local_cache.NewLocalCache(ft.Ctx, ft.Egrp)
+	require.NoError(t, err)
+
+	reader, err := lc.Get(context.Background(), "/test/hello_world.txt", "")
+	require.NoError(t, err)
+	byteBuff, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, World!", string(byteBuff))
+
+	require.NoError(t, os.Remove(filepath.Join(ft.Exports[0].StoragePrefix, "hello_world.txt")))
+
+	reader, err = lc.Get(context.Background(), "/test/hello_world.txt", "")
+	require.NoError(t, err)
+	assert.Equal(t, "*os.File", fmt.Sprintf("%T", reader))
+	byteBuff, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, World!", string(byteBuff))
+}
+
 // Test the local cache library on an authenticated GET.
 func TestFedAuthGet(t *testing.T) {
 	viper.Reset()
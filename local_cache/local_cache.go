@@ -30,6 +30,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -79,6 +80,10 @@ type (
 		lastUse time.Time
 		path    string
 		size    int64
+		// When the object's TTL hint from the origin runs out; zero if there was no hint
+		expiry time.Time
+		// Whether the entry was past its expiry when the purge started
+		expired bool
 	}
 
 	lru []*lruEntry
@@ -189,7 +194,15 @@ func (lru lru) Len() int {
 	return len(lru)
 }
 
+// Entries past their TTL are evicted first, soonest expired first; the rest are evicted
+// least recently used first
 func (lru lru) Less(i, j int) bool {
+	if lru[i].expired != lru[j].expired {
+		return lru[i].expired
+	}
+	if lru[i].expired {
+		return lru[i].expiry.Before(lru[j].expiry)
+	}
 	return lru[i].lastUse.Before(lru[j].lastUse)
 }
 
@@ -454,7 +467,7 @@ func (sc *LocalCache) runMux() error {
 				} else {
 					fp.Close()
 				}
-				sc.lruHit(lruEntry{lastUse: time.Now(), path: reqPath, size: results.TransferredBytes})
+				sc.lruHit(lruEntry{lastUse: time.Now(), path: reqPath, size: results.TransferredBytes, expiry: sc.getExpiry(reqPath, results)})
 			}
 		} else if chosen == lenChan+2 {
 			// Ticker has fired - update progress
@@ -605,14 +618,61 @@ func (lc *LocalCache) lruHit(hit lruEntry) {
 	if hit.size > entry.size {
 		entry.size = hit.size
 	}
+	if !hit.expiry.IsZero() {
+		entry.expiry = hit.expiry
+	}
+}
+
+// Get when the newly downloaded object should expire from the cache: from the TTL hint the
+// server sent with the object, else from the default TTL of the object's namespace. Returns
+// the zero time if neither gives a hint, or if the namespace is immutable: its objects can't
+// go stale, so they stay until evicted to make room or purged
+func (lc *LocalCache) getExpiry(objectPath string, results client.TransferResults) time.Time {
+	nsAd := lc.getNamespaceAd(objectPath)
+	if nsAd != nil && nsAd.Caps.Immutable {
+		return time.Time{}
+	}
+	if len(results.Attempts) > 0 {
+		if ttlHint := results.Attempts[len(results.Attempts)-1].TTLHint; ttlHint >= 0 {
+			return time.Now().Add(ttlHint)
+		}
+	}
+	if nsAd == nil || nsAd.CacheTTL <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(nsAd.CacheTTL) * time.Second)
+}
+
+// Get the advertisement of the most specific namespace containing the object; nil if none is known
+func (lc *LocalCache) getNamespaceAd(objectPath string) *server_structs.NamespaceAdV2 {
+	nsAds := lc.ac.ns.Load()
+	if nsAds == nil {
+		return nil
+	}
+	objectPath = path.Clean(objectPath)
+	var best *server_structs.NamespaceAdV2
+	for idx := range *nsAds {
+		nsAd := &(*nsAds)[idx]
+		nsPath := strings.TrimSuffix(nsAd.Path, "/")
+		if objectPath != nsPath && !strings.HasPrefix(objectPath, nsPath+"/") {
+			continue
+		}
+		if best == nil || len(nsAd.Path) > len(best.Path) {
+			best = nsAd
+		}
+	}
+	return best
 }
 
 func (lc *LocalCache) purge() (err error) {
 	log.Debugln("Starting purge routine")
 	lc.purgeMutex.Lock()
 	defer lc.purgeMutex.Unlock()
-	heap.Init(&lc.lru)
 	start := time.Now()
+	for _, entry := range lc.lru {
+		entry.expired = !entry.expiry.IsZero() && entry.expiry.Before(start)
+	}
+	heap.Init(&lc.lru)
 	log.Debugf("Purge running with cache size %d and low watermark of %d", lc.cacheSize, lc.lowWater)
 	for lc.cacheSize > lc.lowWater {
 		if len(lc.lru) == 0 {
@@ -0,0 +1,240 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+type (
+	// An entry of the origin access log, written as a line of JSON when XRootD reports a file was closed
+	// or logs that it refused a request. The subject comes from the claims XRootD reports in its token
+	// monitoring records; the token itself never reaches the monitoring stream and so can't be logged.
+	// XRootD doesn't log who made the requests it refused, so their entries have no subject
+	AccessLogEntry struct {
+		Time         time.Time `json:"time"`
+		Path         string    `json:"path"`
+		Namespace    string    `json:"namespace"`
+		Subject      string    `json:"subject,omitempty"`
+		AuthProtocol string    `json:"auth_protocol,omitempty"`
+		ReadBytes    uint64    `json:"read_bytes"`
+		WriteBytes   uint64    `json:"write_bytes"`
+		Status       string    `json:"status"` // AccessStatusClosed, AccessStatusForced if XRootD closed the file itself, e.g. on a disconnect, or AccessStatusFailed
+		StatusCode   int       `json:"status_code"`
+		Error        string    `json:"error,omitempty"`
+	}
+
+	accessLogger struct {
+		mutex       sync.Mutex
+		encoder     *json.Encoder
+		subjectMode string
+		namespaces  []string
+	}
+)
+
+const (
+	AccessLogSubjectPlain  = "plain"
+	AccessLogSubjectHash   = "hash"
+	AccessLogSubjectRedact = "redact"
+
+	AccessStatusClosed = "closed"
+	AccessStatusForced = "forced-close"
+	AccessStatusFailed = "failed"
+
+	redactedSubject = "[redacted]"
+)
+
+var (
+	accessLog      *accessLogger
+	accessLogMutex sync.RWMutex
+
+	// A file system operation XRootD failed, which it logs for every request it refuses, e.g.
+	// "240102 10:11:12 1234 ofs_open: unknown.1:23@host Unable to open /foo/bar; no such file or directory"
+	xrootdOfsErrorRegex = regexp.MustCompile(`^(\d{6} \d{2}:\d{2}:\d{2}) \d+ ofs_\w+: \S+ Unable to \S+ (.+); ([^;]+)$`)
+)
+
+// Set up the origin access log at Origin.AccessLogLocation, logging the transfers of the objects
+// under the given namespace prefixes. The access log is disabled if the location is empty
+func ConfigureAccessLog(ctx context.Context, egrp *errgroup.Group, namespaces []string) error {
+	location := param.Origin_AccessLogLocation.GetString()
+	if location == "" {
+		return nil
+	}
+	subjectMode := param.Origin_AccessLogSubject.GetString()
+	switch subjectMode {
+	case AccessLogSubjectPlain, AccessLogSubjectHash, AccessLogSubjectRedact:
+	default:
+		return errors.Errorf("Invalid value '%s' for Origin.AccessLogSubject. Valid values are '%s', '%s' and '%s'",
+			subjectMode, AccessLogSubjectPlain, AccessLogSubjectHash, AccessLogSubjectRedact)
+	}
+
+	file, err := os.OpenFile(location, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the access log at %s", location)
+	}
+	setAccessLog(file, subjectMode, namespaces)
+	log.Infof("Logging the origin's transfers to %s", location)
+
+	egrp.Go(func() error {
+		<-ctx.Done()
+		setAccessLog(nil, "", nil)
+		return file.Close()
+	})
+	return nil
+}
+
+// Direct the access log to the writer, or disable it if the writer is nil
+func setAccessLog(out io.Writer, subjectMode string, namespaces []string) {
+	accessLogMutex.Lock()
+	defer accessLogMutex.Unlock()
+	if out == nil {
+		accessLog = nil
+		return
+	}
+	accessLog = &accessLogger{encoder: json.NewEncoder(out), subjectMode: subjectMode, namespaces: namespaces}
+}
+
+// Find the longest namespace prefix containing the object path, or "" if none does
+func (logger *accessLogger) namespaceFor(objectPath string) string {
+	objectPath = path.Clean(objectPath)
+	best := ""
+	for _, namespace := range logger.namespaces {
+		namespace = path.Clean(namespace)
+		if objectPath != namespace && !strings.HasPrefix(objectPath, strings.TrimSuffix(namespace, "/")+"/") {
+			continue
+		}
+		if len(namespace) > len(best) {
+			best = namespace
+		}
+	}
+	return best
+}
+
+func (logger *accessLogger) subjectFor(subject string) string {
+	if subject == "" {
+		return ""
+	}
+	switch logger.subjectMode {
+	case AccessLogSubjectHash:
+		digest := sha256.Sum256([]byte(subject))
+		return "sha256:" + hex.EncodeToString(digest[:])
+	case AccessLogSubjectRedact:
+		return redactedSubject
+	default:
+		return subject
+	}
+}
+
+// Write an access log entry for a closed file, if the access log is enabled
+func logAccess(file FileRecord, user *UserRecord, readBytes, writeBytes uint64, forced bool) {
+	accessLogMutex.RLock()
+	defer accessLogMutex.RUnlock()
+	if accessLog == nil {
+		return
+	}
+
+	entry := AccessLogEntry{
+		Time:       time.Now().UTC(),
+		Path:       file.Lfn,
+		Namespace:  accessLog.namespaceFor(file.Lfn),
+		ReadBytes:  readBytes,
+		WriteBytes: writeBytes,
+		Status:     AccessStatusClosed,
+		StatusCode: http.StatusOK,
+	}
+	// Uploads are answered with a 201 Created
+	if writeBytes > 0 && readBytes == 0 {
+		entry.StatusCode = http.StatusCreated
+	}
+	if forced {
+		entry.Status = AccessStatusForced
+	}
+	if user != nil {
+		entry.Subject = accessLog.subjectFor(user.DN)
+		entry.AuthProtocol = user.AuthenticationProtocol
+	}
+	accessLog.write(entry)
+}
+
+// Write an access log entry for a request XRootD refused, given a line of the origin's XRootD log,
+// if the access log is enabled. Any other line is ignored
+func LogXrootdAccessFailure(line string) {
+	match := xrootdOfsErrorRegex.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	accessLogMutex.RLock()
+	defer accessLogMutex.RUnlock()
+	if accessLog == nil {
+		return
+	}
+
+	// XRootD logs in local time
+	logTime, err := time.ParseInLocation("060102 15:04:05", match[1], time.Local)
+	if err != nil {
+		logTime = time.Now()
+	}
+	accessLog.write(AccessLogEntry{
+		Time:       logTime.UTC(),
+		Path:       match[2],
+		Namespace:  accessLog.namespaceFor(match[2]),
+		Status:     AccessStatusFailed,
+		StatusCode: xrootdErrorStatusCode(match[3]),
+		Error:      match[3],
+	})
+}
+
+// Get the HTTP status XRootD answers a request with, given the error it logged for the request
+func xrootdErrorStatusCode(xrootdErr string) int {
+	switch xrootdErr {
+	case "no such file or directory":
+		return http.StatusNotFound
+	case "permission denied", "operation not permitted":
+		return http.StatusForbidden
+	case "file exists":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (logger *accessLogger) write(entry AccessLogEntry) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if err := logger.encoder.Encode(entry); err != nil {
+		log.Warningf("Failed to write the access log entry for %s: %v", entry.Path, err)
+	}
+}
@@ -0,0 +1,172 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	mockFileID := uint32(1001)
+	mockSID := int64(143152967831384)
+	mockUserID := uint32(20)
+	t.Cleanup(func() {
+		setAccessLog(nil, "", nil)
+		transfers.DeleteAll()
+		sessions.DeleteAll()
+	})
+
+	// Transfer a file as the token subject and return the resulting access log entries
+	transfer := func(t *testing.T, subjectMode string, forced bool) []map[string]any {
+		buf := &bytes.Buffer{}
+		setAccessLog(buf, subjectMode, []string{"/first", "/first/nested", "/second"})
+		transfers.DeleteAll()
+		sessions.DeleteAll()
+		sessions.Set(UserId{Id: mockUserID}, UserRecord{AuthenticationProtocol: "ztn", DN: "alice", Org: "https://issuer.example.com"}, 0)
+
+		openPacket, err := mockFileOpenPacket(0, mockFileID, mockUserID, mockSID, "/first/nested/file.txt")
+		require.NoError(t, err)
+		closePacket, err := mockFileClosePacket(1, mockFileID, mockSID, mockStatOps(1, 1, 1, 1), 1000, 24, 7)
+		require.NoError(t, err)
+		if forced {
+			closePacket[8+24+1] |= 0x01 // XrdXrootdMonFileHdr::forced, after the header and the time record
+		}
+		require.NoError(t, HandlePacket(openPacket))
+		require.NoError(t, HandlePacket(closePacket))
+
+		entries := []map[string]any{}
+		decoder := json.NewDecoder(buf)
+		for decoder.More() {
+			entry := map[string]any{}
+			require.NoError(t, decoder.Decode(&entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	t.Run("logs-transfer", func(t *testing.T) {
+		entries := transfer(t, AccessLogSubjectPlain, false)
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		assert.ElementsMatch(t, []string{"time", "path", "namespace", "subject", "auth_protocol", "read_bytes", "write_bytes", "status", "status_code"}, mapKeys(entry))
+		assert.NotEmpty(t, entry["time"])
+		assert.Equal(t, "/first/nested/file.txt", entry["path"])
+		assert.Equal(t, "/first/nested", entry["namespace"])
+		assert.Equal(t, "alice", entry["subject"])
+		assert.Equal(t, "ztn", entry["auth_protocol"])
+		assert.EqualValues(t, 1024, entry["read_bytes"])
+		assert.EqualValues(t, 7, entry["write_bytes"])
+		assert.Equal(t, AccessStatusClosed, entry["status"])
+		assert.EqualValues(t, http.StatusOK, entry["status_code"])
+	})
+
+	t.Run("reports-forced-close", func(t *testing.T) {
+		entries := transfer(t, AccessLogSubjectPlain, true)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AccessStatusForced, entries[0]["status"])
+	})
+
+	t.Run("hashes-subject", func(t *testing.T) {
+		entries := transfer(t, AccessLogSubjectHash, false)
+		require.Len(t, entries, 1)
+		// The SHA-256 digest of "alice"
+		assert.Equal(t, "sha256:2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90", entries[0]["subject"])
+	})
+
+	t.Run("redacts-subject", func(t *testing.T) {
+		entries := transfer(t, AccessLogSubjectRedact, false)
+		require.Len(t, entries, 1)
+		assert.Equal(t, redactedSubject, entries[0]["subject"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		setAccessLog(nil, "", nil)
+		openPacket, err := mockFileOpenPacket(0, mockFileID, mockUserID, mockSID, "/first/file.txt")
+		require.NoError(t, err)
+		closePacket, err := mockFileClosePacket(1, mockFileID, mockSID, mockStatOps(1, 1, 1, 1), 1, 1, 1)
+		require.NoError(t, err)
+		require.NoError(t, HandlePacket(openPacket))
+		require.NoError(t, HandlePacket(closePacket))
+	})
+}
+
+func TestAccessLogFailures(t *testing.T) {
+	buf := &bytes.Buffer{}
+	setAccessLog(buf, AccessLogSubjectPlain, []string{"/first"})
+	t.Cleanup(func() { setAccessLog(nil, "", nil) })
+
+	LogXrootdAccessFailure("240102 10:11:12 1234 ofs_stat: unknown.1:23@[::1] Unable to stat /first/missing.txt; no such file or directory")
+	LogXrootdAccessFailure("240102 10:11:13 1234 ofs_open: unknown.1:24@[::1] Unable to open /first/private file.txt; permission denied")
+	LogXrootdAccessFailure("240102 10:11:14 1234 ofs_open: unknown.1:25@[::1] Unable to create /second/file.txt; input/output error")
+	// Other lines of the XRootD log are ignored
+	LogXrootdAccessFailure("240102 10:11:15 1234 XrootdXeq: unknown.1:26@[::1] pub IP46 login as nobody")
+
+	entries := []map[string]any{}
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		entry := map[string]any{}
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 3)
+
+	assert.ElementsMatch(t, []string{"time", "path", "namespace", "read_bytes", "write_bytes", "status", "status_code", "error"}, mapKeys(entries[0]))
+	expectedTime := time.Date(2024, 1, 2, 10, 11, 12, 0, time.Local).UTC().Format(time.RFC3339Nano)
+	assert.Equal(t, expectedTime, entries[0]["time"])
+	assert.Equal(t, "/first/missing.txt", entries[0]["path"])
+	assert.Equal(t, "/first", entries[0]["namespace"])
+	assert.Equal(t, AccessStatusFailed, entries[0]["status"])
+	assert.EqualValues(t, http.StatusNotFound, entries[0]["status_code"])
+	assert.Equal(t, "no such file or directory", entries[0]["error"])
+
+	assert.Equal(t, "/first/private file.txt", entries[1]["path"])
+	assert.EqualValues(t, http.StatusForbidden, entries[1]["status_code"])
+
+	assert.Equal(t, "/second/file.txt", entries[2]["path"])
+	assert.Equal(t, "", entries[2]["namespace"])
+	assert.EqualValues(t, http.StatusInternalServerError, entries[2]["status_code"])
+
+	// Nothing is logged once the access log is disabled
+	setAccessLog(nil, "", nil)
+	LogXrootdAccessFailure("240102 10:11:16 1234 ofs_stat: unknown.1:27@[::1] Unable to stat /first/missing.txt; no such file or directory")
+}
+
+func TestAccessLogNamespace(t *testing.T) {
+	logger := &accessLogger{namespaces: []string{"/foo", "/foo/bar/", "/foobar"}}
+	assert.Equal(t, "/foo", logger.namespaceFor("/foo/baz"))
+	assert.Equal(t, "/foo/bar", logger.namespaceFor("/foo/bar/baz"))
+	assert.Equal(t, "/foobar", logger.namespaceFor("/foobar/baz"))
+	assert.Equal(t, "/foo", logger.namespaceFor("/foo"))
+	assert.Equal(t, "", logger.namespaceFor("/other/baz"))
+}
+
+func mapKeys(entry map[string]any) []string {
+	result := []string{}
+	for key := range entry {
+		result = append(result, key)
+	}
+	return result
+}
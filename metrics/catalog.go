@@ -0,0 +1,142 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type (
+	// The name, type, and labels of a metric exposed by Pelican
+	MetricInfo struct {
+		Name   string   `json:"name"`
+		Type   string   `json:"type"`
+		Help   string   `json:"help"`
+		Labels []string `json:"labels"`
+	}
+
+	catalogEntry struct {
+		collector prometheus.Collector
+		info      MetricInfo
+	}
+
+	// The metrics created through the factory, so they can be listed without scraping the server
+	metricsCatalog struct {
+		mutex   sync.Mutex
+		entries []catalogEntry
+	}
+
+	// Creates and registers the metrics of this package like promauto, recording the
+	// options of each metric in the catalog
+	catalogFactory struct {
+		promauto promauto.Factory
+	}
+)
+
+var (
+	catalog = &metricsCatalog{}
+
+	// Every metric of this package is created through the factory so it is part of the catalog
+	factory = catalogFactory{promauto: promauto.With(prometheus.DefaultRegisterer)}
+)
+
+// Build the catalog entry of a metric from the options it was created with
+func newMetricInfo(metricType, namespace, subsystem, name, help string, constLabels prometheus.Labels, labelNames []string) MetricInfo {
+	info := MetricInfo{
+		Name:   prometheus.BuildFQName(namespace, subsystem, name),
+		Type:   metricType,
+		Help:   help,
+		Labels: make([]string, 0, len(constLabels)+len(labelNames)),
+	}
+	for label := range constLabels {
+		info.Labels = append(info.Labels, label)
+	}
+	sort.Strings(info.Labels)
+	info.Labels = append(info.Labels, labelNames...)
+	return info
+}
+
+func (c *metricsCatalog) add(collector prometheus.Collector, info MetricInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = append(c.entries, catalogEntry{collector: collector, info: info})
+}
+
+// Unregister a collector created through the factory and remove it from the catalog
+func (c *metricsCatalog) Unregister(collector prometheus.Collector) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for idx, entry := range c.entries {
+		if entry.collector == collector {
+			c.entries = append(c.entries[:idx], c.entries[idx+1:]...)
+			break
+		}
+	}
+	return prometheus.DefaultRegisterer.Unregister(collector)
+}
+
+func (f catalogFactory) NewCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	counter := f.promauto.NewCounter(opts)
+	catalog.add(counter, newMetricInfo("counter", opts.Namespace, opts.Subsystem, opts.Name, opts.Help, opts.ConstLabels, nil))
+	return counter
+}
+
+func (f catalogFactory) NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	counterVec := f.promauto.NewCounterVec(opts, labelNames)
+	catalog.add(counterVec, newMetricInfo("counter", opts.Namespace, opts.Subsystem, opts.Name, opts.Help, opts.ConstLabels, labelNames))
+	return counterVec
+}
+
+func (f catalogFactory) NewGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	gauge := f.promauto.NewGauge(opts)
+	catalog.add(gauge, newMetricInfo("gauge", opts.Namespace, opts.Subsystem, opts.Name, opts.Help, opts.ConstLabels, nil))
+	return gauge
+}
+
+func (f catalogFactory) NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	gaugeVec := f.promauto.NewGaugeVec(opts, labelNames)
+	catalog.add(gaugeVec, newMetricInfo("gauge", opts.Namespace, opts.Subsystem, opts.Name, opts.Help, opts.ConstLabels, labelNames))
+	return gaugeVec
+}
+
+func (f catalogFactory) NewHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
+	histogramVec := f.promauto.NewHistogramVec(opts, labelNames)
+	catalog.add(histogramVec, newMetricInfo("histogram", opts.Namespace, opts.Subsystem, opts.Name, opts.Help, opts.ConstLabels, labelNames))
+	return histogramVec
+}
+
+// List the metrics Pelican exposes, sorted by name.  The list is generated from the
+// options of the metrics, so metrics with labels are included even before they have
+// been observed.
+func ListMetrics() []MetricInfo {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+
+	result := make([]MetricInfo, 0, len(catalog.entries))
+	for _, entry := range catalog.entries {
+		info := entry.info
+		info.Labels = append([]string{}, entry.info.Labels...)
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
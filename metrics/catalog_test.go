@@ -0,0 +1,85 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMetricInfo(t *testing.T) {
+	info := newMetricInfo("counter", "pelican", "test", "metric_total", `A "quoted" description`, prometheus.Labels{"component": "test", "a_label": "x"}, []string{"server_name", "status"})
+	assert.Equal(t, "pelican_test_metric_total", info.Name)
+	assert.Equal(t, "counter", info.Type)
+	assert.Equal(t, `A "quoted" description`, info.Help)
+	assert.Equal(t, []string{"a_label", "component", "server_name", "status"}, info.Labels)
+
+	info = newMetricInfo("gauge", "", "", "pelican_unlabelled", "No labels", nil, nil)
+	assert.Equal(t, "pelican_unlabelled", info.Name)
+	assert.Empty(t, info.Labels)
+}
+
+func TestListMetrics(t *testing.T) {
+	list := ListMetrics()
+	byName := map[string]MetricInfo{}
+	for _, info := range list {
+		byName[info.Name] = info
+	}
+
+	// Metrics with labels are listed even though nothing was observed yet
+	latency, ok := byName["pelican_director_redirect_latency_seconds"]
+	require.True(t, ok)
+	assert.Equal(t, "histogram", latency.Type)
+	assert.NotEmpty(t, latency.Labels)
+	assert.NotEmpty(t, latency.Help)
+
+	breaker, ok := byName["pelican_director_registry_breaker_state"]
+	require.True(t, ok)
+	assert.Equal(t, "gauge", breaker.Type)
+	assert.Empty(t, breaker.Labels)
+
+	connections, ok := byName["xrootd_server_connection_count"]
+	require.True(t, ok)
+	assert.Equal(t, "counter", connections.Type)
+
+	assert.IsIncreasing(t, func() []string {
+		names := make([]string, 0, len(list))
+		for _, info := range list {
+			names = append(names, info.Name)
+		}
+		return names
+	}())
+}
+
+func TestCatalogUnregister(t *testing.T) {
+	counter := factory.NewCounter(prometheus.CounterOpts{Name: "pelican_test_catalog_total", Help: "Test counter"})
+	assert.Contains(t, metricNames(), "pelican_test_catalog_total")
+	assert.True(t, catalog.Unregister(counter))
+	assert.NotContains(t, metricNames(), "pelican_test_catalog_total")
+}
+
+func metricNames() []string {
+	names := []string{}
+	for _, info := range ListMetrics() {
+		names = append(names, info.Name)
+	}
+	return names
+}
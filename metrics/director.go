@@ -20,13 +20,13 @@ package metrics
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type (
-	MetricSimpleStatus    string
-	DirectorFTXTestStatus MetricSimpleStatus
-	DirectorStatResult    string
+	MetricSimpleStatus      string
+	DirectorFTXTestStatus   MetricSimpleStatus
+	DirectorStatResult      string
+	DirectorJwksCacheResult string
 )
 
 const (
@@ -39,51 +39,106 @@ const (
 	StatCancelled DirectorStatResult = "Cancelled"
 	StatForbidden DirectorStatResult = "Forbidden"
 	StatUnkownErr DirectorStatResult = "UnknownErr"
+
+	JwksCacheHit         DirectorJwksCacheResult = "hit"         // Served from the cache without contacting the registry
+	JwksCacheRevalidated DirectorJwksCacheResult = "revalidated" // The registry responded 304 Not Modified
+	JwksCacheMiss        DirectorJwksCacheResult = "miss"        // The full key set was fetched from the registry
 )
 
 var (
-	PelicanDirectorFileTransferTestSuite = promauto.NewCounterVec(prometheus.CounterOpts{
+	PelicanDirectorFileTransferTestSuite = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "pelican_director_total_ftx_test_suite",
 		Help: "The total number of file transfer test suite the director issued. A new test suite is a new goroutine started at origin's advertisement to the director and is cancelled when such registration expired in director's TTL cache",
 	}, []string{"server_name", "server_web_url", "server_type"})
 
-	PelicanDirectorActiveFileTransferTestSuite = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanDirectorActiveFileTransferTestSuite = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_active_ftx_test_suite",
 		Help: "The number of active director file transfer test suite. The number of active goroutines that executes test run",
 	}, []string{"server_name", "server_web_url", "server_type"})
 
-	PelicanDirectorFileTransferTestsRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+	PelicanDirectorFileTransferTestsRuns = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "pelican_director_total_ftx_test_runs",
 		Help: "The number of file transfer test runs the director issued. A test run is a cycle of upload/download/delete test file, which is executed per 15s per origin (by defult)",
 	}, []string{"server_name", "server_web_url", "server_type", "status", "report_status"})
 
-	PelicanDirectorAdvertisementsRecievedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	PelicanDirectorAdvertisementsRecievedTotal = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "pelican_director_advertisements_received_total",
 		Help: "The total number of advertisement the director received from the origin and cache servers. Labelled by status_code, server_name, serve_type: Origin|Cache, server_web_url",
 	}, []string{"server_name", "server_web_url", "server_type", "status_code", "namespace_prefix"})
 
-	PelicanDirectorMapItemsTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanDirectorMapItemsTotal = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_map_items_total",
 		Help: "The total number of map items in the director, by the name of the map",
 	}, []string{"name"}) // name: healthTestUtils, filteredServers, originStatUtils
 
-	PelicanDirectorTTLCache = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanDirectorTTLCache = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_ttl_cache",
 		Help: "The statistics of various TTL caches",
 	}, []string{"name", "type"}) // name: serverAds, jwks; type: evictions, insersions, hits, misses, total
 
-	PelicanDirectorStatActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanDirectorStatActive = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_stat_active",
 		Help: "The active stat queries in the director",
 	}, []string{"server_name", "server_url", "server_type"})
 
-	PelicanDirectorStatTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanDirectorStatTotal = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_stat_total",
 		Help: "The total stat queries the director issues. The status can be Succeeded, Cancelled, Timeout, Forbidden, or UnknownErr",
 	}, []string{"server_name", "server_url", "server_type", "result"}) // result: see enums for DirectorStatResult
 
-	PelicanDirectorServerCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	PelicanDirectorServerCount = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "pelican_director_server_count",
 		Help: "Total number of servers, delineated by pelican/non-pelican and origin/cache",
 	}, []string{"server_name", "server_type", "server_url", "server_web_url", "server_lat", "server_long", "from_topology"})
+
+	PelicanDirectorJwksCacheRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_jwks_cache_requests_total",
+		Help: "The total number of namespace JWKS lookups by the director, by result: hit, revalidated, or miss. The cache hit ratio is (hit + revalidated) / total",
+	}, []string{"result"})
+
+	PelicanDirectorAdvertiseRateLimitedTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_advertise_ratelimited_total",
+		Help: "The total number of server advertisements rejected by the director for exceeding Director.AdvertiseRateLimit",
+	}, []string{"server_type", "limit_type"}) // limit_type: source_ip, server_name
+
+	PelicanDirectorAdvertisementsRejectedTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_advertisements_rejected_total",
+		Help: "The total number of server advertisements rejected by the director for not being signed by the server's registered key",
+	}, []string{"server_type", "reason"}) // reason: unsigned, invalid_signature
+
+	PelicanDirectorAdEvictionsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_ad_evictions_total",
+		Help: "The total number of server advertisements evicted from the director after their TTL expired without a re-advertisement",
+	}, []string{"server_type"})
+
+	PelicanDirectorAdvertiseFetchTimeoutsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_advertise_fetch_timeouts_total",
+		Help: "The total number of times a server didn't advertise within Director.AdvertiseFetchTimeout after the director asked it to, or the topology (server_type \"topology\") didn't respond within it",
+	}, []string{"server_type"})
+
+	PelicanDirectorRedirectLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pelican_director_redirect_latency_seconds",
+		Help:    "The time the director spends selecting the servers to redirect a client to: looking up the servers of the namespace, the GeoIP lookup of the client, then sorting and filtering the servers. The time waiting on object stats is excluded",
+		Buckets: []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25},
+	}, []string{"server_type"}) // server_type: Cache, Origin
+
+	PelicanDirectorNamespaceRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_namespace_requests_total",
+		Help: "The total number of object redirect requests to the director by the namespace prefix they matched, or \"other\" if none matched",
+	}, []string{"namespace"})
+
+	PelicanDirectorRegistryBreakerState = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_director_registry_breaker_state",
+		Help: "The state of the circuit breaker around the director's calls to the registry: 0 for closed, 1 for half-open, 2 for open",
+	})
+
+	PelicanDirectorRegistryBreakerRejectedTotal = factory.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_director_registry_breaker_rejected_total",
+		Help: "The total number of calls to the registry the director failed fast because the circuit breaker was open",
+	})
+
+	PelicanDirectorOriginInFlightRedirects = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_director_origin_inflight_redirects",
+		Help: "The number of redirects to an origin the director counts as in flight, i.e. sent within Director.OriginInFlightWindow",
+	}, []string{"server_name", "server_url"})
 )
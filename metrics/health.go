@@ -24,7 +24,6 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type (
@@ -74,21 +73,27 @@ const (
 	OriginCache_Director      HealthStatusComponent = "director"   // File transfer tests with director
 	OriginCache_Registry      HealthStatusComponent = "registry"   // Register namespace at the registry
 	DirectorRegistry_Topology HealthStatusComponent = "topology"   // Fetch data from OSDF topology
+	Origin_SelfTest           HealthStatusComponent = "self-test"  // Initial self-test before advertising to the director
 	Server_WebUI              HealthStatusComponent = "web-ui"
 )
 
 var (
 	healthStatus = sync.Map{} // In-memory map of component health status, key is HealthStatusComponent, value is componentStatusInternal
 
-	PelicanHealthStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanHealthStatus = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_component_health_status",
 		Help: "The health status of various components",
 	}, []string{"component"})
 
-	PelicanHealthLastUpdate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	PelicanHealthLastUpdate = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_component_health_status_last_update",
 		Help: "Last update timestamp of components health status",
 	}, []string{"component"})
+
+	PelicanAdvertiseConsecutiveFailures = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_advertise_consecutive_failures",
+		Help: "The number of consecutive failed attempts of the origin/cache to advertise to the director",
+	})
 )
 
 // Unfortunately we don't have a better way to ensure the enum constants always have
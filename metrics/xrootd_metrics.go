@@ -36,7 +36,6 @@ import (
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 
@@ -76,7 +75,8 @@ type (
 
 	FileRecord struct {
 		UserId     UserId
-		Path       string
+		Path       string // The monitoring prefix of the file, from Monitoring.AggregatePrefixes
+		Lfn        string // The full path of the file
 		ReadOps    uint32
 		ReadvOps   uint32
 		WriteOps   uint32
@@ -274,62 +274,62 @@ const (
 )
 
 var (
-	PacketsReceived = promauto.NewCounter(prometheus.CounterOpts{
+	PacketsReceived = factory.NewCounter(prometheus.CounterOpts{
 		Name: "xrootd_monitoring_packets_received",
 		Help: "The total number of monitoring UDP packets received",
 	})
 
-	TransferReadvSegs = promauto.NewCounterVec(prometheus.CounterOpts{
+	TransferReadvSegs = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "xrootd_transfer_readv_segments_count",
 		Help: "Number of segments in readv operations",
 	}, []string{"path", "ap", "dn", "role", "org", "proj", "network"})
 
-	TransferOps = promauto.NewCounterVec(prometheus.CounterOpts{
+	TransferOps = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "xrootd_transfer_operations_count",
 		Help: "Number of transfer operations performed",
 	}, []string{"path", "ap", "dn", "role", "org", "proj", "type", "network"})
 
-	TransferBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+	TransferBytes = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "xrootd_transfer_bytes",
 		Help: "Bytes of transfers",
 	}, []string{"path", "ap", "dn", "role", "org", "proj", "type", "network"})
 
-	Threads = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Threads = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "xrootd_sched_thread_count",
 		Help: "Number of scheduler threads",
 	}, []string{"state"})
 
-	Connections = promauto.NewCounter(prometheus.CounterOpts{
+	Connections = factory.NewCounter(prometheus.CounterOpts{
 		Name: "xrootd_server_connection_count",
 		Help: "Aggregate number of server connections",
 	})
 
-	BytesXfer = promauto.NewCounterVec(prometheus.CounterOpts{
+	BytesXfer = factory.NewCounterVec(prometheus.CounterOpts{
 		Name: "xrootd_server_bytes",
 		Help: "Number of bytes read into the server",
 	}, []string{"direction"})
 
-	StorageVolume = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	StorageVolume = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "xrootd_storage_volume_bytes",
 		Help: "Storage volume usage on the server",
 	}, []string{"ns", "type", "server_type"}) // type: total/free; server_type: origin/cache
 
-	CacheAccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	CacheAccess = factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "xrootd_cache_access_bytes",
 		Help: "Number of bytes the data requested is in the cache or not",
 	}, []string{"path", "type"}) // type: hit/miss/bypass
 
-	ServerTotalIO = promauto.NewCounter(prometheus.CounterOpts{
+	ServerTotalIO = factory.NewCounter(prometheus.CounterOpts{
 		Name: "xrootd_server_io_total",
 		Help: "Total storage operations in origin/cache server",
 	})
 
-	ServerActiveIO = promauto.NewGauge(prometheus.GaugeOpts{
+	ServerActiveIO = factory.NewGauge(prometheus.GaugeOpts{
 		Name: "xrootd_server_io_active",
 		Help: "Number of ongoing storage operations in origin/cache server",
 	})
 
-	ServerIOWaitTime = promauto.NewCounter(prometheus.CounterOpts{
+	ServerIOWaitTime = factory.NewCounter(prometheus.CounterOpts{
 		Name: "xrootd_server_io_wait_time",
 		Help: "The aggregate time spent in storage operations in origin/cache server",
 	})
@@ -617,7 +617,7 @@ func HandlePacket(packet []byte) error {
 		}
 		path := computePrefix(rest, monitorPaths)
 		if useridItem := userids.Get(xrdUserId); useridItem != nil {
-			transfers.Set(fileid, FileRecord{UserId: useridItem.Value(), Path: path}, ttlcache.DefaultTTL)
+			transfers.Set(fileid, FileRecord{UserId: useridItem.Value(), Path: path, Lfn: rest}, ttlcache.DefaultTTL)
 		}
 	case 'f':
 		log.Debug("HandlePacket: Received a f-stream packet")
@@ -676,6 +676,16 @@ func HandlePacket(packet []byte) error {
 						labels["org"] = userRecord.Value().Org
 						labels["proj"] = userRecord.Value().Project
 					}
+					var user *UserRecord
+					if userRecord != nil {
+						userVal := userRecord.Value()
+						user = &userVal
+					}
+					xfr := packet[offset+8 : offset+32] // XrdXrootdMonStatXFR
+					logAccess(xferRecord.Value(), user,
+						binary.BigEndian.Uint64(xfr[0:8])+binary.BigEndian.Uint64(xfr[8:16]),
+						binary.BigEndian.Uint64(xfr[16:24]),
+						fileHdr.RecFlag&0x01 == 0x01) // XrdXrootdMonFileHdr::forced
 					oldReadvSegs = xferRecord.Value().ReadvSegs
 					oldReadOps = xferRecord.Value().ReadOps
 					oldReadvOps = xferRecord.Value().ReadvOps
@@ -727,10 +737,11 @@ func HandlePacket(packet []byte) error {
 				log.Debug("MonPacket: Received a f-stream file-open packet")
 				fileid := FileId{Id: fileHdr.FileId}
 				path := ""
+				lfn := ""
 				userId := UserId{}
 				if fileHdr.RecFlag&0x01 == 0x01 { // hasLFN
 					lfnSize := uint32(fileHdr.RecSize - 20)
-					lfn := NullTermToString(packet[offset+20 : offset+lfnSize+20])
+					lfn = NullTermToString(packet[offset+20 : offset+lfnSize+20])
 					// path has been difined
 					path = computePrefix(lfn, monitorPaths)
 					log.Debugf("MonPacket: User LFN %v matches prefix %v",
@@ -738,7 +749,7 @@ func HandlePacket(packet []byte) error {
 					// UserId is part of LFN
 					userId = UserId{Id: binary.BigEndian.Uint32(packet[offset+16 : offset+20])}
 				}
-				transfers.Set(fileid, FileRecord{UserId: userId, Path: path},
+				transfers.Set(fileid, FileRecord{UserId: userId, Path: path, Lfn: lfn},
 					ttlcache.DefaultTTL)
 			case isTime: // XrdXrootdMonFileHdr::isTime
 				log.Debug("MonPacket: Received a f-stream time packet")
@@ -90,6 +90,10 @@ type Namespace struct {
 	UseTokenOnRead       bool                  `json:"usetokenonread"`
 	WriteBackHost        string                `json:"writebackhost"`
 	DirListHost          string                `json:"dirlisthost"`
+	// Whether the namespace's objects never change once written, so downloads need no revalidation
+	Immutable bool `json:"immutable"`
+	// Whether uploads go to a staging name first and are moved into place once complete
+	StagedUploads bool `json:"stageduploads"`
 }
 
 // GetCaches returns the list of caches for the namespace
@@ -89,6 +89,7 @@ func (server *OriginServer) CreateAdvertisement(name, originUrlStr, originWebUrl
 		return nil, err
 	}
 
+	hasS3Exports := false
 	for _, export := range originExports {
 		if isGlobusBackend {
 			// Do not include the export if it's an inactive Globus collection
@@ -107,6 +108,7 @@ func (server *OriginServer) CreateAdvertisement(name, originUrlStr, originWebUrl
 				Writes:      export.Capabilities.Writes,
 				Listings:    export.Capabilities.Listings,
 				DirectReads: export.Capabilities.DirectReads,
+				Immutable:   export.Capabilities.Immutable,
 			},
 			Path: export.FederationPrefix,
 			Generation: []server_structs.TokenGen{{
@@ -118,8 +120,24 @@ func (server *OriginServer) CreateAdvertisement(name, originUrlStr, originWebUrl
 				BasePaths: []string{export.FederationPrefix},
 				IssuerUrl: *issuerUrl,
 			}},
+			CacheTTL: int64(param.Origin_DefaultCacheTTL.GetDuration().Seconds()),
+			Backend:  export.GetStorageType(),
+			// Only POSIX storage can move an upload into place once it completes
+			StagedUploads: export.Capabilities.Writes && export.GetStorageType() == server_structs.OriginStoragePosix && param.Origin_AtomicUploads.GetBool(),
 		})
 		prefixes = append(prefixes, export.FederationPrefix)
+		if export.GetStorageType() == server_structs.OriginStorageS3 {
+			hasS3Exports = true
+		}
+	}
+
+	labels, err := server_utils.GetServerLabels()
+	if err != nil {
+		return nil, err
+	}
+	altDataUrls, err := server_utils.GetAlternateDataURLs(context.Background(), originUrlStr)
+	if err != nil {
+		return nil, err
 	}
 
 	// PublicReads implies reads
@@ -147,6 +165,22 @@ func (server *OriginServer) CreateAdvertisement(name, originUrlStr, originWebUrl
 		}},
 		StorageType:         ost,
 		DisableDirectorTest: !param.Origin_DirectorTest.GetBool(),
+		Weight:              param.Origin_Weight.GetInt(),
+		MaxConcurrency:      max(param.Origin_MaxConcurrency.GetInt(), 0),
+		Site:                param.Server_Site.GetString(),
+		Labels:              labels,
+		AltDataURLs:         altDataUrls,
+	}
+	// Advertise the space left on the storage, so that the director can send writes elsewhere when it runs low
+	if usage, ok, err := getDiskUsage(); err != nil {
+		log.Warningln("Failed to get the disk usage to advertise:", err)
+	} else if ok {
+		ad.FreeSpace = usage.FreeSpace
+		ad.TotalSpace = usage.TotalSpace
+	}
+	applyReadOnlyMode(&ad)
+	if hasS3Exports && param.Origin_S3PresignRedirects.GetBool() {
+		ad.Protocols = []string{server_structs.ProtocolS3Presign}
 	}
 
 	if len(prefixes) == 0 {
@@ -20,7 +20,6 @@ package origin
 
 import (
 	"context"
-	"io"
 	"net"
 	"net/http"
 	"strconv"
@@ -34,6 +33,7 @@ import (
 	"github.com/pelicanplatform/pelican/broker"
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/utils"
 )
 
@@ -48,6 +48,11 @@ var (
 	// It's possible to overwhelm the XRootD listen socket with requests.  This rate
 	// limiter will allow no more than 32 requests / second and 8 new ones in a burst
 	xrdConnLimit *rate.Limiter = rate.NewLimiter(32, 8)
+
+	// The limiter of the data sent across all of the connections served through the broker,
+	// created from Origin.MaxBytesPerSecTotal on first use; nil for no limit
+	bandwidthLimit     *rate.Limiter
+	onceBandwidthLimit sync.Once
 )
 
 // Return a custom HTTP transport object; starts with the default transport for
@@ -89,7 +94,11 @@ func proxyOrigin(resp http.ResponseWriter, req *http.Request) {
 
 	utils.CopyHeader(resp.Header(), xrdResp.Header)
 	resp.WriteHeader(xrdResp.StatusCode)
-	if _, err = io.Copy(resp, xrdResp.Body); err != nil {
+	onceBandwidthLimit.Do(func() {
+		bandwidthLimit = server_utils.NewBandwidthLimiter(param.Origin_MaxBytesPerSecTotal.GetInt())
+	})
+	connLimit := server_utils.NewBandwidthLimiter(param.Origin_MaxBytesPerSecPerConn.GetInt())
+	if _, err = server_utils.CopyWithRateLimit(req.Context(), resp, xrdResp.Body, connLimit, bandwidthLimit); err != nil {
 		log.Warningln("Failed to copy response body from Xrootd to remote cache:", err)
 	}
 }
@@ -0,0 +1,105 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package origin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// The bandwidth limits are enforced on the responses the origin serves through the broker
+func TestProxyOriginBandwidthLimit(t *testing.T) {
+	const rate = 128 * 1024
+	data := bytes.Repeat([]byte("x"), 64*1024)
+	// Serving takes at least the time to send what doesn't fit in the initial burst
+	minElapsed := time.Duration(float64(len(data)-rate/10) / rate * float64(time.Second))
+
+	xrootd := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer xrootd.Close()
+	// Stand in for the local XRootD the origin proxies requests to
+	onceTransport.Do(func() {})
+	proxyTransport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, "tcp", xrootd.Listener.Addr().String())
+		},
+	}
+
+	serve := func(t *testing.T) time.Duration {
+		req := httptest.NewRequest(http.MethodGet, "/test/object", nil)
+		recorder := httptest.NewRecorder()
+		start := time.Now()
+		proxyOrigin(recorder, req)
+		elapsed := time.Since(start)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, data, recorder.Body.Bytes())
+		return elapsed
+	}
+	setLimits := func(t *testing.T, perConn, total int) {
+		viper.Reset()
+		viper.Set("Server.Hostname", "localhost")
+		viper.Set("Origin.MaxBytesPerSecPerConn", perConn)
+		viper.Set("Origin.MaxBytesPerSecTotal", total)
+		bandwidthLimit = nil
+		onceBandwidthLimit = sync.Once{}
+		t.Cleanup(func() {
+			viper.Reset()
+			bandwidthLimit = nil
+			onceBandwidthLimit = sync.Once{}
+		})
+	}
+
+	t.Run("no-limit", func(t *testing.T) {
+		setLimits(t, 0, 0)
+		assert.Less(t, serve(t), minElapsed)
+	})
+
+	t.Run("per-connection", func(t *testing.T) {
+		setLimits(t, rate, 0)
+		assert.GreaterOrEqual(t, serve(t), minElapsed)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		// Each connection alone is below the aggregate limit, the two together aren't
+		setLimits(t, 0, 2*rate)
+		start := time.Now()
+		wg := sync.WaitGroup{}
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serve(t)
+			}()
+		}
+		wg.Wait()
+		assert.GreaterOrEqual(t, time.Since(start), minElapsed)
+	})
+}
@@ -0,0 +1,83 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// The space of the storage backing the origin's POSIX exports, in bytes
+type diskUsageRes struct {
+	FreeSpace  uint64 `json:"freeSpace"`
+	UsedSpace  uint64 `json:"usedSpace"`
+	TotalSpace uint64 `json:"totalSpace"`
+}
+
+// Get the space of the filesystems backing the origin's POSIX exports. When the exports
+// span several filesystems, the one with the least free space is reported, as writes may
+// land on any of them. Returns false if the origin has no POSIX exports
+func getDiskUsage() (usage diskUsageRes, ok bool, err error) {
+	exports, err := server_utils.GetOriginExports()
+	if err != nil {
+		return
+	}
+	for _, export := range exports {
+		if export.GetStorageType() != server_structs.OriginStoragePosix {
+			continue
+		}
+		free, total, statErr := getFilesystemSpace(export.StoragePrefix)
+		if statErr != nil {
+			err = errors.Wrapf(statErr, "failed to get the space of the export %s", export.FederationPrefix)
+			return
+		}
+		if !ok || free < usage.FreeSpace {
+			usage = diskUsageRes{FreeSpace: free, UsedSpace: total - free, TotalSpace: total}
+			ok = true
+		}
+	}
+	return
+}
+
+// A gin route handler reporting the free, used and total space of the origin's storage
+func handleDiskUsage(ctx *gin.Context) {
+	usage, ok, err := getDiskUsage()
+	if err != nil {
+		log.Errorln("Failed to get the disk usage of the origin:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to get the disk usage of the origin: " + err.Error(),
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The origin has no POSIX exports to report the disk usage of",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, usage)
+}
@@ -26,6 +26,8 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 )
 
@@ -39,6 +41,47 @@ func RegisterOriginAPI(router *gin.Engine, ctx context.Context, egrp *errgroup.G
 		return errors.New("Origin configuration passed a nil pointer")
 	}
 
+	// Fail at startup on misconfigured rewrite rules rather than on each request
+	rewriter, err := server_utils.GetOriginPathRewriter()
+	if err != nil {
+		return err
+	}
+
+	presignRedirects := param.Origin_S3PresignRedirects.GetBool()
+	// The rules are only applied to the objects served through presigned S3 URLs; without them,
+	// XRootD would serve the objects at their unrewritten paths
+	if !rewriter.Empty() {
+		if !presignRedirects {
+			return errors.New("Origin.PathRewrites requires Origin.S3PresignRedirects to be enabled")
+		}
+		originExports, err := server_utils.GetOriginExports()
+		if err != nil {
+			return err
+		}
+		for _, export := range originExports {
+			if export.GetStorageType() != server_structs.OriginStorageS3 {
+				return errors.Errorf("Origin.PathRewrites only applies to S3 exports, but the export %s has storage type %s", export.FederationPrefix, export.GetStorageType())
+			}
+		}
+	}
+	if presignRedirects && server_structs.OriginStorageType(param.Origin_StorageType.GetString()) != server_structs.OriginStorageS3 {
+		// A POSIX origin may still serve some of its exports from S3
+		originExports, err := server_utils.GetOriginExports()
+		if err != nil {
+			return err
+		}
+		hasS3Exports := false
+		for _, export := range originExports {
+			if export.GetStorageType() == server_structs.OriginStorageS3 {
+				hasS3Exports = true
+				break
+			}
+		}
+		if !hasS3Exports {
+			return errors.Errorf("Origin.S3PresignRedirects requires Origin.StorageType or the storage type of an export to be %s, but it is %s", server_structs.OriginStorageS3, param.Origin_StorageType.GetString())
+		}
+	}
+
 	metrics.SetComponentHealthStatus(metrics.OriginCache_Director, metrics.StatusWarning, "Initializing the server, unknown status from the director file transfer test")
 	// start the timer for the director test report timeout
 	server_utils.LaunchPeriodicDirectorTimeout(ctx, egrp, notificationChan)
@@ -51,6 +94,9 @@ func RegisterOriginAPI(router *gin.Engine, ctx context.Context, egrp *errgroup.G
 	group := router.Group("/api/v1.0/origin")
 	{
 		group.POST("/directorTest", func(ctx *gin.Context) { server_utils.HandleDirectorTestResponse(ctx, notificationChan) })
+		if presignRedirects {
+			group.GET("/presign", handlePresign)
+		}
 	}
 	return nil
 }
@@ -165,6 +165,9 @@ func RegisterOriginWebAPI(engine *gin.Engine) error {
 	originWebAPI := engine.Group("/api/v1.0/origin_ui")
 	{
 		originWebAPI.GET("/exports", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleExports)
+		originWebAPI.GET("/stats", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleDiskUsage)
+		originWebAPI.GET("/readOnly", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleGetReadOnlyMode)
+		originWebAPI.PUT("/readOnly", web_ui.AuthHandler, web_ui.AdminAuthHandler, handleSetReadOnlyMode)
 	}
 
 	// Globus backend specific. Config other origin routes above this line
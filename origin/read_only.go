@@ -0,0 +1,101 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package origin
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+type readOnlyModeReq struct {
+	ReadOnly *bool `json:"readOnly" binding:"required"`
+}
+
+type readOnlyModeRes struct {
+	ReadOnly bool `json:"readOnly"`
+}
+
+// Whether an admin has put the origin into read-only mode, e.g. for maintenance of the
+// backing storage. The flag lives in memory only and is cleared on restart
+var readOnlyMode atomic.Bool
+
+// Notifications of read-only mode changes, so that the origin's XRootD authorization is
+// regenerated and the origin re-advertises right away. Pending notifications are coalesced
+var readOnlyModeUpdates = make(chan struct{}, 1)
+
+// Whether the origin is in read-only mode
+func IsReadOnlyMode() bool {
+	return readOnlyMode.Load()
+}
+
+// Get the channel notified whenever the read-only mode of the origin changes
+func ReadOnlyModeUpdates() <-chan struct{} {
+	return readOnlyModeUpdates
+}
+
+// Put the origin into, or take it out of, read-only mode
+func SetReadOnlyMode(readOnly bool) {
+	if old := readOnlyMode.Swap(readOnly); old == readOnly {
+		return
+	}
+	if readOnly {
+		log.Warningln("Origin is now in read-only mode; writes will be rejected and no longer advertised to the director")
+	} else {
+		log.Infoln("Origin has left read-only mode; writes will be accepted and advertised to the director again")
+	}
+	select {
+	case readOnlyModeUpdates <- struct{}{}:
+	default:
+	}
+}
+
+// Turn off the writes of the advertisement, at both the server and the namespace level,
+// if the origin is in read-only mode. Reads and listings are left as configured so the
+// director keeps sending them here
+func applyReadOnlyMode(ad *server_structs.OriginAdvertiseV2) {
+	if !readOnlyMode.Load() {
+		return
+	}
+	ad.Caps.Writes = false
+	for idx := range ad.Namespaces {
+		ad.Namespaces[idx].Caps.Writes = false
+	}
+}
+
+// A gin route handler reporting whether the origin is in read-only mode
+func handleGetReadOnlyMode(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, readOnlyModeRes{ReadOnly: readOnlyMode.Load()})
+}
+
+// A gin route handler toggling the read-only mode of the origin. XRootD starts rejecting writes
+// once it reloads its regenerated authorization, and the origin re-advertises to the director
+func handleSetReadOnlyMode(ctx *gin.Context) {
+	req := readOnlyModeReq{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_utils.NewBindingErrorResp(err, req))
+		return
+	}
+	SetReadOnlyMode(*req.ReadOnly)
+	ctx.JSON(http.StatusOK, readOnlyModeRes{ReadOnly: *req.ReadOnly})
+}
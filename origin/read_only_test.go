@@ -0,0 +1,121 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package origin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	readOnlyMode.Store(false)
+	t.Cleanup(func() { readOnlyMode.Store(false) })
+	notified := func() bool {
+		select {
+		case <-ReadOnlyModeUpdates():
+			return true
+		default:
+			return false
+		}
+	}
+	notified()
+
+	router := gin.New()
+	router.GET("/readOnly", handleGetReadOnlyMode)
+	router.PUT("/readOnly", handleSetReadOnlyMode)
+	var lastErrs []server_structs.FieldValidationError
+	doRequest := func(method, body string) (int, readOnlyModeRes) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/readOnly", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		res := readOnlyModeRes{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		} else {
+			errRes := server_structs.ValidationErrorResp{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errRes))
+			lastErrs = errRes.Errors
+		}
+		return w.Code, res
+	}
+	newAd := func() server_structs.OriginAdvertiseV2 {
+		caps := server_structs.Capabilities{Reads: true, Writes: true, Listings: true}
+		return server_structs.OriginAdvertiseV2{
+			Caps:       caps,
+			Namespaces: []server_structs.NamespaceAdV2{{Path: "/foo", Caps: caps}, {Path: "/bar", Caps: caps}},
+		}
+	}
+
+	code, res := doRequest(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, res.ReadOnly)
+	ad := newAd()
+	applyReadOnlyMode(&ad)
+	assert.Equal(t, newAd(), ad)
+
+	code, res = doRequest(http.MethodPut, `{"readOnly": true}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, res.ReadOnly)
+	assert.True(t, notified())
+	code, res = doRequest(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, res.ReadOnly)
+	assert.True(t, IsReadOnlyMode())
+
+	// Setting the same mode again isn't a change
+	code, _ = doRequest(http.MethodPut, `{"readOnly": true}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, notified())
+
+	// Writes are dropped everywhere, reads and listings are kept
+	ad = newAd()
+	applyReadOnlyMode(&ad)
+	assert.Equal(t, server_structs.Capabilities{Reads: true, Listings: true}, ad.Caps)
+	for _, ns := range ad.Namespaces {
+		assert.Equal(t, server_structs.Capabilities{Reads: true, Listings: true}, ns.Caps)
+	}
+
+	// Malformed requests must not change the mode, and say which field is wrong
+	code, _ = doRequest(http.MethodPut, `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []server_structs.FieldValidationError{{Field: "readOnly", Msg: "field 'readOnly' is required and must be a boolean"}}, lastErrs)
+	code, _ = doRequest(http.MethodPut, `{"readOnly": "no"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.Len(t, lastErrs, 1)
+	assert.Equal(t, "readOnly", lastErrs[0].Field)
+	assert.True(t, readOnlyMode.Load())
+
+	assert.False(t, notified())
+
+	code, res = doRequest(http.MethodPut, `{"readOnly": false}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, res.ReadOnly)
+	assert.True(t, notified())
+	ad = newAd()
+	applyReadOnlyMode(&ad)
+	assert.Equal(t, newAd(), ad)
+}
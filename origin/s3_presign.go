@@ -0,0 +1,351 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+type (
+	// The S3 location of an object and the credentials to presign the URL with
+	s3ObjectLocation struct {
+		ServiceUrl string
+		Region     string
+		UrlStyle   string
+		Bucket     string
+		Key        string
+		AccessKey  string
+		SecretKey  string
+	}
+)
+
+const (
+	awsSigningAlgorithm = "AWS4-HMAC-SHA256"
+	awsDateFormat       = "20060102"
+	awsTimeFormat       = "20060102T150405Z"
+)
+
+// Encode the string per the AWS signature version 4 rules: every byte except the unreserved
+// characters is percent-encoded, and slashes are kept if encodeSlash is false
+func awsURIEncode(str string, encodeSlash bool) string {
+	var builder strings.Builder
+	for idx := 0; idx < len(str); idx++ {
+		ch := str[idx]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			builder.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", ch)
+		}
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// Generate a presigned URL for the HTTP method on the object, valid for the given duration from now, per
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html. The method is part of the
+// signature, so a URL presigned for GET is refused for a HEAD.
+// Objects of public buckets, i.e. without credentials, get a plain URL
+func presignS3URL(loc s3ObjectLocation, method string, expiry time.Duration, now time.Time) (string, error) {
+	serviceUrl, err := url.Parse(loc.ServiceUrl)
+	if err != nil || serviceUrl.Host == "" {
+		return "", errors.Errorf("invalid S3 service URL %q", loc.ServiceUrl)
+	}
+	objectUrl := url.URL{Scheme: serviceUrl.Scheme, Host: serviceUrl.Host}
+	basePath := strings.TrimSuffix(serviceUrl.Path, "/")
+	if loc.UrlStyle == "virtual" {
+		objectUrl.Host = loc.Bucket + "." + serviceUrl.Host
+		objectUrl.Path = basePath + "/" + loc.Key
+	} else {
+		objectUrl.Path = basePath + "/" + loc.Bucket + "/" + loc.Key
+	}
+	canonicalUri := awsURIEncode(objectUrl.Path, false)
+	objectUrl.RawPath = canonicalUri
+
+	if loc.AccessKey == "" || loc.SecretKey == "" {
+		return objectUrl.String(), nil
+	}
+
+	now = now.UTC()
+	date := now.Format(awsDateFormat)
+	credentialScope := date + "/" + loc.Region + "/s3/aws4_request"
+	query := map[string]string{
+		"X-Amz-Algorithm":     awsSigningAlgorithm,
+		"X-Amz-Credential":    loc.AccessKey + "/" + credentialScope,
+		"X-Amz-Date":          now.Format(awsTimeFormat),
+		"X-Amz-Expires":       strconv.Itoa(int(expiry.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	queryParts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		queryParts = append(queryParts, awsURIEncode(key, true)+"="+awsURIEncode(query[key], true))
+	}
+	canonicalQuery := strings.Join(queryParts, "&")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalUri,
+		canonicalQuery,
+		"host:" + objectUrl.Host,
+		"",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		query["X-Amz-Date"],
+		credentialScope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+loc.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, loc.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	objectUrl.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return objectUrl.String(), nil
+}
+
+// Read a key file of an export, ignoring surrounding whitespace
+func readS3Keyfile(keyfile string) (string, error) {
+	if keyfile == "" {
+		return "", nil
+	}
+	contents, err := os.ReadFile(keyfile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the S3 key file %s", keyfile)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// Find the export serving the object and the S3 location of the object
+func getS3ObjectLocation(objectPath string) (server_utils.OriginExport, s3ObjectLocation, error) {
+	exports, err := server_utils.GetOriginExports()
+	if err != nil {
+		return server_utils.OriginExport{}, s3ObjectLocation{}, err
+	}
+	rewriter, err := server_utils.GetOriginPathRewriter()
+	if err != nil {
+		return server_utils.OriginExport{}, s3ObjectLocation{}, err
+	}
+	for _, export := range exports {
+		prefix := path.Clean("/" + export.FederationPrefix)
+		relPath, found := strings.CutPrefix(objectPath, prefix)
+		if !found || (relPath != "" && !strings.HasPrefix(relPath, "/") && prefix != "/") {
+			continue
+		}
+		if export.GetStorageType() != server_structs.OriginStorageS3 {
+			return export, s3ObjectLocation{}, errors.Errorf("the export serving the path %s is backed by %s storage, not S3", objectPath, export.GetStorageType())
+		}
+		// Map the object's path within the export onto the storage layout
+		relPath = rewriter.Rewrite(relPath)
+		loc := s3ObjectLocation{
+			ServiceUrl: param.Origin_S3ServiceUrl.GetString(),
+			Region:     param.Origin_S3Region.GetString(),
+			UrlStyle:   param.Origin_S3UrlStyle.GetString(),
+			Bucket:     export.S3Bucket,
+			Key:        strings.TrimPrefix(relPath, "/"),
+		}
+		// Without a bucket, the export serves all public buckets at /federation/prefix/bucket/object
+		if loc.Bucket == "" {
+			loc.Bucket, loc.Key, _ = strings.Cut(loc.Key, "/")
+		}
+		if loc.Bucket == "" || loc.Key == "" {
+			return export, loc, errors.Errorf("the path %s doesn't name an object in a bucket", objectPath)
+		}
+		if loc.AccessKey, err = readS3Keyfile(export.S3AccessKeyfile); err != nil {
+			return export, loc, err
+		}
+		if loc.SecretKey, err = readS3Keyfile(export.S3SecretKeyfile); err != nil {
+			return export, loc, err
+		}
+		return export, loc, nil
+	}
+	return server_utils.OriginExport{}, s3ObjectLocation{}, errors.Errorf("no export serves the path %s", objectPath)
+}
+
+// Get the public keys of the origin's token issuer
+func getIssuerKeys(ginCtx *gin.Context, issuer string) (jwk.Set, error) {
+	if issuer == param.Server_ExternalWebUrl.GetString() {
+		return config.GetIssuerPublicJWKS()
+	}
+	jwksUrl, err := token.LookupIssuerJwksUrl(ginCtx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.Fetch(ginCtx, jwksUrl.String(), jwk.WithHTTPClient(&http.Client{Transport: config.GetTransport()}))
+}
+
+// Verify the token is issued by the origin's issuer and grants reading the object, the same
+// check XRootD does before serving an object of the export
+func verifyObjectReadToken(ginCtx *gin.Context, strToken string, export server_utils.OriginExport, objectPath string) error {
+	if strToken == "" {
+		return errors.New("a token is required to read the object")
+	}
+	unverified, err := jwt.Parse([]byte(strToken), jwt.WithVerify(false))
+	if err != nil {
+		return errors.Wrap(err, "invalid JWT")
+	}
+	issuer, err := config.GetServerIssuerURL()
+	if err != nil {
+		return err
+	}
+	if unverified.Issuer() != issuer {
+		return errors.Errorf("the token issuer %s is not the origin's issuer %s", unverified.Issuer(), issuer)
+	}
+	keys, err := getIssuerKeys(ginCtx, issuer)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the public keys of the token issuer")
+	}
+	tok, err := jwt.Parse([]byte(strToken), jwt.WithKeySet(keys), jwt.WithValidate(true))
+	if err != nil {
+		return errors.Wrap(err, "failed to verify the token")
+	}
+
+	// Resource scopes are relative to the export's federation prefix
+	matcher := token_scopes.ScopeMatcher{BasePath: export.FederationPrefix}
+	if matcher.Authorized(token_scopes.ParseResourceScopeString(tok), token_scopes.Storage_Read, objectPath) {
+		return nil
+	}
+	return errors.Errorf("the token doesn't grant %s", token_scopes.NewResourceScope(token_scopes.Storage_Read, objectPath).String())
+}
+
+// Authorize reading the object with the request's client certificate, if it maps to an identity
+// in Server.ClientCertIdentities, or else with its token
+func authorizeObjectRead(ginCtx *gin.Context, export server_utils.OriginExport, objectPath string) error {
+	identity, scopes, err := token.GetClientCertIdentity(ginCtx.Request)
+	if err != nil {
+		log.Warningln("Unable to authorize the request by its client certificate:", err)
+	} else if identity != "" {
+		// Unlike token scopes, the scopes of an identity are paths in the federation namespace
+		if (token_scopes.ScopeMatcher{}).Authorized(scopes, token_scopes.Storage_Read, objectPath) {
+			log.Debugf("Client certificate identity %s is authorized to read %s", identity, objectPath)
+			return nil
+		}
+		log.Debugf("Client certificate identity %s isn't authorized to read %s; checking the token", identity, objectPath)
+	}
+	return verifyObjectReadToken(ginCtx, getRequestToken(ginCtx), export, objectPath)
+}
+
+// Get the token of the request from the Authorization header or the authz query parameter
+func getRequestToken(ginCtx *gin.Context) string {
+	if tok, found := strings.CutPrefix(ginCtx.GetHeader("Authorization"), "Bearer "); found {
+		return tok
+	}
+	return ginCtx.Query("authz")
+}
+
+// A gin route handler that presigns a URL to read the object at the `path` query parameter directly from S3,
+// after verifying the request's token as the origin would before serving the object. The URL is signed
+// for the method of the `method` query parameter, GET or HEAD, defaulting to GET
+func handlePresign(ginCtx *gin.Context) {
+	objectPath := path.Clean("/" + ginCtx.Query("path"))
+	if ginCtx.Query("path") == "" {
+		ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The 'path' query parameter is required",
+		})
+		return
+	}
+	method := strings.ToUpper(ginCtx.DefaultQuery("method", http.MethodGet))
+	if method != http.MethodGet && method != http.MethodHead {
+		ginCtx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The 'method' query parameter must be GET or HEAD",
+		})
+		return
+	}
+	export, loc, err := getS3ObjectLocation(objectPath)
+	if err != nil {
+		ginCtx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if !export.Capabilities.PublicReads {
+		if !export.Capabilities.Reads {
+			ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "The export doesn't allow reads",
+			})
+			return
+		}
+		if err := authorizeObjectRead(ginCtx, export, objectPath); err != nil {
+			log.Debugf("Refusing to presign a URL for %s: %v", objectPath, err)
+			ginCtx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Authorization failed: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	expiry := param.Origin_S3PresignExpiry.GetDuration()
+	presignedUrl, err := presignS3URL(loc, method, expiry, time.Now())
+	if err != nil {
+		log.Errorf("Failed to presign a URL for %s: %v", objectPath, err)
+		ginCtx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to presign the URL",
+		})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, server_structs.PresignedURLResp{
+		URL:       presignedUrl,
+		ExpiresAt: time.Now().Add(expiry).Unix(),
+	})
+}
@@ -20,22 +20,103 @@ package origin
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 )
 
-func doSelfMonitor(ctx context.Context) {
-	log.Debug("Starting a new self-test monitoring cycle")
+const initialSelfTestRetryInterval = 10 * time.Second
+
+// Run a self-test by uploading, downloading, and deleting a generated file at the local origin,
+// which exercises the origin's storage backend
+func runSelfTest(ctx context.Context) error {
 	fileTests := server_utils.TestFileTransferImpl{}
 	issuerUrl := param.Server_ExternalWebUrl.GetString()
 	ok, err := fileTests.RunTests(ctx, param.Origin_Url.GetString(), config.GetServerAudience(), issuerUrl, server_utils.ServerSelfTest)
-	if ok && err == nil {
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("self-test file transfer did not succeed")
+	}
+	return nil
+}
+
+// Check that the bucket of each S3 export is reachable with the export's credentials, with a
+// signed HEAD request on the bucket. Exports serving all public buckets have no bucket to check
+func probeS3Buckets(ctx context.Context, exports []server_utils.OriginExport) (err error) {
+	client := &http.Client{Transport: config.GetTransport(), Timeout: 10 * time.Second}
+	for _, export := range exports {
+		if export.GetStorageType() != server_structs.OriginStorageS3 || export.S3Bucket == "" {
+			continue
+		}
+		loc := s3ObjectLocation{
+			ServiceUrl: param.Origin_S3ServiceUrl.GetString(),
+			Region:     param.Origin_S3Region.GetString(),
+			UrlStyle:   param.Origin_S3UrlStyle.GetString(),
+			Bucket:     export.S3Bucket,
+		}
+		if loc.AccessKey, err = readS3Keyfile(export.S3AccessKeyfile); err != nil {
+			return
+		}
+		if loc.SecretKey, err = readS3Keyfile(export.S3SecretKeyfile); err != nil {
+			return
+		}
+		var bucketUrl string
+		if bucketUrl, err = presignS3URL(loc, http.MethodHead, time.Minute, time.Now()); err != nil {
+			return errors.Wrapf(err, "failed to sign the request for the S3 bucket %s of export %s", export.S3Bucket, export.FederationPrefix)
+		}
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodHead, bucketUrl, nil); err != nil {
+			return errors.Wrapf(err, "failed to create the request for the S3 bucket %s of export %s", export.S3Bucket, export.FederationPrefix)
+		}
+		var resp *http.Response
+		if resp, err = client.Do(req); err != nil {
+			return errors.Wrapf(err, "the S3 bucket %s of export %s is unreachable", export.S3Bucket, export.FederationPrefix)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("the S3 bucket %s of export %s responded with status %d", export.S3Bucket, export.FederationPrefix, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// Run the checks the origin must pass before it first advertises: the buckets of its S3
+// exports must be reachable, then, if Origin.SelfTest is set, the self-test must succeed.
+// S3 origins don't run the self-test, so the bucket check is all they get
+func runPreAdvertiseChecks(ctx context.Context) error {
+	exports, err := server_utils.GetOriginExports()
+	if err != nil {
+		return err
+	}
+	if err = probeS3Buckets(ctx, exports); err != nil {
+		return err
+	}
+	if !param.Origin_SelfTest.GetBool() {
+		return nil
+	}
+	return runSelfTest(ctx)
+}
+
+func doSelfMonitor(ctx context.Context) {
+	if IsReadOnlyMode() {
+		// The self-test uploads a file, which the origin rejects in read-only mode
+		log.Debugln("Skipping the self-test monitoring cycle as the origin is in read-only mode")
+		metrics.SetComponentHealthStatus(metrics.OriginCache_XRootD, metrics.StatusWarning, "Self-test monitoring is paused while the origin is in read-only mode")
+		return
+	}
+	log.Debug("Starting a new self-test monitoring cycle")
+	if err := runSelfTest(ctx); err == nil {
 		log.Debugln("Self-test monitoring cycle succeeded at", time.Now().Format(time.UnixDate))
 		metrics.SetComponentHealthStatus(metrics.OriginCache_XRootD, metrics.StatusOK, "Self-test monitoring cycle succeeded at "+time.Now().Format(time.RFC3339))
 	} else {
@@ -44,6 +125,35 @@ func doSelfMonitor(ctx context.Context) {
 	}
 }
 
+// Block until the origin passes its pre-advertisement checks (see runPreAdvertiseChecks), retrying
+// every initialSelfTestRetryInterval. This is to be called before the origin first advertises
+// to the director, so that the director doesn't route clients to an origin whose storage
+// backend is misconfigured. The result is exposed as the "self-test" component of the origin's
+// health status.
+func WaitUntilSelfTestPasses(ctx context.Context) error {
+	return waitUntilSelfTestPasses(ctx, runPreAdvertiseChecks, initialSelfTestRetryInterval)
+}
+
+func waitUntilSelfTestPasses(ctx context.Context, selfTest func(context.Context) error, retryInterval time.Duration) error {
+	metrics.SetComponentHealthStatus(metrics.Origin_SelfTest, metrics.StatusWarning, "Running the initial self-test before advertising to the director")
+	for attempt := 1; ; attempt++ {
+		err := selfTest(ctx)
+		if err == nil {
+			log.Infof("Origin passed the initial self-test after %d attempt(s)", attempt)
+			metrics.SetComponentHealthStatus(metrics.Origin_SelfTest, metrics.StatusOK, "Initial self-test succeeded at "+time.Now().Format(time.RFC3339))
+			return nil
+		}
+		log.Errorf("Origin failed the initial self-test (attempt %d); it won't advertise to the director until the self-test passes: %v", attempt, err)
+		metrics.SetComponentHealthStatus(metrics.Origin_SelfTest, metrics.StatusCritical,
+			fmt.Sprintf("Initial self-test failed; the origin won't advertise to the director until it passes: %v", err))
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "origin shut down before passing the initial self-test")
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
 // Start self-test monitoring of the origin.  This will upload, download, and delete
 // a generated filename every 15 seconds to the local origin.  On failure, it will
 // set the xrootd component's status to critical.
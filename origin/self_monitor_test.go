@@ -0,0 +1,123 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package origin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+func TestWaitUntilSelfTestPasses(t *testing.T) {
+	t.Run("passes-after-retries", func(t *testing.T) {
+		attempts := 0
+		selfTest := func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("storage backend is unreachable")
+			}
+			return nil
+		}
+		err := waitUntilSelfTestPasses(context.Background(), selfTest, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+
+		status, err := metrics.GetComponentStatus(metrics.Origin_SelfTest)
+		require.NoError(t, err)
+		assert.Equal(t, metrics.StatusOK.String(), status)
+	})
+
+	t.Run("blocks-until-cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		selfTest := func(ctx context.Context) error {
+			return errors.New("storage backend is unreachable")
+		}
+		err := waitUntilSelfTestPasses(ctx, selfTest, time.Millisecond)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		status, err := metrics.GetComponentStatus(metrics.Origin_SelfTest)
+		require.NoError(t, err)
+		assert.Equal(t, metrics.StatusCritical.String(), status)
+	})
+}
+
+func TestProbeS3Buckets(t *testing.T) {
+	s3Server := newMockS3Server(t, "test-bucket", "", nil)
+	defer s3Server.Close()
+
+	tDir := t.TempDir()
+	accessKeyfile := filepath.Join(tDir, "access-key")
+	secretKeyfile := filepath.Join(tDir, "secret-key")
+	wrongKeyfile := filepath.Join(tDir, "wrong-key")
+	require.NoError(t, os.WriteFile(accessKeyfile, []byte(testS3AccessKey+"\n"), 0600))
+	require.NoError(t, os.WriteFile(secretKeyfile, []byte(testS3SecretKey+"\n"), 0600))
+	require.NoError(t, os.WriteFile(wrongKeyfile, []byte("not-the-secret-key"), 0600))
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("Origin.StorageType", "posix")
+	viper.Set("Origin.S3ServiceUrl", s3Server.URL)
+	viper.Set("Origin.S3Region", "us-east-1")
+	viper.Set("Origin.S3UrlStyle", "path")
+	s3Export := func(secretKeyfile string) server_utils.OriginExport {
+		return server_utils.OriginExport{
+			FederationPrefix: "/s3",
+			StorageType:      server_structs.OriginStorageS3,
+			S3Bucket:         "test-bucket",
+			S3AccessKeyfile:  accessKeyfile,
+			S3SecretKeyfile:  secretKeyfile,
+		}
+	}
+	posixExport := server_utils.OriginExport{FederationPrefix: "/posix", StoragePrefix: "/data"}
+
+	t.Run("reachable-bucket", func(t *testing.T) {
+		assert.NoError(t, probeS3Buckets(context.Background(), []server_utils.OriginExport{posixExport, s3Export(secretKeyfile)}))
+	})
+
+	t.Run("wrong-credentials", func(t *testing.T) {
+		err := probeS3Buckets(context.Background(), []server_utils.OriginExport{s3Export(wrongKeyfile)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 403")
+	})
+
+	t.Run("unreachable-service", func(t *testing.T) {
+		viper.Set("Origin.S3ServiceUrl", "http://127.0.0.1:1")
+		t.Cleanup(func() { viper.Set("Origin.S3ServiceUrl", s3Server.URL) })
+		assert.Error(t, probeS3Buckets(context.Background(), []server_utils.OriginExport{s3Export(secretKeyfile)}))
+	})
+
+	t.Run("no-s3-exports", func(t *testing.T) {
+		viper.Set("Origin.S3ServiceUrl", "http://127.0.0.1:1")
+		t.Cleanup(func() { viper.Set("Origin.S3ServiceUrl", s3Server.URL) })
+		assert.NoError(t, probeS3Buckets(context.Background(), []server_utils.OriginExport{posixExport}))
+	})
+}
@@ -0,0 +1,101 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package origin
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// Remove the staged uploads in the origin's POSIX exports that weren't written to for longer than maxAge,
+// as their clients went away before moving them into place. Returns the number of uploads removed
+func removeAbandonedUploads(maxAge time.Duration, now time.Time) (removed int, err error) {
+	exports, err := server_utils.GetOriginExports()
+	if err != nil {
+		return
+	}
+	for _, export := range exports {
+		if export.GetStorageType() != server_structs.OriginStoragePosix {
+			continue
+		}
+		walkErr := filepath.WalkDir(export.StoragePrefix, func(objectPath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Keep going past the directories that can't be read
+				log.Debugf("Failed to check %s for abandoned uploads: %v", objectPath, err)
+				return nil
+			}
+			if !entry.Type().IsRegular() || !server_structs.IsStagedUploadName(entry.Name()) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || now.Sub(info.ModTime()) <= maxAge {
+				return nil
+			}
+			if err := os.Remove(objectPath); err != nil {
+				log.Warningf("Failed to remove the abandoned upload %s: %v", objectPath, err)
+				return nil
+			}
+			log.Infof("Removed the abandoned upload %s, last written at %s", objectPath, info.ModTime().Format(time.RFC3339))
+			removed++
+			return nil
+		})
+		if walkErr != nil {
+			log.Warningf("Failed to check the export %s for abandoned uploads: %v", export.FederationPrefix, walkErr)
+		}
+	}
+	return
+}
+
+// Periodically remove the staged uploads abandoned by their clients, if the origin advertises staged uploads
+func LaunchAbandonedUploadCleanup(ctx context.Context, egrp *errgroup.Group) {
+	if !param.Origin_AtomicUploads.GetBool() {
+		return
+	}
+	maxAge := param.Origin_StagedUploadMaxAge.GetDuration()
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	interval := maxAge / 4
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	egrp.Go(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := removeAbandonedUploads(maxAge, time.Now()); err != nil {
+				log.Errorln("Failed to remove the abandoned uploads:", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
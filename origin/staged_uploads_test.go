@@ -0,0 +1,62 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package origin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+func TestRemoveAbandonedUploads(t *testing.T) {
+	server_utils.ResetOriginExports()
+	viper.Reset()
+	t.Cleanup(func() {
+		server_utils.ResetOriginExports()
+		viper.Reset()
+	})
+	storageDir := t.TempDir()
+	viper.Set("Origin.StorageType", "posix")
+	viper.Set("Origin.Exports", []map[string]interface{}{{"StoragePrefix": storageDir, "FederationPrefix": "/test"}})
+
+	now := time.Now()
+	writeFile := func(name string, modTime time.Time) string {
+		filePath := filepath.Join(storageDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte("data"), 0644))
+		require.NoError(t, os.Chtimes(filePath, modTime, modTime))
+		return filePath
+	}
+	abandoned := writeFile("dir/.pelican-upload.object.txt.0123456789abcdef", now.Add(-48*time.Hour))
+	inFlight := writeFile("dir/.pelican-upload.other.txt.fedcba9876543210", now.Add(-time.Minute))
+	oldObject := writeFile("dir/object.txt", now.Add(-48*time.Hour))
+
+	removed, err := removeAbandonedUploads(24*time.Hour, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, abandoned)
+	assert.FileExists(t, inFlight)
+	assert.FileExists(t, oldObject)
+}
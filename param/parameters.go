@@ -147,10 +147,18 @@ var (
 	Cache_SentinelLocation = StringParam{"Cache.SentinelLocation"}
 	Cache_Url = StringParam{"Cache.Url"}
 	Cache_XRootDPrefix = StringParam{"Cache.XRootDPrefix"}
+	Client_ChecksumAlgorithm = StringParam{"Client.ChecksumAlgorithm"}
+	Client_VerifyChecksum = StringParam{"Client.VerifyChecksum"}
+	Director_CacheSelectionStrategy = StringParam{"Director.CacheSelectionStrategy"}
 	Director_CacheSortMethod = StringParam{"Director.CacheSortMethod"}
 	Director_DefaultResponse = StringParam{"Director.DefaultResponse"}
+	Director_FilteredServersStateFile = StringParam{"Director.FilteredServersStateFile"}
 	Director_GeoIPLocation = StringParam{"Director.GeoIPLocation"}
+	Director_GeoIPProvider = StringParam{"Director.GeoIPProvider"}
+	Director_GeoIPStaticFile = StringParam{"Director.GeoIPStaticFile"}
 	Director_MaxMindKeyFile = StringParam{"Director.MaxMindKeyFile"}
+	Director_MinOriginFreeSpace = StringParam{"Director.MinOriginFreeSpace"}
+	Director_NamespaceStatsStateFile = StringParam{"Director.NamespaceStatsStateFile"}
 	Director_SupportContactEmail = StringParam{"Director.SupportContactEmail"}
 	Director_SupportContactUrl = StringParam{"Director.SupportContactUrl"}
 	Federation_DiscoveryUrl = StringParam{"Federation.DiscoveryUrl"}
@@ -198,6 +206,8 @@ var (
 	OIDC_Issuer = StringParam{"OIDC.Issuer"}
 	OIDC_TokenEndpoint = StringParam{"OIDC.TokenEndpoint"}
 	OIDC_UserInfoEndpoint = StringParam{"OIDC.UserInfoEndpoint"}
+	Origin_AccessLogLocation = StringParam{"Origin.AccessLogLocation"}
+	Origin_AccessLogSubject = StringParam{"Origin.AccessLogSubject"}
 	Origin_DbLocation = StringParam{"Origin.DbLocation"}
 	Origin_ExportVolume = StringParam{"Origin.ExportVolume"}
 	Origin_FederationPrefix = StringParam{"Origin.FederationPrefix"}
@@ -223,23 +233,30 @@ var (
 	Origin_ScitokensUsernameClaim = StringParam{"Origin.ScitokensUsernameClaim"}
 	Origin_StoragePrefix = StringParam{"Origin.StoragePrefix"}
 	Origin_StorageType = StringParam{"Origin.StorageType"}
+	Origin_UploadLogLocation = StringParam{"Origin.UploadLogLocation"}
 	Origin_Url = StringParam{"Origin.Url"}
 	Origin_XRootDPrefix = StringParam{"Origin.XRootDPrefix"}
 	Origin_XRootServiceUrl = StringParam{"Origin.XRootServiceUrl"}
 	Plugin_Token = StringParam{"Plugin.Token"}
 	Registry_DbLocation = StringParam{"Registry.DbLocation"}
 	Registry_InstitutionsUrl = StringParam{"Registry.InstitutionsUrl"}
+	Registry_PrefixCollisionPolicy = StringParam{"Registry.PrefixCollisionPolicy"}
+	Registry_WebhookSecretFile = StringParam{"Registry.WebhookSecretFile"}
+	Server_ClientCertCAFile = StringParam{"Server.ClientCertCAFile"}
 	Server_ExternalWebUrl = StringParam{"Server.ExternalWebUrl"}
 	Server_Hostname = StringParam{"Server.Hostname"}
 	Server_IssuerHostname = StringParam{"Server.IssuerHostname"}
 	Server_IssuerJwks = StringParam{"Server.IssuerJwks"}
 	Server_IssuerUrl = StringParam{"Server.IssuerUrl"}
 	Server_SessionSecretFile = StringParam{"Server.SessionSecretFile"}
+	Server_Site = StringParam{"Server.Site"}
 	Server_TLSCACertificateDirectory = StringParam{"Server.TLSCACertificateDirectory"}
 	Server_TLSCACertificateFile = StringParam{"Server.TLSCACertificateFile"}
 	Server_TLSCAKey = StringParam{"Server.TLSCAKey"}
 	Server_TLSCertificate = StringParam{"Server.TLSCertificate"}
 	Server_TLSKey = StringParam{"Server.TLSKey"}
+	Server_TLSMinVersion = StringParam{"Server.TLSMinVersion"}
+	Server_TokenAudienceValidation = StringParam{"Server.TokenAudienceValidation"}
 	Server_UIActivationCodeFile = StringParam{"Server.UIActivationCodeFile"}
 	Server_UIPasswordFile = StringParam{"Server.UIPasswordFile"}
 	Server_WebConfigFile = StringParam{"Server.WebConfigFile"}
@@ -275,36 +292,60 @@ var (
 	Cache_DataLocations = StringSliceParam{"Cache.DataLocations"}
 	Cache_MetaLocations = StringSliceParam{"Cache.MetaLocations"}
 	Cache_PermittedNamespaces = StringSliceParam{"Cache.PermittedNamespaces"}
+	Cache_UpstreamCaches = StringSliceParam{"Cache.UpstreamCaches"}
 	ConfigLocations = StringSliceParam{"ConfigLocations"}
 	Director_CacheResponseHostnames = StringSliceParam{"Director.CacheResponseHostnames"}
 	Director_FilteredServers = StringSliceParam{"Director.FilteredServers"}
+	Director_NamespaceAllowlist = StringSliceParam{"Director.NamespaceAllowlist"}
+	Director_NamespaceDenylist = StringSliceParam{"Director.NamespaceDenylist"}
 	Director_OriginResponseHostnames = StringSliceParam{"Director.OriginResponseHostnames"}
 	Issuer_GroupRequirements = StringSliceParam{"Issuer.GroupRequirements"}
 	Monitoring_AggregatePrefixes = StringSliceParam{"Monitoring.AggregatePrefixes"}
 	Origin_ExportVolumes = StringSliceParam{"Origin.ExportVolumes"}
 	Origin_ScitokensRestrictedPaths = StringSliceParam{"Origin.ScitokensRestrictedPaths"}
 	Registry_AdminUsers = StringSliceParam{"Registry.AdminUsers"}
+	Registry_WebhookUrls = StringSliceParam{"Registry.WebhookUrls"}
+	Server_AlternateHostnames = StringSliceParam{"Server.AlternateHostnames"}
+	Server_CORSAllowedHeaders = StringSliceParam{"Server.CORSAllowedHeaders"}
+	Server_CORSAllowedMethods = StringSliceParam{"Server.CORSAllowedMethods"}
+	Server_CORSAllowedOrigins = StringSliceParam{"Server.CORSAllowedOrigins"}
 	Server_Modules = StringSliceParam{"Server.Modules"}
+	Server_TLSCipherSuites = StringSliceParam{"Server.TLSCipherSuites"}
+	Server_TokenAudiences = StringSliceParam{"Server.TokenAudiences"}
 	Server_UIAdminUsers = StringSliceParam{"Server.UIAdminUsers"}
 	Shoveler_OutputDestinations = StringSliceParam{"Shoveler.OutputDestinations"}
 )
 
 var (
 	Cache_Concurrency = IntParam{"Cache.Concurrency"}
+	Cache_MaxBytesPerSecTotal = IntParam{"Cache.MaxBytesPerSecTotal"}
 	Cache_Port = IntParam{"Cache.Port"}
+	Client_DownloadStreams = IntParam{"Client.DownloadStreams"}
 	Client_MaximumDownloadSpeed = IntParam{"Client.MaximumDownloadSpeed"}
+	Client_MaximumTransferAttempts = IntParam{"Client.MaximumTransferAttempts"}
 	Client_MinimumDownloadSpeed = IntParam{"Client.MinimumDownloadSpeed"}
 	Client_WorkerCount = IntParam{"Client.WorkerCount"}
+	Director_AdvertiseRateLimit = IntParam{"Director.AdvertiseRateLimit"}
+	Director_FailurePenalty = IntParam{"Director.FailurePenalty"}
+	Director_HealthFailureThreshold = IntParam{"Director.HealthFailureThreshold"}
 	Director_MaxStatResponse = IntParam{"Director.MaxStatResponse"}
 	Director_MinStatResponse = IntParam{"Director.MinStatResponse"}
+	Director_RedirectStatusCode = IntParam{"Director.RedirectStatusCode"}
+	Director_RegistryBreakerThreshold = IntParam{"Director.RegistryBreakerThreshold"}
 	Director_StatConcurrencyLimit = IntParam{"Director.StatConcurrencyLimit"}
 	LocalCache_HighWaterMarkPercentage = IntParam{"LocalCache.HighWaterMarkPercentage"}
 	LocalCache_LowWaterMarkPercentage = IntParam{"LocalCache.LowWaterMarkPercentage"}
 	MinimumDownloadSpeed = IntParam{"MinimumDownloadSpeed"}
 	Monitoring_PortHigher = IntParam{"Monitoring.PortHigher"}
 	Monitoring_PortLower = IntParam{"Monitoring.PortLower"}
+	Origin_MaxBytesPerSecPerConn = IntParam{"Origin.MaxBytesPerSecPerConn"}
+	Origin_MaxBytesPerSecTotal = IntParam{"Origin.MaxBytesPerSecTotal"}
+	Origin_MaxConcurrency = IntParam{"Origin.MaxConcurrency"}
 	Origin_Port = IntParam{"Origin.Port"}
+	Origin_Weight = IntParam{"Origin.Weight"}
+	Registry_WebhookMaxRetries = IntParam{"Registry.WebhookMaxRetries"}
 	Server_IssuerPort = IntParam{"Server.IssuerPort"}
+	Server_TokenCacheSize = IntParam{"Server.TokenCacheSize"}
 	Server_UILoginRateLimit = IntParam{"Server.UILoginRateLimit"}
 	Server_WebPort = IntParam{"Server.WebPort"}
 	Shoveler_PortHigher = IntParam{"Shoveler.PortHigher"}
@@ -321,6 +362,7 @@ var (
 	Cache_EnableOIDC = BoolParam{"Cache.EnableOIDC"}
 	Cache_EnableVoms = BoolParam{"Cache.EnableVoms"}
 	Cache_SelfTest = BoolParam{"Cache.SelfTest"}
+	Client_ConditionalGet = BoolParam{"Client.ConditionalGet"}
 	Client_DisableHttpProxy = BoolParam{"Client.DisableHttpProxy"}
 	Client_DisableProxyFallback = BoolParam{"Client.DisableProxyFallback"}
 	Debug = BoolParam{"Debug"}
@@ -328,6 +370,11 @@ var (
 	Director_EnableBroker = BoolParam{"Director.EnableBroker"}
 	Director_EnableOIDC = BoolParam{"Director.EnableOIDC"}
 	Director_EnableStat = BoolParam{"Director.EnableStat"}
+	Director_EnableStickySessions = BoolParam{"Director.EnableStickySessions"}
+	Director_MetalinkHeaders = BoolParam{"Director.MetalinkHeaders"}
+	Director_PreferSameSiteCaches = BoolParam{"Director.PreferSameSiteCaches"}
+	Director_RequireSignedAdvertisements = BoolParam{"Director.RequireSignedAdvertisements"}
+	Director_UseCacheHierarchy = BoolParam{"Director.UseCacheHierarchy"}
 	DisableHttpProxy = BoolParam{"DisableHttpProxy"}
 	DisableProxyFallback = BoolParam{"DisableProxyFallback"}
 	Issuer_UserStripDomain = BoolParam{"Issuer.UserStripDomain"}
@@ -335,6 +382,7 @@ var (
 	Lotman_EnableAPI = BoolParam{"Lotman.EnableAPI"}
 	Monitoring_MetricAuthorization = BoolParam{"Monitoring.MetricAuthorization"}
 	Monitoring_PromQLAuthorization = BoolParam{"Monitoring.PromQLAuthorization"}
+	Origin_AtomicUploads = BoolParam{"Origin.AtomicUploads"}
 	Origin_DirectorTest = BoolParam{"Origin.DirectorTest"}
 	Origin_EnableBroker = BoolParam{"Origin.EnableBroker"}
 	Origin_EnableCmsd = BoolParam{"Origin.EnableCmsd"}
@@ -352,11 +400,14 @@ var (
 	Origin_EnableWrite = BoolParam{"Origin.EnableWrite"}
 	Origin_EnableWrites = BoolParam{"Origin.EnableWrites"}
 	Origin_Multiuser = BoolParam{"Origin.Multiuser"}
+	Origin_S3PresignRedirects = BoolParam{"Origin.S3PresignRedirects"}
 	Origin_ScitokensMapSubject = BoolParam{"Origin.ScitokensMapSubject"}
 	Origin_SelfTest = BoolParam{"Origin.SelfTest"}
 	Registry_RequireCacheApproval = BoolParam{"Registry.RequireCacheApproval"}
 	Registry_RequireKeyChaining = BoolParam{"Registry.RequireKeyChaining"}
 	Registry_RequireOriginApproval = BoolParam{"Registry.RequireOriginApproval"}
+	Server_CORSAllowCredentials = BoolParam{"Server.CORSAllowCredentials"}
+	Server_EnableHTTP3 = BoolParam{"Server.EnableHTTP3"}
 	Server_EnablePprof = BoolParam{"Server.EnablePprof"}
 	Server_EnableUI = BoolParam{"Server.EnableUI"}
 	Shoveler_Enable = BoolParam{"Shoveler.Enable"}
@@ -367,19 +418,40 @@ var (
 
 var (
 	Cache_SelfTestInterval = DurationParam{"Cache.SelfTestInterval"}
+	Client_MaximumRetryDuration = DurationParam{"Client.MaximumRetryDuration"}
+	Client_RetryBackoffBase = DurationParam{"Client.RetryBackoffBase"}
 	Client_SlowTransferRampupTime = DurationParam{"Client.SlowTransferRampupTime"}
 	Client_SlowTransferWindow = DurationParam{"Client.SlowTransferWindow"}
 	Client_StoppedTransferTimeout = DurationParam{"Client.StoppedTransferTimeout"}
+	Director_AdvertiseFetchTimeout = DurationParam{"Director.AdvertiseFetchTimeout"}
 	Director_AdvertisementTTL = DurationParam{"Director.AdvertisementTTL"}
+	Director_BrokerHeartbeatTimeout = DurationParam{"Director.BrokerHeartbeatTimeout"}
+	Director_CacheAdTTL = DurationParam{"Director.CacheAdTTL"}
+	Director_FailurePenaltyWindow = DurationParam{"Director.FailurePenaltyWindow"}
+	Director_HealthTestDegradedLatency = DurationParam{"Director.HealthTestDegradedLatency"}
+	Director_NamespaceStatsPushInterval = DurationParam{"Director.NamespaceStatsPushInterval"}
+	Director_NotFoundCacheTTL = DurationParam{"Director.NotFoundCacheTTL"}
+	Director_OriginAdTTL = DurationParam{"Director.OriginAdTTL"}
 	Director_OriginCacheHealthTestInterval = DurationParam{"Director.OriginCacheHealthTestInterval"}
+	Director_OriginInFlightWindow = DurationParam{"Director.OriginInFlightWindow"}
+	Director_RegistryBreakerCooldown = DurationParam{"Director.RegistryBreakerCooldown"}
+	Director_StaleAdGracePeriod = DurationParam{"Director.StaleAdGracePeriod"}
 	Director_StatTimeout = DurationParam{"Director.StatTimeout"}
+	Director_StickySessionTTL = DurationParam{"Director.StickySessionTTL"}
 	Federation_TopologyReloadInterval = DurationParam{"Federation.TopologyReloadInterval"}
 	Monitoring_TokenExpiresIn = DurationParam{"Monitoring.TokenExpiresIn"}
 	Monitoring_TokenRefreshInterval = DurationParam{"Monitoring.TokenRefreshInterval"}
+	Origin_DefaultCacheTTL = DurationParam{"Origin.DefaultCacheTTL"}
+	Origin_S3PresignExpiry = DurationParam{"Origin.S3PresignExpiry"}
 	Origin_SelfTestInterval = DurationParam{"Origin.SelfTestInterval"}
+	Origin_StagedUploadMaxAge = DurationParam{"Origin.StagedUploadMaxAge"}
 	Registry_InstitutionsUrlReloadMinutes = DurationParam{"Registry.InstitutionsUrlReloadMinutes"}
+	Registry_JwksCacheMaxAge = DurationParam{"Registry.JwksCacheMaxAge"}
+	Registry_KeyRetirementGracePeriod = DurationParam{"Registry.KeyRetirementGracePeriod"}
+	Registry_NamespaceTransferExpiration = DurationParam{"Registry.NamespaceTransferExpiration"}
 	Server_RegistrationRetryInterval = DurationParam{"Server.RegistrationRetryInterval"}
 	Server_StartupTimeout = DurationParam{"Server.StartupTimeout"}
+	Server_TokenCacheTTL = DurationParam{"Server.TokenCacheTTL"}
 	Transport_DialerKeepAlive = DurationParam{"Transport.DialerKeepAlive"}
 	Transport_DialerTimeout = DurationParam{"Transport.DialerTimeout"}
 	Transport_ExpectContinueTimeout = DurationParam{"Transport.ExpectContinueTimeout"}
@@ -390,12 +462,17 @@ var (
 )
 
 var (
+	Director_ClientSites = ObjectParam{"Director.ClientSites"}
 	GeoIPOverrides = ObjectParam{"GeoIPOverrides"}
 	Issuer_AuthorizationTemplates = ObjectParam{"Issuer.AuthorizationTemplates"}
 	Issuer_OIDCAuthenticationRequirements = ObjectParam{"Issuer.OIDCAuthenticationRequirements"}
 	Lotman_Lots = ObjectParam{"Lotman.Lots"}
 	Origin_Exports = ObjectParam{"Origin.Exports"}
+	Origin_PathRewrites = ObjectParam{"Origin.PathRewrites"}
 	Registry_CustomRegistrationFields = ObjectParam{"Registry.CustomRegistrationFields"}
 	Registry_Institutions = ObjectParam{"Registry.Institutions"}
+	Registry_NamespaceAliases = ObjectParam{"Registry.NamespaceAliases"}
+	Server_ClientCertIdentities = ObjectParam{"Server.ClientCertIdentities"}
+	Server_Labels = ObjectParam{"Server.Labels"}
 	Shoveler_IPMapping = ObjectParam{"Shoveler.IPMapping"}
 )
@@ -35,6 +35,7 @@ type Config struct {
 		HighWaterMark string `mapstructure:"highwatermark"`
 		LocalRoot string `mapstructure:"localroot"`
 		LowWatermark string `mapstructure:"lowwatermark"`
+		MaxBytesPerSecTotal int `mapstructure:"maxbytespersectotal"`
 		MetaLocations []string `mapstructure:"metalocations"`
 		PermittedNamespaces []string `mapstructure:"permittednamespaces"`
 		Port int `mapstructure:"port"`
@@ -42,42 +43,81 @@ type Config struct {
 		SelfTest bool `mapstructure:"selftest"`
 		SelfTestInterval time.Duration `mapstructure:"selftestinterval"`
 		SentinelLocation string `mapstructure:"sentinellocation"`
+		UpstreamCaches []string `mapstructure:"upstreamcaches"`
 		Url string `mapstructure:"url"`
 		XRootDPrefix string `mapstructure:"xrootdprefix"`
 	} `mapstructure:"cache"`
 	Client struct {
+		ChecksumAlgorithm string `mapstructure:"checksumalgorithm"`
+		ConditionalGet bool `mapstructure:"conditionalget"`
 		DisableHttpProxy bool `mapstructure:"disablehttpproxy"`
 		DisableProxyFallback bool `mapstructure:"disableproxyfallback"`
+		DownloadStreams int `mapstructure:"downloadstreams"`
 		MaximumDownloadSpeed int `mapstructure:"maximumdownloadspeed"`
+		MaximumRetryDuration time.Duration `mapstructure:"maximumretryduration"`
+		MaximumTransferAttempts int `mapstructure:"maximumtransferattempts"`
 		MinimumDownloadSpeed int `mapstructure:"minimumdownloadspeed"`
+		RetryBackoffBase time.Duration `mapstructure:"retrybackoffbase"`
 		SlowTransferRampupTime time.Duration `mapstructure:"slowtransferrampuptime"`
 		SlowTransferWindow time.Duration `mapstructure:"slowtransferwindow"`
 		StoppedTransferTimeout time.Duration `mapstructure:"stoppedtransfertimeout"`
+		VerifyChecksum string `mapstructure:"verifychecksum"`
 		WorkerCount int `mapstructure:"workercount"`
 	} `mapstructure:"client"`
 	ConfigDir string `mapstructure:"configdir"`
 	ConfigLocations []string `mapstructure:"configlocations"`
 	Debug bool `mapstructure:"debug"`
 	Director struct {
+		AdvertiseFetchTimeout time.Duration `mapstructure:"advertisefetchtimeout"`
+		AdvertiseRateLimit int `mapstructure:"advertiseratelimit"`
 		AdvertisementTTL time.Duration `mapstructure:"advertisementttl"`
+		BrokerHeartbeatTimeout time.Duration `mapstructure:"brokerheartbeattimeout"`
+		CacheAdTTL time.Duration `mapstructure:"cacheadttl"`
 		CacheResponseHostnames []string `mapstructure:"cacheresponsehostnames"`
+		CacheSelectionStrategy string `mapstructure:"cacheselectionstrategy"`
 		CacheSortMethod string `mapstructure:"cachesortmethod"`
 		CachesPullFromCaches bool `mapstructure:"cachespullfromcaches"`
+		ClientSites interface{} `mapstructure:"clientsites"`
 		DefaultResponse string `mapstructure:"defaultresponse"`
 		EnableBroker bool `mapstructure:"enablebroker"`
 		EnableOIDC bool `mapstructure:"enableoidc"`
 		EnableStat bool `mapstructure:"enablestat"`
+		EnableStickySessions bool `mapstructure:"enablestickysessions"`
+		FailurePenalty int `mapstructure:"failurepenalty"`
+		FailurePenaltyWindow time.Duration `mapstructure:"failurepenaltywindow"`
 		FilteredServers []string `mapstructure:"filteredservers"`
+		FilteredServersStateFile string `mapstructure:"filteredserversstatefile"`
 		GeoIPLocation string `mapstructure:"geoiplocation"`
+		GeoIPProvider string `mapstructure:"geoipprovider"`
+		GeoIPStaticFile string `mapstructure:"geoipstaticfile"`
+		HealthFailureThreshold int `mapstructure:"healthfailurethreshold"`
+		HealthTestDegradedLatency time.Duration `mapstructure:"healthtestdegradedlatency"`
 		MaxMindKeyFile string `mapstructure:"maxmindkeyfile"`
 		MaxStatResponse int `mapstructure:"maxstatresponse"`
+		MetalinkHeaders bool `mapstructure:"metalinkheaders"`
+		MinOriginFreeSpace string `mapstructure:"minoriginfreespace"`
 		MinStatResponse int `mapstructure:"minstatresponse"`
+		NamespaceAllowlist []string `mapstructure:"namespaceallowlist"`
+		NamespaceDenylist []string `mapstructure:"namespacedenylist"`
+		NamespaceStatsPushInterval time.Duration `mapstructure:"namespacestatspushinterval"`
+		NamespaceStatsStateFile string `mapstructure:"namespacestatsstatefile"`
+		NotFoundCacheTTL time.Duration `mapstructure:"notfoundcachettl"`
+		OriginAdTTL time.Duration `mapstructure:"originadttl"`
 		OriginCacheHealthTestInterval time.Duration `mapstructure:"origincachehealthtestinterval"`
+		OriginInFlightWindow time.Duration `mapstructure:"origininflightwindow"`
 		OriginResponseHostnames []string `mapstructure:"originresponsehostnames"`
+		PreferSameSiteCaches bool `mapstructure:"prefersamesitecaches"`
+		RedirectStatusCode int `mapstructure:"redirectstatuscode"`
+		RegistryBreakerCooldown time.Duration `mapstructure:"registrybreakercooldown"`
+		RegistryBreakerThreshold int `mapstructure:"registrybreakerthreshold"`
+		RequireSignedAdvertisements bool `mapstructure:"requiresignedadvertisements"`
+		StaleAdGracePeriod time.Duration `mapstructure:"staleadgraceperiod"`
 		StatConcurrencyLimit int `mapstructure:"statconcurrencylimit"`
 		StatTimeout time.Duration `mapstructure:"stattimeout"`
+		StickySessionTTL time.Duration `mapstructure:"stickysessionttl"`
 		SupportContactEmail string `mapstructure:"supportcontactemail"`
 		SupportContactUrl string `mapstructure:"supportcontacturl"`
+		UseCacheHierarchy bool `mapstructure:"usecachehierarchy"`
 	} `mapstructure:"director"`
 	DisableHttpProxy bool `mapstructure:"disablehttpproxy"`
 	DisableProxyFallback bool `mapstructure:"disableproxyfallback"`
@@ -168,7 +208,11 @@ type Config struct {
 		UserInfoEndpoint string `mapstructure:"userinfoendpoint"`
 	} `mapstructure:"oidc"`
 	Origin struct {
+		AccessLogLocation string `mapstructure:"accessloglocation"`
+		AccessLogSubject string `mapstructure:"accesslogsubject"`
+		AtomicUploads bool `mapstructure:"atomicuploads"`
 		DbLocation string `mapstructure:"dblocation"`
+		DefaultCacheTTL time.Duration `mapstructure:"defaultcachettl"`
 		DirectorTest bool `mapstructure:"directortest"`
 		EnableBroker bool `mapstructure:"enablebroker"`
 		EnableCmsd bool `mapstructure:"enablecmsd"`
@@ -196,13 +240,19 @@ type Config struct {
 		GlobusConfigLocation string `mapstructure:"globusconfiglocation"`
 		HttpAuthTokenFile string `mapstructure:"httpauthtokenfile"`
 		HttpServiceUrl string `mapstructure:"httpserviceurl"`
+		MaxBytesPerSecPerConn int `mapstructure:"maxbytespersecperconn"`
+		MaxBytesPerSecTotal int `mapstructure:"maxbytespersectotal"`
+		MaxConcurrency int `mapstructure:"maxconcurrency"`
 		Mode string `mapstructure:"mode"`
 		Multiuser bool `mapstructure:"multiuser"`
 		NamespacePrefix string `mapstructure:"namespaceprefix"`
+		PathRewrites interface{} `mapstructure:"pathrewrites"`
 		Port int `mapstructure:"port"`
 		RunLocation string `mapstructure:"runlocation"`
 		S3AccessKeyfile string `mapstructure:"s3accesskeyfile"`
 		S3Bucket string `mapstructure:"s3bucket"`
+		S3PresignExpiry time.Duration `mapstructure:"s3presignexpiry"`
+		S3PresignRedirects bool `mapstructure:"s3presignredirects"`
 		S3Region string `mapstructure:"s3region"`
 		S3SecretKeyfile string `mapstructure:"s3secretkeyfile"`
 		S3ServiceName string `mapstructure:"s3servicename"`
@@ -215,9 +265,12 @@ type Config struct {
 		ScitokensUsernameClaim string `mapstructure:"scitokensusernameclaim"`
 		SelfTest bool `mapstructure:"selftest"`
 		SelfTestInterval time.Duration `mapstructure:"selftestinterval"`
+		StagedUploadMaxAge time.Duration `mapstructure:"stageduploadmaxage"`
 		StoragePrefix string `mapstructure:"storageprefix"`
 		StorageType string `mapstructure:"storagetype"`
+		UploadLogLocation string `mapstructure:"uploadloglocation"`
 		Url string `mapstructure:"url"`
+		Weight int `mapstructure:"weight"`
 		XRootDPrefix string `mapstructure:"xrootdprefix"`
 		XRootServiceUrl string `mapstructure:"xrootserviceurl"`
 	} `mapstructure:"origin"`
@@ -231,11 +284,27 @@ type Config struct {
 		Institutions interface{} `mapstructure:"institutions"`
 		InstitutionsUrl string `mapstructure:"institutionsurl"`
 		InstitutionsUrlReloadMinutes time.Duration `mapstructure:"institutionsurlreloadminutes"`
+		JwksCacheMaxAge time.Duration `mapstructure:"jwkscachemaxage"`
+		KeyRetirementGracePeriod time.Duration `mapstructure:"keyretirementgraceperiod"`
+		NamespaceAliases interface{} `mapstructure:"namespacealiases"`
+		NamespaceTransferExpiration time.Duration `mapstructure:"namespacetransferexpiration"`
+		PrefixCollisionPolicy string `mapstructure:"prefixcollisionpolicy"`
 		RequireCacheApproval bool `mapstructure:"requirecacheapproval"`
 		RequireKeyChaining bool `mapstructure:"requirekeychaining"`
 		RequireOriginApproval bool `mapstructure:"requireoriginapproval"`
+		WebhookMaxRetries int `mapstructure:"webhookmaxretries"`
+		WebhookSecretFile string `mapstructure:"webhooksecretfile"`
+		WebhookUrls []string `mapstructure:"webhookurls"`
 	} `mapstructure:"registry"`
 	Server struct {
+		AlternateHostnames []string `mapstructure:"alternatehostnames"`
+		CORSAllowCredentials bool `mapstructure:"corsallowcredentials"`
+		CORSAllowedHeaders []string `mapstructure:"corsallowedheaders"`
+		CORSAllowedMethods []string `mapstructure:"corsallowedmethods"`
+		CORSAllowedOrigins []string `mapstructure:"corsallowedorigins"`
+		ClientCertCAFile string `mapstructure:"clientcertcafile"`
+		ClientCertIdentities interface{} `mapstructure:"clientcertidentities"`
+		EnableHTTP3 bool `mapstructure:"enablehttp3"`
 		EnablePprof bool `mapstructure:"enablepprof"`
 		EnableUI bool `mapstructure:"enableui"`
 		ExternalWebUrl string `mapstructure:"externalweburl"`
@@ -244,15 +313,23 @@ type Config struct {
 		IssuerJwks string `mapstructure:"issuerjwks"`
 		IssuerPort int `mapstructure:"issuerport"`
 		IssuerUrl string `mapstructure:"issuerurl"`
+		Labels interface{} `mapstructure:"labels"`
 		Modules []string `mapstructure:"modules"`
 		RegistrationRetryInterval time.Duration `mapstructure:"registrationretryinterval"`
 		SessionSecretFile string `mapstructure:"sessionsecretfile"`
+		Site string `mapstructure:"site"`
 		StartupTimeout time.Duration `mapstructure:"startuptimeout"`
 		TLSCACertificateDirectory string `mapstructure:"tlscacertificatedirectory"`
 		TLSCACertificateFile string `mapstructure:"tlscacertificatefile"`
 		TLSCAKey string `mapstructure:"tlscakey"`
 		TLSCertificate string `mapstructure:"tlscertificate"`
+		TLSCipherSuites []string `mapstructure:"tlsciphersuites"`
 		TLSKey string `mapstructure:"tlskey"`
+		TLSMinVersion string `mapstructure:"tlsminversion"`
+		TokenAudienceValidation string `mapstructure:"tokenaudiencevalidation"`
+		TokenAudiences []string `mapstructure:"tokenaudiences"`
+		TokenCacheSize int `mapstructure:"tokencachesize"`
+		TokenCacheTTL time.Duration `mapstructure:"tokencachettl"`
 		UIActivationCodeFile string `mapstructure:"uiactivationcodefile"`
 		UIAdminUsers []string `mapstructure:"uiadminusers"`
 		UILoginRateLimit int `mapstructure:"uiloginratelimit"`
@@ -329,6 +406,7 @@ type configWithType struct {
 		HighWaterMark struct { Type string; Value string }
 		LocalRoot struct { Type string; Value string }
 		LowWatermark struct { Type string; Value string }
+		MaxBytesPerSecTotal struct { Type string; Value int }
 		MetaLocations struct { Type string; Value []string }
 		PermittedNamespaces struct { Type string; Value []string }
 		Port struct { Type string; Value int }
@@ -336,42 +414,81 @@ type configWithType struct {
 		SelfTest struct { Type string; Value bool }
 		SelfTestInterval struct { Type string; Value time.Duration }
 		SentinelLocation struct { Type string; Value string }
+		UpstreamCaches struct { Type string; Value []string }
 		Url struct { Type string; Value string }
 		XRootDPrefix struct { Type string; Value string }
 	}
 	Client struct {
+		ChecksumAlgorithm struct { Type string; Value string }
+		ConditionalGet struct { Type string; Value bool }
 		DisableHttpProxy struct { Type string; Value bool }
 		DisableProxyFallback struct { Type string; Value bool }
+		DownloadStreams struct { Type string; Value int }
 		MaximumDownloadSpeed struct { Type string; Value int }
+		MaximumRetryDuration struct { Type string; Value time.Duration }
+		MaximumTransferAttempts struct { Type string; Value int }
 		MinimumDownloadSpeed struct { Type string; Value int }
+		RetryBackoffBase struct { Type string; Value time.Duration }
 		SlowTransferRampupTime struct { Type string; Value time.Duration }
 		SlowTransferWindow struct { Type string; Value time.Duration }
 		StoppedTransferTimeout struct { Type string; Value time.Duration }
+		VerifyChecksum struct { Type string; Value string }
 		WorkerCount struct { Type string; Value int }
 	}
 	ConfigDir struct { Type string; Value string }
 	ConfigLocations struct { Type string; Value []string }
 	Debug struct { Type string; Value bool }
 	Director struct {
+		AdvertiseFetchTimeout struct { Type string; Value time.Duration }
+		AdvertiseRateLimit struct { Type string; Value int }
 		AdvertisementTTL struct { Type string; Value time.Duration }
+		BrokerHeartbeatTimeout struct { Type string; Value time.Duration }
+		CacheAdTTL struct { Type string; Value time.Duration }
 		CacheResponseHostnames struct { Type string; Value []string }
+		CacheSelectionStrategy struct { Type string; Value string }
 		CacheSortMethod struct { Type string; Value string }
 		CachesPullFromCaches struct { Type string; Value bool }
+		ClientSites struct { Type string; Value interface{} }
 		DefaultResponse struct { Type string; Value string }
 		EnableBroker struct { Type string; Value bool }
 		EnableOIDC struct { Type string; Value bool }
 		EnableStat struct { Type string; Value bool }
+		EnableStickySessions struct { Type string; Value bool }
+		FailurePenalty struct { Type string; Value int }
+		FailurePenaltyWindow struct { Type string; Value time.Duration }
 		FilteredServers struct { Type string; Value []string }
+		FilteredServersStateFile struct { Type string; Value string }
 		GeoIPLocation struct { Type string; Value string }
+		GeoIPProvider struct { Type string; Value string }
+		GeoIPStaticFile struct { Type string; Value string }
+		HealthFailureThreshold struct { Type string; Value int }
+		HealthTestDegradedLatency struct { Type string; Value time.Duration }
 		MaxMindKeyFile struct { Type string; Value string }
 		MaxStatResponse struct { Type string; Value int }
+		MetalinkHeaders struct { Type string; Value bool }
+		MinOriginFreeSpace struct { Type string; Value string }
 		MinStatResponse struct { Type string; Value int }
+		NamespaceAllowlist struct { Type string; Value []string }
+		NamespaceDenylist struct { Type string; Value []string }
+		NamespaceStatsPushInterval struct { Type string; Value time.Duration }
+		NamespaceStatsStateFile struct { Type string; Value string }
+		NotFoundCacheTTL struct { Type string; Value time.Duration }
+		OriginAdTTL struct { Type string; Value time.Duration }
 		OriginCacheHealthTestInterval struct { Type string; Value time.Duration }
+		OriginInFlightWindow struct { Type string; Value time.Duration }
 		OriginResponseHostnames struct { Type string; Value []string }
+		PreferSameSiteCaches struct { Type string; Value bool }
+		RedirectStatusCode struct { Type string; Value int }
+		RegistryBreakerCooldown struct { Type string; Value time.Duration }
+		RegistryBreakerThreshold struct { Type string; Value int }
+		RequireSignedAdvertisements struct { Type string; Value bool }
+		StaleAdGracePeriod struct { Type string; Value time.Duration }
 		StatConcurrencyLimit struct { Type string; Value int }
 		StatTimeout struct { Type string; Value time.Duration }
+		StickySessionTTL struct { Type string; Value time.Duration }
 		SupportContactEmail struct { Type string; Value string }
 		SupportContactUrl struct { Type string; Value string }
+		UseCacheHierarchy struct { Type string; Value bool }
 	}
 	DisableHttpProxy struct { Type string; Value bool }
 	DisableProxyFallback struct { Type string; Value bool }
@@ -462,7 +579,11 @@ type configWithType struct {
 		UserInfoEndpoint struct { Type string; Value string }
 	}
 	Origin struct {
+		AccessLogLocation struct { Type string; Value string }
+		AccessLogSubject struct { Type string; Value string }
+		AtomicUploads struct { Type string; Value bool }
 		DbLocation struct { Type string; Value string }
+		DefaultCacheTTL struct { Type string; Value time.Duration }
 		DirectorTest struct { Type string; Value bool }
 		EnableBroker struct { Type string; Value bool }
 		EnableCmsd struct { Type string; Value bool }
@@ -490,13 +611,19 @@ type configWithType struct {
 		GlobusConfigLocation struct { Type string; Value string }
 		HttpAuthTokenFile struct { Type string; Value string }
 		HttpServiceUrl struct { Type string; Value string }
+		MaxBytesPerSecPerConn struct { Type string; Value int }
+		MaxBytesPerSecTotal struct { Type string; Value int }
+		MaxConcurrency struct { Type string; Value int }
 		Mode struct { Type string; Value string }
 		Multiuser struct { Type string; Value bool }
 		NamespacePrefix struct { Type string; Value string }
+		PathRewrites struct { Type string; Value interface{} }
 		Port struct { Type string; Value int }
 		RunLocation struct { Type string; Value string }
 		S3AccessKeyfile struct { Type string; Value string }
 		S3Bucket struct { Type string; Value string }
+		S3PresignExpiry struct { Type string; Value time.Duration }
+		S3PresignRedirects struct { Type string; Value bool }
 		S3Region struct { Type string; Value string }
 		S3SecretKeyfile struct { Type string; Value string }
 		S3ServiceName struct { Type string; Value string }
@@ -509,9 +636,12 @@ type configWithType struct {
 		ScitokensUsernameClaim struct { Type string; Value string }
 		SelfTest struct { Type string; Value bool }
 		SelfTestInterval struct { Type string; Value time.Duration }
+		StagedUploadMaxAge struct { Type string; Value time.Duration }
 		StoragePrefix struct { Type string; Value string }
 		StorageType struct { Type string; Value string }
+		UploadLogLocation struct { Type string; Value string }
 		Url struct { Type string; Value string }
+		Weight struct { Type string; Value int }
 		XRootDPrefix struct { Type string; Value string }
 		XRootServiceUrl struct { Type string; Value string }
 	}
@@ -525,11 +655,27 @@ type configWithType struct {
 		Institutions struct { Type string; Value interface{} }
 		InstitutionsUrl struct { Type string; Value string }
 		InstitutionsUrlReloadMinutes struct { Type string; Value time.Duration }
+		JwksCacheMaxAge struct { Type string; Value time.Duration }
+		KeyRetirementGracePeriod struct { Type string; Value time.Duration }
+		NamespaceAliases struct { Type string; Value interface{} }
+		NamespaceTransferExpiration struct { Type string; Value time.Duration }
+		PrefixCollisionPolicy struct { Type string; Value string }
 		RequireCacheApproval struct { Type string; Value bool }
 		RequireKeyChaining struct { Type string; Value bool }
 		RequireOriginApproval struct { Type string; Value bool }
+		WebhookMaxRetries struct { Type string; Value int }
+		WebhookSecretFile struct { Type string; Value string }
+		WebhookUrls struct { Type string; Value []string }
 	}
 	Server struct {
+		AlternateHostnames struct { Type string; Value []string }
+		CORSAllowCredentials struct { Type string; Value bool }
+		CORSAllowedHeaders struct { Type string; Value []string }
+		CORSAllowedMethods struct { Type string; Value []string }
+		CORSAllowedOrigins struct { Type string; Value []string }
+		ClientCertCAFile struct { Type string; Value string }
+		ClientCertIdentities struct { Type string; Value interface{} }
+		EnableHTTP3 struct { Type string; Value bool }
 		EnablePprof struct { Type string; Value bool }
 		EnableUI struct { Type string; Value bool }
 		ExternalWebUrl struct { Type string; Value string }
@@ -538,15 +684,23 @@ type configWithType struct {
 		IssuerJwks struct { Type string; Value string }
 		IssuerPort struct { Type string; Value int }
 		IssuerUrl struct { Type string; Value string }
+		Labels struct { Type string; Value interface{} }
 		Modules struct { Type string; Value []string }
 		RegistrationRetryInterval struct { Type string; Value time.Duration }
 		SessionSecretFile struct { Type string; Value string }
+		Site struct { Type string; Value string }
 		StartupTimeout struct { Type string; Value time.Duration }
 		TLSCACertificateDirectory struct { Type string; Value string }
 		TLSCACertificateFile struct { Type string; Value string }
 		TLSCAKey struct { Type string; Value string }
 		TLSCertificate struct { Type string; Value string }
+		TLSCipherSuites struct { Type string; Value []string }
 		TLSKey struct { Type string; Value string }
+		TLSMinVersion struct { Type string; Value string }
+		TokenAudienceValidation struct { Type string; Value string }
+		TokenAudiences struct { Type string; Value []string }
+		TokenCacheSize struct { Type string; Value int }
+		TokenCacheTTL struct { Type string; Value time.Duration }
 		UIActivationCodeFile struct { Type string; Value string }
 		UIAdminUsers struct { Type string; Value []string }
 		UILoginRateLimit struct { Type string; Value int }
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS retired_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  namespace_id INTEGER NOT NULL,
+  key_id TEXT NOT NULL,
+  retired_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  UNIQUE (namespace_id, key_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS retired_keys;
+-- +goose StatementEnd
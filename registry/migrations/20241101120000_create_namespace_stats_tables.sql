@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS namespace_stats (
+  prefix TEXT PRIMARY KEY,
+  requests INTEGER NOT NULL DEFAULT 0,
+  updated_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS namespace_stats_windows (
+  window_id TEXT PRIMARY KEY,
+  window_start DATETIME,
+  window_end DATETIME,
+  received_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS namespace_stats_windows;
+DROP TABLE IF EXISTS namespace_stats;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE namespace ADD redirect_policy TEXT CHECK (length("redirect_policy") <= 4000) DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE namespace DROP redirect_policy;
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package registry
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Namespace aliases from Registry.NamespaceAliases, with the key being the alias path and the value being the target
+var namespaceAliases = map[string]string{}
+
+// Initialize the namespace aliases provided via Registry.NamespaceAliases.
+// Returns an error if the aliases are invalid or form a loop
+func InitNamespaceAliases() error {
+	configAliases := []server_structs.NamespaceAlias{}
+	if err := param.Registry_NamespaceAliases.Unmarshal(&configAliases); err != nil {
+		return errors.Wrap(err, "Error reading from config value for Registry.NamespaceAliases")
+	}
+	aliases, err := server_structs.ValidateNamespaceAliases(configAliases)
+	if err != nil {
+		return errors.Wrap(err, "Bad namespace aliases in Registry.NamespaceAliases")
+	}
+	namespaceAliases = aliases
+	return nil
+}
+
+// Get the alias paths resolving to the namespace prefix, sorted
+func getAliasesForPrefix(prefix string) []string {
+	aliases := []string{}
+	for aliasPath, target := range namespaceAliases {
+		if resolved, err := server_structs.ResolveNamespaceAlias(namespaceAliases, target); err == nil && resolved == prefix {
+			aliases = append(aliases, aliasPath)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// List all namespace aliases
+func listNamespaceAliases(ctx *gin.Context) {
+	aliases := make([]server_structs.NamespaceAlias, 0, len(namespaceAliases))
+	for aliasPath, target := range namespaceAliases {
+		aliases = append(aliases, server_structs.NamespaceAlias{Path: aliasPath, Target: target})
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Path < aliases[j].Path })
+	ctx.JSON(http.StatusOK, aliases)
+}
@@ -0,0 +1,50 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package registry
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// List the redirect policies of the approved namespaces that set one, sorted by prefix
+func listRedirectPolicies(ctx *gin.Context) {
+	namespaces, err := getAllNamespaces()
+	if err != nil {
+		log.Errorf("Failed to get namespaces for redirect policies: %v", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Server encountered an error trying to list redirect policies"})
+		return
+	}
+	policies := []server_structs.NamespaceRedirectPolicy{}
+	for _, ns := range namespaces {
+		if ns.AdminMetadata.Status != server_structs.RegApproved || ns.RedirectPolicy.IsDefault() {
+			continue
+		}
+		policies = append(policies, server_structs.NamespaceRedirectPolicy{Prefix: ns.Prefix, Policy: ns.RedirectPolicy})
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Prefix < policies[j].Prefix })
+	ctx.JSON(http.StatusOK, policies)
+}
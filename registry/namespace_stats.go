@@ -0,0 +1,96 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+// A gin route handler for the director to report the redirect requests it counted per namespace
+// during a window. Reports are accumulated once per window ID, so the director may safely retry them
+func reportNamespaceStatsHandler(ctx *gin.Context) {
+	status, ok, err := token.Verify(ctx, token.AuthOption{
+		Sources: []token.TokenSource{token.Header},
+		Issuers: []token.TokenIssuer{token.FederationIssuer},
+		Scopes:  []token_scopes.TokenScope{token_scopes.Pelican_NamespaceStats},
+	})
+	if !ok || err != nil {
+		ctx.JSON(status, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Failed to verify the token: ", err),
+		})
+		return
+	}
+
+	report := server_structs.NamespaceStatsReport{}
+	if err := ctx.ShouldBindJSON(&report); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid namespace stats report: " + err.Error(),
+		})
+		return
+	}
+	for prefix, requests := range report.Requests {
+		if prefix == "" || requests < 0 {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    fmt.Sprintf("Invalid request count %d for the namespace %q", requests, prefix),
+			})
+			return
+		}
+	}
+
+	duplicate, err := accumulateNamespaceStats(report)
+	if err != nil {
+		log.Errorf("Failed to accumulate the namespace stats of window %s: %v", report.WindowID, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to accumulate the namespace stats",
+		})
+		return
+	}
+	if duplicate {
+		log.Debugf("Ignoring the namespace stats of window %s: already accumulated", report.WindowID)
+		ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "duplicate"})
+		return
+	}
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK, Msg: "success"})
+}
+
+// A gin route handler listing the redirect requests accumulated per namespace
+func listNamespaceStats(ctx *gin.Context) {
+	stats, err := getNamespaceStats()
+	if err != nil {
+		log.Errorln("Failed to list the namespace stats:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to list the namespace stats",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,413 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package registry
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+type (
+	// A pending transfer of a namespace's registration to a new owner's key. The current owner
+	// initiates it with a token signed by the namespace's key; it completes once the new owner
+	// accepts it with a token signed by the new key, unless it expires first
+	NamespaceTransfer struct {
+		ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+		NamespaceID int       `json:"namespace_id" gorm:"not null;unique"`
+		NewPubkey   string    `json:"new_pubkey" gorm:"not null"`
+		InitiatedAt time.Time `json:"initiated_at"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+
+	// An entry of the registry's audit log, recording a change of who controls a namespace
+	AuditLogEntry struct {
+		ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+		NamespaceID int       `json:"namespace_id" gorm:"not null"`
+		Prefix      string    `json:"prefix" gorm:"not null"`
+		Action      string    `json:"action" gorm:"not null"`
+		Details     string    `json:"details"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	// The details of a namespace transfer recorded in the audit log
+	transferAuditDetails struct {
+		OldKeyIDs []string  `json:"old_key_ids"`
+		NewKeyID  string    `json:"new_key_id"`
+		ExpiresAt time.Time `json:"expires_at,omitempty"`
+	}
+
+	initiateTransferRequest struct {
+		Prefix    string `json:"prefix" binding:"required"`
+		NewPubkey string `json:"new_pubkey" binding:"required"` // A JWKS holding the new owner's public key
+	}
+
+	acceptTransferRequest struct {
+		Prefix string `json:"prefix" binding:"required"`
+	}
+
+	namespaceTransferResponse struct {
+		Prefix    string     `json:"prefix"`
+		NewKeyID  string     `json:"new_key_id"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+)
+
+const (
+	auditActionTransferInitiated = "namespace_transfer_initiated"
+	auditActionTransferCompleted = "namespace_transfer_completed"
+
+	// Claims binding a transfer token to the namespace and the key it's transferred to
+	transferPrefixClaim      = "prefix"
+	transferNewKeyClaim      = "new_key_thumbprint"
+	maxTransferTokenLifetime = 5 * time.Minute
+)
+
+func (NamespaceTransfer) TableName() string {
+	return "namespace_transfers"
+}
+
+func (AuditLogEntry) TableName() string {
+	return "audit_log"
+}
+
+func jwksKeyIDs(set jwk.Set) []string {
+	kids := make([]string, 0, set.Len())
+	for idx := 0; idx < set.Len(); idx++ {
+		if key, ok := set.Key(idx); ok {
+			kids = append(kids, key.KeyID())
+		}
+	}
+	return kids
+}
+
+func addAuditLogEntry(tx *gorm.DB, ns *server_structs.Namespace, action string, details any) error {
+	detailsBytes, err := json.Marshal(details)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the audit log details")
+	}
+	entry := AuditLogEntry{
+		NamespaceID: ns.ID,
+		Prefix:      ns.Prefix,
+		Action:      action,
+		Details:     string(detailsBytes),
+		CreatedAt:   time.Now(),
+	}
+	return errors.Wrap(tx.Create(&entry).Error, "failed to add the audit log entry")
+}
+
+// Start the transfer of the namespace with the given prefix to newKey, replacing any transfer
+// of the namespace that's already pending. The transfer expires if it's not accepted in time
+func initiateNamespaceTransfer(prefix string, newKey jwk.Key, expiration time.Duration) (*NamespaceTransfer, error) {
+	newSet := jwk.NewSet()
+	if err := newSet.AddKey(newKey); err != nil {
+		return nil, errors.Wrap(err, "failed to add the key to a jwks")
+	}
+	newSetBytes, err := json.Marshal(newSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the new jwks")
+	}
+	now := time.Now()
+	transfer := NamespaceTransfer{NewPubkey: string(newSetBytes), InitiatedAt: now, ExpiresAt: now.Add(expiration)}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var ns server_structs.Namespace
+		if err := tx.Select("id", "prefix", "pubkey").Where("prefix = ?", prefix).Last(&ns).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("namespace with prefix %q not found in database", prefix)
+			}
+			return errors.Wrap(err, "error retrieving pubkey")
+		}
+		set, err := parseNamespaceJwks(ns.Pubkey)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse pubkey as a jwks")
+		}
+		if _, exists := set.LookupKeyID(newKey.KeyID()); exists {
+			return badRequestError{Message: fmt.Sprintf("the key with kid %q already belongs to the namespace", newKey.KeyID())}
+		}
+		if err := tx.Where("namespace_id = ?", ns.ID).Delete(&NamespaceTransfer{}).Error; err != nil {
+			return errors.Wrap(err, "failed to replace the pending transfer")
+		}
+		transfer.NamespaceID = ns.ID
+		if err := tx.Create(&transfer).Error; err != nil {
+			return errors.Wrap(err, "failed to create the transfer")
+		}
+		return addAuditLogEntry(tx, &ns, auditActionTransferInitiated, transferAuditDetails{
+			OldKeyIDs: jwksKeyIDs(set),
+			NewKeyID:  newKey.KeyID(),
+			ExpiresAt: transfer.ExpiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Get the unexpired transfer pending for the namespace with the given prefix, or nil if there's none
+func getPendingNamespaceTransfer(prefix string) (*NamespaceTransfer, error) {
+	var transfer NamespaceTransfer
+	err := db.Joins("JOIN namespace ON namespace.id = namespace_transfers.namespace_id").
+		Where("namespace.prefix = ? AND namespace_transfers.expires_at > ?", prefix, time.Now()).
+		First(&transfer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the pending transfer")
+	}
+	return &transfer, nil
+}
+
+// Complete the pending transfer with the given id: the namespace's JWKS is replaced by the new
+// owner's key, the keys the previous owner retired are dropped with it, and the previous owner's
+// registry account no longer manages the namespace. All of it happens in one transaction, so the
+// namespace is never left with a mix of the two owners' keys
+func completeNamespaceTransfer(transferID int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var transfer NamespaceTransfer
+		if err := tx.Where("id = ? AND expires_at > ?", transferID, time.Now()).First(&transfer).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return badRequestError{Message: "the transfer is no longer pending"}
+			}
+			return errors.Wrap(err, "error retrieving the transfer")
+		}
+		var ns server_structs.Namespace
+		if err := tx.Where("id = ?", transfer.NamespaceID).Last(&ns).Error; err != nil {
+			return errors.Wrap(err, "error retrieving the namespace")
+		}
+		oldSet, err := parseNamespaceJwks(ns.Pubkey)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse pubkey as a jwks")
+		}
+		newSet, err := parseNamespaceJwks(transfer.NewPubkey)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse the new pubkey as a jwks")
+		}
+
+		ns.AdminMetadata.UserID = ""
+		ns.AdminMetadata.UpdatedAt = time.Now()
+		adminMetadataBytes, err := json.Marshal(ns.AdminMetadata)
+		if err != nil {
+			return errors.Wrap(err, "Error marshaling admin metadata")
+		}
+		err = tx.Model(&server_structs.Namespace{}).Where("id = ?", ns.ID).Updates(map[string]interface{}{
+			"pubkey":         transfer.NewPubkey,
+			"admin_metadata": string(adminMetadataBytes),
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to update the namespace")
+		}
+		if err := tx.Where("namespace_id = ?", ns.ID).Delete(&RetiredKey{}).Error; err != nil {
+			return errors.Wrap(err, "failed to remove the retired keys of the previous owner")
+		}
+		if err := tx.Delete(&transfer).Error; err != nil {
+			return errors.Wrap(err, "failed to remove the completed transfer")
+		}
+		newKey, _ := newSet.Key(0)
+		return addAuditLogEntry(tx, &ns, auditActionTransferCompleted, transferAuditDetails{
+			OldKeyIDs: jwksKeyIDs(oldSet),
+			NewKeyID:  newKey.KeyID(),
+		})
+	})
+}
+
+// The base64url-encoded SHA-256 JWK thumbprint (RFC 7638) of a transfer's new key, which transfer
+// tokens carry in their new_key_thumbprint claim
+func transferKeyThumbprint(key jwk.Key) (string, error) {
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to compute the thumbprint of the new key")
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+// Check that the request carries a token with the namespace transfer scope signed by a key of jwks.
+// The token must be bound to the namespace prefix and to the thumbprint of the key the namespace is
+// transferred to, and must expire within maxTransferTokenLifetime, so a token can't be replayed to
+// move the namespace, or another namespace sharing its key, to a different key
+func verifyTransferToken(ctx *gin.Context, jwks jwk.Set, prefix string, newKey jwk.Key) error {
+	tokenStr := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if tokenStr == "" {
+		return errors.New("no token provided")
+	}
+	parsed, err := jwt.Parse([]byte(tokenStr), jwt.WithKeySet(jwks))
+	if err != nil {
+		return errors.Wrap(err, "failed to verify the token")
+	}
+	thumbprint, err := transferKeyThumbprint(newKey)
+	if err != nil {
+		return err
+	}
+	scopeValidator := token_scopes.CreateScopeValidator([]token_scopes.TokenScope{token_scopes.Pelican_NamespaceTransfer}, true)
+	err = jwt.Validate(parsed,
+		jwt.WithValidator(scopeValidator),
+		jwt.WithRequiredClaim(jwt.ExpirationKey),
+		jwt.WithClaimValue(transferPrefixClaim, prefix),
+		jwt.WithClaimValue(transferNewKeyClaim, thumbprint),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate the token")
+	}
+	if lifetime := time.Until(parsed.Expiration()); lifetime > maxTransferTokenLifetime {
+		return errors.Errorf("the token expires in %s, more than the maximum of %s", lifetime.Round(time.Second), maxTransferTokenLifetime)
+	}
+	return nil
+}
+
+// A gin route handler for the current owner of a namespace to start transferring its registration
+// to a new owner's key. The request must carry a token signed by the namespace's current key and
+// bound to the prefix and the new key (see verifyTransferToken)
+//
+// POST /namespaces/transfer
+func initiateNamespaceTransferHandler(ctx *gin.Context) {
+	req := initiateTransferRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Invalid request body: ", err)})
+		return
+	}
+	exists, err := namespaceExistsByPrefix(req.Prefix)
+	if err != nil {
+		log.Errorf("Failed to check if the namespace %s exists: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "server encountered an error checking if namespace exists"})
+		return
+	}
+	if !exists {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("namespace %s is not registered", req.Prefix)})
+		return
+	}
+	jwks, _, err := getNamespaceJwksByPrefix(req.Prefix)
+	if err != nil {
+		log.Errorf("Failed to get the jwks of the namespace %s: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "server encountered an error loading the prefix's stored jwks"})
+		return
+	}
+	newKey, err := parseSinglePublicKey(req.NewPubkey)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error()})
+		return
+	}
+	if err := verifyTransferToken(ctx, jwks, req.Prefix, newKey); err != nil {
+		log.Warningf("Rejected a transfer of the namespace %s not authorized by its key: %v", req.Prefix, err)
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The transfer must be authorized by a token signed by the namespace's key for this namespace and new key"})
+		return
+	}
+
+	transfer, err := initiateNamespaceTransfer(req.Prefix, newKey, param.Registry_NamespaceTransferExpiration.GetDuration())
+	if err != nil {
+		if badReqErr, ok := err.(badRequestError); ok {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    badReqErr.Message})
+			return
+		}
+		log.Errorf("Failed to initiate the transfer of the namespace %s: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to initiate the transfer"})
+		return
+	}
+	log.Infof("Initiated the transfer of the namespace %s to the key %q; it expires at %s", req.Prefix, newKey.KeyID(), transfer.ExpiresAt.Format(time.RFC3339))
+	ctx.JSON(http.StatusOK, namespaceTransferResponse{Prefix: req.Prefix, NewKeyID: newKey.KeyID(), ExpiresAt: &transfer.ExpiresAt})
+}
+
+// A gin route handler for the new owner of a namespace to accept the transfer pending for it,
+// which makes their key the namespace's only key. The request must carry a token signed by the new key
+// and bound to the prefix and the new key, like the one initiating the transfer
+//
+// POST /namespaces/transfer/accept
+func acceptNamespaceTransferHandler(ctx *gin.Context) {
+	req := acceptTransferRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Invalid request body: ", err)})
+		return
+	}
+	transfer, err := getPendingNamespaceTransfer(req.Prefix)
+	if err != nil {
+		log.Errorf("Failed to get the pending transfer of the namespace %s: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "server encountered an error retrieving the pending transfer"})
+		return
+	}
+	if transfer == nil {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("no transfer of the namespace %s is pending", req.Prefix)})
+		return
+	}
+	newJwks, err := parseNamespaceJwks(transfer.NewPubkey)
+	if err != nil {
+		log.Errorf("Failed to parse the new jwks of the transfer of the namespace %s: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "server encountered an error loading the new owner's jwks"})
+		return
+	}
+	newKey, _ := newJwks.Key(0)
+	if err := verifyTransferToken(ctx, newJwks, req.Prefix, newKey); err != nil {
+		log.Warningf("Rejected an acceptance of the transfer of the namespace %s not authorized by the new key: %v", req.Prefix, err)
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The transfer must be accepted with a token signed by the new owner's key"})
+		return
+	}
+
+	if err := completeNamespaceTransfer(transfer.ID); err != nil {
+		if badReqErr, ok := err.(badRequestError); ok {
+			ctx.JSON(http.StatusConflict, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    badReqErr.Message})
+			return
+		}
+		log.Errorf("Failed to complete the transfer of the namespace %s: %v", req.Prefix, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to complete the transfer"})
+		return
+	}
+	log.Infof("Transferred the namespace %s to the key %q", req.Prefix, newKey.KeyID())
+	ctx.JSON(http.StatusOK, namespaceTransferResponse{Prefix: req.Prefix, NewKeyID: newKey.KeyID()})
+}
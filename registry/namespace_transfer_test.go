@@ -0,0 +1,276 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package registry
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+func TestNamespaceTransfer(t *testing.T) {
+	setupMockRegistryDB(t)
+	defer teardownMockNamespaceDB(t)
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("Registry.NamespaceTransferExpiration", time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/namespaces/transfer", initiateNamespaceTransferHandler)
+	router.POST("/namespaces/transfer/accept", acceptNamespaceTransferHandler)
+
+	newPrivateKey := func(t *testing.T, kid string) jwk.Key {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(privKey)
+		require.NoError(t, err)
+		require.NoError(t, key.Set(jwk.KeyIDKey, kid))
+		require.NoError(t, key.Set(jwk.AlgorithmKey, jwa.ES256))
+		return key
+	}
+
+	jwksOf := func(t *testing.T, privKey jwk.Key) string {
+		pubKey, err := privKey.PublicKey()
+		require.NoError(t, err)
+		set := jwk.NewSet()
+		require.NoError(t, set.AddKey(pubKey))
+		setBytes, err := json.Marshal(set)
+		require.NoError(t, err)
+		return string(setBytes)
+	}
+
+	buildToken := func(t *testing.T, privKey jwk.Key, scope token_scopes.TokenScope, prefix string, newKey jwk.Key, lifetime time.Duration) string {
+		pubKey, err := newKey.PublicKey()
+		require.NoError(t, err)
+		thumbprint, err := transferKeyThumbprint(pubKey)
+		require.NoError(t, err)
+		builder := jwt.NewBuilder().
+			Issuer("https://origin.example.com").
+			Claim("scope", scope.String()).
+			Claim(transferPrefixClaim, prefix).
+			Claim(transferNewKeyClaim, thumbprint)
+		if lifetime > 0 {
+			builder = builder.Expiration(time.Now().Add(lifetime))
+		}
+		tok, err := builder.Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, privKey))
+		require.NoError(t, err)
+		return string(signed)
+	}
+
+	// A transfer token for /foo signed by privKey, bound to newKey
+	signToken := func(t *testing.T, privKey jwk.Key, newKey jwk.Key, scope token_scopes.TokenScope) string {
+		return buildToken(t, privKey, scope, "/foo", newKey, time.Minute)
+	}
+
+	post := func(t *testing.T, path string, body any, token string) *httptest.ResponseRecorder {
+		bodyBytes, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	setup := func(t *testing.T) (oldKey jwk.Key, newKey jwk.Key) {
+		resetNamespaceDB(t)
+		require.NoError(t, db.Where("1 = 1").Delete(&NamespaceTransfer{}).Error)
+		require.NoError(t, db.Where("1 = 1").Delete(&AuditLogEntry{}).Error)
+		oldKey = newPrivateKey(t, "old-key")
+		newKey = newPrivateKey(t, "new-key")
+		err := insertMockDBData([]server_structs.Namespace{
+			mockNamespace("/foo", jwksOf(t, oldKey), "", server_structs.AdminMetadata{UserID: "old-owner"}),
+		})
+		require.NoError(t, err)
+		return
+	}
+
+	t.Run("transfer-completes-after-acceptance", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		// The old key stays authoritative until the new owner accepts
+		jwks, _, err := getNamespaceJwksByPrefix("/foo")
+		require.NoError(t, err)
+		_, found := jwks.LookupKeyID("old-key")
+		assert.True(t, found)
+
+		resp = post(t, "/namespaces/transfer/accept", acceptTransferRequest{Prefix: "/foo"},
+			signToken(t, newKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		jwks, adminMetadata, err := getNamespaceJwksByPrefix("/foo")
+		require.NoError(t, err)
+		assert.Equal(t, 1, jwks.Len())
+		_, found = jwks.LookupKeyID("new-key")
+		assert.True(t, found)
+		assert.Empty(t, adminMetadata.UserID)
+
+		pending, err := getPendingNamespaceTransfer("/foo")
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+
+		entries := []AuditLogEntry{}
+		require.NoError(t, db.Order("id").Find(&entries).Error)
+		require.Len(t, entries, 2)
+		assert.Equal(t, auditActionTransferInitiated, entries[0].Action)
+		assert.Equal(t, auditActionTransferCompleted, entries[1].Action)
+		assert.Equal(t, "/foo", entries[1].Prefix)
+		details := transferAuditDetails{}
+		require.NoError(t, json.Unmarshal([]byte(entries[1].Details), &details))
+		assert.Equal(t, []string{"old-key"}, details.OldKeyIDs)
+		assert.Equal(t, "new-key", details.NewKeyID)
+
+		// The previous owner can no longer move the namespace
+		otherKey := newPrivateKey(t, "other-key")
+		resp = post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, otherKey)},
+			signToken(t, oldKey, otherKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("initiate-with-wrong-key-rejected", func(t *testing.T) {
+		_, newKey := setup(t)
+		attackerKey := newPrivateKey(t, "old-key")
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, attackerKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		resp = post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)}, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		pending, err := getPendingNamespaceTransfer("/foo")
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+		var count int64
+		require.NoError(t, db.Model(&AuditLogEntry{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("initiate-without-transfer-scope-rejected", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceDelete))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("initiate-with-unbound-token-rejected", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+		err := insertMockDBData([]server_structs.Namespace{
+			mockNamespace("/bar", jwksOf(t, oldKey), "", server_structs.AdminMetadata{UserID: "old-owner"}),
+		})
+		require.NoError(t, err)
+		attackerKey := newPrivateKey(t, "attacker-key")
+		scope := token_scopes.Pelican_NamespaceTransfer
+
+		// A token the owner issued to transfer /foo to newKey can't move /foo to another key,
+		// nor another namespace sharing the owner's key
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, attackerKey)},
+			buildToken(t, oldKey, scope, "/foo", newKey, time.Minute))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/bar", NewPubkey: jwksOf(t, newKey)},
+			buildToken(t, oldKey, scope, "/foo", newKey, time.Minute))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		// Tokens must be short-lived
+		resp = post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			buildToken(t, oldKey, scope, "/foo", newKey, 0))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			buildToken(t, oldKey, scope, "/foo", newKey, time.Hour))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		for _, prefix := range []string{"/foo", "/bar"} {
+			pending, err := getPendingNamespaceTransfer(prefix)
+			require.NoError(t, err)
+			assert.Nil(t, pending)
+		}
+	})
+
+	t.Run("accept-with-wrong-key-rejected", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		// Only the new owner can confirm, not the old owner nor anyone else
+		resp = post(t, "/namespaces/transfer/accept", acceptTransferRequest{Prefix: "/foo"},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = post(t, "/namespaces/transfer/accept", acceptTransferRequest{Prefix: "/foo"},
+			signToken(t, newPrivateKey(t, "new-key"), newKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		jwks, adminMetadata, err := getNamespaceJwksByPrefix("/foo")
+		require.NoError(t, err)
+		_, found := jwks.LookupKeyID("old-key")
+		assert.True(t, found)
+		_, found = jwks.LookupKeyID("new-key")
+		assert.False(t, found)
+		assert.Equal(t, "old-owner", adminMetadata.UserID)
+	})
+
+	t.Run("expired-transfer-cannot-be-accepted", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/foo", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		require.NoError(t, db.Model(&NamespaceTransfer{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		resp = post(t, "/namespaces/transfer/accept", acceptTransferRequest{Prefix: "/foo"},
+			signToken(t, newKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("unknown-namespace", func(t *testing.T) {
+		oldKey, newKey := setup(t)
+
+		resp := post(t, "/namespaces/transfer", initiateTransferRequest{Prefix: "/bar", NewPubkey: jwksOf(t, newKey)},
+			signToken(t, oldKey, newKey, token_scopes.Pelican_NamespaceTransfer))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
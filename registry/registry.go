@@ -54,6 +54,7 @@ import (
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/token_scopes"
+	"github.com/pelicanplatform/pelican/web_ui"
 )
 
 var OIDC struct {
@@ -275,6 +276,15 @@ func keySignChallengeCommit(ctx *gin.Context, data *registrationData) (bool, map
 	if clientVerified && serverVerified {
 		log.Debug("Registering namespace ", data.Prefix)
 
+		// Validate the prefix first, so the existence check below sees its normalized form
+		reqPrefix, err := validatePrefix(data.Prefix)
+		if err != nil {
+			err = errors.Wrapf(err, "Requested namespace %s failed validation", data.Prefix)
+			log.Errorln(err)
+			return false, nil, badRequestError{Message: err.Error()}
+		}
+		data.Prefix = reqPrefix
+
 		// Check if prefix exists before doing anything else
 		exists, err := namespaceExistsByPrefix(data.Prefix)
 		if err != nil {
@@ -289,14 +299,6 @@ func keySignChallengeCommit(ctx *gin.Context, data *registrationData) (bool, map
 			return false, returnMsg, nil
 		}
 
-		reqPrefix, err := validatePrefix(data.Prefix)
-		if err != nil {
-			err = errors.Wrapf(err, "Requested namespace %s failed validation", data.Prefix)
-			log.Errorln(err)
-			return false, nil, badRequestError{Message: err.Error()}
-		}
-		data.Prefix = reqPrefix
-
 		inTopo, topoNss, valErr, sysErr := validateKeyChaining(reqPrefix, key)
 		if valErr != nil {
 			log.Errorln(err)
@@ -306,6 +308,13 @@ func keySignChallengeCommit(ctx *gin.Context, data *registrationData) (bool, map
 			log.Errorln(err)
 			return false, nil, sysErr
 		}
+		reviewNote, valErr, sysErr := checkPrefixCollisions(reqPrefix, key)
+		if valErr != nil {
+			return false, nil, permissionDeniedError{Message: valErr.Error()}
+		}
+		if sysErr != nil {
+			return false, nil, sysErr
+		}
 
 		var ns server_structs.Namespace
 		ns.Prefix = data.Prefix
@@ -371,6 +380,8 @@ func keySignChallengeCommit(ctx *gin.Context, data *registrationData) (bool, map
 			}
 		}
 
+		ns.AdminMetadata.Description = reviewNote + ns.AdminMetadata.Description
+
 		// Overwrite status to Pending to filter malicious request
 		ns.AdminMetadata.Status = server_structs.RegPending
 
@@ -378,6 +389,7 @@ func keySignChallengeCommit(ctx *gin.Context, data *registrationData) (bool, map
 		if err != nil {
 			return false, nil, errors.Wrapf(err, "Failed to add the prefix %q to the database", ns.Prefix)
 		} else {
+			notifyNamespaceEvent(namespaceRegistered, &ns)
 			msg := fmt.Sprintf("Prefix %s successfully registered", ns.Prefix)
 			if inTopo {
 				msg = fmt.Sprintf("Prefix %s successfully registered. Note that there is an existing superspace or subspace of the namespace in the OSDF topology: %s. The registry admin will review your request and approve your namespace if this is expected.", ns.Prefix, GetTopoPrefixString(topoNss))
@@ -746,6 +758,10 @@ func deleteNamespaceHandler(ctx *gin.Context) {
 	}
 
 	// If we get to this point in the code, we've passed all the security checks and we're ready to delete
+	deletedNs, err := getNamespaceByPrefix(prefix)
+	if err != nil {
+		log.Warningf("Failed to look up namespace %s before deleting it: %v", prefix, err)
+	}
 	err = deleteNamespaceByPrefix(prefix)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
@@ -754,6 +770,7 @@ func deleteNamespaceHandler(ctx *gin.Context) {
 		log.Errorf("Failed to delete namespace from database: %v", err)
 		return
 	}
+	notifyNamespaceEvent(namespaceDeleted, deletedNs)
 
 	ctx.JSON(http.StatusOK,
 		server_structs.SimpleApiResp{
@@ -856,7 +873,7 @@ func wildcardHandler(ctx *gin.Context) {
 				}
 			}
 		}
-		ctx.JSON(http.StatusOK, jwks)
+		serveNamespaceJwks(ctx, jwks)
 		return
 	} else if strings.HasSuffix(path, "/.well-known/openid-configuration") {
 		// Check that the namespace exists before constructing config JSON
@@ -902,6 +919,45 @@ func wildcardHandler(ctx *gin.Context) {
 	}
 }
 
+// Serve a namespace's JWKS with an ETag and Cache-Control max-age so that clients
+// like the director can cache the keys and revalidate them with If-None-Match.
+// A matching If-None-Match gets a 304 Not Modified without the body
+func serveNamespaceJwks(ctx *gin.Context, jwks jwk.Set) {
+	jwksBytes, err := json.Marshal(jwks)
+	if err != nil {
+		log.Errorf("Failed to marshal jwks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "server encountered an error trying to encode jwks for prefix"})
+		return
+	}
+	checksum := sha256.Sum256(jwksBytes)
+	etag := `"` + hex.EncodeToString(checksum[:]) + `"`
+
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", fmt.Sprintf("max-age=%d", int(param.Registry_JwksCacheMaxAge.GetDuration().Seconds())))
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", jwksBytes)
+}
+
+// Check if the value of an If-None-Match header matches the etag.
+// Weak validators (W/"...") are compared by their opaque tag, per RFC 9110 section 13.1.2
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 func getNamespaceHandler(ctx *gin.Context) {
 	param := ctx.Param("wildcard")
 	prefix := path.Clean(param)
@@ -927,6 +983,7 @@ func getNamespaceHandler(ctx *gin.Context) {
 			Msg:    "server encountered an error trying to get the namespace registration for the prefix " + prefix})
 		return
 	}
+	ns.Aliases = getAliasesForPrefix(ns.Prefix)
 	ctx.JSON(http.StatusOK, ns)
 }
 
@@ -1168,6 +1225,10 @@ func RegisterRegistryAPI(router *gin.RouterGroup) {
 		registryAPI.GET("/*wildcard", wildcardHandler)
 		registryAPI.POST("/checkNamespaceExists", checkNamespaceExistsHandler)
 		registryAPI.POST("/checkNamespaceStatus", checkApprovalHandler)
+		registryAPI.POST("/namespaceStats", reportNamespaceStatsHandler)
+		registryAPI.POST("/namespaces/import", web_ui.AdminTokenAuthHandler, importNamespacesHandler)
+		registryAPI.POST("/namespaces/transfer", initiateNamespaceTransferHandler)
+		registryAPI.POST("/namespaces/transfer/accept", acceptNamespaceTransferHandler)
 
 		registryAPI.DELETE("/*wildcard", deleteNamespaceHandler)
 	}
@@ -29,7 +29,9 @@ import (
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/param"
@@ -50,6 +52,33 @@ type Topology struct {
 	Prefix string `json:"prefix" gorm:"unique;not null"`
 }
 
+// A public key of a namespace that was retired during a key rollover. The key stays
+// in the namespace's JWKS so that tokens it signed keep verifying until ExpiresAt,
+// after which it's pruned from the JWKS
+type RetiredKey struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	NamespaceID int       `json:"namespace_id" gorm:"not null"`
+	KeyID       string    `json:"key_id" gorm:"not null"`
+	RetiredAt   time.Time `json:"retired_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// The redirect requests the directors reported for a namespace prefix, accumulated across reports
+type NamespaceStats struct {
+	Prefix    string    `json:"prefix" gorm:"primaryKey"`
+	Requests  int64     `json:"requests" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// A report window whose counts were accumulated into NamespaceStats, recorded so that
+// a retried report of the same window isn't counted twice
+type NamespaceStatsWindow struct {
+	WindowID    string    `json:"window_id" gorm:"primaryKey"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
 type prefixType string // Type of a prefix
 
 const (
@@ -79,6 +108,18 @@ func (Topology) TableName() string {
 	return "topology"
 }
 
+func (RetiredKey) TableName() string {
+	return "retired_keys"
+}
+
+func (NamespaceStats) TableName() string {
+	return "namespace_stats"
+}
+
+func (NamespaceStatsWindow) TableName() string {
+	return "namespace_stats_windows"
+}
+
 func GetTopoPrefixString(topoNss []Topology) (result string) {
 	for i, topoNs := range topoNss {
 		if i != len(topoNss)-1 {
@@ -172,6 +213,26 @@ func namespaceBelongsToUserId(id int, userId string) (bool, error) {
 	return result.AdminMetadata.UserID == userId, nil
 }
 
+// Parse the stored JWKS of a namespace. Keys registered without a key ID (kid) get their
+// RFC 7638 thumbprint as the kid, which is stable across reads, so every published key can be
+// picked by the kid in the header of the tokens it signed and retired by its kid
+func parseNamespaceJwks(pubkey string) (jwk.Set, error) {
+	set, err := jwk.ParseString(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	for idx := 0; idx < set.Len(); idx++ {
+		key, _ := set.Key(idx)
+		if key.KeyID() != "" {
+			continue
+		}
+		if err := jwk.AssignKeyID(key); err != nil {
+			return nil, errors.Wrap(err, "failed to assign a key ID to the key")
+		}
+	}
+	return set, nil
+}
+
 func getNamespaceJwksById(id int) (jwk.Set, error) {
 	var result server_structs.Namespace
 	err := db.Select("pubkey").Where("id = ?", id).Last(&result).Error
@@ -181,7 +242,7 @@ func getNamespaceJwksById(id int) (jwk.Set, error) {
 		return nil, errors.Wrap(err, "error retrieving pubkey")
 	}
 
-	set, err := jwk.ParseString(result.Pubkey)
+	set, err := parseNamespaceJwks(result.Pubkey)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to parse pubkey as a jwks")
 	}
@@ -203,7 +264,7 @@ func getNamespaceJwksByPrefix(prefix string) (jwk.Set, *server_structs.AdminMeta
 		return nil, nil, errors.Wrap(err, "error retrieving pubkey")
 	}
 
-	set, err := jwk.ParseString(result.Pubkey)
+	set, err := parseNamespaceJwks(result.Pubkey)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Failed to parse pubkey as a jwks")
 	}
@@ -211,6 +272,200 @@ func getNamespaceJwksByPrefix(prefix string) (jwk.Set, *server_structs.AdminMeta
 	return set, &result.AdminMetadata, nil
 }
 
+// Add a public key to the JWKS of the namespace with the given id, so that
+// tokens signed by either the existing keys or the new key verify
+func addNamespaceKeyById(id int, key jwk.Key) error {
+	if key.KeyID() == "" {
+		return errors.New("the key must have a key ID (kid)")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ns server_structs.Namespace
+		if err := tx.Select("id", "pubkey").Where("id = ?", id).Last(&ns).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("namespace with id %d not found in database", id)
+			}
+			return errors.Wrap(err, "error retrieving pubkey")
+		}
+		set, err := parseNamespaceJwks(ns.Pubkey)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse pubkey as a jwks")
+		}
+		if _, exists := set.LookupKeyID(key.KeyID()); exists {
+			return badRequestError{Message: fmt.Sprintf("a key with kid %q already exists for the namespace", key.KeyID())}
+		}
+		if err := set.AddKey(key); err != nil {
+			return errors.Wrap(err, "failed to add the key to the namespace jwks")
+		}
+		setBytes, err := json.Marshal(set)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the namespace jwks")
+		}
+		return tx.Model(&server_structs.Namespace{}).Where("id = ?", id).Update("pubkey", string(setBytes)).Error
+	})
+}
+
+// Retire the public key with the given kid from the namespace with the given id. The key
+// remains in the namespace's JWKS for gracePeriod so that tokens it signed keep verifying
+// while the new key propagates. At least one non-retired key must remain in the namespace.
+//
+// Returns the time after which the key will be pruned from the JWKS
+func retireNamespaceKeyById(id int, kid string, gracePeriod time.Duration) (time.Time, error) {
+	now := time.Now()
+	retiredKey := RetiredKey{NamespaceID: id, KeyID: kid, RetiredAt: now, ExpiresAt: now.Add(gracePeriod)}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ns server_structs.Namespace
+		if err := tx.Select("id", "pubkey").Where("id = ?", id).Last(&ns).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("namespace with id %d not found in database", id)
+			}
+			return errors.Wrap(err, "error retrieving pubkey")
+		}
+		set, err := parseNamespaceJwks(ns.Pubkey)
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse pubkey as a jwks")
+		}
+		if _, exists := set.LookupKeyID(kid); !exists {
+			return badRequestError{Message: fmt.Sprintf("no key with kid %q found for the namespace", kid)}
+		}
+		retired := []RetiredKey{}
+		if err := tx.Where("namespace_id = ?", id).Find(&retired).Error; err != nil {
+			return errors.Wrap(err, "error retrieving retired keys")
+		}
+		retiredKids := map[string]bool{kid: true}
+		for _, rk := range retired {
+			if rk.KeyID == kid {
+				return badRequestError{Message: fmt.Sprintf("the key with kid %q has already been retired", kid)}
+			}
+			retiredKids[rk.KeyID] = true
+		}
+		activeKeys := 0
+		for idx := 0; idx < set.Len(); idx++ {
+			if key, ok := set.Key(idx); ok && !retiredKids[key.KeyID()] {
+				activeKeys++
+			}
+		}
+		if activeKeys == 0 {
+			return badRequestError{Message: "can't retire the last active key of the namespace. Add a new key first"}
+		}
+		return tx.Create(&retiredKey).Error
+	})
+	return retiredKey.ExpiresAt, err
+}
+
+// Remove the retired keys whose grace period has passed from their namespace's JWKS.
+// Returns the number of keys pruned
+func pruneExpiredNamespaceKeys() (int, error) {
+	expired := []RetiredKey{}
+	if err := db.Where("expires_at <= ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, errors.Wrap(err, "error retrieving expired keys")
+	}
+	pruned := 0
+	for _, rk := range expired {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var ns server_structs.Namespace
+			err := tx.Select("id", "pubkey").Where("id = ?", rk.NamespaceID).Last(&ns).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.Wrap(err, "error retrieving pubkey")
+			}
+			// Only touch the jwks if the namespace still exists
+			if err == nil {
+				set, err := parseNamespaceJwks(ns.Pubkey)
+				if err != nil {
+					return errors.Wrap(err, "Failed to parse pubkey as a jwks")
+				}
+				if key, exists := set.LookupKeyID(rk.KeyID); exists {
+					if err := set.RemoveKey(key); err != nil {
+						return errors.Wrap(err, "failed to remove the key from the namespace jwks")
+					}
+					setBytes, err := json.Marshal(set)
+					if err != nil {
+						return errors.Wrap(err, "failed to marshal the namespace jwks")
+					}
+					if err := tx.Model(&server_structs.Namespace{}).Where("id = ?", rk.NamespaceID).Update("pubkey", string(setBytes)).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return tx.Delete(&RetiredKey{}, rk.ID).Error
+		})
+		if err != nil {
+			return pruned, errors.Wrapf(err, "failed to prune key %q of namespace with id %d", rk.KeyID, rk.NamespaceID)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// Add the request counts of the report to the accumulated namespace stats, unless a report
+// of the same window was already accumulated. Returns true if the report is a duplicate
+func accumulateNamespaceStats(report server_structs.NamespaceStatsReport) (duplicate bool, err error) {
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&NamespaceStatsWindow{}).Where("window_id = ?", report.WindowID).Count(&count).Error; err != nil {
+			return errors.Wrap(err, "error checking the report window")
+		}
+		if count > 0 {
+			duplicate = true
+			return nil
+		}
+		window := NamespaceStatsWindow{
+			WindowID:    report.WindowID,
+			WindowStart: report.WindowStart,
+			WindowEnd:   report.WindowEnd,
+			ReceivedAt:  now,
+		}
+		if err := tx.Create(&window).Error; err != nil {
+			return errors.Wrap(err, "error recording the report window")
+		}
+		for prefix, requests := range report.Requests {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "prefix"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"requests":   gorm.Expr("requests + ?", requests),
+					"updated_at": now,
+				}),
+			}).Create(&NamespaceStats{Prefix: prefix, Requests: requests, UpdatedAt: now}).Error
+			if err != nil {
+				return errors.Wrapf(err, "error accumulating the requests of %s", prefix)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Get the accumulated namespace stats, ordered by prefix
+func getNamespaceStats() ([]NamespaceStats, error) {
+	stats := []NamespaceStats{}
+	if err := db.Order("prefix").Find(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, "error retrieving the namespace stats")
+	}
+	return stats, nil
+}
+
+// Periodically prune retired namespace keys whose grace period has passed
+func LaunchKeyPruning(ctx context.Context, egrp *errgroup.Group) {
+	egrp.Go(func() error {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				pruned, err := pruneExpiredNamespaceKeys()
+				if err != nil {
+					log.Warningln("Failed to prune expired namespace keys:", err)
+				}
+				if pruned > 0 {
+					log.Infof("Pruned %d expired namespace key(s) after their retirement grace period", pruned)
+				}
+			}
+		}
+	})
+}
+
 func getNamespaceStatusById(id int) (server_structs.RegistrationStatus, error) {
 	if id < 1 {
 		return "", errors.New("Invalid id. id must be a positive integer")
@@ -580,3 +835,18 @@ func PeriodicTopologyReload(ctx context.Context) {
 func ShutdownRegistryDB() error {
 	return server_utils.ShutdownDB(db)
 }
+
+// Check if the registry is ready to serve requests, i.e. its database is initialized and reachable
+func CheckReadiness(ctx context.Context) error {
+	if db == nil {
+		return errors.New("registry database is not initialized")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed to get registry database connection")
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return errors.Wrap(err, "registry database is not reachable")
+	}
+	return nil
+}
@@ -20,10 +20,15 @@ package registry
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"fmt"
 	"testing"
 	"time"
 
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -58,6 +63,12 @@ func setupMockRegistryDB(t *testing.T) {
 	require.NoError(t, err, "Failed to migrate DB for namespace table")
 	err = migrateTopologyTestTable()
 	require.NoError(t, err, "Error creating topology table")
+	err = db.AutoMigrate(&RetiredKey{})
+	require.NoError(t, err, "Failed to migrate DB for retired keys table")
+	err = db.AutoMigrate(&NamespaceStats{}, &NamespaceStatsWindow{})
+	require.NoError(t, err, "Failed to migrate DB for namespace stats tables")
+	err = db.AutoMigrate(&NamespaceTransfer{}, &AuditLogEntry{})
+	require.NoError(t, err, "Failed to migrate DB for namespace transfer tables")
 }
 
 func resetNamespaceDB(t *testing.T) {
@@ -784,6 +795,155 @@ func TestGetNamespaceJwksByPrefix(t *testing.T) {
 	})
 }
 
+func TestAccumulateNamespaceStats(t *testing.T) {
+	setupMockRegistryDB(t)
+	defer teardownMockNamespaceDB(t)
+
+	report := server_structs.NamespaceStatsReport{
+		WindowID:    "window-1",
+		WindowStart: time.Now().Add(-time.Minute),
+		WindowEnd:   time.Now(),
+		Requests:    map[string]int64{"/foo": 3, "/bar": 1},
+	}
+	duplicate, err := accumulateNamespaceStats(report)
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+
+	// A retried report of the same window is ignored
+	duplicate, err = accumulateNamespaceStats(report)
+	require.NoError(t, err)
+	assert.True(t, duplicate)
+
+	duplicate, err = accumulateNamespaceStats(server_structs.NamespaceStatsReport{
+		WindowID: "window-2",
+		Requests: map[string]int64{"/foo": 2},
+	})
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+
+	stats, err := getNamespaceStats()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "/bar", stats[0].Prefix)
+	assert.Equal(t, int64(1), stats[0].Requests)
+	assert.Equal(t, "/foo", stats[1].Prefix)
+	assert.Equal(t, int64(5), stats[1].Requests)
+}
+
+func TestNamespaceKeyRollover(t *testing.T) {
+	setupMockRegistryDB(t)
+	defer teardownMockNamespaceDB(t)
+
+	newPublicKey := func(t *testing.T, kid string) jwk.Key {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		pubKey, err := jwk.FromRaw(privKey.Public())
+		require.NoError(t, err)
+		require.NoError(t, pubKey.Set(jwk.KeyIDKey, kid))
+		return pubKey
+	}
+
+	setupNamespace := func(t *testing.T) int {
+		resetNamespaceDB(t)
+		require.NoError(t, db.Where("1 = 1").Delete(&RetiredKey{}).Error)
+		jwks := jwk.NewSet()
+		require.NoError(t, jwks.AddKey(newPublicKey(t, "old-key")))
+		jwksByte, err := json.Marshal(jwks)
+		require.NoError(t, err)
+		err = insertMockDBData([]server_structs.Namespace{mockNamespace("/foo", string(jwksByte), "", server_structs.AdminMetadata{})})
+		require.NoError(t, err)
+		id, err := getLastNamespaceId()
+		require.NoError(t, err)
+		return id
+	}
+
+	t.Run("add-key-keeps-existing-keys", func(t *testing.T) {
+		id := setupNamespace(t)
+		require.NoError(t, addNamespaceKeyById(id, newPublicKey(t, "new-key")))
+
+		jwks, err := getNamespaceJwksById(id)
+		require.NoError(t, err)
+		assert.Equal(t, 2, jwks.Len())
+		_, found := jwks.LookupKeyID("old-key")
+		assert.True(t, found)
+		_, found = jwks.LookupKeyID("new-key")
+		assert.True(t, found)
+
+		err = addNamespaceKeyById(id, newPublicKey(t, "new-key"))
+		require.Error(t, err)
+		assert.IsType(t, badRequestError{}, err)
+	})
+
+	t.Run("key-without-kid-gets-thumbprint", func(t *testing.T) {
+		resetNamespaceDB(t)
+		legacyKey := newPublicKey(t, "")
+		require.NoError(t, legacyKey.Remove(jwk.KeyIDKey))
+		jwks := jwk.NewSet()
+		require.NoError(t, jwks.AddKey(legacyKey))
+		jwksByte, err := json.Marshal(jwks)
+		require.NoError(t, err)
+		require.NoError(t, insertMockDBData([]server_structs.Namespace{mockNamespace("/foo", string(jwksByte), "", server_structs.AdminMetadata{})}))
+		id, err := getLastNamespaceId()
+		require.NoError(t, err)
+
+		thumbprint, err := legacyKey.Thumbprint(crypto.SHA256)
+		require.NoError(t, err)
+		expectedKid := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+		published, _, err := getNamespaceJwksByPrefix("/foo")
+		require.NoError(t, err)
+		key, ok := published.Key(0)
+		require.True(t, ok)
+		assert.Equal(t, expectedKid, key.KeyID())
+
+		// The kid is stable, so the key can be retired by it once another key is added
+		require.NoError(t, addNamespaceKeyById(id, newPublicKey(t, "new-key")))
+		_, err = retireNamespaceKeyById(id, expectedKid, time.Hour)
+		require.NoError(t, err)
+	})
+
+	t.Run("cannot-retire-last-active-key", func(t *testing.T) {
+		id := setupNamespace(t)
+		_, err := retireNamespaceKeyById(id, "old-key", time.Hour)
+		require.Error(t, err)
+		assert.IsType(t, badRequestError{}, err)
+	})
+
+	t.Run("retired-key-verifies-until-pruned", func(t *testing.T) {
+		id := setupNamespace(t)
+		require.NoError(t, addNamespaceKeyById(id, newPublicKey(t, "new-key")))
+
+		expiresAt, err := retireNamespaceKeyById(id, "old-key", time.Hour)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		// Still within the grace period, so nothing is pruned
+		pruned, err := pruneExpiredNamespaceKeys()
+		require.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+		jwks, err := getNamespaceJwksById(id)
+		require.NoError(t, err)
+		assert.Equal(t, 2, jwks.Len())
+
+		// Move the expiration to the past
+		err = db.Model(&RetiredKey{}).Where("namespace_id = ?", id).Update("expires_at", time.Now().Add(-time.Minute)).Error
+		require.NoError(t, err)
+		pruned, err = pruneExpiredNamespaceKeys()
+		require.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+
+		jwks, err = getNamespaceJwksById(id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, jwks.Len())
+		_, found := jwks.LookupKeyID("new-key")
+		assert.True(t, found)
+
+		var remaining int64
+		require.NoError(t, db.Model(&RetiredKey{}).Count(&remaining).Error)
+		assert.Equal(t, int64(0), remaining)
+	})
+}
+
 func topologyMockup(t *testing.T, namespaces []string) *httptest.Server {
 	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var namespaceList []map[string]string
@@ -0,0 +1,210 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// A namespace record in a bulk import. The public key may be given either as
+	// a JWKS object or as its JSON string encoding
+	NamespaceImportRecord struct {
+		Path         string                       `json:"path"`
+		Pubkey       json.RawMessage              `json:"pubkey"`
+		Capabilities *server_structs.Capabilities `json:"capabilities,omitempty"`
+	}
+
+	NamespaceImportReq struct {
+		Namespaces []NamespaceImportRecord `json:"namespaces"`
+		DryRun     bool                    `json:"dry_run"`
+	}
+
+	NamespaceImportResult struct {
+		Prefix  string `json:"prefix"`
+		Status  string `json:"status"`
+		Message string `json:"message,omitempty"`
+	}
+
+	NamespaceImportRes struct {
+		DryRun  bool                    `json:"dry_run"`
+		Results []NamespaceImportResult `json:"results"`
+	}
+)
+
+const (
+	ImportStatusImported    = "imported"
+	ImportStatusWouldImport = "would-import"
+	ImportStatusConflict    = "conflict"
+	ImportStatusInvalid     = "invalid"
+)
+
+// Get the JWKS string of the record's public key, which may be a JSON string or a JWKS object
+func (record NamespaceImportRecord) pubkeyString() string {
+	var pubkey string
+	if err := json.Unmarshal(record.Pubkey, &pubkey); err == nil {
+		return pubkey
+	}
+	return string(record.Pubkey)
+}
+
+// Validate the namespace records of a bulk import, returning the namespaces to register
+// (nil for invalid records) along with the result of each record so far
+func validateImportRecords(records []NamespaceImportRecord) ([]*server_structs.Namespace, []NamespaceImportResult, error) {
+	namespaces := make([]*server_structs.Namespace, len(records))
+	results := make([]NamespaceImportResult, len(records))
+	seen := map[string]bool{}
+	for idx, record := range records {
+		results[idx].Prefix = record.Path
+		prefix, err := validatePrefix(record.Path)
+		if err != nil {
+			results[idx].Status = ImportStatusInvalid
+			results[idx].Message = err.Error()
+			continue
+		}
+		results[idx].Prefix = prefix
+		if seen[prefix] {
+			results[idx].Status = ImportStatusConflict
+			results[idx].Message = "the prefix appears more than once in the import"
+			continue
+		}
+		seen[prefix] = true
+
+		pubkeyStr := record.pubkeyString()
+		key, err := validateJwks(pubkeyStr)
+		if err != nil {
+			results[idx].Status = ImportStatusInvalid
+			results[idx].Message = err.Error()
+			continue
+		}
+		_, _, validationErr, serverErr := validateKeyChaining(prefix, key)
+		if serverErr != nil {
+			return nil, nil, serverErr
+		}
+		if validationErr != nil {
+			results[idx].Status = ImportStatusConflict
+			results[idx].Message = validationErr.Error()
+			continue
+		}
+		reviewNote, validationErr, serverErr := checkPrefixCollisions(prefix, key)
+		if serverErr != nil {
+			return nil, nil, serverErr
+		}
+		if validationErr != nil {
+			results[idx].Status = ImportStatusConflict
+			results[idx].Message = validationErr.Error()
+			continue
+		}
+		namespaces[idx] = &server_structs.Namespace{Prefix: prefix, Pubkey: pubkeyStr}
+		namespaces[idx].AdminMetadata.Description = reviewNote
+		if reviewNote != "" {
+			results[idx].Message = "flagged for review, as the prefix collides with namespaces registered under a different owner"
+		} else if record.Capabilities != nil {
+			results[idx].Message = "capabilities are advertised by the origins serving the namespace and are not stored by the registry"
+		}
+	}
+	return namespaces, results, nil
+}
+
+// Register the validated namespaces in a single transaction. Namespaces whose prefix
+// is already registered are reported as conflicts and don't abort the import
+func importNamespaces(namespaces []*server_structs.Namespace, results []NamespaceImportResult, user string, dryRun bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for idx, ns := range namespaces {
+			if ns == nil {
+				continue
+			}
+			var count int64
+			if err := tx.Model(&server_structs.Namespace{}).Where("prefix = ?", ns.Prefix).Count(&count).Error; err != nil {
+				return errors.Wrapf(err, "failed to check if the namespace %s exists", ns.Prefix)
+			}
+			if count > 0 {
+				results[idx].Status = ImportStatusConflict
+				results[idx].Message = "the prefix is already registered"
+				continue
+			}
+			if dryRun {
+				results[idx].Status = ImportStatusWouldImport
+				continue
+			}
+			ns.AdminMetadata.UserID = user
+			ns.AdminMetadata.CreatedAt = time.Now()
+			ns.AdminMetadata.UpdatedAt = time.Now()
+			ns.AdminMetadata.Status = server_structs.RegPending
+			if err := tx.Create(ns).Error; err != nil {
+				return errors.Wrapf(err, "failed to register the namespace %s", ns.Prefix)
+			}
+			results[idx].Status = ImportStatusImported
+		}
+		return nil
+	})
+}
+
+// A gin route handler registering namespaces in bulk. The records are validated first,
+// then the valid ones are registered in a single transaction, so either all of them or
+// none of them are imported. Invalid or conflicting records are reported per prefix
+func importNamespacesHandler(ctx *gin.Context) {
+	var req NamespaceImportReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Bad Request: ", err.Error()),
+		})
+		return
+	}
+	if len(req.Namespaces) == 0 {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No namespaces to import",
+		})
+		return
+	}
+
+	namespaces, results, err := validateImportRecords(req.Namespaces)
+	if err != nil {
+		log.Errorln("Failed to validate the namespaces to import:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to validate the namespaces to import: " + err.Error(),
+		})
+		return
+	}
+	if err := importNamespaces(namespaces, results, ctx.GetString("User"), req.DryRun); err != nil {
+		log.Errorln("Failed to import namespaces:", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to import namespaces; none were registered: " + err.Error(),
+		})
+		return
+	}
+	if !req.DryRun {
+		log.Infof("User %q imported namespaces into the registry", ctx.GetString("User"))
+	}
+	ctx.JSON(http.StatusOK, NamespaceImportRes{DryRun: req.DryRun, Results: results})
+}
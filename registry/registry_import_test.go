@@ -0,0 +1,133 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestImportNamespacesHandler(t *testing.T) {
+	setupMockRegistryDB(t)
+	t.Cleanup(func() {
+		viper.Reset()
+		teardownMockNamespaceDB(t)
+	})
+
+	router := gin.New()
+	router.POST("/namespaces/import", func(ctx *gin.Context) {
+		ctx.Set("User", "admin")
+		ctx.Next()
+	}, importNamespacesHandler)
+
+	jwksStr, err := test_utils.GenerateJWKS()
+	require.NoError(t, err)
+	jwksObj := json.RawMessage(jwksStr)
+	jwksQuoted, err := json.Marshal(jwksStr)
+	require.NoError(t, err)
+
+	doImport := func(req NamespaceImportReq) (int, NamespaceImportRes) {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		httpReq, _ := http.NewRequest(http.MethodPost, "/namespaces/import", bytes.NewReader(body))
+		router.ServeHTTP(w, httpReq)
+		res := NamespaceImportRes{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		}
+		return w.Code, res
+	}
+	statuses := func(res NamespaceImportRes) map[string]string {
+		byPrefix := map[string]string{}
+		for _, result := range res.Results {
+			byPrefix[result.Prefix] = result.Status
+		}
+		return byPrefix
+	}
+	records := []NamespaceImportRecord{
+		{Path: "/existing", Pubkey: jwksObj},
+		{Path: "/new-obj", Pubkey: jwksObj, Capabilities: &server_structs.Capabilities{Reads: true}},
+		{Path: "/new-str", Pubkey: jwksQuoted},
+		{Path: "/new-obj", Pubkey: jwksObj},
+		{Path: "/api/foo", Pubkey: jwksObj},
+		{Path: "/bad-key", Pubkey: json.RawMessage(`"not a key"`)},
+	}
+
+	t.Run("empty-import", func(t *testing.T) {
+		code, _ := doImport(NamespaceImportReq{})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("dry-run-registers-nothing", func(t *testing.T) {
+		resetNamespaceDB(t)
+		require.NoError(t, insertMockDBData([]server_structs.Namespace{mockNamespace("/existing", jwksStr, "", server_structs.AdminMetadata{})}))
+
+		code, res := doImport(NamespaceImportReq{Namespaces: records, DryRun: true})
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res.Results, len(records))
+		assert.True(t, res.DryRun)
+		assert.Equal(t, ImportStatusConflict, res.Results[0].Status)
+		assert.Equal(t, ImportStatusWouldImport, res.Results[1].Status)
+		assert.Equal(t, ImportStatusWouldImport, res.Results[2].Status)
+		assert.Equal(t, ImportStatusConflict, res.Results[3].Status)
+		assert.Equal(t, ImportStatusInvalid, res.Results[4].Status)
+		assert.Equal(t, ImportStatusInvalid, res.Results[5].Status)
+
+		exists, err := namespaceExistsByPrefix("/new-obj")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("import-reports-conflicts", func(t *testing.T) {
+		resetNamespaceDB(t)
+		require.NoError(t, insertMockDBData([]server_structs.Namespace{mockNamespace("/existing", jwksStr, "", server_structs.AdminMetadata{})}))
+
+		code, res := doImport(NamespaceImportReq{Namespaces: records})
+		require.Equal(t, http.StatusOK, code)
+		byPrefix := statuses(res)
+		assert.Equal(t, ImportStatusConflict, byPrefix["/existing"])
+		assert.Equal(t, ImportStatusImported, res.Results[1].Status)
+		assert.Equal(t, ImportStatusImported, byPrefix["/new-str"])
+
+		ns, err := getNamespaceByPrefix("/new-str")
+		require.NoError(t, err)
+		assert.Equal(t, jwksStr, ns.Pubkey)
+		assert.Equal(t, "admin", ns.AdminMetadata.UserID)
+		assert.Equal(t, server_structs.RegPending, ns.AdminMetadata.Status)
+
+		// Importing the same records again only yields conflicts
+		code, res = doImport(NamespaceImportReq{Namespaces: records[1:3]})
+		require.Equal(t, http.StatusOK, code)
+		for _, result := range res.Results {
+			assert.Equal(t, ImportStatusConflict, result.Status, result.Prefix)
+		}
+	})
+}
@@ -106,6 +106,49 @@ func TestHandleWildcard(t *testing.T) {
 		assert.Equal(t, string(mockJWKSBytes), w.Body.String())
 	})
 
+	t.Run("jwks-conditional-request-with-etag", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.JwksCacheMaxAge", "5m")
+		mockPrefix := "/testnamespace/foo"
+
+		setupMockRegistryDB(t)
+		defer teardownMockNamespaceDB(t)
+
+		mockJWKS := jwk.NewSet()
+		mockJWKSBytes, err := json.Marshal(mockJWKS)
+		require.NoError(t, err)
+		err = insertMockDBData([]server_structs.Namespace{{Prefix: mockPrefix, Pubkey: string(mockJWKSBytes)}})
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/registry%s/.well-known/issuer.jwks", mockPrefix), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Equal(t, "max-age=300", w.Header().Get("Cache-Control"))
+
+		// Matching ETag gets a 304 without the body
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/registry%s/.well-known/issuer.jwks", mockPrefix), nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		// Stale ETag gets the full key set
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/registry%s/.well-known/issuer.jwks", mockPrefix), nil)
+		req.Header.Set("If-None-Match", `"stale-etag"`)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, string(mockJWKSBytes), w.Body.String())
+	})
+
 	mockApprovalTcs := []struct {
 		Name               string
 		CacheApprovedOnly  bool
@@ -32,6 +32,7 @@ import (
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/pelicanplatform/pelican/config"
@@ -68,6 +69,19 @@ type (
 	listNamespacesForUserRequest struct {
 		Status string `form:"status"`
 	}
+
+	addNamespaceKeyRequest struct {
+		Pubkey string `json:"pubkey" binding:"required"` // A JWKS with the single public key to add
+	}
+
+	addNamespaceKeyResponse struct {
+		KeyID string `json:"key_id"`
+	}
+
+	retireNamespaceKeyResponse struct {
+		KeyID     string    `json:"key_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
 )
 
 var registrationFields []registrationField // A list of available registration fields
@@ -420,6 +434,26 @@ func createUpdateNamespace(ctx *gin.Context, isUpdate bool) {
 		return
 	}
 
+	// Check if the prefix collides with namespaces of a different owner. Existing registrations
+	// were checked when they were created, so this is only done on create
+	reviewNote := ""
+	if !isUpdate {
+		reviewNote, valErr, sysErr = checkPrefixCollisions(ns.Prefix, pubkey)
+		if valErr != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    valErr.Error()})
+			return
+		}
+		if sysErr != nil {
+			log.Errorln("Failed to check the prefix for collisions", sysErr)
+			ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    sysErr.Error()})
+			return
+		}
+	}
+
 	validInst, err := validateInstitution(ns.AdminMetadata.Institution)
 
 	if !validInst {
@@ -451,12 +485,20 @@ func createUpdateNamespace(ctx *gin.Context, isUpdate bool) {
 		}
 	}
 
+	if err := ns.RedirectPolicy.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Validation failed: %v", err)})
+		return
+	}
+
 	if !isUpdate { // Create
 		// Overwrite status to Pending to filter malicious request
 		ns.AdminMetadata.Status = server_structs.RegPending
 		if inTopo {
 			ns.AdminMetadata.Description = fmt.Sprintf("[ Attention: A superspace or subspace of this prefix exists in OSDF topology: %s ] ", GetTopoPrefixString(topoNss))
 		}
+		ns.AdminMetadata.Description = reviewNote + ns.AdminMetadata.Description
 		// Basic validation (type, required, etc)
 		errs := config.GetValidate().Struct(ns)
 		if errs != nil {
@@ -478,6 +520,7 @@ func createUpdateNamespace(ctx *gin.Context, isUpdate bool) {
 				Msg:    "Fail to insert namespace"})
 			return
 		}
+		notifyNamespaceEvent(namespaceRegistered, &ns)
 		if inTopo {
 			ctx.JSON(http.StatusOK,
 				server_structs.SimpleApiResp{
@@ -732,6 +775,7 @@ func getNamespace(ctx *gin.Context) {
 		}
 	}
 
+	ns.Aliases = getAliasesForPrefix(ns.Prefix)
 	ctx.JSON(http.StatusOK, ns)
 }
 
@@ -769,6 +813,13 @@ func updateNamespaceStatus(ctx *gin.Context, status server_structs.RegistrationS
 			Msg:    "Failed to update namespace"})
 		return
 	}
+	if ns, err := getNamespaceById(id); err != nil {
+		log.Warningf("Failed to look up namespace %d after updating its status: %v", id, err)
+	} else if status == server_structs.RegApproved {
+		notifyNamespaceEvent(namespaceApproved, ns)
+	} else if status == server_structs.RegDenied {
+		notifyNamespaceEvent(namespaceDenied, ns)
+	}
 	ctx.JSON(http.StatusOK,
 		server_structs.SimpleApiResp{
 			Status: server_structs.RespOK,
@@ -822,6 +873,140 @@ func getNamespaceJWKS(ctx *gin.Context) {
 	ctx.Data(200, "application/json", jsonData)
 }
 
+// Parse the namespace id from the path and check that the namespace exists and that the user
+// is either an admin or owns the namespace. Writes the error response and returns false if not
+func checkNamespaceKeyAccess(ctx *gin.Context) (int, bool) {
+	user := ctx.GetString("User")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid ID format. ID must a non-zero integer"})
+		return 0, false
+	}
+	exists, err := namespaceExistsById(id)
+	if err != nil {
+		log.Error("Error checking if namespace exists: ", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Error checking if namespace exists"})
+		return 0, false
+	}
+	if !exists {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Namespace not found"})
+		return 0, false
+	}
+	if isAdmin, _ := web_ui.CheckAdmin(user); isAdmin {
+		return id, true
+	}
+	belongsTo, err := namespaceBelongsToUserId(id, user)
+	if err != nil {
+		log.Error("Error checking if namespace belongs to the user: ", err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Error checking if namespace belongs to the user"})
+		return 0, false
+	}
+	if !belongsTo {
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "You do not have permissions to manage the keys of this namespace"})
+		return 0, false
+	}
+	return id, true
+}
+
+// Parse a JWKS holding exactly one public key. A key without a kid gets its thumbprint,
+// so the key can be referenced later, e.g. when it's retired
+func parseSinglePublicKey(pubkey string) (jwk.Key, error) {
+	keySet, err := jwk.ParseString(pubkey)
+	if err != nil {
+		return nil, errors.Wrap(err, "pubkey is not a valid jwks")
+	}
+	if keySet.Len() != 1 {
+		return nil, errors.Errorf("pubkey is a jwks with %d keys, expected exactly one", keySet.Len())
+	}
+	key, _ := keySet.Key(0)
+	switch key.(type) {
+	case jwk.ECDSAPrivateKey, jwk.RSAPrivateKey, jwk.OKPPrivateKey, jwk.SymmetricKey:
+		return nil, errors.New("The key must be a public key")
+	}
+	if key.KeyID() == "" {
+		if err := jwk.AssignKeyID(key); err != nil {
+			return nil, errors.Wrap(err, "Failed to assign a key ID to the key")
+		}
+	}
+	return key, nil
+}
+
+// Add a public key to a namespace to start a key rollover, returning the key's kid. Tokens signed by
+// any key in the namespace's JWKS verify, so the old key keeps working until it's retired
+//
+// POST /namespaces/:id/pubkey
+func addNamespaceKey(ctx *gin.Context) {
+	id, ok := checkNamespaceKeyAccess(ctx)
+	if !ok {
+		return
+	}
+	req := addNamespaceKeyRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprint("Invalid request body: ", err)})
+		return
+	}
+	key, err := parseSinglePublicKey(req.Pubkey)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    err.Error()})
+		return
+	}
+	if err := addNamespaceKeyById(id, key); err != nil {
+		if badReqErr, ok := err.(badRequestError); ok {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    badReqErr.Message})
+			return
+		}
+		log.Errorf("Failed to add a key to the namespace with id %d: %v", id, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to add the key"})
+		return
+	}
+	ctx.JSON(http.StatusOK, addNamespaceKeyResponse{KeyID: key.KeyID()})
+}
+
+// Retire a public key of a namespace. The key stays valid for Registry.KeyRetirementGracePeriod
+// so that tokens it signed keep verifying, then it's pruned from the namespace's JWKS
+//
+// DELETE /namespaces/:id/pubkey/:kid
+func retireNamespaceKey(ctx *gin.Context) {
+	id, ok := checkNamespaceKeyAccess(ctx)
+	if !ok {
+		return
+	}
+	kid := ctx.Param("kid")
+	expiresAt, err := retireNamespaceKeyById(id, kid, param.Registry_KeyRetirementGracePeriod.GetDuration())
+	if err != nil {
+		if badReqErr, ok := err.(badRequestError); ok {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    badReqErr.Message})
+			return
+		}
+		log.Errorf("Failed to retire key %q of the namespace with id %d: %v", kid, id, err)
+		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Failed to retire the key"})
+		return
+	}
+	ctx.JSON(http.StatusOK, retireNamespaceKeyResponse{KeyID: kid, ExpiresAt: expiresAt})
+}
+
 func deleteNamespace(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -847,13 +1032,19 @@ func deleteNamespace(ctx *gin.Context) {
 			Msg:    "Namespace not found"})
 		return
 	}
+	deletedNs, err := getNamespaceById(id)
+	if err != nil {
+		log.Warningf("Failed to look up namespace %d before deleting it: %v", id, err)
+	}
 	err = deleteNamespaceByID(id)
 	if err != nil {
 		log.Errorf("Error deleting the namespace: %v", err)
 		ctx.JSON(http.StatusInternalServerError, server_structs.SimpleApiResp{
 			Status: server_structs.RespFailed,
 			Msg:    "Error deleting the namespace"})
+		return
 	}
+	notifyNamespaceEvent(namespaceDeleted, deletedNs)
 	ctx.JSON(http.StatusOK,
 		server_structs.SimpleApiResp{
 			Status: server_structs.RespOK,
@@ -938,6 +1129,8 @@ func RegisterRegistryWebAPI(router *gin.RouterGroup) error {
 		})
 		registryWebAPI.DELETE("/namespaces/:id", web_ui.AuthHandler, web_ui.AdminAuthHandler, deleteNamespace)
 		registryWebAPI.GET("/namespaces/:id/pubkey", getNamespaceJWKS)
+		registryWebAPI.POST("/namespaces/:id/pubkey", web_ui.AuthHandler, addNamespaceKey)
+		registryWebAPI.DELETE("/namespaces/:id/pubkey/:kid", web_ui.AuthHandler, retireNamespaceKey)
 		registryWebAPI.PATCH("/namespaces/:id/approve", web_ui.AuthHandler, web_ui.AdminAuthHandler, func(ctx *gin.Context) {
 			updateNamespaceStatus(ctx, server_structs.RegApproved)
 		})
@@ -947,6 +1140,9 @@ func RegisterRegistryWebAPI(router *gin.RouterGroup) error {
 	}
 	{
 		registryWebAPI.GET("/topology", listTopologyNamespaces)
+		registryWebAPI.GET("/aliases", listNamespaceAliases)
+		registryWebAPI.GET("/redirectPolicies", listRedirectPolicies)
+		registryWebAPI.GET("/stats", web_ui.AuthHandler, web_ui.AdminAuthHandler, listNamespaceStats)
 	}
 	{
 		registryWebAPI.GET("/institutions", web_ui.AuthHandler, listInstitutions)
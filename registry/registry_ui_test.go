@@ -618,6 +618,39 @@ func TestGetNamespaceJWKS(t *testing.T) {
 	}
 }
 
+func TestListRedirectPolicies(t *testing.T) {
+	setupMockRegistryDB(t)
+	defer teardownMockNamespaceDB(t)
+
+	router := gin.Default()
+	router.GET("/redirectPolicies", listRedirectPolicies)
+
+	cachesOnly := server_structs.RedirectPolicy{Mode: server_structs.RedirectPolicyCachesOnly}
+	nearest := server_structs.RedirectPolicy{MaxServers: 2}
+	approved := server_structs.AdminMetadata{Status: server_structs.RegApproved}
+	pending := server_structs.AdminMetadata{Status: server_structs.RegPending}
+	err := insertMockDBData([]server_structs.Namespace{
+		{Prefix: "/foo", AdminMetadata: approved, RedirectPolicy: cachesOnly},
+		{Prefix: "/bar", AdminMetadata: approved, RedirectPolicy: nearest},
+		{Prefix: "/baz", AdminMetadata: approved},
+		{Prefix: "/pending", AdminMetadata: pending, RedirectPolicy: cachesOnly},
+	})
+	require.NoError(t, err)
+	defer resetNamespaceDB(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/redirectPolicies", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	policies := []server_structs.NamespaceRedirectPolicy{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &policies))
+	assert.Equal(t, []server_structs.NamespaceRedirectPolicy{
+		{Prefix: "/bar", Policy: nearest},
+		{Prefix: "/foo", Policy: cachesOnly},
+	}, policies)
+}
+
 func TestUpdateNamespaceStatus(t *testing.T) {
 	_, cancel, egrp := test_utils.TestContext(context.Background(), t)
 	defer func() { require.NoError(t, egrp.Wait()) }()
@@ -35,53 +35,58 @@ import (
 // This file has all custom validator logic for registry struct
 // data validation besides the ones already included in validator package
 
+// Normalize a namespace prefix so that equivalent spellings of it, such as /foo//bar/ and
+// /foo/bar, are registered only once: ensure the leading slash, collapse duplicate slashes,
+// and strip the trailing slash
+func normalizePrefix(nspath string) string {
+	components := []string{}
+	for _, component := range strings.Split(nspath, "/") {
+		if component != "" {
+			components = append(components, component)
+		}
+	}
+	return "/" + strings.Join(components, "/")
+}
+
 func validatePrefix(nspath string) (string, error) {
 	if len(nspath) == 0 {
 		return "", errors.New("Path prefix may not be empty")
 	}
-	if nspath[0] != '/' {
-		return "", errors.New("Path prefix must be absolute - relative paths are not allowed")
+	result := normalizePrefix(nspath)
+	if result == "/" {
+		return "", errors.New("Cannot register the prefix '/'")
 	}
-	components := strings.Split(nspath, "/")[1:]
-	if len(components) == 0 {
-		return "", errors.New("Cannot register the prefix '/' for an origin")
-	} else if components[0] == "api" {
+	components := strings.Split(result, "/")[1:]
+	if components[0] == "api" {
 		return "", errors.New("Cannot register a prefix starting with '/api'")
 	} else if components[0] == "view" {
 		return "", errors.New("Cannot register a prefix starting with '/view'")
 	} else if components[0] == "pelican" {
 		return "", errors.New("Cannot register a prefix starting with '/pelican'")
 	}
-	result := ""
 	for _, component := range components {
-		if len(component) == 0 {
-			continue
-		} else if component == "." {
+		if component == "." {
 			return "", errors.New("Path component cannot be '.'")
 		} else if component == ".." {
 			return "", errors.New("Path component cannot be '..'")
 		} else if component[0] == '.' {
 			return "", errors.New("Path component cannot begin with a '.'")
 		}
-		result += "/" + component
-	}
-	if result == "/" || len(result) == 0 {
-		return "", errors.New("Cannot register the prefix '/'")
 	}
 	// Check cache/origin prefxies
-	if strings.TrimPrefix(nspath, server_structs.OriginPrefix.String()) == "" {
+	if result+"/" == server_structs.OriginPrefix.String() {
 		return "", errors.New("Origin prefix is missing hostname")
 	}
-	if strings.TrimPrefix(nspath, server_structs.CachePrefix.String()) == "" {
+	if result+"/" == server_structs.CachePrefix.String() {
 		return "", errors.New("Cache prefix is missing sitename")
 	}
-	if server_structs.IsCacheNS(nspath) {
-		hostname := strings.TrimPrefix(nspath, server_structs.CachePrefix.String()) // /caches/blah -> blah
+	if server_structs.IsCacheNS(result) {
+		hostname := strings.TrimPrefix(result, server_structs.CachePrefix.String()) // /caches/blah -> blah
 		if server_structs.IsCacheNS("/" + hostname) {                               // /caches/caches/blah -> caches/blah -> /caches/blah
 			return "", errors.Errorf("Duplicated cache prefix %s", nspath)
 		}
-	} else if server_structs.IsOriginNS(nspath) {
-		hostname := strings.TrimPrefix(nspath, server_structs.OriginPrefix.String()) // /origins/blah -> blah
+	} else if server_structs.IsOriginNS(result) {
+		hostname := strings.TrimPrefix(result, server_structs.OriginPrefix.String()) // /origins/blah -> blah
 		if server_structs.IsOriginNS("/" + hostname) {                               // /origins/origins/blah -> origins/blah -> /origins/blah
 			return "", errors.Errorf("Duplicated origin prefix %s", nspath)
 		}
@@ -148,6 +153,56 @@ func validateKeyChaining(prefix string, pubkey jwk.Key) (inTopo bool, topoNss []
 	return
 }
 
+// Find the registered namespaces nesting, or nested under, the prefix that are registered
+// with keys other than the incoming one, i.e. that belong to a different owner
+func findPrefixCollisions(prefix string, pubkey jwk.Key) (collisions []string, err error) {
+	// Caches and origins register under their own hostnames and can't collide with namespaces
+	if server_structs.IsCacheNS(prefix) || server_structs.IsOriginNS(prefix) {
+		return
+	}
+	superspaces, subspaces, _, _, err := namespaceSupSubChecks(prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check for namespaces nesting or nested under the prefix")
+	}
+	for _, nested := range append(superspaces, subspaces...) {
+		if nested == prefix {
+			continue
+		}
+		matched, err := matchKeys(pubkey, []string{nested})
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			collisions = append(collisions, nested)
+		}
+	}
+	return
+}
+
+// Check the prefix for collisions with namespaces of a different owner and apply
+// Registry.PrefixCollisionPolicy: with the "reject" policy, a collision is a validation error;
+// with the "review" policy, a note flagging the collision for the reviewing admin is returned
+func checkPrefixCollisions(prefix string, pubkey jwk.Key) (reviewNote string, validationError error, serverError error) {
+	collisions, err := findPrefixCollisions(prefix, pubkey)
+	if err != nil {
+		serverError = err
+		return
+	}
+	if len(collisions) == 0 {
+		return
+	}
+	switch policy := param.Registry_PrefixCollisionPolicy.GetString(); policy {
+	case "reject":
+		validationError = errors.Errorf("The prefix %s collides with namespaces registered under a different owner: %s", prefix, strings.Join(collisions, ", "))
+	case "review", "":
+		log.Infof("Flagging the registration of %s for review, as it collides with namespaces registered under a different owner: %s", prefix, strings.Join(collisions, ", "))
+		reviewNote = fmt.Sprintf("[ Attention: This prefix collides with namespaces registered under a different owner: %s ] ", strings.Join(collisions, ", "))
+	default:
+		serverError = errors.Errorf("Invalid prefix collision policy '%s' set in Registry.PrefixCollisionPolicy. Valid policies are 'review' and 'reject'", policy)
+	}
+	return
+}
+
 func validateJwks(jwksStr string) (jwk.Key, error) {
 	if jwksStr == "" {
 		return nil, errors.New("public key is empty")
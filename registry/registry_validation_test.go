@@ -279,11 +279,116 @@ func TestValidateKeyChaining(t *testing.T) {
 	})
 }
 
+func TestCheckPrefixCollisions(t *testing.T) {
+	viper.Reset()
+	setupMockRegistryDB(t)
+	defer func() {
+		resetNamespaceDB(t)
+		teardownMockNamespaceDB(t)
+		viper.Reset()
+	}()
+
+	_, jwksFoo, jwksStrFoo, err := test_utils.GenerateJWK()
+	require.NoError(t, err)
+	jwkFoo, ok := jwksFoo.Key(0)
+	require.True(t, ok)
+
+	_, jwksOther, _, err := test_utils.GenerateJWK()
+	require.NoError(t, err)
+	jwkOther, ok := jwksOther.Key(0)
+	require.True(t, ok)
+
+	err = insertMockDBData([]server_structs.Namespace{
+		mockNamespace("/foo", jwksStrFoo, "", server_structs.AdminMetadata{}),
+		mockNamespace("/baz/qux", jwksStrFoo, "", server_structs.AdminMetadata{}),
+	})
+	require.NoError(t, err)
+
+	t.Run("same-owner-no-collision", func(t *testing.T) {
+		viper.Set("Registry.PrefixCollisionPolicy", "reject")
+		collisions, err := findPrefixCollisions("/foo/bar", jwkFoo)
+		require.NoError(t, err)
+		assert.Empty(t, collisions)
+	})
+
+	t.Run("unrelated-prefix-no-collision", func(t *testing.T) {
+		collisions, err := findPrefixCollisions("/foobar", jwkOther)
+		require.NoError(t, err)
+		assert.Empty(t, collisions)
+	})
+
+	t.Run("nested-prefixes-collide", func(t *testing.T) {
+		collisions, err := findPrefixCollisions("/foo/bar", jwkOther)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/foo"}, collisions)
+
+		collisions, err = findPrefixCollisions("/baz", jwkOther)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/baz/qux"}, collisions)
+	})
+
+	t.Run("reject-policy", func(t *testing.T) {
+		viper.Set("Registry.PrefixCollisionPolicy", "reject")
+		note, validErr, serverErr := checkPrefixCollisions("/foo/bar", jwkOther)
+		require.NoError(t, serverErr)
+		require.Error(t, validErr)
+		assert.Contains(t, validErr.Error(), "collides with namespaces registered under a different owner: /foo")
+		assert.Empty(t, note)
+	})
+
+	t.Run("review-policy", func(t *testing.T) {
+		viper.Set("Registry.PrefixCollisionPolicy", "review")
+		note, validErr, serverErr := checkPrefixCollisions("/foo/bar", jwkOther)
+		require.NoError(t, serverErr)
+		require.NoError(t, validErr)
+		assert.Contains(t, note, "/foo")
+	})
+
+	t.Run("invalid-policy", func(t *testing.T) {
+		viper.Set("Registry.PrefixCollisionPolicy", "ignore")
+		_, _, serverErr := checkPrefixCollisions("/foo/bar", jwkOther)
+		assert.Error(t, serverErr)
+	})
+}
+
 func TestValidatePrefix(t *testing.T) {
+	t.Run("normalizes-prefix", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"/foo/bar":       "/foo/bar",
+			"foo/bar":        "/foo/bar",
+			"/foo//bar/":     "/foo/bar",
+			"//foo///bar//":  "/foo/bar",
+			"/origins//foo/": "/origins/foo",
+		} {
+			got, err := validatePrefix(input)
+			require.NoError(t, err, input)
+			assert.Equal(t, expected, got, input)
+		}
+	})
+
+	t.Run("root-prefix-returns-err", func(t *testing.T) {
+		for _, input := range []string{"/", "//", "///"} {
+			_, err := validatePrefix(input)
+			require.Error(t, err, input)
+			assert.Equal(t, "Cannot register the prefix '/'", err.Error())
+		}
+	})
+
+	t.Run("invalid-components-return-err", func(t *testing.T) {
+		for _, input := range []string{"/foo/./bar", "/foo/../bar", "/foo/.hidden", "//api/foo"} {
+			_, err := validatePrefix(input)
+			assert.Error(t, err, input)
+		}
+	})
+
 	t.Run("root-origin-prefix-returns-err", func(t *testing.T) {
 		_, err := validatePrefix("/origins/")
 		require.Error(t, err)
 		assert.Equal(t, "Origin prefix is missing hostname", err.Error())
+
+		_, err = validatePrefix("/origins")
+		require.Error(t, err)
+		assert.Equal(t, "Origin prefix is missing hostname", err.Error())
 	})
 
 	t.Run("root-cache-prefix-returns-err", func(t *testing.T) {
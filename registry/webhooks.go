@@ -0,0 +1,228 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	namespaceEventType string
+
+	// The event the registry POSTs to each of Registry.WebhookUrls on a namespace lifecycle transition
+	namespaceEvent struct {
+		Event     namespaceEventType    `json:"event"`
+		Timestamp time.Time             `json:"timestamp"`
+		Namespace namespaceEventSubject `json:"namespace"`
+	}
+
+	namespaceEventSubject struct {
+		ID     int    `json:"id"`
+		Prefix string `json:"prefix"`
+		Status string `json:"status"`
+	}
+
+	// A webhook delivery failure that retrying won't fix
+	permanentWebhookError struct {
+		err error
+	}
+)
+
+const (
+	namespaceRegistered namespaceEventType = "namespace.registered"
+	namespaceApproved   namespaceEventType = "namespace.approved"
+	namespaceDenied     namespaceEventType = "namespace.denied"
+	namespaceDeleted    namespaceEventType = "namespace.deleted"
+
+	webhookSignatureHeader = "X-Pelican-Signature"
+	webhookEventHeader     = "X-Pelican-Event"
+)
+
+var (
+	// The delay before the first retry of a failed delivery; it doubles with each retry
+	webhookRetryDelay = 2 * time.Second
+
+	// The context and errgroup of the running registry the deliveries run under, so that they
+	// stop at shutdown; set by LaunchWebhookNotifier
+	webhookCtx   context.Context
+	webhookEgrp  *errgroup.Group
+	webhookMutex sync.RWMutex
+)
+
+func (e permanentWebhookError) Error() string {
+	return e.err.Error()
+}
+
+// Compute the value of the X-Pelican-Signature header of a webhook request body
+func signWebhookBody(body []byte, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Read the webhook secret from Registry.WebhookSecretFile on each delivery, so that
+// rotating the secret doesn't require a restart. Returns nil if no secret is configured
+func getWebhookSecret() ([]byte, error) {
+	secretFile := param.Registry_WebhookSecretFile.GetString()
+	if secretFile == "" {
+		return nil, nil
+	}
+	contents, err := os.ReadFile(secretFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the webhook secret file")
+	}
+	secret := bytes.TrimSpace(contents)
+	if len(secret) == 0 {
+		return nil, errors.Errorf("the webhook secret file %s is empty", secretFile)
+	}
+	return secret, nil
+}
+
+// POST an event to a single webhook
+func postWebhookEvent(ctx context.Context, client *http.Client, webhookUrl string, eventType namespaceEventType, body []byte, secret []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return permanentWebhookError{errors.Wrap(err, "failed to create the webhook request")}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pelican-registry/"+config.GetVersion())
+	req.Header.Set(webhookEventHeader, string(eventType))
+	if secret != nil {
+		req.Header.Set(webhookSignatureHeader, signWebhookBody(body, secret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = errors.Errorf("the webhook responded with status %d", resp.StatusCode)
+	// The receiver rejected the event itself; sending it again won't change that
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return permanentWebhookError{err}
+	}
+	return err
+}
+
+// Deliver an event to a single webhook, retrying up to Registry.WebhookMaxRetries times with
+// an exponential backoff. An event that can't be delivered is logged as a dead letter
+func deliverWebhookEvent(ctx context.Context, webhookUrl string, event namespaceEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the namespace event")
+	}
+	secret, err := getWebhookSecret()
+	if err != nil {
+		log.WithFields(log.Fields{"webhook": webhookUrl, "event": string(body)}).Errorf("Undeliverable namespace event: %v", err)
+		return err
+	}
+	client := &http.Client{Transport: config.GetTransport(), Timeout: 10 * time.Second}
+
+	maxRetries := param.Registry_WebhookMaxRetries.GetInt()
+	delay := webhookRetryDelay
+	for attempt := 0; ; attempt++ {
+		if err = postWebhookEvent(ctx, client, webhookUrl, event.Event, body, secret); err == nil {
+			log.Debugf("Delivered the %s event of namespace %s to the webhook %s", event.Event, event.Namespace.Prefix, webhookUrl)
+			return nil
+		}
+		if errors.As(err, &permanentWebhookError{}) || attempt >= maxRetries {
+			break
+		}
+		log.Warningf("Failed to deliver the %s event of namespace %s to the webhook %s (attempt %d of %d): %v",
+			event.Event, event.Namespace.Prefix, webhookUrl, attempt+1, maxRetries+1, err)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			delay *= 2
+			continue
+		}
+		break
+	}
+	log.WithFields(log.Fields{"webhook": webhookUrl, "event": string(body)}).Errorf("Undeliverable namespace event: %v", err)
+	return err
+}
+
+// Start delivering namespace lifecycle events to Registry.WebhookUrls. Deliveries run in the
+// registry's errgroup and are abandoned when its context is canceled
+func LaunchWebhookNotifier(ctx context.Context, egrp *errgroup.Group) {
+	if len(param.Registry_WebhookUrls.GetStringSlice()) > 0 && param.Registry_WebhookSecretFile.GetString() == "" {
+		log.Warningf("%s is set without %s: the namespace events sent to the webhooks are not signed and receivers can't verify they come from the registry",
+			param.Registry_WebhookUrls.GetName(), param.Registry_WebhookSecretFile.GetName())
+	}
+	webhookMutex.Lock()
+	defer webhookMutex.Unlock()
+	webhookCtx = ctx
+	webhookEgrp = egrp
+}
+
+// Notify each of Registry.WebhookUrls of a namespace lifecycle event in the background
+func notifyNamespaceEvent(eventType namespaceEventType, ns *server_structs.Namespace) {
+	webhookUrls := param.Registry_WebhookUrls.GetStringSlice()
+	if len(webhookUrls) == 0 || ns == nil {
+		return
+	}
+	webhookMutex.RLock()
+	ctx, egrp := webhookCtx, webhookEgrp
+	webhookMutex.RUnlock()
+	if egrp == nil {
+		log.Warningf("Dropping the %s event of namespace %s: the webhook notifier isn't running", eventType, ns.Prefix)
+		return
+	}
+	event := namespaceEvent{
+		Event:     eventType,
+		Timestamp: time.Now().UTC(),
+		Namespace: namespaceEventSubject{
+			ID:     ns.ID,
+			Prefix: ns.Prefix,
+			Status: ns.AdminMetadata.Status.String(),
+		},
+	}
+	for _, webhookUrl := range webhookUrls {
+		webhookUrl := strings.TrimSpace(webhookUrl)
+		if webhookUrl == "" {
+			continue
+		}
+		egrp.Go(func() error {
+			// A failed delivery is logged as a dead letter; it mustn't bring the registry down
+			_ = deliverWebhookEvent(ctx, webhookUrl, event)
+			return nil
+		})
+	}
+}
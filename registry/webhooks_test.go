@@ -0,0 +1,226 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+// Check the signature against the HMAC-SHA256 test vector of RFC 4231, test case 2, so that
+// receivers computing it with any standard HMAC implementation agree with the registry
+func TestSignWebhookBody(t *testing.T) {
+	assert.Equal(t, "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		signWebhookBody([]byte("what do ya want for nothing?"), []byte("Jefe")))
+}
+
+func TestLaunchWebhookNotifier(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+		webhookMutex.Lock()
+		webhookCtx, webhookEgrp = nil, nil
+		webhookMutex.Unlock()
+	})
+	ctx, _, egrp := test_utils.TestContext(context.Background(), t)
+
+	t.Run("unsigned-webhooks-warn", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookUrls", []string{"https://example.com/hook"})
+		hook := test.NewLocal(logrus.StandardLogger())
+		defer hook.Reset()
+		LaunchWebhookNotifier(ctx, egrp)
+		require.NotNil(t, hook.LastEntry())
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "Registry.WebhookSecretFile")
+	})
+
+	t.Run("signed-webhooks-dont-warn", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookUrls", []string{"https://example.com/hook"})
+		viper.Set("Registry.WebhookSecretFile", filepath.Join(t.TempDir(), "webhook-secret"))
+		hook := test.NewLocal(logrus.StandardLogger())
+		defer hook.Reset()
+		LaunchWebhookNotifier(ctx, egrp)
+		assert.Nil(t, hook.LastEntry())
+	})
+
+	t.Run("shutdown-abandons-retries", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookMaxRetries", 100)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		viper.Set("Registry.WebhookUrls", []string{server.URL})
+		shutdownCtx, shutdown := context.WithCancel(context.Background())
+		shutdownEgrp, shutdownCtx := errgroup.WithContext(shutdownCtx)
+		LaunchWebhookNotifier(shutdownCtx, shutdownEgrp)
+
+		notifyNamespaceEvent(namespaceDeleted, &server_structs.Namespace{ID: 3, Prefix: "/baz"})
+		shutdown()
+		done := make(chan error)
+		go func() {
+			done <- shutdownEgrp.Wait()
+		}()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("The webhook delivery kept retrying after the registry shut down")
+		}
+	})
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+		webhookRetryDelay = 2 * time.Second
+	})
+	webhookRetryDelay = time.Millisecond
+	secretFile := filepath.Join(t.TempDir(), "webhook-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("a-shared-secret\n"), 0600))
+
+	event := namespaceEvent{
+		Event:     namespaceApproved,
+		Timestamp: time.Now().UTC(),
+		Namespace: namespaceEventSubject{ID: 1, Prefix: "/foo", Status: server_structs.RegApproved.String()},
+	}
+
+	t.Run("signed-delivery", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookSecretFile", secretFile)
+		viper.Set("Registry.WebhookMaxRetries", 3)
+		var received namespaceEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, signWebhookBody(body, []byte("a-shared-secret")), r.Header.Get(webhookSignatureHeader))
+			assert.Equal(t, string(namespaceApproved), r.Header.Get(webhookEventHeader))
+			assert.NoError(t, json.Unmarshal(body, &received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		require.NoError(t, deliverWebhookEvent(context.Background(), server.URL, event))
+		assert.Equal(t, "/foo", received.Namespace.Prefix)
+		assert.Equal(t, namespaceApproved, received.Event)
+	})
+
+	t.Run("retry-until-delivered", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookMaxRetries", 3)
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(webhookSignatureHeader))
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		require.NoError(t, deliverWebhookEvent(context.Background(), server.URL, event))
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("retries-exhausted", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookMaxRetries", 2)
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		assert.Error(t, deliverWebhookEvent(context.Background(), server.URL, event))
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("rejected-event-not-retried", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Registry.WebhookMaxRetries", 3)
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		assert.Error(t, deliverWebhookEvent(context.Background(), server.URL, event))
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("notify-all-webhooks", func(t *testing.T) {
+		viper.Reset()
+		var mu sync.Mutex
+		events := []namespaceEvent{}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var received namespaceEvent
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			mu.Lock()
+			events = append(events, received)
+			mu.Unlock()
+		})
+		server1 := httptest.NewServer(handler)
+		defer server1.Close()
+		server2 := httptest.NewServer(handler)
+		defer server2.Close()
+		viper.Set("Registry.WebhookUrls", []string{server1.URL, server2.URL})
+		ctx, _, egrp := test_utils.TestContext(context.Background(), t)
+		LaunchWebhookNotifier(ctx, egrp)
+		t.Cleanup(func() {
+			webhookMutex.Lock()
+			webhookCtx, webhookEgrp = nil, nil
+			webhookMutex.Unlock()
+		})
+
+		ns := &server_structs.Namespace{ID: 2, Prefix: "/bar", AdminMetadata: server_structs.AdminMetadata{Status: server_structs.RegPending}}
+		notifyNamespaceEvent(namespaceRegistered, ns)
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(events) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		for _, received := range events {
+			assert.Equal(t, namespaceRegistered, received.Event)
+			assert.Equal(t, "/bar", received.Namespace.Prefix)
+			assert.Equal(t, "Pending", received.Namespace.Status)
+		}
+	})
+}
@@ -38,6 +38,21 @@ type (
 
 	// The standardized status message for the API response
 	SimpleRespStatus string
+
+	// A field of a request body that failed to bind, with a message fit for showing
+	// next to the matching form input
+	FieldValidationError struct {
+		Field string `json:"field"`
+		Msg   string `json:"msg"`
+	}
+
+	// A SimpleApiResp for a request body that failed to bind, listing every offending field.
+	// Msg holds all the field messages joined, for clients that only read it
+	ValidationErrorResp struct {
+		Status SimpleRespStatus       `json:"status"`
+		Msg    string                 `json:"msg,omitempty"`
+		Errors []FieldValidationError `json:"errors"`
+	}
 )
 
 const (
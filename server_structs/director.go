@@ -19,9 +19,21 @@
 package server_structs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
 	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 )
 
 type (
@@ -45,6 +57,8 @@ type (
 		Writes      bool `json:"Write"`
 		Listings    bool `json:"Listing"`
 		DirectReads bool `json:"FallBackRead"`
+		// The objects of the namespace never change once written, so cached copies need no revalidation
+		Immutable bool `json:"Immutable,omitempty"`
 	}
 
 	NamespaceAdV2 struct {
@@ -56,6 +70,30 @@ type (
 		Generation   []TokenGen    `json:"token-generation"`
 		Issuer       []TokenIssuer `json:"token-issuer"`
 		FromTopology bool          `json:"from-topology"`
+		CacheTTL     int64         `json:"cache-ttl,omitempty"` // The default number of seconds caches may keep the namespace's objects; 0 for no hint
+		// The storage backend serving the namespace at the origin; empty for the origin's StorageType
+		Backend OriginStorageType `json:"backend,omitempty"`
+		// Whether clients should upload to a staging name and move the object into place once complete,
+		// so partial uploads are never visible under the object's name; see Origin.AtomicUploads
+		StagedUploads bool `json:"staged-uploads,omitempty"`
+	}
+
+	// A server a client may get an object from, as listed by the director when the client asks for
+	// the candidates of a redirect with `Accept: application/json`
+	RedirectCandidate struct {
+		URL           string     `json:"url"`
+		Name          string     `json:"name"`
+		Type          ServerType `json:"type"`
+		Priority      int        `json:"priority"`             // 1-based position in the ranking, the same as the Link header's pri
+		DistanceKm    *float64   `json:"distanceKm,omitempty"` // Absent if the client or server location is unknown
+		Load          float64    `json:"load"`
+		TokenRequired bool       `json:"tokenRequired"`
+	}
+
+	RedirectCandidatesResp struct {
+		Namespace  string              `json:"namespace"`
+		Depth      int                 `json:"depth"`
+		Candidates []RedirectCandidate `json:"candidates"`
 	}
 
 	NamespaceAdV1 struct {
@@ -86,6 +124,16 @@ type (
 		DirectReads         bool              `json:"enable_fallback_read"` // True if reads from the origin are permitted when no cache is available
 		FromTopology        bool              `json:"from_topology"`
 		IOLoad              float64           `json:"io_load"`
+		Weight              int               `json:"weight"`                    // The relative share of uploads the director sends to the origin among origins serving the same namespace
+		Protocols           []string          `json:"protocols,omitempty"`       // The additional protocols the server supports to serve objects, e.g. ProtocolS3Presign
+		Site                string            `json:"site,omitempty"`            // The administrative site the server belongs to
+		Labels              map[string]string `json:"labels,omitempty"`          // Operator-defined labels grouping servers, e.g. tier=production
+		FreeSpace           uint64            `json:"free_space,omitempty"`      // The bytes available on the origin's storage; 0 if unknown
+		TotalSpace          uint64            `json:"total_space,omitempty"`     // The size of the origin's storage in bytes; 0 if unknown
+		MaxConcurrency      int               `json:"max_concurrency,omitempty"` // The number of redirects to the origin the director lets be in flight at once; 0 for no limit
+		AltURLs             []url.URL         `json:"alt_urls,omitempty"`        // Other URLs the data endpoint is reachable by, e.g. through a CNAME, for clients to fail over to
+		UpstreamCaches      []url.URL         `json:"upstream_caches,omitempty"` // The data URLs of the parent caches a cache pulls objects from
+		LastAdvertised      *time.Time        `json:"last_advertised,omitempty"` // When the director last recorded the ad; set by the director, not the server
 	}
 
 	// The struct holding a server's advertisement (including ServerAd and NamespaceAd)
@@ -113,6 +161,15 @@ type (
 		Issuer              []TokenIssuer     `json:"token-issuer"`
 		StorageType         OriginStorageType `json:"storageType"`
 		DisableDirectorTest bool              `json:"directorTest"` // Use negative attribute (disable instead of enable) to be BC with legacy servers where they don't have this field
+		Weight              int               `json:"weight,omitempty"`
+		Protocols           []string          `json:"protocols,omitempty"`
+		Site                string            `json:"site,omitempty"`
+		Labels              map[string]string `json:"labels,omitempty"`
+		FreeSpace           uint64            `json:"freeSpace,omitempty"`
+		TotalSpace          uint64            `json:"totalSpace,omitempty"`
+		MaxConcurrency      int               `json:"maxConcurrency,omitempty"`
+		AltDataURLs         []string          `json:"altDataUrls,omitempty"`
+		UpstreamCaches      []string          `json:"upstreamCaches,omitempty"` // The data URLs of the parent caches of a cache
 	}
 
 	OriginAdvertiseV1 struct {
@@ -124,6 +181,39 @@ type (
 		DirectReads bool            `json:"enable-fallback-read"` // True if the origin will allow direct client reads when no caches are available
 	}
 
+	// The metadata of an object as reported by an origin serving it. The fields the
+	// origin didn't report are omitted
+	StatResponse struct {
+		Path         string     `json:"path"`
+		Size         int64      `json:"size"`
+		LastModified *time.Time `json:"lastModified,omitempty"`
+		Checksum     string     `json:"checksum,omitempty"` // The Digest header of the origin, e.g. crc32c=2a6d8ed2
+		ContentType  string     `json:"contentType,omitempty"`
+		OriginURL    string     `json:"originUrl"` // The URL of the object at the origin reporting the metadata
+	}
+
+	// An entry of a collection listing served by the director
+	ListingEntry struct {
+		Name         string    `json:"name"`
+		Path         string    `json:"path"`
+		Size         int64     `json:"size"`
+		ModTime      time.Time `json:"modTime"`
+		IsCollection bool      `json:"isCollection"`
+	}
+
+	// The listing of a collection, merged from all the origins serving its namespace
+	ListingResp struct {
+		Path    string         `json:"path"`
+		Entries []ListingEntry `json:"entries"`
+		// The cursor to pass back to fetch the next page; empty on the last page
+		NextCursor string `json:"nextCursor,omitempty"`
+	}
+
+	// The last time each origin, by hostname, polled the broker for reversal requests
+	BrokerHeartbeatsResp struct {
+		Heartbeats map[string]time.Time `json:"heartbeats"`
+	}
+
 	DirectorTestResult struct {
 		Status    string `json:"status"`
 		Message   string `json:"message"`
@@ -152,11 +242,131 @@ const (
 	OriginType ServerType = "Origin"
 )
 
+// Parse a server type string case-insensitively, e.g. "origin" or "Cache".
+// Returns an error if the string is not a known server type
+func ParseServerType(serverType string) (ServerType, error) {
+	switch {
+	case strings.EqualFold(serverType, string(OriginType)):
+		return OriginType, nil
+	case strings.EqualFold(serverType, string(CacheType)):
+		return CacheType, nil
+	default:
+		return "", fmt.Errorf("invalid server type %q: must be %s or %s", serverType, OriginType, CacheType)
+	}
+}
+
 const (
 	OAuthStrategy StrategyType = "OAuth2"
 	VaultStrategy StrategyType = "Vault"
 )
 
+// The advertise token claim binding the token to the body of the advertisement it's sent
+// with, so that the advertisement is signed by the key the server registered in the registry
+const AdvertisementDigestClaim = "pelican.ad_digest"
+
+// The limits on the labels of a server ad
+const (
+	MaxServerLabels        = 64
+	MaxServerLabelValueLen = 256
+)
+
+// Label keys start and end with an alphanumeric character, with '-', '_' and '.' allowed in between
+var serverLabelKeyRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+
+// Validate the labels advertised by a server, returning an error describing the first invalid label
+func ValidateServerLabels(labels map[string]string) error {
+	if len(labels) > MaxServerLabels {
+		return fmt.Errorf("too many labels: %d, at most %d are allowed", len(labels), MaxServerLabels)
+	}
+	for key, val := range labels {
+		if !serverLabelKeyRegex.MatchString(key) {
+			return fmt.Errorf("invalid label key %q: keys must be at most 63 alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", key)
+		}
+		if len(val) > MaxServerLabelValueLen {
+			return fmt.Errorf("the value of the label %q is longer than %d characters", key, MaxServerLabelValueLen)
+		}
+		if strings.IndexFunc(val, unicode.IsControl) >= 0 {
+			return fmt.Errorf("the value of the label %q contains control characters", key)
+		}
+	}
+	return nil
+}
+
+// Check that a server ad is well-formed: it identifies a server of a known type, its
+// numbers can be encoded as JSON and its labels are valid
+func (ad *ServerAd) Validate() error {
+	if ad.Name == "" {
+		return errors.New("the server has no name")
+	}
+	if ad.URL.Host == "" {
+		return fmt.Errorf("the server %s has no URL", ad.Name)
+	}
+	if ad.Type != OriginType && ad.Type != CacheType {
+		return fmt.Errorf("the server %s has an invalid type %q", ad.Name, ad.Type)
+	}
+	for field, val := range map[string]float64{"latitude": ad.Latitude, "longitude": ad.Longitude, "IO load": ad.IOLoad} {
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return fmt.Errorf("the server %s has an invalid %s %v", ad.Name, field, val)
+		}
+	}
+	if ad.Weight < 0 {
+		return fmt.Errorf("the server %s has a negative weight %d", ad.Name, ad.Weight)
+	}
+	if ad.MaxConcurrency < 0 {
+		return fmt.Errorf("the server %s has a negative concurrency limit %d", ad.Name, ad.MaxConcurrency)
+	}
+	if ad.TotalSpace > 0 && ad.FreeSpace > ad.TotalSpace {
+		return fmt.Errorf("the server %s has more free space (%d bytes) than its total space (%d bytes)", ad.Name, ad.FreeSpace, ad.TotalSpace)
+	}
+	if err := ValidateServerLabels(ad.Labels); err != nil {
+		return fmt.Errorf("the server %s has invalid labels: %w", ad.Name, err)
+	}
+	return nil
+}
+
+// Check that a namespace ad can be used to authorize access: a namespace that isn't
+// publicly readable needs an issuer to accept tokens from and a strategy to get them with
+func (ns *NamespaceAdV2) Validate() error {
+	if ns.PublicRead || ns.Caps.PublicReads {
+		return nil
+	}
+	hasIssuer := false
+	for _, issuer := range ns.Issuer {
+		if issuer.IssuerUrl.String() != "" {
+			hasIssuer = true
+			break
+		}
+	}
+	if !hasIssuer {
+		return fmt.Errorf("the namespace %s is not publicly readable but has no token issuer with a URL", ns.Path)
+	}
+	hasGeneration := false
+	for _, gen := range ns.Generation {
+		switch gen.Strategy {
+		case "":
+			continue
+		case OAuthStrategy:
+		case VaultStrategy:
+			if gen.VaultServer == "" {
+				return fmt.Errorf("the namespace %s uses the %s token generation strategy without a vault server", ns.Path, gen.Strategy)
+			}
+		default:
+			return fmt.Errorf("the namespace %s has an unknown token generation strategy %q", ns.Path, gen.Strategy)
+		}
+		hasGeneration = true
+	}
+	if !hasGeneration {
+		return fmt.Errorf("the namespace %s is not publicly readable but has no token generation strategy", ns.Path)
+	}
+	return nil
+}
+
+// Compute the digest of an advertisement body for the AdvertisementDigestClaim
+func AdvertisementDigest(body []byte) string {
+	digest := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(digest[:])
+}
+
 func (ad *ServerAd) MarshalJSON() ([]byte, error) {
 	type Alias ServerAd
 	return json.Marshal(&struct {
@@ -186,6 +396,124 @@ func (ad *Advertisement) GetIOLoad() float64 {
 	return ad.IOLoad
 }
 
+// Make a deep copy of the namespace ad, so that changes to the copy's slices don't affect the original
+func (ns NamespaceAdV2) clone() NamespaceAdV2 {
+	cloned := ns
+	cloned.Generation = slices.Clone(ns.Generation)
+	if ns.Issuer != nil {
+		cloned.Issuer = make([]TokenIssuer, len(ns.Issuer))
+		for idx, issuer := range ns.Issuer {
+			cloned.Issuer[idx] = issuer
+			cloned.Issuer[idx].BasePaths = slices.Clone(issuer.BasePaths)
+			cloned.Issuer[idx].RestrictedPaths = slices.Clone(issuer.RestrictedPaths)
+		}
+	}
+	return cloned
+}
+
+// Make a deep copy of the advertisement. The copy shares no slices with the original,
+// so either can be modified without affecting the other
+func (ad *Advertisement) Clone() *Advertisement {
+	if ad == nil {
+		return nil
+	}
+	ad.RLock()
+	defer ad.RUnlock()
+	cloned := &Advertisement{ServerAd: ad.ServerAd}
+	cloned.Protocols = slices.Clone(ad.Protocols)
+	cloned.Labels = maps.Clone(ad.Labels)
+	cloned.UpstreamCaches = slices.Clone(ad.UpstreamCaches)
+	cloned.AltURLs = slices.Clone(ad.AltURLs)
+	if ad.LastAdvertised != nil {
+		lastAdvertised := *ad.LastAdvertised
+		cloned.LastAdvertised = &lastAdvertised
+	}
+	if ad.NamespaceAds != nil {
+		cloned.NamespaceAds = make([]NamespaceAdV2, len(ad.NamespaceAds))
+		for idx, ns := range ad.NamespaceAds {
+			cloned.NamespaceAds[idx] = ns.clone()
+		}
+	}
+	return cloned
+}
+
+// Check whether two advertisements describe the same server ad and namespaces. Nil and
+// empty Protocols or NamespaceAds are considered equal
+func (ad *Advertisement) Equal(other *Advertisement) bool {
+	if ad == nil || other == nil {
+		return ad == other
+	}
+	if ad == other {
+		return true
+	}
+	// Compare snapshots rather than holding both locks at once
+	adCopy, otherCopy := ad.Clone(), other.Clone()
+	for _, snapshot := range []*Advertisement{adCopy, otherCopy} {
+		if len(snapshot.Protocols) == 0 {
+			snapshot.Protocols = nil
+		}
+		if len(snapshot.Labels) == 0 {
+			snapshot.Labels = nil
+		}
+		if len(snapshot.UpstreamCaches) == 0 {
+			snapshot.UpstreamCaches = nil
+		}
+		if len(snapshot.AltURLs) == 0 {
+			snapshot.AltURLs = nil
+		}
+		if len(snapshot.NamespaceAds) == 0 {
+			snapshot.NamespaceAds = nil
+		}
+	}
+	return reflect.DeepEqual(adCopy.ServerAd, otherCopy.ServerAd) && reflect.DeepEqual(adCopy.NamespaceAds, otherCopy.NamespaceAds)
+}
+
+// Summarize the advertisement in one line for logs, e.g.
+// `origin "my-origin" at https://origin.org:8443 (2 namespaces; caps: reads,writes)`.
+// Unlike formatting the whole advertisement, the namespaces are only counted
+func (ad *Advertisement) Summary() string {
+	if ad == nil {
+		return "<nil advertisement>"
+	}
+	ad.RLock()
+	defer ad.RUnlock()
+
+	sType := string(ad.Type)
+	if sType == "" {
+		sType = "server"
+	}
+	serverURL := ad.URL.String()
+	if serverURL == "" {
+		serverURL = "<no URL>"
+	}
+	// The legacy top-level flags are still set by older servers
+	caps := []string{}
+	for _, capability := range []struct {
+		name string
+		set  bool
+	}{
+		{"publicreads", ad.Caps.PublicReads},
+		{"reads", ad.Caps.Reads},
+		{"writes", ad.Caps.Writes || ad.Writes},
+		{"listings", ad.Caps.Listings || ad.Listings},
+		{"directreads", ad.Caps.DirectReads || ad.DirectReads},
+	} {
+		if capability.set {
+			caps = append(caps, capability.name)
+		}
+	}
+	capsStr := "none"
+	if len(caps) > 0 {
+		capsStr = strings.Join(caps, ",")
+	}
+
+	summary := fmt.Sprintf("%s %q at %s (%d namespaces; caps: %s)", sType, ad.Name, serverURL, len(ad.NamespaceAds), capsStr)
+	if ad.FromTopology {
+		summary += " from topology"
+	}
+	return summary
+}
+
 func ConvertNamespaceAdsV2ToV1(nsV2 []NamespaceAdV2) []NamespaceAdV1 {
 	// Converts a list of V2 namespace ads to a list of V1 namespace ads.
 	// This is for backwards compatibility in the case an old version of a client calls
@@ -19,8 +19,12 @@
 package server_structs
 
 import (
+	"encoding/json"
+	"math"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -150,3 +154,172 @@ func TestConversion(t *testing.T) {
 	require.Equal(t, oAdV2, OAdConv)
 
 }
+
+func TestParseServerType(t *testing.T) {
+	for _, input := range []string{"origin", "Origin", "ORIGIN"} {
+		sType, err := ParseServerType(input)
+		require.NoError(t, err)
+		require.Equal(t, OriginType, sType)
+	}
+	for _, input := range []string{"cache", "Cache"} {
+		sType, err := ParseServerType(input)
+		require.NoError(t, err)
+		require.Equal(t, CacheType, sType)
+	}
+	for _, input := range []string{"", "staging", "origins"} {
+		_, err := ParseServerType(input)
+		require.Error(t, err)
+	}
+}
+
+func TestValidateServerLabels(t *testing.T) {
+	require.NoError(t, ValidateServerLabels(nil))
+	require.NoError(t, ValidateServerLabels(map[string]string{"tier": "production", "gpu": "true", "site.zone_1-a": ""}))
+	for _, key := range []string{"", "-tier", "tier.", "tier=production", "ti er", "tier/prod", strings.Repeat("a", 64)} {
+		require.Error(t, ValidateServerLabels(map[string]string{key: "value"}), key)
+	}
+	require.Error(t, ValidateServerLabels(map[string]string{"tier": strings.Repeat("a", MaxServerLabelValueLen+1)}))
+	require.Error(t, ValidateServerLabels(map[string]string{"tier": "prod\nuction"}))
+}
+
+func TestServerAdValidate(t *testing.T) {
+	valid := ServerAd{
+		Name:       "origin",
+		URL:        url.URL{Scheme: "https", Host: "origin.org"},
+		Type:       OriginType,
+		FreeSpace:  10,
+		TotalSpace: 100,
+		Labels:     map[string]string{"tier": "production"},
+	}
+	require.NoError(t, valid.Validate())
+
+	for name, modify := range map[string]func(ad *ServerAd){
+		"no-name":         func(ad *ServerAd) { ad.Name = "" },
+		"no-url":          func(ad *ServerAd) { ad.URL = url.URL{} },
+		"unknown-type":    func(ad *ServerAd) { ad.Type = "registry" },
+		"nan-latitude":    func(ad *ServerAd) { ad.Latitude = math.NaN() },
+		"inf-io-load":     func(ad *ServerAd) { ad.IOLoad = math.Inf(1) },
+		"negative-weight": func(ad *ServerAd) { ad.Weight = -1 },
+		"negative-limit":  func(ad *ServerAd) { ad.MaxConcurrency = -1 },
+		"free-over-total": func(ad *ServerAd) { ad.FreeSpace = 200 },
+		"invalid-label":   func(ad *ServerAd) { ad.Labels = map[string]string{"-tier": "production"} },
+	} {
+		ad := valid
+		modify(&ad)
+		require.Error(t, ad.Validate(), name)
+	}
+}
+
+func TestNamespaceAdValidate(t *testing.T) {
+	issuerUrl := url.URL{Scheme: "https", Host: "origin.org"}
+	valid := NamespaceAdV2{
+		Path:       "/foo",
+		Caps:       Capabilities{Reads: true},
+		Issuer:     []TokenIssuer{{BasePaths: []string{"/foo"}, IssuerUrl: issuerUrl}},
+		Generation: []TokenGen{{Strategy: OAuthStrategy, MaxScopeDepth: 1, CredentialIssuer: issuerUrl}},
+	}
+	require.NoError(t, valid.Validate())
+
+	public := NamespaceAdV2{Path: "/public", Caps: Capabilities{PublicReads: true, Reads: true}}
+	require.NoError(t, public.Validate())
+
+	for name, modify := range map[string]func(ns *NamespaceAdV2){
+		"no-issuer":        func(ns *NamespaceAdV2) { ns.Issuer = nil },
+		"empty-issuer-url": func(ns *NamespaceAdV2) { ns.Issuer = []TokenIssuer{{IssuerUrl: url.URL{}}} },
+		"no-generation":    func(ns *NamespaceAdV2) { ns.Generation = nil },
+		"empty-strategy":   func(ns *NamespaceAdV2) { ns.Generation = []TokenGen{{MaxScopeDepth: 1}} },
+		"unknown-strategy": func(ns *NamespaceAdV2) { ns.Generation = []TokenGen{{Strategy: "SciTokens"}} },
+		"vault-no-server":  func(ns *NamespaceAdV2) { ns.Generation = []TokenGen{{Strategy: VaultStrategy}} },
+	} {
+		ns := valid
+		modify(&ns)
+		err := ns.Validate()
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), "/foo", name)
+	}
+}
+
+func TestAdvertisementCloneAndEqual(t *testing.T) {
+	original := &Advertisement{
+		ServerAd: ServerAd{
+			Name:      "origin",
+			URL:       url.URL{Scheme: "https", Host: "origin.org"},
+			Type:      OriginType,
+			Protocols: []string{ProtocolS3Presign},
+			AltURLs:   []url.URL{{Scheme: "https", Host: "alt.origin.org"}},
+		},
+		NamespaceAds: []NamespaceAdV2{{
+			Path:   "/foo",
+			Issuer: []TokenIssuer{{BasePaths: []string{"/foo"}, IssuerUrl: url.URL{Scheme: "https", Host: "issuer.org"}}},
+		}},
+	}
+
+	cloned := original.Clone()
+	require.True(t, original.Equal(cloned))
+	require.True(t, cloned.Equal(original))
+
+	// Modifying the copy doesn't affect the original
+	cloned.URL.Host = "origin2.org"
+	cloned.Protocols[0] = "other"
+	cloned.AltURLs[0].Host = "alt.origin2.org"
+	cloned.NamespaceAds[0].Path = "/bar"
+	cloned.NamespaceAds[0].Issuer[0].BasePaths[0] = "/bar"
+	require.False(t, original.Equal(cloned))
+	require.Equal(t, "origin.org", original.URL.Host)
+	require.Equal(t, ProtocolS3Presign, original.Protocols[0])
+	require.Equal(t, "alt.origin.org", original.AltURLs[0].Host)
+	require.Equal(t, "/foo", original.NamespaceAds[0].Path)
+	require.Equal(t, "/foo", original.NamespaceAds[0].Issuer[0].BasePaths[0])
+
+	// Nil and empty slices are equal
+	empty := &Advertisement{ServerAd: ServerAd{Name: "cache"}, NamespaceAds: []NamespaceAdV2{}}
+	require.True(t, empty.Equal(&Advertisement{ServerAd: ServerAd{Name: "cache", Protocols: []string{}}}))
+
+	var nilAd *Advertisement
+	require.Nil(t, nilAd.Clone())
+	require.True(t, nilAd.Equal(nil))
+	require.False(t, nilAd.Equal(empty))
+	require.False(t, empty.Equal(nilAd))
+}
+
+func TestServerAdLastAdvertised(t *testing.T) {
+	// Ads that didn't come from the director don't report when they were last advertised
+	body, err := json.Marshal(ServerAd{Name: "origin"})
+	require.NoError(t, err)
+	require.NotContains(t, string(body), "last_advertised")
+
+	lastAdvertised := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ad := &Advertisement{ServerAd: ServerAd{Name: "origin", LastAdvertised: &lastAdvertised}}
+	body, err = json.Marshal(ad.ServerAd)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"last_advertised":"2024-06-01T12:00:00Z"`)
+
+	cloned := ad.Clone()
+	require.True(t, ad.Equal(cloned))
+	*cloned.LastAdvertised = lastAdvertised.Add(time.Minute)
+	require.Equal(t, lastAdvertised, *ad.LastAdvertised)
+}
+
+func TestAdvertisementSummary(t *testing.T) {
+	ad := &Advertisement{
+		ServerAd: ServerAd{
+			Name:        "origin",
+			URL:         url.URL{Scheme: "https", Host: "origin.org:8443"},
+			Type:        OriginType,
+			Caps:        Capabilities{Reads: true, Listings: true},
+			DirectReads: true,
+		},
+		NamespaceAds: []NamespaceAdV2{{Path: "/foo"}, {Path: "/bar"}},
+	}
+	expected := `Origin "origin" at https://origin.org:8443 (2 namespaces; caps: reads,listings,directreads)`
+	require.Equal(t, expected, ad.Summary())
+	// The summary doesn't depend on anything but the advertisement
+	require.Equal(t, expected, ad.Clone().Summary())
+
+	ad.FromTopology = true
+	require.Equal(t, expected+" from topology", ad.Summary())
+
+	require.Equal(t, `server "" at <no URL> (0 namespaces; caps: none)`, (&Advertisement{}).Summary())
+	var nilAd *Advertisement
+	require.Equal(t, "<nil advertisement>", nilAd.Summary())
+}
@@ -0,0 +1,91 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package server_structs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A namespace alias maps an old namespace path to the path it's re-homed to,
+// e.g. /old/path -> /new/path, so /old/path/foo resolves to /new/path/foo
+type NamespaceAlias struct {
+	Path   string `mapstructure:"Path" json:"path"`
+	Target string `mapstructure:"Target" json:"target"`
+}
+
+// Find the longest alias path in the map that the request path is at or under, and
+// rewrite the request path with the alias target. Returns false if no alias applies
+func applyNamespaceAlias(aliases map[string]string, reqPath string) (string, bool) {
+	best := ""
+	for aliasPath := range aliases {
+		if (reqPath == aliasPath || strings.HasPrefix(reqPath, aliasPath+"/")) && len(aliasPath) > len(best) {
+			best = aliasPath
+		}
+	}
+	if best == "" {
+		return reqPath, false
+	}
+	return aliases[best] + strings.TrimPrefix(reqPath, best), true
+}
+
+// Resolve a request path through the alias map, following chained aliases.
+// Returns an error if the aliases form a loop
+func ResolveNamespaceAlias(aliases map[string]string, reqPath string) (string, error) {
+	resolved := path.Clean("/" + reqPath)
+	// Each alias can apply at most once in a loop-free chain
+	for i := 0; i <= len(aliases); i++ {
+		next, ok := applyNamespaceAlias(aliases, resolved)
+		if !ok {
+			return resolved, nil
+		}
+		resolved = next
+	}
+	return "", fmt.Errorf("namespace aliases form a loop when resolving %s", reqPath)
+}
+
+// Validate the namespace aliases and convert them to a map from the alias path to the target.
+// Paths are cleaned; duplicated alias paths, self-referencing aliases, and loops are rejected
+func ValidateNamespaceAliases(aliases []NamespaceAlias) (map[string]string, error) {
+	aliasMap := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		if alias.Path == "" || alias.Target == "" {
+			return nil, fmt.Errorf("namespace alias %q -> %q must have both a path and a target", alias.Path, alias.Target)
+		}
+		aliasPath := path.Clean("/" + alias.Path)
+		target := path.Clean("/" + alias.Target)
+		if aliasPath == "/" {
+			return nil, fmt.Errorf("namespace alias path can't be the root path /")
+		}
+		if _, exists := aliasMap[aliasPath]; exists {
+			return nil, fmt.Errorf("namespace alias path %s is defined more than once", aliasPath)
+		}
+		if target == aliasPath || strings.HasPrefix(target, aliasPath+"/") {
+			return nil, fmt.Errorf("namespace alias %s -> %s resolves to itself", aliasPath, target)
+		}
+		aliasMap[aliasPath] = target
+	}
+	for aliasPath := range aliasMap {
+		if _, err := ResolveNamespaceAlias(aliasMap, aliasPath); err != nil {
+			return nil, err
+		}
+	}
+	return aliasMap, nil
+}
@@ -0,0 +1,85 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package server_structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNamespaceAliases(t *testing.T) {
+	t.Run("valid-aliases", func(t *testing.T) {
+		aliases, err := ValidateNamespaceAliases([]NamespaceAlias{
+			{Path: "/old/path/", Target: "/new/path"},
+			{Path: "/older", Target: "/old/path"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/old/path": "/new/path", "/older": "/old/path"}, aliases)
+	})
+
+	t.Run("duplicated-alias", func(t *testing.T) {
+		_, err := ValidateNamespaceAliases([]NamespaceAlias{
+			{Path: "/old", Target: "/new"},
+			{Path: "/old/", Target: "/newer"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("self-referencing-alias", func(t *testing.T) {
+		_, err := ValidateNamespaceAliases([]NamespaceAlias{{Path: "/old", Target: "/old/sub"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("alias-loop", func(t *testing.T) {
+		_, err := ValidateNamespaceAliases([]NamespaceAlias{
+			{Path: "/a", Target: "/b"},
+			{Path: "/b", Target: "/c"},
+			{Path: "/c", Target: "/a"},
+		})
+		assert.ErrorContains(t, err, "loop")
+	})
+
+	t.Run("growing-alias-loop", func(t *testing.T) {
+		_, err := ValidateNamespaceAliases([]NamespaceAlias{
+			{Path: "/a", Target: "/b"},
+			{Path: "/b", Target: "/a/c"},
+		})
+		assert.ErrorContains(t, err, "loop")
+	})
+}
+
+func TestResolveNamespaceAlias(t *testing.T) {
+	aliases := map[string]string{"/old/path": "/new/path", "/older": "/old/path"}
+
+	resolved, err := ResolveNamespaceAlias(aliases, "/old/path/foo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/new/path/foo.txt", resolved)
+
+	// Chained aliases are followed
+	resolved, err = ResolveNamespaceAlias(aliases, "/older/foo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/new/path/foo.txt", resolved)
+
+	// Only whole path components match
+	resolved, err = ResolveNamespaceAlias(aliases, "/old/pathological/foo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/old/pathological/foo.txt", resolved)
+}
@@ -18,10 +18,20 @@
 
 package server_structs
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 type (
 	OriginStorageType string
+
+	// The response of an S3 origin's presign API: a URL to GET the object directly from S3
+	PresignedURLResp struct {
+		URL       string `json:"url"`
+		ExpiresAt int64  `json:"expires"` // Unix time the URL expires at
+	}
 )
 
 const (
@@ -32,6 +42,19 @@ const (
 	OriginStorageXRoot  OriginStorageType = "xroot" // Not meant to be extensible, but facilitates legacy OSDF --> Pelican transition
 )
 
+// The protocol an S3 origin advertises when the director may redirect clients to presigned S3 URLs
+const ProtocolS3Presign = "s3-presign"
+
+// The prefix of the staging names clients upload objects to before moving them into place, for
+// namespaces advertising staged uploads. Objects with such names are in-flight uploads: listings
+// skip them and origins remove the ones left behind by interrupted clients
+const StagedUploadPrefix = ".pelican-upload."
+
+// Check if the object name is the staging name of an in-flight upload
+func IsStagedUploadName(name string) bool {
+	return strings.HasPrefix(name, StagedUploadPrefix)
+}
+
 var (
 	ErrUnknownOriginStorageType = errors.New("unknown origin storage type")
 )
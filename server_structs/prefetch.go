@@ -0,0 +1,44 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package server_structs
+
+type (
+	// The request to prefetch objects into a cache. Exactly one of Prefix and Objects is set
+	PrefetchRequest struct {
+		Prefix  string   `json:"prefix,omitempty"`  // Prefetch the objects directly under the namespace prefix
+		Objects []string `json:"objects,omitempty"` // Prefetch the listed object paths
+		Token   string   `json:"token,omitempty"`   // The token to read the objects of a protected namespace
+	}
+
+	PrefetchObjectStatus struct {
+		Path   string `json:"path"`
+		Status string `json:"status"` // pending|in_progress|succeeded|failed
+		Bytes  int64  `json:"bytes"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// The progress of a prefetch job at a cache
+	PrefetchJobStatus struct {
+		ID      string                 `json:"id"`
+		Done    bool                   `json:"done"`
+		Pending int                    `json:"pending"`
+		Failed  int                    `json:"failed"`
+		Objects []PrefetchObjectStatus `json:"objects"`
+	}
+)
@@ -0,0 +1,89 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package server_structs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type (
+	RedirectPolicyMode string
+
+	// How the director redirects the clients of a namespace. The namespace's owner sets it in the
+	// registry, and it takes precedence over the director's own configuration for the namespace
+	RedirectPolicy struct {
+		Mode RedirectPolicyMode `json:"mode,omitempty"`
+		// Only offer clients the N best-ranked servers, e.g. the N nearest; 0 for the director's default
+		MaxServers int `json:"max_servers,omitempty"`
+	}
+
+	// The redirect policy of a namespace prefix, as the registry reports it to the director
+	NamespaceRedirectPolicy struct {
+		Prefix string         `json:"prefix"`
+		Policy RedirectPolicy `json:"policy"`
+	}
+)
+
+const (
+	// Follow the director's configuration
+	RedirectPolicyDefault RedirectPolicyMode = ""
+	// Reads are only served through caches: clients are never redirected to an origin to read,
+	// even with the directread query or when no cache is available
+	RedirectPolicyCachesOnly RedirectPolicyMode = "caches-only"
+	// Reads are served straight from the origins allowing direct reads, never through caches
+	RedirectPolicyDirectToOrigin RedirectPolicyMode = "direct-to-origin"
+
+	MaxRedirectPolicyServers = 100
+)
+
+// Check that the policy has a known mode and a sensible server limit
+func (policy RedirectPolicy) Validate() error {
+	switch policy.Mode {
+	case RedirectPolicyDefault, RedirectPolicyCachesOnly, RedirectPolicyDirectToOrigin:
+	default:
+		return fmt.Errorf("invalid redirect policy mode %q: must be empty, %q or %q", policy.Mode, RedirectPolicyCachesOnly, RedirectPolicyDirectToOrigin)
+	}
+	if policy.MaxServers < 0 || policy.MaxServers > MaxRedirectPolicyServers {
+		return fmt.Errorf("invalid redirect policy server limit %d: must be between 0 and %d", policy.MaxServers, MaxRedirectPolicyServers)
+	}
+	return nil
+}
+
+// Whether the policy leaves the redirects to the director's configuration
+func (policy RedirectPolicy) IsDefault() bool {
+	return policy == RedirectPolicy{}
+}
+
+// Find the policy of the longest namespace prefix in the map that the request path is at or under.
+// A namespace's policy applies to everything under it, unless a nested namespace has its own
+func ResolveRedirectPolicy(policies map[string]RedirectPolicy, reqPath string) RedirectPolicy {
+	reqPath = path.Clean("/" + reqPath)
+	best := ""
+	for prefix := range policies {
+		if (reqPath == prefix || strings.HasPrefix(reqPath, strings.TrimSuffix(prefix, "/")+"/")) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return RedirectPolicy{}
+	}
+	return policies[best]
+}
@@ -0,0 +1,48 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package server_structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectPolicyValidate(t *testing.T) {
+	assert.NoError(t, RedirectPolicy{}.Validate())
+	assert.NoError(t, RedirectPolicy{Mode: RedirectPolicyCachesOnly, MaxServers: 3}.Validate())
+	assert.NoError(t, RedirectPolicy{Mode: RedirectPolicyDirectToOrigin}.Validate())
+	assert.Error(t, RedirectPolicy{Mode: "nearest"}.Validate())
+	assert.Error(t, RedirectPolicy{MaxServers: -1}.Validate())
+	assert.Error(t, RedirectPolicy{MaxServers: MaxRedirectPolicyServers + 1}.Validate())
+}
+
+func TestResolveRedirectPolicy(t *testing.T) {
+	cachesOnly := RedirectPolicy{Mode: RedirectPolicyCachesOnly}
+	nearest := RedirectPolicy{MaxServers: 2}
+	policies := map[string]RedirectPolicy{"/foo": cachesOnly, "/foo/bar": nearest}
+
+	assert.Equal(t, cachesOnly, ResolveRedirectPolicy(policies, "/foo"))
+	assert.Equal(t, cachesOnly, ResolveRedirectPolicy(policies, "/foo/baz.txt"))
+	// The most specific namespace wins
+	assert.Equal(t, nearest, ResolveRedirectPolicy(policies, "/foo/bar/baz.txt"))
+	// Only whole path components match
+	assert.True(t, ResolveRedirectPolicy(policies, "/foobar/baz.txt").IsDefault())
+	assert.True(t, ResolveRedirectPolicy(nil, "/foo").IsDefault())
+}
@@ -54,12 +54,14 @@ type AdminMetadata struct {
 }
 
 type Namespace struct {
-	ID            int                    `json:"id" post:"exclude" gorm:"primaryKey"`
-	Prefix        string                 `json:"prefix" validate:"required"`
-	Pubkey        string                 `json:"pubkey" validate:"required" description:"Pubkey is your Pelican server public key in JWKS form"`
-	Identity      string                 `json:"identity" post:"exclude"`
-	AdminMetadata AdminMetadata          `json:"admin_metadata" gorm:"serializer:json"`
-	CustomFields  map[string]interface{} `json:"custom_fields" gorm:"serializer:json"`
+	ID             int                    `json:"id" post:"exclude" gorm:"primaryKey"`
+	Prefix         string                 `json:"prefix" validate:"required"`
+	Pubkey         string                 `json:"pubkey" validate:"required" description:"Pubkey is your Pelican server public key in JWKS form"`
+	Identity       string                 `json:"identity" post:"exclude"`
+	AdminMetadata  AdminMetadata          `json:"admin_metadata" gorm:"serializer:json"`
+	CustomFields   map[string]interface{} `json:"custom_fields" gorm:"serializer:json"`
+	RedirectPolicy RedirectPolicy         `json:"redirect_policy" gorm:"serializer:json"`    // How the director redirects the clients of the namespace
+	Aliases        []string               `json:"aliases,omitempty" post:"exclude" gorm:"-"` // The alias paths resolving to this namespace, from Registry.NamespaceAliases
 }
 
 type (
@@ -96,6 +98,15 @@ type (
 	CheckNamespaceCompleteRes struct {
 		Results map[string]NamespaceCompletenessResult `json:"results"`
 	}
+
+	// The redirect requests the director counted per namespace prefix during a window of time.
+	// The window ID is unique per window so that the registry counts a retried report only once
+	NamespaceStatsReport struct {
+		WindowID    string           `json:"window_id" binding:"required"`
+		WindowStart time.Time        `json:"window_start"`
+		WindowEnd   time.Time        `json:"window_end"`
+		Requests    map[string]int64 `json:"requests"`
+	}
 )
 
 const (
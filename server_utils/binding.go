@@ -0,0 +1,143 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package server_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// Describe the JSON type of a Go type, e.g. "a boolean" for *bool
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// Convert the Go namespace of a struct field in a validation error (e.g. "req.Spec.ReadOnly")
+// to the JSON path the client sent (e.g. "spec.readOnly"), using the json tags of obj
+func jsonFieldPath(obj interface{}, structNamespace string) string {
+	parts := strings.Split(structNamespace, ".")
+	if len(parts) < 2 {
+		return structNamespace
+	}
+	t := reflect.TypeOf(obj)
+	names := make([]string, 0, len(parts)-1)
+	// The first part is the name of the top-level struct
+	for _, part := range parts[1:] {
+		goName, index, _ := strings.Cut(part, "[")
+		name := goName
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(goName); ok {
+				if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+					name = tag
+				}
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+		if index != "" {
+			name += "[" + index
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ".")
+}
+
+// Describe a failed validation rule of a field
+func fieldErrorMsg(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("field '%s' is required and must be %s", field, jsonTypeName(fe.Type()))
+	case "oneof":
+		return fmt.Sprintf("field '%s' must be one of: %s", field, strings.Join(strings.Fields(fe.Param()), ", "))
+	case "min", "gte":
+		return fmt.Sprintf("field '%s' must be at least %s", field, fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("field '%s' must be at most %s", field, fe.Param())
+	default:
+		return fmt.Sprintf("field '%s' failed the '%s' validation", field, fe.Tag())
+	}
+}
+
+// Turn the error from binding a JSON request body into obj (e.g. with gin's ShouldBindJSON)
+// into field-level messages a web UI can show next to each form input
+func GetBindingErrors(err error, obj interface{}) []server_structs.FieldValidationError {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrs):
+		fieldErrs := make([]server_structs.FieldValidationError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := jsonFieldPath(obj, fe.StructNamespace())
+			fieldErrs = append(fieldErrs, server_structs.FieldValidationError{Field: field, Msg: fieldErrorMsg(field, fe)})
+		}
+		return fieldErrs
+	case errors.As(err, &typeErr):
+		return []server_structs.FieldValidationError{{
+			Field: typeErr.Field,
+			Msg:   fmt.Sprintf("field '%s' must be %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value),
+		}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return []server_structs.FieldValidationError{{Msg: "request body is not valid JSON"}}
+	default:
+		return []server_structs.FieldValidationError{{Msg: err.Error()}}
+	}
+}
+
+// Build the response to a request whose body failed to bind into obj
+func NewBindingErrorResp(err error, obj interface{}) server_structs.ValidationErrorResp {
+	fieldErrs := GetBindingErrors(err, obj)
+	msgs := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		msgs = append(msgs, fe.Msg)
+	}
+	return server_structs.ValidationErrorResp{
+		Status: server_structs.RespFailed,
+		Msg:    "Invalid request body: " + strings.Join(msgs, "; "),
+		Errors: fieldErrs,
+	}
+}
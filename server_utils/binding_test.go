@@ -0,0 +1,65 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestGetBindingErrors(t *testing.T) {
+	type spec struct {
+		Mode  string `json:"mode" binding:"required,oneof=read write"`
+		Limit int    `json:"limit" binding:"min=1"`
+	}
+	type req struct {
+		Disabled *bool `json:"disabled" binding:"required"`
+		Spec     spec  `json:"spec"`
+	}
+
+	bindErrs := func(body string) []server_structs.FieldValidationError {
+		r := req{}
+		err := binding.JSON.BindBody([]byte(body), &r)
+		if err == nil {
+			return nil
+		}
+		return GetBindingErrors(err, r)
+	}
+
+	assert.Nil(t, bindErrs(`{"disabled": true, "spec": {"mode": "read", "limit": 1}}`))
+	assert.Equal(t, []server_structs.FieldValidationError{
+		{Field: "disabled", Msg: "field 'disabled' is required and must be a boolean"},
+		{Field: "spec.mode", Msg: "field 'spec.mode' must be one of: read, write"},
+		{Field: "spec.limit", Msg: "field 'spec.limit' must be at least 1"},
+	}, bindErrs(`{"spec": {"mode": "delete"}}`))
+	assert.Equal(t, []server_structs.FieldValidationError{
+		{Field: "disabled", Msg: "field 'disabled' must be a boolean, got string"},
+	}, bindErrs(`{"disabled": "yes"}`))
+	assert.Equal(t, []server_structs.FieldValidationError{
+		{Msg: "request body is not valid JSON"},
+	}, bindErrs(`{"disabled": tru`))
+
+	resp := NewBindingErrorResp(binding.JSON.BindBody([]byte(`{}`), &req{}), req{})
+	assert.Equal(t, server_structs.RespFailed, resp.Status)
+	assert.Contains(t, resp.Msg, "field 'disabled' is required")
+	assert.Len(t, resp.Errors, 3)
+}
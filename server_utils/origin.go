@@ -43,6 +43,10 @@ type (
 		StoragePrefix    string `json:"storagePrefix"`
 		FederationPrefix string `json:"federationPrefix"`
 
+		// The storage backend of the export, if it differs from the origin's Origin.StorageType.
+		// A POSIX origin may front S3 exports, which are served through presigned S3 URLs
+		StorageType server_structs.OriginStorageType `json:"storageType,omitempty"`
+
 		// Export fields specific to S3 backend. Other things like
 		// S3ServiceUrl, S3Region, etc are kept top-level in the config
 		S3Bucket        string `json:"s3Bucket,omitempty"`
@@ -90,8 +94,8 @@ func StringListToCapsHookFunc() mapstructure.DecodeHookFuncType {
 		to reflect.Type,
 		data interface{},
 	) (interface{}, error) {
-		// Check that data is a slice of empty interfaces
-		if from.Kind() != reflect.Slice || from.Elem().Kind() != reflect.Interface {
+		// Check that data is a slice of empty interfaces, or of strings if set directly
+		if from.Kind() != reflect.Slice || (from.Elem().Kind() != reflect.Interface && from.Elem().Kind() != reflect.String) {
 			return data, nil
 		}
 
@@ -101,10 +105,13 @@ func StringListToCapsHookFunc() mapstructure.DecodeHookFuncType {
 		}
 
 		// Convert the slice of interfaces to a slice of strings
-		interfaces := data.([]interface{})
-		caps := make([]string, len(interfaces))
-		for i, v := range interfaces {
-			caps[i] = v.(string)
+		caps, ok := data.([]string)
+		if !ok {
+			interfaces := data.([]interface{})
+			caps = make([]string, len(interfaces))
+			for i, v := range interfaces {
+				caps[i] = v.(string)
+			}
 		}
 
 		// Convert the string slice to ExportCapabilities struct
@@ -123,6 +130,8 @@ func StringListToCapsHookFunc() mapstructure.DecodeHookFuncType {
 				exportCaps.DirectReads = true
 			case "Reads":
 				exportCaps.Reads = true
+			case "Immutable":
+				exportCaps.Immutable = true
 			default:
 				return nil, errors.Errorf("Unknown capability %v", cap)
 			}
@@ -132,6 +141,47 @@ func StringListToCapsHookFunc() mapstructure.DecodeHookFuncType {
 	}
 }
 
+// Get the storage backend of the export, defaulting to the origin's Origin.StorageType
+func (export OriginExport) GetStorageType() server_structs.OriginStorageType {
+	if export.StorageType != "" {
+		return export.StorageType
+	}
+	return server_structs.OriginStorageType(param.Origin_StorageType.GetString())
+}
+
+// Validate the export of an Origin.Exports block according to its storage backend. Exports of a
+// POSIX origin may be backed by S3, as long as they are served through presigned S3 URLs
+func validateExport(export OriginExport, originType server_structs.OriginStorageType) error {
+	exportType := originType
+	if export.StorageType != "" {
+		parsed, err := server_structs.ParseOriginStorageType(string(export.StorageType))
+		if err != nil {
+			return errors.Wrapf(err, "invalid storage type for export %s", export.FederationPrefix)
+		}
+		exportType = parsed
+	}
+	switch {
+	case exportType == originType:
+	case originType == server_structs.OriginStoragePosix && exportType == server_structs.OriginStorageS3:
+		if !param.Origin_S3PresignRedirects.GetBool() {
+			return errors.Wrapf(ErrInvalidOriginConfig, "the S3 export %s of a POSIX origin requires Origin.S3PresignRedirects to be enabled", export.FederationPrefix)
+		}
+	default:
+		return errors.Wrapf(ErrInvalidOriginConfig, "the export %s with storage type %s isn't supported by an origin with storage type %s", export.FederationPrefix, exportType, originType)
+	}
+
+	if exportType == server_structs.OriginStorageS3 {
+		if err := validateFederationPrefix(export.FederationPrefix); err != nil {
+			return errors.Wrapf(err, "invalid federation prefix for export %s", export.FederationPrefix)
+		}
+		if err := validateBucketName(export.S3Bucket); err != nil {
+			return errors.Wrapf(err, "invalid bucket name for export %s", export.S3Bucket)
+		}
+		return nil
+	}
+	return validateExportPaths(export.StoragePrefix, export.FederationPrefix)
+}
+
 func validateExportPaths(storagePrefix string, federationPrefix string) error {
 	if storagePrefix == "" || federationPrefix == "" {
 		return errors.Wrap(ErrInvalidOriginConfig, "volume mount/ExportVolume paths cannot be empty")
@@ -334,7 +384,7 @@ func GetOriginExports() ([]OriginExport, error) {
 				viper.Set("Origin.EnableDirectReads", capabilities.DirectReads)
 			}
 			for _, export := range tmpExports {
-				if err = validateExportPaths(export.StoragePrefix, export.FederationPrefix); err != nil {
+				if err = validateExport(export, storageType); err != nil {
 					return nil, err
 				}
 			}
@@ -441,11 +491,8 @@ from S3 service URL. In this configuration, objects can be accessed at /federati
 
 			// Validate each bucket name and federation prefix in the exports
 			for _, export := range tmpExports {
-				if err := validateFederationPrefix(export.FederationPrefix); err != nil {
-					return nil, errors.Wrapf(err, "invalid federation prefix for export %s", export.FederationPrefix)
-				}
-				if err := validateBucketName(export.S3Bucket); err != nil {
-					return nil, errors.Wrapf(err, "invalid bucket name for export %s", export.S3Bucket)
+				if err := validateExport(export, storageType); err != nil {
+					return nil, err
 				}
 			}
 			originExports = tmpExports
@@ -671,4 +718,5 @@ func CheckOriginSentinelLocations(exports []OriginExport) (ok bool, err error) {
 
 func ResetOriginExports() {
 	originExports = nil
+	resetOriginPathRewriter()
 }
@@ -51,6 +51,9 @@ var (
 	//go:embed resources/posix-origins/single-export-volume.yml
 	exportSingleVolumeConfig string
 
+	//go:embed resources/posix-origins/mixed-backend-exports.yml
+	mixedBackendExportsConfig string
+
 	//go:embed resources/s3-origins/env-var-mimic.yml
 	s3envVarMimicConfig string
 
@@ -269,6 +272,30 @@ func TestGetExports(t *testing.T) {
 		assert.True(t, exports[0].Capabilities.DirectReads, "expected direct reads")
 	})
 
+	t.Run("testMixedBackendExports", func(t *testing.T) {
+		defer viper.Reset()
+		defer ResetOriginExports()
+		exports := setup(t, mixedBackendExportsConfig)
+		require.Len(t, exports, 2, "expected 2 exports")
+
+		assert.Equal(t, "/posix/namespace", exports[0].FederationPrefix)
+		assert.Equal(t, server_structs.OriginStoragePosix, exports[0].GetStorageType())
+		assert.Equal(t, "/s3/namespace", exports[1].FederationPrefix)
+		assert.Equal(t, server_structs.OriginStorageS3, exports[1].GetStorageType())
+		assert.Equal(t, "my-bucket", exports[1].S3Bucket)
+	})
+
+	t.Run("testMixedBackendExportsRequirePresign", func(t *testing.T) {
+		defer viper.Reset()
+		defer ResetOriginExports()
+		viper.SetConfigType("yaml")
+		require.NoError(t, viper.ReadConfig(strings.NewReader(mixedBackendExportsConfig)))
+		viper.Set("Origin.S3PresignRedirects", false)
+		_, err := GetOriginExports()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidOriginConfig)
+	})
+
 	t.Run("testMultiExportValidS3", func(t *testing.T) {
 		defer viper.Reset()
 		defer ResetOriginExports()
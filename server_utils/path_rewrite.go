@@ -0,0 +1,107 @@
+/***************************************************************
+*
+* Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+*
+* Licensed under the Apache License, Version 2.0 (the "License"); you
+* may not use this file except in compliance with the License.  You may
+* obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*
+***************************************************************/
+
+package server_utils
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+type (
+	// A rule of Origin.PathRewrites, rewriting object paths matching the regular expression
+	// Pattern into Replacement, which may refer to the pattern's capture groups as $1 or ${name}
+	PathRewriteRule struct {
+		Pattern     string
+		Replacement string
+	}
+
+	// The compiled rules of Origin.PathRewrites
+	PathRewriter struct {
+		rules        []PathRewriteRule
+		compiledExpr []*regexp.Regexp
+	}
+)
+
+var (
+	originPathRewriter     *PathRewriter
+	originPathRewriterOnce sync.Once
+	originPathRewriterErr  error
+)
+
+// Compile the path rewrite rules, failing on the first rule with an invalid pattern
+func NewPathRewriter(rules []PathRewriteRule) (*PathRewriter, error) {
+	rewriter := &PathRewriter{
+		rules:        rules,
+		compiledExpr: make([]*regexp.Regexp, len(rules)),
+	}
+	for idx, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, errors.Wrapf(ErrInvalidOriginConfig, "path rewrite rule %d has an empty pattern", idx+1)
+		}
+		expr, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidOriginConfig, "path rewrite rule %d has an invalid pattern %q: %v", idx+1, rule.Pattern, err)
+		}
+		rewriter.compiledExpr[idx] = expr
+	}
+	return rewriter, nil
+}
+
+// Rewrite the path with the first rule whose pattern matches it. Paths matching no rule are
+// returned as is
+func (rewriter *PathRewriter) Rewrite(objectPath string) string {
+	if rewriter == nil {
+		return objectPath
+	}
+	for idx, expr := range rewriter.compiledExpr {
+		if expr.MatchString(objectPath) {
+			return expr.ReplaceAllString(objectPath, rewriter.rules[idx].Replacement)
+		}
+	}
+	return objectPath
+}
+
+// Whether the rewriter has no rules, i.e. passes every path through unchanged
+func (rewriter *PathRewriter) Empty() bool {
+	return rewriter == nil || len(rewriter.rules) == 0
+}
+
+// Get the origin's path rewriter from Origin.PathRewrites. The rules are compiled once, so
+// misconfigured rules are reported when the origin starts rather than per request
+func GetOriginPathRewriter() (*PathRewriter, error) {
+	originPathRewriterOnce.Do(func() {
+		rules := []PathRewriteRule{}
+		if err := viper.UnmarshalKey("Origin.PathRewrites", &rules); err != nil {
+			originPathRewriterErr = errors.Wrap(err, "failed to parse Origin.PathRewrites")
+			return
+		}
+		originPathRewriter, originPathRewriterErr = NewPathRewriter(rules)
+	})
+	return originPathRewriter, originPathRewriterErr
+}
+
+// Reset the origin's path rewriter, so it is read again from the configuration
+func resetOriginPathRewriter() {
+	originPathRewriterOnce = sync.Once{}
+	originPathRewriter = nil
+	originPathRewriterErr = nil
+}
@@ -0,0 +1,80 @@
+/***************************************************************
+*
+* Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+*
+* Licensed under the Apache License, Version 2.0 (the "License"); you
+* may not use this file except in compliance with the License.  You may
+* obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*
+***************************************************************/
+
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathRewriter(t *testing.T) {
+	t.Run("capture-group-substitution", func(t *testing.T) {
+		rewriter, err := NewPathRewriter([]PathRewriteRule{
+			{Pattern: `^/v1/(.*)$`, Replacement: "/$1"},
+			{Pattern: `^/runs/(?P<run>[0-9]+)/(?P<file>.*)$`, Replacement: "/data/run-${run}/${file}"},
+			{Pattern: `^/runs/`, Replacement: "/never-reached/"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/dir/object.txt", rewriter.Rewrite("/v1/dir/object.txt"))
+		assert.Equal(t, "/data/run-42/events.root", rewriter.Rewrite("/runs/42/events.root"))
+		// Only the first matching rule applies
+		assert.Equal(t, "/never-reached/latest", rewriter.Rewrite("/runs/latest"))
+	})
+
+	t.Run("default-pass-through", func(t *testing.T) {
+		rewriter, err := NewPathRewriter([]PathRewriteRule{{Pattern: `^/v1/(.*)$`, Replacement: "/$1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "/other/object.txt", rewriter.Rewrite("/other/object.txt"))
+		assert.False(t, rewriter.Empty())
+
+		noRules, err := NewPathRewriter(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/v1/object.txt", noRules.Rewrite("/v1/object.txt"))
+		assert.True(t, noRules.Empty())
+	})
+
+	t.Run("invalid-rules", func(t *testing.T) {
+		_, err := NewPathRewriter([]PathRewriteRule{{Pattern: `^/(unclosed`, Replacement: "/$1"}})
+		require.ErrorIs(t, err, ErrInvalidOriginConfig)
+		_, err = NewPathRewriter([]PathRewriteRule{{Replacement: "/foo"}})
+		require.ErrorIs(t, err, ErrInvalidOriginConfig)
+	})
+
+	t.Run("origin-config", func(t *testing.T) {
+		viper.Reset()
+		ResetOriginExports()
+		t.Cleanup(func() {
+			viper.Reset()
+			ResetOriginExports()
+		})
+
+		viper.Set("Origin.PathRewrites", []map[string]string{{"Pattern": `^/(.*)$`, "Replacement": "/prefix/$1"}})
+		rewriter, err := GetOriginPathRewriter()
+		require.NoError(t, err)
+		assert.Equal(t, "/prefix/object.txt", rewriter.Rewrite("/object.txt"))
+
+		ResetOriginExports()
+		viper.Set("Origin.PathRewrites", []map[string]string{{"Pattern": `*invalid`, "Replacement": "/"}})
+		_, err = GetOriginPathRewriter()
+		require.ErrorIs(t, err, ErrInvalidOriginConfig)
+	})
+}
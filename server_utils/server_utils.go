@@ -29,8 +29,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -41,6 +44,7 @@ import (
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
 )
 
 type (
@@ -228,6 +232,67 @@ func WaitUntilWorking(ctx context.Context, method, reqUrl, server string, expect
 	}
 }
 
+// Get the labels of Server.Labels the server advertises to the director, checking
+// they are valid so that a misconfiguration is reported at startup rather than as a
+// rejected advertisement
+func GetServerLabels() (map[string]string, error) {
+	labels := map[string]string{}
+	if err := param.Server_Labels.Unmarshal(&labels); err != nil {
+		return nil, errors.Wrap(err, "failed to parse Server.Labels")
+	}
+	if err := server_structs.ValidateServerLabels(labels); err != nil {
+		return nil, errors.Wrap(err, "invalid Server.Labels")
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// How long to wait on resolving each of Server.AlternateHostnames
+const alternateHostnameLookupTimeout = 5 * time.Second
+
+// Resolve a hostname; overridden in unit tests
+var lookupHost = net.DefaultResolver.LookupHost
+
+// Get the alternate data URLs the server advertises to the director: its data URL with the host
+// swapped for each of Server.AlternateHostnames, keeping the port. Hostnames that don't resolve are
+// left out with a warning, so that a DNS record being fixed shows up on the next advertisement
+func GetAlternateDataURLs(ctx context.Context, dataUrl string) ([]string, error) {
+	hostnames := param.Server_AlternateHostnames.GetStringSlice()
+	if len(hostnames) == 0 {
+		return nil, nil
+	}
+	primary, err := url.Parse(dataUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the data URL %s", dataUrl)
+	}
+
+	var altUrls []string
+	seen := map[string]bool{strings.ToLower(primary.Hostname()): true}
+	for _, hostname := range hostnames {
+		hostname = strings.ToLower(strings.TrimSpace(hostname))
+		if hostname == "" || seen[hostname] {
+			continue
+		}
+		seen[hostname] = true
+		lookupCtx, cancel := context.WithTimeout(ctx, alternateHostnameLookupTimeout)
+		_, err := lookupHost(lookupCtx, hostname)
+		cancel()
+		if err != nil {
+			log.Warningf("Skipping the alternate hostname %s in the advertisement as it doesn't resolve: %v", hostname, err)
+			continue
+		}
+		altUrl := *primary
+		altUrl.Host = hostname
+		if port := primary.Port(); port != "" {
+			altUrl.Host = net.JoinHostPort(hostname, port)
+		}
+		altUrls = append(altUrls, altUrl.String())
+	}
+	return altUrls, nil
+}
+
 // Launch a maintenance goroutine.
 // The maintenance routine will watch the directory `dirPath`, invoking `maintenanceFunc` whenever
 // an event occurs in the directory.  Note the behavior of directory watching differs across platforms;
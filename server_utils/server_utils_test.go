@@ -22,6 +22,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -158,3 +159,30 @@ func TestWaitUntilWorking(t *testing.T) {
 		assert.Equal(t, expectedErrorMsg, err.Error())
 	})
 }
+
+func TestGetAlternateDataURLs(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+		lookupHost = net.DefaultResolver.LookupHost
+	})
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		if host == "dangling.example.com" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+
+	viper.Reset()
+	altUrls, err := GetAlternateDataURLs(context.Background(), "https://cache.example.com:8443")
+	require.NoError(t, err)
+	assert.Nil(t, altUrls)
+
+	viper.Set("Server.AlternateHostnames", []string{"cache-alias.example.com", "dangling.example.com", "cache.example.com", "Cache-Alias.example.com", "internal.site.lan"})
+	altUrls, err = GetAlternateDataURLs(context.Background(), "https://cache.example.com:8443")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://cache-alias.example.com:8443", "https://internal.site.lan:8443"}, altUrls)
+
+	altUrls, err = GetAlternateDataURLs(context.Background(), "https://origin.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://cache-alias.example.com", "https://cache.example.com", "https://internal.site.lan"}, altUrls)
+}
@@ -0,0 +1,89 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package server_utils
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+type (
+	// A writer pacing its writes with token-bucket limiters, e.g. one for the connection
+	// and one shared by all of the server's connections
+	rateLimitedWriter struct {
+		ctx      context.Context
+		w        io.Writer
+		limiters []*rate.Limiter
+		chunk    int
+	}
+)
+
+// Create a token-bucket limiter allowing bytesPerSec bytes per second, or nil for no limit.
+// The burst is a tenth of a second's worth of data, so that a connection can't exceed
+// the rate for long before being slowed down
+func NewBandwidthLimiter(bytesPerSec int) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := bytesPerSec / 10
+	if burst < 1024 {
+		burst = min(1024, bytesPerSec)
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (written int, err error) {
+	for len(p) > 0 {
+		size := min(len(p), w.chunk)
+		for _, limiter := range w.limiters {
+			if err = limiter.WaitN(w.ctx, size); err != nil {
+				return
+			}
+		}
+		var n int
+		n, err = w.w.Write(p[:size])
+		written += n
+		if err != nil {
+			return
+		}
+		p = p[size:]
+	}
+	return
+}
+
+// Copy src to dst like io.Copy, pacing the copy so that it stays within the rate of each
+// of the limiters; nil limiters are ignored. Exceeding a limit blocks the copy rather than
+// failing it, until the context is canceled
+func CopyWithRateLimit(ctx context.Context, dst io.Writer, src io.Reader, limiters ...*rate.Limiter) (int64, error) {
+	writer := &rateLimitedWriter{ctx: ctx, w: dst, chunk: 32 * 1024}
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+		writer.limiters = append(writer.limiters, limiter)
+		// A write can't wait for more tokens than the bucket holds
+		writer.chunk = min(writer.chunk, limiter.Burst())
+	}
+	if len(writer.limiters) == 0 {
+		return io.Copy(dst, src)
+	}
+	// Hide dst's ReaderFrom, if any, from io.Copy so that every write goes through the limiters
+	return io.Copy(writer, src)
+}
@@ -0,0 +1,90 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package server_utils
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyWithRateLimit(t *testing.T) {
+	const rate = 128 * 1024
+	data := bytes.Repeat([]byte("x"), 64*1024)
+	// Copying takes at least the time to send what doesn't fit in the initial burst
+	minElapsed := time.Duration(float64(len(data)-rate/10) / rate * float64(time.Second))
+
+	t.Run("no-limit", func(t *testing.T) {
+		var dst bytes.Buffer
+		n, err := CopyWithRateLimit(context.Background(), &dst, bytes.NewReader(data), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), n)
+		assert.Equal(t, data, dst.Bytes())
+	})
+
+	t.Run("per-connection", func(t *testing.T) {
+		var dst bytes.Buffer
+		start := time.Now()
+		n, err := CopyWithRateLimit(context.Background(), &dst, bytes.NewReader(data), NewBandwidthLimiter(rate))
+		elapsed := time.Since(start)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), n)
+		assert.Equal(t, data, dst.Bytes())
+		assert.GreaterOrEqual(t, elapsed, minElapsed)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		// Two connections sharing the aggregate limit together take as long as one sending both halves
+		total := NewBandwidthLimiter(rate)
+		start := time.Now()
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var dst bytes.Buffer
+				n, err := CopyWithRateLimit(context.Background(), &dst, bytes.NewReader(data[:len(data)/2]), nil, total)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(data)/2), n)
+			}()
+		}
+		wg.Wait()
+		assert.GreaterOrEqual(t, time.Since(start), minElapsed)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var dst bytes.Buffer
+		_, err := CopyWithRateLimit(ctx, &dst, bytes.NewReader(data), NewBandwidthLimiter(rate))
+		assert.Error(t, err)
+	})
+}
+
+func TestNewBandwidthLimiter(t *testing.T) {
+	assert.Nil(t, NewBandwidthLimiter(0))
+	assert.Nil(t, NewBandwidthLimiter(-1))
+	assert.Equal(t, 100, NewBandwidthLimiter(100).Burst())
+	assert.Equal(t, 1024, NewBandwidthLimiter(2048).Burst())
+	assert.Equal(t, 1024*1024/10, NewBandwidthLimiter(1024*1024).Burst())
+}
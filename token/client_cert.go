@@ -0,0 +1,88 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package token
+
+import (
+	"crypto/x509"
+	"net/http"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+type (
+	// An entry of Server.ClientCertIdentities: the identity of the client certificates
+	// matching any of the names, and the resource scopes it's granted
+	ClientCertIdentity struct {
+		Identity string   `mapstructure:"Identity"`
+		Match    []string `mapstructure:"Match"`
+		Scopes   []string `mapstructure:"Scopes"`
+	}
+)
+
+// Get the names a client certificate may be matched by: the distinguished name of
+// its subject and its subject alternative names
+func clientCertNames(cert *x509.Certificate) []string {
+	names := []string{cert.Subject.String()}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// Get the entries of Server.ClientCertIdentities
+func GetClientCertIdentities() (identities []ClientCertIdentity, err error) {
+	if err = param.Server_ClientCertIdentities.Unmarshal(&identities); err != nil {
+		err = errors.Wrap(err, "failed to parse Server.ClientCertIdentities")
+	}
+	return
+}
+
+// Get the identity and resource scopes Server.ClientCertIdentities grants to the client
+// certificate of the request.  Only certificates the TLS handshake verified against
+// Server.ClientCertCAFile are considered; the identity is empty if the request has no
+// such certificate or it matches no entry, in which case the caller should fall back
+// to token authorization.
+//
+// This covers the requests served by the web server; XRootD maps the certificates of
+// the requests it serves to the same identities through its generated gridmap and authfile.
+func GetClientCertIdentity(r *http.Request) (identity string, scopes []token_scopes.ResourceScope, err error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return
+	}
+	identities, err := GetClientCertIdentities()
+	if err != nil {
+		return
+	}
+
+	names := clientCertNames(r.TLS.VerifiedChains[0][0])
+	for _, entry := range identities {
+		if slices.ContainsFunc(entry.Match, func(match string) bool { return slices.Contains(names, match) }) {
+			return entry.Identity, token_scopes.ParseResourceScopes(entry.Scopes), nil
+		}
+	}
+	return
+}
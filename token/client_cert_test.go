@@ -0,0 +1,89 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package token
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/test_utils"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+func TestGetClientCertIdentity(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("Server.ClientCertIdentities", []map[string]any{
+		{"Identity": "by-subject", "Match": []string{"CN=transfer,O=Example"}, "Scopes": []string{"storage.read:/example"}},
+		{"Identity": "by-san", "Match": []string{"mover.example.com"}, "Scopes": []string{"storage.read:/data", "storage.modify:/data"}},
+	})
+
+	ca := test_utils.GenerateTestCA(t, "Test CA")
+	requestWithCert := func(cert *test_utils.TestCert) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert.Cert, ca.Cert}}}
+		return req
+	}
+
+	t.Run("match-by-subject", func(t *testing.T) {
+		cert := ca.IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "transfer", Organization: []string{"Example"}}})
+		identity, scopes, err := GetClientCertIdentity(requestWithCert(cert))
+		require.NoError(t, err)
+		assert.Equal(t, "by-subject", identity)
+		assert.Equal(t, []token_scopes.ResourceScope{token_scopes.NewResourceScope(token_scopes.Storage_Read, "/example")}, scopes)
+	})
+
+	t.Run("match-by-san", func(t *testing.T) {
+		cert := ca.IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "mover"}, DNSNames: []string{"mover.example.com"}})
+		identity, scopes, err := GetClientCertIdentity(requestWithCert(cert))
+		require.NoError(t, err)
+		assert.Equal(t, "by-san", identity)
+		assert.Len(t, scopes, 2)
+	})
+
+	t.Run("unmapped-cert", func(t *testing.T) {
+		cert := ca.IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}})
+		identity, _, err := GetClientCertIdentity(requestWithCert(cert))
+		require.NoError(t, err)
+		assert.Empty(t, identity)
+	})
+
+	t.Run("unverified-cert", func(t *testing.T) {
+		// A certificate the handshake didn't verify doesn't count, even if it matches
+		cert := ca.IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "transfer", Organization: []string{"Example"}}})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert.Cert}}
+		identity, _, err := GetClientCertIdentity(req)
+		require.NoError(t, err)
+		assert.Empty(t, identity)
+	})
+
+	t.Run("no-tls", func(t *testing.T) {
+		identity, _, err := GetClientCertIdentity(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NoError(t, err)
+		assert.Empty(t, identity)
+	})
+}
@@ -0,0 +1,140 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package token
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+type (
+	// A token whose signature was verified, along with the key set that verified it
+	verifiedToken struct {
+		token       jwt.Token
+		keysetPrint string
+	}
+)
+
+var (
+	verifiedTokens      *ttlcache.Cache[string, verifiedToken]
+	verifiedTokensMutex sync.Mutex
+)
+
+// Get the cache of verified tokens, creating it on first use.  Returns nil
+// if the cache is disabled by Server.TokenCacheSize
+func getVerifiedTokenCache() *ttlcache.Cache[string, verifiedToken] {
+	verifiedTokensMutex.Lock()
+	defer verifiedTokensMutex.Unlock()
+	if verifiedTokens == nil {
+		size := param.Server_TokenCacheSize.GetInt()
+		if size <= 0 {
+			return nil
+		}
+		// A hit must not extend the entry's lifetime, or it could outlive the token's expiration
+		verifiedTokens = ttlcache.New[string, verifiedToken](
+			ttlcache.WithCapacity[string, verifiedToken](uint64(size)),
+			ttlcache.WithDisableTouchOnHit[string, verifiedToken](),
+		)
+	}
+	return verifiedTokens
+}
+
+// Drop every verified token; the next request for each will re-check the signature
+func ResetTokenCache() {
+	verifiedTokensMutex.Lock()
+	defer verifiedTokensMutex.Unlock()
+	if verifiedTokens != nil {
+		verifiedTokens.DeleteAll()
+	}
+	verifiedTokens = nil
+}
+
+// Compute a fingerprint of the keys in a key set so that a token verified by a key
+// that has since been rotated out is not served from the cache
+func keysetFingerprint(jwks jwk.Set) (string, error) {
+	hash := sha256.New()
+	for idx := 0; idx < jwks.Len(); idx++ {
+		key, ok := jwks.Key(idx)
+		if !ok {
+			continue
+		}
+		thumbprint, err := key.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to compute the thumbprint of a public key")
+		}
+		hash.Write(thumbprint)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// The cache is keyed by a hash of the token so the tokens themselves are not kept around
+func cacheKeyForToken(strToken string) string {
+	tokenHash := sha256.Sum256([]byte(strToken))
+	return hex.EncodeToString(tokenHash[:])
+}
+
+// Parse the token and verify its signature against the key set, reusing the
+// result of a previous verification of the same token by the same keys.
+//
+// Only the signature check is cached: the caller still validates the claims
+// (expiration, scopes, audience) of the returned token on every request.
+func parseWithCache(strToken string, jwks jwk.Set) (jwt.Token, error) {
+	cache := getVerifiedTokenCache()
+	if cache == nil {
+		return jwt.Parse([]byte(strToken), jwt.WithKeySet(jwks))
+	}
+
+	keysetPrint, err := keysetFingerprint(jwks)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := cacheKeyForToken(strToken)
+
+	now := time.Now()
+	if item := cache.Get(cacheKey); item != nil {
+		entry := item.Value()
+		if entry.keysetPrint == keysetPrint && (entry.token.Expiration().IsZero() || now.Before(entry.token.Expiration())) {
+			return entry.token, nil
+		}
+		cache.Delete(cacheKey)
+	}
+
+	parsed, err := jwt.Parse([]byte(strToken), jwt.WithKeySet(jwks))
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := param.Server_TokenCacheTTL.GetDuration()
+	if exp := parsed.Expiration(); !exp.IsZero() && exp.Sub(now) < ttl {
+		ttl = exp.Sub(now)
+	}
+	if ttl > 0 {
+		cache.Set(cacheKey, verifiedToken{token: parsed, keysetPrint: keysetPrint}, ttl)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,178 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Create a signing key and the public key set that verifies it
+func newTestKeyset(t testing.TB) (jwk.Key, jwk.Set) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privKey, err := jwk.FromRaw(ecKey)
+	require.NoError(t, err)
+	require.NoError(t, jwk.AssignKeyID(privKey))
+	require.NoError(t, privKey.Set(jwk.AlgorithmKey, jwa.ES256))
+	pubKey, err := jwk.PublicKeyOf(privKey)
+	require.NoError(t, err)
+	jwks := jwk.NewSet()
+	require.NoError(t, jwks.AddKey(pubKey))
+	return privKey, jwks
+}
+
+func signTestToken(t testing.TB, key jwk.Key, expiration time.Time) string {
+	tok, err := jwt.NewBuilder().
+		Issuer("https://issuer.example.com").
+		Subject("test").
+		IssuedAt(time.Now().Add(-time.Minute)).
+		Expiration(expiration).
+		Build()
+	require.NoError(t, err)
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, key))
+	require.NoError(t, err)
+	return string(signed)
+}
+
+func setupTokenCache(t testing.TB, size int) {
+	viper.Set("Server.TokenCacheSize", size)
+	viper.Set("Server.TokenCacheTTL", 5*time.Minute)
+	ResetTokenCache()
+	t.Cleanup(func() {
+		viper.Reset()
+		ResetTokenCache()
+	})
+}
+
+func TestParseWithCache(t *testing.T) {
+	t.Run("verified-token-is-cached", func(t *testing.T) {
+		setupTokenCache(t, 10)
+		key, jwks := newTestKeyset(t)
+		tok := signTestToken(t, key, time.Now().Add(time.Hour))
+
+		parsed, err := parseWithCache(tok, jwks)
+		require.NoError(t, err)
+		assert.Equal(t, "test", parsed.Subject())
+		assert.Equal(t, 1, getVerifiedTokenCache().Len())
+
+		cached, err := parseWithCache(tok, jwks)
+		require.NoError(t, err)
+		assert.Same(t, parsed, cached)
+	})
+
+	t.Run("ttl-capped-by-expiration", func(t *testing.T) {
+		setupTokenCache(t, 10)
+		key, jwks := newTestKeyset(t)
+		exp := time.Now().Add(time.Minute)
+		tok := signTestToken(t, key, exp)
+
+		_, err := parseWithCache(tok, jwks)
+		require.NoError(t, err)
+		for _, item := range getVerifiedTokenCache().Items() {
+			assert.False(t, item.ExpiresAt().After(exp))
+		}
+	})
+
+	t.Run("expired-token-not-served", func(t *testing.T) {
+		setupTokenCache(t, 10)
+		key, jwks := newTestKeyset(t)
+		tok := signTestToken(t, key, time.Now().Add(-time.Second))
+
+		// Plant the expired token in the cache as if it were verified while still valid
+		parsed, err := jwt.Parse([]byte(tok), jwt.WithKeySet(jwks), jwt.WithValidate(false))
+		require.NoError(t, err)
+		keysetPrint, err := keysetFingerprint(jwks)
+		require.NoError(t, err)
+		cache := getVerifiedTokenCache()
+		cache.Set(cacheKeyForToken(tok), verifiedToken{token: parsed, keysetPrint: keysetPrint}, time.Hour)
+
+		_, err = parseWithCache(tok, jwks)
+		assert.Error(t, err)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("key-rotation-invalidates", func(t *testing.T) {
+		setupTokenCache(t, 10)
+		key, jwks := newTestKeyset(t)
+		tok := signTestToken(t, key, time.Now().Add(time.Hour))
+
+		_, err := parseWithCache(tok, jwks)
+		require.NoError(t, err)
+
+		_, rotated := newTestKeyset(t)
+		_, err = parseWithCache(tok, rotated)
+		assert.Error(t, err)
+		assert.Equal(t, 0, getVerifiedTokenCache().Len())
+	})
+
+	t.Run("least-recently-used-evicted", func(t *testing.T) {
+		setupTokenCache(t, 2)
+		key, jwks := newTestKeyset(t)
+		first := signTestToken(t, key, time.Now().Add(time.Hour))
+		second := signTestToken(t, key, time.Now().Add(2*time.Hour))
+		third := signTestToken(t, key, time.Now().Add(3*time.Hour))
+
+		for _, tok := range []string{first, second, first, third} {
+			_, err := parseWithCache(tok, jwks)
+			require.NoError(t, err)
+		}
+		cache := getVerifiedTokenCache()
+		assert.Equal(t, 2, cache.Len())
+		assert.True(t, cache.Has(cacheKeyForToken(first)))
+		assert.False(t, cache.Has(cacheKeyForToken(second)))
+		assert.True(t, cache.Has(cacheKeyForToken(third)))
+	})
+
+	t.Run("disabled-cache", func(t *testing.T) {
+		setupTokenCache(t, 0)
+		key, jwks := newTestKeyset(t)
+		tok := signTestToken(t, key, time.Now().Add(time.Hour))
+
+		_, err := parseWithCache(tok, jwks)
+		require.NoError(t, err)
+		assert.Nil(t, getVerifiedTokenCache())
+	})
+}
+
+func benchmarkParse(b *testing.B, cacheSize int) {
+	setupTokenCache(b, cacheSize)
+	key, jwks := newTestKeyset(b)
+	tok := signTestToken(b, key, time.Now().Add(time.Hour))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseWithCache(tok, jwks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseUncached(b *testing.B) { benchmarkParse(b, 0) }
+
+func BenchmarkParseCached(b *testing.B) { benchmarkParse(b, 10) }
@@ -25,6 +25,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -47,10 +48,14 @@ type (
 		Issuers   []TokenIssuer
 		Scopes    []token_scopes.TokenScope
 		AllScopes bool
+		// Audiences accepted on top of the server's own, e.g. a fixed audience shared by the tokens
+		// the director sends to every server
+		Audiences []string
 	}
 	AuthChecker interface {
 		checkFederationIssuer(ctx *gin.Context, token string, expectedScopes []token_scopes.TokenScope, allScopes bool) error
 		checkLocalIssuer(ctx *gin.Context, token string, expectedScopes []token_scopes.TokenScope, allScopes bool) error
+		checkAudience(token string, extraAudiences []string) error
 	}
 	AuthCheckImpl struct{}
 )
@@ -66,6 +71,12 @@ const (
 	LocalIssuer      TokenIssuer = "LocalIssuer"
 )
 
+// Values of Server.TokenAudienceValidation
+const (
+	AudienceValidationPermissive = "permissive" // Log tokens with a mismatched or missing audience, but accept them
+	AudienceValidationStrict     = "strict"     // Reject tokens with a mismatched or missing audience
+)
+
 var (
 	federationJWK *jwk.Cache
 	authChecker   AuthChecker
@@ -123,7 +134,7 @@ func (a AuthCheckImpl) checkFederationIssuer(c *gin.Context, strToken string, ex
 		return errors.Wrap(err, "Failed to get federation's public JWKS")
 	}
 
-	parsed, err := jwt.Parse([]byte(strToken), jwt.WithKeySet(jwks))
+	parsed, err := parseWithCache(strToken, jwks)
 
 	if err != nil {
 		return errors.Wrap(err, "Failed to verify JWT by federation's key")
@@ -161,7 +172,7 @@ func (a AuthCheckImpl) checkLocalIssuer(c *gin.Context, strToken string, expecte
 		return errors.Wrap(err, "Failed to load issuer server's public key")
 	}
 
-	parsed, err := jwt.Parse([]byte(strToken), jwt.WithKeySet(jwks))
+	parsed, err := parseWithCache(strToken, jwks)
 
 	if err != nil {
 		return errors.Wrap(err, "Failed to verify JWT by issuer's key")
@@ -176,9 +187,66 @@ func (a AuthCheckImpl) checkLocalIssuer(c *gin.Context, strToken string, expecte
 	return nil
 }
 
+// Get the audiences a token presented to this server may carry: the server's own URLs,
+// Server.TokenAudiences, the extra audiences of the endpoint, and the "any" audiences
+func getAcceptedAudiences(extraAudiences []string) []string {
+	candidates := []string{
+		param.Server_ExternalWebUrl.GetString(),
+		param.Origin_Url.GetString(),
+		config.GetServerAudience(),
+		param.Cache_Url.GetString(),
+		wlcgAny,
+		scitokensAny,
+	}
+	candidates = append(candidates, param.Server_TokenAudiences.GetStringSlice()...)
+	candidates = append(candidates, extraAudiences...)
+	audiences := make([]string, 0, len(candidates))
+	for _, aud := range candidates {
+		if aud != "" {
+			audiences = append(audiences, strings.TrimSuffix(aud, "/"))
+		}
+	}
+	return audiences
+}
+
+// Check that the token, whose signature and scopes are already verified, was issued for this server.
+// Depending on Server.TokenAudienceValidation, a mismatched or missing audience is either
+// rejected or only logged
+func (a AuthCheckImpl) checkAudience(strToken string, extraAudiences []string) error {
+	tok, err := jwt.Parse([]byte(strToken), jwt.WithVerify(false), jwt.WithValidate(false))
+	if err != nil {
+		return errors.Wrap(err, "Invalid JWT")
+	}
+	accepted := getAcceptedAudiences(extraAudiences)
+	for _, aud := range tok.Audience() {
+		if slices.Contains(accepted, strings.TrimSuffix(aud, "/")) {
+			return nil
+		}
+	}
+	var mismatch string
+	if len(tok.Audience()) == 0 {
+		mismatch = "the token has no audience"
+	} else {
+		mismatch = fmt.Sprintf("the token audience %v does not match this server; expecting one of %v", tok.Audience(), accepted)
+	}
+
+	switch mode := param.Server_TokenAudienceValidation.GetString(); mode {
+	case AudienceValidationPermissive, "":
+		log.Warningf("Accepting the token of subject %q issued by %s although %s, as Server.TokenAudienceValidation is %s",
+			tok.Subject(), tok.Issuer(), mismatch, AudienceValidationPermissive)
+		return nil
+	case AudienceValidationStrict:
+		log.Warningf("Rejecting the token of subject %q issued by %s: %s", tok.Subject(), tok.Issuer(), mismatch)
+		return errors.New(mismatch)
+	default:
+		return errors.Errorf("invalid Server.TokenAudienceValidation %q; valid values are %s and %s", mode, AudienceValidationPermissive, AudienceValidationStrict)
+	}
+}
+
 // Check token authentication with token obtained from authOption.Sources, found the first
 // token available and proceed to check against a list of authOption.Issuers with
-// authOption.Scopes, return true and set "User" context to the issuer if any of the issuer check succeed
+// authOption.Scopes, return true and set "User" context to the issuer if any of the issuer check succeed.
+// The token's audience is then checked against the server, see AuthCheckImpl.checkAudience
 //
 // Scope check will pass if your token has ANY of the scopes in authOption.Scopes
 func Verify(ctx *gin.Context, authOption AuthOption) (status int, verified bool, err error) {
@@ -234,12 +302,16 @@ func Verify(ctx *gin.Context, authOption AuthOption) (status int, verified bool,
 		case FederationIssuer:
 			if err := authChecker.checkFederationIssuer(ctx, token, authOption.Scopes, authOption.AllScopes); err != nil {
 				errMsg += fmt.Sprintln("Cannot verify token with federation issuer: ", err)
+			} else if err := authChecker.checkAudience(token, authOption.Audiences); err != nil {
+				errMsg += fmt.Sprintln("Cannot verify token audience: ", err)
 			} else {
 				return http.StatusOK, true, nil
 			}
 		case LocalIssuer:
 			if err := authChecker.checkLocalIssuer(ctx, token, authOption.Scopes, authOption.AllScopes); err != nil {
 				errMsg += fmt.Sprintln("Cannot verify token with server issuer: ", err)
+			} else if err := authChecker.checkAudience(token, authOption.Audiences); err != nil {
+				errMsg += fmt.Sprintln("Cannot verify token audience: ", err)
 			} else {
 				return http.StatusOK, true, nil
 			}
@@ -20,13 +20,21 @@ package token
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -37,6 +45,7 @@ import (
 type MockAuthChecker struct {
 	FederationCheckFunc func(ctx *gin.Context, token string, expectedScopes []token_scopes.TokenScope, allScope bool) error
 	IssuerCheckFunc     func(ctx *gin.Context, token string, expectedScopes []token_scopes.TokenScope, allScope bool) error
+	AudienceCheckFunc   func(token string, extraAudiences []string) error
 }
 
 func (m *MockAuthChecker) checkFederationIssuer(ctx *gin.Context, token string, expectedScopes []token_scopes.TokenScope, allScope bool) error {
@@ -47,6 +56,13 @@ func (m *MockAuthChecker) checkLocalIssuer(ctx *gin.Context, token string, expec
 	return m.IssuerCheckFunc(ctx, token, expectedScopes, allScope)
 }
 
+func (m *MockAuthChecker) checkAudience(token string, extraAudiences []string) error {
+	if m.AudienceCheckFunc == nil {
+		return nil
+	}
+	return m.AudienceCheckFunc(token, extraAudiences)
+}
+
 // Helper function to create a gin context with different token sources
 func createContextWithToken(cookieToken, headerToken, queryToken string) *gin.Context {
 	r := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -273,6 +289,25 @@ func TestVerify(t *testing.T) {
 			expectedResult: false,
 			expectedStatus: 403,
 		},
+		{
+			name: "valid-token-with-mismatched-audience",
+			authOption: AuthOption{
+				Sources:   []TokenSource{Cookie},
+				Issuers:   []TokenIssuer{FederationIssuer, LocalIssuer},
+				Audiences: []string{"extra-audience"},
+			},
+			setupMock: func() {
+				mock.AudienceCheckFunc = func(token string, extraAudiences []string) error {
+					assert.Equal(t, []string{"extra-audience"}, extraAudiences)
+					return errors.New("the token audience does not match this server")
+				}
+			},
+			tokenSetup: func() *gin.Context {
+				return createContextWithToken("for-issuer", "", "")
+			},
+			expectedResult: false,
+			expectedStatus: 403,
+		},
 	}
 
 	// Batch-run the test cases
@@ -298,6 +333,69 @@ func TestVerify(t *testing.T) {
 
 }
 
+func TestCheckAudience(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("Server.ExternalWebUrl", "https://origin.com:8444")
+	viper.Set("Origin.Url", "https://origin.com:8443")
+	viper.Set("Server.TokenAudiences", []string{"https://lb.origin.com"})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	makeToken := func(audiences ...string) string {
+		builder := jwt.NewBuilder().Issuer("https://issuer.com").Subject("test-subject")
+		if len(audiences) > 0 {
+			builder = builder.Audience(audiences)
+		}
+		tok, err := builder.Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, key))
+		require.NoError(t, err)
+		return string(signed)
+	}
+	checker := AuthCheckImpl{}
+	hook := test.NewGlobal()
+
+	t.Run("matching-audience", func(t *testing.T) {
+		viper.Set("Server.TokenAudienceValidation", AudienceValidationStrict)
+		for _, aud := range []string{"https://origin.com:8444", "https://origin.com:8444/", "https://origin.com:8443", "https://lb.origin.com", wlcgAny, scitokensAny} {
+			assert.NoError(t, checker.checkAudience(makeToken("someone-else", aud), nil), aud)
+		}
+		assert.NoError(t, checker.checkAudience(makeToken("prometheus"), []string{"prometheus"}))
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		viper.Set("Server.TokenAudienceValidation", AudienceValidationStrict)
+		err := checker.checkAudience(makeToken("https://other-origin.com:8444"), nil)
+		assert.ErrorContains(t, err, "does not match this server")
+		err = checker.checkAudience(makeToken(), nil)
+		assert.ErrorContains(t, err, "no audience")
+	})
+
+	t.Run("permissive", func(t *testing.T) {
+		viper.Set("Server.TokenAudienceValidation", AudienceValidationPermissive)
+		// The warnings are only recorded if the global level lets them through
+		oldLevel := log.GetLevel()
+		log.SetLevel(log.WarnLevel)
+		t.Cleanup(func() { log.SetLevel(oldLevel) })
+		hook.Reset()
+		assert.NoError(t, checker.checkAudience(makeToken("https://other-origin.com:8444"), nil))
+		require.NotNil(t, hook.LastEntry())
+		assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "does not match this server")
+
+		hook.Reset()
+		assert.NoError(t, checker.checkAudience(makeToken(), nil))
+		require.NotNil(t, hook.LastEntry())
+		assert.Contains(t, hook.LastEntry().Message, "no audience")
+	})
+
+	t.Run("invalid-mode", func(t *testing.T) {
+		viper.Set("Server.TokenAudienceValidation", "lenient")
+		assert.ErrorContains(t, checker.checkAudience(makeToken("https://other-origin.com:8444"), nil), "Server.TokenAudienceValidation")
+	})
+}
+
 func TestGetAuthzEscaped(t *testing.T) {
 	// Test passing a token via header with no bearer prefix
 	req, err := http.NewRequest(http.MethodPost, "http://fake-server.com", bytes.NewBuffer([]byte("a body")))
@@ -34,12 +34,21 @@ type (
 	// A resourced scope is a scope whose privileges
 	// are narrowed to a specific resource.  If there's
 	// the authorization for foo, then the ResourceScope of
-	// foo:/bar also contains foo:/bar/baz.
+	// foo:/bar also contains foo:/bar/baz.  A trailing "/*"
+	// grants everything in the directory, so foo:/bar/* is the
+	// same as foo:/bar.
 	ResourceScope struct {
 		Authorization TokenScope
 		Resource      string
 	}
 
+	// Matches the resource scopes of a token against the paths the token is used for, so that
+	// every server and client authorizing with the token agrees on what it grants. Scope resources
+	// are relative to BasePath, the base path of the token issuer
+	ScopeMatcher struct {
+		BasePath string
+	}
+
 	Scope interface {
 		TokenScope | ResourceScope
 
@@ -48,9 +57,14 @@ type (
 )
 
 func NewResourceScope(authz TokenScope, resource string) ResourceScope {
+	resource = path.Clean("/" + resource)
+	// Granting everything in a directory is the same as granting the directory
+	if strings.HasSuffix(resource, "/*") {
+		resource = path.Dir(resource)
+	}
 	return ResourceScope{
 		Authorization: authz,
-		Resource:      path.Clean("/" + resource),
+		Resource:      resource,
 	}
 }
 
@@ -77,6 +91,44 @@ func (rc ResourceScope) Contains(other ResourceScope) bool {
 	return false
 }
 
+// Resolve a token scope against the base path of the issuer
+func (m ScopeMatcher) resolve(scope ResourceScope) ResourceScope {
+	return NewResourceScope(scope.Authorization, path.Join(m.BasePath, scope.Resource))
+}
+
+// Check if any of the resource scopes grants the action on the resource at resourcePath
+func (m ScopeMatcher) Authorized(scopes []ResourceScope, action TokenScope, resourcePath string) bool {
+	required := NewResourceScope(action, resourcePath)
+	for _, scope := range scopes {
+		if m.resolve(scope).Contains(required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the resource scopes authorize listing the entry at entryPath: the entry must be within
+// a granted storage.read scope or, for directories, lead to one so the authorized subtree can be reached
+func (m ScopeMatcher) ListingAuthorized(scopes []ResourceScope, entryPath string, isDir bool) bool {
+	entry := NewResourceScope(Storage_Read, entryPath)
+	for _, scope := range scopes {
+		if scope.Authorization != Storage_Read {
+			continue
+		}
+		granted := m.resolve(scope)
+		if granted.Contains(entry) || (isDir && entry.Contains(granted)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the resource scopes authorize listing the entry at entryPath, for scope resources
+// relative to basePath. See ScopeMatcher.ListingAuthorized
+func ListingEntryAuthorized(scopes []ResourceScope, basePath string, entryPath string, isDir bool) bool {
+	return ScopeMatcher{BasePath: basePath}.ListingAuthorized(scopes, entryPath, isDir)
+}
+
 // Get a string representation of a list of scopes, which can then be passed
 // to the Claim builder of JWT constructor
 func GetScopeString[Scopes ~[]Sc, Sc Scope](scopes Scopes) (scopeString string) {
@@ -101,7 +153,30 @@ func ParseResourceScopeString(tok jwt.Token) (scopes []ResourceScope) {
 	if !ok {
 		return
 	}
-	for _, scope := range strings.Split(scopeString, " ") {
+	return ParseResourceScopes(strings.Split(scopeString, " "))
+}
+
+// Get the storage.read scopes of a serialized token without verifying it, for narrowing down what's
+// shown to the token's holder.  Returns none if the token isn't a JWT or grants no reads, as with an
+// opaque token, which only the server it's meant for can interpret
+func ParseReadScopes(token string) (scopes []ResourceScope) {
+	scopes = make([]ResourceScope, 0)
+	tok, err := jwt.ParseString(token, jwt.WithVerify(false), jwt.WithValidate(false))
+	if err != nil {
+		return
+	}
+	for _, scope := range ParseResourceScopeString(tok) {
+		if scope.Authorization == Storage_Read {
+			scopes = append(scopes, scope)
+		}
+	}
+	return
+}
+
+// Get a list of resource-style scopes from scope strings such as "storage.read:/foo"
+func ParseResourceScopes(scopeStrings []string) (scopes []ResourceScope) {
+	scopes = make([]ResourceScope, 0)
+	for _, scope := range scopeStrings {
 		if scope == "" {
 			continue
 		}
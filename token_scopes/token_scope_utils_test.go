@@ -110,6 +110,9 @@ func TestResourceScopes(t *testing.T) {
 		{"noPath", NewResourceScope(Storage_Create, "/foo"), NewResourceScope(Storage_Create, "/foobar"), false},
 		{"sameDeep", NewResourceScope(Storage_Create, "/foo/bar"), NewResourceScope(Storage_Create, "/foo/bar"), true},
 		{"sameDeep", NewResourceScope(Storage_Create, "/foo/bar"), NewResourceScope(Storage_Create, "/foo/barbaz"), false},
+		{"dirWildcard", NewResourceScope(Storage_Create, "/foo/*"), NewResourceScope(Storage_Create, "/foo/bar"), true},
+		{"dirWildcardSelf", NewResourceScope(Storage_Create, "/foo/*"), NewResourceScope(Storage_Create, "/foo"), true},
+		{"dirWildcardSibling", NewResourceScope(Storage_Create, "/foo/*"), NewResourceScope(Storage_Create, "/foobar"), false},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +122,64 @@ func TestResourceScopes(t *testing.T) {
 	}
 }
 
+func TestListingEntryAuthorized(t *testing.T) {
+	narrow := []ResourceScope{NewResourceScope(Storage_Read, "/public"), NewResourceScope(Storage_Create, "/")}
+	broad := []ResourceScope{NewResourceScope(Storage_Read, "/")}
+	tests := []struct {
+		name     string
+		scopes   []ResourceScope
+		entry    string
+		isDir    bool
+		expected bool
+	}{
+		{"narrowWithin", narrow, "/ns/public/file", false, true},
+		{"narrowScopeRoot", narrow, "/ns/public", true, true},
+		{"narrowOutside", narrow, "/ns/private", true, false},
+		{"narrowPrefixSibling", narrow, "/ns/publicity", false, false},
+		{"narrowAncestorDir", narrow, "/ns", true, true},
+		{"narrowAncestorFile", narrow, "/ns", false, false},
+		{"createOnly", []ResourceScope{NewResourceScope(Storage_Create, "/")}, "/ns/public/file", false, false},
+		{"broad", broad, "/ns/private/file", false, true},
+		{"otherNamespace", broad, "/other/file", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ListingEntryAuthorized(tt.scopes, "/ns", tt.entry, tt.isDir))
+		})
+	}
+}
+
+func TestScopeMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    string
+		action   TokenScope
+		resource string
+		expected bool
+	}{
+		{"exact", "/foo/bar.txt", Storage_Read, "/ns/foo/bar.txt", true},
+		{"exactSibling", "/foo/bar.txt", Storage_Read, "/ns/foo/baz.txt", false},
+		{"directory", "/foo", Storage_Read, "/ns/foo/bar/baz.txt", true},
+		{"deep", "/foo/bar/baz", Storage_Read, "/ns/foo/bar/baz/qux.txt", true},
+		{"wrongAction", "/foo", Storage_Create, "/ns/foo/bar.txt", false},
+		{"rootWildcard", "/*", Storage_Read, "/ns/foo/bar.txt", true},
+		{"rootWildcardOtherNamespace", "/*", Storage_Read, "/other/foo", false},
+		{"wildcardDepth1", "/foo/*", Storage_Read, "/ns/foo/bar.txt", true},
+		{"wildcardDepth2", "/foo/bar/*", Storage_Read, "/ns/foo/bar/baz/qux.txt", true},
+		{"wildcardDepth2Outside", "/foo/bar/*", Storage_Read, "/ns/foo/baz.txt", false},
+		{"noPrefixWildcard", "/foo/run*", Storage_Read, "/ns/foo/run42/out.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := ScopeMatcher{BasePath: "/ns"}
+			scopes := []ResourceScope{NewResourceScope(Storage_Read, tt.scope)}
+			assert.Equal(t, tt.expected, matcher.Authorized(scopes, tt.action, tt.resource))
+		})
+	}
+}
+
 func TestParseResources(t *testing.T) {
 	tok := jwt.New()
 
@@ -134,3 +195,14 @@ func TestParseResources(t *testing.T) {
 	require.NoError(t, tok.Set("scope", "storage.create:/foo"))
 	assert.Equal(t, []ResourceScope{{Authorization: Storage_Create, Resource: "/foo"}}, ParseResourceScopeString(tok))
 }
+
+func TestParseReadScopes(t *testing.T) {
+	tok, err := jwt.NewBuilder().Claim("scope", "storage.read:/foo storage.modify:/bar storage.read:/baz").Build()
+	require.NoError(t, err)
+	signed, err := jwt.Sign(tok, jwt.WithInsecureNoSignature())
+	require.NoError(t, err)
+	assert.Equal(t, []ResourceScope{{Authorization: Storage_Read, Resource: "/foo"}, {Authorization: Storage_Read, Resource: "/baz"}}, ParseReadScopes(string(signed)))
+
+	assert.Empty(t, ParseReadScopes("opaque-token"))
+	assert.Empty(t, ParseReadScopes(""))
+}
@@ -29,9 +29,13 @@ type TokenScope string
 const (
 	Pelican_Advertise TokenScope = "pelican.advertise"
 	Pelican_DirectorTestReport TokenScope = "pelican.director_test_report"
+	Pelican_AdvertiseRefresh TokenScope = "pelican.advertise_refresh"
+	Pelican_NamespaceStats TokenScope = "pelican.namespace_stats"
 	Pelican_DirectorServiceDiscovery TokenScope = "pelican.director_service_discovery"
 	Pelican_NamespaceDelete TokenScope = "pelican.namespace_delete"
+	Pelican_NamespaceTransfer TokenScope = "pelican.namespace_transfer"
 	WebUi_Access TokenScope = "web_ui.access"
+	WebUi_Admin TokenScope = "web_ui.admin"
 	Registry_EditRegistration TokenScope = "registry.edit_registration"
 	Monitoring_Scrape TokenScope = "monitoring.scrape"
 	Monitoring_Query TokenScope = "monitoring.query"
@@ -39,6 +43,7 @@ const (
 	Broker_Retrieve TokenScope = "broker.retrieve"
 	Broker_Callback TokenScope = "broker.callback"
 	Localcache_Purge TokenScope = "localcache.purge"
+	Cache_Prefetch TokenScope = "cache.prefetch"
 
 	// Storage Scopes
 	Storage_Read TokenScope = "storage.read"
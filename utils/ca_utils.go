@@ -37,19 +37,21 @@ import (
 	"github.com/pelicanplatform/pelican/param"
 )
 
-// Write out all the trusted CAs as a CA bundle on disk.  This is useful
-// for components that do not use go's trusted CA store
-func WriteCABundle(filename string) (int, error) {
+// Write out all the trusted CAs as a CA bundle on disk, along with the CAs of
+// any extraCAFiles.  This is useful for components that do not use go's trusted CA store
+func WriteCABundle(filename string, extraCAFiles ...string) (int, error) {
 	roots, err := loadSystemRoots()
 	if err != nil {
 		return -1, errors.Wrap(err, "Unable to write CA bundle due to failure when loading system trust roots")
 	}
 
 	// Append in any custom CAs we might have
-	caFile := param.Server_TLSCACertificateFile.GetString()
-	pemContents, err := os.ReadFile(caFile)
-	if err == nil {
-		roots = append(roots, getCertsFromPEM(pemContents)...)
+	caFiles := append([]string{param.Server_TLSCACertificateFile.GetString()}, extraCAFiles...)
+	for _, caFile := range caFiles {
+		pemContents, err := os.ReadFile(caFile)
+		if err == nil {
+			roots = append(roots, getCertsFromPEM(pemContents)...)
+		}
 	}
 
 	if len(roots) == 0 {
@@ -86,8 +88,8 @@ func WriteCABundle(filename string) (int, error) {
 //
 // If we're on a platform (Mac, Windows) that does not provide a CA bundle, we return
 // a count of 0 and do not launch the go routine.
-func LaunchPeriodicWriteCABundle(ctx context.Context, filename string, sleepTime time.Duration) (count int, err error) {
-	count, err = WriteCABundle(filename)
+func LaunchPeriodicWriteCABundle(ctx context.Context, filename string, sleepTime time.Duration, extraCAFiles ...string) (count int, err error) {
+	count, err = WriteCABundle(filename, extraCAFiles...)
 	if err != nil || count == 0 {
 		return
 	}
@@ -101,7 +103,7 @@ func LaunchPeriodicWriteCABundle(ctx context.Context, filename string, sleepTime
 		for {
 			select {
 			case <-ticker.C:
-				_, err := WriteCABundle(filename)
+				_, err := WriteCABundle(filename, extraCAFiles...)
 				if err != nil {
 					log.Warningln("Failure during periodic CA bundle update:", err)
 				}
@@ -27,7 +27,9 @@ import (
 	"mime"
 	"net/http"
 	"net/netip"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lestrrat-go/jwx/v2/jwk"
@@ -141,34 +143,102 @@ func HasContentType(r *http.Response, mimetype string) bool {
 }
 
 func GetJwks(ctx context.Context, location string) (jwk.Set, error) {
+	res, err := GetJwksConditional(ctx, location, "")
+	if err != nil {
+		return nil, err
+	}
+	return res.Keys, nil
+}
+
+// An error for an HTTP response with an unexpected status code, so callers can tell a server
+// that responded apart from one that couldn't be reached
+type StatusCodeError struct {
+	StatusCode int
+	Msg        string
+}
+
+func (e *StatusCodeError) Error() string {
+	return e.Msg
+}
+
+// The result of a conditional JWKS fetch by GetJwksConditional
+type JwksFetchResult struct {
+	Keys        jwk.Set       // The fetched key set; nil if NotModified is true
+	ETag        string        // The ETag the server returned with the key set, if any
+	MaxAge      time.Duration // The max-age directive of the Cache-Control header
+	HasMaxAge   bool          // Whether the response had a max-age (or no-cache) directive
+	NotModified bool          // Whether the server responded with 304 Not Modified
+}
+
+// GetJwksConditional fetches the JWKS at location. If etag is not empty, it's sent
+// in the If-None-Match header so that the server can respond with 304 Not Modified
+// when the key set hasn't changed, in which case the returned Keys is nil and the
+// caller should keep using its cached copy.
+func GetJwksConditional(ctx context.Context, location string, etag string) (result JwksFetchResult, err error) {
 	if location == "" {
-		return nil, errors.New("jwks location is empty")
+		err = errors.New("jwks location is empty")
+		return
 	}
 	client := http.Client{Transport: config.GetTransport()}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
 	if err != nil {
-		return nil, err
+		return
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return
 	}
+	defer res.Body.Close()
 	bodyByte, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return
+	}
+
+	result.ETag = res.Header.Get("ETag")
+	result.MaxAge, result.HasMaxAge = ParseCacheControlMaxAge(res.Header.Get("Cache-Control"))
+
+	if res.StatusCode == http.StatusNotModified {
+		if etag == "" {
+			err = errors.New("request failed with response code 304 without a conditional request")
+			return
+		}
+		result.NotModified = true
+		return
 	}
 	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("request failed with response code %d and response body: %s", res.StatusCode, string(bodyByte))
+		err = &StatusCodeError{StatusCode: res.StatusCode, Msg: fmt.Sprintf("request failed with response code %d and response body: %s", res.StatusCode, string(bodyByte))}
+		return
 	}
 	if len(bodyByte) == 0 {
-		return nil, fmt.Errorf("request failed with response returns 200 but with empty response body")
+		err = fmt.Errorf("request failed with response returns 200 but with empty response body")
+		return
 	}
-	key, err := jwk.Parse(bodyByte)
-	if err != nil {
-		return nil, err
-	} else {
-		return key, nil
+	result.Keys, err = jwk.Parse(bodyByte)
+	return
+}
+
+// Parse the max-age directive from the value of a Cache-Control header.
+//
+// The no-cache and no-store directives are treated as a max-age of 0.
+// The second return value is false if the header has none of these directives.
+func ParseCacheControlMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-cache" || directive == "no-store" {
+			return 0, true
+		}
+		if value, found := strings.CutPrefix(directive, "max-age="); found {
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds < 0 {
+				continue
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
 	}
+	return 0, false
 }
 
 // Equivalent to c.ClientIP() except that it returns netip.Addr instead of net.IP
@@ -23,6 +23,7 @@ import (
 	"net/http/httptest"
 	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -106,3 +107,23 @@ func TestClientIPAddr(t *testing.T) {
 		assert.Equal(t, expectedIP.String(), w.Body.String())
 	})
 }
+
+func TestParseCacheControlMaxAge(t *testing.T) {
+	testCases := []struct {
+		header    string
+		maxAge    time.Duration
+		hasMaxAge bool
+	}{
+		{header: "", maxAge: 0, hasMaxAge: false},
+		{header: "public", maxAge: 0, hasMaxAge: false},
+		{header: "max-age=300", maxAge: 5 * time.Minute, hasMaxAge: true},
+		{header: "public, Max-Age=60", maxAge: time.Minute, hasMaxAge: true},
+		{header: "no-cache", maxAge: 0, hasMaxAge: true},
+		{header: "max-age=-1", maxAge: 0, hasMaxAge: false},
+	}
+	for _, tc := range testCases {
+		maxAge, hasMaxAge := ParseCacheControlMaxAge(tc.header)
+		assert.Equal(t, tc.maxAge, maxAge, "header %q", tc.header)
+		assert.Equal(t, tc.hasMaxAge, hasMaxAge, "header %q", tc.header)
+	}
+}
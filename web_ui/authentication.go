@@ -285,6 +285,60 @@ func AdminAuthHandler(ctx *gin.Context) {
 	}
 }
 
+// AdminTokenAuthHandler guards privileged API endpoints. A request carrying an
+// "Authorization: Bearer" token must present a JWT signed by this server's issuer
+// with the web_ui.admin scope; otherwise the "login" cookie of an admin user is required.
+// Requests without any credential are rejected with 401 and requests with
+// non-admin credentials are rejected with 403.
+func AdminTokenAuthHandler(ctx *gin.Context) {
+	if authHeader := ctx.GetHeader("Authorization"); authHeader != "" {
+		status, ok, err := token.Verify(ctx, token.AuthOption{
+			Sources: []token.TokenSource{token.Header},
+			Issuers: []token.TokenIssuer{token.LocalIssuer},
+			Scopes:  []token_scopes.TokenScope{token_scopes.WebUi_Admin},
+		})
+		if !ok {
+			log.Debugln("Rejecting admin request with invalid bearer token:", err)
+			ctx.AbortWithStatusJSON(status,
+				server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    "A valid token with the " + token_scopes.WebUi_Admin.String() + " scope is required to perform this operation",
+				})
+			return
+		}
+		// token.Verify records the issuer type as the user; prefer the token subject
+		if parsed, err := jwt.Parse([]byte(strings.TrimPrefix(authHeader, "Bearer ")), jwt.WithVerify(false)); err == nil && parsed.Subject() != "" {
+			ctx.Set("User", parsed.Subject())
+		}
+		ctx.Next()
+		return
+	}
+
+	user, groups, err := GetUserGroups(ctx)
+	if user == "" {
+		if err != nil {
+			log.Errorln("Invalid user cookie or unable to parse user cookie:", err)
+		}
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized,
+			server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Authentication required to perform this operation",
+			})
+		return
+	}
+	if isAdmin, msg := CheckAdmin(user); !isAdmin {
+		ctx.AbortWithStatusJSON(http.StatusForbidden,
+			server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    msg,
+			})
+		return
+	}
+	ctx.Set("User", user)
+	ctx.Set("Groups", groups)
+	ctx.Next()
+}
+
 // Handle regular username/password based login
 func loginHandler(ctx *gin.Context) {
 	db := authDB.Load()
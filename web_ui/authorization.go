@@ -31,6 +31,9 @@ import (
 	"github.com/pelicanplatform/pelican/token_scopes"
 )
 
+// The audience of the token the director scrapes the /metrics endpoint of every server with
+const promScrapeAudience = "prometheus"
+
 // Create a token for accessing Prometheus /metrics endpoint on
 // the server itself
 func createPromMetricToken() (string, error) {
@@ -63,9 +66,10 @@ func promMetricAuthHandler(ctx *gin.Context) {
 		// Auth is granted if the request is from either
 		// 1.director scraper 2.server (self) scraper 3.authenticated web user (via cookie)
 		authOption := token.AuthOption{
-			Sources: []token.TokenSource{token.Header, token.Cookie},
-			Issuers: []token.TokenIssuer{token.FederationIssuer, token.LocalIssuer},
-			Scopes:  []token_scopes.TokenScope{token_scopes.Monitoring_Scrape}}
+			Sources:   []token.TokenSource{token.Header, token.Cookie},
+			Issuers:   []token.TokenIssuer{token.FederationIssuer, token.LocalIssuer},
+			Scopes:    []token_scopes.TokenScope{token_scopes.Monitoring_Scrape},
+			Audiences: []string{promScrapeAudience}}
 
 		status, ok, err := token.Verify(ctx, authOption)
 		if !ok {
@@ -0,0 +1,109 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package web_ui
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// How long browsers may cache the result of a preflight request, in seconds
+const corsPreflightMaxAge = "600"
+
+// Whether the Origin header of a request names the server itself, i.e. the host the
+// request was sent to or the server's external web URL
+func isSameOrigin(req *http.Request, origin string) bool {
+	originUrl, err := url.Parse(origin)
+	if err != nil || originUrl.Host == "" {
+		return false
+	}
+	if strings.EqualFold(originUrl.Host, req.Host) {
+		return true
+	}
+	extUrl, err := url.Parse(param.Server_ExternalWebUrl.GetString())
+	return err == nil && strings.EqualFold(originUrl.Scheme, extUrl.Scheme) && strings.EqualFold(originUrl.Host, extUrl.Host)
+}
+
+// Create a gin middleware applying the CORS policy set by the Server.CORS* parameters.
+// Same-origin requests and requests without an Origin header, e.g. from the Pelican client,
+// are passed through untouched. Preflight requests from an allowed origin are answered
+// directly and those from any other origin are rejected; other requests from a disallowed
+// origin are served without CORS headers, leaving the browser to block the response.
+//
+// The parameters are read once, so the middleware must be created after the config is loaded
+func NewCORSMiddleware() gin.HandlerFunc {
+	allowAll := false
+	allowedOrigins := make(map[string]bool)
+	for _, origin := range param.Server_CORSAllowedOrigins.GetStringSlice() {
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		allowedOrigins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	allowedMethods := strings.Join(param.Server_CORSAllowedMethods.GetStringSlice(), ", ")
+	allowedHeaders := strings.Join(param.Server_CORSAllowedHeaders.GetStringSlice(), ", ")
+	allowCredentials := param.Server_CORSAllowCredentials.GetBool()
+
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" || isSameOrigin(ctx.Request, origin) {
+			ctx.Next()
+			return
+		}
+		// The response depends on the origin, so caches must not share it across origins
+		ctx.Writer.Header().Add("Vary", "Origin")
+
+		isPreflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if !allowAll && !allowedOrigins[strings.ToLower(origin)] {
+			if isPreflight {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, server_structs.SimpleApiResp{
+					Status: server_structs.RespFailed,
+					Msg:    "Cross-origin requests from " + origin + " are not allowed",
+				})
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		// Browsers refuse a wildcard origin on requests with credentials, so echo the origin back instead
+		if allowAll && !allowCredentials {
+			ctx.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			ctx.Header("Access-Control-Allow-Origin", origin)
+		}
+		if allowCredentials {
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if isPreflight {
+			ctx.Header("Access-Control-Allow-Methods", allowedMethods)
+			ctx.Header("Access-Control-Allow-Headers", allowedHeaders)
+			ctx.Header("Access-Control-Max-Age", corsPreflightMaxAge)
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		ctx.Next()
+	}
+}
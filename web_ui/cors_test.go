@@ -0,0 +1,110 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+package web_ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pelicanplatform/pelican/config"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	newEngine := func(allowedOrigins []string, allowCredentials bool) *gin.Engine {
+		viper.Reset()
+		viper.Set("ConfigDir", t.TempDir())
+		config.InitConfig()
+		viper.Set("Server.ExternalWebUrl", "https://director.example.com")
+		viper.Set("Server.CORSAllowedOrigins", allowedOrigins)
+		viper.Set("Server.CORSAllowCredentials", allowCredentials)
+		engine := gin.New()
+		engine.HandleMethodNotAllowed = true
+		engine.Use(NewCORSMiddleware())
+		engine.GET("/api/v1.0/director/servers", func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "ok")
+		})
+		return engine
+	}
+	doRequest := func(engine *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "https://director.example.com/api/v1.0/director/servers", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("same-origin-only-by-default", func(t *testing.T) {
+		engine := newEngine(nil, false)
+		w := doRequest(engine, http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(engine, http.MethodGet, "https://director.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = doRequest(engine, http.MethodOptions, "https://dashboard.example.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		w = doRequest(engine, http.MethodGet, "https://dashboard.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("allowed-origin", func(t *testing.T) {
+		engine := newEngine([]string{"https://dashboard.example.com/"}, true)
+		w := doRequest(engine, http.MethodOptions, "https://dashboard.example.com")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+		w = doRequest(engine, http.MethodGet, "https://dashboard.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+		w = doRequest(engine, http.MethodOptions, "https://evil.example.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("wildcard-origin", func(t *testing.T) {
+		w := doRequest(newEngine([]string{"*"}, false), http.MethodGet, "https://dashboard.example.com")
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+		// A wildcard can't be used with credentials
+		w = doRequest(newEngine([]string{"*"}, true), http.MethodGet, "https://dashboard.example.com")
+		assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
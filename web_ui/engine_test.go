@@ -29,10 +29,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -127,6 +130,145 @@ func TestRunEngine(t *testing.T) {
 	}
 }
 
+// Test that the engine serves both HTTP/2 and HTTP/1.1 clients
+func TestEngineProtocols(t *testing.T) {
+	ctx, cancel, egrp := test_utils.TestContext(context.Background(), t)
+	defer func() { require.NoError(t, egrp.Wait()) }()
+	defer cancel()
+
+	doneChan, pingCancel, socketLocation := setupPingEngine(t, ctx, egrp)
+	defer pingCancel()
+
+	for _, protoMajor := range []int{2, 1} {
+		transport := config.GetTransport().Clone()
+		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socketLocation)
+		}
+		if protoMajor == 2 {
+			// A custom dialer disables HTTP/2 unless it's forced
+			transport.ForceAttemptHTTP2 = true
+		} else {
+			transport.ForceAttemptHTTP2 = false
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		httpc := http.Client{Transport: transport}
+
+		resp, err := httpc.Get("https://" + param.Server_Hostname.GetString() + "/ping")
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "pong", string(body))
+		assert.Equal(t, protoMajor, resp.ProtoMajor)
+		transport.CloseIdleConnections()
+	}
+
+	pingCancel()
+	timeout := time.Tick(3 * time.Second)
+	select {
+	case ok := <-doneChan:
+		require.True(t, ok)
+	case <-timeout:
+		require.Fail(t, "Timeout when shutting down the engine")
+	}
+}
+
+// Test that with Server.EnableHTTP3 the engine serves HTTP/3 clients too, advertising
+// the HTTP/3 endpoint to the HTTP/2 and HTTP/1.1 ones
+func TestEngineHTTP3(t *testing.T) {
+	ctx, cancel, egrp := test_utils.TestContext(context.Background(), t)
+	defer func() { require.NoError(t, egrp.Wait()) }()
+	defer cancel()
+
+	dirname := t.TempDir()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("ConfigDir", dirname)
+	viper.Set("Server.WebPort", 8444)
+	viper.Set("Origin.Port", 8443)
+	config.InitConfig()
+	require.NoError(t, config.InitServer(ctx, config.OriginType))
+	viper.Set("Server.EnableHTTP3", true)
+
+	engine, err := GetEngine()
+	require.NoError(t, err)
+	engine.GET("/ping", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte("pong"))
+	})
+
+	// HTTP/3 is served on the UDP port with the number of the TCP one
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	engineAddr := ln.Addr().String()
+	port := ln.Addr().(*net.TCPAddr).Port
+	engineCtx, engineCancel := context.WithCancel(ctx)
+	doneChan := make(chan bool)
+	egrp.Go(func() error {
+		defer ln.Close()
+		err := runEngineWithListener(engineCtx, ln, engine, egrp)
+		assert.NoError(t, err)
+		doneChan <- true
+		return err
+	})
+
+	pingUrl := "https://" + param.Server_Hostname.GetString() + "/ping"
+	checkPing := func(t *testing.T, httpc *http.Client, protoMajor int) *http.Response {
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			var getErr error
+			resp, getErr = httpc.Get(pingUrl)
+			return getErr == nil
+		}, 5*time.Second, 50*time.Millisecond)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "pong", string(body))
+		assert.Equal(t, protoMajor, resp.ProtoMajor)
+		return resp
+	}
+
+	for _, protoMajor := range []int{2, 1} {
+		t.Run("http"+strconv.Itoa(protoMajor), func(t *testing.T) {
+			transport := config.GetTransport().Clone()
+			transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, engineAddr)
+			}
+			if protoMajor == 2 {
+				transport.ForceAttemptHTTP2 = true
+			} else {
+				transport.ForceAttemptHTTP2 = false
+				transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			}
+			defer transport.CloseIdleConnections()
+
+			resp := checkPing(t, &http.Client{Transport: transport}, protoMajor)
+			assert.Contains(t, resp.Header.Get("Alt-Svc"), `h3=":`+strconv.Itoa(port)+`"`)
+		})
+	}
+
+	t.Run("http3", func(t *testing.T) {
+		roundTripper := &http3.RoundTripper{
+			TLSClientConfig: config.GetTransport().TLSClientConfig.Clone(),
+			Dial: func(ctx context.Context, _ string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+				return quic.DialAddrEarly(ctx, engineAddr, tlsCfg, cfg)
+			},
+		}
+		defer roundTripper.Close()
+
+		checkPing(t, &http.Client{Transport: roundTripper}, 3)
+	})
+
+	engineCancel()
+	select {
+	case ok := <-doneChan:
+		require.True(t, ok)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "Timeout when shutting down the engine")
+	}
+}
+
 // Ensure that if the TLS certificate is updated on disk then new
 // connections will use the new version.
 func TestUpdateCert(t *testing.T) {
@@ -14,7 +14,7 @@ import {
   Portal,
   Alert,
 } from '@mui/material';
-import { red, grey } from '@mui/material/colors';
+import { red, grey, orange } from '@mui/material/colors';
 import { Server } from '@/index';
 import { Language } from '@mui/icons-material';
 import { NamespaceIcon } from '@/components/Namespace/index';
@@ -50,10 +50,19 @@ export const DirectorCard = ({ server, authenticated }: DirectorCardProps) => {
             borderRadius: '4px',
             transition: 'background-color 0.3s',
             '&:hover': {
-              bgcolor: server.healthStatus === 'Error' ? red[200] : grey[200],
+              bgcolor:
+                server.healthStatus === 'Error'
+                  ? red[200]
+                  : server.healthStatus === 'Degraded'
+                    ? orange[200]
+                    : grey[200],
             },
             bgcolor:
-              server.healthStatus === 'Error' ? red[100] : 'secondary.main',
+              server.healthStatus === 'Error'
+                ? red[100]
+                : server.healthStatus === 'Degraded'
+                  ? orange[100]
+                  : 'secondary.main',
             p: 1,
           }}
           onClick={() => setDropdownOpen(!dropdownOpen)}
@@ -92,7 +92,7 @@ export default function Page() {
 }
 
 const getServers = async () => {
-  const url = new URL('/api/v1.0/director_ui/servers', window.location.origin);
+  const url = new URL('/api/v2.0/director/servers', window.location.origin);
 
   let response = await fetch(url);
   if (response.ok) {
@@ -85,10 +85,7 @@ export const ServerTable = ({ type }: ServerTableProps) => {
   };
 
   const getData = useCallback(async () => {
-    const url = new URL(
-      '/api/v1.0/director_ui/servers',
-      window.location.origin
-    );
+    const url = new URL('/api/v2.0/director/servers', window.location.origin);
     if (type) {
       url.searchParams.append('server_type', type);
     }
@@ -0,0 +1,93 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// A readiness check returns nil if the server component is ready to serve traffic,
+	// or an error describing why it is not
+	ReadinessCheck func(ctx context.Context) error
+
+	readinessResp struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+)
+
+const readinessCheckTimeout = 5 * time.Second
+
+var (
+	readinessChecks      = make(map[string]ReadinessCheck)
+	readinessChecksMutex = sync.RWMutex{}
+)
+
+// Register a named readiness check to be evaluated by the /readyz endpoint.
+// Registering a check with an existing name replaces it.
+func RegisterReadinessCheck(name string, check ReadinessCheck) {
+	readinessChecksMutex.Lock()
+	defer readinessChecksMutex.Unlock()
+	readinessChecks[name] = check
+}
+
+// Liveness probe: the process is up and the web engine is serving requests
+func handleHealthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, readinessResp{Status: "ok"})
+}
+
+// Readiness probe: every registered readiness check passes. Returns 503 with the
+// failed checks otherwise, so that a load balancer can hold traffic until the
+// server is warm
+func handleReadyz(ctx *gin.Context) {
+	readinessChecksMutex.RLock()
+	checks := make(map[string]ReadinessCheck, len(readinessChecks))
+	names := make([]string, 0, len(readinessChecks))
+	for name, check := range readinessChecks {
+		checks[name] = check
+		names = append(names, name)
+	}
+	readinessChecksMutex.RUnlock()
+	sort.Strings(names)
+
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	resp := readinessResp{Status: "ok", Checks: make(map[string]string, len(names))}
+	for _, name := range names {
+		if err := checks[name](checkCtx); err != nil {
+			resp.Status = "unavailable"
+			resp.Checks[name] = err.Error()
+		} else {
+			resp.Checks[name] = "ok"
+		}
+	}
+	if resp.Status != "ok" {
+		ctx.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,78 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthzReadyz(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/healthz", handleHealthz)
+	engine.GET("/readyz", handleReadyz)
+
+	t.Cleanup(func() {
+		readinessChecksMutex.Lock()
+		readinessChecks = make(map[string]ReadinessCheck)
+		readinessChecksMutex.Unlock()
+	})
+
+	getReadyz := func(t *testing.T) (int, readinessResp) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		engine.ServeHTTP(w, req)
+		resp := readinessResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("healthz-always-ok", func(t *testing.T) {
+		RegisterReadinessCheck("failing", func(ctx context.Context) error { return errors.New("not warm") })
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("readyz-reports-failed-checks", func(t *testing.T) {
+		RegisterReadinessCheck("passing", func(ctx context.Context) error { return nil })
+		RegisterReadinessCheck("failing", func(ctx context.Context) error { return errors.New("not warm") })
+		code, resp := getReadyz(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", resp.Status)
+		assert.Equal(t, "ok", resp.Checks["passing"])
+		assert.Equal(t, "not warm", resp.Checks["failing"])
+	})
+
+	t.Run("readyz-ok-when-all-checks-pass", func(t *testing.T) {
+		RegisterReadinessCheck("failing", func(ctx context.Context) error { return nil })
+		code, resp := getReadyz(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", resp.Status)
+	})
+}
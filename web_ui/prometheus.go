@@ -178,7 +178,7 @@ func configDirectorPromScraper(ctx context.Context) (*config.ScrapeConfig, error
 	scrapeTokenCfg := token.NewWLCGToken()
 	scrapeTokenCfg.Lifetime = param.Monitoring_TokenExpiresIn.GetDuration()
 	scrapeTokenCfg.Issuer = directorBaseUrl.String()
-	scrapeTokenCfg.AddAudiences("prometheus")
+	scrapeTokenCfg.AddAudiences(promScrapeAudience)
 	scrapeTokenCfg.Subject = "director"
 	scrapeTokenCfg.AddScopes(token_scopes.Monitoring_Scrape)
 
@@ -0,0 +1,75 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	RequestIDHeader = "X-Request-Id"
+	// The gin context key holding the request ID
+	requestIDKey = "requestID"
+	// Inbound request IDs longer than this are replaced, to keep clients from stuffing the logs
+	maxRequestIDLength = 128
+)
+
+// Gin middleware that assigns a request ID to each request, honoring an inbound X-Request-Id header,
+// echoes it back in the response header, and logs the request once it's handled
+func RequestLoggingMiddleware(ctx *gin.Context) {
+	start := time.Now()
+	requestID := ctx.GetHeader(RequestIDHeader)
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = uuid.NewString()
+	}
+	ctx.Set(requestIDKey, requestID)
+	ctx.Header(RequestIDHeader, requestID)
+
+	ctx.Next()
+
+	entry := log.WithFields(log.Fields{
+		"request_id": requestID,
+		"method":     ctx.Request.Method,
+		"path":       ctx.Request.URL.Path,
+		"status":     ctx.Writer.Status(),
+		"latency":    time.Since(start).String(),
+		"client_ip":  ctx.ClientIP(),
+	})
+	if ctx.Writer.Status() >= http.StatusInternalServerError {
+		entry.Warning("Request failed")
+	} else {
+		entry.Debug("Request handled")
+	}
+}
+
+// Get the request ID assigned by RequestLoggingMiddleware, or an empty string
+// if the middleware was not applied to the route
+func GetRequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
+
+// Get a log entry carrying the request ID, for correlating handler logs with request logs
+func RequestLogger(ctx *gin.Context) *log.Entry {
+	return log.WithField("request_id", GetRequestID(ctx))
+}
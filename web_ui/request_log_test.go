@@ -0,0 +1,63 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLoggingMiddleware(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/test", RequestLoggingMiddleware, func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, GetRequestID(ctx))
+	})
+
+	t.Run("generates-request-id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+		assert.Equal(t, w.Header().Get(RequestIDHeader), w.Body.String())
+	})
+
+	t.Run("honors-inbound-request-id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(RequestIDHeader, "my-request-id")
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, "my-request-id", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "my-request-id", w.Body.String())
+	})
+
+	t.Run("replaces-oversized-request-id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(RequestIDHeader, strings.Repeat("a", maxRequestIDLength+1))
+		engine.ServeHTTP(w, req)
+		assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+		assert.LessOrEqual(t, len(w.Header().Get(RequestIDHeader)), maxRequestIDLength)
+	})
+}
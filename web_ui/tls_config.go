@@ -0,0 +1,120 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Parse Server.TLSMinVersion; an empty value leaves the choice to the Go default
+func parseTLSMinVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	if v, ok := tlsVersions[version]; ok {
+		return v, nil
+	}
+	return 0, errors.Errorf("invalid Server.TLSMinVersion %q; valid versions are 1.0, 1.1, 1.2, and 1.3", version)
+}
+
+// Convert the cipher suite names of Server.TLSCipherSuites to their IDs.
+// An empty list leaves the choice to the Go default
+func parseTLSCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, errors.Errorf("unknown TLS cipher suite %q in Server.TLSCipherSuites", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Create the TLS configuration of the web server, honoring Server.TLSMinVersion,
+// Server.TLSCipherSuites, and Server.ClientCertCAFile. The caller is responsible for providing the certificate
+func newServerTLSConfig() (*tls.Config, error) {
+	minVersion, err := parseTLSMinVersion(param.Server_TLSMinVersion.GetString())
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := parseTLSCipherSuites(param.Server_TLSCipherSuites.GetStringSlice())
+	if err != nil {
+		return nil, err
+	}
+	// HTTP/2 requires one of these suites unless TLS 1.3, whose suites aren't configurable, is
+	// the minimum; without them the server would fail to start rather than fall back to HTTP/1.1
+	if len(cipherSuites) > 0 && minVersion < tls.VersionTLS13 &&
+		!slices.Contains(cipherSuites, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) &&
+		!slices.Contains(cipherSuites, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256) {
+		return nil, errors.New("Server.TLSCipherSuites must include TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or " +
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 for HTTP/2")
+	}
+	cfg := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		// Prefer HTTP/2 so that clients can multiplex their requests over a single connection
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if caFile := param.Server_ClientCertCAFile.GetString(); caFile != "" {
+		if cfg.ClientCAs, err = loadClientCAs(caFile); err != nil {
+			return nil, err
+		}
+		// Clients without a certificate may still authenticate with a token
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
+
+// Load the CAs trusted to issue client certificates from Server.ClientCertCAFile
+func loadClientCAs(caFile string) (*x509.CertPool, error) {
+	contents, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read Server.ClientCertCAFile")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(contents) {
+		return nil, errors.Errorf("no PEM-encoded certificates found in Server.ClientCertCAFile %s", caFile)
+	}
+	return pool, nil
+}
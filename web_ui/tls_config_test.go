@@ -0,0 +1,170 @@
+/***************************************************************
+ *
+ * Copyright (C) 2024, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package web_ui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestNewServerTLSConfig(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+
+	t.Run("valid-config", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Server.TLSMinVersion", "1.3")
+		viper.Set("Server.TLSCipherSuites", []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+		cfg, err := newServerTLSConfig()
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+	})
+
+	t.Run("invalid-version", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Server.TLSMinVersion", "2.0")
+		_, err := newServerTLSConfig()
+		assert.ErrorContains(t, err, "Server.TLSMinVersion")
+	})
+
+	t.Run("unknown-cipher-suite", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Server.TLSCipherSuites", []string{"TLS_NOT_A_CIPHER"})
+		_, err := newServerTLSConfig()
+		assert.ErrorContains(t, err, "TLS_NOT_A_CIPHER")
+	})
+
+	t.Run("cipher-suites-without-http2", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Server.TLSMinVersion", "1.2")
+		viper.Set("Server.TLSCipherSuites", []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"})
+		_, err := newServerTLSConfig()
+		assert.ErrorContains(t, err, "HTTP/2")
+	})
+
+	t.Run("tls11-handshake-refused", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("Server.TLSMinVersion", "1.2")
+		cfg, err := newServerTLSConfig()
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = cfg
+		server.StartTLS()
+		defer server.Close()
+
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS11,
+			MaxVersion:         tls.VersionTLS11,
+		})
+		if err == nil {
+			conn.Close()
+		}
+		assert.Error(t, err)
+
+		conn, err = tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS12,
+		})
+		require.NoError(t, err)
+		conn.Close()
+	})
+	t.Run("client-certificates", func(t *testing.T) {
+		viper.Reset()
+		ca := test_utils.GenerateTestCA(t, "Client CA")
+		caFile := filepath.Join(t.TempDir(), "client-ca.pem")
+		require.NoError(t, os.WriteFile(caFile, ca.PEM(), 0644))
+		viper.Set("Server.ClientCertCAFile", caFile)
+		cfg, err := newServerTLSConfig()
+		require.NoError(t, err)
+		assert.Equal(t, tls.VerifyClientCertIfGiven, cfg.ClientAuth)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		server.TLS = cfg
+		server.StartTLS()
+		defer server.Close()
+
+		get := func(certs ...tls.Certificate) (*http.Response, error) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+				// Present the certificate even if it's not from a CA the server asks for
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					if len(certs) == 0 {
+						return &tls.Certificate{}, nil
+					}
+					return &certs[0], nil
+				},
+			}}}
+			return client.Get(server.URL)
+		}
+
+		// A certificate from the trusted CA is verified
+		trusted := ca.IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "trusted"}})
+		resp, err := get(trusted.TLSCertificate())
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// A certificate from another CA is rejected during the handshake
+		untrusted := test_utils.GenerateTestCA(t, "Other CA").IssueClientCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "untrusted"}})
+		resp, err = get(untrusted.TLSCertificate())
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+
+		// Without a certificate the connection is accepted, leaving authorization to tokens
+		resp, err = get()
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("invalid-client-ca-file", func(t *testing.T) {
+		viper.Reset()
+		caFile := filepath.Join(t.TempDir(), "client-ca.pem")
+		require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0644))
+		viper.Set("Server.ClientCertCAFile", caFile)
+		_, err := newServerTLSConfig()
+		assert.ErrorContains(t, err, "Server.ClientCertCAFile")
+	})
+}
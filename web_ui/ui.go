@@ -39,6 +39,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/pkg/errors"
+	"github.com/quic-go/quic-go/http3"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
@@ -391,6 +392,9 @@ func configureCommonEndpoints(engine *gin.Engine) error {
 	engine.GET("/api/v1.0/health", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Web Engine Running. Time: %s", time.Now().String())})
 	})
+	// Liveness and readiness probes, e.g. for Kubernetes
+	engine.GET("/healthz", handleHealthz)
+	engine.GET("/readyz", handleReadyz)
 	return nil
 }
 
@@ -613,6 +617,11 @@ func RunEngineRoutineWithListener(ctx context.Context, engine *gin.Engine, egrp
 // This was split out from RunEngine to allow unit tests to provide a Unix domain socket'
 // as a listener.
 func runEngineWithListener(ctx context.Context, ln net.Listener, engine *gin.Engine, egrp *errgroup.Group) error {
+	tlsConfig, err := newServerTLSConfig()
+	if err != nil {
+		return errors.Wrap(err, "invalid TLS configuration for the web server")
+	}
+
 	certFile := param.Server_TLSCertificate.GetString()
 	keyFile := param.Server_TLSKey.GetString()
 
@@ -649,13 +658,46 @@ func runEngineWithListener(ctx context.Context, ln net.Listener, engine *gin.Eng
 		return certPtr.Load(), nil
 	}
 
-	config := &tls.Config{
-		GetCertificate: getCert,
+	tlsConfig.GetCertificate = getCert
+	handler := engine.Handler()
+
+	var h3Server *http3.Server
+	if param.Server_EnableHTTP3.GetBool() {
+		// Serve HTTP/3 on the UDP port with the number of the TCP one
+		h3Addr := addr
+		if tcpAddr, ok := ln.Addr().(*net.TCPAddr); ok {
+			h3Addr = tcpAddr.String()
+		}
+		udpConn, err := net.ListenPacket("udp", h3Addr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to listen for HTTP/3 connections at %s", h3Addr)
+		}
+		h3Server = &http3.Server{
+			Handler:   handler,
+			TLSConfig: tlsConfig.Clone(),
+		}
+		// Advertise the HTTP/3 endpoint to the clients of the HTTP/1.1 and HTTP/2 one
+		tcpHandler := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := h3Server.SetQuicHeaders(w.Header()); err != nil {
+				log.Debugln("Failed to advertise the HTTP/3 endpoint:", err)
+			}
+			tcpHandler.ServeHTTP(w, r)
+		})
+		log.Debugln("Starting HTTP/3 web engine at address", h3Addr)
+		egrp.Go(func() error {
+			defer udpConn.Close()
+			if err := h3Server.Serve(udpConn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return errors.Wrap(err, "failed to serve HTTP/3")
+			}
+			return nil
+		})
 	}
+
 	server := &http.Server{
 		Addr:      addr,
-		Handler:   engine.Handler(),
-		TLSConfig: config,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
 	}
 	log.Debugln("Starting web engine at address", addr)
 
@@ -663,6 +705,11 @@ func runEngineWithListener(ctx context.Context, ln net.Listener, engine *gin.Eng
 	// 10 seconds to shutdown existing requests.
 	egrp.Go(func() error {
 		<-ctx.Done()
+		if h3Server != nil {
+			if err := h3Server.Close(); err != nil {
+				log.Errorln("Failed to shutdown HTTP/3 server:", err)
+			}
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		err = server.Shutdown(ctx)
@@ -30,6 +30,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -50,6 +51,8 @@ import (
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
 )
 
 type (
@@ -69,6 +72,8 @@ type (
 		DefaultUser     string
 		UsernameClaim   string
 		NameMapfile     string
+		// The operations the issuer's tokens may authorize, e.g. "read"; all of them if empty
+		AcceptableAuthorization string
 	}
 
 	// Top-level configuration object for the template
@@ -352,6 +357,19 @@ func EmitAuthfile(server server_structs.XRootDServer) error {
 		}
 	}
 
+	// Grant the identities XRootD maps client certificates to their scopes; caches only serve reads
+	identities, err := getXrootdClientCertIdentities()
+	if err != nil {
+		return err
+	}
+	output.WriteString(clientCertAuthfileEntries(identities, server.GetServerType().IsEnabled(config.CacheType)))
+
+	if server.GetServerType().IsEnabled(config.OriginType) && origin.IsReadOnlyMode() {
+		readOnly := stripAuthfileWritePrivileges(output.String())
+		output.Reset()
+		output.WriteString(readOnly)
+	}
+
 	gid, err := config.GetDaemonGID()
 	if err != nil {
 		return err
@@ -383,6 +401,171 @@ func EmitAuthfile(server server_structs.XRootDServer) error {
 	return nil
 }
 
+// Remove the privileges other than lookups and reads from the entries of an authfile, for an
+// origin in read-only mode. Negated privileges are left as is since they only take access away
+func stripAuthfileWritePrivileges(authfile string) string {
+	output := new(bytes.Buffer)
+	sc := bufio.NewScanner(strings.NewReader(authfile))
+	sc.Split(ScanLinesWithCont)
+	for sc.Scan() {
+		words := strings.Fields(sc.Text())
+		if len(words) < 4 || strings.HasPrefix(words[0], "#") {
+			output.WriteString(sc.Text() + "\n")
+			continue
+		}
+		// Entries are "idtype id path privs [path privs]..."
+		entry := words[:2]
+		for idx := 2; idx+1 < len(words); idx += 2 {
+			privs := words[idx+1]
+			if !strings.HasPrefix(privs, "-") {
+				privs = strings.Map(func(r rune) rune {
+					if r == 'l' || r == 'r' {
+						return r
+					}
+					return -1
+				}, privs)
+			}
+			if privs != "" {
+				entry = append(entry, words[idx], privs)
+			}
+		}
+		if len(entry) > 2 {
+			output.WriteString(strings.Join(entry, " ") + "\n")
+		}
+	}
+	return output.String()
+}
+
+// Get the entries of Server.ClientCertIdentities XRootD can map client certificates to. There
+// are none unless Server.ClientCertCAFile is set, as for the web server, and identities XRootD
+// can't use as a username are skipped
+func getXrootdClientCertIdentities() ([]token.ClientCertIdentity, error) {
+	if param.Server_ClientCertCAFile.GetString() == "" {
+		return nil, nil
+	}
+	identities, err := token.GetClientCertIdentities()
+	if err != nil {
+		return nil, err
+	}
+	valid := make([]token.ClientCertIdentity, 0, len(identities))
+	for _, entry := range identities {
+		if entry.Identity == "" || strings.ContainsFunc(entry.Identity, unicode.IsSpace) || strings.Contains(entry.Identity, "\"") {
+			log.Warningf("Ignoring the client certificate identity %q for XRootD, as it isn't a valid username", entry.Identity)
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	return valid, nil
+}
+
+// Convert a distinguished name in the form Go prints certificate subjects in (RFC 4514), such as
+// "CN=transfer.example.com,O=Example", to the form XRootD matches gridmap entries against,
+// "/O=Example/CN=transfer.example.com". Returns false if the name isn't a distinguished name
+func gridmapDN(name string) (string, bool) {
+	rdns := []string{}
+	current := strings.Builder{}
+	escaped := false
+	for _, r := range name {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			rdns = append(rdns, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	rdns = append(rdns, current.String())
+
+	dn := ""
+	for idx := len(rdns) - 1; idx >= 0; idx-- {
+		attr, value, found := strings.Cut(rdns[idx], "=")
+		if !found || attr == "" || value == "" || strings.ContainsAny(rdns[idx], "/\"") {
+			return "", false
+		}
+		dn += "/" + rdns[idx]
+	}
+	return dn, true
+}
+
+// Generate the gridmap mapping the distinguished names of client certificates to their identities.
+// Matches on subject alternative names have no gridmap equivalent and are only honored by the web server
+func clientCertGridmap(identities []token.ClientCertIdentity) []byte {
+	output := new(bytes.Buffer)
+	for _, entry := range identities {
+		for _, match := range entry.Match {
+			if dn, ok := gridmapDN(match); ok {
+				fmt.Fprintf(output, "\"%s\" %s\n", dn, entry.Identity)
+			}
+		}
+	}
+	return output.Bytes()
+}
+
+// Generate the authfile entries granting the client certificate identities their resource scopes.
+// If readOnly, only the storage.read scopes are granted
+func clientCertAuthfileEntries(identities []token.ClientCertIdentity, readOnly bool) string {
+	output := strings.Builder{}
+	for _, entry := range identities {
+		grants := []string{}
+		for _, scope := range token_scopes.ParseResourceScopes(entry.Scopes) {
+			var privs string
+			switch scope.Authorization {
+			case token_scopes.Storage_Read:
+				privs = "lr"
+			case token_scopes.Storage_Create:
+				privs = "ilw"
+			case token_scopes.Storage_Modify:
+				privs = "dilnw"
+			default:
+				continue
+			}
+			if readOnly && scope.Authorization != token_scopes.Storage_Read {
+				continue
+			}
+			grants = append(grants, scope.Resource+" "+privs)
+		}
+		if len(grants) > 0 {
+			output.WriteString("u " + entry.Identity + " " + strings.Join(grants, " ") + "\n")
+		}
+	}
+	return output.String()
+}
+
+// Write the gridmap of the client certificate identities into the xrootd runtime directory.
+// Returns its location, or an empty string if no identity is matched by a distinguished name
+func EmitClientCertGridmap(isOrigin bool) (string, error) {
+	identities, err := getXrootdClientCertIdentities()
+	if err != nil {
+		return "", err
+	}
+	contents := clientCertGridmap(identities)
+	if len(contents) == 0 {
+		return "", nil
+	}
+
+	gid, err := config.GetDaemonGID()
+	if err != nil {
+		return "", err
+	}
+	xrootdRun := param.Origin_RunLocation.GetString()
+	if !isOrigin {
+		xrootdRun = param.Cache_RunLocation.GetString()
+	}
+	gridmapPath := filepath.Join(xrootdRun, "gridmap-generated")
+	if err = os.WriteFile(gridmapPath, contents, 0640); err != nil {
+		return "", errors.Wrapf(err, "Failed to write the generated gridmap %s", gridmapPath)
+	}
+	if err = os.Chown(gridmapPath, -1, gid); err != nil {
+		return "", errors.Wrapf(err, "Unable to change ownership of the generated gridmap %v to desired daemon gid %v", gridmapPath, gid)
+	}
+	return gridmapPath, nil
+}
+
 // Given a filename, load and parse the file into a ScitokensCfg object
 func LoadScitokensConfig(fileName string) (cfg ScitokensCfg, err error) {
 	configIni, err := ini.Load(fileName)
@@ -607,9 +790,30 @@ func WriteOriginScitokensConfig(authedPaths []string) error {
 		return errors.Wrap(err, "failed to generate xrootd issuer for director-based monitoring")
 	}
 
+	// In read-only mode, the issuers of the exports may only authorize reads. The issuers
+	// dedicated to monitoring keep their writes so the director's health tests still pass
+	if origin.IsReadOnlyMode() {
+		for key, issuer := range cfg.IssuerMap {
+			if !isMonitoringIssuer(issuer) {
+				issuer.AcceptableAuthorization = "read"
+				cfg.IssuerMap[key] = issuer
+			}
+		}
+	}
+
 	return writeScitokensConfiguration(config.OriginType, &cfg)
 }
 
+// Whether the issuer only authorizes access to the monitoring namespace of the server
+func isMonitoringIssuer(issuer Issuer) bool {
+	for _, basePath := range issuer.BasePaths {
+		if basePath != "/pelican/monitoring" {
+			return false
+		}
+	}
+	return true
+}
+
 // Writes out the cache's scitokens.cfg configuration
 func WriteCacheScitokensConfig(nsAds []server_structs.NamespaceAdV2) error {
 	cfg, err := makeSciTokensCfg()
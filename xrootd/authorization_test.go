@@ -362,6 +362,77 @@ func TestEmitAuthfile(t *testing.T) {
 	}
 }
 
+func TestGridmapDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		match    string
+		expected string
+		ok       bool
+	}{
+		{"single", "CN=transfer.example.com", "/CN=transfer.example.com", true},
+		{"reversed", "CN=transfer.example.com,O=Example,C=US", "/C=US/O=Example/CN=transfer.example.com", true},
+		{"escapedComma", "CN=Transfer\\, Inc.,O=Example", "/O=Example/CN=Transfer, Inc.", true},
+		{"dnsName", "transfer.example.com", "", false},
+		{"uri", "spiffe://example.com/transfer?id=1", "", false},
+		{"emptyValue", "CN=,O=Example", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dn, ok := gridmapDN(tt.match)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, dn)
+		})
+	}
+}
+
+func TestClientCertXrootdAuthz(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Reset()
+	viper.Set("Server.ClientCertIdentities", []map[string]interface{}{
+		{
+			"Identity": "transfer-service",
+			"Match":    []string{"CN=transfer.example.com,O=Example", "transfer.example.com"},
+			"Scopes":   []string{"storage.read:/example/data", "storage.create:/example/incoming", "storage.modify:/example/scratch"},
+		},
+		{
+			"Identity": "monitor",
+			"Match":    []string{"CN=monitor.example.com"},
+			"Scopes":   []string{"storage.read:/example/monitoring"},
+		},
+		{
+			"Identity": "not a username",
+			"Match":    []string{"CN=bad.example.com"},
+			"Scopes":   []string{"storage.read:/example"},
+		},
+	})
+
+	t.Run("disabled-without-ca", func(t *testing.T) {
+		identities, err := getXrootdClientCertIdentities()
+		require.NoError(t, err)
+		assert.Empty(t, identities)
+	})
+
+	viper.Set("Server.ClientCertCAFile", filepath.Join(t.TempDir(), "client-ca.pem"))
+	identities, err := getXrootdClientCertIdentities()
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+
+	t.Run("gridmap", func(t *testing.T) {
+		assert.Equal(t, "\"/O=Example/CN=transfer.example.com\" transfer-service\n\"/CN=monitor.example.com\" monitor\n",
+			string(clientCertGridmap(identities)))
+	})
+
+	t.Run("origin-authfile", func(t *testing.T) {
+		assert.Equal(t, "u transfer-service /example/data lr /example/incoming ilw /example/scratch dilnw\n"+
+			"u monitor /example/monitoring lr\n", clientCertAuthfileEntries(identities, false))
+	})
+
+	t.Run("cache-authfile", func(t *testing.T) {
+		assert.Equal(t, "u transfer-service /example/data lr\nu monitor /example/monitoring lr\n",
+			clientCertAuthfileEntries(identities, true))
+	})
+}
+
 func TestEmitCfg(t *testing.T) {
 	dirname := t.TempDir()
 	viper.Reset()
@@ -758,4 +829,59 @@ func TestWriteOriginScitokensConfig(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, string(monitoringOutput), string(genCfg))
+
+	// In read-only mode, every issuer of the exports may only authorize reads
+	origin.SetReadOnlyMode(true)
+	defer origin.SetReadOnlyMode(false)
+	err = WriteOriginScitokensConfig([]string{"/foo/bar"})
+	require.NoError(t, err)
+
+	genCfg, err = os.ReadFile(filepath.Join(dirname, "scitokens-origin-generated.cfg"))
+	require.NoError(t, err)
+	cfg, err := LoadScitokensConfig(filepath.Join(dirname, "scitokens-origin-generated.cfg"))
+	require.NoError(t, err)
+	require.Len(t, cfg.IssuerMap, 3)
+	assert.Equal(t, 3, strings.Count(string(genCfg), "acceptable_authorization = read\n"))
+	assert.Contains(t, string(genCfg), "base_path = /foo/bar, /pelican/monitoring\nacceptable_authorization = read\n")
+}
+
+func TestStripAuthfileWritePrivileges(t *testing.T) {
+	authfile := "# A comment about writes\n" +
+		"u * /.well-known lr /foo lr\n" +
+		"u writer /foo/bar rlw /baz a\n" +
+		"u admin /foo -w\n" +
+		"g /collab /data \\\n  dilnrw\n"
+	expected := "# A comment about writes\n" +
+		"u * /.well-known lr /foo lr\n" +
+		"u writer /foo/bar rl\n" +
+		"u admin /foo -w\n" +
+		"g /collab /data lr\n"
+	assert.Equal(t, expected, stripAuthfileWritePrivileges(authfile))
+}
+
+func TestReadOnlyModeAuthfile(t *testing.T) {
+	dirName := t.TempDir()
+	viper.Reset()
+	server_utils.ResetOriginExports()
+	defer viper.Reset()
+	defer server_utils.ResetOriginExports()
+	viper.Set("Xrootd.Authfile", filepath.Join(dirName, "authfile"))
+	viper.Set("Origin.RunLocation", dirName)
+	viper.Set("Origin.FederationPrefix", "/foo")
+	viper.Set("Origin.StoragePrefix", "/")
+	viper.Set("Origin.EnablePublicReads", true)
+	require.NoError(t, os.WriteFile(filepath.Join(dirName, "authfile"), []byte("u writer /foo rlw\n"), fs.FileMode(0600)))
+	server := &origin.OriginServer{}
+
+	require.NoError(t, EmitAuthfile(server))
+	contents, err := os.ReadFile(filepath.Join(dirName, "authfile-origin-generated"))
+	require.NoError(t, err)
+	assert.Equal(t, "u writer /foo rlw\nu * /.well-known lr /foo lr\n", string(contents))
+
+	origin.SetReadOnlyMode(true)
+	defer origin.SetReadOnlyMode(false)
+	require.NoError(t, EmitAuthfile(server))
+	contents, err = os.ReadFile(filepath.Join(dirName, "authfile-origin-generated"))
+	require.NoError(t, err)
+	assert.Equal(t, "u writer /foo rl\nu * /.well-known lr /foo lr\n", string(contents))
 }
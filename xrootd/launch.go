@@ -34,6 +34,7 @@ import (
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/daemon"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 )
 
@@ -79,6 +80,11 @@ func makeUnprivilegedXrootdLauncher(daemonName string, configPath string, isCach
 		}
 	}
 
+	if daemonName == "xrootd" && !isCache {
+		// Requests the origin refuses only show up in its log, not in its monitoring stream
+		result.LogLineHandler = metrics.LogXrootdAccessFailure
+	}
+
 	if isCache {
 		result.ExtraEnv = []string{
 			"XRD_PELICANBROKERSOCKET=" + filepath.Join(xrootdRun, "cache-reversal.sock"),
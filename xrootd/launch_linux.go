@@ -33,6 +33,7 @@ import (
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/daemon"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 )
 
@@ -141,7 +142,12 @@ func (plauncher PrivilegedXrootdLauncher) Launch(ctx context.Context) (context.C
 
 	writeStdout.Close()
 	writeStderr.Close()
-	go daemon.ForwardCommandToLogger(ctx, plauncher.Name(), readStdout, readStderr)
+	// Privileged launches are only for origins, whose refused requests go to the access log
+	var lineHandler func(string)
+	if plauncher.Name() == "xrootd" {
+		lineHandler = metrics.LogXrootdAccessFailure
+	}
+	go daemon.ForwardCommandToLogger(ctx, plauncher.Name(), readStdout, readStderr, lineHandler)
 
 	ctx_result, cancel := context.WithCancelCause(ctx)
 	go func() {
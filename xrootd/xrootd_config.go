@@ -96,6 +96,10 @@ type (
 		XRootServiceUrl   string
 		RunLocation       string
 		StorageType       string
+		AtomicUploads     bool
+		UploadLogLocation string
+		// The aggregate bandwidth limit enforced by the XRootD throttling plugin; 0 for no limit
+		MaxBytesPerSecTotal int
 
 		// S3 specific options that are kept top-level because
 		// they aren't specific to each export
@@ -118,6 +122,8 @@ type (
 		LocalRoot      string
 		PSSOrigin      string
 		Concurrency    int
+		// The aggregate bandwidth limit enforced by the XRootD throttling plugin; 0 for no limit
+		MaxBytesPerSecTotal int
 	}
 
 	XrootdOptions struct {
@@ -144,6 +150,8 @@ type (
 		TLSKey                    string
 		TLSCACertificateDirectory string
 		TLSCACertificateFile      string
+		// The generated gridmap of the client certificate identities, if any
+		ClientCertGridmap string
 	}
 
 	LoggingConfig struct {
@@ -202,6 +210,14 @@ func CheckOriginXrootdEnv(exportPath string, server server_structs.XRootDServer,
 		// For each export, we symlink the exported directory, currently at /var/run/pelican/export/<export.FederationPrefix>,
 		// to the actual data source, which is what we get from the Export object's StoragePrefix
 		for _, export := range originExports {
+			// S3 exports of a POSIX origin are served through presigned S3 URLs rather than by XRootD,
+			// so they can't be served at all without them
+			if export.GetStorageType() != server_structs.OriginStoragePosix {
+				if !param.Origin_S3PresignRedirects.GetBool() {
+					return errors.Errorf("the %s export %s of a POSIX origin requires Origin.S3PresignRedirects to be enabled", export.GetStorageType(), export.FederationPrefix)
+				}
+				continue
+			}
 			destPath := path.Clean(filepath.Join(exportPath, export.FederationPrefix))
 			err := config.MkdirAll(filepath.Dir(destPath), 0755, uid, gid)
 			if err != nil {
@@ -216,6 +232,14 @@ func CheckOriginXrootdEnv(exportPath string, server server_structs.XRootDServer,
 		}
 		// Set the mount to our export path now that everything is symlinked
 		viper.Set("Xrootd.Mount", exportPath)
+
+		// XRootD logs the uploads in progress here so that the objects of interrupted uploads
+		// can be removed at startup, so the directory must survive restarts
+		if param.Origin_AtomicUploads.GetBool() {
+			if err := config.MkdirAll(param.Origin_UploadLogLocation.GetString(), 0700, uid, gid); err != nil {
+				return errors.Wrapf(err, "Unable to create the upload log directory %v", param.Origin_UploadLogLocation.GetString())
+			}
+		}
 	}
 
 	if param.Origin_SelfTest.GetBool() {
@@ -770,7 +794,12 @@ func ConfigXrootd(ctx context.Context, isOrigin bool) (string, error) {
 	if !isOrigin {
 		runtimeCAs = filepath.Join(param.Cache_RunLocation.GetString(), "ca-bundle.crt")
 	}
-	caCount, err := utils.LaunchPeriodicWriteCABundle(ctx, runtimeCAs, 2*time.Minute)
+	// XRootD verifies client certificates against the same bundle, so it includes the CAs trusted to issue them
+	extraCAFiles := []string{}
+	if clientCAFile := param.Server_ClientCertCAFile.GetString(); clientCAFile != "" {
+		extraCAFiles = append(extraCAFiles, clientCAFile)
+	}
+	caCount, err := utils.LaunchPeriodicWriteCABundle(ctx, runtimeCAs, 2*time.Minute, extraCAFiles...)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to setup the runtime CA bundle")
 	}
@@ -779,6 +808,10 @@ func ConfigXrootd(ctx context.Context, isOrigin bool) (string, error) {
 		xrdConfig.Server.TLSCACertificateFile = runtimeCAs
 	}
 
+	if xrdConfig.Server.ClientCertGridmap, err = EmitClientCertGridmap(isOrigin); err != nil {
+		return "", err
+	}
+
 	if isOrigin {
 		if xrdConfig.Origin.Multiuser {
 			ok, err := config.HasMultiuserCaps()
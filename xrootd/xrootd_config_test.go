@@ -244,6 +244,30 @@ func TestXrootDOriginConfig(t *testing.T) {
 		viper.Reset()
 	})
 
+	t.Run("TestOriginAtomicUploads", func(t *testing.T) {
+		xrootd := xrootdTest{T: t}
+		xrootd.setup()
+
+		uploadLogDir := t.TempDir()
+		viper.Set("Origin.StorageType", "posix")
+		viper.Set("Origin.AtomicUploads", true)
+		viper.Set("Origin.UploadLogLocation", uploadLogDir)
+
+		configPath, err := ConfigXrootd(ctx, true)
+		require.NoError(t, err)
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "ofs.persist auto hold 0 logdir "+uploadLogDir)
+
+		viper.Set("Origin.AtomicUploads", false)
+		configPath, err = ConfigXrootd(ctx, true)
+		require.NoError(t, err)
+		content, err = os.ReadFile(configPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "ofs.persist")
+		viper.Reset()
+	})
+
 	t.Run("TestOsdfWithXRDHOSTAndPort", func(t *testing.T) {
 		xrootd := xrootdTest{T: t}
 		defer os.Unsetenv("XRDHOST")
@@ -341,6 +365,22 @@ func TestXrootDCacheConfig(t *testing.T) {
 		assert.Contains(t, string(content), "throttle.throttle concurrency 10")
 	})
 
+	t.Run("TestCacheBandwidthLimit", func(t *testing.T) {
+		defer viper.Reset()
+		defer server_utils.ResetOriginExports()
+		xrootd := xrootdTest{T: t}
+		xrootd.setup()
+
+		viper.Set("Cache.Concurrency", 10)
+		viper.Set("Cache.MaxBytesPerSecTotal", 1048576)
+
+		configPath, err := ConfigXrootd(ctx, false)
+		require.NoError(t, err)
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "throttle.throttle concurrency 10 data 1048576")
+	})
+
 	t.Run("TestCacheThrottlePluginDisabled", func(t *testing.T) {
 		defer viper.Reset()
 		defer server_utils.ResetOriginExports()